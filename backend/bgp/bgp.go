@@ -0,0 +1,479 @@
+// Package bgp is a lightweight path-vector layer over the routing graph. Gateways advertise IP
+// prefixes, every node acts as a speaker that selects a best route per prefix and re-advertises it
+// to its neighbors, and updates are paced by processing delay and a minimum route advertisement
+// interval (MRAI), so convergence after topology changes can be studied.
+package bgp
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+const (
+	defaultMRAIMS       = 5000
+	defaultProcessingMS = 10
+)
+
+// Advertisement originates Prefix at Gateway. LocalPref is carried network-wide, since the whole
+// constellation is treated as one routing domain; higher values are preferred.
+type Advertisement struct {
+	Gateway   string       `json:"gateway"`
+	Prefix    netip.Prefix `json:"prefix"`
+	LocalPref int          `json:"localPref,omitempty"`
+}
+
+// Config lists the advertisements and protocol timers. Zero timers select a 5 s MRAI and 10 ms of
+// processing per update.
+type Config struct {
+	Advertisements []Advertisement `json:"advertisements"`
+	MRAIMS         float64         `json:"mraiMs,omitempty"`
+	ProcessingMS   float64         `json:"processingMs,omitempty"`
+}
+
+// Validate reports malformed advertisements and negative timers.
+func (c Config) Validate() error {
+	if c.MRAIMS < 0 || c.ProcessingMS < 0 {
+		return errors.New("BGP timers cannot be negative")
+	}
+	for _, ad := range c.Advertisements {
+		if ad.Gateway == "" {
+			return errors.New("advertisement gateway cannot be empty")
+		}
+		if !ad.Prefix.IsValid() {
+			return fmt.Errorf("gateway %s advertises an invalid prefix", ad.Gateway)
+		}
+	}
+	return nil
+}
+
+// Route is a speaker's best route to a prefix. Path runs from the speaker to the originating
+// gateway, inclusive; NextHop is empty at the gateway itself. LatencyMS is as advertised: it tracks
+// changes of the speaker's own links but not of links further along an unchanged path.
+type Route struct {
+	Prefix    netip.Prefix `json:"prefix"`
+	Gateway   string       `json:"gateway"`
+	NextHop   string       `json:"nextHop,omitempty"`
+	Path      []string     `json:"path"`
+	LocalPref int          `json:"localPref"`
+	LatencyMS float64      `json:"latencyMs"`
+}
+
+// Convergence reports one run of the protocol to quiescence. TimeMS is the simulated time of the
+// last best-route change after the topology was applied.
+type Convergence struct {
+	TimeMS      float64 `json:"timeMs"`
+	Updates     int     `json:"updates"`
+	Withdrawals int     `json:"withdrawals"`
+	Routes      int     `json:"routes"`
+}
+
+type speaker struct {
+	id         string
+	neighbors  map[string]float64                 // neighbor ID to link latency
+	adjIn      map[string]map[netip.Prefix]Route  // routes learned per neighbor
+	best       map[netip.Prefix]Route             // selected route per prefix
+	advertised map[string]map[netip.Prefix]string // path key last sent per neighbor
+	pending    map[string]map[netip.Prefix]bool   // prefixes awaiting a flush per neighbor
+	scheduled  map[string]bool                    // neighbors with a flush queued
+	lastSent   map[string]float64                 // time of the last flush per neighbor
+}
+
+// Network holds every speaker's state between topology changes.
+type Network struct {
+	cfg      Config
+	speakers map[string]*speaker
+	origins  map[string][]Advertisement
+
+	now     float64
+	seq     uint64
+	events  eventQueue
+	changed float64
+	stats   Convergence
+}
+
+// NewNetwork validates cfg and returns a network with no topology yet; call Converge to apply one.
+func NewNetwork(cfg Config) (*Network, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.MRAIMS == 0 {
+		cfg.MRAIMS = defaultMRAIMS
+	}
+	if cfg.ProcessingMS == 0 {
+		cfg.ProcessingMS = defaultProcessingMS
+	}
+	n := &Network{cfg: cfg, speakers: make(map[string]*speaker), origins: make(map[string][]Advertisement)}
+	for _, ad := range cfg.Advertisements {
+		ad.Prefix = ad.Prefix.Masked()
+		n.origins[ad.Gateway] = append(n.origins[ad.Gateway], ad)
+	}
+	return n, nil
+}
+
+// Converge applies the topology of g, starting from the routes held after the previous call: lost
+// neighbors implicitly withdraw what they advertised, new neighbors receive full tables, and
+// gateways missing from g stop originating. The protocol then runs until no updates remain.
+func (n *Network) Converge(g *routing.Graph) Convergence {
+	n.now, n.changed, n.stats = 0, 0, Convergence{}
+
+	for id := range n.speakers {
+		if _, ok := g.Nodes[id]; !ok {
+			delete(n.speakers, id)
+		}
+	}
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // deterministic event order
+
+	for _, id := range ids {
+		sp, ok := n.speakers[id]
+		if !ok {
+			sp = newSpeaker(id)
+			n.speakers[id] = sp
+		}
+		neighbors := make(map[string]float64)
+		g.EdgesFrom(id, func(e routing.Edge) bool {
+			neighbors[e.To] = e.LatencyMS
+			return true
+		})
+		for nbr, old := range sp.neighbors {
+			latency, ok := neighbors[nbr]
+			if !ok {
+				delete(sp.adjIn, nbr)
+				delete(sp.advertised, nbr)
+				delete(sp.pending, nbr)
+				continue
+			}
+			for prefix, route := range sp.adjIn[nbr] {
+				route.LatencyMS += latency - old
+				sp.adjIn[nbr][prefix] = route
+			}
+		}
+		sp.neighbors = neighbors
+		// The previous run has long finished, so no MRAI is pending.
+		sp.lastSent = make(map[string]float64)
+	}
+
+	for _, id := range ids {
+		sp := n.speakers[id]
+		for _, prefix := range sortedPrefixes(n.knownPrefixes(sp)) {
+			n.reselect(sp, prefix)
+		}
+		// Neighbors that have not been sent a route (new links) get the full table.
+		for _, nbr := range sortedKeys(sp.neighbors) {
+			for _, prefix := range sortedPrefixes(sp.best) {
+				if _, sent := sp.advertised[nbr][prefix]; !sent {
+					n.markPending(sp, nbr, prefix)
+				}
+			}
+		}
+	}
+
+	for n.events.Len() > 0 {
+		evt := heap.Pop(&n.events).(event)
+		n.now = evt.at
+		switch evt.kind {
+		case flushEvent:
+			n.flush(n.speakers[evt.from], evt.to)
+		case updateEvent:
+			n.receive(evt)
+		}
+	}
+
+	n.stats.TimeMS = n.changed
+	for _, sp := range n.speakers {
+		n.stats.Routes += len(sp.best)
+	}
+	return n.stats
+}
+
+// Lookup returns the longest-prefix match for addr in node's table.
+func (n *Network) Lookup(node string, addr netip.Addr) (Route, bool) {
+	sp, ok := n.speakers[node]
+	if !ok {
+		return Route{}, false
+	}
+	var match Route
+	found := false
+	for prefix, route := range sp.best {
+		if prefix.Contains(addr) && (!found || prefix.Bits() > match.Prefix.Bits()) {
+			match, found = route, true
+		}
+	}
+	return match, found
+}
+
+// RIB lists node's best routes ordered by prefix.
+func (n *Network) RIB(node string) []Route {
+	sp, ok := n.speakers[node]
+	if !ok {
+		return nil
+	}
+	routes := make([]Route, 0, len(sp.best))
+	for _, route := range sp.best {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return comparePrefixes(routes[i].Prefix, routes[j].Prefix) < 0 })
+	return routes
+}
+
+func newSpeaker(id string) *speaker {
+	return &speaker{
+		id:         id,
+		neighbors:  make(map[string]float64),
+		adjIn:      make(map[string]map[netip.Prefix]Route),
+		best:       make(map[netip.Prefix]Route),
+		advertised: make(map[string]map[netip.Prefix]string),
+		pending:    make(map[string]map[netip.Prefix]bool),
+		scheduled:  make(map[string]bool),
+		lastSent:   make(map[string]float64),
+	}
+}
+
+func (n *Network) knownPrefixes(sp *speaker) map[netip.Prefix]bool {
+	prefixes := make(map[netip.Prefix]bool)
+	for prefix := range sp.best {
+		prefixes[prefix] = true
+	}
+	for _, routes := range sp.adjIn {
+		for prefix := range routes {
+			prefixes[prefix] = true
+		}
+	}
+	for _, ad := range n.origins[sp.id] {
+		prefixes[ad.Prefix] = true
+	}
+	return prefixes
+}
+
+// reselect recomputes sp's best route to prefix and queues updates to every neighbor if it changed.
+func (n *Network) reselect(sp *speaker, prefix netip.Prefix) {
+	var best Route
+	found := false
+	for _, ad := range n.origins[sp.id] {
+		if ad.Prefix == prefix {
+			candidate := Route{Prefix: prefix, Gateway: sp.id, Path: []string{sp.id}, LocalPref: ad.LocalPref}
+			if !found || better(candidate, best) {
+				best, found = candidate, true
+			}
+		}
+	}
+	for _, routes := range sp.adjIn {
+		if candidate, ok := routes[prefix]; ok && (!found || better(candidate, best)) {
+			best, found = candidate, true
+		}
+	}
+
+	previous, had := sp.best[prefix]
+	switch {
+	case !found && !had:
+		return
+	case !found:
+		delete(sp.best, prefix)
+	case had && pathKey(previous) == pathKey(best):
+		sp.best[prefix] = best // latency may have moved; the advertisement is unchanged
+		return
+	default:
+		sp.best[prefix] = best
+	}
+	n.changed = n.now
+	for _, nbr := range sortedKeys(sp.neighbors) {
+		n.markPending(sp, nbr, prefix)
+	}
+}
+
+// better orders candidates by higher LocalPref, fewer hops, lower latency, then gateway and next
+// hop IDs so selection is deterministic.
+func better(a, b Route) bool {
+	switch {
+	case a.LocalPref != b.LocalPref:
+		return a.LocalPref > b.LocalPref
+	case len(a.Path) != len(b.Path):
+		return len(a.Path) < len(b.Path)
+	case a.LatencyMS != b.LatencyMS:
+		return a.LatencyMS < b.LatencyMS
+	case a.Gateway != b.Gateway:
+		return a.Gateway < b.Gateway
+	default:
+		return a.NextHop < b.NextHop
+	}
+}
+
+func (n *Network) markPending(sp *speaker, nbr string, prefix netip.Prefix) {
+	if sp.pending[nbr] == nil {
+		sp.pending[nbr] = make(map[netip.Prefix]bool)
+	}
+	sp.pending[nbr][prefix] = true
+	if sp.scheduled[nbr] {
+		return
+	}
+	sp.scheduled[nbr] = true
+	at := n.now + n.cfg.ProcessingMS
+	if last, ok := sp.lastSent[nbr]; ok {
+		at = math.Max(at, last+n.cfg.MRAIMS)
+	}
+	n.schedule(event{at: at, kind: flushEvent, from: sp.id, to: nbr})
+}
+
+// flush sends nbr the current state of every pending prefix, skipping prefixes whose advertisement
+// has not changed since the last flush.
+func (n *Network) flush(sp *speaker, nbr string) {
+	if sp == nil {
+		return
+	}
+	sp.scheduled[nbr] = false
+	latency, linked := sp.neighbors[nbr]
+	pending := sp.pending[nbr]
+	delete(sp.pending, nbr)
+	if !linked || len(pending) == 0 {
+		return
+	}
+	sent := false
+	for _, prefix := range sortedPrefixes(pending) {
+		route, ok := sp.best[prefix]
+		// Never advertise a route back along its own path.
+		if ok && containsNode(route.Path, nbr) {
+			ok = false
+		}
+		key := ""
+		if ok {
+			key = pathKey(route)
+		}
+		last, advertised := sp.advertised[nbr][prefix]
+		if (ok && advertised && last == key) || (!ok && !advertised) {
+			continue
+		}
+		if sp.advertised[nbr] == nil {
+			sp.advertised[nbr] = make(map[netip.Prefix]string)
+		}
+		evt := event{at: n.now + latency + n.cfg.ProcessingMS, kind: updateEvent, from: sp.id, to: nbr, prefix: prefix}
+		if ok {
+			sp.advertised[nbr][prefix] = key
+			evt.route, evt.announce = route, true
+		} else {
+			delete(sp.advertised[nbr], prefix)
+		}
+		n.schedule(evt)
+		sent = true
+	}
+	if sent {
+		sp.lastSent[nbr] = n.now
+	}
+}
+
+func (n *Network) receive(evt event) {
+	sp, ok := n.speakers[evt.to]
+	if !ok {
+		return
+	}
+	latency, linked := sp.neighbors[evt.from]
+	if !linked {
+		return
+	}
+	if evt.announce {
+		n.stats.Updates++
+	} else {
+		n.stats.Withdrawals++
+	}
+	if sp.adjIn[evt.from] == nil {
+		sp.adjIn[evt.from] = make(map[netip.Prefix]Route)
+	}
+	if evt.announce && !containsNode(evt.route.Path, sp.id) {
+		route := evt.route
+		route.NextHop = evt.from
+		route.Path = append([]string{sp.id}, evt.route.Path...)
+		route.LatencyMS += latency
+		sp.adjIn[evt.from][evt.prefix] = route
+	} else {
+		delete(sp.adjIn[evt.from], evt.prefix)
+	}
+	n.reselect(sp, evt.prefix)
+}
+
+func sortedPrefixes[V any](m map[netip.Prefix]V) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(m))
+	for prefix := range m {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return comparePrefixes(prefixes[i], prefixes[j]) < 0 })
+	return prefixes
+}
+
+func comparePrefixes(a, b netip.Prefix) int {
+	if c := a.Addr().Compare(b.Addr()); c != 0 {
+		return c
+	}
+	return a.Bits() - b.Bits()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func pathKey(r Route) string {
+	return fmt.Sprintf("%d|%s", r.LocalPref, strings.Join(r.Path, ">"))
+}
+
+func containsNode(path []string, id string) bool {
+	for _, node := range path {
+		if node == id {
+			return true
+		}
+	}
+	return false
+}
+
+type eventKind int
+
+const (
+	flushEvent  eventKind = iota // a speaker sends pending updates to one neighbor
+	updateEvent                  // an announcement or withdrawal arrives at a neighbor
+)
+
+type event struct {
+	at       float64
+	seq      uint64
+	kind     eventKind
+	from, to string
+	prefix   netip.Prefix
+	route    Route
+	announce bool
+}
+
+type eventQueue []event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+func (n *Network) schedule(evt event) {
+	n.seq++
+	evt.seq = n.seq
+	heap.Push(&n.events, evt)
+}
@@ -0,0 +1,137 @@
+package bgp
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// graph builds an undirected graph from links given as {a, b, latencyMS}.
+func graph(t *testing.T, links ...[3]any) *routing.Graph {
+	t.Helper()
+	seen := make(map[string]bool)
+	var nodes []routing.Node
+	var edges []routing.Edge
+	for _, l := range links {
+		a, b, latency := l[0].(string), l[1].(string), float64(l[2].(int))
+		for _, id := range []string{a, b} {
+			if !seen[id] {
+				seen[id] = true
+				nodes = append(nodes, routing.Node{ID: id})
+			}
+		}
+		edges = append(edges, routing.Edge{From: a, To: b, LatencyMS: latency}, routing.Edge{From: b, To: a, LatencyMS: latency})
+	}
+	g, err := routing.NewGraph(nodes, edges)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	return g
+}
+
+func network(t *testing.T, cfg Config) *Network {
+	t.Helper()
+	n, err := NewNetwork(cfg)
+	if err != nil {
+		t.Fatalf("failed to build network: %v", err)
+	}
+	return n
+}
+
+func TestAdvertisementPropagatesHopByHop(t *testing.T) {
+	n := network(t, Config{Advertisements: []Advertisement{{Gateway: "d", Prefix: netip.MustParsePrefix("10.0.0.0/8")}}})
+	conv := n.Converge(graph(t, [3]any{"a", "b", 1}, [3]any{"b", "c", 1}, [3]any{"c", "d", 1}))
+
+	route, ok := n.Lookup("a", netip.MustParseAddr("10.2.3.4"))
+	if !ok {
+		t.Fatalf("expected a route at a")
+	}
+	if !reflect.DeepEqual(route.Path, []string{"a", "b", "c", "d"}) || route.NextHop != "b" || route.LatencyMS != 3 {
+		t.Fatalf("unexpected route %+v", route)
+	}
+	// Each hop costs 10 ms processing before sending, 1 ms on the link, and 10 ms on receipt.
+	if conv.TimeMS != 63 || conv.Updates != 3 || conv.Routes != 4 {
+		t.Fatalf("unexpected convergence %+v", conv)
+	}
+	if _, ok := n.Lookup("a", netip.MustParseAddr("192.0.2.1")); ok {
+		t.Fatalf("expected no route outside the advertised prefix")
+	}
+}
+
+func TestLongestPrefixAndLocalPrefWin(t *testing.T) {
+	n := network(t, Config{Advertisements: []Advertisement{
+		{Gateway: "near", Prefix: netip.MustParsePrefix("10.0.0.0/8")},
+		{Gateway: "far", Prefix: netip.MustParsePrefix("10.1.0.0/16")},
+		{Gateway: "near", Prefix: netip.MustParsePrefix("0.0.0.0/0")},
+		{Gateway: "far", Prefix: netip.MustParsePrefix("0.0.0.0/0"), LocalPref: 200},
+	}})
+	n.Converge(graph(t, [3]any{"a", "near", 1}, [3]any{"a", "x", 1}, [3]any{"x", "far", 1}))
+
+	if route, _ := n.Lookup("a", netip.MustParseAddr("10.1.2.3")); route.Gateway != "far" {
+		t.Fatalf("expected the /16 to win by longest match, got %+v", route)
+	}
+	if route, _ := n.Lookup("a", netip.MustParseAddr("10.9.9.9")); route.Gateway != "near" {
+		t.Fatalf("expected the /8 at near, got %+v", route)
+	}
+	if route, _ := n.Lookup("a", netip.MustParseAddr("8.8.8.8")); route.Gateway != "far" || route.LocalPref != 200 {
+		t.Fatalf("expected LocalPref to beat a shorter path, got %+v", route)
+	}
+	if rib := n.RIB("a"); len(rib) != 3 || rib[0].Prefix.String() != "0.0.0.0/0" {
+		t.Fatalf("unexpected RIB %+v", rib)
+	}
+}
+
+func TestLinkFailureReconvergesOverBackupPath(t *testing.T) {
+	n := network(t, Config{Advertisements: []Advertisement{{Gateway: "gw", Prefix: netip.MustParsePrefix("203.0.113.0/24")}}})
+	addr := netip.MustParseAddr("203.0.113.9")
+	n.Converge(graph(t, [3]any{"a", "b", 1}, [3]any{"b", "gw", 1}, [3]any{"a", "c", 1}, [3]any{"c", "e", 1}, [3]any{"e", "gw", 1}))
+	if route, _ := n.Lookup("b", addr); !reflect.DeepEqual(route.Path, []string{"b", "gw"}) {
+		t.Fatalf("unexpected initial route %+v", route)
+	}
+
+	conv := n.Converge(graph(t, [3]any{"a", "b", 1}, [3]any{"a", "c", 1}, [3]any{"c", "e", 1}, [3]any{"e", "gw", 1}))
+	route, ok := n.Lookup("b", addr)
+	if !ok || !reflect.DeepEqual(route.Path, []string{"b", "a", "c", "e", "gw"}) {
+		t.Fatalf("expected b to reroute through a, got %+v", route)
+	}
+	if conv.Withdrawals == 0 || conv.TimeMS <= 0 {
+		t.Fatalf("expected withdrawals while reconverging, got %+v", conv)
+	}
+}
+
+func TestMRAIDelaysRepeatedUpdates(t *testing.T) {
+	// The long path reaches a first over fast links, so a advertises it to z, then must wait out the
+	// MRAI before replacing it with the shorter path that arrives over the slow link.
+	n := network(t, Config{Advertisements: []Advertisement{{Gateway: "gw", Prefix: netip.MustParsePrefix("10.0.0.0/8")}}, MRAIMS: 2000})
+	conv := n.Converge(graph(t, [3]any{"gw", "p", 500}, [3]any{"p", "a", 500}, [3]any{"gw", "q", 1}, [3]any{"q", "r", 1}, [3]any{"r", "a", 1}, [3]any{"a", "z", 1}))
+
+	if route, _ := n.Lookup("z", netip.MustParseAddr("10.0.0.1")); !reflect.DeepEqual(route.Path, []string{"z", "a", "p", "gw"}) {
+		t.Fatalf("expected z to settle on the shorter path, got %+v", route)
+	}
+	if conv.TimeMS < 2000 {
+		t.Fatalf("expected the MRAI to delay convergence past 2000 ms, got %v", conv.TimeMS)
+	}
+}
+
+func TestRemovedGatewayWithdrawsEverywhere(t *testing.T) {
+	n := network(t, Config{Advertisements: []Advertisement{{Gateway: "gw", Prefix: netip.MustParsePrefix("10.0.0.0/8")}}})
+	n.Converge(graph(t, [3]any{"a", "b", 1}, [3]any{"b", "gw", 1}))
+	conv := n.Converge(graph(t, [3]any{"a", "b", 1}))
+	if conv.Routes != 0 {
+		t.Fatalf("expected every route withdrawn, got %+v", conv)
+	}
+	if _, ok := n.Lookup("a", netip.MustParseAddr("10.0.0.1")); ok {
+		t.Fatalf("expected no route once the gateway is gone")
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	if _, err := NewNetwork(Config{Advertisements: []Advertisement{{Gateway: "gw"}}}); err == nil {
+		t.Fatalf("expected an invalid prefix to be rejected")
+	}
+	if _, err := NewNetwork(Config{MRAIMS: -1}); err == nil {
+		t.Fatalf("expected negative timers to be rejected")
+	}
+}
@@ -0,0 +1,196 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// GroundStation is a catalog entry with its Earth-centered position precomputed.
+type GroundStation struct {
+	Name             string
+	LatDeg           float64
+	LonDeg           float64
+	AltitudeKm       float64
+	ElevationMaskDeg float64
+	Band             string
+	Position         visibility.Vector3
+}
+
+// Load reads a ground station catalog, choosing the parser from the file extension
+// (.csv, .geojson, or .json).
+func Load(path string) ([]GroundStation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(f)
+	case ".geojson", ".json":
+		return ParseGeoJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %q", filepath.Ext(path))
+	}
+}
+
+// ParseCSV reads a catalog with a header row. The name, lat, and lon columns are required;
+// altitude (km), mask (degrees), and band are optional. Column order is free.
+func ParseCSV(r io.Reader) ([]GroundStation, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read catalog header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "lat", "lon"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("catalog header missing %q column", required)
+		}
+	}
+
+	var stations []GroundStation
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(name string) (float64, error) {
+			raw := field(name)
+			if raw == "" {
+				return 0, nil
+			}
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: invalid %s %q", line, name, raw)
+			}
+			return v, nil
+		}
+
+		station := GroundStation{Name: field("name"), Band: field("band")}
+		if station.LatDeg, err = number("lat"); err != nil {
+			return nil, err
+		}
+		if station.LonDeg, err = number("lon"); err != nil {
+			return nil, err
+		}
+		if station.AltitudeKm, err = number("altitude"); err != nil {
+			return nil, err
+		}
+		if station.ElevationMaskDeg, err = number("mask"); err != nil {
+			return nil, err
+		}
+		if err := station.finalize(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		stations = append(stations, station)
+	}
+
+	return stations, nil
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Name string  `json:"name"`
+		Mask float64 `json:"mask"`
+		Band string  `json:"band"`
+	} `json:"properties"`
+}
+
+// ParseGeoJSON reads a FeatureCollection of Point features. Coordinates follow the GeoJSON
+// [lon, lat, altitude-in-meters] order; name, mask (degrees), and band come from properties.
+func ParseGeoJSON(r io.Reader) ([]GroundStation, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("decode geojson: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected FeatureCollection, got %q", collection.Type)
+	}
+
+	stations := make([]GroundStation, 0, len(collection.Features))
+	for i, f := range collection.Features {
+		if f.Geometry.Type != "Point" {
+			return nil, fmt.Errorf("feature %d: expected Point geometry, got %q", i, f.Geometry.Type)
+		}
+		coords := f.Geometry.Coordinates
+		if len(coords) < 2 {
+			return nil, fmt.Errorf("feature %d: point needs at least longitude and latitude", i)
+		}
+
+		station := GroundStation{
+			Name:             f.Properties.Name,
+			LonDeg:           coords[0],
+			LatDeg:           coords[1],
+			ElevationMaskDeg: f.Properties.Mask,
+			Band:             f.Properties.Band,
+		}
+		if len(coords) > 2 {
+			station.AltitudeKm = coords[2] / 1000
+		}
+		if err := station.finalize(); err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		stations = append(stations, station)
+	}
+
+	return stations, nil
+}
+
+// finalize validates the entry and derives its Earth-centered position.
+func (g *GroundStation) finalize() error {
+	if g.Name == "" {
+		return errors.New("ground station name cannot be empty")
+	}
+	// The comparisons are negated so NaN, which fails every comparison, is out of range too.
+	if !(g.LatDeg >= -90 && g.LatDeg <= 90) {
+		return fmt.Errorf("latitude %.4f out of range", g.LatDeg)
+	}
+	if !(g.LonDeg >= -180 && g.LonDeg <= 180) {
+		return fmt.Errorf("longitude %.4f out of range", g.LonDeg)
+	}
+	if math.IsNaN(g.AltitudeKm) || math.IsInf(g.AltitudeKm, 0) {
+		return fmt.Errorf("altitude %v is not a finite number", g.AltitudeKm)
+	}
+	if !(g.ElevationMaskDeg >= 0 && g.ElevationMaskDeg <= 90) {
+		return fmt.Errorf("elevation mask %.2f out of range", g.ElevationMaskDeg)
+	}
+
+	g.Position = visibility.FromGeodetic(g.LatDeg, g.LonDeg, g.AltitudeKm)
+	return nil
+}
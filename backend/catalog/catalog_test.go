@@ -0,0 +1,111 @@
+package catalog
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := `name,lat,lon,altitude,mask,band
+equator,0,0,0,10,Ka
+north-pole,90,0,1.5,,Ku
+`
+	stations, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stations) != 2 {
+		t.Fatalf("expected 2 stations, got %d", len(stations))
+	}
+
+	eq := stations[0]
+	if eq.Band != "Ka" || eq.ElevationMaskDeg != 10 {
+		t.Fatalf("unexpected metadata: %+v", eq)
+	}
+	if math.Abs(eq.Position.X-visibility.EarthRadius) > 1e-9 || math.Abs(eq.Position.Y) > 1e-9 || math.Abs(eq.Position.Z) > 1e-9 {
+		t.Fatalf("equator station should sit on +X axis, got %+v", eq.Position)
+	}
+
+	pole := stations[1]
+	if math.Abs(pole.Position.Z-(visibility.EarthRadius+1.5)) > 1e-9 {
+		t.Fatalf("polar station should sit on +Z axis above the surface, got %+v", pole.Position)
+	}
+}
+
+func TestParseCSVReportsLine(t *testing.T) {
+	input := "name,lat,lon\nok,10,10\nbad,north,10\n"
+	_, err := ParseCSV(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("expected line-numbered error, got %v", err)
+	}
+
+	if _, err := ParseCSV(strings.NewReader("name,lon\nx,1\n")); err == nil {
+		t.Fatalf("expected missing latitude column to fail")
+	}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	input := `{"type":"FeatureCollection","features":[
+		{"type":"Feature","geometry":{"type":"Point","coordinates":[90,0,2000]},"properties":{"name":"east","mask":5,"band":"S"}}
+	]}`
+	stations, err := ParseGeoJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stations) != 1 {
+		t.Fatalf("expected 1 station, got %d", len(stations))
+	}
+
+	east := stations[0]
+	if east.AltitudeKm != 2 || east.LonDeg != 90 || east.Band != "S" {
+		t.Fatalf("unexpected station: %+v", east)
+	}
+	if math.Abs(east.Position.Y-(visibility.EarthRadius+2)) > 1e-9 || math.Abs(east.Position.X) > 1e-9 {
+		t.Fatalf("station at 90E should sit on +Y axis, got %+v", east.Position)
+	}
+
+	if _, err := ParseGeoJSON(strings.NewReader(`{"type":"FeatureCollection","features":[{"geometry":{"type":"LineString","coordinates":[]}}]}`)); err == nil {
+		t.Fatalf("expected non-point geometry to fail")
+	}
+}
+
+// checkStations asserts the properties every successfully parsed catalog must have.
+func checkStations(t *testing.T, stations []GroundStation) {
+	t.Helper()
+	for _, s := range stations {
+		p := s.Position
+		if s.Name == "" || math.IsNaN(p.X+p.Y+p.Z) || math.IsInf(p.X+p.Y+p.Z, 0) {
+			t.Fatalf("parsed an invalid station: %+v", s)
+		}
+		if !(s.LatDeg >= -90 && s.LatDeg <= 90 && s.LonDeg >= -180 && s.LonDeg <= 180) {
+			t.Fatalf("parsed a station out of range: %+v", s)
+		}
+	}
+}
+
+func FuzzParseCSV(f *testing.F) {
+	f.Add("name,lat,lon,altitude,mask,band\nequator,0,0,0,10,Ka\nnorth-pole,90,0,1.5,,Ku\n")
+	f.Add("name,lat,lon\nbad,north,10\n")
+	f.Add("lon,name,lat\n\"quoted, name\",1,2\n")
+	f.Fuzz(func(t *testing.T, input string) {
+		stations, err := ParseCSV(strings.NewReader(input))
+		if err == nil {
+			checkStations(t, stations)
+		}
+	})
+}
+
+func FuzzParseGeoJSON(f *testing.F) {
+	f.Add(`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[90,0,2000]},"properties":{"name":"east","mask":5,"band":"S"}}]}`)
+	f.Add(`{"type":"FeatureCollection","features":[{"geometry":{"type":"LineString","coordinates":[]}}]}`)
+	f.Add(`{"type":"FeatureCollection","features":[{"geometry":{"type":"Point","coordinates":[1]}}]}`)
+	f.Fuzz(func(t *testing.T, input string) {
+		stations, err := ParseGeoJSON(strings.NewReader(input))
+		if err == nil {
+			checkStations(t, stations)
+		}
+	})
+}
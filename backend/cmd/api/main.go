@@ -1,14 +1,164 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"io"
 	"log"
+	"log/slog"
+	"os"
+	"strings"
 
+	_ "github.com/example/satnet/backend/coverage/blas"
 	"github.com/example/satnet/backend/internal/api"
+	"github.com/example/satnet/backend/internal/config"
+	"github.com/example/satnet/backend/internal/eventsink"
+	"github.com/example/satnet/backend/internal/mqtt"
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/internal/tracing"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/pluginhost"
+	"github.com/example/satnet/backend/shard"
+	"github.com/example/satnet/backend/simulation"
 )
 
 func main() {
-	server := api.NewServer(":8080")
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+
+	level, _ := cfg.SlogLevel()
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	var sharder simulation.Sharder
+	if len(cfg.ShardWorkers) > 0 {
+		workers := make([]shard.Worker, 0, len(cfg.ShardWorkers))
+		for _, url := range cfg.ShardWorkers {
+			workers = append(workers, shard.Remote{BaseURL: url, Token: cfg.ShardToken})
+		}
+		coordinator, err := shard.NewCoordinator(workers...)
+		if err != nil {
+			log.Fatalf("failed to configure sharding: %v", err)
+		}
+		sharder = coordinator
+	}
+
+	// Child processes outlive a log.Fatalf, which skips deferred calls, so every exit closes them.
+	var children []io.Closer
+	closeChildren := func() {
+		for i := len(children) - 1; i >= 0; i-- {
+			children[i].Close()
+		}
+		children = nil
+	}
+	defer closeChildren()
+	fatalf := func(format string, args ...any) {
+		closeChildren()
+		log.Fatalf(format, args...)
+	}
+
+	var propagators map[string]simulation.PropagatorFactory
+	if args := strings.Fields(cfg.ExternalPropagator); len(args) > 0 {
+		external, err := orbits.StartExternal(args[0], args[1:]...)
+		if err != nil {
+			log.Fatalf("failed to configure external propagator: %v", err)
+		}
+		children = append(children, external)
+		propagators = map[string]simulation.PropagatorFactory{"external": simulation.ExternalPropagator(external)}
+	}
+
+	var plugins simulation.Plugins
+	if len(cfg.Plugins) > 0 {
+		commands := make([][]string, 0, len(cfg.Plugins))
+		for _, plugin := range cfg.Plugins {
+			commands = append(commands, strings.Fields(plugin))
+		}
+		host, err := pluginhost.Load(commands)
+		if err != nil {
+			fatalf("failed to load plugins: %v", err)
+		}
+		children = append(children, host)
+		slog.Info("plugins loaded", "models", host.Names())
+		plugins = host.Plugins()
+	}
+
+	scenario := simulation.DemoConfig()
+	if cfg.ScenarioFile != "" {
+		if scenario, err = simulation.LoadScenario(cfg.ScenarioFile); err != nil {
+			fatalf("failed to load scenario: %v", err)
+		}
+	}
+	scenario.Sharder, scenario.Propagators, scenario.Plugins = sharder, propagators, plugins
+	if scenario.HistorySize == 0 {
+		scenario.HistorySize = cfg.HistorySize
+	}
+	sim, err := simulation.NewSimulator(scenario)
+	if err != nil {
+		fatalf("failed to build simulator: %v", err)
+	}
+
+	st, err := store.Open(cfg.StoreDSN)
+	if err != nil {
+		fatalf("failed to open store: %v", err)
+	}
+
+	sink, err := eventsink.Open(cfg.EventSinkDSN)
+	if err != nil {
+		fatalf("failed to configure event sink: %v", err)
+	}
+	var bridge *mqtt.Bridge
+	if cfg.MQTTBroker != "" {
+		if bridge, err = mqtt.Open(cfg.MQTTBroker); err != nil {
+			fatalf("failed to configure MQTT bridge: %v", err)
+		}
+	}
+	units, err := api.ParseUnits(cfg.Units)
+	if err != nil {
+		fatalf("failed to configure response units: %v", err)
+	}
+	var tracer *tracing.Tracer
+	if cfg.TraceEndpoint != "" {
+		if tracer, err = tracing.New(cfg.TraceEndpoint, "satnet-api", cfg.TraceSampleRatio); err != nil {
+			fatalf("failed to configure tracing: %v", err)
+		}
+	}
+
+	server := api.NewServer(api.Options{
+		Addr:             cfg.ListenAddr,
+		TLSCertFile:      cfg.TLSCertFile,
+		TLSKeyFile:       cfg.TLSKeyFile,
+		AutocertDomains:  cfg.AutocertDomains,
+		AutocertCacheDir: cfg.AutocertCacheDir,
+		H2C:              cfg.H2C,
+		Limits:           cfg.SessionLimits(),
+		MaxSessions:      cfg.MaxSessions,
+		Workers:          cfg.Workers,
+		WorkerQueue:      cfg.WorkerQueue,
+		TickInterval:     cfg.TickInterval,
+		HistorySize:      cfg.HistorySize,
+		Sharder:          sharder,
+		Propagators:      propagators,
+		Plugins:          plugins,
+		ShardWorker:      cfg.ShardWorker,
+		ShardToken:       cfg.ShardToken,
+		AdminToken:       cfg.AdminToken,
+		EventSink:        sink,
+		MQTT:             bridge,
+		TelemetryCadence: cfg.TelemetryCadence,
+		Tracer:           tracer,
+		Units:            units,
+		Demo: api.DemoOptions{
+			Enabled:            cfg.Demo,
+			SessionIdle:        cfg.DemoSessionIdle,
+			SessionsPerVisitor: cfg.DemoSessionsPerVisitor,
+			TrustProxy:         cfg.DemoTrustProxy,
+		},
+	}, sim, st)
 	if err := server.Start(); err != nil {
-		log.Fatalf("server exited: %v", err)
+		fatalf("server exited: %v", err)
 	}
 }
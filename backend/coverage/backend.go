@@ -0,0 +1,91 @@
+package coverage
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Backend applies footprint caps to grid cells, letting research-scale grids offload the
+// cells-by-footprints product to multiple cores or an accelerator. Backends are registered by name
+// and selected with GridConfig.Backend; the pure-Go loop in ApplyFootprints is the default.
+// Accelerated implementations belong behind build tags in the packages that register them, so the
+// default build stays free of cgo; package coverage/blas registers a CBLAS one under -tags blas.
+type Backend interface {
+	// Apply adds every cap whose region contains units[i] to counts[i] and raises strongest[i] to the
+	// cap's strength when larger. The three slices are parallel. A grid passes the same units slice
+	// on every call, so implementations may keep device-side copies keyed by it.
+	Apply(units []UnitVector, caps []Cap, counts []int, strongest []float64)
+}
+
+// ParallelBackend is the name of the built-in backend that splits cells across GOMAXPROCS goroutines.
+const ParallelBackend = "parallel"
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{ParallelBackend: parallelBackend{}}
+)
+
+// RegisterBackend makes b available to grids whose configuration names it. It panics if the name
+// is empty or already registered, since that is a programming error.
+func RegisterBackend(name string, b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if name == "" || b == nil {
+		panic("coverage: RegisterBackend needs a name and a backend")
+	}
+	if _, dup := backends[name]; dup {
+		panic(fmt.Sprintf("coverage: backend %q registered twice", name))
+	}
+	backends[name] = b
+}
+
+func lookupBackend(name string) (Backend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	b, ok := backends[name]
+	return b, ok
+}
+
+// applyWith runs caps through backend on column copies of the cell metrics.
+func (g *CoverageGrid) applyWith(backend Backend, caps []Cap) {
+	counts := make([]int, len(g.cells))
+	strongest := make([]float64, len(g.cells))
+	for i, cell := range g.cells {
+		counts[i], strongest[i] = cell.CoverageCount, cell.StrongestLink
+	}
+	backend.Apply(g.units, caps, counts, strongest)
+	for i := range g.cells {
+		g.cells[i].CoverageCount, g.cells[i].StrongestLink = counts[i], strongest[i]
+	}
+}
+
+type parallelBackend struct{}
+
+func (parallelBackend) Apply(units []UnitVector, caps []Cap, counts []int, strongest []float64) {
+	workers := runtime.GOMAXPROCS(0)
+	chunk := (len(units) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(units); start += chunk {
+		end := start + chunk
+		if end > len(units) {
+			end = len(units)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				u := units[i]
+				for _, c := range caps {
+					if u.Dot(c.Center) >= c.CosRadius {
+						counts[i]++
+						if c.Strength > strongest[i] {
+							strongest[i] = c.Strength
+						}
+					}
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,79 @@
+package coverage
+
+import (
+	"testing"
+)
+
+type countingBackend struct {
+	calls int
+	inner Backend
+}
+
+func (b *countingBackend) Apply(units []UnitVector, caps []Cap, counts []int, strongest []float64) {
+	b.calls++
+	b.inner.Apply(units, caps, counts, strongest)
+}
+
+func TestParallelBackendMatchesDefault(t *testing.T) {
+	footprints := randomFootprints(50, 11)
+	want, err := NewCoverageGrid(GridConfig{LatStep: 3, LonStep: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewCoverageGrid(GridConfig{LatStep: 3, LonStep: 3, Backend: ParallelBackend})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Apply twice so accumulation across calls is covered too.
+	for i := 0; i < 2; i++ {
+		want.ApplyFootprints(footprints)
+		got.ApplyFootprints(footprints)
+	}
+	for i, cell := range want.Cells() {
+		if got.cells[i] != cell {
+			t.Fatalf("cell %d = %+v, want %+v", i, got.cells[i], cell)
+		}
+	}
+}
+
+func TestRegisteredBackendIsSelectedByConfig(t *testing.T) {
+	backend := &countingBackend{inner: parallelBackend{}}
+	RegisterBackend("counting-test", backend)
+	grid, err := NewCoverageGrid(GridConfig{LatStep: 90, LonStep: 90, Backend: "counting-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid.ApplyFootprints([]Footprint{{RadiusKm: 20000, LinkStrength: 1}})
+	if backend.calls != 1 || grid.Summarize().CoveredCells == 0 {
+		t.Fatalf("expected the registered backend to cover cells, got %d calls and %+v", backend.calls, grid.Summarize())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected registering a name twice to panic")
+		}
+	}()
+	RegisterBackend("counting-test", backend)
+}
+
+func TestBackendValidation(t *testing.T) {
+	if err := (GridConfig{LatStep: 10, LonStep: 10, Backend: "no-such-backend"}).Validate(); err == nil {
+		t.Fatal("expected an unknown backend to be rejected")
+	}
+	if err := (GridConfig{LatStep: 10, LonStep: 10, Backend: ParallelBackend, Float32: true}).Validate(); err == nil {
+		t.Fatal("expected float32 grids to reject a backend")
+	}
+}
+
+func BenchmarkParallelBackend(b *testing.B) {
+	footprints := randomFootprints(100, 1)
+	grid, err := NewCoverageGrid(GridConfig{LatStep: 0.5, LonStep: 0.5, Backend: ParallelBackend})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Reset()
+		grid.ApplyFootprints(footprints)
+	}
+}
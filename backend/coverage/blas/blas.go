@@ -0,0 +1,69 @@
+//go:build cgo && blas
+
+package blas
+
+/*
+#cgo LDFLAGS: -lopenblas
+#include <cblas.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+// blockElements bounds the cosines computed per dgemm call, so a fine grid under many footprints
+// is processed in row blocks of about 32 MiB rather than one cells-by-caps matrix.
+const blockElements = 1 << 22
+
+func init() {
+	coverage.RegisterBackend(Name, backend{})
+}
+
+type backend struct{}
+
+// Apply computes the cosine between every cell and cap center as units (cells x 3) times the
+// transposed centers (3 x caps), then compares each against the cap's radius on the Go side. The
+// product may round differently from the pure-Go dot product, so a cell lying exactly on a
+// footprint edge can fall on the other side of it.
+func (backend) Apply(units []coverage.UnitVector, caps []coverage.Cap, counts []int, strongest []float64) {
+	if len(units) == 0 || len(caps) == 0 {
+		return
+	}
+	centers := make([]float64, 0, 3*len(caps))
+	for _, c := range caps {
+		centers = append(centers, c.Center[:]...)
+	}
+	rows := blockElements / len(caps)
+	if rows < 1 {
+		rows = 1
+	}
+	if rows > len(units) {
+		rows = len(units)
+	}
+	cosines := make([]float64, rows*len(caps))
+	for start := 0; start < len(units); start += rows {
+		n := len(units) - start
+		if n > rows {
+			n = rows
+		}
+		C.cblas_dgemm(C.CblasRowMajor, C.CblasNoTrans, C.CblasTrans,
+			C.int(n), C.int(len(caps)), 3,
+			1, (*C.double)(unsafe.Pointer(&units[start][0])), 3,
+			(*C.double)(unsafe.Pointer(&centers[0])), 3,
+			0, (*C.double)(unsafe.Pointer(&cosines[0])), C.int(len(caps)))
+		for i := 0; i < n; i++ {
+			cell := start + i
+			for j, cosine := range cosines[i*len(caps) : (i+1)*len(caps)] {
+				if cosine >= caps[j].CosRadius {
+					counts[cell]++
+					if caps[j].Strength > strongest[cell] {
+						strongest[cell] = caps[j].Strength
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//go:build cgo && blas
+
+package blas
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+func TestBLASBackendMatchesDefault(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	footprints := make([]coverage.Footprint, 200)
+	for i := range footprints {
+		footprints[i] = coverage.Footprint{
+			CenterLat:    rng.Float64()*180 - 90,
+			CenterLon:    rng.Float64()*360 - 180,
+			RadiusKm:     200 + rng.Float64()*3000,
+			LinkStrength: rng.Float64(),
+		}
+	}
+	want, err := coverage.NewCoverageGrid(coverage.GridConfig{LatStep: 2, LonStep: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := coverage.NewCoverageGrid(coverage.GridConfig{LatStep: 2, LonStep: 2, Backend: Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.ApplyFootprints(footprints)
+	got.ApplyFootprints(footprints)
+	mismatched := 0
+	for i, cell := range want.Cells() {
+		other := got.Cells()[i]
+		if other.CoverageCount != cell.CoverageCount || math.Abs(other.StrongestLink-cell.StrongestLink) > 1e-12 {
+			mismatched++
+		}
+	}
+	// Rounding may move a cell sitting exactly on an edge, but never more than a handful.
+	if mismatched > 2 {
+		t.Fatalf("%d cells differ from the pure-Go backend", mismatched)
+	}
+}
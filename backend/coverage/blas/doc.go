@@ -0,0 +1,9 @@
+// Package blas registers a coverage backend that evaluates footprint caps as a matrix product on a
+// CBLAS library, for research-scale grids where the cells-by-footprints product dominates a
+// recompute. It is built only with cgo and the blas build tag (go build -tags blas) and links
+// OpenBLAS by default; without the tag, importing the package registers nothing and grids naming
+// the backend fail validation.
+package blas
+
+// Name is the coverage.GridConfig.Backend value that selects this backend.
+const Name = "blas"
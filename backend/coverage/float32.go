@@ -0,0 +1,58 @@
+package coverage
+
+import "math"
+
+// unitVector32 is a point on the unit sphere in single precision.
+type unitVector32 [3]float32
+
+// cap32 is a footprint expressed as the largest squared chord between its center and a covered
+// point on the unit sphere. Comparing chords rather than angle cosines keeps single precision
+// accurate for small footprints, where cosines all round to one.
+type cap32 struct {
+	center   unitVector32
+	chordSq  float32
+	strength float64
+}
+
+// capChordSq is the squared unit-sphere chord subtended by a great-circle distance of radiusKm.
+func capChordSq(radiusKm float64) float64 {
+	angle := math.Min(radiusKm/EarthRadiusKm, math.Pi)
+	chord := 2 * math.Sin(angle/2)
+	return chord * chord
+}
+
+func singlePrecision(units []UnitVector) []unitVector32 {
+	out := make([]unitVector32, len(units))
+	for i, u := range units {
+		out[i] = unitVector32{float32(u[0]), float32(u[1]), float32(u[2])}
+	}
+	return out
+}
+
+// applyFootprints32 is ApplyFootprints in single precision, halving the memory the inner loop reads.
+func (g *CoverageGrid) applyFootprints32(footprints []Footprint) {
+	caps := make([]cap32, 0, len(footprints))
+	for _, footprint := range footprints {
+		if footprint.RadiusKm <= 0 {
+			continue
+		}
+		c := unitVectorOf(footprint.CenterLat, footprint.CenterLon)
+		caps = append(caps, cap32{
+			center:   unitVector32{float32(c[0]), float32(c[1]), float32(c[2])},
+			chordSq:  float32(capChordSq(footprint.RadiusKm)),
+			strength: footprint.LinkStrength,
+		})
+	}
+	for i := range g.cells {
+		cell, u := &g.cells[i], g.units32[i]
+		for _, c := range caps {
+			dx, dy, dz := u[0]-c.center[0], u[1]-c.center[1], u[2]-c.center[2]
+			if dx*dx+dy*dy+dz*dz <= c.chordSq {
+				cell.CoverageCount++
+				if c.strength > cell.StrongestLink {
+					cell.StrongestLink = c.strength
+				}
+			}
+		}
+	}
+}
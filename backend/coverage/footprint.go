@@ -0,0 +1,16 @@
+package coverage
+
+import "math"
+
+// FootprintRadiusForAltitude returns the ground radius, in kilometers along the surface, of the
+// region that sees a satellite at altKm above a spherical Earth at or above minElevationDeg. With
+// nadir angle η given by sin η = R cos ε / (R + h), the Earth central angle to the edge of coverage
+// is λ = 90° − ε − η and the radius is R·λ. Satellites at or below the surface cover nothing.
+func FootprintRadiusForAltitude(altKm, minElevationDeg float64) float64 {
+	if altKm <= 0 {
+		return 0
+	}
+	elevation := math.Max(0, math.Min(90, minElevationDeg)) * math.Pi / 180
+	nadir := math.Asin(EarthRadiusKm * math.Cos(elevation) / (EarthRadiusKm + altKm))
+	return EarthRadiusKm * math.Max(0, math.Pi/2-elevation-nadir)
+}
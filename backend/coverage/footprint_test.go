@@ -0,0 +1,38 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFootprintRadiusForAltitude(t *testing.T) {
+	// At zero elevation the edge of coverage is the horizon: cos λ = R / (R + h).
+	horizon := EarthRadiusKm * math.Acos(EarthRadiusKm/(EarthRadiusKm+550))
+	if got := FootprintRadiusForAltitude(550, 0); math.Abs(got-horizon) > 1e-9 {
+		t.Fatalf("horizon radius = %v, want %v", got, horizon)
+	}
+
+	// A 550 km shell with a 25° mask, as filed for Starlink, covers about 940 km around nadir.
+	if got := FootprintRadiusForAltitude(550, 25); math.Abs(got-940) > 10 {
+		t.Fatalf("radius at 550 km and 25° = %v, want about 940 km", got)
+	}
+	// GEO at 0° sees 81.3° of central angle.
+	if got := FootprintRadiusForAltitude(35786, 0) / EarthRadiusKm * 180 / math.Pi; math.Abs(got-81.3) > 0.05 {
+		t.Fatalf("GEO central angle = %v°, want 81.3°", got)
+	}
+
+	previous := math.Inf(1)
+	for mask := 0.0; mask <= 90; mask += 5 {
+		radius := FootprintRadiusForAltitude(1200, mask)
+		if radius > previous {
+			t.Fatalf("radius grew from %v to %v as the mask rose to %v°", previous, radius, mask)
+		}
+		previous = radius
+	}
+	if previous != 0 {
+		t.Fatalf("radius at a 90° mask = %v, want 0", previous)
+	}
+	if got := FootprintRadiusForAltitude(0, 10); got != 0 {
+		t.Fatalf("radius on the surface = %v, want 0", got)
+	}
+}
@@ -0,0 +1,117 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// GapRegion is a connected group of uncovered cells. Cells are connected through shared edges,
+// including across the antimeridian.
+type GapRegion struct {
+	ID      string  `json:"id"`
+	Cells   int     `json:"cells"`
+	AreaKm2 float64 `json:"areaKm2"`
+	// CentroidLat and CentroidLon locate the region's area-weighted center on the sphere.
+	CentroidLat float64 `json:"centroidLat"`
+	CentroidLon float64 `json:"centroidLon"`
+	// MinLat and MaxLat bound the region's cell centers.
+	MinLat float64 `json:"minLat"`
+	MaxLat float64 `json:"maxLat"`
+}
+
+// ClusterGaps groups the uncovered cells of a heatmap laid out on config's grid into regions,
+// largest first, labeled "gap-1", "gap-2", and so on.
+func ClusterGaps(cells []HeatmapCell, config GridConfig) ([]GapRegion, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	rows, cols := int(math.Ceil(180/config.LatStep)), int(math.Ceil(360/config.LonStep))
+	index := make(map[[2]int]int, len(cells))
+	position := make([][2]int, len(cells))
+	for i, cell := range cells {
+		rc := [2]int{
+			int(math.Round((cell.Lat + 90 - config.LatStep/2) / config.LatStep)),
+			int(math.Round((cell.Lon + 180 - config.LonStep/2) / config.LonStep)),
+		}
+		if rc[0] < 0 || rc[0] >= rows || rc[1] < 0 || rc[1] >= cols {
+			return nil, fmt.Errorf("cell at %.4f, %.4f is off the grid", cell.Lat, cell.Lon)
+		}
+		index[rc] = i
+		position[i] = rc
+	}
+	if len(index) != len(cells) {
+		return nil, errors.New("heatmap cells do not match the grid")
+	}
+
+	visited := make([]bool, len(cells))
+	var regions []GapRegion
+	var stack []int
+	for start, cell := range cells {
+		if cell.Covered || visited[start] {
+			continue
+		}
+		visited[start] = true
+		stack = append(stack[:0], start)
+		region := GapRegion{MinLat: cell.Lat, MaxLat: cell.Lat}
+		var center UnitVector
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			c := cells[i]
+			area := cellAreaKm2(c.Lat, config)
+			u := unitVectorOf(c.Lat, c.Lon)
+			for k := range center {
+				center[k] += area * u[k]
+			}
+			region.Cells++
+			region.AreaKm2 += area
+			region.MinLat, region.MaxLat = math.Min(region.MinLat, c.Lat), math.Max(region.MaxLat, c.Lat)
+
+			row, col := position[i][0], position[i][1]
+			for _, next := range [][2]int{{row - 1, col}, {row + 1, col}, {row, (col + cols - 1) % cols}, {row, (col + 1) % cols}} {
+				j, ok := index[next]
+				if ok && !visited[j] && !cells[j].Covered {
+					visited[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+		region.CentroidLat, region.CentroidLon = centroid(center, region, config)
+		regions = append(regions, region)
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool { return regions[i].AreaKm2 > regions[j].AreaKm2 })
+	for i := range regions {
+		regions[i].ID = fmt.Sprintf("gap-%d", i+1)
+	}
+	return regions, nil
+}
+
+// cellAreaKm2 is the surface area of the grid cell centered at latDeg, with its latitude band
+// clipped at the poles.
+func cellAreaKm2(latDeg float64, config GridConfig) float64 {
+	const degToRad = math.Pi / 180
+	south := math.Max(latDeg-config.LatStep/2, -90) * degToRad
+	north := math.Min(latDeg+config.LatStep/2, 90) * degToRad
+	return EarthRadiusKm * EarthRadiusKm * config.LonStep * degToRad * (math.Sin(north) - math.Sin(south))
+}
+
+// centroid converts a region's area-weighted sum of unit vectors to latitude and longitude. A region
+// ringing the globe sums to nearly nothing horizontally: one touching a pole is centered on it, and a
+// latitude band gets the middle of its latitude range at longitude 0.
+func centroid(sum UnitVector, region GapRegion, config GridConfig) (float64, float64) {
+	const radToDeg = 180 / math.Pi
+	horizontal := math.Hypot(sum[0], sum[1])
+	if horizontal >= 1e-9*region.AreaKm2 {
+		return math.Atan2(sum[2], horizontal) * radToDeg, math.Atan2(sum[1], sum[0]) * radToDeg
+	}
+	switch {
+	case region.MaxLat+config.LatStep/2 >= 90 && region.MinLat-config.LatStep/2 > -90:
+		return 90, 0
+	case region.MinLat-config.LatStep/2 <= -90 && region.MaxLat+config.LatStep/2 < 90:
+		return -90, 0
+	}
+	return (region.MinLat + region.MaxLat) / 2, 0
+}
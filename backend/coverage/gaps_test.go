@@ -0,0 +1,78 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+func gapGrid(t *testing.T, config GridConfig, footprints []Footprint) []HeatmapCell {
+	t.Helper()
+	grid, err := NewCoverageGrid(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	grid.ApplyFootprints(footprints)
+	return grid.HeatmapData()
+}
+
+func TestClusterGapsSeparatesRegions(t *testing.T) {
+	// An equatorial belt of coverage splits the globe's gaps into a northern and a southern region,
+	// the southern one larger because the belt sits north of the equator.
+	config := GridConfig{LatStep: 10, LonStep: 10}
+	var belt []Footprint
+	for lon := -175.0; lon < 180; lon += 10 {
+		belt = append(belt, Footprint{CenterLat: 5, CenterLon: lon, RadiusKm: 1500, LinkStrength: 1})
+	}
+	regions, err := ClusterGaps(gapGrid(t, config, belt), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 2 {
+		t.Fatalf("expected northern and southern gaps, got %+v", regions)
+	}
+	south, north := regions[0], regions[1]
+	if south.ID != "gap-1" || south.CentroidLat > -45 || north.CentroidLat < 45 || south.AreaKm2 <= north.AreaKm2 {
+		t.Fatalf("expected the larger southern gap first, got %+v", regions)
+	}
+	sphere := 4 * math.Pi * EarthRadiusKm * EarthRadiusKm
+	if total := south.AreaKm2 + north.AreaKm2; total >= sphere || total < sphere/2 {
+		t.Fatalf("gap area %v should be most of the sphere %v", total, sphere)
+	}
+}
+
+func TestClusterGapsJoinsAcrossAntimeridian(t *testing.T) {
+	config := GridConfig{LatStep: 10, LonStep: 10}
+	cells := gapGrid(t, config, []Footprint{{CenterLat: 0, CenterLon: 0, RadiusKm: 20000, LinkStrength: 1}})
+	// Open a gap straddling ±180°: the cells at -175° and 175° on the equator band.
+	open := 0
+	for i := range cells {
+		if math.Abs(cells[i].Lat-5) < 1e-9 && math.Abs(math.Abs(cells[i].Lon)-175) < 1e-9 {
+			cells[i].Covered = false
+			open++
+		}
+	}
+	regions, err := ClusterGaps(cells, config)
+	if err != nil || open != 2 {
+		t.Fatal(err, open)
+	}
+	if len(regions) != 1 || regions[0].Cells != 2 || math.Abs(math.Abs(regions[0].CentroidLon)-180) > 1e-6 {
+		t.Fatalf("expected one gap centered on the antimeridian, got %+v", regions)
+	}
+}
+
+func TestClusterGapsCentersPolarCapOnPole(t *testing.T) {
+	config := GridConfig{LatStep: 10, LonStep: 30}
+	cells := gapGrid(t, config, []Footprint{{CenterLat: -90, RadiusKm: 16000, LinkStrength: 1}})
+	regions, err := ClusterGaps(cells, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 1 || regions[0].CentroidLat != 90 || regions[0].MaxLat != 85 {
+		t.Fatalf("expected one northern cap gap, got %+v", regions)
+	}
+	// Whole latitude bands from the gap's southern edge to the pole.
+	want := 2 * math.Pi * EarthRadiusKm * EarthRadiusKm * (1 - math.Sin((regions[0].MinLat-5)*math.Pi/180))
+	if math.Abs(regions[0].AreaKm2-want) > 1e-6*want {
+		t.Fatalf("expected the cap's area %v, got %v", want, regions[0].AreaKm2)
+	}
+}
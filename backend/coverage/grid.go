@@ -2,35 +2,100 @@ package coverage
 
 import (
 	"errors"
+	"fmt"
 	"math"
+	"sync"
+	"unsafe"
 )
 
 // EarthRadiusKm is the mean Earth radius in kilometers.
 const EarthRadiusKm = 6371.0
 
+// MinStep is the finest grid or region step in degrees, about 111 m at the equator. Finer steps
+// would need more cells than any session can hold, and their counts overflow before limits apply.
+const MinStep = 0.001
+
 // GridConfig controls the sampling resolution for coverage aggregation.
 type GridConfig struct {
-	LatStep float64 // degrees between latitude samples
-	LonStep float64 // degrees between longitude samples
+	LatStep float64 `json:"latStep"` // degrees between latitude samples
+	LonStep float64 `json:"lonStep"` // degrees between longitude samples
+	// Float32 stores cell unit vectors in single precision, halving their memory at the cost of
+	// about a meter of accuracy at footprint edges. It is no faster than the default float64 cap test
+	// without SIMD; see BenchmarkApplyFootprints.
+	Float32 bool `json:"float32,omitempty"`
+	// Backend names the registered Backend that applies footprints; empty selects the pure-Go loop.
+	// It cannot be combined with Float32.
+	Backend string `json:"backend,omitempty"`
+	// AreaWeighted weights each cell's share of CoveragePercent by its area rather than counting
+	// cells equally. Cells narrow towards the poles, so equal counting overstates polar coverage.
+	AreaWeighted bool `json:"areaWeighted,omitempty"`
+}
+
+// CellWeight is the share of coverage a cell centered at latDeg carries: 1 for every cell, or,
+// with AreaWeighted, the cosine of its latitude. A latitude band's area is proportional to the
+// cosine of its central latitude, so the weights are exact for the grid's bands.
+func (c GridConfig) CellWeight(latDeg float64) float64 {
+	if !c.AreaWeighted {
+		return 1
+	}
+	return math.Max(math.Cos(latDeg*math.Pi/180), 0)
 }
 
 // Validate ensures the configuration is usable for generating a grid.
 func (c GridConfig) Validate() error {
-	if c.LatStep <= 0 || c.LonStep <= 0 {
+	if !(c.LatStep > 0 && c.LonStep > 0) {
 		return errors.New("grid steps must be positive")
 	}
+	if c.LatStep < MinStep || c.LonStep < MinStep {
+		return fmt.Errorf("grid steps must be at least %g degrees", MinStep)
+	}
 	if c.LatStep > 180 || c.LonStep > 360 {
 		return errors.New("grid steps are too large to tile the globe")
 	}
+	if c.Backend != "" {
+		if _, ok := lookupBackend(c.Backend); !ok {
+			return fmt.Errorf("unknown coverage backend %q", c.Backend)
+		}
+		if c.Float32 {
+			return errors.New("float32 grids use the built-in coverage path")
+		}
+	}
 	return nil
 }
 
+// CellVectorBytes is the memory each cell's precomputed unit vector takes under the configuration.
+func (c GridConfig) CellVectorBytes() int {
+	if c.Float32 {
+		return int(unsafe.Sizeof(unitVector32{}))
+	}
+	return int(unsafe.Sizeof(UnitVector{}))
+}
+
 // Footprint represents the portion of Earth a satellite can service at an instant.
 type Footprint struct {
-	CenterLat    float64 // degrees
-	CenterLon    float64 // degrees
-	RadiusKm     float64 // kilometers
-	LinkStrength float64 // arbitrary unit; larger indicates better link margin
+	CenterLat    float64 `json:"centerLat"`    // degrees
+	CenterLon    float64 `json:"centerLon"`    // degrees
+	RadiusKm     float64 `json:"radiusKm"`     // kilometers
+	LinkStrength float64 `json:"linkStrength"` // arbitrary unit; larger indicates better link margin
+	// Auto asks the owner of the footprint to derive the center and radius from the satellite's
+	// position and elevation mask with FootprintRadiusForAltitude instead of the fields above.
+	Auto bool `json:"auto,omitempty"`
+
+	// Shape selects a circle (the default, sized by RadiusKm), an ellipse or a polygon.
+	Shape          string   `json:"shape,omitempty"`
+	SemiMajorKm    float64  `json:"semiMajorKm,omitempty"`    // ellipse, around the center
+	SemiMinorKm    float64  `json:"semiMinorKm,omitempty"`    // ellipse
+	OrientationDeg float64  `json:"orientationDeg,omitempty"` // ellipse major axis, clockwise from north
+	Vertices       []Vertex `json:"vertices,omitempty"`       // polygon corners in order; the center is unused
+
+	// BandwidthMHz is the spectrum the beam transmits, on frequency color Color of a reuse plan.
+	// Color 0 is spectrum no other beam reuses. Beams without bandwidth add nothing to the cells'
+	// usable bandwidth.
+	BandwidthMHz float64 `json:"bandwidthMHz,omitempty"`
+	Color        int     `json:"color,omitempty"`
+
+	// Uncertainty, when set, bounds the radius and link strength for CoverageBounds.
+	Uncertainty *FootprintUncertainty `json:"uncertainty,omitempty"`
 }
 
 // Cell captures aggregated coverage metrics for a single grid point.
@@ -39,6 +104,9 @@ type Cell struct {
 	Lon           float64 // degrees
 	CoverageCount int
 	StrongestLink float64
+	// BandwidthMHz is the widest spectrum a terminal in the cell can use, after co-channel
+	// interference between beams reusing a color over the cell.
+	BandwidthMHz float64
 }
 
 // Covered reports whether the cell is serviced by at least one footprint.
@@ -48,8 +116,12 @@ func (c Cell) Covered() bool {
 
 // CoverageGrid holds the generated cells and supports aggregation of footprints.
 type CoverageGrid struct {
-	Config GridConfig
-	cells  []Cell
+	Config  GridConfig
+	cells   []Cell
+	units   []UnitVector   // cell centers on the unit sphere, parallel to cells, unless Config.Float32
+	units32 []unitVector32 // single-precision cell centers when Config.Float32 is set
+	// channels holds the frequency colors in use over cells touched by beams with bandwidth.
+	channels map[int]*cellChannels
 }
 
 // NewCoverageGrid builds a globe-spanning grid with the provided resolution.
@@ -59,73 +131,150 @@ func NewCoverageGrid(config GridConfig) (*CoverageGrid, error) {
 		return nil, err
 	}
 
-	var cells []Cell
-	for lat := -90.0 + config.LatStep/2; lat < 90.0; lat += config.LatStep {
-		for lon := -180.0 + config.LonStep/2; lon < 180.0; lon += config.LonStep {
+	return newGrid(config, -90, 90, -180, 180, int(math.Ceil(180/config.LatStep))*int(math.Ceil(360/config.LonStep))), nil
+}
+
+// newGrid builds the cells of a validated configuration over a latitude/longitude box.
+func newGrid(config GridConfig, minLat, maxLat, minLon, maxLon float64, capacity int) *CoverageGrid {
+	cells := make([]Cell, 0, capacity)
+	for lat := minLat + config.LatStep/2; lat < maxLat; lat += config.LatStep {
+		for lon := minLon + config.LonStep/2; lon < maxLon; lon += config.LonStep {
 			cells = append(cells, Cell{Lat: lat, Lon: lon})
 		}
 	}
 
-	return &CoverageGrid{Config: config, cells: cells}, nil
+	grid := &CoverageGrid{Config: config, cells: cells, units: make([]UnitVector, len(cells)), channels: make(map[int]*cellChannels)}
+	for i, cell := range cells {
+		grid.units[i] = unitVectorOf(cell.Lat, cell.Lon)
+	}
+	if config.Float32 {
+		grid.units32, grid.units = singlePrecision(grid.units), nil
+	}
+	return grid
 }
 
 // ApplyFootprints increments coverage metrics for cells inside the provided footprints.
+// A cell is inside a footprint when the dot product of their unit vectors is at least the cosine of
+// the footprint's angular radius, so the inner loop is trigonometry-free and vectorizable.
+// Grids configured with Float32 use the single-precision fast path, and grids naming a Backend
+// hand the caps to it. Elliptical and polygonal footprints take a separate double-precision pass
+// after the caps in every mode, as does the spectrum of footprints carrying bandwidth.
 func (g *CoverageGrid) ApplyFootprints(footprints []Footprint) {
+	g.applyChannels(footprints)
+	footprints, shapes := splitShapes(footprints)
+	defer g.applyShapes(shapes)
+	if g.units32 != nil {
+		g.applyFootprints32(footprints)
+		return
+	}
+	caps := make([]Cap, 0, len(footprints))
+	for _, footprint := range footprints {
+		if footprint.RadiusKm <= 0 {
+			continue
+		}
+		caps = append(caps, Cap{
+			Center:    unitVectorOf(footprint.CenterLat, footprint.CenterLon),
+			CosRadius: math.Cos(math.Min(footprint.RadiusKm/EarthRadiusKm, math.Pi)),
+			Strength:  footprint.LinkStrength,
+		})
+	}
+	if backend, ok := lookupBackend(g.Config.Backend); ok {
+		g.applyWith(backend, caps)
+		return
+	}
 	for i := range g.cells {
-		cell := &g.cells[i]
-		for _, footprint := range footprints {
-			if footprint.RadiusKm <= 0 {
-				continue
-			}
-			if pointInsideFootprint(cell.Lat, cell.Lon, footprint) {
+		cell, u := &g.cells[i], g.units[i]
+		for _, c := range caps {
+			if u.Dot(c.Center) >= c.CosRadius {
 				cell.CoverageCount++
-				if footprint.LinkStrength > cell.StrongestLink {
-					cell.StrongestLink = footprint.LinkStrength
+				if c.Strength > cell.StrongestLink {
+					cell.StrongestLink = c.Strength
 				}
 			}
 		}
 	}
 }
 
+// UnitVector is a point on the unit sphere in an Earth-centered frame.
+type UnitVector [3]float64
+
+// Dot returns the cosine of the angle between u and v.
+func (u UnitVector) Dot(v UnitVector) float64 {
+	return u[0]*v[0] + u[1]*v[1] + u[2]*v[2]
+}
+
+func unitVectorOf(latDeg, lonDeg float64) UnitVector {
+	const degToRad = math.Pi / 180
+	lat, lon := latDeg*degToRad, lonDeg*degToRad
+	return UnitVector{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+// Cap is a footprint as a spherical cap: it covers the cells whose unit vectors have a dot product
+// of at least CosRadius with Center.
+type Cap struct {
+	Center    UnitVector
+	CosRadius float64
+	Strength  float64
+}
+
 // Summary captures high-level visibility statistics for the grid.
 type Summary struct {
-	TotalCells       int
-	CoveredCells     int
-	CoveragePercent  float64
-	UncoveredSamples []GapSample
+	TotalCells      int     `json:"totalCells"`
+	CoveredCells    int     `json:"coveredCells"`
+	CoveragePercent float64 `json:"coveragePercent"`
+	// AreaWeighted reports that CoveragePercent weights cells by area; see GridConfig.AreaWeighted.
+	AreaWeighted     bool        `json:"areaWeighted,omitempty"`
+	UncoveredSamples []GapSample `json:"uncoveredSamples,omitempty"`
+	// MeanBandwidthMHz averages the usable bandwidth over covered cells.
+	MeanBandwidthMHz float64 `json:"meanBandwidthMHz,omitempty"`
+	// Regions summarizes the fine regional grids by region name, without their uncovered samples.
+	Regions map[string]Summary `json:"regions,omitempty"`
+	// Bounds brackets the statistics under footprint uncertainty, when any footprint has some.
+	Bounds *CoverageBounds `json:"bounds,omitempty"`
+	// Expected weighs coverage by satellite reliability, when any satellite may fail.
+	Expected *ExpectedCoverage `json:"expected,omitempty"`
 }
 
 // GapSample represents a gap in coverage suitable for surfacing on a heatmap.
 type GapSample struct {
-	Lat float64
-	Lon float64
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
 }
 
 // Summarize returns coverage statistics and gap locations.
 func (g *CoverageGrid) Summarize() Summary {
 	var covered int
+	var bandwidth, coveredWeight, totalWeight float64
 	var gaps []GapSample
 
 	for _, cell := range g.cells {
+		weight := g.Config.CellWeight(cell.Lat)
+		totalWeight += weight
 		if cell.Covered() {
 			covered++
+			coveredWeight += weight
+			bandwidth += cell.BandwidthMHz
 		} else {
 			gaps = append(gaps, GapSample{Lat: cell.Lat, Lon: cell.Lon})
 		}
 	}
 
-	total := len(g.cells)
 	percent := 0.0
-	if total > 0 {
-		percent = (float64(covered) / float64(total)) * 100.0
+	if totalWeight > 0 {
+		percent = (coveredWeight / totalWeight) * 100.0
 	}
 
-	return Summary{
-		TotalCells:       total,
+	summary := Summary{
+		TotalCells:       len(g.cells),
 		CoveredCells:     covered,
 		CoveragePercent:  percent,
+		AreaWeighted:     g.Config.AreaWeighted,
 		UncoveredSamples: gaps,
 	}
+	if covered > 0 {
+		summary.MeanBandwidthMHz = bandwidth / float64(covered)
+	}
+	return summary
 }
 
 // HeatmapCell is a frontend-friendly payload describing a cell's coverage strength.
@@ -135,48 +284,132 @@ type HeatmapCell struct {
 	Covered  bool    `json:"covered"`
 	Count    int     `json:"count"`
 	Strength float64 `json:"strength"`
+	// BandwidthMHz is the cell's usable bandwidth, present when beams carry a frequency plan.
+	BandwidthMHz float64 `json:"bandwidthMHz,omitempty"`
 }
 
 // HeatmapData exports coverage information formatted for the UI heatmap.
 func (g *CoverageGrid) HeatmapData() []HeatmapCell {
 	heatmap := make([]HeatmapCell, 0, len(g.cells))
+	g.StreamHeatmap(func(cell HeatmapCell) bool {
+		heatmap = append(heatmap, cell)
+		return true
+	})
+	return heatmap
+}
+
+// StreamHeatmap calls yield with each cell's heatmap entry, in grid order, until yield returns
+// false. Unlike HeatmapData it allocates nothing, so callers can encode large grids incrementally.
+func (g *CoverageGrid) StreamHeatmap(yield func(HeatmapCell) bool) {
 	for _, cell := range g.cells {
-		heatmap = append(heatmap, HeatmapCell{
-			Lat:      cell.Lat,
-			Lon:      cell.Lon,
-			Covered:  cell.Covered(),
-			Count:    cell.CoverageCount,
-			Strength: cell.StrongestLink,
-		})
+		if !yield(HeatmapCell{
+			Lat:          cell.Lat,
+			Lon:          cell.Lon,
+			Covered:      cell.Covered(),
+			Count:        cell.CoverageCount,
+			Strength:     cell.StrongestLink,
+			BandwidthMHz: cell.BandwidthMHz,
+		}) {
+			return
+		}
 	}
-	return heatmap
 }
 
-func pointInsideFootprint(lat, lon float64, footprint Footprint) bool {
-	distance := haversineDistanceKm(lat, lon, footprint.CenterLat, footprint.CenterLon)
-	return distance <= footprint.RadiusKm
+// Cells exposes a copy of the grid cells to callers that need to inspect raw results.
+func (g *CoverageGrid) Cells() []Cell {
+	out := make([]Cell, len(g.cells))
+	copy(out, g.cells)
+	return out
 }
 
-func haversineDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
-	const degToRad = math.Pi / 180
-	dLat := (lat2 - lat1) * degToRad
-	dLon := (lon2 - lon1) * degToRad
+// CellCoverage is a sparse coverage contribution for the cell at Index. Grids built from
+// disjoint footprint sets exchange these to be combined with Merge.
+type CellCoverage struct {
+	Index    int       `json:"i"`
+	Count    int       `json:"n"`
+	Strength float64   `json:"s"`
+	Channels []Channel `json:"c,omitempty"`
+}
 
-	lat1Rad := lat1 * degToRad
-	lat2Rad := lat2 * degToRad
+// Contributions lists the covered cells of the grid and those carrying spectrum.
+func (g *CoverageGrid) Contributions() []CellCoverage {
+	var out []CellCoverage
+	for i, cell := range g.cells {
+		channels := g.cellChannelList(i)
+		if cell.Covered() || channels != nil {
+			out = append(out, CellCoverage{Index: i, Count: cell.CoverageCount, Strength: cell.StrongestLink, Channels: channels})
+		}
+	}
+	return out
+}
 
-	sinLat := math.Sin(dLat / 2)
-	sinLon := math.Sin(dLon / 2)
+// Merge folds contributions from a grid with the same configuration into this one, summing
+// coverage counts and keeping the strongest link, exactly as if the footprints were applied here.
+// Channels are combined too, so beams reusing a color on different grids still interfere.
+func (g *CoverageGrid) Merge(contributions []CellCoverage) error {
+	for _, c := range contributions {
+		if c.Index < 0 || c.Index >= len(g.cells) {
+			return fmt.Errorf("coverage contribution for cell %d is outside the %d-cell grid", c.Index, len(g.cells))
+		}
+		cell := &g.cells[c.Index]
+		cell.CoverageCount += c.Count
+		if c.Strength > cell.StrongestLink {
+			cell.StrongestLink = c.Strength
+		}
+		for _, ch := range c.Channels {
+			if ch.Color < 0 || ch.Color > MaxReuseColors {
+				return fmt.Errorf("coverage contribution for cell %d uses color %d", c.Index, ch.Color)
+			}
+			g.addChannel(c.Index, ch)
+		}
+	}
+	return nil
+}
 
-	a := sinLat*sinLat + sinLon*sinLon*math.Cos(lat1Rad)*math.Cos(lat2Rad)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+// Reset clears coverage counts, link strengths and spectrum so the grid can be reused for another step.
+func (g *CoverageGrid) Reset() {
+	for i := range g.cells {
+		g.cells[i].CoverageCount = 0
+		g.cells[i].StrongestLink = 0
+		g.cells[i].BandwidthMHz = 0
+	}
+	clear(g.channels)
+}
+
+var (
+	gridPoolsMu sync.Mutex
+	gridPools   = make(map[GridConfig]*sync.Pool)
+)
+
+// AcquireGrid returns an empty grid for config, reusing one previously passed to ReleaseGrid
+// when possible. Tick-driven recomputes use it to avoid allocating a full grid every step.
+func AcquireGrid(config GridConfig) (*CoverageGrid, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if g, ok := gridPool(config).Get().(*CoverageGrid); ok {
+		g.Reset()
+		return g, nil
+	}
+	return NewCoverageGrid(config)
+}
 
-	return EarthRadiusKm * c
+// ReleaseGrid makes g available to AcquireGrid. Callers must not use g, or slices returned by
+// Cells, afterwards; HeatmapData and Summarize results are copies and remain valid.
+func ReleaseGrid(g *CoverageGrid) {
+	if g == nil {
+		return
+	}
+	gridPool(g.Config).Put(g)
 }
 
-// Cells exposes a copy of the grid cells to callers that need to inspect raw results.
-func (g *CoverageGrid) Cells() []Cell {
-	out := make([]Cell, len(g.cells))
-	copy(out, g.cells)
-	return out
+func gridPool(config GridConfig) *sync.Pool {
+	gridPoolsMu.Lock()
+	defer gridPoolsMu.Unlock()
+	pool, ok := gridPools[config]
+	if !ok {
+		pool = &sync.Pool{}
+		gridPools[config] = pool
+	}
+	return pool
 }
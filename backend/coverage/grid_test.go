@@ -21,6 +21,12 @@ func TestNewCoverageGrid(t *testing.T) {
 	if math.Abs(first.Lat-(-80)) > 1e-9 || math.Abs(first.Lon-(-160)) > 1e-9 {
 		t.Fatalf("unexpected first cell center: %+v", first)
 	}
+
+	for _, config := range []GridConfig{{LatStep: 1e-300, LonStep: 1}, {LatStep: 1, LonStep: MinStep / 2}, {LatStep: math.NaN(), LonStep: 1}} {
+		if _, err := NewCoverageGrid(config); err == nil {
+			t.Fatalf("expected steps %v by %v to be rejected", config.LatStep, config.LonStep)
+		}
+	}
 }
 
 func TestApplyFootprintsAndSummarize(t *testing.T) {
@@ -76,6 +82,33 @@ func TestApplyFootprintsAndSummarize(t *testing.T) {
 	}
 }
 
+func TestAreaWeightedCoverageMatchesCapArea(t *testing.T) {
+	// A 20° cap over the North Pole covers (1 - cos 20°) / 2 of the sphere, about 3%, but 10 of the
+	// 90 latitude bands, so counting cells equally reports over 11%.
+	const capDeg = 20
+	polar := []Footprint{{CenterLat: 90, RadiusKm: capDeg * math.Pi / 180 * 6371, LinkStrength: 1}}
+	want := (1 - math.Cos(capDeg*math.Pi/180)) / 2 * 100
+
+	percent := func(areaWeighted bool) Summary {
+		grid, err := NewCoverageGrid(GridConfig{LatStep: 2, LonStep: 2, AreaWeighted: areaWeighted})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		grid.ApplyFootprints(polar)
+		return grid.Summarize()
+	}
+	counted, weighted := percent(false), percent(true)
+	if math.Abs(weighted.CoveragePercent-want) > 0.2 || !weighted.AreaWeighted {
+		t.Fatalf("expected area-weighted coverage near %.2f%%, got %+v", want, weighted.CoveragePercent)
+	}
+	if counted.CoveragePercent < 3*want || counted.AreaWeighted {
+		t.Fatalf("cell counting should keep the old, overstated figure, got %.2f%%", counted.CoveragePercent)
+	}
+	if counted.CoveredCells != weighted.CoveredCells {
+		t.Fatalf("weighting should not change cell counts: %d vs %d", counted.CoveredCells, weighted.CoveredCells)
+	}
+}
+
 func TestHeatmapData(t *testing.T) {
 	grid, err := NewCoverageGrid(GridConfig{LatStep: 30, LonStep: 60})
 	if err != nil {
@@ -105,3 +138,175 @@ func TestHeatmapData(t *testing.T) {
 		t.Fatalf("unexpected heatmap metrics: %+v", covered)
 	}
 }
+
+func TestStreamHeatmapStopsEarly(t *testing.T) {
+	grid, err := NewCoverageGrid(GridConfig{LatStep: 30, LonStep: 60})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := grid.HeatmapData()
+	var got []HeatmapCell
+	grid.StreamHeatmap(func(cell HeatmapCell) bool {
+		got = append(got, cell)
+		return len(got) < 5
+	})
+	if len(got) != 5 {
+		t.Fatalf("expected streaming to stop after 5 cells, got %d", len(got))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("cell %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMergeMatchesApplyingAllFootprints(t *testing.T) {
+	config := GridConfig{LatStep: 10, LonStep: 10}
+	footprints := []Footprint{
+		{CenterLat: 0, CenterLon: 0, RadiusKm: 2000, LinkStrength: 5},
+		{CenterLat: 10, CenterLon: 5, RadiusKm: 2500, LinkStrength: 9},
+		{CenterLat: -40, CenterLon: 120, RadiusKm: 1500, LinkStrength: 3},
+	}
+
+	whole, _ := NewCoverageGrid(config)
+	whole.ApplyFootprints(footprints)
+
+	merged, _ := NewCoverageGrid(config)
+	for _, fp := range footprints {
+		part, _ := NewCoverageGrid(config)
+		part.ApplyFootprints([]Footprint{fp})
+		if err := merged.Merge(part.Contributions()); err != nil {
+			t.Fatalf("merge failed: %v", err)
+		}
+	}
+
+	want, got := whole.Cells(), merged.Cells()
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("cell %d differs: want %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	if err := merged.Merge([]CellCoverage{{Index: len(want)}}); err == nil {
+		t.Fatalf("expected out-of-range contribution to be rejected")
+	}
+}
+
+func TestAcquireGridReturnsClearedGrid(t *testing.T) {
+	config := GridConfig{LatStep: 30, LonStep: 30}
+	grid, err := AcquireGrid(config)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	grid.ApplyFootprints([]Footprint{{CenterLat: 0, CenterLon: 0, RadiusKm: 5000, LinkStrength: 3}})
+	if grid.Summarize().CoveredCells == 0 {
+		t.Fatalf("expected the footprint to cover cells")
+	}
+	ReleaseGrid(grid)
+
+	// sync.Pool may or may not hand the same grid back; either way it must be empty.
+	reused, err := AcquireGrid(config)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	if covered := reused.Summarize().CoveredCells; covered != 0 {
+		t.Fatalf("expected a cleared grid, got %d covered cells", covered)
+	}
+	if len(reused.Cells()) != 72 {
+		t.Fatalf("expected 72 cells, got %d", len(reused.Cells()))
+	}
+
+	if _, err := AcquireGrid(GridConfig{}); err == nil {
+		t.Fatalf("expected invalid configuration to be rejected")
+	}
+}
+
+// haversineDistanceKm is the reference great-circle distance that cap tests must agree with.
+func haversineDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const degToRad = math.Pi / 180
+	dLat := (lat2 - lat1) * degToRad
+	dLon := (lon2 - lon1) * degToRad
+	sinLat := math.Sin(dLat / 2)
+	sinLon := math.Sin(dLon / 2)
+	a := sinLat*sinLat + sinLon*sinLon*math.Cos(lat1*degToRad)*math.Cos(lat2*degToRad)
+	return EarthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// applyHaversine is ApplyFootprints computed with the haversine formula per cell and footprint.
+func applyHaversine(g *CoverageGrid, footprints []Footprint) {
+	for i := range g.cells {
+		cell := &g.cells[i]
+		for _, fp := range footprints {
+			if fp.RadiusKm > 0 && haversineDistanceKm(cell.Lat, cell.Lon, fp.CenterLat, fp.CenterLon) <= fp.RadiusKm {
+				cell.CoverageCount++
+				if fp.LinkStrength > cell.StrongestLink {
+					cell.StrongestLink = fp.LinkStrength
+				}
+			}
+		}
+	}
+}
+
+func TestCapTestMatchesHaversine(t *testing.T) {
+	footprints := append(randomFootprints(200, 5),
+		Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 1, LinkStrength: 9},
+		Footprint{CenterLat: -89.9, CenterLon: 170, RadiusKm: 30000, LinkStrength: 2},
+	)
+	reference, err := NewCoverageGrid(GridConfig{LatStep: 2, LonStep: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyHaversine(reference, footprints)
+	grid, err := NewCoverageGrid(GridConfig{LatStep: 2, LonStep: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid.ApplyFootprints(footprints)
+	// Cosines near one resolve angles to about 1e-8 rad, well under a meter on the ground.
+	assertMatchesAwayFromEdges(t, grid, reference, footprints, 0.001)
+}
+
+// assertMatchesAwayFromEdges fails for cells whose coverage differs from reference unless they lie
+// within toleranceKm of a footprint edge, and returns how many differ.
+func assertMatchesAwayFromEdges(tb testing.TB, grid, reference *CoverageGrid, footprints []Footprint, toleranceKm float64) int {
+	mismatched := 0
+	for i, want := range reference.cells {
+		got := grid.cells[i]
+		if got.CoverageCount == want.CoverageCount && got.StrongestLink == want.StrongestLink {
+			continue
+		}
+		mismatched++
+		nearEdge := false
+		for _, fp := range footprints {
+			if math.Abs(haversineDistanceKm(want.Lat, want.Lon, fp.CenterLat, fp.CenterLon)-fp.RadiusKm) < toleranceKm {
+				nearEdge = true
+			}
+		}
+		if !nearEdge {
+			tb.Fatalf("cell %+v differs from %+v away from any footprint edge", got, want)
+		}
+	}
+	return mismatched
+}
+
+func BenchmarkNewCoverageGrid(b *testing.B) {
+	config := GridConfig{LatStep: 0.5, LonStep: 0.5}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewCoverageGrid(config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAcquireGrid(b *testing.B) {
+	config := GridConfig{LatStep: 0.5, LonStep: 0.5}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		grid, err := AcquireGrid(config)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ReleaseGrid(grid)
+	}
+}
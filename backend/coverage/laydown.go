@@ -0,0 +1,247 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MaxLaydownBeams bounds the beams one laydown may generate, so a tiny beamwidth over a large area
+// cannot produce an unbounded footprint list.
+const MaxLaydownBeams = 10000
+
+// laydownCandidates bounds the grid positions a laydown examines before giving up on an area that is
+// mostly empty space within its bounding cap.
+const laydownCandidates = 100 * MaxLaydownBeams
+
+// Laydown describes a spot-beam payload to lay out over a service area.
+type Laydown struct {
+	SubLat     float64 `json:"subLat"`     // sub-satellite point, degrees
+	SubLon     float64 `json:"subLon"`     // degrees
+	AltitudeKm float64 `json:"altitudeKm"` // satellite altitude above the spherical Earth
+	// BeamwidthDeg is each beam's full beamwidth as seen from the satellite.
+	BeamwidthDeg float64   `json:"beamwidthDeg"`
+	Area         Footprint `json:"area"` // region to cover, of any shape
+	LinkStrength float64   `json:"linkStrength"`
+	// ReuseColors selects a frequency reuse plan of 1, 3, 4 or 7 colors, and BandwidthMHz the
+	// spectrum it divides evenly between them. Zero leaves the beams without a plan.
+	ReuseColors  int     `json:"reuseColors,omitempty"`
+	BandwidthMHz float64 `json:"bandwidthMHz,omitempty"`
+}
+
+// SpotBeamLaydown lays a hexagonal grid of spot beams over the laydown's area, the way
+// high-throughput satellites tile their service regions. Beams are spaced evenly in the satellite's
+// view, √3 half-beamwidths apart so neighboring beams overlap without gaps, and the grid is steered
+// so that one beam points at the center of the area. A beam is kept when its boresight or any corner
+// of its hexagonal cell lands inside the area.
+//
+// Each beam's ground footprint is an ellipse: beams pointed away from nadir meet the Earth at a
+// slant and stretch along the direction away from the sub-satellite point. With a reuse plan, beams
+// are colored in the lattice's repeating pattern so that adjacent beams never share a color.
+func SpotBeamLaydown(l Laydown) ([]Footprint, error) {
+	if l.AltitudeKm <= 0 {
+		return nil, errors.New("altitude must be positive")
+	}
+	if l.BeamwidthDeg <= 0 || l.BeamwidthDeg >= 180 {
+		return nil, errors.New("beamwidth must be between 0° and 180°")
+	}
+	if err := l.Area.Validate(); err != nil {
+		return nil, fmt.Errorf("area: %w", err)
+	}
+	if (l.Area.Shape == "" || l.Area.Shape == ShapeCircle) && l.Area.RadiusKm <= 0 {
+		return nil, errors.New("area: radius must be positive")
+	}
+	if err := checkReuse(l.ReuseColors, l.BandwidthMHz); err != nil {
+		return nil, err
+	}
+
+	view := newSatelliteView(unitVectorOf(l.SubLat, l.SubLon), l.AltitudeKm)
+	anchorX, anchorY, ok := view.look(l.Area.anchor())
+	if !ok {
+		return nil, errors.New("area center is not visible from the satellite")
+	}
+	inArea := l.Area.inclusion()
+	half := l.BeamwidthDeg / 2 * math.Pi / 180
+	spacing, rowSpacing := math.Sqrt(3)*half, 1.5*half
+	reach := view.extent(l.Area.bound(), anchorX, anchorY) + half
+	if candidates := (2*reach/spacing + 1) * (2*reach/rowSpacing + 1); candidates > laydownCandidates {
+		return nil, fmt.Errorf("laydown needs more than %d beams; widen the beams or shrink the area", MaxLaydownBeams)
+	}
+
+	var beams []Footprint
+	for j := -math.Floor(reach / rowSpacing); j*rowSpacing <= reach; j++ {
+		y := anchorY + j*rowSpacing
+		offset := anchorX
+		if math.Mod(math.Abs(j), 2) == 1 {
+			offset += spacing / 2
+		}
+		for i := -math.Floor(reach / spacing); i*spacing <= reach; i++ {
+			x := offset + i*spacing
+			if !view.cellTouches(x, y, half, inArea) {
+				continue
+			}
+			beam, ok := view.beam(x, y, half)
+			if !ok {
+				continue
+			}
+			if len(beams) == MaxLaydownBeams {
+				return nil, fmt.Errorf("laydown needs more than %d beams; widen the beams or shrink the area", MaxLaydownBeams)
+			}
+			beam.LinkStrength = l.LinkStrength
+			if l.ReuseColors > 0 {
+				beam.Color = reuseColor(l.ReuseColors, int(i), int(j))
+				beam.BandwidthMHz = l.BandwidthMHz / float64(l.ReuseColors)
+			}
+			beams = append(beams, beam)
+		}
+	}
+	return beams, nil
+}
+
+// anchor returns the point a laydown steers its central beam at: the center of circles and ellipses
+// and the vertex centroid of polygons.
+func (f Footprint) anchor() UnitVector {
+	if f.Shape == ShapePolygon {
+		if s, ok := newPolygon(f.Vertices); ok {
+			return s.bound.Center
+		}
+	}
+	return unitVectorOf(f.CenterLat, f.CenterLon)
+}
+
+// satelliteView maps between directions from a satellite, as nadir-angle offsets east and north in
+// radians, and points on the spherical Earth.
+type satelliteView struct {
+	sub, east, north UnitVector
+	radius           float64 // satellite's distance from the Earth's center, km
+	horizon          float64 // nadir angle of the Earth's limb
+}
+
+func newSatelliteView(sub UnitVector, altitudeKm float64) satelliteView {
+	east, north := tangentBasis(sub)
+	radius := EarthRadiusKm + altitudeKm
+	return satelliteView{sub: sub, east: east, north: north, radius: radius, horizon: math.Asin(EarthRadiusKm / radius)}
+}
+
+// centralAngle returns the Earth central angle between the sub-satellite point and where a ray at
+// nadir angle eta meets the Earth, signed like eta. Rays at or beyond the limb are clamped to it.
+func (v satelliteView) centralAngle(eta float64) float64 {
+	sign := 1.0
+	if eta < 0 {
+		sign, eta = -1, -eta
+	}
+	eta = math.Min(eta, v.horizon)
+	return sign * (math.Asin(math.Min(v.radius/EarthRadiusKm*math.Sin(eta), 1)) - eta)
+}
+
+// ground returns the point at central angle lambda from the sub-satellite point along azimuth
+// components (east, north), which need not be normalized.
+func (v satelliteView) ground(lambda, east, north float64) UnitVector {
+	norm := math.Hypot(east, north)
+	if norm == 0 {
+		return v.sub
+	}
+	sinL, cosL := math.Sincos(lambda)
+	var p UnitVector
+	for k := range p {
+		p[k] = cosL*v.sub[k] + sinL*(east*v.east[k]+north*v.north[k])/norm
+	}
+	return p
+}
+
+// point returns where the direction (x, y) meets the Earth, reporting false past the limb.
+func (v satelliteView) point(x, y float64) (UnitVector, bool) {
+	eta := math.Hypot(x, y)
+	if eta >= v.horizon {
+		return UnitVector{}, false
+	}
+	return v.ground(v.centralAngle(eta), x, y), true
+}
+
+// look returns the direction from the satellite to u, reporting false when u is out of sight.
+func (v satelliteView) look(u UnitVector) (x, y float64, ok bool) {
+	east, north := u.Dot(v.east), u.Dot(v.north)
+	lambda := math.Atan2(math.Hypot(east, north), u.Dot(v.sub))
+	if math.Cos(lambda) <= EarthRadiusKm/v.radius {
+		return 0, 0, false
+	}
+	eta := math.Atan2(EarthRadiusKm*math.Sin(lambda), v.radius-EarthRadiusKm*math.Cos(lambda))
+	azimuth := math.Atan2(east, north)
+	return eta * math.Sin(azimuth), eta * math.Cos(azimuth), true
+}
+
+// extent returns how far, in nadir angle, the visible part of a cap reaches from the direction
+// (x, y). It samples the cap's rim, so it pads the result, and answers the whole Earth disk when the
+// rim dips past the limb.
+func (v satelliteView) extent(c Cap, x, y float64) float64 {
+	const samples = 32
+	east, north := tangentBasis(c.Center)
+	sinR, cosR := math.Sqrt(math.Max(0, 1-c.CosRadius*c.CosRadius)), c.CosRadius
+	reach := 0.0
+	for k := 0; k < samples; k++ {
+		sin, cos := math.Sincos(2 * math.Pi * float64(k) / samples)
+		var rim UnitVector
+		for i := range rim {
+			rim[i] = cosR*c.Center[i] + sinR*(cos*east[i]+sin*north[i])
+		}
+		rx, ry, ok := v.look(rim)
+		if !ok {
+			return 2 * v.horizon
+		}
+		reach = math.Max(reach, math.Hypot(rx-x, ry-y))
+	}
+	return reach * 1.1
+}
+
+// cellTouches reports whether the boresight (x, y) or a corner of its hexagonal cell, which has
+// circumradius half, lands inside the area.
+func (v satelliteView) cellTouches(x, y, half float64, inArea func(UnitVector) bool) bool {
+	if p, ok := v.point(x, y); ok && inArea(p) {
+		return true
+	}
+	for k := 0; k < 6; k++ {
+		sin, cos := math.Sincos(math.Pi/2 + float64(k)*math.Pi/3)
+		if p, ok := v.point(x+half*cos, y+half*sin); ok && inArea(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// beam returns the elliptical ground footprint of a beam with boresight (x, y) and half-beamwidth
+// half. Along the line from the sub-satellite point the ellipse spans the beam's near and far
+// edges; across it the beam is half wide at the boresight's slant range.
+func (v satelliteView) beam(x, y, half float64) (Footprint, bool) {
+	eta := math.Hypot(x, y)
+	if eta >= v.horizon {
+		return Footprint{}, false
+	}
+	near, far := v.centralAngle(eta-half), v.centralAngle(eta+half)
+	radial := EarthRadiusKm * (far - near) / 2
+	slant := v.radius*math.Cos(eta) - math.Sqrt(EarthRadiusKm*EarthRadiusKm-v.radius*v.radius*math.Sin(eta)*math.Sin(eta))
+	cross := slant * math.Tan(half)
+
+	center := v.ground((far+near)/2, x, y)
+	// The radial axis points along the bearing from the ellipse center back to the sub-satellite point.
+	east, north := tangentBasis(center)
+	orientation := math.Atan2(v.sub.Dot(east), v.sub.Dot(north)) * 180 / math.Pi
+	if cross > radial {
+		radial, cross = cross, radial
+		orientation += 90
+	}
+	lat, lon := latLonOf(center)
+	return Footprint{
+		CenterLat:      lat,
+		CenterLon:      lon,
+		Shape:          ShapeEllipse,
+		SemiMajorKm:    radial,
+		SemiMinorKm:    cross,
+		OrientationDeg: math.Mod(orientation+360, 180),
+	}, true
+}
+
+// latLonOf returns u's latitude and longitude in degrees.
+func latLonOf(u UnitVector) (float64, float64) {
+	const radToDeg = 180 / math.Pi
+	return math.Asin(math.Max(-1, math.Min(1, u[2]))) * radToDeg, math.Atan2(u[1], u[0]) * radToDeg
+}
@@ -0,0 +1,105 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpotBeamLaydownCoversArea(t *testing.T) {
+	area := Footprint{CenterLat: 45, CenterLon: 10, RadiusKm: 800}
+	beams, err := SpotBeamLaydown(Laydown{AltitudeKm: 35786, BeamwidthDeg: 0.5, Area: area, LinkStrength: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(beams) < 20 || len(beams) > 200 {
+		t.Fatalf("unexpected beam count %d", len(beams))
+	}
+	for _, b := range beams {
+		if err := b.Validate(); err != nil || b.LinkStrength != 2 {
+			t.Fatalf("invalid beam %+v: %v", b, err)
+		}
+	}
+
+	// Every point of the area is inside some beam.
+	for lat := 35.0; lat <= 55; lat += 0.25 {
+		for lon := -5.0; lon <= 25; lon += 0.25 {
+			if !area.Contains(lat, lon) {
+				continue
+			}
+			covered := false
+			for _, b := range beams {
+				if b.Contains(lat, lon) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				t.Fatalf("%v, %v is inside the area but no beam covers it", lat, lon)
+			}
+		}
+	}
+
+	// The grid is steered so one beam lands on the area's center.
+	closest := math.Inf(1)
+	center := unitVectorOf(area.CenterLat, area.CenterLon)
+	for _, b := range beams {
+		closest = math.Min(closest, EarthRadiusKm*math.Acos(math.Min(1, center.Dot(unitVectorOf(b.CenterLat, b.CenterLon)))))
+	}
+	if closest > 20 {
+		t.Fatalf("closest beam is %v km from the area center", closest)
+	}
+}
+
+func TestSpotBeamShapes(t *testing.T) {
+	view := newSatelliteView(unitVectorOf(0, 0), 35786)
+	half := 0.25 * math.Pi / 180
+
+	// At nadir the beam is a circle the width of the beam at the satellite's altitude.
+	nadir, ok := view.beam(0, 0, half)
+	if !ok {
+		t.Fatal("expected a nadir beam")
+	}
+	if want := 35786 * math.Tan(half); math.Abs(nadir.SemiMajorKm-want) > 1 || math.Abs(nadir.SemiMinorKm-want) > 1 {
+		t.Fatalf("nadir beam axes %v × %v, want about %v", nadir.SemiMajorKm, nadir.SemiMinorKm, want)
+	}
+
+	// A beam steered north toward the limb stretches north-south.
+	steered, ok := view.beam(0, 7*math.Pi/180, half)
+	if !ok {
+		t.Fatal("expected a steered beam")
+	}
+	if steered.SemiMajorKm < 1.5*steered.SemiMinorKm || steered.CenterLat < 30 {
+		t.Fatalf("unexpected steered beam %+v", steered)
+	}
+	if math.Abs(steered.OrientationDeg) > 1e-6 && math.Abs(steered.OrientationDeg-180) > 1e-6 {
+		t.Fatalf("steered beam oriented %v°, want north-south", steered.OrientationDeg)
+	}
+	if _, ok := view.beam(0, 9*math.Pi/180, half); ok {
+		t.Fatal("expected a beam past the limb to miss the Earth")
+	}
+}
+
+func TestSpotBeamLaydownRejectsBadInput(t *testing.T) {
+	area := Footprint{CenterLat: 10, CenterLon: 0, RadiusKm: 500}
+	for _, l := range []Laydown{
+		{AltitudeKm: 0, BeamwidthDeg: 1, Area: area},
+		{AltitudeKm: 35786, BeamwidthDeg: 0, Area: area},
+		{AltitudeKm: 35786, BeamwidthDeg: 1, Area: Footprint{CenterLat: 10}},
+		{AltitudeKm: 35786, BeamwidthDeg: 1, Area: Footprint{Shape: "hexagon"}},
+		// The far side of the Earth is out of sight.
+		{AltitudeKm: 35786, BeamwidthDeg: 1, Area: Footprint{CenterLat: 0, CenterLon: 180, RadiusKm: 500}},
+		{AltitudeKm: 35786, BeamwidthDeg: 0.001, Area: Footprint{RadiusKm: 5000}},
+	} {
+		if _, err := SpotBeamLaydown(l); err == nil {
+			t.Fatalf("expected %+v to be rejected", l)
+		}
+	}
+
+	// Polygonal areas work too.
+	beams, err := SpotBeamLaydown(Laydown{AltitudeKm: 1200, BeamwidthDeg: 3, Area: Footprint{Shape: ShapePolygon, Vertices: []Vertex{
+		{Lat: -5, Lon: -5}, {Lat: -5, Lon: 5}, {Lat: 5, Lon: 5}, {Lat: 5, Lon: -5},
+	}}})
+	if err != nil || len(beams) == 0 {
+		t.Fatalf("polygon laydown gave %d beams: %v", len(beams), err)
+	}
+}
@@ -0,0 +1,62 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Region is a named latitude/longitude box sampled at a finer resolution than the global grid,
+// for regional studies that need detail without paying for it over the whole globe. Regions do
+// not wrap around the antimeridian; split one that crosses it into two.
+type Region struct {
+	Name   string  `json:"name"`
+	MinLat float64 `json:"minLat"` // degrees
+	MaxLat float64 `json:"maxLat"` // degrees
+	MinLon float64 `json:"minLon"` // degrees
+	MaxLon float64 `json:"maxLon"` // degrees
+	// LatStep and LonStep are the region's resolution in degrees.
+	LatStep float64 `json:"latStep"`
+	LonStep float64 `json:"lonStep"`
+}
+
+// Validate ensures the region is a non-empty box on the globe with a resolution that fits it.
+func (r Region) Validate() error {
+	switch {
+	case r.Name == "":
+		return errors.New("coverage region name cannot be empty")
+	case r.MinLat < -90 || r.MaxLat > 90 || r.MinLat >= r.MaxLat:
+		return fmt.Errorf("coverage region %q latitudes must satisfy -90 <= minLat < maxLat <= 90", r.Name)
+	case r.MinLon < -180 || r.MaxLon > 180 || r.MinLon >= r.MaxLon:
+		return fmt.Errorf("coverage region %q longitudes must satisfy -180 <= minLon < maxLon <= 180", r.Name)
+	case !(r.LatStep > 0 && r.LonStep > 0):
+		return fmt.Errorf("coverage region %q steps must be positive", r.Name)
+	case r.LatStep < MinStep || r.LonStep < MinStep:
+		return fmt.Errorf("coverage region %q steps must be at least %g degrees", r.Name, MinStep)
+	case r.LatStep > r.MaxLat-r.MinLat || r.LonStep > r.MaxLon-r.MinLon:
+		return fmt.Errorf("coverage region %q steps are larger than the region", r.Name)
+	}
+	return nil
+}
+
+// CellCount returns how many cells NewRegionalGrid allocates for the region.
+func (r Region) CellCount() int {
+	if r.LatStep <= 0 || r.LonStep <= 0 {
+		return 0
+	}
+	return int(math.Ceil((r.MaxLat-r.MinLat)/r.LatStep)) * int(math.Ceil((r.MaxLon-r.MinLon)/r.LonStep))
+}
+
+// NewRegionalGrid builds a grid over region at the region's resolution. Cells are centered halfway
+// into each step from the region's south-west corner. config supplies everything but the steps:
+// the backend, single precision and area weighting.
+func NewRegionalGrid(config GridConfig, region Region) (*CoverageGrid, error) {
+	if err := region.Validate(); err != nil {
+		return nil, err
+	}
+	config.LatStep, config.LonStep = region.LatStep, region.LonStep
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return newGrid(config, region.MinLat, region.MaxLat, region.MinLon, region.MaxLon, region.CellCount()), nil
+}
@@ -0,0 +1,62 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRegionalGridMatchesGlobalCells(t *testing.T) {
+	region := Region{Name: "europe", MinLat: 35, MaxLat: 71, MinLon: -10, MaxLon: 40, LatStep: 1, LonStep: 1}
+	if n := region.CellCount(); n != 36*50 {
+		t.Fatalf("expected %d cells, got %d", 36*50, n)
+	}
+	regional, err := NewRegionalGrid(GridConfig{LatStep: 10, LonStep: 10}, region)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := regional.Cells()
+	if len(cells) != region.CellCount() {
+		t.Fatalf("expected %d cells, got %d", region.CellCount(), len(cells))
+	}
+	if first := cells[0]; first.Lat != 35.5 || first.Lon != -9.5 {
+		t.Fatalf("expected the first cell at the south-west corner, got %+v", first)
+	}
+	if regional.Config.LatStep != 1 || regional.Config.LonStep != 1 {
+		t.Fatalf("expected the region's resolution, got %+v", regional.Config)
+	}
+
+	// The same footprint covers the same cells on the regional grid as on a global grid of the same
+	// resolution, restricted to the region.
+	global, err := NewCoverageGrid(GridConfig{LatStep: 1, LonStep: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	footprints := []Footprint{{CenterLat: 50, CenterLon: 10, RadiusKm: 800, LinkStrength: 1}}
+	regional.ApplyFootprints(footprints)
+	global.ApplyFootprints(footprints)
+	want := 0
+	for _, cell := range global.Cells() {
+		if cell.Lat > 35 && cell.Lat < 71 && cell.Lon > -10 && cell.Lon < 40 && cell.Covered() {
+			want++
+		}
+	}
+	summary := regional.Summarize()
+	if summary.CoveredCells != want || want == 0 {
+		t.Fatalf("expected %d covered regional cells, got %d", want, summary.CoveredCells)
+	}
+	if math.Abs(summary.CoveragePercent-100*float64(want)/float64(len(cells))) > 1e-9 {
+		t.Fatalf("unexpected regional coverage %v", summary.CoveragePercent)
+	}
+
+	for name, bad := range map[string]Region{
+		"unnamed":      {MinLat: 0, MaxLat: 10, MinLon: 0, MaxLon: 10, LatStep: 1, LonStep: 1},
+		"inverted":     {Name: "r", MinLat: 10, MaxLat: 0, MinLon: 0, MaxLon: 10, LatStep: 1, LonStep: 1},
+		"off globe":    {Name: "r", MinLat: 0, MaxLat: 10, MinLon: 170, MaxLon: 190, LatStep: 1, LonStep: 1},
+		"no step":      {Name: "r", MinLat: 0, MaxLat: 10, MinLon: 0, MaxLon: 10, LonStep: 1},
+		"coarse steps": {Name: "r", MinLat: 0, MaxLat: 10, MinLon: 0, MaxLon: 10, LatStep: 20, LonStep: 1},
+	} {
+		if _, err := NewRegionalGrid(GridConfig{LatStep: 10, LonStep: 10}, bad); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package coverage
+
+// ExpectedCoverage is coverage averaged over the failure states of the satellites behind the
+// footprints, so planners can quote an availability-adjusted figure next to the nominal one.
+type ExpectedCoverage struct {
+	// CoveredCells is the expected number of covered cells.
+	CoveredCells    float64 `json:"coveredCells"`
+	CoveragePercent float64 `json:"coveragePercent"`
+}
+
+// Expected computes the coverage expected when the satellite behind each footprint is out of
+// service with the matching probability in failures, independently of the others. A cell is then
+// covered unless every footprint over it has failed, which happens with the product of their
+// failure probabilities, so the result is exact rather than sampled. Footprints without a matching
+// probability never fail. The grid's own cells are left untouched.
+func (g *CoverageGrid) Expected(footprints []Footprint, failures []float64) ExpectedCoverage {
+	contains := make([]func(UnitVector) bool, len(footprints))
+	for i, f := range footprints {
+		contains[i] = f.inclusion()
+	}
+	var expected, expectedWeight, totalWeight float64
+	for _, cell := range g.cells {
+		u := unitVectorOf(cell.Lat, cell.Lon)
+		uncovered := 1.0
+		for i, inside := range contains {
+			if !inside(u) {
+				continue
+			}
+			failure := 0.0
+			if i < len(failures) {
+				failure = failures[i]
+			}
+			if uncovered *= failure; uncovered == 0 {
+				break
+			}
+		}
+		weight := g.Config.CellWeight(cell.Lat)
+		totalWeight += weight
+		expected += 1 - uncovered
+		expectedWeight += (1 - uncovered) * weight
+	}
+	result := ExpectedCoverage{CoveredCells: expected}
+	if totalWeight > 0 {
+		result.CoveragePercent = expectedWeight / totalWeight * 100
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpectedCoverageMultipliesFailureProbabilities(t *testing.T) {
+	grid, err := NewCoverageGrid(GridConfig{LatStep: 5, LonStep: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two identical footprints over the same cells: a cell is lost only when both fail.
+	footprint := Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 2000, LinkStrength: 1}
+	footprints := []Footprint{footprint, footprint}
+	grid.ApplyFootprints(footprints)
+	nominal := grid.Summarize()
+
+	expected := grid.Expected(footprints, []float64{0.1, 0.2})
+	want := float64(nominal.CoveredCells) * (1 - 0.1*0.2)
+	if math.Abs(expected.CoveredCells-want) > 1e-9 {
+		t.Fatalf("expected %v covered cells, got %v", want, expected.CoveredCells)
+	}
+	if math.Abs(expected.CoveragePercent-nominal.CoveragePercent*0.98) > 1e-9 {
+		t.Fatalf("expected %v%%, got %v%%", nominal.CoveragePercent*0.98, expected.CoveragePercent)
+	}
+
+	if certain := grid.Expected(footprints, nil); math.Abs(certain.CoveragePercent-nominal.CoveragePercent) > 1e-9 {
+		t.Fatalf("expected reliable satellites to keep the nominal coverage, got %+v", certain)
+	}
+	if lost := grid.Expected(footprints, []float64{1, 1}); lost.CoveredCells != 0 {
+		t.Fatalf("expected failed satellites to cover nothing, got %+v", lost)
+	}
+	if after := grid.Summarize(); after.CoveredCells != nominal.CoveredCells {
+		t.Fatal("expected the grid's own cells to be left untouched")
+	}
+}
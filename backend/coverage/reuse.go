@@ -0,0 +1,114 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxReuseColors is the largest frequency reuse plan footprints may be colored with.
+const MaxReuseColors = 16
+
+// reusePatterns colors a hexagonal beam lattice, in axial coordinates, so that no two adjacent
+// beams share a color. The cluster sizes are the ones hexagonal cells tile with: 3, 4 and 7, plus 1
+// for a single shared band.
+var reusePatterns = map[int]func(q, r int) int{
+	1: func(q, r int) int { return 0 },
+	3: func(q, r int) int { return mod(q-r, 3) },
+	4: func(q, r int) int { return mod(q, 2) + 2*mod(r, 2) },
+	7: func(q, r int) int { return mod(q+3*r, 7) },
+}
+
+func mod(a, n int) int {
+	return (a%n + n) % n
+}
+
+// reuseColor returns the 1-based color of the beam in column i and row j of a laydown, where odd
+// rows are shifted half a beam to the east.
+func reuseColor(colors, i, j int) int {
+	q := i - (j-(j&1))/2
+	return reusePatterns[colors](q, j) + 1
+}
+
+func checkReuse(colors int, bandwidthMHz float64) error {
+	if _, ok := reusePatterns[colors]; !ok && colors != 0 {
+		return fmt.Errorf("reuse plans of %d colors are not supported; use 1, 3, 4 or 7", colors)
+	}
+	if bandwidthMHz < 0 {
+		return errors.New("bandwidth cannot be negative")
+	}
+	if bandwidthMHz > 0 && colors == 0 {
+		return errors.New("bandwidth needs a reuse plan to divide it between beams")
+	}
+	return nil
+}
+
+// Channel is one frequency color's share of a cell: how many beams carry that color over the cell
+// and the widest of their bandwidths. Color 0 is spectrum dedicated to a single beam.
+type Channel struct {
+	Color        int     `json:"k"`
+	Beams        int     `json:"n"`
+	BandwidthMHz float64 `json:"b"`
+}
+
+// cellChannels holds a cell's channels, indexed by color.
+type cellChannels [MaxReuseColors + 1]Channel
+
+// usable returns the widest bandwidth a terminal in the cell can use: the best channel that only one
+// beam transmits on. Two beams reusing a color over the same cell interfere and leave it unusable.
+func (c *cellChannels) usable() float64 {
+	best := 0.0
+	for color, ch := range c {
+		if ch.Beams > 0 && (color == 0 || ch.Beams == 1) && ch.BandwidthMHz > best {
+			best = ch.BandwidthMHz
+		}
+	}
+	return best
+}
+
+// addChannel records beams of a color over cell i.
+func (g *CoverageGrid) addChannel(i int, ch Channel) {
+	channels, ok := g.channels[i]
+	if !ok {
+		channels = new(cellChannels)
+		g.channels[i] = channels
+	}
+	slot := &channels[ch.Color]
+	slot.Color = ch.Color
+	slot.Beams += ch.Beams
+	if ch.BandwidthMHz > slot.BandwidthMHz {
+		slot.BandwidthMHz = ch.BandwidthMHz
+	}
+	g.cells[i].BandwidthMHz = channels.usable()
+}
+
+// applyChannels records the spectrum of footprints that carry bandwidth. It tests cells at double
+// precision in every mode, like applyShapes.
+func (g *CoverageGrid) applyChannels(footprints []Footprint) {
+	for _, f := range footprints {
+		if f.BandwidthMHz <= 0 || f.Color < 0 || f.Color > MaxReuseColors {
+			continue
+		}
+		bound, contains := f.bound(), f.inclusion()
+		ch := Channel{Color: f.Color, Beams: 1, BandwidthMHz: f.BandwidthMHz}
+		for i := range g.cells {
+			if u := g.unit(i); u.Dot(bound.Center) >= bound.CosRadius && contains(u) {
+				g.addChannel(i, ch)
+			}
+		}
+	}
+}
+
+// cellChannelList returns the channels in use over cell i, for Contributions.
+func (g *CoverageGrid) cellChannelList(i int) []Channel {
+	channels, ok := g.channels[i]
+	if !ok {
+		return nil
+	}
+	var out []Channel
+	for _, ch := range channels {
+		if ch.Beams > 0 {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
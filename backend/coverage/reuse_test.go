@@ -0,0 +1,170 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReusePatternsSeparateNeighbors(t *testing.T) {
+	// Axial neighbors of a hexagonal lattice.
+	neighbors := [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, -1}, {-1, 1}}
+	for colors, pattern := range reusePatterns {
+		if colors == 1 {
+			continue
+		}
+		seen := make(map[int]bool)
+		for q := -6; q <= 6; q++ {
+			for r := -6; r <= 6; r++ {
+				c := pattern(q, r)
+				seen[c] = true
+				for _, n := range neighbors {
+					if pattern(q+n[0], r+n[1]) == c {
+						t.Fatalf("%d-color pattern gives neighbors %d,%d and %d,%d the same color", colors, q, r, q+n[0], r+n[1])
+					}
+				}
+			}
+		}
+		if len(seen) != colors {
+			t.Fatalf("%d-color pattern uses %d colors", colors, len(seen))
+		}
+	}
+}
+
+func TestLaydownReuseColorsAdjacentBeamsApart(t *testing.T) {
+	area := Footprint{CenterLat: 40, CenterLon: 0, RadiusKm: 1200}
+	for _, colors := range []int{3, 4, 7} {
+		beams, err := SpotBeamLaydown(Laydown{AltitudeKm: 35786, BeamwidthDeg: 0.6, Area: area, ReuseColors: colors, BandwidthMHz: 2800})
+		if err != nil {
+			t.Fatalf("%d colors: %v", colors, err)
+		}
+		used := make(map[int]bool)
+		for i, a := range beams {
+			if a.Color < 1 || a.Color > colors || a.BandwidthMHz != 2800/float64(colors) {
+				t.Fatalf("%d colors: unexpected beam color %d and bandwidth %v", colors, a.Color, a.BandwidthMHz)
+			}
+			used[a.Color] = true
+			for _, b := range beams[i+1:] {
+				if a.Color == b.Color && overlaps(a, b) {
+					t.Fatalf("%d colors: co-channel beams at %v,%v and %v,%v overlap", colors, a.CenterLat, a.CenterLon, b.CenterLat, b.CenterLon)
+				}
+			}
+		}
+		if len(used) != colors {
+			t.Fatalf("laydown used %d of %d colors", len(used), colors)
+		}
+	}
+
+	for _, l := range []Laydown{
+		{AltitudeKm: 35786, BeamwidthDeg: 0.6, Area: area, ReuseColors: 5},
+		{AltitudeKm: 35786, BeamwidthDeg: 0.6, Area: area, BandwidthMHz: 100},
+		{AltitudeKm: 35786, BeamwidthDeg: 0.6, Area: area, ReuseColors: 4, BandwidthMHz: -1},
+	} {
+		if _, err := SpotBeamLaydown(l); err == nil {
+			t.Fatalf("expected %+v to be rejected", l)
+		}
+	}
+}
+
+// overlaps reports whether two beams share a point, sampled along each one's boundary.
+func overlaps(a, b Footprint) bool {
+	for _, pair := range [][2]Footprint{{a, b}, {b, a}} {
+		f, other := pair[0], pair[1]
+		sinT, cosT := math.Sincos(f.OrientationDeg * math.Pi / 180)
+		for k := 0; k < 72; k++ {
+			sin, cos := math.Sincos(float64(k) * 5 * math.Pi / 180)
+			// Walk a little inside f's boundary, then turn the axes to the ellipse's orientation.
+			major, minor := 0.98*f.SemiMajorKm*cos, 0.98*f.SemiMinorKm*sin
+			east := major*sinT + minor*cosT
+			north := major*cosT - minor*sinT
+			lat := f.CenterLat + north/EarthRadiusKm*180/math.Pi
+			lon := f.CenterLon + east/(EarthRadiusKm*math.Cos(f.CenterLat*math.Pi/180))*180/math.Pi
+			if other.Contains(lat, lon) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestUsableBandwidthAccountsForReuse(t *testing.T) {
+	area := Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 1500}
+	usable := func(colors int) []Cell {
+		beams, err := SpotBeamLaydown(Laydown{AltitudeKm: 35786, BeamwidthDeg: 1, Area: area, ReuseColors: colors, BandwidthMHz: 2000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		grid, err := NewCoverageGrid(GridConfig{LatStep: 0.5, LonStep: 0.5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		grid.ApplyFootprints(beams)
+		return grid.Cells()
+	}
+
+	// With four colors neighbors never clash, so every covered cell gets a quarter of the band.
+	for _, cell := range usable(4) {
+		if cell.Covered() && cell.BandwidthMHz != 500 {
+			t.Fatalf("cell %v,%v has %v MHz under 4-color reuse, want 500", cell.Lat, cell.Lon, cell.BandwidthMHz)
+		}
+	}
+	// With one color the full band is usable only where a single beam reaches; overlaps interfere.
+	full, clashing := 0, 0
+	for _, cell := range usable(1) {
+		switch {
+		case cell.CoverageCount == 1 && cell.BandwidthMHz == 2000:
+			full++
+		case cell.CoverageCount > 1 && cell.BandwidthMHz == 0:
+			clashing++
+		case cell.Covered():
+			t.Fatalf("cell %v,%v with %d beams has %v MHz", cell.Lat, cell.Lon, cell.CoverageCount, cell.BandwidthMHz)
+		}
+	}
+	if full == 0 || clashing == 0 {
+		t.Fatalf("expected both clear and co-channel cells, got %d and %d", full, clashing)
+	}
+
+	// Dedicated spectrum never clashes.
+	grid, _ := NewCoverageGrid(GridConfig{LatStep: 1, LonStep: 1})
+	grid.ApplyFootprints([]Footprint{
+		{RadiusKm: 500, BandwidthMHz: 100},
+		{RadiusKm: 500, BandwidthMHz: 300},
+	})
+	if summary := grid.Summarize(); summary.MeanBandwidthMHz != 300 {
+		t.Fatalf("mean bandwidth %v, want 300", summary.MeanBandwidthMHz)
+	}
+	grid.Reset()
+	if summary := grid.Summarize(); summary.MeanBandwidthMHz != 0 || len(grid.channels) != 0 {
+		t.Fatal("expected Reset to clear spectrum")
+	}
+}
+
+func TestMergeCombinesChannels(t *testing.T) {
+	config := GridConfig{LatStep: 1, LonStep: 1}
+	a := Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 400, BandwidthMHz: 250, Color: 2}
+	b := Footprint{CenterLat: 0, CenterLon: 3, RadiusKm: 400, BandwidthMHz: 250, Color: 2}
+
+	whole, _ := NewCoverageGrid(config)
+	whole.ApplyFootprints([]Footprint{a, b})
+	left, _ := NewCoverageGrid(config)
+	left.ApplyFootprints([]Footprint{a})
+	merged, _ := NewCoverageGrid(config)
+	merged.ApplyFootprints([]Footprint{b})
+	if err := merged.Merge(left.Contributions()); err != nil {
+		t.Fatal(err)
+	}
+
+	overlap := false
+	got := merged.Cells()
+	for i, cell := range whole.Cells() {
+		if got[i] != cell {
+			t.Fatalf("merged cell %+v differs from %+v", got[i], cell)
+		}
+		overlap = overlap || cell.CoverageCount == 2
+	}
+	if !overlap {
+		t.Fatal("expected the beams to overlap")
+	}
+	if err := merged.Merge([]CellCoverage{{Index: 0, Channels: []Channel{{Color: MaxReuseColors + 1, Beams: 1}}}}); err == nil {
+		t.Fatal("expected an out-of-range color to be rejected")
+	}
+}
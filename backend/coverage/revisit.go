@@ -0,0 +1,115 @@
+package coverage
+
+import (
+	"errors"
+	"time"
+)
+
+// CellGap is the longest uncovered interval of one grid cell over an observation window.
+type CellGap struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+	// MaxGapS is the longest run of time, in seconds, the cell spent uncovered. A cell never covered
+	// over the window has a gap as long as the window.
+	MaxGapS float64 `json:"maxGapS"`
+	// CoveredFraction is the share of the window the cell spent covered, in [0, 1].
+	CoveredFraction float64 `json:"coveredFraction"`
+}
+
+// GapTracker follows per-cell coverage through a sequence of heatmaps sampled over time. Each
+// sample's coverage is assumed to hold until the next one, so the sampling interval bounds how
+// precisely gap edges are placed.
+type GapTracker struct {
+	start, last time.Time
+	cells       []HeatmapCell
+	// gapStart is when each cell's current uncovered run began; zero when the cell is covered.
+	gapStart []time.Time
+	maxGap   []time.Duration
+	covered  []time.Duration
+}
+
+// Observe records the heatmap sampled at at. Every heatmap must list the same cells in the same
+// order, and samples must arrive in time order.
+func (t *GapTracker) Observe(at time.Time, cells []HeatmapCell) error {
+	if t.cells == nil {
+		t.start = at
+		t.cells = append([]HeatmapCell(nil), cells...)
+		t.gapStart = make([]time.Time, len(cells))
+		t.maxGap = make([]time.Duration, len(cells))
+		t.covered = make([]time.Duration, len(cells))
+	} else {
+		if at.Before(t.last) {
+			return errors.New("samples must be observed in time order")
+		}
+		if len(cells) != len(t.cells) {
+			return errors.New("heatmap cells changed between samples")
+		}
+		for i, cell := range cells {
+			if cell.Lat != t.cells[i].Lat || cell.Lon != t.cells[i].Lon {
+				return errors.New("heatmap cells changed between samples")
+			}
+		}
+		t.hold(at)
+	}
+	t.last = at
+	for i, cell := range cells {
+		t.cells[i].Covered = cell.Covered
+		switch {
+		case cell.Covered:
+			t.closeGap(i, at)
+		case t.gapStart[i].IsZero():
+			t.gapStart[i] = at
+		}
+	}
+	return nil
+}
+
+// hold extends the latest sample's coverage up to at.
+func (t *GapTracker) hold(at time.Time) {
+	elapsed := at.Sub(t.last)
+	for i, cell := range t.cells {
+		if cell.Covered {
+			t.covered[i] += elapsed
+		}
+	}
+}
+
+func (t *GapTracker) closeGap(i int, at time.Time) {
+	if t.gapStart[i].IsZero() {
+		return
+	}
+	if gap := at.Sub(t.gapStart[i]); gap > t.maxGap[i] {
+		t.maxGap[i] = gap
+	}
+	t.gapStart[i] = time.Time{}
+}
+
+// Gaps returns every cell's longest gap over the window from the first sample to end, with the
+// last sample holding until end. Gaps still open at end are cut off there. The tracker is left
+// unchanged, so observation can continue afterwards.
+func (t *GapTracker) Gaps(end time.Time) ([]CellGap, error) {
+	if t.cells == nil {
+		return nil, errors.New("no samples observed")
+	}
+	if end.Before(t.last) {
+		return nil, errors.New("window cannot end before the last sample")
+	}
+	window := end.Sub(t.start)
+	gaps := make([]CellGap, len(t.cells))
+	for i, cell := range t.cells {
+		maxGap, covered := t.maxGap[i], t.covered[i]
+		if cell.Covered {
+			covered += end.Sub(t.last)
+		} else if gap := end.Sub(t.gapStart[i]); gap > maxGap {
+			maxGap = gap
+		}
+		gaps[i] = CellGap{Lat: cell.Lat, Lon: cell.Lon, MaxGapS: maxGap.Seconds()}
+		switch {
+		case window > 0:
+			gaps[i].CoveredFraction = covered.Seconds() / window.Seconds()
+		case cell.Covered:
+			gaps[i].CoveredFraction = 1
+		}
+	}
+	return gaps, nil
+}
@@ -0,0 +1,66 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGapTrackerFindsLongestGap(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	// Cell a is covered, loses coverage for 2 and then 3 minutes; cell b is never covered; cell c
+	// loses coverage at the last sample, leaving a gap open at the end of the window.
+	pattern := []struct{ a, b, c bool }{
+		{true, false, true},
+		{false, false, true},
+		{false, false, true},
+		{true, false, true},
+		{false, false, true},
+		{false, false, true},
+		{false, false, true},
+		{true, false, false},
+	}
+	var tracker GapTracker
+	for i, p := range pattern {
+		cells := []HeatmapCell{{Lat: 0, Lon: 0, Covered: p.a}, {Lat: 0, Lon: 10, Covered: p.b}, {Lat: 0, Lon: 20, Covered: p.c}}
+		if err := tracker.Observe(start.Add(time.Duration(i)*time.Minute), cells); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gaps, err := tracker.Gaps(start.Add(10 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CellGap{
+		{Lat: 0, Lon: 0, MaxGapS: 180, CoveredFraction: 0.5},
+		{Lat: 0, Lon: 10, MaxGapS: 600, CoveredFraction: 0},
+		{Lat: 0, Lon: 20, MaxGapS: 180, CoveredFraction: 0.7},
+	}
+	for i := range want {
+		got := gaps[i]
+		if got.Lat != want[i].Lat || got.Lon != want[i].Lon || got.MaxGapS != want[i].MaxGapS ||
+			math.Abs(got.CoveredFraction-want[i].CoveredFraction) > 1e-9 {
+			t.Fatalf("cell %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+}
+
+func TestGapTrackerRejectsMismatchedSamples(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var tracker GapTracker
+	if _, err := tracker.Gaps(start); err == nil {
+		t.Fatal("expected an error before any sample")
+	}
+	if err := tracker.Observe(start, []HeatmapCell{{Lat: 0, Lon: 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Observe(start.Add(time.Minute), []HeatmapCell{{Lat: 0, Lon: 10}}); err == nil {
+		t.Fatal("expected an error for a different grid")
+	}
+	if err := tracker.Observe(start.Add(-time.Minute), []HeatmapCell{{Lat: 0, Lon: 0}}); err == nil {
+		t.Fatal("expected an error for an out-of-order sample")
+	}
+	if _, err := tracker.Gaps(start.Add(-time.Second)); err == nil {
+		t.Fatal("expected an error for a window ending before the last sample")
+	}
+}
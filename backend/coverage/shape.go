@@ -0,0 +1,267 @@
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Footprint shapes. The zero value of Footprint.Shape is a circle.
+const (
+	ShapeCircle  = "circle"
+	ShapeEllipse = "ellipse"
+	ShapePolygon = "polygon"
+)
+
+// Vertex is a polygon corner in degrees.
+type Vertex struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Validate reports whether the footprint's shape is fully described. Polygons must lie within 90° of
+// their vertices' centroid, which every real beam does, so that they can be projected onto a plane
+// without wrapping.
+func (f Footprint) Validate() error {
+	if f.Color < 0 || f.Color > MaxReuseColors {
+		return fmt.Errorf("footprint color must be between 0 and %d", MaxReuseColors)
+	}
+	if f.BandwidthMHz < 0 {
+		return errors.New("footprint bandwidth cannot be negative")
+	}
+	if f.Uncertainty != nil {
+		if err := f.Uncertainty.validate(); err != nil {
+			return err
+		}
+	}
+	switch f.Shape {
+	case "", ShapeCircle:
+		return nil
+	case ShapeEllipse:
+		if f.SemiMinorKm <= 0 || f.SemiMajorKm < f.SemiMinorKm {
+			return errors.New("elliptical footprints need 0 < semiMinorKm <= semiMajorKm")
+		}
+	case ShapePolygon:
+		if len(f.Vertices) < 3 {
+			return errors.New("polygonal footprints need at least three vertices")
+		}
+		if _, ok := newPolygon(f.Vertices); !ok {
+			return errors.New("polygonal footprints must lie within a hemisphere")
+		}
+	default:
+		return fmt.Errorf("unknown footprint shape %q", f.Shape)
+	}
+	if f.Auto {
+		return errors.New("auto footprints are circles")
+	}
+	return nil
+}
+
+// Contains reports whether the point at latDeg, lonDeg lies inside the footprint.
+func (f Footprint) Contains(latDeg, lonDeg float64) bool {
+	return f.inclusion()(unitVectorOf(latDeg, lonDeg))
+}
+
+// CoverageWithin returns the percentage of heatmap cells centered inside the footprint that are
+// covered, reporting false when no cell center falls inside it.
+func (f Footprint) CoverageWithin(cells []HeatmapCell) (float64, bool) {
+	contains := f.inclusion()
+	inside, covered := 0, 0
+	for _, cell := range cells {
+		if contains(unitVectorOf(cell.Lat, cell.Lon)) {
+			inside++
+			if cell.Covered {
+				covered++
+			}
+		}
+	}
+	if inside == 0 {
+		return 0, false
+	}
+	return float64(covered) / float64(inside) * 100, true
+}
+
+// inclusion prepares the footprint's point test once for callers testing many points. Invalid
+// footprints contain nothing.
+func (f Footprint) inclusion() func(UnitVector) bool {
+	switch f.Shape {
+	case "", ShapeCircle:
+		center := unitVectorOf(f.CenterLat, f.CenterLon)
+		cosRadius := math.Cos(math.Min(f.RadiusKm/EarthRadiusKm, math.Pi))
+		return func(u UnitVector) bool { return f.RadiusKm > 0 && u.Dot(center) >= cosRadius }
+	}
+	shape, ok := newShape(f)
+	return func(u UnitVector) bool {
+		return ok && u.Dot(shape.bound.Center) >= shape.bound.CosRadius && shape.contains(u)
+	}
+}
+
+// bound returns a cap holding the whole footprint.
+func (f Footprint) bound() Cap {
+	switch f.Shape {
+	case "", ShapeCircle:
+		return Cap{Center: unitVectorOf(f.CenterLat, f.CenterLon), CosRadius: math.Cos(math.Min(f.RadiusKm/EarthRadiusKm, math.Pi))}
+	}
+	s, _ := newShape(f)
+	return s.bound
+}
+
+// shape is an elliptical or polygonal footprint with a bounding cap that lets most cells be
+// rejected with a dot product before the exact test.
+type shape struct {
+	bound    Cap
+	contains func(UnitVector) bool
+}
+
+// newShape prepares a non-circular footprint, reporting false for circles and invalid shapes.
+func newShape(f Footprint) (shape, bool) {
+	switch f.Shape {
+	case ShapeEllipse:
+		if f.Validate() != nil {
+			return shape{}, false
+		}
+		return newEllipse(f), true
+	case ShapePolygon:
+		s, ok := newPolygon(f.Vertices)
+		s.bound.Strength = f.LinkStrength
+		return s, ok
+	}
+	return shape{}, false
+}
+
+// splitShapes separates circular footprints, which the cap loops handle, from the shaped ones.
+// Invalid shapes cover nothing.
+func splitShapes(footprints []Footprint) ([]Footprint, []shape) {
+	circles := footprints[:0:0]
+	var shapes []shape
+	for _, f := range footprints {
+		if f.Shape == "" || f.Shape == ShapeCircle {
+			circles = append(circles, f)
+		} else if s, ok := newShape(f); ok {
+			shapes = append(shapes, s)
+		}
+	}
+	return circles, shapes
+}
+
+// tangentBasis returns unit vectors pointing east and north at c.
+func tangentBasis(c UnitVector) (east, north UnitVector) {
+	lon := math.Atan2(c[1], c[0])
+	sinLat := c[2]
+	cosLat := math.Hypot(c[0], c[1])
+	east = UnitVector{-math.Sin(lon), math.Cos(lon), 0}
+	north = UnitVector{-sinLat * math.Cos(lon), -sinLat * math.Sin(lon), cosLat}
+	return east, north
+}
+
+// newEllipse tests points in the azimuthal equidistant projection about the center, where distance
+// and bearing from the center are preserved, against an ellipse whose major axis points
+// OrientationDeg clockwise from north.
+func newEllipse(f Footprint) shape {
+	center := unitVectorOf(f.CenterLat, f.CenterLon)
+	east, north := tangentBasis(center)
+	theta := f.OrientationDeg * math.Pi / 180
+	sinT, cosT := math.Sin(theta), math.Cos(theta)
+	a, b := f.SemiMajorKm, f.SemiMinorKm
+	return shape{
+		bound: Cap{Center: center, CosRadius: math.Cos(math.Min(a/EarthRadiusKm, math.Pi)), Strength: f.LinkStrength},
+		contains: func(u UnitVector) bool {
+			x, y := u.Dot(east), u.Dot(north)
+			planar := math.Hypot(x, y)
+			if planar == 0 {
+				return u.Dot(center) > 0
+			}
+			distance := EarthRadiusKm * math.Atan2(planar, u.Dot(center))
+			x, y = x/planar*distance, y/planar*distance
+			major := x*sinT + y*cosT
+			minor := x*cosT - y*sinT
+			return (major/a)*(major/a)+(minor/b)*(minor/b) <= 1
+		},
+	}
+}
+
+// newPolygon tests points in the gnomonic projection about the vertices' centroid, which maps the
+// great-circle edges of the beam to straight lines, with the even-odd rule. It reports false when a
+// vertex is 90° or more from the centroid.
+func newPolygon(vertices []Vertex) (shape, bool) {
+	if len(vertices) < 3 {
+		return shape{}, false
+	}
+	var sum UnitVector
+	units := make([]UnitVector, len(vertices))
+	for i, v := range vertices {
+		units[i] = unitVectorOf(v.Lat, v.Lon)
+		for k := range sum {
+			sum[k] += units[i][k]
+		}
+	}
+	norm := math.Sqrt(sum.Dot(sum))
+	if norm == 0 {
+		return shape{}, false
+	}
+	center := UnitVector{sum[0] / norm, sum[1] / norm, sum[2] / norm}
+	east, north := tangentBasis(center)
+
+	project := func(u UnitVector) (float64, float64) {
+		d := u.Dot(center)
+		return u.Dot(east) / d, u.Dot(north) / d
+	}
+	cosRadius := 1.0
+	xs, ys := make([]float64, len(units)), make([]float64, len(units))
+	for i, u := range units {
+		d := u.Dot(center)
+		if d <= 1e-9 {
+			return shape{}, false
+		}
+		cosRadius = math.Min(cosRadius, d)
+		xs[i], ys[i] = project(u)
+	}
+	return shape{
+		// Caps smaller than a hemisphere are convex, so the cap through the farthest vertex holds
+		// every edge too.
+		bound: Cap{Center: center, CosRadius: cosRadius},
+		contains: func(u UnitVector) bool {
+			if u.Dot(center) <= 0 {
+				return false
+			}
+			x, y := project(u)
+			inside := false
+			for i, j := 0, len(xs)-1; i < len(xs); j, i = i, i+1 {
+				if (ys[i] > y) != (ys[j] > y) && x < (xs[j]-xs[i])*(y-ys[i])/(ys[j]-ys[i])+xs[i] {
+					inside = !inside
+				}
+			}
+			return inside
+		},
+	}, true
+}
+
+// applyShapes adds elliptical and polygonal footprints cell by cell. They bypass the cap backends,
+// whose interface only carries circles.
+func (g *CoverageGrid) applyShapes(shapes []shape) {
+	if len(shapes) == 0 {
+		return
+	}
+	for i := range g.cells {
+		u := g.unit(i)
+		cell := &g.cells[i]
+		for _, s := range shapes {
+			if u.Dot(s.bound.Center) < s.bound.CosRadius || !s.contains(u) {
+				continue
+			}
+			cell.CoverageCount++
+			if s.bound.Strength > cell.StrongestLink {
+				cell.StrongestLink = s.bound.Strength
+			}
+		}
+	}
+}
+
+// unit returns cell i's center on the unit sphere at double precision.
+func (g *CoverageGrid) unit(i int) UnitVector {
+	if g.units32 != nil {
+		u := g.units32[i]
+		return UnitVector{float64(u[0]), float64(u[1]), float64(u[2])}
+	}
+	return g.units[i]
+}
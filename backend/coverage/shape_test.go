@@ -0,0 +1,156 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+// kmToDeg converts a great-circle distance to degrees of arc.
+func kmToDeg(km float64) float64 {
+	return km / EarthRadiusKm * 180 / math.Pi
+}
+
+func TestEllipseContains(t *testing.T) {
+	// An ellipse at the equator with its 1000 km major axis pointing east.
+	f := Footprint{Shape: ShapeEllipse, SemiMajorKm: 1000, SemiMinorKm: 400, OrientationDeg: 90, LinkStrength: 1}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := []struct {
+		lat, lon float64
+		want     bool
+	}{
+		{0, 0, true},
+		{0, kmToDeg(950), true},
+		{0, -kmToDeg(950), true},
+		{0, kmToDeg(1050), false},
+		{kmToDeg(350), 0, true},
+		{-kmToDeg(450), 0, false},
+		// Inside the major-axis circle but off the narrow side.
+		{kmToDeg(500), kmToDeg(500), false},
+	}
+	for _, c := range cases {
+		if got := f.Contains(c.lat, c.lon); got != c.want {
+			t.Fatalf("Contains(%v, %v) = %v, want %v", c.lat, c.lon, got, c.want)
+		}
+	}
+
+	// Turning the major axis north swaps which points are covered.
+	f.OrientationDeg = 0
+	if f.Contains(0, kmToDeg(950)) || !f.Contains(kmToDeg(950), 0) {
+		t.Fatal("expected a north-pointing ellipse to extend along the meridian")
+	}
+	// A circle-shaped ellipse matches a circle of the same radius.
+	round := Footprint{Shape: ShapeEllipse, CenterLat: 40, CenterLon: 10, SemiMajorKm: 800, SemiMinorKm: 800, OrientationDeg: 33}
+	circle := Footprint{CenterLat: 40, CenterLon: 10, RadiusKm: 800}
+	for lat := 30.0; lat <= 50; lat += 0.7 {
+		for lon := -5.0; lon <= 25; lon += 0.7 {
+			if round.Contains(lat, lon) != circle.Contains(lat, lon) {
+				t.Fatalf("round ellipse and circle disagree at %v, %v", lat, lon)
+			}
+		}
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	// An L-shaped beam over Europe, concave at its north-east corner.
+	f := Footprint{Shape: ShapePolygon, Vertices: []Vertex{
+		{Lat: 40, Lon: 0}, {Lat: 40, Lon: 20}, {Lat: 45, Lon: 20},
+		{Lat: 45, Lon: 5}, {Lat: 60, Lon: 5}, {Lat: 60, Lon: 0},
+	}}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := []struct {
+		lat, lon float64
+		want     bool
+	}{
+		{42, 10, true},
+		{55, 2, true},
+		{55, 10, false}, // the notch
+		{38, 10, false},
+		{42, 25, false},
+		{50, -1, false},
+	}
+	for _, c := range cases {
+		if got := f.Contains(c.lat, c.lon); got != c.want {
+			t.Fatalf("Contains(%v, %v) = %v, want %v", c.lat, c.lon, got, c.want)
+		}
+	}
+
+	// Polygons spanning the antimeridian need no special casing.
+	pacific := Footprint{Shape: ShapePolygon, Vertices: []Vertex{{Lat: -10, Lon: 170}, {Lat: -10, Lon: -170}, {Lat: 10, Lon: -170}, {Lat: 10, Lon: 170}}}
+	if !pacific.Contains(0, 180) || !pacific.Contains(5, -175) || pacific.Contains(0, 160) {
+		t.Fatal("unexpected coverage for a polygon across the antimeridian")
+	}
+}
+
+func TestValidateFootprintShapes(t *testing.T) {
+	for _, f := range []Footprint{
+		{Shape: "hexagon"},
+		{Shape: ShapeEllipse, SemiMajorKm: 100},
+		{Shape: ShapeEllipse, SemiMajorKm: 100, SemiMinorKm: 200},
+		{Shape: ShapeEllipse, SemiMajorKm: 200, SemiMinorKm: 100, Auto: true},
+		{Shape: ShapePolygon, Vertices: []Vertex{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 1}}},
+		{Shape: ShapePolygon, Vertices: []Vertex{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 120}, {Lat: 0, Lon: -120}}},
+	} {
+		if f.Validate() == nil {
+			t.Fatalf("expected %+v to be rejected", f)
+		}
+	}
+	if err := (Footprint{RadiusKm: 500, Auto: true}).Validate(); err != nil {
+		t.Fatalf("unexpected error for a circle: %v", err)
+	}
+}
+
+func TestApplyShapedFootprints(t *testing.T) {
+	footprints := []Footprint{
+		{Shape: ShapeEllipse, CenterLat: 10, CenterLon: 30, SemiMajorKm: 2500, SemiMinorKm: 900, OrientationDeg: 60, LinkStrength: 0.7},
+		{Shape: ShapePolygon, LinkStrength: 0.4, Vertices: []Vertex{{Lat: -20, Lon: -60}, {Lat: -20, Lon: -30}, {Lat: 5, Lon: -45}}},
+		{CenterLat: 0, CenterLon: 30, RadiusKm: 1500, LinkStrength: 0.9},
+	}
+	for _, float32 := range []bool{false, true} {
+		grid, err := NewCoverageGrid(GridConfig{LatStep: 2, LonStep: 2, Float32: float32})
+		if err != nil {
+			t.Fatal(err)
+		}
+		grid.ApplyFootprints(footprints)
+		covered := 0
+		for _, cell := range grid.Cells() {
+			count, strongest := 0, 0.0
+			for _, f := range footprints {
+				if f.Contains(cell.Lat, cell.Lon) {
+					count++
+					strongest = math.Max(strongest, f.LinkStrength)
+				}
+			}
+			if cell.CoverageCount != count || cell.StrongestLink != strongest {
+				t.Fatalf("float32=%v: cell %v, %v has %d footprints at %v, want %d at %v", float32, cell.Lat, cell.Lon, cell.CoverageCount, cell.StrongestLink, count, strongest)
+			}
+			if count > 0 {
+				covered++
+			}
+		}
+		if covered == 0 {
+			t.Fatal("expected the footprints to cover some cells")
+		}
+	}
+}
+
+func TestCoverageWithin(t *testing.T) {
+	grid, _ := NewCoverageGrid(GridConfig{LatStep: 2, LonStep: 2})
+	grid.ApplyFootprints([]Footprint{{CenterLat: 0, CenterLon: 0, RadiusKm: 600, LinkStrength: 1}})
+	cells := grid.HeatmapData()
+
+	inside := Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 300}
+	if pct, ok := inside.CoverageWithin(cells); !ok || pct != 100 {
+		t.Fatalf("zone inside the footprint is %v%% covered (%v)", pct, ok)
+	}
+	zone := Footprint{Shape: ShapePolygon, Vertices: []Vertex{{-10, -10}, {-10, 10}, {10, 10}, {10, -10}}}
+	if pct, ok := zone.CoverageWithin(cells); !ok || pct <= 0 || pct >= 50 {
+		t.Fatalf("zone around the footprint is %v%% covered (%v)", pct, ok)
+	}
+	if _, ok := (Footprint{CenterLat: 0.5, CenterLon: 0.5, RadiusKm: 1}).CoverageWithin(cells); ok {
+		t.Fatal("expected a zone between cell centers to have no coverage figure")
+	}
+}
@@ -0,0 +1,111 @@
+package coverage
+
+import (
+	"errors"
+	"math"
+)
+
+// FootprintUncertainty bounds how far a footprint's size and link strength may stray from their
+// nominal values, in percent either way, for early designs whose antenna performance is not yet
+// known. Polygons keep their vertices and vary in strength only.
+type FootprintUncertainty struct {
+	RadiusPct   float64 `json:"radiusPct,omitempty"`
+	StrengthPct float64 `json:"strengthPct,omitempty"`
+}
+
+func (u FootprintUncertainty) validate() error {
+	if u.RadiusPct < 0 || u.RadiusPct >= 100 || u.StrengthPct < 0 || u.StrengthPct >= 100 {
+		return errors.New("footprint uncertainty percentages must be at least 0 and below 100")
+	}
+	return nil
+}
+
+// Range is a statistic at its nominal value and its bounds under footprint uncertainty.
+type Range struct {
+	Min     float64 `json:"min"`
+	Nominal float64 `json:"nominal"`
+	Max     float64 `json:"max"`
+}
+
+// CoverageBounds brackets coverage statistics between every footprint at the low end of its
+// uncertainty and every footprint at the high end. Covered cells only grow with footprint size and
+// strength, so no mix of the footprints' values falls outside CoveredCells or CoveragePercent.
+type CoverageBounds struct {
+	CoveredCells    Range `json:"coveredCells"`
+	CoveragePercent Range `json:"coveragePercent"`
+	// MeanStrength averages the strongest link over covered cells. It is not a bound: a footprint
+	// growing into cells it covers weakly lowers the mean, so mixes of the footprints' values can
+	// fall outside it. Min and Max are the lower and higher of its values at the two ends.
+	MeanStrength Range `json:"meanStrength"`
+}
+
+// Bounded returns the footprint at one end of its uncertainty: shrunk and weakened for a negative
+// side, grown and strengthened for a positive one. Footprints without uncertainty are returned
+// unchanged.
+func (f Footprint) Bounded(side float64) Footprint {
+	if f.Uncertainty == nil {
+		return f
+	}
+	radius := 1 + math.Copysign(f.Uncertainty.RadiusPct/100, side)
+	strength := 1 + math.Copysign(f.Uncertainty.StrengthPct/100, side)
+	f.RadiusKm *= radius
+	f.SemiMajorKm *= radius
+	f.SemiMinorKm *= radius
+	f.LinkStrength *= strength
+	return f
+}
+
+// Bounds brackets nominal, the summary of grid as covered by footprints at their nominal values,
+// with the statistics of grids covered by the footprints at both ends of their uncertainty. cover
+// computes those grids the same way grid was computed; Bounds hands them to ReleaseGrid. It returns
+// nil when no footprint is uncertain.
+func Bounds(nominal Summary, grid *CoverageGrid, footprints []Footprint, cover func([]Footprint) (*CoverageGrid, error)) (*CoverageBounds, error) {
+	uncertain := false
+	for _, f := range footprints {
+		uncertain = uncertain || f.Uncertainty != nil
+	}
+	if !uncertain {
+		return nil, nil
+	}
+	bounds := CoverageBounds{
+		CoveredCells:    Range{Nominal: float64(nominal.CoveredCells)},
+		CoveragePercent: Range{Nominal: nominal.CoveragePercent},
+		MeanStrength:    Range{Nominal: grid.meanStrength(nominal.CoveredCells)},
+	}
+	var strengths [2]float64
+	for i, side := range []float64{-1, 1} {
+		bounded := make([]Footprint, len(footprints))
+		for j, f := range footprints {
+			bounded[j] = f.Bounded(side)
+		}
+		end, err := cover(bounded)
+		if err != nil {
+			return nil, err
+		}
+		summary := end.Summarize()
+		strengths[i] = end.meanStrength(summary.CoveredCells)
+		ReleaseGrid(end)
+
+		cells, percent := float64(summary.CoveredCells), summary.CoveragePercent
+		if side < 0 {
+			bounds.CoveredCells.Min, bounds.CoveragePercent.Min = cells, percent
+		} else {
+			bounds.CoveredCells.Max, bounds.CoveragePercent.Max = cells, percent
+		}
+	}
+	bounds.MeanStrength.Min = math.Min(strengths[0], strengths[1])
+	bounds.MeanStrength.Max = math.Max(strengths[0], strengths[1])
+	return &bounds, nil
+}
+
+// meanStrength averages the strongest link over the grid's covered cells.
+func (g *CoverageGrid) meanStrength(covered int) float64 {
+	if covered == 0 {
+		return 0
+	}
+	strength := 0.0
+	for _, cell := range g.cells {
+		strength += cell.StrongestLink
+	}
+	return strength / float64(covered)
+}
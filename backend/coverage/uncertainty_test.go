@@ -0,0 +1,110 @@
+package coverage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBoundsBracketNominalCoverage(t *testing.T) {
+	config := GridConfig{LatStep: 2, LonStep: 2}
+	footprints := []Footprint{
+		{CenterLat: 10, CenterLon: 20, RadiusKm: 1500, LinkStrength: 4, Uncertainty: &FootprintUncertainty{RadiusPct: 20, StrengthPct: 25}},
+		{CenterLat: -30, CenterLon: 120, Shape: ShapeEllipse, SemiMajorKm: 1200, SemiMinorKm: 600, LinkStrength: 2},
+	}
+	grid, err := NewCoverageGrid(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid.ApplyFootprints(footprints)
+	nominal := grid.Summarize()
+	bounds, err := Bounds(nominal, grid, footprints, coverLocally(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds == nil {
+		t.Fatal("expected bounds for an uncertain footprint")
+	}
+	if bounds.CoveredCells.Nominal != float64(nominal.CoveredCells) || bounds.CoveragePercent.Nominal != nominal.CoveragePercent {
+		t.Fatalf("expected nominal bounds to match the summary %+v, got %+v", nominal, bounds)
+	}
+	for name, r := range map[string]Range{
+		"coveredCells":    bounds.CoveredCells,
+		"coveragePercent": bounds.CoveragePercent,
+		"meanStrength":    bounds.MeanStrength,
+	} {
+		if !(r.Min < r.Nominal && r.Nominal < r.Max) {
+			t.Fatalf("expected %s to widen strictly around the nominal value, got %+v", name, r)
+		}
+	}
+}
+
+func TestBoundedScalesFootprint(t *testing.T) {
+	f := Footprint{RadiusKm: 1000, SemiMajorKm: 800, SemiMinorKm: 400, LinkStrength: 2, Uncertainty: &FootprintUncertainty{RadiusPct: 10, StrengthPct: 50}}
+	low, high := f.Bounded(-1), f.Bounded(1)
+	if math.Abs(low.RadiusKm-900) > 1e-9 || math.Abs(high.SemiMajorKm-880) > 1e-9 || math.Abs(high.SemiMinorKm-440) > 1e-9 {
+		t.Fatalf("expected sizes scaled by 10%%, got %+v and %+v", low, high)
+	}
+	if low.LinkStrength != 1 || high.LinkStrength != 3 {
+		t.Fatalf("expected strengths of 1 and 3, got %v and %v", low.LinkStrength, high.LinkStrength)
+	}
+	if certain := (Footprint{RadiusKm: 1000}); certain.Bounded(1).RadiusKm != 1000 {
+		t.Fatal("expected footprints without uncertainty to be unchanged")
+	}
+}
+
+// coverLocally covers grids of config in process, as a simulator without a sharder does.
+func coverLocally(config GridConfig) func([]Footprint) (*CoverageGrid, error) {
+	return func(footprints []Footprint) (*CoverageGrid, error) {
+		grid, err := AcquireGrid(config)
+		if err != nil {
+			return nil, err
+		}
+		grid.ApplyFootprints(footprints)
+		return grid, nil
+	}
+}
+
+func TestMeanStrengthIsNotBoundedByTheEnds(t *testing.T) {
+	config := GridConfig{LatStep: 2, LonStep: 2}
+	strong := Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 1500, LinkStrength: 1, Uncertainty: &FootprintUncertainty{StrengthPct: 50}}
+	weak := Footprint{CenterLat: 0, CenterLon: 120, RadiusKm: 2000, LinkStrength: 0.1, Uncertainty: &FootprintUncertainty{RadiusPct: 50}}
+	footprints := []Footprint{strong, weak}
+	cover := coverLocally(config)
+	grid, _ := cover(footprints)
+	bounds, err := Bounds(grid.Summarize(), grid, footprints, cover)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A strong footprint at its high end beside a weak one shrunk to its low end.
+	mixed, _ := cover([]Footprint{strong.Bounded(1), weak.Bounded(-1)})
+	summary := mixed.Summarize()
+	if cells := float64(summary.CoveredCells); cells < bounds.CoveredCells.Min || cells > bounds.CoveredCells.Max {
+		t.Fatalf("expected %v covered cells within %+v", cells, bounds.CoveredCells)
+	}
+	if mean := mixed.meanStrength(summary.CoveredCells); mean <= bounds.MeanStrength.Max {
+		t.Fatalf("expected the mixed mean strength %v above both ends %+v", mean, bounds.MeanStrength)
+	}
+}
+
+func TestBoundsWithoutUncertainty(t *testing.T) {
+	config := GridConfig{LatStep: 10, LonStep: 10}
+	footprints := []Footprint{{RadiusKm: 1000, LinkStrength: 1}}
+	grid, _ := coverLocally(config)(footprints)
+	bounds, err := Bounds(grid.Summarize(), grid, footprints, coverLocally(config))
+	if err != nil || bounds != nil {
+		t.Fatalf("expected no bounds, got %+v, %v", bounds, err)
+	}
+}
+
+func TestFootprintUncertaintyValidation(t *testing.T) {
+	for _, u := range []FootprintUncertainty{{RadiusPct: -1}, {RadiusPct: 100}, {StrengthPct: 150}} {
+		u := u
+		if err := (Footprint{RadiusKm: 100, Uncertainty: &u}).Validate(); err == nil {
+			t.Fatalf("expected %+v to be rejected", u)
+		}
+	}
+	if err := (Footprint{RadiusKm: 100, Uncertainty: &FootprintUncertainty{RadiusPct: 15}}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
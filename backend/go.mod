@@ -1,3 +1,10 @@
 module github.com/example/satnet/backend
 
 go 1.21
+
+require (
+	golang.org/x/crypto v0.24.0
+	golang.org/x/net v0.21.0
+)
+
+require golang.org/x/text v0.16.0 // indirect
@@ -0,0 +1,282 @@
+// Package alert evaluates user-defined threshold rules against session snapshots, tracking each
+// alert from pending through firing to resolution.
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// Metrics a rule can watch.
+const (
+	// MetricCoverage is the coverage percentage of the whole grid, or of Rule.Zone when set.
+	MetricCoverage = "coverage"
+	// MetricLatency is Rule.Demand's route latency in milliseconds; an unrouted demand counts as
+	// infinitely slow.
+	MetricLatency = "latency"
+)
+
+// Alert states.
+const (
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// maxResolved bounds how many resolved alerts are kept for GET /alerts?state=resolved.
+const maxResolved = 100
+
+// Rule raises an alert while Metric compares to Threshold by Op for at least ForS seconds of
+// simulation time.
+type Rule struct {
+	ID        string              `json:"id"`
+	Name      string              `json:"name,omitempty"`
+	Session   string              `json:"session,omitempty"` // empty for every session
+	Metric    string              `json:"metric"`
+	Zone      *coverage.Footprint `json:"zone,omitempty"`
+	Demand    string              `json:"demand,omitempty"`
+	Op        string              `json:"op"` // "<" or ">"
+	Threshold float64             `json:"threshold"`
+	ForS      float64             `json:"forS,omitempty"`
+	Created   time.Time           `json:"created"`
+}
+
+// Alert is one rule's violation in one session. Value is the latest measurement, absent while a
+// latency rule's demand has no route. Since and the transition times are simulation times, so
+// alerts line up with the snapshots that caused them.
+type Alert struct {
+	Rule       string     `json:"rule"`
+	Name       string     `json:"name,omitempty"`
+	Session    string     `json:"session"`
+	State      string     `json:"state"`
+	Value      *float64   `json:"value,omitempty"`
+	Since      time.Time  `json:"since"`
+	FiredAt    *time.Time `json:"firedAt,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// Validate checks that the rule names a known metric with the parameters it needs.
+func (r Rule) Validate() error {
+	switch r.Metric {
+	case MetricCoverage:
+		if r.Demand != "" {
+			return errors.New("coverage rules do not take a demand")
+		}
+		if r.Zone != nil {
+			if err := r.Zone.Validate(); err != nil {
+				return fmt.Errorf("zone: %w", err)
+			}
+			if (r.Zone.Shape == "" || r.Zone.Shape == coverage.ShapeCircle) && r.Zone.RadiusKm <= 0 {
+				return errors.New("zone: circular zones need a positive radiusKm")
+			}
+		}
+	case MetricLatency:
+		if r.Demand == "" {
+			return errors.New("latency rules need a demand")
+		}
+		if r.Zone != nil {
+			return errors.New("latency rules do not take a zone")
+		}
+	default:
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	if r.Op != "<" && r.Op != ">" {
+		return fmt.Errorf("op must be < or >, not %q", r.Op)
+	}
+	if math.IsNaN(r.Threshold) || math.IsInf(r.Threshold, 0) {
+		return errors.New("threshold must be finite")
+	}
+	if r.ForS < 0 {
+		return errors.New("duration cannot be negative")
+	}
+	return nil
+}
+
+// value measures the rule's metric, reporting false when the snapshot cannot answer it.
+func (r *Rule) value(snap simulation.Snapshot) (float64, bool) {
+	switch r.Metric {
+	case MetricCoverage:
+		if r.Zone == nil {
+			return snap.Coverage.CoveragePercent, true
+		}
+		return r.Zone.CoverageWithin(snap.Heatmap)
+	case MetricLatency:
+		path, ok := snap.Routes[r.Demand]
+		if !ok {
+			return math.Inf(1), true
+		}
+		return path.LatencyMS, true
+	}
+	return 0, false
+}
+
+func (r *Rule) violated(value float64) bool {
+	if r.Op == "<" {
+		return value < r.Threshold
+	}
+	return value > r.Threshold
+}
+
+type alertKey struct{ rule, session string }
+
+// Engine holds the rules and the alerts they have raised.
+type Engine struct {
+	mu       sync.Mutex
+	rules    map[string]*Rule
+	active   map[alertKey]*Alert
+	resolved []Alert // oldest first
+}
+
+// New returns an engine with no rules.
+func New() *Engine {
+	return &Engine{rules: make(map[string]*Rule), active: make(map[alertKey]*Alert)}
+}
+
+// AddRule validates and adds a rule, returning it with its assigned ID.
+func (e *Engine) AddRule(r Rule) (Rule, error) {
+	if err := r.Validate(); err != nil {
+		return Rule{}, err
+	}
+	r.ID = newID()
+	r.Created = time.Now().UTC()
+	stored := r
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules[r.ID] = &stored
+	return r, nil
+}
+
+// Rules lists the rules, oldest first.
+func (e *Engine) Rules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Rule, 0, len(e.rules))
+	for _, r := range e.rules {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out
+}
+
+// DeleteRule removes a rule and its open alerts, reporting false when there is no such rule.
+func (e *Engine) DeleteRule(id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.rules[id]; !ok {
+		return false
+	}
+	delete(e.rules, id)
+	for key := range e.active {
+		if key.rule == id {
+			delete(e.active, key)
+		}
+	}
+	return true
+}
+
+// Observe evaluates every rule that applies to session against its latest snapshot. It returns
+// the alerts that started firing or were resolved by this snapshot.
+func (e *Engine) Observe(session string, snap simulation.Snapshot) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var transitions []Alert
+	for _, r := range e.rules {
+		if r.Session != "" && r.Session != session {
+			continue
+		}
+		key := alertKey{r.ID, session}
+		value, ok := r.value(snap)
+		current := e.active[key]
+		if !ok || !r.violated(value) {
+			if current != nil {
+				delete(e.active, key)
+				if current.State == StateFiring {
+					resolved := *current
+					resolved.State, resolved.Value, resolved.ResolvedAt = StateResolved, measured(value, ok), timePtr(snap.SimTime)
+					e.resolved = append(e.resolved, resolved)
+					if len(e.resolved) > maxResolved {
+						e.resolved = e.resolved[1:]
+					}
+					transitions = append(transitions, resolved)
+				}
+			}
+			continue
+		}
+
+		if current == nil {
+			current = &Alert{Rule: r.ID, Name: r.Name, Session: session, State: StatePending, Since: snap.SimTime}
+			e.active[key] = current
+		}
+		current.Value = measured(value, true)
+		if current.State == StatePending && snap.SimTime.Sub(current.Since).Seconds() >= r.ForS {
+			current.State, current.FiredAt = StateFiring, timePtr(snap.SimTime)
+			transitions = append(transitions, *current)
+		}
+	}
+	return transitions
+}
+
+// Forget drops the open alerts of a deleted session without resolving them.
+func (e *Engine) Forget(session string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key := range e.active {
+		if key.session == session {
+			delete(e.active, key)
+		}
+	}
+}
+
+// Active lists pending and firing alerts, longest-standing first.
+func (e *Engine) Active() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Alert, 0, len(e.active))
+	for _, a := range e.active {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Since.Equal(out[j].Since) {
+			return out[i].Since.Before(out[j].Since)
+		}
+		return out[i].Rule+out[i].Session < out[j].Rule+out[j].Session
+	})
+	return out
+}
+
+// Resolved lists the most recently resolved alerts, oldest first.
+func (e *Engine) Resolved() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Alert{}, e.resolved...)
+}
+
+// measured reports a rule's value for JSON, which cannot encode the infinite latency of an
+// unrouted demand.
+func measured(value float64, ok bool) *float64 {
+	if !ok || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("r-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
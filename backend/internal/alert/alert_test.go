@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func snapshotAt(minute int, coveragePct float64, routes map[string]routing.Path) simulation.Snapshot {
+	return simulation.Snapshot{
+		SimTime:  epoch.Add(time.Duration(minute) * time.Minute),
+		Coverage: coverage.Summary{CoveragePercent: coveragePct},
+		Routes:   routes,
+	}
+}
+
+func TestRuleFiresAfterDurationAndResolves(t *testing.T) {
+	e := New()
+	rule, err := e.AddRule(Rule{Name: "low coverage", Metric: MetricCoverage, Op: "<", Threshold: 95, ForS: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := e.Observe("s1", snapshotAt(0, 90, nil)); len(got) != 0 {
+		t.Fatalf("expected a pending alert only, got transitions %+v", got)
+	}
+	if active := e.Active(); len(active) != 1 || active[0].State != StatePending {
+		t.Fatalf("expected one pending alert, got %+v", active)
+	}
+	if got := e.Observe("s1", snapshotAt(3, 91, nil)); len(got) != 0 {
+		t.Fatalf("alert fired before its duration: %+v", got)
+	}
+	got := e.Observe("s1", snapshotAt(5, 92, nil))
+	if len(got) != 1 || got[0].State != StateFiring || got[0].Rule != rule.ID || *got[0].Value != 92 {
+		t.Fatalf("expected the alert to fire at five minutes, got %+v", got)
+	}
+	if !got[0].Since.Equal(epoch) || !got[0].FiredAt.Equal(epoch.Add(5*time.Minute)) {
+		t.Fatalf("unexpected alert times %+v", got[0])
+	}
+
+	got = e.Observe("s1", snapshotAt(6, 99, nil))
+	if len(got) != 1 || got[0].State != StateResolved || !got[0].ResolvedAt.Equal(epoch.Add(6*time.Minute)) {
+		t.Fatalf("expected the alert to resolve, got %+v", got)
+	}
+	if len(e.Active()) != 0 || len(e.Resolved()) != 1 {
+		t.Fatalf("expected the alert to move to resolved: active %+v resolved %+v", e.Active(), e.Resolved())
+	}
+}
+
+func TestPendingAlertClearsWithoutResolving(t *testing.T) {
+	e := New()
+	e.AddRule(Rule{Metric: MetricCoverage, Op: "<", Threshold: 95, ForS: 300})
+	e.Observe("s1", snapshotAt(0, 90, nil))
+	if got := e.Observe("s1", snapshotAt(1, 97, nil)); len(got) != 0 {
+		t.Fatalf("a pending alert should clear silently, got %+v", got)
+	}
+	if len(e.Active()) != 0 || len(e.Resolved()) != 0 {
+		t.Fatal("expected no alerts left")
+	}
+}
+
+func TestLatencyRuleTreatsMissingRouteAsViolation(t *testing.T) {
+	e := New()
+	e.AddRule(Rule{Metric: MetricLatency, Demand: "d1", Op: ">", Threshold: 80, Session: "s1"})
+
+	if got := e.Observe("s1", snapshotAt(0, 100, map[string]routing.Path{"d1": {LatencyMS: 40}})); len(got) != 0 {
+		t.Fatalf("fast route raised %+v", got)
+	}
+	if got := e.Observe("s2", snapshotAt(0, 100, nil)); len(got) != 0 {
+		t.Fatalf("rule scoped to s1 fired for s2: %+v", got)
+	}
+	if got := e.Observe("s1", snapshotAt(1, 100, nil)); len(got) != 1 || got[0].State != StateFiring || got[0].Value != nil {
+		t.Fatalf("expected an unrouted demand to fire immediately, got %+v", got)
+	}
+}
+
+func TestZoneRuleUsesCellsInsideZone(t *testing.T) {
+	e := New()
+	zone := coverage.Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 500}
+	e.AddRule(Rule{Metric: MetricCoverage, Zone: &zone, Op: "<", Threshold: 50})
+
+	snap := snapshotAt(0, 100, nil)
+	snap.Heatmap = []coverage.HeatmapCell{{Lat: 0, Lon: 0, Covered: false}, {Lat: 40, Lon: 40, Covered: true}}
+	if got := e.Observe("s1", snap); len(got) != 1 || *got[0].Value != 0 {
+		t.Fatalf("expected the uncovered zone to fire despite full grid coverage, got %+v", got)
+	}
+}
+
+func TestRuleValidation(t *testing.T) {
+	e := New()
+	for _, r := range []Rule{
+		{Metric: "jitter", Op: "<"},
+		{Metric: MetricCoverage, Op: "="},
+		{Metric: MetricLatency, Op: ">"},
+		{Metric: MetricCoverage, Op: "<", ForS: -1},
+		{Metric: MetricCoverage, Op: "<", Zone: &coverage.Footprint{}},
+	} {
+		if _, err := e.AddRule(r); err == nil {
+			t.Errorf("expected %+v to be rejected", r)
+		}
+	}
+	if len(e.Rules()) != 0 {
+		t.Fatal("invalid rules were stored")
+	}
+}
+
+func TestDeleteRuleAndForgetDropOpenAlerts(t *testing.T) {
+	e := New()
+	a, _ := e.AddRule(Rule{Metric: MetricCoverage, Op: "<", Threshold: 95})
+	e.AddRule(Rule{Metric: MetricCoverage, Op: "<", Threshold: 99})
+	e.Observe("s1", snapshotAt(0, 90, nil))
+	e.Observe("s2", snapshotAt(0, 90, nil))
+	if len(e.Active()) != 4 {
+		t.Fatalf("expected four alerts, got %+v", e.Active())
+	}
+	if !e.DeleteRule(a.ID) || e.DeleteRule(a.ID) {
+		t.Fatal("expected the rule to delete exactly once")
+	}
+	e.Forget("s2")
+	if active := e.Active(); len(active) != 1 || active[0].Session != "s1" {
+		t.Fatalf("expected one alert left for s1, got %+v", active)
+	}
+}
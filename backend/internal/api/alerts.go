@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/internal/alert"
+)
+
+type alertRuleRequest struct {
+	Name      string              `json:"name,omitempty"`
+	Session   string              `json:"session,omitempty"`
+	Metric    string              `json:"metric"`
+	Zone      *coverage.Footprint `json:"zone,omitempty"`
+	Demand    string              `json:"demand,omitempty"`
+	Op        string              `json:"op"`
+	Threshold float64             `json:"threshold"`
+	ForS      float64             `json:"forS,omitempty"`
+}
+
+type alertRuleListResponse struct {
+	Rules []alert.Rule `json:"rules"`
+}
+
+type alertListResponse struct {
+	Alerts []alert.Alert `json:"alerts"`
+}
+
+// alertsHandler serves GET /alerts: the pending and firing alerts, or with ?state=resolved the
+// most recently resolved ones.
+func (s *Server) alertsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	switch state := r.URL.Query().Get("state"); state {
+	case "", "active":
+		writeJSON(w, r, alertListResponse{Alerts: s.alerts.Active()})
+	case alert.StateResolved:
+		writeJSON(w, r, alertListResponse{Alerts: s.alerts.Resolved()})
+	default:
+		writeError(w, r, invalidArgument("state", "state must be active or resolved"))
+	}
+}
+
+// alertRulesHandler serves POST /alerts/rules, adding a rule, and GET /alerts/rules.
+func (s *Server) alertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, r, alertRuleListResponse{Rules: s.alerts.Rules()})
+		return
+	}
+
+	var req alertRuleRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, invalidArgument("body", "decode alert rule: "+err.Error()))
+		return
+	}
+	rule, err := s.alerts.AddRule(alert.Rule{
+		Name: req.Name, Session: req.Session, Metric: req.Metric, Zone: req.Zone, Demand: req.Demand,
+		Op: req.Op, Threshold: req.Threshold, ForS: req.ForS,
+	})
+	if err != nil {
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+	w.Header().Set("Location", "/alerts/rules/"+rule.ID)
+	writeJSONStatus(w, r, http.StatusCreated, rule)
+}
+
+// alertRuleHandler serves DELETE /alerts/rules/{id}.
+func (s *Server) alertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/alerts/rules/"), "/")
+	if !allowMethods(w, r, http.MethodDelete) {
+		return
+	}
+	if !s.alerts.DeleteRule(id) {
+		writeError(w, r, notFound("unknown alert rule "+id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/internal/store"
+)
+
+const defaultAuditLimit = 100
+
+// maxAuditBodyBytes is the largest payload an audit entry keeps; larger ones keep only their size
+// and digest.
+const maxAuditBodyBytes = 4 << 10
+
+// auditRedacted lists the payload keys, matched case-insensitively at any depth, whose values are
+// never recorded.
+var auditRedacted = map[string]bool{"secret": true, "token": true, "password": true}
+
+type auditResponse struct {
+	Entries []store.AuditEntry `json:"entries"`
+}
+
+// audit records every mutating request with its caller, payload, outcome, and resulting snapshot
+// version. The caller is taken from the X-Operator header so shared sessions remain attributable.
+func (s *Server) audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Capture the payload as the handler reads it, then whatever it left unread.
+		payload := &auditPayload{hash: sha256.New()}
+		body := r.Body
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(body, payload), body}
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		_, _ = io.Copy(payload, io.LimitReader(body, maxBundleBytes))
+
+		actor := r.Header.Get("X-Operator")
+		if actor == "" {
+			actor = "anonymous"
+		}
+		entry := store.AuditEntry{
+			Time:   time.Now().UTC(),
+			Actor:  actor,
+			Method: r.Method,
+			Path:   r.URL.RequestURI(),
+			Status: rec.status,
+		}
+		payload.fill(&entry)
+		if rec.status < 300 {
+			if version, err := parseETag(w.Header().Get("ETag")); err == nil {
+				entry.SnapshotVersion = version
+			}
+		}
+		if _, err := s.store.AppendAudit(entry); err != nil {
+			log.Printf("failed to record audit entry: %v", err)
+		}
+	})
+}
+
+// auditPayload keeps the start of a request body along with its size and digest.
+type auditPayload struct {
+	hash hash.Hash
+	head bytes.Buffer
+	size int64
+}
+
+func (p *auditPayload) Write(b []byte) (int, error) {
+	if room := maxAuditBodyBytes + 1 - p.head.Len(); room > 0 {
+		if room > len(b) {
+			room = len(b)
+		}
+		p.head.Write(b[:room])
+	}
+	p.size += int64(len(b))
+	return p.hash.Write(b)
+}
+
+func (p *auditPayload) fill(entry *store.AuditEntry) {
+	if p.size == 0 {
+		return
+	}
+	entry.BodyBytes = p.size
+	entry.BodySHA256 = hex.EncodeToString(p.hash.Sum(nil))
+	if p.size <= maxAuditBodyBytes {
+		entry.Body = redactPayload(p.head.Bytes())
+	}
+}
+
+// redactPayload returns a JSON payload with the values of auditRedacted keys replaced, or any
+// other payload as a JSON string.
+func redactPayload(raw []byte) json.RawMessage {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		encoded, _ := json.Marshal(string(raw))
+		return encoded
+	}
+	encoded, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if auditRedacted[strings.ToLower(key)] {
+				v[key] = "[redacted]"
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// auditHandler lists audit entries; ?after= pages by entry ID and ?limit= caps the result size.
+func (s *Server) auditHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	query := r.URL.Query()
+	var after uint64
+	if raw := query.Get("after"); raw != "" {
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, r, invalidArgument("after", "after must be a non-negative integer"))
+			return
+		}
+		after = v
+	}
+	limit := defaultAuditLimit
+	if raw := query.Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			writeError(w, r, invalidArgument("limit", "limit must be a positive integer"))
+			return
+		}
+		limit = v
+	}
+
+	entries, err := s.store.ListAudit(after, limit)
+	if err != nil {
+		log.Printf("failed to list audit entries: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	writeJSON(w, r, auditResponse{Entries: entries})
+}
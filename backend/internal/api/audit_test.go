@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/simulation"
+)
+
+func TestAuditRecordsTheRedactedPayload(t *testing.T) {
+	st := store.NewMemory()
+	handler := NewServer(Options{}, simulation.NewDemoSimulator(), st).Handler()
+	body := `{"url": "http://example.com/hook", "secret": "s3cret"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set("X-Operator", "alice")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	entries, err := st.ListAudit(0, 0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %+v (%v)", entries, err)
+	}
+	entry := entries[0]
+	if entry.Actor != "alice" || entry.BodyBytes != int64(len(body)) || len(entry.BodySHA256) != 64 {
+		t.Fatalf("expected the actor and payload digest, got %+v", entry)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(entry.Body, &payload); err != nil {
+		t.Fatalf("expected a JSON payload, got %s: %v", entry.Body, err)
+	}
+	if payload["url"] != "http://example.com/hook" || payload["secret"] != "[redacted]" {
+		t.Fatalf("expected the URL kept and the secret redacted, got %v", payload)
+	}
+}
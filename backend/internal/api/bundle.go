@@ -0,0 +1,94 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+// maxBundleBytes bounds an uploaded session bundle.
+const maxBundleBytes = 512 << 20
+
+const bundleContentType = "application/zip"
+
+// writeBundle serves GET /sessions/{id}/bundle, exporting the session as a zip archive that
+// POST /sessions can import.
+func writeBundle(w http.ResponseWriter, r *http.Request, id string, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	var buf bytes.Buffer
+	if err := simulation.WriteBundle(&buf, sim.Bundle()); err != nil {
+		log.Printf("failed to export session %s: %v", id, err)
+		writeError(w, r, internalError())
+		return
+	}
+	w.Header().Set("Content-Type", bundleContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "satnet-" + id + ".zip"}))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("failed to write bundle for session %s: %v", id, err)
+	}
+}
+
+// isBundleUpload reports whether a POST /sessions body is a session bundle rather than a scenario.
+func isBundleUpload(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == bundleContentType
+}
+
+// importSession creates a session from an uploaded bundle by replaying its events against its
+// scenario, rejecting bundles whose replay does not reach the state they captured.
+func (s *Server) importSession(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, invalidArgument("body", "bundle exceeds "+strconv.Itoa(maxBundleBytes)+" bytes"))
+			return
+		}
+		writeError(w, r, invalidArgument("body", "read bundle: "+err.Error()))
+		return
+	}
+	bundle, err := simulation.ReadBundle(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+
+	var sess *session
+	var replayErr error
+	if !s.compute(w, r, func() {
+		if sess, err = s.sessions.create(bundle.Scenario, s.visitor(r)); err != nil {
+			return
+		}
+		if replayErr = sess.sim.Replay(bundle.Events); replayErr == nil {
+			replayErr = bundle.Reproduces(sess.sim.Snapshot())
+		}
+		if replayErr != nil {
+			s.sessions.remove(sess.id)
+		}
+	}) {
+		return
+	}
+	if err != nil {
+		writeError(w, r, sessionCreateError(err))
+		return
+	}
+	if replayErr != nil {
+		writeError(w, r, invalidArgument("body", replayErr.Error()))
+		return
+	}
+	s.watch(sess)
+	snap := sess.sim.Snapshot()
+	s.traceRecompute(r.Context(), sess.sim, snap.Version)
+	w.Header().Set("Location", "/sessions/"+sess.id)
+	w.Header().Set("ETag", snapshotETag(snap))
+	writeJSONStatus(w, r, http.StatusCreated, sessionResponse{ID: sess.id, Snapshot: newSnapshotDTO(snap)})
+}
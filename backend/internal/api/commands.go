@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+type commandsResponse struct {
+	Commands []simulation.Command `json:"commands"`
+}
+
+type contactsResponse struct {
+	Tracks []simulation.AntennaTrack `json:"tracks"`
+	Passes []simulation.Pass         `json:"passes"`
+}
+
+type commandResponse struct {
+	Message string             `json:"message"`
+	Command simulation.Command `json:"command"`
+}
+
+func (s *Server) commandsHandler(w http.ResponseWriter, r *http.Request) {
+	writeCommands(w, r, s.sim)
+}
+
+// writeCommands lists the commands issued to a simulator's satellites over the TT&C path.
+func writeCommands(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	if !sim.TTCModeled() {
+		writeError(w, r, notFound("the scenario does not model TT&C; commands apply immediately"))
+		return
+	}
+	writeJSON(w, r, commandsResponse{Commands: sim.Commands()})
+}
+
+func (s *Server) contactsHandler(w http.ResponseWriter, r *http.Request) {
+	writeContacts(w, r, s.sim)
+}
+
+// writeContacts reports a simulator's command-station antenna schedule and the passes it served.
+func writeContacts(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	tracks, passes, err := sim.Contacts()
+	if err != nil {
+		writeError(w, r, notFound("the scenario does not model TT&C"))
+		return
+	}
+	writeJSON(w, r, contactsResponse{Tracks: tracks, Passes: passes})
+}
+
+// issueCommand queues a satellite command on the TT&C path and answers 202 Accepted, since the
+// change only takes effect once a later step has delivered and executed it.
+func (s *Server) issueCommand(w http.ResponseWriter, r *http.Request, issue func() (simulation.Command, error)) {
+	var cmd simulation.Command
+	var err error
+	if !s.compute(w, r, func() { cmd, err = issue() }) {
+		return
+	}
+	switch {
+	case errors.Is(err, simulation.ErrUnknownSatellite):
+		writeError(w, r, notFound(err.Error()))
+		return
+	case err != nil:
+		log.Printf("command failed: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	writeJSONStatus(w, r, http.StatusAccepted, commandResponse{Message: "command queued", Command: cmd})
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/internal/eventsink"
+	"github.com/example/satnet/backend/internal/mqtt"
+	"github.com/example/satnet/backend/internal/tracing"
+	"github.com/example/satnet/backend/internal/worker"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// registerDebug mounts the admin-only diagnostics under /debug/. Without an admin token they are
+// not served at all.
+func (s *Server) registerDebug(mux *http.ServeMux) {
+	if s.opts.AdminToken == "" {
+		return
+	}
+	mux.Handle("/debug/pprof/", s.requireAdmin(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", s.requireAdmin(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", s.requireAdmin(captureHandler(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", s.requireAdmin(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", s.requireAdmin(captureHandler(pprof.Trace)))
+	mux.Handle("/debug/simstats", s.requireAdmin(http.HandlerFunc(s.simstatsHandler)))
+	mux.Handle("/debug/visibility", s.requireAdmin(http.HandlerFunc(s.visibilityHandler)))
+	mux.Handle("/debug/invariants", s.requireAdmin(http.HandlerFunc(s.invariantsHandler)))
+}
+
+// captureHandler serves a pprof capture that records for ?seconds= (pprof's default when absent),
+// moving the write deadline past the end of the capture.
+func captureHandler(capture http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seconds, err := strconv.ParseFloat(r.FormValue("seconds"), 64)
+		if err != nil || seconds <= 0 {
+			seconds = 30
+		}
+		extendWriteDeadline(w, time.Duration(seconds*float64(time.Second))+writeTimeout)
+		capture(w, r)
+	})
+}
+
+// requireAdmin admits requests carrying "Authorization: Bearer <admin token>".
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return requireBearer("satnet-admin", s.opts.AdminToken, next)
+}
+
+// requireBearer admits requests carrying "Authorization: Bearer <token>"; an empty token admits none.
+func requireBearer(realm, want string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || want == "" || subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm+`"`)
+			writeError(w, r, apiError{status: http.StatusUnauthorized, Code: codeUnauthenticated, Message: strings.TrimPrefix(realm, "satnet-") + " token required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type phaseTimingsDTO struct {
+	VisibilityMS float64 `json:"visibilityMs"`
+	CoverageMS   float64 `json:"coverageMs"`
+	ShardedMS    float64 `json:"shardedMs,omitempty"`
+	RoutingMS    float64 `json:"routingMs"`
+	SnapshotMS   float64 `json:"snapshotMs"`
+	TotalMS      float64 `json:"totalMs"`
+}
+
+type sessionStatsDTO struct {
+	ID               string          `json:"id"`
+	Satellites       int             `json:"satellites"`
+	Recomputes       uint64          `json:"recomputes"`
+	Last             phaseTimingsDTO `json:"last"`
+	Mean             phaseTimingsDTO `json:"mean"`
+	Max              phaseTimingsDTO `json:"max"`
+	HistorySnapshots int             `json:"historySnapshots"`
+	HistoryBytes     int64           `json:"historyBytes"`
+	// Events lists each event subscriber with its backpressure policy and drop counters.
+	Events []simulation.SubscriberStats `json:"events"`
+}
+
+type simstatsResponse struct {
+	Sessions  []sessionStatsDTO `json:"sessions"`
+	Workers   worker.Stats      `json:"workers"`
+	EventSink *eventsink.Stats  `json:"eventSink,omitempty"`
+	MQTT      *mqtt.Stats       `json:"mqtt,omitempty"`
+	Tracing   *tracing.Stats    `json:"tracing,omitempty"`
+}
+
+// simstatsHandler serves GET /debug/simstats: per-session recompute timings by phase plus worker
+// pool utilization.
+func (s *Server) simstatsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	resp := simstatsResponse{Sessions: []sessionStatsDTO{}, Workers: s.pool.Stats()}
+	if s.sink != nil {
+		stats := s.sink.Stats()
+		resp.EventSink = &stats
+	}
+	if s.opts.MQTT != nil {
+		stats := s.opts.MQTT.Stats()
+		resp.MQTT = &stats
+	}
+	if s.opts.Tracer != nil {
+		stats := s.opts.Tracer.Stats()
+		resp.Tracing = &stats
+	}
+	for _, sess := range s.sessions.list() {
+		stats := sess.sim.RecomputeStats()
+		snap := sess.sim.Snapshot()
+		history, historyBytes := sess.sim.HistorySummaries()
+		resp.Sessions = append(resp.Sessions, sessionStatsDTO{
+			ID:               sess.id,
+			Satellites:       len(snap.ActiveSatellites) + len(snap.DisabledSatellites),
+			Recomputes:       stats.Count,
+			Last:             newPhaseTimingsDTO(stats.Last),
+			Mean:             newPhaseTimingsDTO(stats.Mean),
+			Max:              newPhaseTimingsDTO(stats.Max),
+			HistorySnapshots: len(history),
+			HistoryBytes:     historyBytes,
+			Events:           sess.sim.EventStats(),
+		})
+	}
+	writeJSON(w, r, resp)
+}
+
+// visibilityHandler serves GET /debug/visibility?from=&to=: why two nodes of a session (?session=,
+// default the default session) can or cannot link.
+func (s *Server) visibilityHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	sessionID := query.Get("session")
+	if sessionID == "" {
+		sessionID = defaultSessionID
+	}
+	sess, ok := s.sessions.get(sessionID)
+	if !ok {
+		writeError(w, r, notFound("unknown session "+sessionID))
+		return
+	}
+	from, to := query.Get("from"), query.Get("to")
+	if from == "" {
+		writeError(w, r, invalidArgument("from", "from is required"))
+		return
+	}
+	if to == "" {
+		writeError(w, r, invalidArgument("to", "to is required"))
+		return
+	}
+
+	check, err := sess.sim.CheckVisibility(from, to)
+	switch {
+	case errors.Is(err, routing.ErrUnknownNode):
+		writeError(w, r, notFound(err.Error()))
+		return
+	case err != nil:
+		writeError(w, r, invalidArgument("to", err.Error()))
+		return
+	}
+	writeJSON(w, r, check)
+}
+
+func newPhaseTimingsDTO(t simulation.PhaseTimings) phaseTimingsDTO {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return phaseTimingsDTO{
+		VisibilityMS: ms(t.Visibility),
+		CoverageMS:   ms(t.Coverage),
+		ShardedMS:    ms(t.Sharded),
+		RoutingMS:    ms(t.Routing),
+		SnapshotMS:   ms(t.Snapshot),
+		TotalMS:      ms(t.Total),
+	}
+}
+
+type sessionInvariantsDTO struct {
+	ID string `json:"id"`
+	OK bool   `json:"ok"`
+	simulation.InvariantReport
+}
+
+type invariantsResponse struct {
+	OK       bool                   `json:"ok"`
+	Sessions []sessionInvariantsDTO `json:"sessions"`
+}
+
+// invariantsHandler serves GET /debug/invariants: the simulator invariants checked against the
+// latest recompute of every session, or only ?session=.
+func (s *Server) invariantsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	sessions := s.sessions.list()
+	if id := r.URL.Query().Get("session"); id != "" {
+		sess, ok := s.sessions.get(id)
+		if !ok {
+			writeError(w, r, notFound("unknown session "+id))
+			return
+		}
+		sessions = []*session{sess}
+	}
+	resp := invariantsResponse{OK: true, Sessions: []sessionInvariantsDTO{}}
+	for _, sess := range sessions {
+		report := sess.sim.CheckInvariants()
+		if report.Violations == nil {
+			report.Violations = []simulation.InvariantViolation{}
+		}
+		resp.OK = resp.OK && report.OK()
+		resp.Sessions = append(resp.Sessions, sessionInvariantsDTO{ID: sess.id, OK: report.OK(), InvariantReport: report})
+	}
+	writeJSON(w, r, resp)
+}
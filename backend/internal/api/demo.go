@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DemoOptions turn the server into a public demo: the default session and every shared resource
+// are read-only, and visitors experiment in sessions of their own that expire when left idle.
+type DemoOptions struct {
+	Enabled bool
+	// SessionIdle removes a visitor's session this long after the last request addressing it;
+	// zero keeps sessions until they are deleted.
+	SessionIdle time.Duration
+	// SessionsPerVisitor caps the sessions each visitor may own at once; zero disables the cap.
+	SessionsPerVisitor int
+	// TrustProxy identifies visitors by the address the nearest proxy appended to X-Forwarded-For
+	// rather than by the connection's address.
+	TrustProxy bool
+}
+
+// visitor identifies the demo visitor making r by address, or returns "" outside demo mode.
+func (s *Server) visitor(r *http.Request) string {
+	if !s.opts.Demo.Enabled {
+		return ""
+	}
+	if s.opts.Demo.TrustProxy {
+		hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+			return last
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// demoGuard admits safe requests, session creation, stateless computations, and requests to the
+// visitor's own sessions. Every other mutating request is refused with 403 read_only.
+func (s *Server) demoGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sess *session
+		if id, ok := strings.CutPrefix(r.URL.Path, "/sessions/"); ok {
+			id, _, _ = strings.Cut(id, "/")
+			if sess, ok = s.sessions.get(id); ok && sess.owner != "" {
+				sess.touch(time.Now())
+			}
+		}
+
+		switch {
+		case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		case r.URL.Path == "/sessions" && r.Method == http.MethodPost && !isBundleUpload(r):
+		case r.URL.Path == "/coverage/laydown", strings.HasPrefix(r.URL.Path, "/tools/"):
+		case sess == nil && strings.HasPrefix(r.URL.Path, "/sessions/"):
+			// Unknown sessions get their usual 404.
+		case sess != nil && strings.HasPrefix(r.URL.Path, "/sessions/"+sess.id+"/tools/"):
+		case sess != nil && sess.owner != "" && sess.owner == s.visitor(r):
+		default:
+			writeError(w, r, apiError{
+				status:  http.StatusForbidden,
+				Code:    codeReadOnly,
+				Message: "this demo is read-only; create your own session with POST /sessions to experiment",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// expireIdleSessions removes visitor sessions nobody has addressed for the idle period, checking
+// every interval forever.
+func (s *Server) expireIdleSessions(idle, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		cutoff := now.Add(-idle).UnixNano()
+		for _, sess := range s.sessions.list() {
+			if sess.owner != "" && sess.lastUsed.Load() < cutoff {
+				s.sessions.remove(sess.id)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error codes are stable, machine-readable identifiers the frontend maps to user-facing messages.
+const (
+	codeInvalidArgument   = "invalid_argument"
+	codeNotFound          = "not_found"
+	codeUnauthenticated   = "unauthenticated"
+	codeReadOnly          = "read_only"
+	codeMethodNotAllowed  = "method_not_allowed"
+	codeConflict          = "conflict"
+	codePrecondition      = "precondition_failed"
+	codeIdempotencyReused = "idempotency_key_reused"
+	codeQuotaExceeded     = "quota_exceeded"
+	codeRateLimited       = "rate_limited"
+	codeStepFailed        = "step_failed"
+	codeUnavailable       = "unavailable"
+	codeInternal          = "internal"
+)
+
+// apiError is the structured error body returned by every handler.
+type apiError struct {
+	status  int
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Field   string         `json:"field,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+func (e apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func invalidArgument(field, message string) apiError {
+	return apiError{status: http.StatusBadRequest, Code: codeInvalidArgument, Message: message, Field: field}
+}
+
+func notFound(message string) apiError {
+	return apiError{status: http.StatusNotFound, Code: codeNotFound, Message: message}
+}
+
+func internalError() apiError {
+	return apiError{status: http.StatusInternalServerError, Code: codeInternal, Message: "internal error"}
+}
+
+// writeError renders err using the structured error model.
+func writeError(w http.ResponseWriter, r *http.Request, err apiError) {
+	if err.status == 0 {
+		err.status = http.StatusInternalServerError
+	}
+	writeJSONStatus(w, r, err.status, errorResponse{Error: err})
+}
+
+// allowMethods rejects requests whose method is not listed, returning false when it has responded.
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, r, apiError{
+		status:  http.StatusMethodNotAllowed,
+		Code:    codeMethodNotAllowed,
+		Message: fmt.Sprintf("method %s not allowed", r.Method),
+		Details: map[string]any{"allowed": methods},
+	})
+	return false
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+// demandHandler serves GET /demands/{id}/explain.
+func (s *Server) demandHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/demands/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "explain" {
+		writeError(w, r, notFound("no route for "+r.URL.Path))
+		return
+	}
+	s.writeExplain(w, r, s.sim, parts[0])
+}
+
+// writeExplain serves the explanation of how a simulator's latest recompute routed a demand.
+func (s *Server) writeExplain(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator, demandID string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	var exp simulation.RouteExplanation
+	var err error
+	if !s.compute(w, r, func() { exp, err = sim.ExplainRoute(demandID) }) {
+		return
+	}
+	switch {
+	case errors.Is(err, simulation.ErrUnknownDemand):
+		writeError(w, r, notFound(err.Error()))
+		return
+	case err != nil:
+		log.Printf("explain demand %q: %v", demandID, err)
+		writeError(w, r, internalError())
+		return
+	}
+	w.Header().Set("ETag", formatETag(exp.Version))
+	writeJSON(w, r, exp)
+}
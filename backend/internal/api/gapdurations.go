@@ -0,0 +1,112 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+func (s *Server) gapDurationsHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeGapDurations(w, r, s.sim)
+}
+
+// writeGapDurations serves each cell's longest coverage gap over ?window= (default a day) simulated
+// ahead in steps of ?dt= (default a minute). ?format=geojson returns the cells as a GeoJSON
+// FeatureCollection of polygons instead of the heatmap-style list.
+func (s *Server) writeGapDurations(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	window := 24 * time.Hour
+	if raw := query.Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("window", "window must be a positive duration such as 24h"))
+			return
+		}
+		window = parsed
+	}
+	dt := time.Minute
+	if raw := query.Get("dt"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("dt", "dt must be a positive duration such as 60s"))
+			return
+		}
+		dt = parsed
+	}
+	if window/dt > maxRunSteps {
+		writeError(w, r, invalidArgument("dt", "window/dt must not exceed "+strconv.Itoa(maxRunSteps)+" steps"))
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "geojson" {
+		writeError(w, r, invalidArgument("format", "format must be json or geojson"))
+		return
+	}
+
+	var (
+		result simulation.GapDurationMap
+		err    error
+	)
+	if !s.compute(w, r, func() { result, err = sim.GapDurations(window, dt) }) {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to compute gap durations: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	w.Header().Set("ETag", formatETag(result.Version))
+	if format == "geojson" {
+		writeJSON(w, r, newGapDurationFeatures(result))
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+// gapDurationFeatures is a GeoJSON FeatureCollection with one polygon per grid cell. GeoJSON fixes
+// coordinates to longitude and latitude in degrees, so they ignore ?units=.
+type gapDurationFeatures struct {
+	Type     string               `json:"type"`
+	Features []gapDurationFeature `json:"features"`
+}
+
+type gapDurationFeature struct {
+	Type       string                `json:"type"`
+	Geometry   gapDurationPolygon    `json:"geometry"`
+	Properties gapDurationProperties `json:"properties"`
+}
+
+type gapDurationPolygon struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+type gapDurationProperties struct {
+	MaxGapS         float64 `json:"maxGapS"`
+	CoveredFraction float64 `json:"coveredFraction"`
+}
+
+func newGapDurationFeatures(result simulation.GapDurationMap) gapDurationFeatures {
+	collection := gapDurationFeatures{Type: "FeatureCollection", Features: make([]gapDurationFeature, len(result.Cells))}
+	for i, cell := range result.Cells {
+		south, north := math.Max(cell.Lat-result.LatStep/2, -90), math.Min(cell.Lat+result.LatStep/2, 90)
+		west, east := cell.Lon-result.LonStep/2, math.Min(cell.Lon+result.LonStep/2, 180)
+		collection.Features[i] = gapDurationFeature{
+			Type: "Feature",
+			Geometry: gapDurationPolygon{
+				Type: "Polygon",
+				// Counterclockwise, as RFC 7946 requires of exterior rings.
+				Coordinates: [][][2]float64{{{west, south}, {east, south}, {east, north}, {west, north}, {west, south}}},
+			},
+			Properties: gapDurationProperties{MaxGapS: cell.MaxGapS, CoveredFraction: cell.CoveredFraction},
+		}
+	}
+	return collection
+}
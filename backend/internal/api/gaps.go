@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+func (s *Server) gapsHandler(w http.ResponseWriter, r *http.Request) {
+	writeGaps(w, r, s.sim)
+}
+
+// writeGaps serves the coverage gaps of the latest recompute as contiguous regions, largest first;
+// ?limit= keeps only the largest regions.
+func writeGaps(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	limit := -1
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			writeError(w, r, invalidArgument("limit", "limit must be a non-negative integer"))
+			return
+		}
+		limit = parsed
+	}
+	report, err := sim.CoverageGaps()
+	if err != nil {
+		writeError(w, r, internalError())
+		return
+	}
+	count := len(report.Regions)
+	if limit >= 0 && limit < count {
+		report.Regions = report.Regions[:limit]
+	}
+	w.Header().Set("ETag", formatETag(report.Version))
+	writeJSON(w, r, gapsResponse{Count: count, GapReport: report})
+}
+
+type gapsResponse struct {
+	// Count is the number of regions before ?limit= applies, matching TotalAreaKm2.
+	Count int `json:"count"`
+	simulation.GapReport
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/internal/worker"
+	"github.com/example/satnet/backend/simulation"
+)
+
+type gridResponse struct {
+	Message string                `json:"message,omitempty"`
+	Status  simulation.GridStatus `json:"status"`
+}
+
+func (s *Server) gridHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeGrid(w, r, s.sim)
+}
+
+// writeGrid serves a simulator's coverage grid: GET reports the grid in use and any rebuild under
+// way, and PUT starts a rebuild at the resolution in the body on the worker pool, answering 202
+// Accepted at once. The new grid is swapped in, and a fresh snapshot published, when it is ready.
+func (s *Server) writeGrid(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut) {
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, r, gridResponse{Status: sim.GridStatus()})
+		return
+	}
+
+	var grid coverage.GridConfig
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&grid); err != nil {
+		writeError(w, r, invalidArgument("body", "decode grid: "+err.Error()))
+		return
+	}
+	cfg := sim.Scenario()
+	cfg.GridConfig = grid
+	if err := s.sessions.limits.CheckLimits(cfg); err != nil {
+		writeError(w, r, sessionCreateError(err))
+		return
+	}
+	switch err := sim.StartGridRebuild(grid, s.pool.Submit); {
+	case errors.Is(err, simulation.ErrGridRebuildInProgress):
+		writeError(w, r, apiError{status: http.StatusConflict, Code: codeConflict, Message: err.Error()})
+		return
+	case errors.Is(err, worker.ErrQueueFull), errors.Is(err, worker.ErrClosed):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, apiError{status: http.StatusServiceUnavailable, Code: codeUnavailable, Message: "simulation workers are busy; retry shortly"})
+		return
+	case err != nil:
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+	writeJSONStatus(w, r, http.StatusAccepted, gridResponse{Message: "grid rebuild started", Status: sim.GridStatus()})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// heatmapChunkCells is how many encoded cells are buffered before each write and flush.
+const heatmapChunkCells = 4096
+
+// writeHeatmap streams a simulator's current heatmap as {"version": N, "heatmap": [...]}, encoding
+// and flushing a chunk of cells at a time so the full JSON body is never held in memory. Under a
+// scenario's streamHeatmap the cells come straight from the coverage grid. It honors ?precision=,
+// ?units= and ?casing= like other responses.
+func writeHeatmap(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	snap, cells := sim.StreamHeatmap()
+	etag := snapshotETag(snap)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	// An invalid precision was already rejected by validateQuery; fall back to full precision.
+	precision, _ := parsePrecision(r.URL.Query().Get("precision"))
+	keys := newHeatmapKeys(requestUnits(r), parseCasing(r.URL.Query().Get("casing")))
+
+	w.Header().Set("Content-Type", "application/json")
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 0, heatmapChunkCells*96)
+	buf = append(buf, `{"version":`...)
+	buf = strconv.AppendUint(buf, snap.Version, 10)
+	if units := requestUnits(r); !units.IsNative() {
+		buf = append(buf, `,"units":{"distance":"`+units.Distance+`","angle":"`+units.Angle+`","time":"`+units.Time+`"}`...)
+	}
+	buf = append(buf, `,"heatmap":[`...)
+	written := 0
+	failed := false
+	cells(func(cell coverage.HeatmapCell) bool {
+		if written > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendHeatmapCell(buf, cell, precision, keys)
+		written++
+		if written%heatmapChunkCells == 0 {
+			if _, err := w.Write(buf); err != nil {
+				log.Printf("failed to stream heatmap: %v", err)
+				failed = true
+				return false
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			buf = buf[:0]
+		}
+		return true
+	})
+	if failed {
+		return
+	}
+	buf = append(buf, "]}\n"...)
+	if _, err := w.Write(buf); err != nil {
+		log.Printf("failed to stream heatmap: %v", err)
+	}
+}
+
+func (s *Server) heatmapHandler(w http.ResponseWriter, r *http.Request) {
+	writeHeatmap(w, r, s.sim)
+}
+
+// heatmapKeys are the encoded openings of a streamed cell's keys, in the response casing and
+// units, and the scale applied to its coordinates.
+type heatmapKeys struct {
+	lat, lon, covered, count, strength, bandwidth string
+	scale                                         float64
+}
+
+func newHeatmapKeys(units Units, casing fieldCasing) heatmapKeys {
+	key := func(name string) string {
+		if casing == casingSnake {
+			name = toSnakeCase(name)
+		}
+		return `"` + name + `":`
+	}
+	lat, lon, scale := "lat", "lon", 1.0
+	if !units.IsNative() {
+		var to string
+		to, scale = unitScale("deg", units)
+		lat, lon = lat+unitSuffixes[to], lon+unitSuffixes[to]
+	}
+	return heatmapKeys{
+		lat:       "{" + key(lat),
+		lon:       "," + key(lon),
+		covered:   "," + key("covered"),
+		count:     "," + key("count"),
+		strength:  "," + key("strength"),
+		bandwidth: "," + key("bandwidthMHz"),
+		scale:     scale,
+	}
+}
+
+// appendHeatmapCell encodes cell with the fields of heatmapCellDTO, without reflection.
+func appendHeatmapCell(buf []byte, cell coverage.HeatmapCell, precision int, keys heatmapKeys) []byte {
+	buf = append(buf, keys.lat...)
+	buf = appendNumber(buf, cell.Lat*keys.scale, precision)
+	buf = append(buf, keys.lon...)
+	buf = appendNumber(buf, cell.Lon*keys.scale, precision)
+	buf = append(buf, keys.covered...)
+	buf = strconv.AppendBool(buf, cell.Covered)
+	buf = append(buf, keys.count...)
+	buf = strconv.AppendInt(buf, int64(cell.Count), 10)
+	buf = append(buf, keys.strength...)
+	buf = appendNumber(buf, cell.Strength, precision)
+	if cell.BandwidthMHz != 0 {
+		buf = append(buf, keys.bandwidth...)
+		buf = appendNumber(buf, cell.BandwidthMHz, precision)
+	}
+	return append(buf, '}')
+}
+
+func appendNumber(buf []byte, f float64, precision int) []byte {
+	if precision >= 0 {
+		return append(buf, formatRounded(f, precision)...)
+	}
+	return strconv.AppendFloat(buf, f, 'f', -1, 64)
+}
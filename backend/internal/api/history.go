@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+type historyResponse struct {
+	Entries         []simulation.HistorySummary `json:"entries"`
+	CompressedBytes int64                       `json:"compressedBytes"`
+}
+
+// writeHistory serves a simulator's snapshot history: the buffered entries, or with ?version= the
+// full decompressed snapshot.
+func writeHistory(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	if raw := r.URL.Query().Get("version"); raw != "" {
+		version, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, r, invalidArgument("version", "version must be a snapshot version number"))
+			return
+		}
+		snap, ok := sim.HistorySnapshot(version)
+		if !ok {
+			writeError(w, r, notFound("snapshot version "+raw+" is not in the history buffer"))
+			return
+		}
+		writeJSON(w, r, newSnapshotDTO(snap))
+		return
+	}
+
+	entries, size := sim.HistorySummaries()
+	if entries == nil {
+		entries = []simulation.HistorySummary{}
+	}
+	writeJSON(w, r, historyResponse{Entries: entries, CompressedBytes: size})
+}
+
+func (s *Server) historyHandler(w http.ResponseWriter, r *http.Request) {
+	writeHistory(w, r, s.sim)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+type laydownResponse struct {
+	Beams []coverage.Footprint `json:"beams"`
+}
+
+// laydownHandler serves POST /coverage/laydown, generating the spot-beam footprints a satellite
+// needs to tile a service area. The result can be pasted into scenarios as satellite footprints.
+func (s *Server) laydownHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var laydown coverage.Laydown
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScenarioBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&laydown); err != nil {
+		writeError(w, r, invalidArgument("body", "decode laydown: "+err.Error()))
+		return
+	}
+
+	var beams []coverage.Footprint
+	var err error
+	if !s.compute(w, r, func() { beams, err = coverage.SpotBeamLaydown(laydown) }) {
+		return
+	}
+	if err != nil {
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+	if beams == nil {
+		beams = []coverage.Footprint{}
+	}
+	writeJSON(w, r, laydownResponse{Beams: beams})
+}
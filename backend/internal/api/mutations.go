@@ -0,0 +1,326 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+const (
+	idempotencyTTL        = 24 * time.Hour
+	idempotencyMaxEntries = 1024
+)
+
+type mutationResponse struct {
+	Message  string      `json:"message"`
+	Snapshot snapshotDTO `json:"snapshot"`
+}
+
+// satelliteHandler serves topology mutations on /satellites/{id} (DELETE) and
+// /satellites/{id}/disable (POST). Both honor If-Match against the snapshot ETag, except that a
+// disable under a scenario modeling TT&C is queued as a command instead.
+func (s *Server) satelliteHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/satellites/"), "/"), "/")
+	id := parts[0]
+	if id == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "disable") {
+		writeError(w, r, notFound("no route for "+r.URL.Path))
+		return
+	}
+
+	if len(parts) == 2 {
+		if !allowMethods(w, r, http.MethodPost) {
+			return
+		}
+		if s.sim.TTCModeled() {
+			s.issueCommand(w, r, func() (simulation.Command, error) {
+				return s.sim.CommandDisableSatellite(id)
+			})
+			return
+		}
+		s.applyMutation(w, r, "satellite disabled", func() (simulation.Snapshot, error) {
+			return s.sim.DisableSatellite(id)
+		}, func(version uint64) (simulation.Snapshot, error) {
+			return s.sim.DisableSatelliteIfMatch(id, version)
+		})
+		return
+	}
+
+	if !allowMethods(w, r, http.MethodDelete) {
+		return
+	}
+	s.applyMutation(w, r, "satellite removed", func() (simulation.Snapshot, error) {
+		return s.sim.RemoveSatellite(id)
+	}, func(version uint64) (simulation.Snapshot, error) {
+		return s.sim.RemoveSatelliteIfMatch(id, version)
+	})
+}
+
+// shellHandler serves POST /shells/{shell}/enable and /shells/{shell}/disable, toggling a
+// constellation shell in or out of routing and coverage. Both honor If-Match against the snapshot ETag.
+func (s *Server) shellHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/shells/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || (parts[1] != "enable" && parts[1] != "disable") {
+		writeError(w, r, notFound("no route for "+r.URL.Path))
+		return
+	}
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	shell, enabled := parts[0], parts[1] == "enable"
+	s.applyMutation(w, r, "shell "+parts[1]+"d", func() (simulation.Snapshot, error) {
+		return s.sim.SetShellEnabled(shell, enabled)
+	}, func(version uint64) (simulation.Snapshot, error) {
+		return s.sim.SetShellEnabledIfMatch(shell, enabled, version)
+	})
+}
+
+// applyMutation runs an unconditional or If-Match guarded mutation and writes the resulting snapshot.
+func (s *Server) applyMutation(
+	w http.ResponseWriter,
+	r *http.Request,
+	message string,
+	unconditional func() (simulation.Snapshot, error),
+	conditional func(uint64) (simulation.Snapshot, error),
+) {
+	var snap simulation.Snapshot
+	var err error
+	if header := r.Header.Get("If-Match"); header != "" && header != "*" {
+		version, parseErr := parseTopologyETag(header)
+		if parseErr != nil {
+			writeError(w, r, invalidArgument("If-Match", parseErr.Error()))
+			return
+		}
+		if !s.compute(w, r, func() { snap, err = conditional(version) }) {
+			return
+		}
+	} else if !s.compute(w, r, func() { snap, err = unconditional() }) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, simulation.ErrVersionConflict):
+		current := s.sim.Snapshot()
+		w.Header().Set("ETag", snapshotETag(current))
+		writeError(w, r, apiError{
+			status:  http.StatusPreconditionFailed,
+			Code:    codePrecondition,
+			Message: "topology changed since it was read; refresh and retry",
+			Field:   "If-Match",
+			Details: map[string]any{"currentVersion": current.TopologyVersion},
+		})
+		return
+	case errors.Is(err, simulation.ErrUnknownSatellite), errors.Is(err, simulation.ErrUnknownShell):
+		writeError(w, r, notFound(err.Error()))
+		return
+	case err != nil:
+		log.Printf("mutation failed: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+
+	s.traceRecompute(r.Context(), s.sim, snap.Version)
+	w.Header().Set("ETag", snapshotETag(snap))
+	writeJSON(w, r, mutationResponse{Message: message, Snapshot: newSnapshotDTO(snap)})
+}
+
+func formatETag(version uint64) string {
+	return fmt.Sprintf("%q", strconv.FormatUint(version, 10))
+}
+
+// snapshotETag tags a snapshot as "<topology version>.<version>". If-None-Match compares the whole
+// tag, while If-Match compares only the topology part, so the steps a running simulation takes
+// between reading a snapshot and mutating it do not fail the mutation.
+func snapshotETag(snap simulation.Snapshot) string {
+	return fmt.Sprintf(`"%d.%d"`, snap.TopologyVersion, snap.Version)
+}
+
+// parseETag returns the version of a tag made by formatETag or snapshotETag.
+func parseETag(header string) (uint64, error) {
+	tag := trimETag(header)
+	if _, version, ok := strings.Cut(tag, "."); ok {
+		tag = version
+	}
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed entity tag %q", header)
+	}
+	return version, nil
+}
+
+// parseTopologyETag returns the topology version of a tag made by snapshotETag.
+func parseTopologyETag(header string) (uint64, error) {
+	topology, _, ok := strings.Cut(trimETag(header), ".")
+	version, err := strconv.ParseUint(topology, 10, 64)
+	if !ok || err != nil {
+		return 0, fmt.Errorf("malformed entity tag %q; send the ETag of a snapshot", header)
+	}
+	return version, nil
+}
+
+func trimETag(header string) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimSpace(header), "W/"), `"`)
+}
+
+// idempotencyStore remembers responses to mutating requests by Idempotency-Key so retries
+// replay the original outcome instead of applying the change twice. Each entry keeps a hash of the
+// request body, so a key reused for a different request is rejected rather than replayed.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]recordedResponse
+}
+
+type recordedResponse struct {
+	// inFlight marks a request still being handled; the fields below are set once it completes.
+	inFlight bool
+	bodyHash [sha256.Size]byte
+	status   int
+	header   http.Header
+	body     []byte
+	recorded time.Time
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{entries: make(map[string]recordedResponse)}
+}
+
+// wrap replays or records responses for non-GET requests carrying an Idempotency-Key header. A
+// retry that arrives while the original is still running gets 409, and one whose body differs
+// from the original's gets 422.
+func (st *idempotencyStore) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key = r.Method + " " + r.URL.Path + " " + key
+
+		cached, ok := st.begin(key)
+		switch {
+		case ok && cached.inFlight:
+			writeError(w, r, apiError{status: http.StatusConflict, Code: codeConflict, Message: "a request with this Idempotency-Key is still in progress", Field: "Idempotency-Key"})
+			return
+		case ok:
+			if hashBody(r.Body) != cached.bodyHash {
+				writeError(w, r, apiError{
+					status:  http.StatusUnprocessableEntity,
+					Code:    codeIdempotencyReused,
+					Message: "Idempotency-Key was already used with a different request body",
+					Field:   "Idempotency-Key",
+				})
+				return
+			}
+			for name, values := range cached.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(cached.status)
+			_, _ = w.Write(cached.body)
+			return
+		}
+
+		// Hash the body as the handler reads it, then whatever it left unread.
+		hash := sha256.New()
+		body := r.Body
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(body, hash), body}
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if p := recover(); p != nil {
+				st.forget(key)
+				panic(p)
+			}
+			if rec.status >= 500 {
+				st.forget(key)
+				return
+			}
+			_, _ = io.Copy(hash, io.LimitReader(body, maxBundleBytes))
+			entry := recordedResponse{status: rec.status, header: w.Header().Clone(), body: rec.body.Bytes(), recorded: time.Now()}
+			hash.Sum(entry.bodyHash[:0])
+			st.store(key, entry)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// hashBody hashes a retried request's body, up to the largest body any route accepts.
+func hashBody(body io.Reader) [sha256.Size]byte {
+	hash := sha256.New()
+	_, _ = io.Copy(hash, io.LimitReader(body, maxBundleBytes))
+	var sum [sha256.Size]byte
+	hash.Sum(sum[:0])
+	return sum
+}
+
+// begin returns the entry recorded for key, or marks key in flight and reports false.
+func (st *idempotencyStore) begin(key string) (recordedResponse, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	entry, ok := st.entries[key]
+	if ok && time.Since(entry.recorded) <= idempotencyTTL {
+		return entry, true
+	}
+	st.storeLocked(key, recordedResponse{inFlight: true, recorded: time.Now()})
+	return recordedResponse{}, false
+}
+
+func (st *idempotencyStore) store(key string, entry recordedResponse) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.storeLocked(key, entry)
+}
+
+// forget drops key so a request that failed on the server can be retried.
+func (st *idempotencyStore) forget(key string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.entries, key)
+}
+
+func (st *idempotencyStore) storeLocked(key string, entry recordedResponse) {
+	if _, ok := st.entries[key]; !ok && len(st.entries) >= idempotencyMaxEntries {
+		// Evict the oldest entry to keep memory bounded.
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range st.entries {
+			if oldestKey == "" || e.recorded.Before(oldest) {
+				oldestKey, oldest = k, e.recorded
+			}
+		}
+		delete(st.entries, oldestKey)
+	}
+	st.entries[key] = entry
+}
+
+// responseRecorder tees a response so it can be replayed later.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
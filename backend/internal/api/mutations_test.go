@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/simulation"
+)
+
+func TestIfMatchSurvivesSteps(t *testing.T) {
+	sim := simulation.NewDemoSimulator()
+	handler := NewServer(Options{}, sim, store.NewMemory()).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/simulation/snapshot", nil))
+	etag := rec.Header().Get("ETag")
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	disable := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/satellites/demo-01-01/disable", nil)
+		req.Header.Set("If-Match", etag)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := disable(); rec.Code != http.StatusOK {
+		t.Fatalf("expected a step not to invalidate %s, got %d: %s", etag, rec.Code, rec.Body)
+	}
+	if rec := disable(); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected the mutation to invalidate %s, got %d: %s", etag, rec.Code, rec.Body)
+	}
+}
+
+func TestIdempotencyKeyRejectsADifferentBody(t *testing.T) {
+	handler := NewServer(Options{}, simulation.NewDemoSimulator(), store.NewMemory()).Handler()
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/revisions", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "k1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	first := send(`{"name": "a"}`)
+	if replay := send(`{"name": "a"}`); replay.Header().Get("Idempotent-Replayed") != "true" || replay.Code != first.Code {
+		t.Fatalf("expected the retry to replay %d, got %d: %s", first.Code, replay.Code, replay.Body)
+	}
+	if other := send(`{"name": "b"}`); other.Code != http.StatusUnprocessableEntity || !strings.Contains(other.Body.String(), codeIdempotencyReused) {
+		t.Fatalf("expected 422 for a reused key, got %d: %s", other.Code, other.Body)
+	}
+}
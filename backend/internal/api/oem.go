@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// maxOEMBytes bounds an uploaded Orbit Ephemeris Message.
+const maxOEMBytes = 64 << 20
+
+const oemContentType = "text/plain; charset=utf-8"
+
+// oemSatellite is a satellite entry built from one OEM segment, ready to add to a scenario.
+type oemSatellite struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Ephemeris *orbits.Ephemeris `json:"ephemeris"`
+}
+
+type oemImportResponse struct {
+	Satellites []oemSatellite `json:"satellites"`
+}
+
+func (s *Server) oemHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeOEM(w, r, "satnet", s.sim)
+}
+
+// writeOEM serves the trajectories of ?satellites= (a comma-separated list, default all) as a CCSDS
+// Orbit Ephemeris Message sampled every ?step= (default a minute) for ?span= (default a day) from
+// the current simulation time.
+func (s *Server) writeOEM(w http.ResponseWriter, r *http.Request, name string, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	span := 24 * time.Hour
+	if raw := query.Get("span"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("span", "span must be a positive duration such as 24h"))
+			return
+		}
+		span = parsed
+	}
+	step := time.Minute
+	if raw := query.Get("step"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("step", "step must be a positive duration such as 60s"))
+			return
+		}
+		step = parsed
+	}
+	var ids []string
+	if raw := query.Get("satellites"); raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			ids = append(ids, strings.TrimSpace(id))
+		}
+	}
+	satellites := len(ids)
+	if satellites == 0 {
+		satellites = len(sim.Scenario().Satellites)
+	}
+	if int64(span/step+1)*int64(satellites) > maxRunSteps {
+		writeError(w, r, invalidArgument("step", "span/step times the satellites exported must not exceed "+strconv.Itoa(maxRunSteps)+" states"))
+		return
+	}
+
+	var (
+		oem *orbits.OEM
+		err error
+	)
+	if !s.compute(w, r, func() { oem, err = sim.OEM(ids, span, step) }) {
+		return
+	}
+	switch {
+	case errors.Is(err, routing.ErrUnknownNode):
+		writeError(w, r, notFound(err.Error()))
+		return
+	case errors.Is(err, orbits.ErrOutsideEphemeris):
+		writeError(w, r, invalidArgument("span", err.Error()))
+		return
+	case err != nil:
+		log.Printf("export oem: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	var buf bytes.Buffer
+	if err := orbits.WriteOEM(&buf, oem); err != nil {
+		log.Printf("write oem: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	w.Header().Set("Content-Type", oemContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + ".oem"}))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("failed to write oem: %v", err)
+	}
+}
+
+// oemImportHandler serves POST /scenarios/oem, converting an uploaded Orbit Ephemeris Message into
+// satellite entries that move along its segments, one per object.
+func (s *Server) oemImportHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOEMBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, invalidArgument("body", "oem exceeds "+strconv.Itoa(maxOEMBytes)+" bytes"))
+			return
+		}
+		writeError(w, r, invalidArgument("body", "read oem: "+err.Error()))
+		return
+	}
+	oem, err := orbits.ParseOEM(bytes.NewReader(data))
+	if err != nil {
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+	response := oemImportResponse{Satellites: make([]oemSatellite, 0, len(oem.Segments))}
+	seen := make(map[string]bool, len(oem.Segments))
+	for _, segment := range oem.Segments {
+		if seen[segment.ObjectID] {
+			writeError(w, r, invalidArgument("body", "object "+segment.ObjectID+" has more than one segment; split the file per segment"))
+			return
+		}
+		seen[segment.ObjectID] = true
+		ephemeris, err := segment.Ephemeris()
+		if err != nil {
+			writeError(w, r, invalidArgument("body", err.Error()))
+			return
+		}
+		response.Satellites = append(response.Satellites, oemSatellite{ID: segment.ObjectID, Name: segment.ObjectName, Ephemeris: ephemeris})
+	}
+	writeJSON(w, r, response)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/satnet/backend/simulation"
+	"github.com/example/satnet/backend/simulation/des"
+)
+
+// maxPacketDuration bounds the simulated time of one discrete-event run.
+const maxPacketDuration = time.Hour
+
+// writePackets serves a discrete-event packet run over a simulator's current routes:
+// ?duration= (default 10s), ?seed=, ?queue= packets per link, and ?capacityMbps=, which defaults to
+// the scenario's link capacity.
+func (s *Server) writePackets(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	cfg := des.FromTopology(sim.Topology())
+	cfg.Duration = 10 * time.Second
+
+	query := r.URL.Query()
+	if raw := query.Get("duration"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxPacketDuration {
+			writeError(w, r, invalidArgument("duration", "duration must be a positive duration of at most "+maxPacketDuration.String()))
+			return
+		}
+		cfg.Duration = parsed
+	}
+	if raw := query.Get("seed"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeError(w, r, invalidArgument("seed", "seed must be an integer"))
+			return
+		}
+		cfg.Seed = parsed
+	}
+	if raw := query.Get("queue"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("queue", "queue must be a positive number of packets"))
+			return
+		}
+		cfg.QueuePackets = parsed
+	}
+	if raw := query.Get("capacityMbps"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("capacityMbps", "capacityMbps must be a positive number"))
+			return
+		}
+		cfg.LinkCapacityMbps = parsed
+	}
+	if cfg.LinkCapacityMbps <= 0 {
+		writeError(w, r, invalidArgument("capacityMbps", "the scenario has no linkCapacityMbps; pass capacityMbps"))
+		return
+	}
+
+	var result des.Result
+	var err error
+	if !s.compute(w, r, func() { result, err = des.Run(cfg) }) {
+		return
+	}
+	if errors.Is(err, des.ErrTooManyPackets) {
+		writeError(w, r, invalidArgument("duration", err.Error()))
+		return
+	}
+	if err != nil {
+		writeError(w, r, invalidArgument("capacityMbps", err.Error()))
+		return
+	}
+	writeJSON(w, r, result)
+}
+
+func (s *Server) packetsHandler(w http.ResponseWriter, r *http.Request) {
+	s.writePackets(w, r, s.sim)
+}
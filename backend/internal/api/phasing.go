@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+// maxPhasingEvaluations bounds the layouts one phasing request may simulate.
+const maxPhasingEvaluations = 500
+
+type phasingRequest struct {
+	Objective string `json:"objective,omitempty"`
+	// WindowS and StepS default to six hours in one-minute steps.
+	WindowS        float64 `json:"windowS,omitempty"`
+	StepS          float64 `json:"stepS,omitempty"`
+	MaxEvaluations int     `json:"maxEvaluations,omitempty"`
+}
+
+func (s *Server) phasingHandler(w http.ResponseWriter, r *http.Request) {
+	s.writePhasing(w, r, s.sim)
+}
+
+// writePhasing serves POST requests to optimize the right ascension and phasing of a simulator's
+// orbital planes against its coverage gaps, reporting the gap statistics before and after. Every
+// layout tried is simulated over the whole window, so the request's steps times its evaluations
+// may not exceed maxRunSteps.
+func (s *Server) writePhasing(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req phasingRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, invalidArgument("body", "decode phasing request: "+err.Error()))
+		return
+	}
+	opts := simulation.PhasingOptions{
+		Objective:      req.Objective,
+		Window:         6 * time.Hour,
+		Step:           time.Minute,
+		MaxEvaluations: simulation.DefaultPhasingEvaluations,
+	}
+	switch {
+	case req.Objective != "" && req.Objective != simulation.ObjectiveMaxGap && req.Objective != simulation.ObjectiveRevisit:
+		writeError(w, r, invalidArgument("objective", "objective must be "+simulation.ObjectiveMaxGap+" or "+simulation.ObjectiveRevisit))
+		return
+	case req.WindowS < 0:
+		writeError(w, r, invalidArgument("windowS", "windowS must be positive"))
+		return
+	case req.StepS < 0:
+		writeError(w, r, invalidArgument("stepS", "stepS must be positive"))
+		return
+	case req.MaxEvaluations < 0 || req.MaxEvaluations > maxPhasingEvaluations:
+		writeError(w, r, invalidArgument("maxEvaluations", "maxEvaluations must be between 1 and "+strconv.Itoa(maxPhasingEvaluations)))
+		return
+	}
+	if req.WindowS > 0 {
+		opts.Window = time.Duration(req.WindowS * float64(time.Second))
+	}
+	if req.StepS > 0 {
+		opts.Step = time.Duration(req.StepS * float64(time.Second))
+	}
+	if req.MaxEvaluations > 0 {
+		opts.MaxEvaluations = req.MaxEvaluations
+	}
+	if opts.Step <= 0 || int64(opts.Window/opts.Step)*int64(opts.MaxEvaluations) > maxRunSteps {
+		writeError(w, r, invalidArgument("stepS", "windowS/stepS times maxEvaluations must not exceed "+strconv.Itoa(maxRunSteps)+" steps"))
+		return
+	}
+
+	var (
+		result simulation.PhasingResult
+		err    error
+	)
+	if !s.compute(w, r, func() { result, err = sim.OptimizePhasing(opts) }) {
+		return
+	}
+	switch {
+	case errors.Is(err, simulation.ErrTooFewPlanes):
+		writeError(w, r, apiError{status: http.StatusUnprocessableEntity, Code: codeInvalidArgument, Message: err.Error()})
+		return
+	case err != nil:
+		log.Printf("failed to optimize phasing: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	writeJSON(w, r, result)
+}
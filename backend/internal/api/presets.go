@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+type presetListResponse struct {
+	Presets []simulation.PresetInfo `json:"presets"`
+}
+
+// presetsHandler serves GET /scenarios/presets, listing the built-in scenarios that
+// POST /sessions?preset= can start from.
+func (s *Server) presetsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, r, presetListResponse{Presets: simulation.Presets()})
+}
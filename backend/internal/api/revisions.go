@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/simulation"
+)
+
+type revisionRequest struct {
+	Name     string          `json:"name"`
+	Parent   uint64          `json:"parent,omitempty"`
+	Message  string          `json:"message,omitempty"`
+	Scenario json.RawMessage `json:"scenario"`
+}
+
+type revisionListResponse struct {
+	Revisions []store.Revision `json:"revisions"`
+}
+
+type revisionDiffResponse struct {
+	From    uint64                      `json:"from"`
+	To      uint64                      `json:"to"`
+	Changes []simulation.ScenarioChange `json:"changes"`
+}
+
+// revisionsHandler serves POST /revisions, saving a scenario revision, and GET /revisions?name=,
+// listing revisions without their scenarios.
+func (s *Server) revisionsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		revs, err := s.store.ListRevisions(r.URL.Query().Get("name"))
+		if err != nil {
+			log.Printf("failed to list revisions: %v", err)
+			writeError(w, r, internalError())
+			return
+		}
+		for i := range revs {
+			revs[i].Scenario = nil
+		}
+		writeJSON(w, r, revisionListResponse{Revisions: revs})
+		return
+	}
+
+	var req revisionRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScenarioBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, invalidArgument("body", "decode revision: "+err.Error()))
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, r, invalidArgument("name", "name is required"))
+		return
+	}
+	cfg, err := simulation.ParseScenario(bytes.NewReader(req.Scenario))
+	if err != nil {
+		writeError(w, r, invalidArgument("scenario", err.Error()))
+		return
+	}
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		log.Printf("failed to encode scenario: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+
+	rev, err := s.store.PutRevision(store.Revision{Name: req.Name, Parent: req.Parent, Message: req.Message, Created: time.Now().UTC(), Scenario: encoded})
+	if errors.Is(err, store.ErrUnknownRevision) {
+		writeError(w, r, invalidArgument("parent", err.Error()))
+		return
+	} else if err != nil {
+		log.Printf("failed to save revision: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	w.Header().Set("Location", "/revisions/"+strconv.FormatUint(rev.ID, 10))
+	writeJSONStatus(w, r, http.StatusCreated, rev)
+}
+
+// revisionHandler serves GET /revisions/{id} and GET /revisions/{id}/diff?against=, which compares
+// a revision with another one, by default its parent.
+func (s *Server) revisionHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/revisions/"), "/"), "/")
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "diff") {
+		writeError(w, r, notFound("unknown revision endpoint"))
+		return
+	}
+	rev, ok := s.lookupRevision(w, r, parts[0])
+	if !ok {
+		return
+	}
+	if len(parts) == 1 {
+		writeJSON(w, r, rev)
+		return
+	}
+
+	against := strconv.FormatUint(rev.Parent, 10)
+	if raw := r.URL.Query().Get("against"); raw != "" {
+		against = raw
+	} else if rev.Parent == 0 {
+		writeError(w, r, invalidArgument("against", "revision has no parent to compare with"))
+		return
+	}
+	base, ok := s.lookupRevision(w, r, against)
+	if !ok {
+		return
+	}
+	from, err := simulation.ParseScenario(bytes.NewReader(base.Scenario))
+	if err != nil {
+		log.Printf("revision %d unreadable: %v", base.ID, err)
+		writeError(w, r, internalError())
+		return
+	}
+	to, err := simulation.ParseScenario(bytes.NewReader(rev.Scenario))
+	if err != nil {
+		log.Printf("revision %d unreadable: %v", rev.ID, err)
+		writeError(w, r, internalError())
+		return
+	}
+	changes, err := simulation.DiffScenarios(from, to)
+	if err != nil {
+		log.Printf("failed to compare revisions: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	if changes == nil {
+		changes = []simulation.ScenarioChange{}
+	}
+	writeJSON(w, r, revisionDiffResponse{From: base.ID, To: rev.ID, Changes: changes})
+}
+
+// lookupRevision resolves a revision ID, writing the error response when it cannot.
+func (s *Server) lookupRevision(w http.ResponseWriter, r *http.Request, raw string) (store.Revision, bool) {
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		writeError(w, r, notFound("unknown revision "+raw))
+		return store.Revision{}, false
+	}
+	rev, ok, err := s.store.GetRevision(id)
+	if err != nil {
+		log.Printf("failed to read revision %d: %v", id, err)
+		writeError(w, r, internalError())
+		return store.Revision{}, false
+	}
+	if !ok {
+		writeError(w, r, notFound("unknown revision "+raw))
+		return store.Revision{}, false
+	}
+	return rev, true
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/internal/webhook"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// maxRunSteps bounds a single batch run so one request cannot monopolize a worker indefinitely.
+const maxRunSteps = 100000
+
+// runWriteTimeout is how long POST /runs may take to answer, long enough for a run of maxRunSteps
+// on a constellation within the session limits.
+const runWriteTimeout = 10 * time.Minute
+
+type runSummaryDTO struct {
+	Steps    int                                `json:"steps"`
+	Start    time.Time                          `json:"start"`
+	End      time.Time                          `json:"end"`
+	Latency  map[string]simulation.LatencyStats `json:"latency"`
+	Jitter   map[string]simulation.JitterStats  `json:"jitter"`
+	Blocking map[int]simulation.BlockingStats   `json:"blocking,omitempty"`
+	Hourly   []simulation.HourlyLoad            `json:"hourly,omitempty"`
+	Policies map[string]simulation.LatencyStats `json:"policies,omitempty"`
+	Snapshot snapshotDTO                        `json:"snapshot"`
+}
+
+type runResponse struct {
+	Hash    string        `json:"hash"`
+	Cached  bool          `json:"cached"`
+	Summary runSummaryDTO `json:"summary"`
+}
+
+// runsHandler serves POST /runs?steps=&dt=, running a scenario to completion. Results are cached
+// in the store by scenario hash, so repeating an identical run returns the stored summary. A
+// scenario without an epoch runs from the wall clock and is never cached.
+func (s *Server) runsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	query := r.URL.Query()
+	steps := 1
+	if raw := query.Get("steps"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxRunSteps {
+			writeError(w, r, invalidArgument("steps", "steps must be an integer between 1 and "+strconv.Itoa(maxRunSteps)))
+			return
+		}
+		steps = parsed
+	}
+	dt := time.Second
+	if raw := query.Get("dt"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("dt", "dt must be a positive duration such as 10s"))
+			return
+		}
+		dt = parsed
+	}
+
+	cfg, err := simulation.ParseScenario(http.MaxBytesReader(w, r.Body, maxScenarioBytes))
+	if err != nil {
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+	// Hash the epoch the run actually starts at, so the hash names a repeatable run.
+	cacheable := !cfg.Epoch.IsZero()
+	if !cacheable {
+		cfg.Epoch = time.Now().UTC()
+	}
+	hash, err := simulation.ScenarioHash(cfg, steps, dt)
+	if err != nil {
+		log.Printf("failed to hash scenario: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+
+	if cacheable {
+		cached, ok, err := s.store.GetRun(hash)
+		if err != nil {
+			log.Printf("failed to read run cache: %v", err)
+			writeError(w, r, internalError())
+			return
+		}
+		if ok {
+			var summary runSummaryDTO
+			if err := json.Unmarshal(cached.Result, &summary); err == nil {
+				w.Header().Set("X-Cache", "hit")
+				writeJSON(w, r, runResponse{Hash: hash, Cached: true, Summary: summary})
+				return
+			}
+			log.Printf("discarding unreadable cached run %s: %v", hash, err)
+		}
+	}
+
+	if err := s.sessions.limits.CheckLimits(cfg); err != nil {
+		writeError(w, r, sessionCreateError(err))
+		return
+	}
+	cfg.Sharder, cfg.Propagators, cfg.Plugins = s.opts.Sharder, s.opts.Propagators, s.opts.Plugins
+	extendWriteDeadline(w, runWriteTimeout)
+	var summary simulation.RunSummary
+	if !s.compute(w, r, func() {
+		var sim *simulation.Simulator
+		if sim, err = simulation.NewSimulator(cfg); err == nil {
+			summary, err = sim.Run(steps, dt)
+		}
+	}) {
+		return
+	}
+	if err != nil {
+		writeError(w, r, sessionCreateError(err))
+		return
+	}
+
+	dto := runSummaryDTO{
+		Steps:    summary.Steps,
+		Start:    summary.Start,
+		End:      summary.End,
+		Latency:  summary.Latency,
+		Jitter:   summary.Jitter,
+		Blocking: summary.Blocking,
+		Hourly:   summary.Hourly,
+		Policies: summary.Policies,
+		Snapshot: newSnapshotDTO(summary.Snapshot),
+	}
+	cache := "miss"
+	if !cacheable {
+		cache = "bypass"
+	} else if encoded, err := json.Marshal(dto); err != nil {
+		log.Printf("run %s not cached: %v", hash, err)
+	} else if err := s.store.PutRun(store.CachedRun{Hash: hash, Created: time.Now().UTC(), Result: encoded}); err != nil {
+		log.Printf("run %s not cached: %v", hash, err)
+	}
+	s.webhooks.Publish(webhook.RunCompleted, "", webhook.RunData{
+		Hash:            hash,
+		Steps:           summary.Steps,
+		Start:           summary.Start,
+		End:             summary.End,
+		CoveragePercent: summary.Snapshot.Coverage.CoveragePercent,
+	})
+	w.Header().Set("X-Cache", cache)
+	writeJSON(w, r, runResponse{Hash: hash, Summary: dto})
+}
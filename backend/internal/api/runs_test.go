@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/simulation"
+)
+
+func TestRunsCacheOnlyScenariosWithAnEpoch(t *testing.T) {
+	handler := NewServer(Options{}, simulation.NewDemoSimulator(), store.NewMemory()).Handler()
+	run := func(cfg simulation.Config) *httptest.ResponseRecorder {
+		body, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/runs?steps=2&dt=30s", strings.NewReader(string(body))))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("run failed with %d: %s", rec.Code, rec.Body)
+		}
+		return rec
+	}
+
+	cfg := simulation.DemoConfig()
+	cfg.Epoch = time.Time{}
+	for i := 0; i < 2; i++ {
+		if cache := run(cfg).Header().Get("X-Cache"); cache != "bypass" {
+			t.Fatalf("expected a run without an epoch to bypass the cache, got %q", cache)
+		}
+	}
+
+	cfg.Epoch = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if cache := run(cfg).Header().Get("X-Cache"); cache != "miss" {
+		t.Fatalf("expected the first run to miss, got %q", cache)
+	}
+	if cache := run(cfg).Header().Get("X-Cache"); cache != "hit" {
+		t.Fatalf("expected the repeated run to hit, got %q", cache)
+	}
+}
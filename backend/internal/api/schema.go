@@ -0,0 +1,351 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/example/satnet/backend/bgp"
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// The types below define the wire schema documented in docs/api.md. They are kept separate
+// from the simulation structs so internal refactors do not silently change the API contract.
+// Field names are camelCase; large optional arrays are omitted when empty.
+
+type snapshotDTO struct {
+	Version             uint64              `json:"version"`
+	TopologyVersion     uint64              `json:"topologyVersion"`
+	Timestamp           time.Time           `json:"timestamp"`
+	SimTime             time.Time           `json:"simTime"`
+	ActiveSatellites    []string            `json:"activeSatellites"`
+	DisabledSatellites  []string            `json:"disabledSatellites"`
+	Coverage            coverageDTO         `json:"coverage"`
+	Heatmap             []heatmapCellDTO    `json:"heatmap,omitempty"`
+	Routes              map[string]routeDTO `json:"routes"`
+	ContinuityPenaltyMS float64             `json:"continuityPenaltyMs"`
+	// Allocations is only present when the scenario models link capacity.
+	Allocations map[string]allocationDTO           `json:"allocations,omitempty"`
+	Classes     map[string]simulation.ClassMetrics `json:"classes,omitempty"`
+	Slices      map[string]simulation.SliceMetrics `json:"slices,omitempty"`
+	Shells      map[string]simulation.ShellMetrics `json:"shells,omitempty"`
+	BGP         *bgp.Convergence                   `json:"bgp,omitempty"`
+	StaleRoutes []string                           `json:"staleRoutes,omitempty"`
+	// ServingSatellites is only present when demands are sourced at locations.
+	ServingSatellites map[string]string `json:"servingSatellites,omitempty"`
+	InactiveDemands   []string          `json:"inactiveDemands,omitempty"`
+	FiredHooks        []string          `json:"firedHooks,omitempty"`
+	// Policies is only present when demands choose routing policies.
+	Policies map[string]simulation.PolicyMetrics `json:"policies,omitempty"`
+}
+
+type coverageDTO struct {
+	TotalCells      int      `json:"totalCells"`
+	CoveredCells    int      `json:"coveredCells"`
+	CoveragePercent float64  `json:"coveragePercent"`
+	AreaWeighted    bool     `json:"areaWeighted,omitempty"`
+	Gaps            []gapDTO `json:"gaps,omitempty"`
+	// MeanBandwidthMHz is only present when beams carry a frequency plan.
+	MeanBandwidthMHz float64 `json:"meanBandwidthMHz,omitempty"`
+	// Regions is only present when the scenario defines coverage regions.
+	Regions map[string]coverageDTO `json:"regions,omitempty"`
+	// Bounds is only present when footprints carry uncertainty.
+	Bounds *coverage.CoverageBounds `json:"bounds,omitempty"`
+	// Expected is only present when satellites carry failure probabilities.
+	Expected *coverage.ExpectedCoverage `json:"expected,omitempty"`
+}
+
+type gapDTO struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type heatmapCellDTO struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Covered  bool    `json:"covered"`
+	Count    int     `json:"count"`
+	Strength float64 `json:"strength"`
+	// BandwidthMHz is the cell's usable bandwidth when beams carry a frequency plan.
+	BandwidthMHz float64 `json:"bandwidthMHz,omitempty"`
+}
+
+type routeDTO struct {
+	Nodes     []string `json:"nodes"`
+	LatencyMS float64  `json:"latencyMs"`
+	// BottleneckThroughput is omitted for single-node routes, whose bottleneck is unbounded.
+	BottleneckThroughput *float64 `json:"bottleneckThroughput,omitempty"`
+	StabilityS           float64  `json:"stabilityS"`
+	ContinuityPenaltyMS  float64  `json:"continuityPenaltyMs,omitempty"`
+}
+
+type allocationDTO struct {
+	Status          string  `json:"status"`
+	Priority        int     `json:"priority"`
+	RequestedMbps   float64 `json:"requestedMbps"`
+	AllocatedMbps   float64 `json:"allocatedMbps"`
+	QueueingDelayMS float64 `json:"queueingDelayMs,omitempty"`
+}
+
+func newSnapshotDTO(snap simulation.Snapshot) snapshotDTO {
+	dto := snapshotDTO{
+		Version:             snap.Version,
+		TopologyVersion:     snap.TopologyVersion,
+		Timestamp:           snap.Timestamp,
+		SimTime:             snap.SimTime,
+		ActiveSatellites:    nonNilStrings(snap.ActiveSatellites),
+		DisabledSatellites:  nonNilStrings(snap.DisabledSatellites),
+		Coverage:            newCoverageDTO(snap.Coverage),
+		Heatmap:             newHeatmapDTO(snap.Heatmap),
+		Routes:              make(map[string]routeDTO, len(snap.Routes)),
+		ContinuityPenaltyMS: snap.ContinuityPenaltyMS,
+		Classes:             snap.Classes,
+		Slices:              snap.Slices,
+		Shells:              snap.Shells,
+		BGP:                 snap.BGP,
+		StaleRoutes:         snap.StaleRoutes,
+		ServingSatellites:   snap.ServingSatellites,
+		InactiveDemands:     snap.InactiveDemands,
+		FiredHooks:          snap.FiredHooks,
+		Policies:            snap.Policies,
+	}
+	for id, path := range snap.Routes {
+		dto.Routes[id] = newRouteDTO(path)
+	}
+	if len(snap.Allocations) > 0 {
+		dto.Allocations = make(map[string]allocationDTO, len(snap.Allocations))
+		for id, alloc := range snap.Allocations {
+			dto.Allocations[id] = allocationDTO{
+				Status:          string(alloc.Status),
+				Priority:        alloc.Priority,
+				RequestedMbps:   alloc.RequestedMbps,
+				AllocatedMbps:   alloc.AllocatedMbps,
+				QueueingDelayMS: alloc.QueueingDelayMS,
+			}
+		}
+	}
+	return dto
+}
+
+func newCoverageDTO(summary coverage.Summary) coverageDTO {
+	dto := coverageDTO{
+		TotalCells:       summary.TotalCells,
+		CoveredCells:     summary.CoveredCells,
+		CoveragePercent:  summary.CoveragePercent,
+		AreaWeighted:     summary.AreaWeighted,
+		MeanBandwidthMHz: summary.MeanBandwidthMHz,
+		Bounds:           summary.Bounds,
+		Expected:         summary.Expected,
+	}
+	for _, gap := range summary.UncoveredSamples {
+		dto.Gaps = append(dto.Gaps, gapDTO{Lat: gap.Lat, Lon: gap.Lon})
+	}
+	if len(summary.Regions) > 0 {
+		dto.Regions = make(map[string]coverageDTO, len(summary.Regions))
+		for name, region := range summary.Regions {
+			dto.Regions[name] = newCoverageDTO(region)
+		}
+	}
+	return dto
+}
+
+func newHeatmapDTO(cells []coverage.HeatmapCell) []heatmapCellDTO {
+	if len(cells) == 0 {
+		return nil
+	}
+	out := make([]heatmapCellDTO, 0, len(cells))
+	for _, c := range cells {
+		out = append(out, heatmapCellDTO{Lat: c.Lat, Lon: c.Lon, Covered: c.Covered, Count: c.Count, Strength: c.Strength, BandwidthMHz: c.BandwidthMHz})
+	}
+	return out
+}
+
+func newRouteDTO(path routing.Path) routeDTO {
+	dto := routeDTO{
+		Nodes:               nonNilStrings(path.Nodes),
+		LatencyMS:           path.LatencyMS,
+		StabilityS:          path.StabilityS,
+		ContinuityPenaltyMS: path.ContinuityPenaltyMS,
+	}
+	if !math.IsInf(path.BottleneckThroughput, 0) {
+		throughput := path.BottleneckThroughput
+		dto.BottleneckThroughput = &throughput
+	}
+	return dto
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// fieldCasing selects how JSON object keys are rendered.
+type fieldCasing string
+
+const (
+	casingCamel fieldCasing = "camel"
+	casingSnake fieldCasing = "snake"
+)
+
+// parseCasing reads the optional ?casing= query parameter, defaulting to camelCase.
+func parseCasing(raw string) fieldCasing {
+	if strings.EqualFold(raw, string(casingSnake)) {
+		return casingSnake
+	}
+	return casingCamel
+}
+
+// maxPrecision bounds ?precision=; float64 values carry no more meaningful decimals than this.
+const maxPrecision = 9
+
+// parsePrecision reads the optional ?precision= query parameter, the number of decimal places kept
+// for heatmap and gap values. It returns -1 when the parameter is absent.
+func parsePrecision(raw string) (int, error) {
+	if raw == "" {
+		return -1, nil
+	}
+	digits, err := strconv.Atoi(raw)
+	if err != nil || digits < 0 || digits > maxPrecision {
+		return -1, fmt.Errorf("precision must be an integer between 0 and %d", maxPrecision)
+	}
+	return digits, nil
+}
+
+// encodeResponse marshals payload, converts it into units (adding a top-level "units" field unless
+// they are native), rounds heatmap values to precision decimal places (unless it is negative), and
+// rewrites object keys into the requested casing.
+func encodeResponse(payload any, casing fieldCasing, precision int, units Units) ([]byte, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil || (casing == casingCamel && precision < 0 && units.IsNative()) {
+		return encoded, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	if !units.IsNative() {
+		generic = convertUnits(generic, units)
+		if object, ok := generic.(map[string]any); ok {
+			object["units"] = map[string]any{"distance": units.Distance, "angle": units.Angle, "time": units.Time}
+		}
+	}
+	if precision >= 0 {
+		quantize(generic, precision, false)
+	}
+	if casing == casingSnake {
+		generic = rekey(generic, toSnakeCase)
+	}
+	return json.Marshal(generic)
+}
+
+// quantizedFields are the bulky per-cell arrays whose numbers ?precision= rounds.
+var quantizedFields = map[string]bool{"heatmap": true, "gaps": true}
+
+// quantize rounds the numbers inside quantizedFields in place.
+func quantize(value any, digits int, inside bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, inner := range v {
+			if number, ok := inner.(json.Number); ok && inside {
+				v[key] = roundNumber(number, digits)
+				continue
+			}
+			quantize(inner, digits, inside || quantizedFields[key])
+		}
+	case []any:
+		for i := range v {
+			if number, ok := v[i].(json.Number); ok && inside {
+				v[i] = roundNumber(number, digits)
+				continue
+			}
+			quantize(v[i], digits, inside)
+		}
+	}
+}
+
+func roundNumber(number json.Number, digits int) json.Number {
+	f, err := number.Float64()
+	if err != nil {
+		return number
+	}
+	return json.Number(formatRounded(f, digits))
+}
+
+// formatRounded renders f with at most digits decimal places, dropping trailing zeros.
+func formatRounded(f float64, digits int) string {
+	scale := math.Pow10(digits)
+	formatted := strconv.FormatFloat(math.Round(f*scale)/scale, 'f', digits, 64)
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+	if formatted == "-0" {
+		formatted = "0"
+	}
+	return formatted
+}
+
+// idKeyedFields are objects whose keys are identifiers (demand IDs, etc.) rather than field names,
+// so only their values are rewritten.
+var idKeyedFields = map[string]bool{
+	"routes": true, "demands": true, "latency": true, "allocations": true, "classes": true, "blocking": true,
+	"flows": true, "jitter": true, "slices": true, "servingSatellites": true, "shells": true, "policies": true,
+	"regions": true,
+}
+
+func rekey(value any, convert func(string) string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, inner := range v {
+			if nested, ok := inner.(map[string]any); ok && idKeyedFields[key] {
+				preserved := make(map[string]any, len(nested))
+				for id, item := range nested {
+					preserved[id] = rekey(item, convert)
+				}
+				out[convert(key)] = preserved
+				continue
+			}
+			out[convert(key)] = rekey(inner, convert)
+		}
+		return out
+	case []any:
+		for i := range v {
+			v[i] = rekey(v[i], convert)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// toSnakeCase converts camelCase keys such as "latencyMs" into "latency_ms". A run of capitals is
+// one word, so "bandwidthMHz" becomes "bandwidth_mhz".
+func toSnakeCase(key string) string {
+	var b strings.Builder
+	upper := false
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			if i > 0 && !upper {
+				b.WriteByte('_')
+			}
+			upper = true
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
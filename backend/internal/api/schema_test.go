@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/simulation"
+)
+
+func TestToSnakeCaseKeepsCapitalRunsTogether(t *testing.T) {
+	for camel, snake := range map[string]string{
+		"latencyMs":        "latency_ms",
+		"bandwidthMHz":     "bandwidth_mhz",
+		"meanBandwidthMHz": "mean_bandwidth_mhz",
+		"meanMotionDDot":   "mean_motion_ddot",
+		"satelliteID":      "satellite_id",
+		"version":          "version",
+	} {
+		if got := toSnakeCase(camel); got != snake {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", camel, got, snake)
+		}
+	}
+}
+
+func TestStreamedHeatmapFollowsCasing(t *testing.T) {
+	sim, err := simulation.NewSimulator(simulation.Config{
+		GridConfig:     coverage.GridConfig{LatStep: 90, LonStep: 180},
+		Satellites:     []simulation.Satellite{{ID: "sat", Footprint: coverage.Footprint{RadiusKm: 20000, LinkStrength: 1, BandwidthMHz: 250}}},
+		GroundStations: []simulation.GroundStation{{ID: "gs"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	handler := NewServer(Options{}, sim, store.NewMemory()).Handler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/simulation/heatmap?casing=snake", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Heatmap []map[string]any `json:"heatmap"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid heatmap: %v", err)
+	}
+	if len(body.Heatmap) == 0 || body.Heatmap[0]["bandwidth_mhz"] != 250.0 || strings.Contains(rec.Body.String(), "bandwidthMHz") {
+		t.Fatalf("expected snake_case cell keys, got %s", rec.Body)
+	}
+}
+
+func TestSnakeCasingKeepsIdentifierKeys(t *testing.T) {
+	for field, id := range map[string]string{
+		"servingSatellites": "userDemand",
+		"shells":            "upperShell",
+		"policies":          "lowLatency",
+		"regions":           "NorthSea",
+	} {
+		body := map[string]any{field: map[string]any{id: map[string]any{"latencyMs": 1.0}}}
+		converted := rekey(body, toSnakeCase).(map[string]any)
+		inner, ok := converted[toSnakeCase(field)].(map[string]any)
+		if !ok {
+			t.Fatalf("%s: expected the map under %q, got %v", field, toSnakeCase(field), converted)
+		}
+		item, ok := inner[id].(map[string]any)
+		if !ok {
+			t.Fatalf("%s: expected the key %q unchanged, got %v", field, id, inner)
+		}
+		if _, ok := item["latency_ms"]; !ok {
+			t.Fatalf("%s: expected the value's fields in snake_case, got %v", field, item)
+		}
+	}
+}
+
+func TestSnakeCasingKeepsRegionNames(t *testing.T) {
+	sim, err := simulation.NewSimulator(simulation.Config{
+		GridConfig:      coverage.GridConfig{LatStep: 90, LonStep: 180},
+		CoverageRegions: []coverage.Region{{Name: "NorthSea", MinLat: 51, MaxLat: 61, MinLon: -4, MaxLon: 9, LatStep: 1, LonStep: 1}},
+		Satellites:      []simulation.Satellite{{ID: "sat", Footprint: coverage.Footprint{RadiusKm: 20000, LinkStrength: 1}}},
+		GroundStations:  []simulation.GroundStation{{ID: "gs"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	handler := NewServer(Options{}, sim, store.NewMemory()).Handler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/simulation/snapshot?casing=snake", nil))
+	var body struct {
+		Snapshot struct {
+			Coverage struct {
+				Regions map[string]any `json:"regions"`
+			} `json:"coverage"`
+		} `json:"snapshot"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid snapshot: %v", err)
+	}
+	if _, ok := body.Snapshot.Coverage.Regions["NorthSea"]; !ok {
+		t.Fatalf("expected the region name kept, got %s", rec.Body)
+	}
+}
@@ -1,17 +1,84 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/example/satnet/backend/bgp"
+	"github.com/example/satnet/backend/internal/alert"
+	"github.com/example/satnet/backend/internal/eventsink"
+	"github.com/example/satnet/backend/internal/mqtt"
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/internal/tracing"
+	"github.com/example/satnet/backend/internal/webhook"
+	"github.com/example/satnet/backend/internal/worker"
+	"github.com/example/satnet/backend/shard"
 	"github.com/example/satnet/backend/simulation"
 )
 
+// Options configures how the server listens and drives the simulation.
+type Options struct {
+	Addr        string
+	TLSCertFile string
+	TLSKeyFile  string
+	// AutocertDomains enables ACME-managed certificates for these hosts, cached in AutocertCacheDir.
+	AutocertDomains  []string
+	AutocertCacheDir string
+	// H2C serves cleartext HTTP/2 when TLS is disabled, e.g. behind a TLS-terminating proxy.
+	H2C bool
+	// Limits bounds the resources of every session created through the API; MaxSessions caps
+	// how many sessions (including the default one) may exist. Zero disables a limit.
+	Limits      simulation.Limits
+	MaxSessions int
+	// Workers bounds how many recomputes run concurrently; WorkerQueue bounds how many may wait.
+	// Requests beyond that are rejected with 503 rather than stalling the server.
+	Workers     int
+	WorkerQueue int
+	// TickInterval advances the simulation by the same amount of simulated time on a wall-clock
+	// ticker; zero leaves the simulation static between mutations.
+	TickInterval time.Duration
+	// HistorySize is the snapshot history kept by sessions whose scenario does not set one.
+	HistorySize int
+	// Sharder, when set, distributes the recompute work of sessions created through the API.
+	Sharder simulation.Sharder
+	// Propagators are the named propagators scenarios created through the API may use.
+	Propagators map[string]simulation.PropagatorFactory
+	// Plugins are the routers, visibility models and link budgets those scenarios may name.
+	Plugins simulation.Plugins
+	// AdminToken guards /debug/ (pprof, simstats) as a bearer token; empty disables those endpoints.
+	AdminToken string
+	// ShardWorker exposes shard.ComputePath so other API servers can use this one as a shard worker.
+	// Tasks must carry ShardToken as a bearer token, and their grids are held to Limits.
+	ShardWorker bool
+	ShardToken  string
+	// EventSink, when set, receives every session's simulator events.
+	EventSink eventsink.Sink
+	// MQTT, when set, mirrors the default session's satellites and links as retained topics.
+	MQTT *mqtt.Bridge
+	// TelemetryCadence, when positive, emulates satellite telemetry and sends a frame to the event
+	// sink and MQTT bridge every this much simulated time.
+	TelemetryCadence time.Duration
+	// Demo hosts the server as a public, read-only demo with per-visitor sessions.
+	Demo DemoOptions
+	// Tracer, when set, records spans for requests, worker waits, and recompute stages.
+	Tracer *tracing.Tracer
+	// Units are the response units for requests without ?units=; the zero Units keeps native units.
+	Units Units
+}
+
 type Server struct {
-	addr string
-	sim  *simulation.Simulator
+	opts        Options
+	sim         *simulation.Simulator
+	idempotency *idempotencyStore
+	store       store.Store
+	sessions    *sessionRegistry
+	pool        *worker.Pool
+	webhooks    *webhook.Dispatcher
+	alerts      *alert.Engine
+	sink        *eventsink.Forwarder // nil without Options.EventSink
 }
 
 type healthResponse struct {
@@ -20,46 +87,352 @@ type healthResponse struct {
 }
 
 type simulationResponse struct {
-	Message  string              `json:"message"`
-	Snapshot simulation.Snapshot `json:"snapshot"`
+	Message  string      `json:"message"`
+	Snapshot snapshotDTO `json:"snapshot"`
+}
+
+type latencyResponse struct {
+	Demands map[string]simulation.LatencyStats `json:"demands"`
+}
+
+type jitterResponse struct {
+	Demands map[string]simulation.JitterStats `json:"demands"`
+}
+
+type ribResponse struct {
+	Node   string      `json:"node"`
+	Routes []bgp.Route `json:"routes"`
+}
+
+type tcpResponse struct {
+	Demands map[string]simulation.TCPEstimate `json:"demands"`
+}
+
+type admissionResponse struct {
+	Classes map[int]simulation.BlockingStats `json:"classes"`
 }
 
-func NewServer(addr string) *Server {
-	return &Server{
-		addr: addr,
-		sim:  simulation.NewDemoSimulator(),
+func NewServer(opts Options, sim *simulation.Simulator, st store.Store) *Server {
+	s := &Server{
+		opts:        opts,
+		sim:         sim,
+		idempotency: newIdempotencyStore(),
+		store:       st,
+		sessions:    newSessionRegistry(sim, opts),
+		pool:        worker.New(opts.Workers, opts.WorkerQueue),
+		webhooks:    webhook.New(),
+		alerts:      alert.New(),
+	}
+	if opts.EventSink != nil {
+		s.sink = eventsink.NewForwarder(opts.EventSink)
+	}
+	if sess, ok := s.sessions.get(defaultSessionID); ok {
+		s.watch(sess)
 	}
+	if opts.Demo.Enabled && opts.Demo.SessionIdle > 0 {
+		go s.expireIdleSessions(opts.Demo.SessionIdle, min(opts.Demo.SessionIdle/4, time.Minute))
+	}
+	return s
 }
 
-func (s *Server) Start() error {
+// Handler returns the HTTP routes served by the API.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", s.healthHandler)
 	mux.HandleFunc("/simulation/snapshot", s.snapshotHandler)
+	mux.HandleFunc("/simulation/latency", s.latencyHandler)
+	mux.HandleFunc("/simulation/jitter", s.jitterHandler)
+	mux.HandleFunc("/simulation/admission", s.admissionHandler)
+	mux.HandleFunc("/simulation/packets", s.packetsHandler)
+	mux.HandleFunc("/simulation/tcp", s.tcpHandler)
+	mux.HandleFunc("/simulation/rib", s.ribHandler)
+	mux.HandleFunc("/simulation/history", s.historyHandler)
+	mux.HandleFunc("/simulation/heatmap", s.heatmapHandler)
+	mux.HandleFunc("/simulation/grid", s.gridHandler)
+	mux.HandleFunc("/simulation/weathermap", s.weathermapHandler)
+	mux.HandleFunc("/simulation/oem", s.oemHandler)
+	mux.HandleFunc("/satellites/", s.satelliteHandler)
+	mux.HandleFunc("/shells/", s.shellHandler)
+	mux.HandleFunc("/commands", s.commandsHandler)
+	mux.HandleFunc("/contacts", s.contactsHandler)
+	mux.HandleFunc("/demands/", s.demandHandler)
+	mux.HandleFunc("/links/", s.linkHandler)
+	mux.HandleFunc("/audit", s.auditHandler)
+	mux.HandleFunc("/sessions", s.sessionsHandler)
+	mux.HandleFunc("/sessions/", s.sessionHandler)
+	mux.HandleFunc("/runs", s.runsHandler)
+	mux.HandleFunc("/coverage/laydown", s.laydownHandler)
+	mux.HandleFunc("/coverage/gaps", s.gapsHandler)
+	mux.HandleFunc("/coverage/gap-durations", s.gapDurationsHandler)
+	mux.HandleFunc("/coverage/slo", s.sloHandler)
+	mux.HandleFunc("/coverage/phasing", s.phasingHandler)
+	mux.HandleFunc("/scenarios/presets", s.presetsHandler)
+	mux.HandleFunc("/scenarios/oem", s.oemImportHandler)
+	mux.HandleFunc("/scenarios/tle", s.tleImportHandler)
+	mux.HandleFunc("/revisions", s.revisionsHandler)
+	mux.HandleFunc("/revisions/", s.revisionHandler)
+	mux.HandleFunc("/webhooks", s.webhooksHandler)
+	mux.HandleFunc("/webhooks/", s.webhookHandler)
+	mux.HandleFunc("/alerts", s.alertsHandler)
+	mux.HandleFunc("/alerts/rules", s.alertRulesHandler)
+	mux.HandleFunc("/alerts/rules/", s.alertRuleHandler)
+	mux.HandleFunc("/tools/", s.toolsHandler)
+	s.registerDebug(mux)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, notFound("no route for "+r.URL.Path))
+	})
+	var routes http.Handler = mux
+	if s.opts.Demo.Enabled {
+		routes = s.demoGuard(mux)
+	}
+	handler := s.traceRequests(mux, s.validateQuery(s.idempotency.wrap(s.audit(routes))))
+	if !s.opts.ShardWorker {
+		return limitWrites(handler)
+	}
 
+	// Shard tasks are internal traffic: keep them out of the audit log and idempotency cache.
+	root := http.NewServeMux()
+	root.Handle(shard.ComputePath, requireBearer("satnet-shard", s.opts.ShardToken, shard.Handler(limitedWorker{shard.Local{}, s.sessions.limits})))
+	root.Handle("/", handler)
+	return limitWrites(root)
+}
+
+// limitedWorker holds shard tasks from other servers to this server's session grid limits, so a
+// coordinator cannot make it allocate a grid no local session could.
+type limitedWorker struct {
+	shard.Worker
+	limits simulation.Limits
+}
+
+func (w limitedWorker) Compute(ctx context.Context, task shard.Task) (shard.Result, error) {
+	if err := w.limits.CheckLimits(simulation.Config{GridConfig: task.Grid}); err != nil {
+		return shard.Result{}, err
+	}
+	return w.Worker.Compute(ctx, task)
+}
+
+// Request bodies are read through http.MaxBytesReader so a client cannot stream an unbounded body
+// into a decoder. Uploads such as bundles, OEM files and TLE catalogs set their own caps.
+const (
+	// maxRequestBytes bounds small JSON bodies such as alert rules, webhooks and grid changes.
+	maxRequestBytes = 1 << 20
+	// maxScenarioBytes bounds bodies that carry a whole scenario or laydown.
+	maxScenarioBytes = 32 << 20
+)
+
+// writeTimeout bounds writing a response. Routes that legitimately run longer, such as profile
+// captures, extend their own deadline with extendWriteDeadline.
+const writeTimeout = 5 * time.Second
+
+// limitWrites gives every request writeTimeout to write its response, as http.Server.WriteTimeout
+// would, but as a deadline handlers can move.
+func limitWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extendWriteDeadline(w, writeTimeout)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// extendWriteDeadline lets the response take d from now. Writers that cannot set deadlines, such
+// as test recorders, are left unbounded.
+func extendWriteDeadline(w http.ResponseWriter, d time.Duration) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d))
+}
+
+func (s *Server) Start() error {
 	srv := &http.Server{
-		Addr:         s.addr,
-		Handler:      mux,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
+		Addr:        s.opts.Addr,
+		Handler:     s.Handler(),
+		ReadTimeout: 5 * time.Second,
+		// Writes are bounded per request by limitWrites instead, so long captures can extend theirs.
+	}
+
+	if s.opts.TickInterval > 0 {
+		go s.tick(s.opts.TickInterval)
 	}
 
-	log.Printf("API server listening on %s", s.addr)
-	return srv.ListenAndServe()
+	return s.serve(srv)
+}
+
+// tick steps every session forever at the given interval.
+func (s *Server) tick(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, sess := range s.sessions.list() {
+			if !sess.stepping.CompareAndSwap(false, true) {
+				continue
+			}
+			sess := sess
+			err := s.pool.Submit(func() {
+				defer sess.stepping.Store(false)
+				ctx, span := s.opts.Tracer.Start(context.Background(), "tick")
+				defer span.End()
+				span.SetAttribute("session", sess.id)
+				snap, err := sess.sim.Step(interval)
+				if err != nil {
+					span.SetError(err)
+					log.Printf("session %s step failed: %v", sess.id, err)
+					return
+				}
+				s.traceRecompute(ctx, sess.sim, snap.Version)
+			})
+			if err != nil {
+				sess.stepping.Store(false)
+				log.Printf("session %s skipped tick: %v", sess.id, err)
+			}
+		}
+	}
 }
 
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, healthResponse{Status: "ok", Time: time.Now().UTC().Format(time.RFC3339)})
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, r, healthResponse{Status: "ok", Time: time.Now().UTC().Format(time.RFC3339)})
 }
 
 func (s *Server) snapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
 	snap := s.sim.Snapshot()
-	writeJSON(w, simulationResponse{Message: "current simulation state", Snapshot: snap})
+	etag := snapshotETag(snap)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, r, simulationResponse{Message: "current simulation state", Snapshot: newSnapshotDTO(snap)})
+}
+
+func (s *Server) latencyHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, r, latencyResponse{Demands: s.sim.LatencySummary()})
+}
+
+func (s *Server) jitterHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, r, jitterResponse{Demands: s.sim.JitterSummary()})
+}
+
+func (s *Server) tcpHandler(w http.ResponseWriter, r *http.Request) {
+	writeTCP(w, r, s.sim)
+}
+
+// writeTCP serves the estimated TCP goodput of each routed demand.
+func writeTCP(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, r, tcpResponse{Demands: sim.TCPEstimates()})
+}
+
+func (s *Server) ribHandler(w http.ResponseWriter, r *http.Request) {
+	writeRIB(w, r, s.sim)
 }
 
-func writeJSON(w http.ResponseWriter, payload any) {
+// writeRIB serves the best advertised routes held by ?node=.
+func writeRIB(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	node := r.URL.Query().Get("node")
+	if node == "" {
+		writeError(w, r, invalidArgument("node", "node is required"))
+		return
+	}
+	routes := sim.RIB(node)
+	if routes == nil {
+		routes = []bgp.Route{}
+	}
+	writeJSON(w, r, ribResponse{Node: node, Routes: routes})
+}
+
+func (s *Server) admissionHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, r, admissionResponse{Classes: s.sim.AdmissionSummary()})
+}
+
+// compute runs fn on the worker pool and reports whether it completed. When the pool is saturated
+// or the client gives up first, it writes the error response and returns false.
+func (s *Server) compute(w http.ResponseWriter, r *http.Request, fn func()) bool {
+	ctx, span := s.opts.Tracer.Start(r.Context(), "compute")
+	defer span.End()
+	queued := time.Now()
+	err := s.pool.Do(ctx, func() {
+		span.SetAttribute("worker.wait_ms", float64(time.Since(queued).Microseconds())/1000)
+		fn()
+	})
+	span.SetError(err)
+	var panicked *worker.PanicError
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, worker.ErrQueueFull), errors.Is(err, worker.ErrClosed):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, apiError{status: http.StatusServiceUnavailable, Code: codeUnavailable, Message: "simulation workers are busy; retry shortly"})
+	case errors.As(err, &panicked):
+		log.Printf("compute: %v\n%s", panicked, panicked.Stack)
+		writeError(w, r, internalError())
+	default:
+		// The client went away; the work still finishes in the background.
+		log.Printf("request abandoned before recompute finished: %v", err)
+	}
+	return false
+}
+
+// validateQuery rejects malformed response-format parameters before any handler runs, so a bad
+// ?precision= cannot surface only after a mutation was applied.
+// It also resolves the response units: ?units= when given, else the server's default.
+func (s *Server) validateQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := parsePrecision(r.URL.Query().Get("precision")); err != nil {
+			writeError(w, r, invalidArgument("precision", err.Error()))
+			return
+		}
+		units := s.opts.Units
+		if raw := r.URL.Query().Get("units"); raw != "" {
+			parsed, err := ParseUnits(raw)
+			if err != nil {
+				writeError(w, r, invalidArgument("units", err.Error()))
+				return
+			}
+			units = parsed
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), unitsKey{}, units)))
+	})
+}
+
+// writeJSON encodes payload with a 200 status, honoring the optional ?casing=snake query parameter.
+func writeJSON(w http.ResponseWriter, r *http.Request, payload any) {
+	writeJSONStatus(w, r, http.StatusOK, payload)
+}
+
+func writeJSONStatus(w http.ResponseWriter, r *http.Request, status int, payload any) {
+	// An invalid precision was already rejected by validateQuery; fall back to full precision.
+	precision, _ := parsePrecision(r.URL.Query().Get("precision"))
+	units := requestUnits(r)
+	if _, isError := payload.(errorResponse); isError {
+		units = Units{}
+	}
+	body, err := encodeResponse(payload, parseCasing(r.URL.Query().Get("casing")), precision, units)
+	if err != nil {
+		log.Printf("failed to encode response: %v", err)
+		if _, isError := payload.(errorResponse); !isError {
+			writeError(w, r, internalError())
+		}
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
+	w.WriteHeader(status)
+	if _, err := w.Write(append(body, '\n')); err != nil {
 		log.Printf("failed to write response: %v", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
 	}
 }
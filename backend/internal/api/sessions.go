@@ -0,0 +1,486 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+// defaultSessionID names the session created at startup; the top-level /simulation and
+// /satellites endpoints operate on it.
+const defaultSessionID = "default"
+
+// session is an independent simulator owned by one user or experiment.
+type session struct {
+	id      string
+	sim     *simulation.Simulator
+	created time.Time
+	// owner is the demo visitor that created the session; empty outside demo mode.
+	owner string
+	// lastUsed is when a request last addressed the session, in Unix nanoseconds, for demo expiry.
+	lastUsed atomic.Int64
+
+	mu       sync.Mutex
+	lastStep time.Time
+	// stepping is set while a ticker-driven step is queued or running, so slow sessions skip
+	// ticks instead of accumulating a backlog.
+	stepping atomic.Bool
+	// events feeds the session's recomputes to the server's observers; see Server.watch.
+	events *simulation.Subscription
+}
+
+// sessionRegistry tracks live sessions and enforces per-session resource limits.
+type sessionRegistry struct {
+	mu          sync.RWMutex
+	sessions    map[string]*session
+	limits      simulation.Limits
+	maxSessions int
+	sharder     simulation.Sharder
+	propagators map[string]simulation.PropagatorFactory
+	plugins     simulation.Plugins
+	historySize int
+	// perVisitor caps the sessions each demo visitor may own; zero disables the cap.
+	perVisitor int
+}
+
+func newSessionRegistry(defaultSim *simulation.Simulator, opts Options) *sessionRegistry {
+	reg := &sessionRegistry{
+		sessions:    make(map[string]*session),
+		limits:      opts.Limits,
+		maxSessions: opts.MaxSessions,
+		sharder:     opts.Sharder,
+		propagators: opts.Propagators,
+		plugins:     opts.Plugins,
+		historySize: opts.HistorySize,
+		perVisitor:  opts.Demo.SessionsPerVisitor,
+	}
+	reg.sessions[defaultSessionID] = newSession(defaultSessionID, defaultSim)
+	return reg
+}
+
+func newSession(id string, sim *simulation.Simulator) *session {
+	events, err := sim.Subscribe(simulation.SubscribeOptions{Name: "observers", Buffer: 1, Policy: simulation.CoalesceLatest})
+	if err != nil {
+		// The options are constant and valid.
+		panic(err)
+	}
+	sess := &session{id: id, sim: sim, created: time.Now().UTC(), events: events}
+	sess.touch(sess.created)
+	return sess
+}
+
+func (reg *sessionRegistry) get(id string) (*session, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	sess, ok := reg.sessions[id]
+	return sess, ok
+}
+
+func (reg *sessionRegistry) list() []*session {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	out := make([]*session, 0, len(reg.sessions))
+	for _, sess := range reg.sessions {
+		out = append(out, sess)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].created.Before(out[j].created) })
+	return out
+}
+
+// create validates cfg against the limits, then builds and registers a new session owned by owner,
+// a demo visitor or empty.
+func (reg *sessionRegistry) create(cfg simulation.Config, owner string) (*session, error) {
+	cfg.Sharder, cfg.Propagators, cfg.Plugins = reg.sharder, reg.propagators, reg.plugins
+	if cfg.HistorySize == 0 {
+		cfg.HistorySize = reg.historySize
+	}
+	if err := reg.limits.CheckLimits(cfg); err != nil {
+		return nil, err
+	}
+
+	reg.mu.RLock()
+	err := reg.checkCapacityLocked(owner)
+	reg.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	sim, err := simulation.NewSimulator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sess := newSession(newSessionID(), sim)
+	sess.owner = owner
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if err := reg.checkCapacityLocked(owner); err != nil {
+		sess.events.Close()
+		return nil, err
+	}
+	reg.sessions[sess.id] = sess
+	return sess, nil
+}
+
+// checkCapacityLocked reports whether another session, owned by owner, would exceed the limits.
+func (reg *sessionRegistry) checkCapacityLocked(owner string) error {
+	if reg.maxSessions > 0 && len(reg.sessions) >= reg.maxSessions {
+		return errTooManySessions
+	}
+	if owner == "" || reg.perVisitor <= 0 {
+		return nil
+	}
+	owned := 0
+	for _, sess := range reg.sessions {
+		if sess.owner == owner {
+			owned++
+		}
+	}
+	if owned >= reg.perVisitor {
+		return errVisitorSessions
+	}
+	return nil
+}
+
+func (reg *sessionRegistry) remove(id string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	sess, ok := reg.sessions[id]
+	if !ok || id == defaultSessionID {
+		return false
+	}
+	delete(reg.sessions, id)
+	sess.events.Close()
+	return true
+}
+
+// allowStep enforces the minimum wall-clock period between API-driven steps.
+func (sess *session) allowStep(minPeriod time.Duration, now time.Time) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if minPeriod > 0 && !sess.lastStep.IsZero() && now.Sub(sess.lastStep) < minPeriod {
+		return false
+	}
+	sess.lastStep = now
+	return true
+}
+
+// touch records a request addressing the session.
+func (sess *session) touch(now time.Time) {
+	sess.lastUsed.Store(now.UnixNano())
+}
+
+var (
+	errTooManySessions = errors.New("session limit reached")
+	errVisitorSessions = errors.New("visitor session limit reached; delete a session first")
+)
+
+func newSessionID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("s-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+type sessionSummary struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Version uint64    `json:"version"`
+}
+
+type sessionListResponse struct {
+	Sessions []sessionSummary `json:"sessions"`
+	Limits   limitsDTO        `json:"limits"`
+}
+
+type sessionResponse struct {
+	ID       string      `json:"id"`
+	Snapshot snapshotDTO `json:"snapshot"`
+}
+
+type limitsDTO struct {
+	MaxSessions    int     `json:"maxSessions,omitempty"`
+	MaxSatellites  int     `json:"maxSatellites,omitempty"`
+	MaxGridCells   int     `json:"maxGridCells,omitempty"`
+	MaxStepRateHz  float64 `json:"maxStepRateHz,omitempty"`
+	MaxMemoryBytes int64   `json:"maxMemoryBytes,omitempty"`
+}
+
+func (reg *sessionRegistry) limitsDTO() limitsDTO {
+	dto := limitsDTO{
+		MaxSessions:    reg.maxSessions,
+		MaxSatellites:  reg.limits.MaxSatellites,
+		MaxGridCells:   reg.limits.MaxGridCells,
+		MaxMemoryBytes: reg.limits.MaxMemoryBytes,
+	}
+	if reg.limits.MinStepPeriod > 0 {
+		dto.MaxStepRateHz = float64(time.Second) / float64(reg.limits.MinStepPeriod)
+	}
+	return dto
+}
+
+// sessionsHandler serves POST/GET /sessions. POSTing a zip archive imports a session bundle.
+func (s *Server) sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		resp := sessionListResponse{Sessions: []sessionSummary{}, Limits: s.sessions.limitsDTO()}
+		visitor := s.visitor(r)
+		for _, sess := range s.sessions.list() {
+			if sess.owner != "" && sess.owner != visitor {
+				continue
+			}
+			resp.Sessions = append(resp.Sessions, sessionSummary{ID: sess.id, Created: sess.created, Version: sess.sim.Snapshot().Version})
+		}
+		writeJSON(w, r, resp)
+		return
+	}
+
+	if isBundleUpload(r) {
+		s.importSession(w, r)
+		return
+	}
+	cfg, ok := s.sessionScenario(w, r)
+	if !ok {
+		return
+	}
+	var sess *session
+	var err error
+	if !s.compute(w, r, func() { sess, err = s.sessions.create(cfg, s.visitor(r)) }) {
+		return
+	}
+	if err != nil {
+		writeError(w, r, sessionCreateError(err))
+		return
+	}
+	s.watch(sess)
+	snap := sess.sim.Snapshot()
+	s.traceRecompute(r.Context(), sess.sim, snap.Version)
+	w.Header().Set("Location", "/sessions/"+sess.id)
+	w.Header().Set("ETag", snapshotETag(snap))
+	writeJSONStatus(w, r, http.StatusCreated, sessionResponse{ID: sess.id, Snapshot: newSnapshotDTO(snap)})
+}
+
+// sessionScenario reads the scenario a new session starts from: the built-in one named by ?preset=,
+// the saved ?revision=, or else the request body. It writes the error response when it fails.
+func (s *Server) sessionScenario(w http.ResponseWriter, r *http.Request) (simulation.Config, bool) {
+	query := r.URL.Query()
+	if preset := query.Get("preset"); preset != "" {
+		cfg, err := simulation.PresetScenario(preset)
+		if err != nil {
+			writeError(w, r, invalidArgument("preset", err.Error()))
+			return simulation.Config{}, false
+		}
+		return cfg, true
+	}
+
+	body := io.Reader(http.MaxBytesReader(w, r.Body, maxScenarioBytes))
+	field := "body"
+	if raw := query.Get("revision"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, r, invalidArgument("revision", "unknown revision "+raw))
+			return simulation.Config{}, false
+		}
+		rev, ok, err := s.store.GetRevision(id)
+		if err != nil {
+			log.Printf("failed to read revision %d: %v", id, err)
+			writeError(w, r, internalError())
+			return simulation.Config{}, false
+		}
+		if !ok {
+			writeError(w, r, invalidArgument("revision", "unknown revision "+raw))
+			return simulation.Config{}, false
+		}
+		body, field = bytes.NewReader(rev.Scenario), "revision"
+	}
+	cfg, err := simulation.ParseScenario(body)
+	if err != nil {
+		writeError(w, r, invalidArgument(field, err.Error()))
+		return simulation.Config{}, false
+	}
+	return cfg, true
+}
+
+// sessionHandler serves /sessions/{id} (GET, DELETE), /sessions/{id}/step (POST, ?dt=),
+// /sessions/{id}/history, /sessions/{id}/heatmap, /sessions/{id}/grid (GET, PUT),
+// /sessions/{id}/packets, /sessions/{id}/tcp, /sessions/{id}/rib, /sessions/{id}/weathermap,
+// /sessions/{id}/commands, /sessions/{id}/contacts, /sessions/{id}/links/{from}/{to}/utilization,
+// /sessions/{id}/demands/{demand}/explain, /sessions/{id}/bundle (GET), /sessions/{id}/oem (GET),
+// /sessions/{id}/phasing (POST), and /sessions/{id}/tools/traceroute and /sessions/{id}/tools/ping
+// (POST).
+func (s *Server) sessionHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/sessions/"), "/"), "/")
+	sess, ok := s.sessions.get(parts[0])
+	if !ok {
+		writeError(w, r, notFound("unknown session "+parts[0]))
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		if !allowMethods(w, r, http.MethodGet, http.MethodDelete) {
+			return
+		}
+		if r.Method == http.MethodDelete {
+			if !s.sessions.remove(sess.id) {
+				writeError(w, r, apiError{status: http.StatusConflict, Code: codeConflict, Message: "the default session cannot be deleted"})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		snap := sess.sim.Snapshot()
+		w.Header().Set("ETag", snapshotETag(snap))
+		writeJSON(w, r, sessionResponse{ID: sess.id, Snapshot: newSnapshotDTO(snap)})
+
+	case len(parts) == 2 && parts[1] == "step":
+		if !allowMethods(w, r, http.MethodPost) {
+			return
+		}
+		dt := time.Second
+		if raw := r.URL.Query().Get("dt"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed <= 0 {
+				writeError(w, r, invalidArgument("dt", "dt must be a positive duration such as 10s"))
+				return
+			}
+			dt = parsed
+		}
+		if !sess.allowStep(s.sessions.limits.MinStepPeriod, time.Now()) {
+			writeError(w, r, apiError{
+				status:  http.StatusTooManyRequests,
+				Code:    codeRateLimited,
+				Message: "session step rate limit exceeded",
+				Details: map[string]any{"minStepPeriod": s.sessions.limits.MinStepPeriod.String()},
+			})
+			return
+		}
+		var snap simulation.Snapshot
+		var err error
+		if !s.compute(w, r, func() { snap, err = sess.sim.Step(dt) }) {
+			return
+		}
+		if err != nil {
+			writeError(w, r, stepError(err))
+			return
+		}
+		s.traceRecompute(r.Context(), sess.sim, snap.Version)
+		w.Header().Set("ETag", snapshotETag(snap))
+		writeJSON(w, r, sessionResponse{ID: sess.id, Snapshot: newSnapshotDTO(snap)})
+
+	case len(parts) == 2 && parts[1] == "history":
+		writeHistory(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "heatmap":
+		writeHeatmap(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "grid":
+		s.writeGrid(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "gaps":
+		writeGaps(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "gap-durations":
+		s.writeGapDurations(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "phasing":
+		s.writePhasing(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "slo":
+		s.writeLatencySLO(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "packets":
+		s.writePackets(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "tcp":
+		writeTCP(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "rib":
+		writeRIB(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "weathermap":
+		writeWeathermap(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "commands":
+		writeCommands(w, r, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "contacts":
+		writeContacts(w, r, sess.sim)
+
+	case len(parts) == 5 && parts[1] == "links" && parts[4] == "utilization":
+		s.writeUtilization(w, r, sess.id, sess.sim, parts[2], parts[3])
+
+	case len(parts) == 4 && parts[1] == "demands" && parts[3] == "explain":
+		s.writeExplain(w, r, sess.sim, parts[2])
+
+	case len(parts) == 2 && parts[1] == "bundle":
+		writeBundle(w, r, sess.id, sess.sim)
+
+	case len(parts) == 2 && parts[1] == "oem":
+		s.writeOEM(w, r, "satnet-"+sess.id, sess.sim)
+
+	case len(parts) == 3 && parts[1] == "tools":
+		s.writeTool(w, r, sess.sim, parts[2])
+
+	default:
+		writeError(w, r, notFound("no route for "+r.URL.Path))
+	}
+}
+
+// stepError maps a failed step. Only an invalid duration is the caller's dt; a propagator failure
+// or a satellite stepping past its ephemeris is the scenario's, and a broken invariant is ours.
+func stepError(err error) apiError {
+	var invariantErr *simulation.InvariantError
+	switch {
+	case errors.Is(err, simulation.ErrInvalidStep):
+		return invalidArgument("dt", err.Error())
+	case errors.As(err, &invariantErr):
+		return apiError{status: http.StatusInternalServerError, Code: codeInternal, Message: err.Error()}
+	default:
+		return apiError{status: http.StatusUnprocessableEntity, Code: codeStepFailed, Message: err.Error()}
+	}
+}
+
+func sessionCreateError(err error) apiError {
+	var limitErr *simulation.LimitError
+	var dupErr simulation.ErrDuplicateID
+	switch {
+	case errors.As(err, &limitErr):
+		return apiError{
+			status:  http.StatusUnprocessableEntity,
+			Code:    codeQuotaExceeded,
+			Message: limitErr.Error(),
+			Field:   limitErr.Field,
+			Details: map[string]any{"limit": limitErr.Limit, "value": limitErr.Value},
+		}
+	case errors.As(err, &dupErr):
+		return apiError{
+			status:  http.StatusConflict,
+			Code:    codeConflict,
+			Message: dupErr.Error(),
+			Field:   "body",
+			Details: map[string]any{"id": dupErr.ID},
+		}
+	case errors.Is(err, errTooManySessions), errors.Is(err, errVisitorSessions):
+		return apiError{status: http.StatusTooManyRequests, Code: codeQuotaExceeded, Message: err.Error()}
+	default:
+		return invalidArgument("body", err.Error())
+	}
+}
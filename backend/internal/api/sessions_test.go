@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/simulation"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestStepFailuresKeepTheirOwnCode(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	orbit := orbits.KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.01, Inclination: 0.9, Epoch: epoch}
+	ephemeris := &orbits.Ephemeris{Interpolation: orbits.InterpolationHermite}
+	for i := 0; i <= 10; i++ {
+		at := epoch.Add(time.Duration(i) * time.Minute)
+		state := orbit.StateAt(at)
+		ephemeris.Points = append(ephemeris.Points, orbits.EphemerisPoint{Time: at, Position: state.Position, Velocity: state.Velocity})
+	}
+	sim, err := simulation.NewSimulator(simulation.Config{
+		GridConfig:     coverage.GridConfig{LatStep: 90, LonStep: 180},
+		Satellites:     []simulation.Satellite{{ID: "flight-dynamics", Ephemeris: ephemeris, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}}},
+		GroundStations: []simulation.GroundStation{{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}}},
+		Epoch:          epoch,
+	})
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	handler := NewServer(Options{}, sim, store.NewMemory()).Handler()
+	step := func(dt string) (int, apiError) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sessions/default/step?dt="+dt, nil))
+		var body errorResponse
+		if rec.Code != http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid error body: %s", rec.Body)
+			}
+		}
+		return rec.Code, body.Error
+	}
+
+	if status, body := step("1m"); status != http.StatusOK {
+		t.Fatalf("expected a step within the ephemeris to succeed, got %d: %+v", status, body)
+	}
+	if status, body := step("-1s"); status != http.StatusBadRequest || body.Field != "dt" {
+		t.Fatalf("expected a negative dt to be rejected on dt, got %d: %+v", status, body)
+	}
+	status, body := step("1h")
+	if status != http.StatusUnprocessableEntity || body.Code != codeStepFailed || body.Field != "" {
+		t.Fatalf("expected stepping past the ephemeris to fail the step, got %d: %+v", status, body)
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+// defaultLatencySLOMS is the one-way latency SLO applied when a request does not set ?sloMs=.
+const defaultLatencySLOMS = 50
+
+func (s *Server) sloHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeLatencySLO(w, r, s.sim)
+}
+
+// writeLatencySLO serves the per-cell compliance map of the latest recompute against ?sloMs=.
+func (s *Server) writeLatencySLO(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	sloMS := float64(defaultLatencySLOMS)
+	if raw := r.URL.Query().Get("sloMs"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(parsed > 0) || math.IsInf(parsed, 1) {
+			writeError(w, r, invalidArgument("sloMs", "sloMs must be a positive number of milliseconds"))
+			return
+		}
+		sloMS = parsed
+	}
+
+	var (
+		result simulation.LatencySLOMap
+		err    error
+	)
+	if !s.compute(w, r, func() { result, err = sim.LatencySLO(sloMS) }) {
+		return
+	}
+	if err != nil {
+		writeError(w, r, invalidArgument("sloMs", err.Error()))
+		return
+	}
+	w.Header().Set("ETag", formatETag(result.Version))
+	writeJSON(w, r, result)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/example/satnet/backend/internal/eventsink"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// telemetryEvent is the event sink type of emulated satellite telemetry frames.
+const telemetryEvent = "telemetry"
+
+// telemetryMessage is the message body of a telemetry frame published to the event sink.
+type telemetryMessage struct {
+	Type    string                    `json:"type"`
+	Session string                    `json:"session"`
+	Frame   simulation.TelemetryFrame `json:"frame"`
+}
+
+// forwardTelemetry publishes one telemetry frame of a session.
+func (s *Server) forwardTelemetry(session string, frame simulation.TelemetryFrame) {
+	body, err := json.Marshal(telemetryMessage{Type: telemetryEvent, Session: session, Frame: frame})
+	if err != nil {
+		log.Printf("encode telemetry for sink: %v", err)
+		return
+	}
+	s.sink.Send(eventsink.Message{Session: session, Type: telemetryEvent, Value: body})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/example/satnet/backend/orbits"
+)
+
+// maxTLEBytes bounds an uploaded element set catalog.
+const maxTLEBytes = 16 << 20
+
+// tleSatellite is a satellite entry built from one element set, ready to add to a scenario.
+type tleSatellite struct {
+	ID   string              `json:"id"`
+	Name string              `json:"name,omitempty"`
+	TLE  *orbits.TLEElements `json:"tle"`
+}
+
+type tleImportResponse struct {
+	Satellites []tleSatellite `json:"satellites"`
+}
+
+// tleImportHandler serves POST /scenarios/tle, converting an uploaded catalog of two- and
+// three-line element sets into satellite entries propagated with SGP4, one per catalog number.
+func (s *Server) tleImportHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	sets, err := orbits.ParseTLEFile(http.MaxBytesReader(w, r.Body, maxTLEBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, invalidArgument("body", "catalog exceeds "+strconv.Itoa(maxTLEBytes)+" bytes"))
+			return
+		}
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+	response := tleImportResponse{Satellites: make([]tleSatellite, 0, len(sets))}
+	seen := make(map[string]bool, len(sets))
+	for _, set := range sets {
+		if seen[set.CatalogNumber] {
+			writeError(w, r, invalidArgument("body", "catalog number "+set.CatalogNumber+" has more than one element set"))
+			return
+		}
+		seen[set.CatalogNumber] = true
+		elements := set.Elements
+		if _, err := orbits.NewSGP4(elements); err != nil {
+			writeError(w, r, invalidArgument("body", "catalog number "+set.CatalogNumber+": "+err.Error()))
+			return
+		}
+		response.Satellites = append(response.Satellites, tleSatellite{ID: set.CatalogNumber, Name: set.Name, TLE: &elements})
+	}
+	writeJSON(w, r, response)
+}
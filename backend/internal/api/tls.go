@@ -0,0 +1,53 @@
+package api
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// serve starts srv with the transport security selected in the options: static certificate files,
+// ACME-managed certificates for the configured domains, or plaintext (optionally speaking h2c).
+// HTTP/2 is negotiated via ALPN whenever TLS is enabled.
+func (s *Server) serve(srv *http.Server) error {
+	switch {
+	case s.opts.TLSCertFile != "":
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if err := http2.ConfigureServer(srv, &http2.Server{}); err != nil {
+			return err
+		}
+		log.Printf("API server listening on %s (TLS, HTTP/2)", s.opts.Addr)
+		return srv.ListenAndServeTLS(s.opts.TLSCertFile, s.opts.TLSKeyFile)
+
+	case len(s.opts.AutocertDomains) > 0:
+		if s.opts.AutocertCacheDir == "" {
+			return errors.New("autocert requires a cache directory")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.opts.AutocertDomains...),
+			Cache:      autocert.DirCache(s.opts.AutocertCacheDir),
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		if err := http2.ConfigureServer(srv, &http2.Server{}); err != nil {
+			return err
+		}
+		log.Printf("API server listening on %s (ACME TLS for %v, HTTP/2)", s.opts.Addr, s.opts.AutocertDomains)
+		return srv.ListenAndServeTLS("", "")
+
+	case s.opts.H2C:
+		srv.Handler = h2c.NewHandler(srv.Handler, &http2.Server{})
+		log.Printf("API server listening on %s (cleartext HTTP/2)", s.opts.Addr)
+		return srv.ListenAndServe()
+
+	default:
+		log.Printf("API server listening on %s", s.opts.Addr)
+		return srv.ListenAndServe()
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// defaultPingCount is how many probes a ping sends when the request does not say.
+const defaultPingCount = 10
+
+type probeRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Count and IntervalS only apply to ping; they default to defaultPingCount probes a second apart.
+	Count     int     `json:"count,omitempty"`
+	IntervalS float64 `json:"intervalS,omitempty"`
+}
+
+// toolsHandler serves POST /tools/traceroute and POST /tools/ping against the default simulator.
+func (s *Server) toolsHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeTool(w, r, s.sim, strings.Trim(strings.TrimPrefix(r.URL.Path, "/tools/"), "/"))
+}
+
+// writeTool runs a network debugging tool against a simulator: traceroute lists the hops of the
+// current route with their accumulated latency, and ping samples the round trip as the simulation
+// advances, without advancing the simulator itself.
+func (s *Server) writeTool(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator, tool string) {
+	if tool != "traceroute" && tool != "ping" {
+		writeError(w, r, notFound("no route for "+r.URL.Path))
+		return
+	}
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req probeRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, invalidArgument("body", "decode "+tool+" request: "+err.Error()))
+		return
+	}
+	switch {
+	case req.From == "":
+		writeError(w, r, invalidArgument("from", "from is required"))
+		return
+	case req.To == "":
+		writeError(w, r, invalidArgument("to", "to is required"))
+		return
+	case req.From == req.To:
+		writeError(w, r, invalidArgument("to", "to must differ from from"))
+		return
+	}
+
+	if tool == "traceroute" {
+		var trace simulation.Traceroute
+		var err error
+		if !s.compute(w, r, func() { trace, err = sim.Traceroute(req.From, req.To) }) {
+			return
+		}
+		if !probeError(w, r, err) {
+			return
+		}
+		w.Header().Set("ETag", formatETag(trace.Version))
+		writeJSON(w, r, trace)
+		return
+	}
+
+	count, interval := defaultPingCount, time.Second
+	if req.Count != 0 {
+		if req.Count < 0 || req.Count > maxRunSteps {
+			writeError(w, r, invalidArgument("count", "count must be between 1 and "+strconv.Itoa(maxRunSteps)))
+			return
+		}
+		count = req.Count
+	}
+	if req.IntervalS != 0 {
+		interval = time.Duration(req.IntervalS * float64(time.Second))
+		if interval <= 0 {
+			writeError(w, r, invalidArgument("intervalS", "intervalS must be positive"))
+			return
+		}
+	}
+	var series simulation.PingSeries
+	var err error
+	if !s.compute(w, r, func() { series, err = sim.Ping(req.From, req.To, count, interval) }) {
+		return
+	}
+	if !probeError(w, r, err) {
+		return
+	}
+	w.Header().Set("ETag", formatETag(series.Version))
+	writeJSON(w, r, series)
+}
+
+// probeError writes the response for a failed traceroute or ping and reports whether err was nil.
+func probeError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, routing.ErrUnknownNode):
+		writeError(w, r, notFound(err.Error()))
+		return false
+	case err != nil:
+		log.Printf("probe: %v", err)
+		writeError(w, r, internalError())
+		return false
+	}
+	return true
+}
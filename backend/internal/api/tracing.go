@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+// traceRequests starts a server span for every request, named by the method and the mux pattern
+// that serves it, and makes it the parent of the spans the handler records.
+func (s *Server) traceRequests(mux *http.ServeMux, next http.Handler) http.Handler {
+	if s.opts.Tracer == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		if pattern == "" || pattern == "/" {
+			pattern = "unmatched"
+		}
+		ctx, span := s.opts.Tracer.StartServer(r.Context(), r.Method+" "+pattern, r.Header.Get("traceparent"))
+		defer span.End()
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("http.route", pattern)
+		span.SetAttribute("url.path", r.URL.Path)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttribute("http.response.status_code", rec.status)
+		if rec.status >= 500 {
+			span.SetError(errors.New(http.StatusText(rec.status)))
+		}
+	})
+}
+
+// traceRecompute records the stages of the recompute that produced version as a recompute span
+// under the span in ctx, with route searches nested under the routing stage.
+func (s *Server) traceRecompute(ctx context.Context, sim *simulation.Simulator, version uint64) {
+	if s.opts.Tracer == nil {
+		return
+	}
+	stages, ok := sim.RecomputeTrace(version)
+	if !ok || len(stages) == 0 {
+		return
+	}
+	start, end := stages[0].Start, stages[0].End
+	for _, stage := range stages {
+		if stage.Start.Before(start) {
+			start = stage.Start
+		}
+		if stage.End.After(end) {
+			end = stage.End
+		}
+	}
+	recomputeCtx, recompute := s.opts.Tracer.StartAt(ctx, "recompute", start)
+	recompute.SetAttribute("snapshot.version", version)
+
+	parents := make(map[string]context.Context)
+	record := func(parent context.Context, stage simulation.StageSpan) context.Context {
+		stageCtx, span := s.opts.Tracer.StartAt(parent, stage.Name, stage.Start)
+		for key, value := range stage.Attributes {
+			span.SetAttribute(key, value)
+		}
+		span.EndAt(stage.End)
+		return stageCtx
+	}
+	for _, stage := range stages {
+		if stage.Parent == "" {
+			parents[stage.Name] = record(recomputeCtx, stage)
+		}
+	}
+	for _, stage := range stages {
+		if stage.Parent == "" {
+			continue
+		}
+		parent, ok := parents[stage.Parent]
+		if !ok {
+			parent = recomputeCtx
+		}
+		record(parent, stage)
+	}
+	recompute.EndAt(end)
+}
+
+// statusRecorder notes a response's status without buffering it, so streamed responses still flush.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Units selects the units of API output. The zero Units keeps every field in its native unit and
+// name; any other value converts each unit-bearing field and names its unit in the key, so
+// "latencyMs" becomes "latencyS" in seconds and "lat" becomes "latDeg" or "latRad".
+type Units struct {
+	Distance string `json:"distance"`
+	Angle    string `json:"angle"`
+	Time     string `json:"time"`
+}
+
+// IsNative reports whether u leaves responses in their native units.
+func (u Units) IsNative() bool {
+	return u == Units{}
+}
+
+// defaultUnits are the units of the dimensions a unit list does not name.
+var defaultUnits = Units{Distance: "km", Angle: "deg", Time: "ms"}
+
+// ParseUnits reads a comma-separated unit list such as "m,rad,s". Each entry picks the unit of its
+// dimension: km or m, deg or rad, ms or s; unnamed dimensions use km, deg and ms. An empty list or
+// "native" returns the zero Units.
+func ParseUnits(raw string) (Units, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "native" {
+		return Units{}, nil
+	}
+	units := defaultUnits
+	for _, entry := range strings.Split(raw, ",") {
+		switch unit := strings.ToLower(strings.TrimSpace(entry)); unit {
+		case "km", "m":
+			units.Distance = unit
+		case "deg", "rad":
+			units.Angle = unit
+		case "ms", "s":
+			units.Time = unit
+		default:
+			return Units{}, fmt.Errorf("unknown unit %q; use km or m, deg or rad, ms or s, or native", unit)
+		}
+	}
+	return units, nil
+}
+
+type unitsKey struct{}
+
+// requestUnits returns the units resolved for r by validateQuery.
+func requestUnits(r *http.Request) Units {
+	units, _ := r.Context().Value(unitsKey{}).(Units)
+	return units
+}
+
+// unitSuffixes name each unit as it appears at the end of a camelCase key.
+var unitSuffixes = map[string]string{"km": "Km", "m": "M", "deg": "Deg", "rad": "Rad", "ms": "Ms", "s": "S"}
+
+// implicitUnits lists fields whose names do not state their unit.
+var implicitUnits = map[string]string{
+	"lat": "deg", "lon": "deg", "centerLat": "deg", "centerLon": "deg", "subLat": "deg", "subLon": "deg",
+	"latStep": "deg", "lonStep": "deg", "elevationMask": "rad",
+	"centroidLat": "deg", "centroidLon": "deg", "minLat": "deg", "maxLat": "deg",
+}
+
+// nativeUnitFields hold documents that are read back as input, such as saved scenarios, so their
+// fields keep the units the scenario format defines.
+var nativeUnitFields = map[string]bool{"scenario": true}
+
+// fieldUnit splits a camelCase key into its base name and the unit of its value.
+func fieldUnit(key string) (base, unit string, ok bool) {
+	if unit, ok := implicitUnits[key]; ok {
+		return key, unit, true
+	}
+	for _, candidate := range []string{"Km", "Deg", "Ms"} {
+		if base, found := strings.CutSuffix(key, candidate); found && base != "" {
+			return base, strings.ToLower(candidate), true
+		}
+	}
+	// A trailing capital S after a lowercase letter marks seconds, as in "stabilityS".
+	if n := len(key); n > 1 && key[n-1] == 'S' && key[n-2] >= 'a' && key[n-2] <= 'z' {
+		return key[:n-1], "s", true
+	}
+	return "", "", false
+}
+
+// unitScale converts a value in unit from into the matching unit of units.
+func unitScale(from string, units Units) (to string, scale float64) {
+	switch from {
+	case "km":
+		to = units.Distance
+	case "deg", "rad":
+		to = units.Angle
+	case "ms", "s":
+		to = units.Time
+	}
+	switch {
+	case from == to:
+		return to, 1
+	case from == "km":
+		return to, 1000
+	case from == "deg":
+		return to, math.Pi / 180
+	case from == "rad":
+		return to, 180 / math.Pi
+	case from == "ms":
+		return to, 1e-3
+	default:
+		return to, 1e3
+	}
+}
+
+// convertUnits rewrites the unit-bearing numeric fields of a decoded response into units, renaming
+// each key for its unit. Identifier-keyed maps keep their keys.
+func convertUnits(value any, units Units) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, inner := range v {
+			switch {
+			case nativeUnitFields[key]:
+				out[key] = inner
+			case idKeyedFields[key]:
+				if nested, ok := inner.(map[string]any); ok {
+					for id, item := range nested {
+						nested[id] = convertUnits(item, units)
+					}
+					out[key] = nested
+				} else {
+					out[key] = convertUnits(inner, units)
+				}
+			default:
+				base, unit, ok := fieldUnit(key)
+				if !ok || !scaleNumbers(inner) {
+					out[key] = convertUnits(inner, units)
+					continue
+				}
+				to, scale := unitScale(unit, units)
+				out[base+unitSuffixes[to]] = scaled(inner, scale)
+			}
+		}
+		return out
+	case []any:
+		for i := range v {
+			v[i] = convertUnits(v[i], units)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// scaleNumbers reports whether value is a number or an array of numbers.
+func scaleNumbers(value any) bool {
+	switch v := value.(type) {
+	case json.Number:
+		return true
+	case []any:
+		for _, item := range v {
+			if _, ok := item.(json.Number); !ok {
+				return false
+			}
+		}
+		return len(v) > 0
+	}
+	return false
+}
+
+func scaled(value any, scale float64) any {
+	if scale == 1 {
+		return value
+	}
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v
+		}
+		return json.Number(strconv.FormatFloat(f*scale, 'g', -1, 64))
+	case []any:
+		for i := range v {
+			v[i] = scaled(v[i], scale)
+		}
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/internal/store"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// defaultUtilizationRange is the span of simulated time a utilization query covers by default.
+const defaultUtilizationRange = time.Hour
+
+type utilizationResponse struct {
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	ResolutionS float64            `json:"resolutionS"`
+	Start       time.Time          `json:"start"`
+	End         time.Time          `json:"end"`
+	Points      []utilizationPoint `json:"points"`
+}
+
+// utilizationPoint is a stored rollup without the series fields the response already states.
+type utilizationPoint struct {
+	Start              time.Time `json:"start"`
+	Samples            int       `json:"samples"`
+	MeanLoadMbps       float64   `json:"meanLoadMbps"`
+	MaxLoadMbps        float64   `json:"maxLoadMbps"`
+	CapacityMbps       float64   `json:"capacityMbps,omitempty"`
+	MeanUtilizationPct float64   `json:"meanUtilizationPct"`
+	MaxUtilizationPct  float64   `json:"maxUtilizationPct"`
+}
+
+// storeRollups persists the link utilization rollups a session's latest recompute completed.
+func (s *Server) storeRollups(session string, rollups []simulation.LinkRollup) {
+	if len(rollups) == 0 {
+		return
+	}
+	stored := make([]store.Rollup, len(rollups))
+	for i, r := range rollups {
+		stored[i] = store.Rollup{
+			Session: session, From: r.From, To: r.To, ResolutionS: r.ResolutionS, Start: r.Start, Samples: r.Samples,
+			MeanLoadMbps: r.MeanLoadMbps, MaxLoadMbps: r.MaxLoadMbps, CapacityMbps: r.CapacityMbps,
+			MeanUtilizationPct: r.MeanUtilizationPct, MaxUtilizationPct: r.MaxUtilizationPct,
+		}
+	}
+	if err := s.store.AppendRollups(stored); err != nil {
+		log.Printf("store utilization rollups for session %s: %v", session, err)
+	}
+}
+
+// linkHandler serves GET /links/{from}/{to}/utilization for the default session.
+func (s *Server) linkHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/links/"), "/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] != "utilization" {
+		writeError(w, r, notFound("no route for "+r.URL.Path))
+		return
+	}
+	s.writeUtilization(w, r, defaultSessionID, s.sim, parts[0], parts[1])
+}
+
+// writeUtilization serves the stored utilization rollups of one direction of a link over ?range=
+// (default an hour) of simulated time ending at the session's clock. ?resolution= picks 1m, 5m or
+// 1h rollups; by default the finest that keeps the whole range.
+func (s *Server) writeUtilization(w http.ResponseWriter, r *http.Request, session string, sim *simulation.Simulator, from, to string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	span := defaultUtilizationRange
+	if raw := query.Get("range"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, r, invalidArgument("range", "range must be a positive duration such as 24h"))
+			return
+		}
+		span = parsed
+	}
+	var resolution time.Duration
+	if raw := query.Get("resolution"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || !rollupResolution(parsed) {
+			writeError(w, r, invalidArgument("resolution", "resolution must be 1m, 5m or 1h"))
+			return
+		}
+		resolution = parsed
+	} else {
+		resolution = simulation.RollupResolutions[len(simulation.RollupResolutions)-1]
+		for _, candidate := range simulation.RollupResolutions {
+			if span/candidate <= store.MaxRollupsPerSeries {
+				resolution = candidate
+				break
+			}
+		}
+	}
+
+	end := sim.Snapshot().SimTime
+	start := end.Add(-span)
+	rollups, err := s.store.ListRollups(store.RollupQuery{
+		Session: session, From: from, To: to, ResolutionS: resolution.Seconds(), Start: start.Truncate(resolution), End: end,
+	})
+	if err != nil {
+		log.Printf("list utilization rollups: %v", err)
+		writeError(w, r, internalError())
+		return
+	}
+	resp := utilizationResponse{From: from, To: to, ResolutionS: resolution.Seconds(), Start: start, End: end, Points: make([]utilizationPoint, len(rollups))}
+	for i, rollup := range rollups {
+		resp.Points[i] = utilizationPoint{
+			Start: rollup.Start, Samples: rollup.Samples, MeanLoadMbps: rollup.MeanLoadMbps, MaxLoadMbps: rollup.MaxLoadMbps,
+			CapacityMbps: rollup.CapacityMbps, MeanUtilizationPct: rollup.MeanUtilizationPct, MaxUtilizationPct: rollup.MaxUtilizationPct,
+		}
+	}
+	writeJSON(w, r, resp)
+}
+
+// rollupResolution reports whether utilization is rolled up at resolution.
+func rollupResolution(resolution time.Duration) bool {
+	for _, candidate := range simulation.RollupResolutions {
+		if resolution == candidate {
+			return true
+		}
+	}
+	return false
+}
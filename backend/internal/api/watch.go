@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/example/satnet/backend/internal/alert"
+	"github.com/example/satnet/backend/internal/eventsink"
+	"github.com/example/satnet/backend/internal/webhook"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// sinkEvent is the message body published to the event sink.
+type sinkEvent struct {
+	Type     simulation.EventType `json:"type"`
+	Session  string               `json:"session"`
+	Version  uint64               `json:"version"`
+	SimTime  time.Time            `json:"simTime"`
+	Snapshot snapshotDTO          `json:"snapshot"`
+}
+
+// watch hands each recompute of a session to the server's observers until the session is
+// removed. Events are coalesced, so a slow observer sees the latest state rather than a backlog.
+func (s *Server) watch(sess *session) {
+	go func() {
+		var bands simulation.BandTracker
+		var rollups simulation.RollupAggregator
+		emulator := simulation.TelemetryEmulator{Cadence: s.opts.TelemetryCadence}
+		// telemetry is the latest frame, which the MQTT bridge keeps retained between frames.
+		var telemetry simulation.TelemetryFrame
+		for evt := range sess.events.Events() {
+			if s.sink != nil {
+				s.forward(sess.id, evt)
+			}
+			if evt.Type != simulation.EventCoverageUpdated {
+				continue
+			}
+			weathermap := sess.sim.Weathermap()
+			if s.sink != nil {
+				s.forwardUtilization(sess.id, weathermap, &bands)
+			}
+			s.storeRollups(sess.id, rollups.Observe(weathermap))
+			mirror := s.opts.MQTT != nil && sess.id == defaultSessionID
+			if s.opts.TelemetryCadence > 0 || mirror {
+				topo := sess.sim.Topology()
+				if s.opts.TelemetryCadence > 0 {
+					if frame, due := emulator.Observe(topo); due {
+						telemetry = frame
+						if s.sink != nil {
+							s.forwardTelemetry(sess.id, frame)
+						}
+					}
+				}
+				if mirror {
+					s.opts.MQTT.Observe(topo, telemetry.Satellites)
+				}
+			}
+			s.webhooks.Observe(sess.id, evt.Snapshot)
+			for _, a := range s.alerts.Observe(sess.id, evt.Snapshot) {
+				typ := webhook.AlertFiring
+				if a.State == alert.StateResolved {
+					typ = webhook.AlertResolved
+				}
+				s.webhooks.Publish(typ, sess.id, a)
+			}
+		}
+		s.webhooks.Forget(sess.id)
+		s.alerts.Forget(sess.id)
+	}()
+}
+
+// forward queues a simulator event for the event sink.
+func (s *Server) forward(session string, evt simulation.Event) {
+	body, err := json.Marshal(sinkEvent{
+		Type:     evt.Type,
+		Session:  session,
+		Version:  evt.Snapshot.Version,
+		SimTime:  evt.Snapshot.SimTime,
+		Snapshot: newSnapshotDTO(evt.Snapshot),
+	})
+	if err != nil {
+		log.Printf("encode event for sink: %v", err)
+		return
+	}
+	s.sink.Send(eventsink.Message{Session: session, Type: string(evt.Type), Value: body})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/example/satnet/backend/internal/eventsink"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// utilizationChangedEvent is the event sink type of weathermap deltas.
+const utilizationChangedEvent = "utilization_changed"
+
+// utilizationEvent is the message body of a weathermap delta published to the event sink.
+type utilizationEvent struct {
+	Type    string                     `json:"type"`
+	Session string                     `json:"session"`
+	Delta   simulation.WeathermapDelta `json:"delta"`
+}
+
+func (s *Server) weathermapHandler(w http.ResponseWriter, r *http.Request) {
+	writeWeathermap(w, r, s.sim)
+}
+
+// writeWeathermap serves the load on every active link of a simulator, for weathermap frontends.
+// The type query parameter keeps only the links of a comma-separated list of link types.
+func writeWeathermap(w http.ResponseWriter, r *http.Request, sim *simulation.Simulator) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	var types []routing.LinkType
+	if raw := r.URL.Query().Get("type"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			linkType, err := routing.ParseLinkType(strings.TrimSpace(name))
+			if err != nil {
+				writeError(w, r, invalidArgument("type", err.Error()))
+				return
+			}
+			types = append(types, linkType)
+		}
+	}
+	weathermap := sim.Weathermap()
+	if types != nil {
+		weathermap = weathermap.OfTypes(types...)
+	}
+	w.Header().Set("ETag", formatETag(weathermap.Version))
+	writeJSON(w, r, weathermap)
+}
+
+// forwardUtilization publishes the links of a session whose utilization band changed since the
+// weathermap bands last observed.
+func (s *Server) forwardUtilization(session string, weathermap simulation.Weathermap, bands *simulation.BandTracker) {
+	delta, changed := bands.Observe(weathermap)
+	if !changed {
+		return
+	}
+	body, err := json.Marshal(utilizationEvent{Type: utilizationChangedEvent, Session: session, Delta: delta})
+	if err != nil {
+		log.Printf("encode utilization delta for sink: %v", err)
+		return
+	}
+	s.sink.Send(eventsink.Message{Session: session, Type: utilizationChangedEvent, Value: body})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/example/satnet/backend/internal/webhook"
+)
+
+type webhookRequest struct {
+	URL               string   `json:"url"`
+	Events            []string `json:"events"`
+	CoverageThreshold float64  `json:"coverageThreshold,omitempty"`
+	Secret            string   `json:"secret,omitempty"`
+}
+
+type webhookListResponse struct {
+	Webhooks []webhook.Hook `json:"webhooks"`
+}
+
+// webhooksHandler serves POST /webhooks, registering an endpoint, and GET /webhooks.
+func (s *Server) webhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeJSON(w, r, webhookListResponse{Webhooks: s.webhooks.List()})
+		return
+	}
+
+	var req webhookRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, invalidArgument("body", "decode webhook: "+err.Error()))
+		return
+	}
+	hook, err := s.webhooks.Register(webhook.Hook{URL: req.URL, Events: req.Events, CoverageThreshold: req.CoverageThreshold, Secret: req.Secret})
+	if err != nil {
+		writeError(w, r, invalidArgument("body", err.Error()))
+		return
+	}
+	w.Header().Set("Location", "/webhooks/"+hook.ID)
+	writeJSONStatus(w, r, http.StatusCreated, hook)
+}
+
+// webhookHandler serves DELETE /webhooks/{id}.
+func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/webhooks/"), "/")
+	if !allowMethods(w, r, http.MethodDelete) {
+		return
+	}
+	if !s.webhooks.Delete(id) {
+		writeError(w, r, notFound("unknown webhook "+id))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
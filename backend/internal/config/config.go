@@ -0,0 +1,368 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/internal/eventsink"
+	"github.com/example/satnet/backend/internal/mqtt"
+	"github.com/example/satnet/backend/internal/tracing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// Config holds the API server settings. Every field can be set by flag or by the SATNET_*
+// environment variable listed in its flag's usage; flags take precedence over the environment.
+type Config struct {
+	ListenAddr  string
+	TLSCertFile string
+	TLSKeyFile  string
+	// AutocertDomains requests certificates from an ACME CA (Let's Encrypt) instead of using files.
+	AutocertDomains  []string
+	AutocertCacheDir string
+	H2C              bool
+	ScenarioFile     string
+	LogLevel         string
+	StoreDSN         string
+	TickInterval     time.Duration
+	// HistorySize is the compressed snapshot history kept per session; zero disables it.
+	HistorySize int
+
+	// Per-session quotas; zero disables a limit.
+	MaxSessions         int
+	MaxSatellites       int
+	MaxGridCells        int
+	MaxStepRate         float64 // API-driven steps per second
+	MaxSessionMemoryMiB int
+
+	// Workers bounds concurrent recomputes; WorkerQueue bounds how many may wait for a worker.
+	Workers     int
+	WorkerQueue int
+
+	// ShardWorkers lists base URLs of API servers that compute scenario and session recomputes,
+	// partitioned by orbital plane; ShardWorker lets this server act as one of them.
+	ShardWorkers []string
+	ShardWorker  bool
+	// ShardToken is the bearer token coordinators send and shard workers require.
+	ShardToken string
+
+	// AdminToken is the bearer token for /debug/ endpoints; empty leaves them unmounted.
+	AdminToken string
+
+	// EventSinkDSN names a NATS or Kafka destination for simulator events; empty disables it.
+	EventSinkDSN string
+	// MQTTBroker names the MQTT broker mirroring the default session's satellites and links as
+	// retained topics; empty disables the bridge.
+	MQTTBroker string
+	// TelemetryCadence is the simulated time between frames of synthetic satellite telemetry sent
+	// to the event sink and MQTT broker; zero disables telemetry emulation.
+	TelemetryCadence time.Duration
+	// ExternalPropagator is the command line of an external propagator process that satellites
+	// naming the "external" propagator are moved by; empty leaves it unavailable.
+	ExternalPropagator string
+	// Plugins lists the command lines of plugin processes providing routers, visibility models and
+	// link budgets; see package pluginhost.
+	Plugins []string
+	// TraceEndpoint is the OTLP/HTTP receiver for request and recompute spans; empty disables
+	// tracing. TraceSampleRatio is the share of new traces kept.
+	TraceEndpoint    string
+	TraceSampleRatio float64
+
+	// Units is the default unit list for API responses, such as "m,rad,s"; empty keeps each
+	// field's native unit. The API server parses it at startup.
+	Units string
+
+	// Demo serves a public, read-only instance where visitors experiment in sessions of their own;
+	// session limits left at zero get the tight demoLimits defaults instead of being disabled.
+	Demo                   bool
+	DemoSessionIdle        time.Duration
+	DemoSessionsPerVisitor int
+	// DemoTrustProxy identifies visitors by X-Forwarded-For; set it only behind a proxy that sets it.
+	DemoTrustProxy bool
+}
+
+// demoLimits are the session limits a demo instance applies where none is configured.
+var demoLimits = Config{
+	MaxSessions:         100,
+	MaxSatellites:       2000,
+	MaxGridCells:        10368,
+	MaxStepRate:         2,
+	MaxSessionMemoryMiB: 256,
+}
+
+// demoTickInterval is how often the built-in demo network steps when no tick is configured, so
+// its satellites move out of the box.
+const demoTickInterval = time.Second
+
+// Default returns the settings used when nothing is configured.
+func Default() Config {
+	return Config{
+		ListenAddr:       ":8080",
+		LogLevel:         "info",
+		StoreDSN:         "memory:",
+		AutocertCacheDir: "autocert-cache",
+		Workers:          runtime.NumCPU(),
+		WorkerQueue:      64,
+		TraceSampleRatio: 1,
+
+		DemoSessionIdle:        15 * time.Minute,
+		DemoSessionsPerVisitor: 1,
+	}
+}
+
+// Load parses command-line arguments on top of environment variables and defaults, then validates the result.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+	fs := flag.NewFlagSet("satnet-api", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	envString := func(name, fallback string) string {
+		if v := getenv(name); v != "" {
+			return v
+		}
+		return fallback
+	}
+	envDuration := func(name string, fallback time.Duration) (time.Duration, error) {
+		raw := getenv(name)
+		if raw == "" {
+			return fallback, nil
+		}
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		return parsed, nil
+	}
+	tick, err := envDuration("SATNET_TICK_INTERVAL", cfg.TickInterval)
+	if err != nil {
+		return Config{}, err
+	}
+	demoIdle, err := envDuration("SATNET_DEMO_SESSION_IDLE", cfg.DemoSessionIdle)
+	if err != nil {
+		return Config{}, err
+	}
+	telemetry, err := envDuration("SATNET_TELEMETRY_CADENCE", cfg.TelemetryCadence)
+	if err != nil {
+		return Config{}, err
+	}
+
+	fs.StringVar(&cfg.ListenAddr, "listen", envString("SATNET_LISTEN_ADDR", cfg.ListenAddr), "listen address (SATNET_LISTEN_ADDR)")
+	fs.StringVar(&cfg.TLSCertFile, "tls-cert", envString("SATNET_TLS_CERT", cfg.TLSCertFile), "TLS certificate file (SATNET_TLS_CERT)")
+	fs.StringVar(&cfg.TLSKeyFile, "tls-key", envString("SATNET_TLS_KEY", cfg.TLSKeyFile), "TLS private key file (SATNET_TLS_KEY)")
+	domains := envString("SATNET_TLS_AUTOCERT_DOMAINS", "")
+	fs.StringVar(&domains, "tls-autocert-domains", domains, "comma-separated domains for ACME certificates (SATNET_TLS_AUTOCERT_DOMAINS)")
+	fs.StringVar(&cfg.AutocertCacheDir, "tls-autocert-cache", envString("SATNET_TLS_AUTOCERT_CACHE", cfg.AutocertCacheDir), "directory caching ACME certificates (SATNET_TLS_AUTOCERT_CACHE)")
+	fs.BoolVar(&cfg.H2C, "h2c", envString("SATNET_H2C", "") == "true", "serve cleartext HTTP/2 when TLS is off (SATNET_H2C)")
+	fs.StringVar(&cfg.ScenarioFile, "scenario", envString("SATNET_SCENARIO", cfg.ScenarioFile), "scenario JSON file; empty runs the demo (SATNET_SCENARIO)")
+	fs.StringVar(&cfg.LogLevel, "log-level", envString("SATNET_LOG_LEVEL", cfg.LogLevel), "debug, info, warn, or error (SATNET_LOG_LEVEL)")
+	fs.StringVar(&cfg.StoreDSN, "store", envString("SATNET_STORE_DSN", cfg.StoreDSN), "store DSN: memory: or file:<path> (SATNET_STORE_DSN)")
+	fs.DurationVar(&cfg.TickInterval, "tick", tick, "simulation step interval; 0 disables ticking; defaults to 1s for the demo network (SATNET_TICK_INTERVAL)")
+
+	envInt := func(name string, fallback int) int {
+		if v, err := strconv.Atoi(getenv(name)); err == nil {
+			return v
+		}
+		return fallback
+	}
+	fs.IntVar(&cfg.MaxSessions, "max-sessions", envInt("SATNET_MAX_SESSIONS", cfg.MaxSessions), "maximum concurrent sessions (SATNET_MAX_SESSIONS)")
+	fs.IntVar(&cfg.MaxSatellites, "max-satellites", envInt("SATNET_MAX_SATELLITES", cfg.MaxSatellites), "maximum satellites per session (SATNET_MAX_SATELLITES)")
+	fs.IntVar(&cfg.MaxGridCells, "max-grid-cells", envInt("SATNET_MAX_GRID_CELLS", cfg.MaxGridCells), "maximum coverage grid cells per session (SATNET_MAX_GRID_CELLS)")
+	stepRate := cfg.MaxStepRate
+	if v, err := strconv.ParseFloat(getenv("SATNET_MAX_STEP_RATE"), 64); err == nil {
+		stepRate = v
+	}
+	fs.Float64Var(&cfg.MaxStepRate, "max-step-rate", stepRate, "maximum API-driven steps per second per session (SATNET_MAX_STEP_RATE)")
+	fs.IntVar(&cfg.MaxSessionMemoryMiB, "max-session-memory", envInt("SATNET_MAX_SESSION_MEMORY", cfg.MaxSessionMemoryMiB), "estimated memory cap per session in MiB (SATNET_MAX_SESSION_MEMORY)")
+
+	fs.IntVar(&cfg.HistorySize, "history", envInt("SATNET_HISTORY_SIZE", cfg.HistorySize), "snapshots kept in each session's compressed history (SATNET_HISTORY_SIZE)")
+
+	fs.IntVar(&cfg.Workers, "workers", envInt("SATNET_WORKERS", cfg.Workers), "concurrent simulation recomputes (SATNET_WORKERS)")
+	fs.IntVar(&cfg.WorkerQueue, "worker-queue", envInt("SATNET_WORKER_QUEUE", cfg.WorkerQueue), "recomputes allowed to wait for a worker (SATNET_WORKER_QUEUE)")
+
+	shardWorkers := envString("SATNET_SHARD_WORKERS", "")
+	fs.StringVar(&shardWorkers, "shard-workers", shardWorkers, "comma-separated base URLs of shard workers (SATNET_SHARD_WORKERS)")
+	fs.BoolVar(&cfg.ShardWorker, "shard-worker", envString("SATNET_SHARD_WORKER", "") == "true", "accept shard tasks from other servers (SATNET_SHARD_WORKER)")
+	fs.StringVar(&cfg.ShardToken, "shard-token", envString("SATNET_SHARD_TOKEN", ""), "bearer token shared by shard coordinators and workers (SATNET_SHARD_TOKEN)")
+
+	fs.StringVar(&cfg.AdminToken, "admin-token", envString("SATNET_ADMIN_TOKEN", ""), "bearer token for /debug/ endpoints; empty disables them (SATNET_ADMIN_TOKEN)")
+	fs.StringVar(&cfg.EventSinkDSN, "event-sink", envString("SATNET_EVENT_SINK", ""), "nats://host:port[/subject-prefix] or kafka://host:port[,...]/topic receiving simulator events (SATNET_EVENT_SINK)")
+	fs.StringVar(&cfg.TraceEndpoint, "trace-endpoint", envString("SATNET_TRACE_ENDPOINT", ""), "OTLP/HTTP receiver for trace spans, e.g. http://collector:4318 (SATNET_TRACE_ENDPOINT)")
+	sampleRatio := cfg.TraceSampleRatio
+	if v, err := strconv.ParseFloat(getenv("SATNET_TRACE_SAMPLE"), 64); err == nil {
+		sampleRatio = v
+	}
+	fs.Float64Var(&cfg.TraceSampleRatio, "trace-sample", sampleRatio, "share of new traces recorded, 0 to 1 (SATNET_TRACE_SAMPLE)")
+	fs.StringVar(&cfg.Units, "units", envString("SATNET_UNITS", ""), "default response units: km or m, deg or rad, ms or s, comma-separated; empty keeps native units (SATNET_UNITS)")
+	fs.StringVar(&cfg.MQTTBroker, "mqtt", envString("SATNET_MQTT_BROKER", ""), "mqtt://host:port[/root] receiving retained satellite and link state (SATNET_MQTT_BROKER)")
+	fs.DurationVar(&cfg.TelemetryCadence, "telemetry", telemetry, "simulated time between synthetic satellite telemetry frames; 0 disables them (SATNET_TELEMETRY_CADENCE)")
+	plugins := envString("SATNET_PLUGINS", "")
+	fs.StringVar(&plugins, "plugins", plugins, "comma-separated command lines of plugin processes providing routers, visibility models and link budgets (SATNET_PLUGINS)")
+	fs.StringVar(&cfg.ExternalPropagator, "external-propagator", envString("SATNET_EXTERNAL_PROPAGATOR", ""), "command line of a propagator process for satellites with \"propagator\": \"external\" (SATNET_EXTERNAL_PROPAGATOR)")
+
+	fs.BoolVar(&cfg.Demo, "demo", envString("SATNET_DEMO", "") == "true", "serve a read-only public demo with per-visitor sessions (SATNET_DEMO)")
+	fs.DurationVar(&cfg.DemoSessionIdle, "demo-session-idle", demoIdle, "remove demo visitors' sessions idle this long; 0 keeps them (SATNET_DEMO_SESSION_IDLE)")
+	fs.IntVar(&cfg.DemoSessionsPerVisitor, "demo-sessions-per-visitor", envInt("SATNET_DEMO_SESSIONS_PER_VISITOR", cfg.DemoSessionsPerVisitor), "sessions each demo visitor may own; 0 is unlimited (SATNET_DEMO_SESSIONS_PER_VISITOR)")
+	fs.BoolVar(&cfg.DemoTrustProxy, "demo-trust-proxy", envString("SATNET_DEMO_TRUST_PROXY", "") == "true", "identify demo visitors by X-Forwarded-For (SATNET_DEMO_TRUST_PROXY)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+		}
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	tickSet := getenv("SATNET_TICK_INTERVAL") != ""
+	fs.Visit(func(f *flag.Flag) { tickSet = tickSet || f.Name == "tick" })
+	if cfg.ScenarioFile == "" && !tickSet {
+		cfg.TickInterval = demoTickInterval
+	}
+	for _, domain := range strings.Split(domains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			cfg.AutocertDomains = append(cfg.AutocertDomains, domain)
+		}
+	}
+	for _, worker := range strings.Split(shardWorkers, ",") {
+		if worker = strings.TrimSpace(worker); worker != "" {
+			cfg.ShardWorkers = append(cfg.ShardWorkers, worker)
+		}
+	}
+	for _, plugin := range strings.Split(plugins, ",") {
+		if plugin = strings.TrimSpace(plugin); plugin != "" {
+			cfg.Plugins = append(cfg.Plugins, plugin)
+		}
+	}
+	if cfg.Demo {
+		cfg.applyDemoLimits()
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate reports the first configuration problem found.
+func (c Config) Validate() error {
+	if c.ListenAddr == "" {
+		return errors.New("listen address cannot be empty")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("TLS requires both a certificate and a key file")
+	}
+	if len(c.AutocertDomains) > 0 {
+		if c.TLSCertFile != "" {
+			return errors.New("choose either certificate files or ACME domains, not both")
+		}
+		if c.AutocertCacheDir == "" {
+			return errors.New("ACME certificates require a cache directory")
+		}
+	}
+	if c.H2C && (c.TLSCertFile != "" || len(c.AutocertDomains) > 0) {
+		return errors.New("h2c only applies when TLS is disabled")
+	}
+	for _, path := range []string{c.TLSCertFile, c.TLSKeyFile, c.ScenarioFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("cannot read %s: %w", path, err)
+		}
+	}
+	if _, err := c.SlogLevel(); err != nil {
+		return err
+	}
+	if c.StoreDSN != "memory:" && !strings.HasPrefix(c.StoreDSN, "file:") {
+		return fmt.Errorf("unsupported store DSN %q", c.StoreDSN)
+	}
+	if c.TickInterval < 0 {
+		return errors.New("tick interval cannot be negative")
+	}
+	if c.TelemetryCadence < 0 {
+		return errors.New("telemetry cadence cannot be negative")
+	}
+	if c.HistorySize < 0 {
+		return errors.New("history size cannot be negative")
+	}
+	if c.MaxSessions < 0 || c.MaxSatellites < 0 || c.MaxGridCells < 0 || c.MaxStepRate < 0 || c.MaxSessionMemoryMiB < 0 {
+		return errors.New("session limits cannot be negative")
+	}
+	if c.DemoSessionIdle < 0 || c.DemoSessionsPerVisitor < 0 {
+		return errors.New("demo session settings cannot be negative")
+	}
+	if c.Workers < 1 || c.WorkerQueue < 1 {
+		return errors.New("workers and worker queue must be at least 1")
+	}
+	for _, worker := range c.ShardWorkers {
+		if u, err := url.Parse(worker); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("shard worker %q must be an http or https URL", worker)
+		}
+	}
+	if (c.ShardWorker || len(c.ShardWorkers) > 0) && c.ShardToken == "" {
+		return errors.New("sharding requires a shard token")
+	}
+	if _, err := eventsink.Open(c.EventSinkDSN); err != nil {
+		return err
+	}
+	if c.MQTTBroker != "" {
+		if _, err := mqtt.Open(c.MQTTBroker); err != nil {
+			return err
+		}
+	}
+	if c.TraceEndpoint != "" {
+		if _, err := tracing.New(c.TraceEndpoint, "", c.TraceSampleRatio); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDemoLimits replaces unset session limits with demoLimits.
+func (c *Config) applyDemoLimits() {
+	if c.MaxSessions == 0 {
+		c.MaxSessions = demoLimits.MaxSessions
+	}
+	if c.MaxSatellites == 0 {
+		c.MaxSatellites = demoLimits.MaxSatellites
+	}
+	if c.MaxGridCells == 0 {
+		c.MaxGridCells = demoLimits.MaxGridCells
+	}
+	if c.MaxStepRate == 0 {
+		c.MaxStepRate = demoLimits.MaxStepRate
+	}
+	if c.MaxSessionMemoryMiB == 0 {
+		c.MaxSessionMemoryMiB = demoLimits.MaxSessionMemoryMiB
+	}
+}
+
+// SessionLimits converts the quota settings into simulation limits.
+func (c Config) SessionLimits() simulation.Limits {
+	limits := simulation.Limits{
+		MaxSatellites:  c.MaxSatellites,
+		MaxGridCells:   c.MaxGridCells,
+		MaxMemoryBytes: int64(c.MaxSessionMemoryMiB) << 20,
+	}
+	if c.MaxStepRate > 0 {
+		limits.MinStepPeriod = time.Duration(float64(time.Second) / c.MaxStepRate)
+	}
+	return limits
+}
+
+// SlogLevel converts LogLevel into a slog level.
+func (c Config) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", c.LogLevel)
+	}
+	return level, nil
+}
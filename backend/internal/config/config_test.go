@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	env := map[string]string{
+		"SATNET_LISTEN_ADDR":   ":9000",
+		"SATNET_LOG_LEVEL":     "debug",
+		"SATNET_TICK_INTERVAL": "2s",
+	}
+	cfg, err := Load([]string{"-listen", ":9100"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ListenAddr != ":9100" {
+		t.Fatalf("flag should override env, got %q", cfg.ListenAddr)
+	}
+	if cfg.LogLevel != "debug" || cfg.TickInterval != 2*time.Second {
+		t.Fatalf("env values not applied: %+v", cfg)
+	}
+	if cfg.StoreDSN != "memory:" {
+		t.Fatalf("expected default store DSN, got %q", cfg.StoreDSN)
+	}
+}
+
+func TestAutocertDomainsAreSplit(t *testing.T) {
+	cfg, err := Load([]string{"-tls-autocert-domains", "a.example.com, b.example.com"}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.AutocertDomains) != 2 || cfg.AutocertDomains[1] != "b.example.com" {
+		t.Fatalf("unexpected domains: %v", cfg.AutocertDomains)
+	}
+}
+
+func TestShardWorkersAreSplitAndValidated(t *testing.T) {
+	cfg, err := Load([]string{"-shard-token", "secret"}, func(name string) string {
+		if name == "SATNET_SHARD_WORKERS" {
+			return "http://shard-a:8080, https://shard-b"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.ShardWorkers) != 2 || cfg.ShardWorkers[1] != "https://shard-b" {
+		t.Fatalf("unexpected shard workers: %v", cfg.ShardWorkers)
+	}
+
+	if _, err := Load([]string{"-shard-token", "secret", "-shard-workers", "shard-a:8080"}, func(string) string { return "" }); err == nil {
+		t.Fatalf("expected a shard worker without a scheme to be rejected")
+	}
+	if _, err := Load([]string{"-shard-worker"}, func(string) string { return "" }); err == nil {
+		t.Fatalf("expected a shard worker without a token to be rejected")
+	}
+}
+
+func TestMessageBusesAreValidated(t *testing.T) {
+	cfg, err := Load([]string{"-event-sink", "kafka://broker-a:9092,broker-b:9092/satnet"}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.EventSinkDSN != "kafka://broker-a:9092,broker-b:9092/satnet" {
+		t.Fatalf("unexpected event sink %q", cfg.EventSinkDSN)
+	}
+	if _, err := Load(nil, func(name string) string {
+		if name == "SATNET_EVENT_SINK" {
+			return "redis://cache"
+		}
+		return ""
+	}); err == nil {
+		t.Fatalf("expected an unsupported event sink to be rejected")
+	}
+	if _, err := Load([]string{"-mqtt", "tcp://broker:1883"}, func(string) string { return "" }); err == nil {
+		t.Fatalf("expected a non-mqtt broker URL to be rejected")
+	}
+}
+
+func TestDemoFillsUnsetLimits(t *testing.T) {
+	cfg, err := Load([]string{"-demo", "-max-satellites", "500"}, func(name string) string {
+		return map[string]string{"SATNET_DEMO_SESSION_IDLE": "5m"}[name]
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxSatellites != 500 {
+		t.Fatalf("configured limit should win, got %d", cfg.MaxSatellites)
+	}
+	if cfg.MaxSessions != demoLimits.MaxSessions || cfg.MaxStepRate != demoLimits.MaxStepRate {
+		t.Fatalf("unset limits should take demo defaults: %+v", cfg)
+	}
+	if cfg.DemoSessionIdle != 5*time.Minute || cfg.DemoSessionsPerVisitor != 1 {
+		t.Fatalf("unexpected demo settings: %+v", cfg)
+	}
+
+	if cfg, _ := Load(nil, func(string) string { return "" }); cfg.MaxSessions != 0 {
+		t.Fatalf("limits should stay unset outside demo mode, got %d", cfg.MaxSessions)
+	}
+	if _, err := Load([]string{"-demo-sessions-per-visitor", "-1"}, func(string) string { return "" }); err == nil {
+		t.Fatal("expected a negative per-visitor cap to be rejected")
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	cfg, err := Load([]string{"-max-step-rate", "4", "-max-session-memory", "2", "-max-satellites", "100"}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limits := cfg.SessionLimits()
+	if limits.MinStepPeriod != 250*time.Millisecond || limits.MaxMemoryBytes != 2<<20 || limits.MaxSatellites != 100 {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+}
+
+func TestValidateRejectsBadSettings(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	cases := [][]string{
+		{"-tls-cert", "cert.pem"},
+		{"-log-level", "loud"},
+		{"-store", "postgres://db"},
+		{"-tick", "-1s"},
+		{"-scenario", "/does/not/exist.json"},
+		{"stray"},
+		{"-h2c", "-tls-autocert-domains", "example.com"},
+		{"-max-sessions", "-1"},
+		{"-workers", "0"},
+		{"-tls-autocert-domains", "example.com", "-tls-autocert-cache", ""},
+		{"-trace-endpoint", "collector:4318"},
+		{"-trace-endpoint", "http://collector:4318", "-trace-sample", "1.5"},
+	}
+	for _, args := range cases {
+		if _, err := Load(args, noEnv); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestDemoNetworkTicksUnlessConfigured(t *testing.T) {
+	none := func(string) string { return "" }
+	cfg, err := Load(nil, none)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TickInterval != demoTickInterval {
+		t.Fatalf("expected the demo network to tick every %v, got %v", demoTickInterval, cfg.TickInterval)
+	}
+	if cfg, err = Load([]string{"-tick", "0"}, none); err != nil || cfg.TickInterval != 0 {
+		t.Fatalf("expected an explicit -tick 0 to disable ticking, got %v, %v", cfg.TickInterval, err)
+	}
+	scenario := filepath.Join(t.TempDir(), "scenario.json")
+	if err := os.WriteFile(scenario, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load([]string{"-scenario", scenario}, none); err != nil || cfg.TickInterval != 0 {
+		t.Fatalf("expected scenarios to stay static by default, got %v, %v", cfg.TickInterval, err)
+	}
+}
@@ -0,0 +1,118 @@
+// Package eventsink forwards simulator events to an external message bus, so larger deployments
+// can fan them out to consumers that never talk to the API.
+package eventsink
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	queueSize      = 256
+	publishTimeout = 10 * time.Second
+)
+
+// Message is one event bound for the bus. Buses that route by subject derive it from Session and
+// Type; buses that partition by key use Session, so each session's events stay in order.
+type Message struct {
+	Session string
+	Type    string
+	Value   []byte
+}
+
+// Sink publishes messages to a message bus. Implementations connect lazily and reconnect after a
+// failed publish; Publish is never called concurrently.
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// Open returns the sink for dsn: nats://[user:pass@]host:port[/subject-prefix] or
+// kafka://host:port[,host:port...]/topic[?partition=N]. An empty DSN returns a nil sink.
+func Open(dsn string) (Sink, error) {
+	switch {
+	case dsn == "":
+		return nil, nil
+	case strings.HasPrefix(dsn, "nats://"):
+		return OpenNATS(dsn)
+	case strings.HasPrefix(dsn, "kafka://"):
+		return OpenKafka(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported event sink DSN %q", dsn)
+	}
+}
+
+// Stats counts a forwarder's messages. Publishing is at-most-once: failed messages are not
+// retried.
+type Stats struct {
+	Published uint64 `json:"published"`
+	Failed    uint64 `json:"failed"`
+	Dropped   uint64 `json:"dropped"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Forwarder queues messages for a sink and publishes them in order from one goroutine, so a slow
+// or unreachable bus never stalls the simulation.
+type Forwarder struct {
+	sink  Sink
+	queue chan Message
+	done  chan struct{}
+	once  sync.Once
+
+	published, failed, dropped atomic.Uint64
+	mu                         sync.Mutex
+	lastError                  string
+}
+
+// NewForwarder starts forwarding to sink.
+func NewForwarder(sink Sink) *Forwarder {
+	f := &Forwarder{sink: sink, queue: make(chan Message, queueSize), done: make(chan struct{})}
+	go f.run()
+	return f
+}
+
+// Send queues msg, dropping it when the queue is full.
+func (f *Forwarder) Send(msg Message) {
+	select {
+	case f.queue <- msg:
+	default:
+		f.dropped.Add(1)
+	}
+}
+
+// Stats reports the forwarder's counters.
+func (f *Forwarder) Stats() Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Stats{Published: f.published.Load(), Failed: f.failed.Load(), Dropped: f.dropped.Load(), LastError: f.lastError}
+}
+
+// Close publishes what is already queued and closes the sink. Send must not be called afterwards.
+func (f *Forwarder) Close() error {
+	f.once.Do(func() { close(f.queue) })
+	<-f.done
+	return f.sink.Close()
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+	for msg := range f.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := f.sink.Publish(ctx, msg)
+		cancel()
+		if err == nil {
+			f.published.Add(1)
+			continue
+		}
+		f.failed.Add(1)
+		f.mu.Lock()
+		f.lastError = err.Error()
+		f.mu.Unlock()
+		log.Printf("event sink: %s %s: %v", msg.Session, msg.Type, err)
+	}
+}
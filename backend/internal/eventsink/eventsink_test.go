@@ -0,0 +1,81 @@
+package eventsink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	got    []Message
+	fail   bool
+	closed bool
+}
+
+func (s *recordingSink) Publish(_ context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("bus unavailable")
+	}
+	s.got = append(s.got, msg)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestOpenParsesDSNs(t *testing.T) {
+	sink, err := Open("")
+	if err != nil || sink != nil {
+		t.Fatalf("empty DSN should disable the sink, got %v, %v", sink, err)
+	}
+	sink, err = Open("nats://user:pw@bus:4333/satnet.prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := sink.(*NATS); n.addr != "bus:4333" || n.prefix != "satnet.prod" {
+		t.Fatalf("unexpected nats sink %+v", n)
+	}
+	sink, err = Open("kafka://b1:9093,b2/events?partition=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k := sink.(*Kafka); len(k.brokers) != 2 || k.brokers[1] != "b2:9092" || k.topic != "events" || k.partition != 2 {
+		t.Fatalf("unexpected kafka sink %+v", k)
+	}
+
+	for _, dsn := range []string{"amqp://bus", "nats://bus/bad*subject", "kafka://b1:9092", "kafka://b1/events?partition=-1"} {
+		if _, err := Open(dsn); err == nil {
+			t.Errorf("expected %q to be rejected", dsn)
+		}
+	}
+}
+
+func TestForwarderPublishesInOrderAndCountsFailures(t *testing.T) {
+	sink := &recordingSink{}
+	f := NewForwarder(sink)
+	for _, typ := range []string{"a", "b", "c"} {
+		f.Send(Message{Session: "s1", Type: typ})
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.got) != 3 || sink.got[0].Type != "a" || sink.got[2].Type != "c" || !sink.closed {
+		t.Fatalf("expected three messages in order and a closed sink, got %+v", sink.got)
+	}
+	if stats := f.Stats(); stats.Published != 3 || stats.Failed != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	failing := NewForwarder(&recordingSink{fail: true})
+	failing.Send(Message{Session: "s1", Type: "a"})
+	failing.Close()
+	if stats := failing.Stats(); stats.Failed != 1 || stats.LastError != "bus unavailable" {
+		t.Fatalf("expected the failure to be counted, got %+v", stats)
+	}
+}
@@ -0,0 +1,413 @@
+package eventsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kafka API keys and versions. Produce v3 with record batches and Metadata v4 are the oldest
+// versions still accepted by current brokers; neither uses the flexible encoding of later versions.
+const (
+	kafkaProduce         = 0
+	kafkaProduceVersion  = 3
+	kafkaMetadata        = 3
+	kafkaMetadataVersion = 4
+
+	kafkaClientID     = "satnet"
+	kafkaAckTimeout   = 10 * time.Second
+	maxKafkaResponse  = 16 << 20
+	kafkaDefaultPort  = "9092"
+	kafkaTypeHeader   = "type"
+	kafkaRecordsMagic = 2
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Kafka produces each message as a one-record batch to a single partition of a topic, keyed by
+// session with the event type in a "type" header. It looks up the partition leader from the
+// bootstrap brokers and waits for the leader's acknowledgement. Messages are uncompressed, the
+// producer is not idempotent, and TLS and SASL are not supported.
+type Kafka struct {
+	brokers   []string
+	topic     string
+	partition int32
+
+	mu          sync.Mutex
+	conn        net.Conn
+	reader      *bufio.Reader
+	correlation int32
+}
+
+// OpenKafka parses kafka://host:port[,host:port...]/topic[?partition=N]. It does not connect until
+// the first publish.
+func OpenKafka(dsn string) (*Kafka, error) {
+	// net/url rejects comma-separated hosts, so split the broker list off first.
+	rest, ok := strings.CutPrefix(dsn, "kafka://")
+	hosts, path, _ := strings.Cut(rest, "/")
+	u, err := url.Parse("kafka://broker/" + path)
+	if !ok || hosts == "" || err != nil {
+		return nil, fmt.Errorf("kafka DSN %q must be kafka://host:port[,host:port...]/topic", dsn)
+	}
+	k := &Kafka{topic: strings.Trim(u.Path, "/")}
+	if k.topic == "" || strings.Contains(k.topic, "/") {
+		return nil, fmt.Errorf("kafka DSN %q must name one topic", dsn)
+	}
+	for _, broker := range strings.Split(hosts, ",") {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			broker = net.JoinHostPort(broker, kafkaDefaultPort)
+		}
+		k.brokers = append(k.brokers, broker)
+	}
+	if raw := u.Query().Get("partition"); raw != "" {
+		partition, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || partition < 0 {
+			return nil, fmt.Errorf("kafka partition %q must be a non-negative integer", raw)
+		}
+		k.partition = int32(partition)
+	}
+	return k, nil
+}
+
+// Publish produces msg to the partition leader, connecting first if needed. Any failure drops the
+// connection, so the next publish looks the leader up again.
+func (k *Kafka) Publish(ctx context.Context, msg Message) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.conn == nil {
+		if err := k.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+	if err := k.produceLocked(ctx, msg); err != nil {
+		k.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection to the partition leader.
+func (k *Kafka) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.closeLocked()
+	return nil
+}
+
+func (k *Kafka) closeLocked() {
+	if k.conn != nil {
+		k.conn.Close()
+	}
+	k.conn, k.reader = nil, nil
+}
+
+// connectLocked asks the bootstrap brokers in turn for the partition's leader and connects to it.
+func (k *Kafka) connectLocked(ctx context.Context) error {
+	var errs []error
+	for _, broker := range k.brokers {
+		if err := k.dialLocked(ctx, broker); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		leader, err := k.leaderLocked(ctx)
+		if err != nil {
+			k.closeLocked()
+			errs = append(errs, fmt.Errorf("%s: %w", broker, err))
+			continue
+		}
+		if leader == broker {
+			return nil
+		}
+		k.closeLocked()
+		if err := k.dialLocked(ctx, leader); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("kafka: no leader for %s/%d: %w", k.topic, k.partition, errors.Join(errs...))
+}
+
+func (k *Kafka) dialLocked(ctx context.Context, addr string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("kafka connect: %w", err)
+	}
+	k.conn, k.reader = conn, bufio.NewReader(conn)
+	return nil
+}
+
+// leaderLocked sends a Metadata request for the topic and returns the partition leader's address.
+func (k *Kafka) leaderLocked(ctx context.Context) (string, error) {
+	var req kafkaEncoder
+	req.int32(1)
+	req.string(k.topic)
+	req.int8(1) // allow_auto_topic_creation; the broker's own setting still applies
+	resp, err := k.roundTripLocked(ctx, kafkaMetadata, kafkaMetadataVersion, req.buf)
+	if err != nil {
+		return "", err
+	}
+
+	d := kafkaDecoder{buf: resp}
+	d.int32() // throttle_time_ms
+	brokers := make(map[int32]string)
+	for n := d.arrayLen(); n > 0; n-- {
+		id, host, port := d.int32(), d.string(), d.int32()
+		d.nullableString() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.nullableString() // cluster_id
+	d.int32()          // controller_id
+	leader := int32(-1)
+	for topics := d.arrayLen(); topics > 0; topics-- {
+		topicErr, name := d.int16(), d.string()
+		d.int8() // is_internal
+		for partitions := d.arrayLen(); partitions > 0; partitions-- {
+			partitionErr, index, leaderID := d.int16(), d.int32(), d.int32()
+			d.int32Array() // replica_nodes
+			d.int32Array() // isr_nodes
+			if name != k.topic || index != k.partition {
+				continue
+			}
+			if partitionErr != 0 {
+				return "", fmt.Errorf("partition error code %d", partitionErr)
+			}
+			leader = leaderID
+		}
+		if name == k.topic && topicErr != 0 {
+			return "", fmt.Errorf("topic error code %d", topicErr)
+		}
+	}
+	if d.err != nil {
+		return "", fmt.Errorf("decode metadata: %w", d.err)
+	}
+	addr, ok := brokers[leader]
+	if !ok {
+		return "", fmt.Errorf("partition %d has no known leader", k.partition)
+	}
+	return addr, nil
+}
+
+// produceLocked sends msg as a single record batch and checks the partition's acknowledgement.
+func (k *Kafka) produceLocked(ctx context.Context, msg Message) error {
+	var req kafkaEncoder
+	req.int16(-1) // null transactional_id
+	req.int16(1)  // acks: the leader has written the record
+	req.int32(int32(kafkaAckTimeout / time.Millisecond))
+	req.int32(1)
+	req.string(k.topic)
+	req.int32(1)
+	req.int32(k.partition)
+	batch := recordBatch(msg, time.Now())
+	req.int32(int32(len(batch)))
+	req.buf = append(req.buf, batch...)
+	resp, err := k.roundTripLocked(ctx, kafkaProduce, kafkaProduceVersion, req.buf)
+	if err != nil {
+		return err
+	}
+
+	d := kafkaDecoder{buf: resp}
+	acked, code := false, int16(0)
+	for topics := d.arrayLen(); topics > 0; topics-- {
+		name := d.string()
+		for partitions := d.arrayLen(); partitions > 0; partitions-- {
+			index, partitionErr := d.int32(), d.int16()
+			d.int64() // base_offset
+			d.int64() // log_append_time_ms
+			if name == k.topic && index == k.partition {
+				acked, code = true, partitionErr
+			}
+		}
+	}
+	if d.err != nil {
+		return fmt.Errorf("decode produce response: %w", d.err)
+	}
+	if !acked {
+		return errors.New("kafka produce: no acknowledgement for the partition")
+	}
+	if code != 0 {
+		return fmt.Errorf("kafka produce: error code %d", code)
+	}
+	return nil
+}
+
+// roundTripLocked frames a request with a v1 header and returns the response body after its
+// correlation ID.
+func (k *Kafka) roundTripLocked(ctx context.Context, apiKey, version int16, body []byte) ([]byte, error) {
+	k.correlation++
+	var req kafkaEncoder
+	req.int32(0) // size, filled in below
+	req.int16(apiKey)
+	req.int16(version)
+	req.int32(k.correlation)
+	req.string(kafkaClientID)
+	req.buf = append(req.buf, body...)
+	binary.BigEndian.PutUint32(req.buf, uint32(len(req.buf)-4))
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(publishTimeout)
+	}
+	k.conn.SetDeadline(deadline)
+	if _, err := k.conn.Write(req.buf); err != nil {
+		return nil, fmt.Errorf("kafka write: %w", err)
+	}
+	var header [8]byte
+	if _, err := io.ReadFull(k.reader, header[:]); err != nil {
+		return nil, fmt.Errorf("kafka read: %w", err)
+	}
+	size := int32(binary.BigEndian.Uint32(header[:4]))
+	if size < 4 || size > maxKafkaResponse {
+		return nil, fmt.Errorf("kafka response of %d bytes", size)
+	}
+	if correlation := int32(binary.BigEndian.Uint32(header[4:])); correlation != k.correlation {
+		return nil, fmt.Errorf("kafka response for request %d, expected %d", correlation, k.correlation)
+	}
+	resp := make([]byte, size-4)
+	if _, err := io.ReadFull(k.reader, resp); err != nil {
+		return nil, fmt.Errorf("kafka read: %w", err)
+	}
+	return resp, nil
+}
+
+// recordBatch encodes msg as a v2 record batch holding one record.
+func recordBatch(msg Message, now time.Time) []byte {
+	var record []byte
+	record = append(record, 0)              // attributes
+	record = binary.AppendVarint(record, 0) // timestamp delta
+	record = binary.AppendVarint(record, 0) // offset delta
+	record = appendVarbytes(record, []byte(msg.Session))
+	record = appendVarbytes(record, msg.Value)
+	record = binary.AppendVarint(record, 1) // headers
+	record = appendVarbytes(record, []byte(kafkaTypeHeader))
+	record = appendVarbytes(record, []byte(msg.Type))
+
+	// The CRC covers everything from the attributes to the end of the batch.
+	var tail kafkaEncoder
+	tail.int16(0) // attributes: no compression, create time
+	tail.int32(0) // last offset delta
+	ms := now.UnixMilli()
+	tail.int64(ms) // base timestamp
+	tail.int64(ms) // max timestamp
+	tail.int64(-1) // producer ID
+	tail.int16(-1) // producer epoch
+	tail.int32(-1) // base sequence
+	tail.int32(1)  // records
+	tail.buf = binary.AppendVarint(tail.buf, int64(len(record)))
+	tail.buf = append(tail.buf, record...)
+
+	var batch kafkaEncoder
+	batch.int64(0)                                // base offset, assigned by the broker
+	batch.int32(int32(4 + 1 + 4 + len(tail.buf))) // length of what follows
+	batch.int32(-1)                               // partition leader epoch
+	batch.int8(kafkaRecordsMagic)
+	batch.int32(int32(crc32.Checksum(tail.buf, castagnoli)))
+	batch.buf = append(batch.buf, tail.buf...)
+	return batch.buf
+}
+
+func appendVarbytes(buf, b []byte) []byte {
+	buf = binary.AppendVarint(buf, int64(len(b)))
+	return append(buf, b...)
+}
+
+type kafkaEncoder struct{ buf []byte }
+
+func (e *kafkaEncoder) int8(v int8)   { e.buf = append(e.buf, byte(v)) }
+func (e *kafkaEncoder) int16(v int16) { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+func (e *kafkaEncoder) int32(v int32) { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+func (e *kafkaEncoder) int64(v int64) { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// kafkaDecoder reads big-endian fields, recording the first short read in err.
+type kafkaDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	out := d.buf[:n]
+	d.buf = d.buf[n:]
+	return out
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.next(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	return string(d.next(int(d.int16())))
+}
+
+func (d *kafkaDecoder) nullableString() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+// arrayLen reads an array length, treating a null array as empty.
+func (d *kafkaDecoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 || d.err != nil {
+		return 0
+	}
+	if int(n) > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	return int(n)
+}
+
+func (d *kafkaDecoder) int32Array() {
+	for n := d.arrayLen(); n > 0; n-- {
+		d.int32()
+	}
+}
@@ -0,0 +1,206 @@
+package eventsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type producedRecord struct {
+	topic     string
+	partition int32
+	key       string
+	value     string
+	header    string
+}
+
+// fakeKafka answers Metadata requests naming leader (or itself) as the leader of every partition,
+// and decodes Produce requests into records.
+func fakeKafka(t *testing.T, leader string) (string, chan producedRecord) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	if leader == "" {
+		leader = ln.Addr().String()
+	}
+	got := make(chan producedRecord, 8)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveKafka(t, conn, leader, got)
+		}
+	}()
+	return ln.Addr().String(), got
+}
+
+func serveKafka(t *testing.T, conn net.Conn, leader string, got chan producedRecord) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return
+		}
+		req := kafkaDecoder{buf: frame}
+		apiKey, version, correlation := req.int16(), req.int16(), req.int32()
+		req.nullableString() // client ID
+
+		var resp kafkaEncoder
+		resp.int32(0)
+		resp.int32(correlation)
+		switch {
+		case apiKey == kafkaMetadata && version == kafkaMetadataVersion:
+			host, port, _ := net.SplitHostPort(leader)
+			portNum, _ := strconv.Atoi(port)
+			req.int32()
+			topic := req.string()
+			resp.int32(0) // throttle
+			resp.int32(1)
+			resp.int32(7)
+			resp.string(host)
+			resp.int32(int32(portNum))
+			resp.int16(-1) // rack
+			resp.int16(-1) // cluster ID
+			resp.int32(7)  // controller
+			resp.int32(1)
+			resp.int16(0)
+			resp.string(topic)
+			resp.int8(0)
+			resp.int32(3)
+			for p := int32(0); p < 3; p++ {
+				resp.int16(0)
+				resp.int32(p)
+				resp.int32(7)
+				resp.int32(1)
+				resp.int32(7)
+				resp.int32(1)
+				resp.int32(7)
+			}
+		case apiKey == kafkaProduce && version == kafkaProduceVersion:
+			req.nullableString() // transactional ID
+			if acks := req.int16(); acks != 1 {
+				t.Errorf("acks = %d", acks)
+			}
+			req.int32()
+			req.int32()
+			rec := producedRecord{topic: req.string()}
+			req.int32()
+			rec.partition = req.int32()
+			batch := req.next(int(req.int32()))
+			if req.err != nil {
+				t.Errorf("short produce request: %v", req.err)
+				return
+			}
+			decodeBatch(t, batch, &rec)
+			got <- rec
+			resp.int32(1)
+			resp.string(rec.topic)
+			resp.int32(1)
+			resp.int32(rec.partition)
+			resp.int16(0)
+			resp.int64(42)
+			resp.int64(-1)
+			resp.int32(0) // throttle
+		default:
+			t.Errorf("unexpected request %d v%d", apiKey, version)
+			return
+		}
+		binary.BigEndian.PutUint32(resp.buf, uint32(len(resp.buf)-4))
+		conn.Write(resp.buf)
+	}
+}
+
+func decodeBatch(t *testing.T, batch []byte, rec *producedRecord) {
+	d := kafkaDecoder{buf: batch}
+	d.int64() // base offset
+	if length := d.int32(); int(length) != len(d.buf) {
+		t.Errorf("batch length %d, %d bytes follow", length, len(d.buf))
+	}
+	d.int32() // leader epoch
+	if magic := d.int8(); magic != 2 {
+		t.Errorf("magic %d", magic)
+	}
+	crc := uint32(d.int32())
+	if sum := crc32.Checksum(d.buf, crc32.MakeTable(crc32.Castagnoli)); sum != crc {
+		t.Errorf("crc %x, computed %x", crc, sum)
+	}
+	d.next(2 + 4 + 8 + 8 + 8 + 2 + 4)
+	if count := d.int32(); count != 1 {
+		t.Errorf("%d records", count)
+	}
+	varint := func() int64 {
+		v, n := binary.Varint(d.buf)
+		d.buf = d.buf[n:]
+		return v
+	}
+	varbytes := func() string { return string(d.next(int(varint()))) }
+	if length := varint(); int(length) != len(d.buf) {
+		t.Errorf("record length %d, %d bytes follow", length, len(d.buf))
+	}
+	d.int8()
+	varint()
+	varint()
+	rec.key, rec.value = varbytes(), varbytes()
+	if headers := varint(); headers != 1 {
+		t.Errorf("%d headers", headers)
+	}
+	if name := varbytes(); name != "type" {
+		t.Errorf("header %q", name)
+	}
+	rec.header = varbytes()
+}
+
+func TestKafkaProducesToPartitionLeader(t *testing.T) {
+	leader, got := fakeKafka(t, "")
+	bootstrap, _ := fakeKafka(t, leader)
+	sink, err := OpenKafka("kafka://" + bootstrap + "/satnet-events?partition=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, value := range []string{`{"v":1}`, `{"v":2}`} {
+		if err := sink.Publish(ctx, Message{Session: "default", Type: "coverage_updated", Value: []byte(value)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{`{"v":1}`, `{"v":2}`} {
+		select {
+		case rec := <-got:
+			if rec.topic != "satnet-events" || rec.partition != 2 || rec.key != "default" || rec.header != "coverage_updated" || rec.value != want {
+				t.Fatalf("unexpected record %+v", rec)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the leader received no record")
+		}
+	}
+}
+
+func TestKafkaFailsWithoutReachableBroker(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+	sink, _ := OpenKafka("kafka://" + addr + "/events")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sink.Publish(ctx, Message{Session: "s", Type: "t"}); err == nil {
+		t.Fatal("expected publishing without a broker to fail")
+	}
+}
@@ -0,0 +1,210 @@
+package eventsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultNATSSubject = "satnet"
+
+// NATS publishes over the NATS client protocol to <prefix>.<session>.<type>. Publishes are
+// fire-and-forget, as with core NATS; TLS is not supported.
+type NATS struct {
+	addr   string
+	prefix string
+	// connect is the CONNECT payload, carrying any credentials from the DSN.
+	connect []byte
+
+	mu     sync.Mutex
+	conn   net.Conn
+	failed error // set by the read loop when the server closes or rejects the connection
+}
+
+// OpenNATS parses nats://[user:pass@|token@]host:port[/subject-prefix]. It does not connect until
+// the first publish.
+func OpenNATS(dsn string) (*NATS, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("nats DSN %q must be nats://host:port[/subject-prefix]", dsn)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix == "" {
+		prefix = defaultNATSSubject
+	}
+	if !validSubject(prefix) {
+		return nil, fmt.Errorf("nats subject prefix %q must be dot-separated tokens without spaces or wildcards", prefix)
+	}
+	options := map[string]any{"verbose": false, "pedantic": false, "name": "satnet", "lang": "go", "version": "1"}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			options["user"], options["pass"] = u.User.Username(), pass
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+	return &NATS{addr: addr, prefix: prefix, connect: connect}, nil
+}
+
+// Publish sends msg, connecting first if needed.
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	subject := n.prefix + "." + subjectToken(msg.Session) + "." + subjectToken(msg.Type)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.failed != nil {
+		n.closeLocked()
+	}
+	if n.conn == nil {
+		if err := n.dialLocked(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		n.conn.SetWriteDeadline(deadline)
+	}
+	frame := fmt.Appendf(nil, "PUB %s %d\r\n", subject, len(msg.Value))
+	frame = append(append(frame, msg.Value...), "\r\n"...)
+	if _, err := n.conn.Write(frame); err != nil {
+		n.closeLocked()
+		return fmt.Errorf("nats publish: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closeLocked()
+	return nil
+}
+
+// dialLocked connects and completes the handshake: the server's INFO, then CONNECT and a PING
+// whose PONG confirms the server accepted the connection.
+func (n *NATS) dialLocked(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("nats connect: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	reader := bufio.NewReader(conn)
+	fail := func(err error) error {
+		conn.Close()
+		return fmt.Errorf("nats handshake: %w", err)
+	}
+
+	line, err := readLine(reader)
+	if err != nil {
+		return fail(err)
+	}
+	info, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fail(fmt.Errorf("expected INFO, got %q", line))
+	}
+	var server struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(info), &server); err != nil {
+		return fail(err)
+	}
+	if server.TLSRequired {
+		return fail(errors.New("server requires TLS, which this sink does not support"))
+	}
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", n.connect); err != nil {
+		return fail(err)
+	}
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return fail(err)
+		}
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			return fail(errors.New(line))
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	n.conn, n.failed = conn, nil
+	go n.readLoop(conn, reader)
+	return nil
+}
+
+// readLoop answers the server's keepalive PINGs and records why the connection ended.
+func (n *NATS) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := readLine(reader)
+		if err == nil && strings.HasPrefix(line, "-ERR") {
+			err = errors.New(line)
+		}
+		if err != nil {
+			n.mu.Lock()
+			if n.conn == conn {
+				n.failed = err
+			}
+			n.mu.Unlock()
+			return
+		}
+		if line == "PING" {
+			n.mu.Lock()
+			if n.conn == conn {
+				conn.Write([]byte("PONG\r\n"))
+			}
+			n.mu.Unlock()
+		}
+	}
+}
+
+func (n *NATS) closeLocked() {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	n.conn, n.failed = nil, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// subjectToken makes s safe as one subject token, replacing separators and wildcards.
+func subjectToken(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func validSubject(s string) bool {
+	for _, token := range strings.Split(s, ".") {
+		if token == "" || subjectToken(token) != token {
+			return false
+		}
+	}
+	return true
+}
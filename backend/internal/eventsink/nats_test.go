@@ -0,0 +1,112 @@
+package eventsink
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATS accepts one client, completes the handshake, and reports each published subject and
+// payload.
+func fakeNATS(t *testing.T) (string, chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got := make(chan string, 8)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				got <- line
+			case line == "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+				// Exercise the keepalive the other way round.
+				fmt.Fprint(conn, "PING\r\n")
+			case line == "PONG":
+				got <- line
+			case strings.HasPrefix(line, "PUB "):
+				var subject string
+				var size int
+				fmt.Sscanf(line, "PUB %s %d", &subject, &size)
+				payload := make([]byte, size+2)
+				io.ReadFull(r, payload)
+				got <- subject + " " + string(payload[:size])
+			}
+		}
+	}()
+	return ln.Addr().String(), got
+}
+
+func receive(t *testing.T, got chan string) string {
+	select {
+	case line := <-got:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the fake server")
+		return ""
+	}
+}
+
+func TestNATSPublishesToSessionSubjects(t *testing.T) {
+	addr, got := fakeNATS(t)
+	sink, err := OpenNATS("nats://secret@" + addr + "/satnet.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	ctx := context.Background()
+	if err := sink.Publish(ctx, Message{Session: "default", Type: "coverage_updated", Value: []byte(`{"v":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if connect := receive(t, got); !strings.Contains(connect, `"auth_token":"secret"`) || !strings.Contains(connect, `"verbose":false`) {
+		t.Fatalf("unexpected CONNECT %q", connect)
+	}
+	if err := sink.Publish(ctx, Message{Session: "a.b", Type: "x", Value: []byte("2")}); err != nil {
+		t.Fatal(err)
+	}
+	// The keepalive PONG races the publishes; only the publishes are ordered.
+	var pubs []string
+	pong := false
+	for len(pubs) < 2 || !pong {
+		switch line := receive(t, got); line {
+		case "PONG":
+			pong = true
+		default:
+			pubs = append(pubs, line)
+		}
+	}
+	if pubs[0] != `satnet.test.default.coverage_updated {"v":1}` || pubs[1] != "satnet.test.a_b.x 2" {
+		t.Fatalf("unexpected publishes %q", pubs)
+	}
+}
+
+func TestNATSReportsUnreachableServer(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+	sink, _ := OpenNATS("nats://" + addr)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sink.Publish(ctx, Message{Session: "s", Type: "t"}); err == nil {
+		t.Fatal("expected publishing to a closed port to fail")
+	}
+}
@@ -0,0 +1,306 @@
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+	"github.com/example/satnet/backend/visibility"
+)
+
+const (
+	defaultRoot    = "satnet"
+	connectTimeout = 10 * time.Second
+)
+
+// SatelliteState is the retained payload of <root>/sat/{id}/state. Disabled satellites report
+// only their ID and active=false.
+type SatelliteState struct {
+	ID     string   `json:"id"`
+	Active bool     `json:"active"`
+	Lat    *float64 `json:"lat,omitempty"`
+	Lon    *float64 `json:"lon,omitempty"`
+	AltKm  *float64 `json:"altKm,omitempty"`
+	Links  int      `json:"links"`
+}
+
+// LinkState is the retained payload of <root>/link/{from}/{to}/state, one topic per direction.
+type LinkState struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	LatencyMS  float64 `json:"latencyMs"`
+	Throughput float64 `json:"throughput"`
+	ValidForS  float64 `json:"validForS"`
+}
+
+// update is one recompute handed to the bridge.
+type update struct {
+	topo      simulation.Topology
+	telemetry []simulation.SatelliteTelemetry
+}
+
+// Stats counts a bridge's publishes.
+type Stats struct {
+	Topics    int       `json:"topics"`
+	Published uint64    `json:"published"`
+	Cleared   uint64    `json:"cleared"`
+	Failed    uint64    `json:"failed"`
+	LastError string    `json:"lastError,omitempty"`
+	LastSync  time.Time `json:"lastSync"`
+}
+
+// Bridge mirrors a simulator's latest topology onto retained MQTT topics. Only topics whose state
+// changed are republished, and topics of removed satellites and broken links are cleared, so a
+// subscriber always receives the current state of just the topics it matches.
+type Bridge struct {
+	addr, root         string
+	username, password string
+	clientID           string
+
+	latest chan update
+	start  sync.Once
+	done   chan struct{}
+
+	// Owned by the run goroutine.
+	client    *client
+	published map[string][]byte
+	resync    bool
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Open parses mqtt://[user:pass@]host:port[/root]. The bridge connects on its first update; the
+// root defaults to "satnet".
+func Open(dsn string) (*Bridge, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme != "mqtt" || u.Host == "" {
+		return nil, fmt.Errorf("mqtt DSN %q must be mqtt://host:port[/root]", dsn)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "1883")
+	}
+	root := strings.Trim(u.Path, "/")
+	if root == "" {
+		root = defaultRoot
+	}
+	// url.Parse takes a "#" for the start of a fragment, so look for wildcards in the whole DSN.
+	if strings.Contains(root, "+") || strings.Contains(dsn, "#") {
+		return nil, fmt.Errorf("mqtt topic root %q cannot contain wildcards", root)
+	}
+	b := &Bridge{
+		addr:      addr,
+		root:      root,
+		clientID:  "satnet-" + randomSuffix(),
+		latest:    make(chan update, 1),
+		done:      make(chan struct{}),
+		published: make(map[string][]byte),
+	}
+	if u.User != nil {
+		b.username = u.User.Username()
+		b.password, _ = u.User.Password()
+	}
+	return b, nil
+}
+
+// Observe hands the bridge a new topology and the latest emulated telemetry of its satellites,
+// which may be nil. It never blocks: an update the bridge has not started on yet is replaced by the
+// newer one.
+func (b *Bridge) Observe(topo simulation.Topology, telemetry []simulation.SatelliteTelemetry) {
+	b.start.Do(func() { go b.run() })
+	u := update{topo: topo, telemetry: telemetry}
+	for {
+		select {
+		case b.latest <- u:
+			return
+		default:
+		}
+		select {
+		case <-b.latest:
+		default:
+		}
+	}
+}
+
+// Stats reports the bridge's counters.
+func (b *Bridge) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// Close stops the bridge after its current update and disconnects. Retained topics stay on the
+// broker. Observe must not be called afterwards.
+func (b *Bridge) Close() {
+	b.start.Do(func() { close(b.done) })
+	close(b.latest)
+	<-b.done
+}
+
+func (b *Bridge) run() {
+	defer close(b.done)
+	for u := range b.latest {
+		b.sync(u)
+	}
+	if b.client != nil {
+		b.client.close()
+	}
+}
+
+// sync publishes the topics that changed since the last successful sync. After a failure or a
+// reconnect every topic is republished, since the broker may have lost its retained messages.
+func (b *Bridge) sync(u update) {
+	states := topics(b.root, u.topo, u.telemetry)
+	if b.client == nil || b.client.err() != nil {
+		if b.client != nil {
+			b.client.close()
+			b.client = nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		c, err := dial(ctx, b.addr, b.clientID, b.username, b.password)
+		cancel()
+		if err != nil {
+			b.fail(err)
+			return
+		}
+		b.client, b.resync = c, true
+	}
+
+	names := make([]string, 0, len(states))
+	for topic := range states {
+		names = append(names, topic)
+	}
+	sort.Strings(names)
+	var published, cleared uint64
+	for _, topic := range names {
+		if !b.resync && bytes.Equal(b.published[topic], states[topic]) {
+			continue
+		}
+		if err := b.client.publish(topic, states[topic], true); err != nil {
+			b.fail(err)
+			return
+		}
+		published++
+	}
+	for topic := range b.published {
+		if _, ok := states[topic]; ok {
+			continue
+		}
+		if err := b.client.publish(topic, nil, true); err != nil {
+			b.fail(err)
+			return
+		}
+		cleared++
+	}
+	b.published, b.resync = states, false
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Topics = len(states)
+	b.stats.Published += published
+	b.stats.Cleared += cleared
+	b.stats.LastSync = time.Now().UTC()
+}
+
+// fail records an error; the next update reconnects if needed and republishes every topic.
+func (b *Bridge) fail(err error) {
+	b.resync = true
+	b.mu.Lock()
+	b.stats.Failed++
+	b.stats.LastError = err.Error()
+	b.mu.Unlock()
+	log.Printf("mqtt bridge: %v", err)
+}
+
+// topics renders the retained state of every satellite and link in topo, and the telemetry of
+// those satellites, under root.
+func topics(root string, topo simulation.Topology, telemetry []simulation.SatelliteTelemetry) map[string][]byte {
+	out := make(map[string][]byte)
+	encode := func(topic string, state any) {
+		payload, err := json.Marshal(state)
+		if err != nil {
+			// States are plain structs of finite numbers; failing to encode them is a programming error.
+			panic(err)
+		}
+		out[topic] = payload
+	}
+	if topo.Graph != nil {
+		for id, node := range topo.Graph.Nodes {
+			if node.Type != routing.Satellite {
+				continue
+			}
+			geo := visibility.ToGeodetic(node.Position)
+			lat, lon, alt := round(geo.LatDeg, 1e4), round(geo.LonDeg, 1e4), round(geo.AltKm, 1e3)
+			encode(satTopic(root, id), SatelliteState{ID: id, Active: true, Lat: &lat, Lon: &lon, AltKm: &alt, Links: len(topo.Graph.Adj[id])})
+		}
+		for from, edges := range topo.Graph.Adj {
+			for _, e := range edges {
+				encode(root+"/link/"+topicLevel(from)+"/"+topicLevel(e.To)+"/state", LinkState{
+					From:       from,
+					To:         e.To,
+					LatencyMS:  round(e.LatencyMS, 1e3),
+					Throughput: round(e.Throughput, 1e3),
+					ValidForS:  round(e.ValidForS, 1),
+				})
+			}
+		}
+	}
+	for _, t := range telemetry {
+		// Telemetry may lag the topology; a satellite that has gone since has no telemetry topic.
+		if topo.Graph == nil {
+			break
+		}
+		if node, ok := topo.Graph.Nodes[t.ID]; ok && node.Type == routing.Satellite {
+			encode(root+"/sat/"+topicLevel(t.ID)+"/telemetry", t)
+		}
+	}
+	for _, id := range topo.Snapshot.DisabledSatellites {
+		encode(satTopic(root, id), SatelliteState{ID: id})
+	}
+	return out
+}
+
+func satTopic(root, id string) string {
+	return root + "/sat/" + topicLevel(id) + "/state"
+}
+
+// topicLevel makes an ID safe as one topic level, replacing separators and wildcards.
+func topicLevel(id string) string {
+	if id == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#', 0:
+			return '_'
+		}
+		return r
+	}, id)
+}
+
+// round keeps values to a fixed resolution so numerical noise does not republish a topic.
+func round(v, scale float64) float64 {
+	return math.Round(v*scale) / scale
+}
+
+func randomSuffix() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprint(time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
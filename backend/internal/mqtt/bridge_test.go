@@ -0,0 +1,152 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func topology(t *testing.T, satIDs ...string) simulation.Topology {
+	nodes := []routing.Node{{ID: "gw", Type: routing.Ground, Position: visibility.FromGeodetic(0, 0, 0)}}
+	for i, id := range satIDs {
+		nodes = append(nodes, routing.Node{ID: id, Type: routing.Satellite, Position: visibility.FromGeodetic(float64(i), 0, 550)})
+	}
+	g, err := routing.BuildGraph(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return simulation.Topology{Graph: g}
+}
+
+func TestTopicsRenderSatellitesAndLinks(t *testing.T) {
+	topo := topology(t, "sat-1", "sat/2")
+	topo.Snapshot.DisabledSatellites = []string{"sat-3"}
+	got := topics("satnet", topo, nil)
+
+	var sat SatelliteState
+	if err := json.Unmarshal(got["satnet/sat/sat-1/state"], &sat); err != nil {
+		t.Fatal(err)
+	}
+	if !sat.Active || *sat.AltKm != 550 || *sat.Lat != 0 || sat.Links != 2 {
+		t.Fatalf("unexpected satellite state %+v", sat)
+	}
+	if _, ok := got["satnet/sat/sat_2/state"]; !ok {
+		t.Fatal("expected the slash in sat/2 to be escaped")
+	}
+	if string(got["satnet/sat/sat-3/state"]) != `{"id":"sat-3","active":false,"links":0}` {
+		t.Fatalf("unexpected disabled satellite state %s", got["satnet/sat/sat-3/state"])
+	}
+	var link LinkState
+	if err := json.Unmarshal(got["satnet/link/gw/sat-1/state"], &link); err != nil {
+		t.Fatal(err)
+	}
+	if link.From != "gw" || link.To != "sat-1" || link.LatencyMS <= 0 {
+		t.Fatalf("unexpected link state %+v", link)
+	}
+	if _, ok := got["satnet/link/sat-1/gw/state"]; !ok {
+		t.Fatal("expected one topic per link direction")
+	}
+	if _, ok := got["satnet/sat/gw/state"]; ok {
+		t.Fatal("ground stations are not satellites")
+	}
+}
+
+func TestTopicsRenderTelemetryOfPresentSatellites(t *testing.T) {
+	telemetry := []simulation.SatelliteTelemetry{
+		{ID: "sat-1", BatteryPct: 81.5, TemperatureC: 12.3, Sunlit: true, Terminals: []simulation.LinkTerminal{}},
+		{ID: "sat-9", BatteryPct: 50},
+	}
+	got := topics("satnet", topology(t, "sat-1"), telemetry)
+
+	var sat simulation.SatelliteTelemetry
+	if err := json.Unmarshal(got["satnet/sat/sat-1/telemetry"], &sat); err != nil {
+		t.Fatal(err)
+	}
+	if sat.BatteryPct != 81.5 || sat.TemperatureC != 12.3 || !sat.Sunlit {
+		t.Fatalf("unexpected telemetry %+v", sat)
+	}
+	if _, ok := got["satnet/sat/sat-9/telemetry"]; ok {
+		t.Fatal("expected no telemetry topic for a satellite missing from the topology")
+	}
+}
+
+func TestBridgePublishesChangesAndClearsRemovedTopics(t *testing.T) {
+	addr, _, published := fakeBroker(t, 0)
+	b, err := Open("mqtt://" + addr + "/fleet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	collect := func(n int) map[string]string {
+		out := make(map[string]string)
+		for len(out) < n {
+			select {
+			case m := <-published:
+				out[m.topic] = m.payload
+			case <-time.After(5 * time.Second):
+				t.Fatalf("received %d of %d publishes: %v", len(out), n, out)
+			}
+		}
+		return out
+	}
+
+	first := topology(t, "sat-1", "sat-2")
+	b.Observe(first, nil)
+	initial := len(topics("fleet", first, nil))
+	collect(initial)
+
+	// Republishing the same state sends nothing; dropping sat-2 clears its topic and links and
+	// republishes only what changed for sat-1.
+	second := topology(t, "sat-1")
+	before, after := topics("fleet", first, nil), topics("fleet", second, nil)
+	want := make(map[string]string)
+	for topic, payload := range after {
+		if string(before[topic]) != string(payload) {
+			want[topic] = string(payload)
+		}
+	}
+	for topic := range before {
+		if _, ok := after[topic]; !ok {
+			want[topic] = ""
+		}
+	}
+	b.Observe(first, nil)
+	b.Observe(second, nil)
+	got := collect(len(want))
+	for topic, payload := range want {
+		if got[topic] != payload {
+			t.Fatalf("%s: got %q, want %q", topic, got[topic], payload)
+		}
+	}
+	for _, topic := range []string{"fleet/sat/sat-2/state", "fleet/link/gw/sat-2/state"} {
+		if payload, ok := want[topic]; !ok || payload != "" {
+			t.Fatalf("expected %s to be cleared", topic)
+		}
+	}
+	select {
+	case m := <-published:
+		t.Fatalf("unchanged topic republished: %+v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if stats := b.Stats(); stats.Published+stats.Cleared != uint64(initial+len(want)) || stats.Topics != len(after) || stats.Failed != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestOpenParsesDSNs(t *testing.T) {
+	for _, dsn := range []string{"tcp://broker", "mqtt://", "mqtt://broker/satnet/#"} {
+		if _, err := Open(dsn); err == nil {
+			t.Errorf("expected %q to be rejected", dsn)
+		}
+	}
+	b, err := Open("mqtt://user:pw@broker")
+	if err != nil || b.addr != "broker:1883" || b.root != "satnet" || b.password != "pw" {
+		t.Fatalf("unexpected bridge %+v, %v", b, err)
+	}
+	b.Close() // never connected
+}
@@ -0,0 +1,234 @@
+// Package mqtt bridges simulator state to an MQTT broker as retained per-satellite and per-link
+// topics, for dashboards and embedded consumers that only want a slice of the network.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types, already shifted into the fixed header's high nibble.
+const (
+	packetConnect    = 0x10
+	packetConnack    = 0x20
+	packetPublish    = 0x30
+	packetPingreq    = 0xc0
+	packetDisconnect = 0xe0
+
+	publishRetain = 0x01
+	protocolLevel = 4
+
+	flagCleanSession = 0x02
+	flagPassword     = 0x40
+	flagUsername     = 0x80
+
+	keepAlive = 60 * time.Second
+	// maxRemainingLength is the largest packet body MQTT's variable-length encoding can express.
+	maxRemainingLength = 268435455
+)
+
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// client is a minimal MQTT 3.1.1 publisher: QoS 0 only, no subscriptions, no TLS.
+type client struct {
+	conn net.Conn
+	done chan struct{}
+
+	mu     sync.Mutex // serializes writes
+	failed error      // set when the broker closes the connection
+}
+
+// dial connects and completes the CONNECT/CONNACK handshake, then keeps the connection alive.
+func dial(ctx context.Context, addr, clientID, username, password string) (*client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt connect: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	fail := func(err error) (*client, error) {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt connect: %w", err)
+	}
+
+	flags := byte(flagCleanSession)
+	if username != "" {
+		flags |= flagUsername
+	}
+	if password != "" {
+		flags |= flagPassword
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, protocolLevel, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(keepAlive/time.Second))
+	body = appendString(body, clientID)
+	if username != "" {
+		body = appendString(body, username)
+	}
+	if password != "" {
+		body = appendString(body, password)
+	}
+	if _, err := conn.Write(packet(packetConnect, body)); err != nil {
+		return fail(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	typ, ack, err := readPacket(reader)
+	if err != nil {
+		return fail(err)
+	}
+	if typ != packetConnack || len(ack) != 2 {
+		return fail(fmt.Errorf("expected CONNACK, got packet type %#x", typ))
+	}
+	if code := ack[1]; code != 0 {
+		reason, ok := connackErrors[code]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", code)
+		}
+		return fail(errors.New("broker refused connection: " + reason))
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &client{conn: conn, done: make(chan struct{})}
+	go c.readLoop(reader)
+	go c.pingLoop()
+	return c, nil
+}
+
+// publish sends a QoS 0 message. An empty retained payload clears the topic's retained message.
+func (c *client) publish(topic string, payload []byte, retain bool) error {
+	header := byte(packetPublish)
+	if retain {
+		header |= publishRetain
+	}
+	body := appendString(make([]byte, 0, 2+len(topic)+len(payload)), topic)
+	body = append(body, payload...)
+	if len(body) > maxRemainingLength {
+		return fmt.Errorf("mqtt publish to %s: %d-byte message is too large", topic, len(payload))
+	}
+	return c.write(packet(header, body))
+}
+
+// err reports why the connection ended, or nil while it is usable.
+func (c *client) err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.failed
+}
+
+// close sends DISCONNECT and closes the connection.
+func (c *client) close() {
+	c.write(packet(packetDisconnect, nil))
+	c.conn.Close()
+	<-c.done
+}
+
+func (c *client) write(b []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failed != nil {
+		return c.failed
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(keepAlive / 2))
+	if _, err := c.conn.Write(b); err != nil {
+		c.failed = fmt.Errorf("mqtt write: %w", err)
+		c.conn.Close()
+		return c.failed
+	}
+	return nil
+}
+
+// readLoop drains the broker's PINGRESPs and records the connection's end.
+func (c *client) readLoop(reader *bufio.Reader) {
+	defer close(c.done)
+	for {
+		if _, _, err := readPacket(reader); err != nil {
+			c.mu.Lock()
+			if c.failed == nil {
+				c.failed = fmt.Errorf("mqtt connection closed: %w", err)
+			}
+			c.mu.Unlock()
+			return
+		}
+	}
+}
+
+// pingLoop sends PINGREQ well within the keepalive so an idle bridge is not disconnected.
+func (c *client) pingLoop() {
+	ticker := time.NewTicker(keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.write(packet(packetPingreq, nil))
+		}
+	}
+}
+
+func packet(header byte, body []byte) []byte {
+	out := make([]byte, 0, 5+len(body))
+	out = append(out, header)
+	// Remaining length: seven bits per byte, least significant first, high bit for continuation.
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header & 0xf0, body, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
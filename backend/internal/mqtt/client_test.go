@@ -0,0 +1,108 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type message struct {
+	topic   string
+	payload string
+}
+
+// fakeBroker accepts clients, answers CONNECT with returnCode, and reports the CONNECT body and
+// each PUBLISH.
+func fakeBroker(t *testing.T, returnCode byte) (string, chan []byte, chan message) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	connects := make(chan []byte, 4)
+	published := make(chan message, 64)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					typ, body, err := readPacket(r)
+					if err != nil {
+						return
+					}
+					switch typ {
+					case packetConnect:
+						connects <- body
+						conn.Write([]byte{packetConnack, 2, 0, returnCode})
+					case packetPublish:
+						n := binary.BigEndian.Uint16(body)
+						published <- message{topic: string(body[2 : 2+n]), payload: string(body[2+n:])}
+					case packetDisconnect:
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), connects, published
+}
+
+func TestClientConnectsAndPublishes(t *testing.T) {
+	addr, connects, published := fakeBroker(t, 0)
+	c, err := dial(context.Background(), addr, "satnet-test", "user", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	connect := <-connects
+	if !bytes.HasPrefix(connect, []byte("\x00\x04MQTT\x04")) || connect[7]&(flagUsername|flagPassword|flagCleanSession) != flagUsername|flagPassword|flagCleanSession {
+		t.Fatalf("unexpected CONNECT %q", connect)
+	}
+	for _, want := range []string{"satnet-test", "user", "pw"} {
+		if !bytes.Contains(connect, []byte(want)) {
+			t.Fatalf("CONNECT is missing %q", want)
+		}
+	}
+
+	// A payload over 127 bytes needs a two-byte remaining length.
+	long := strings.Repeat("x", 300)
+	if err := c.publish("satnet/sat/a/state", []byte(long), true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-published:
+		if m.topic != "satnet/sat/a/state" || m.payload != long {
+			t.Fatalf("unexpected publish %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("broker received nothing")
+	}
+}
+
+func TestClientReportsRefusedConnection(t *testing.T) {
+	addr, _, _ := fakeBroker(t, 5)
+	if _, err := dial(context.Background(), addr, "satnet-test", "", ""); err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Fatalf("expected the refusal reason, got %v", err)
+	}
+}
+
+func TestPacketLengthRoundTrips(t *testing.T) {
+	for _, size := range []int{0, 127, 128, 16383, 16384, 70000} {
+		body := bytes.Repeat([]byte{1}, size)
+		typ, got, err := readPacket(bufio.NewReader(bytes.NewReader(packet(packetPublish|publishRetain, body))))
+		if err != nil || typ != packetPublish || len(got) != size {
+			t.Fatalf("size %d: type %#x, %d bytes, %v", size, typ, len(got), err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Open returns the Store described by dsn: "memory:" for an in-process store or
+// "file:<path>" for an append-only JSON-lines file that survives restarts.
+func Open(dsn string) (Store, error) {
+	switch {
+	case dsn == "" || dsn == "memory:":
+		return NewMemory(), nil
+	case strings.HasPrefix(dsn, "file:"):
+		return OpenFile(strings.TrimPrefix(dsn, "file:"))
+	default:
+		return nil, fmt.Errorf("unsupported store DSN %q", dsn)
+	}
+}
+
+// File is a Store that keeps records in memory and appends each one to a JSON-lines file.
+type File struct {
+	mu     sync.Mutex
+	memory *Memory
+	file   *os.File
+}
+
+// OpenFile loads existing records from path, creating the file if needed.
+func OpenFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("file store requires a path")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := NewMemory()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec fileRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		memory.restore(rec)
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &File{memory: memory, file: f}, nil
+}
+
+// fileRecord tags each line with the kind of record it holds.
+type fileRecord struct {
+	Kind     string      `json:"kind"`
+	Audit    *AuditEntry `json:"audit,omitempty"`
+	Run      *CachedRun  `json:"run,omitempty"`
+	Revision *Revision   `json:"revision,omitempty"`
+	Rollup   *Rollup     `json:"rollup,omitempty"`
+}
+
+// AppendAudit implements Store.
+func (s *File) AppendAudit(entry AuditEntry) (AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.memory.AppendAudit(entry)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	return stored, s.write(fileRecord{Kind: "audit", Audit: &stored})
+}
+
+// ListAudit implements Store.
+func (s *File) ListAudit(afterID uint64, limit int) ([]AuditEntry, error) {
+	return s.memory.ListAudit(afterID, limit)
+}
+
+// PutRun implements Store.
+func (s *File) PutRun(run CachedRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.memory.PutRun(run); err != nil {
+		return err
+	}
+	return s.write(fileRecord{Kind: "run", Run: &run})
+}
+
+// GetRun implements Store.
+func (s *File) GetRun(hash string) (CachedRun, bool, error) {
+	return s.memory.GetRun(hash)
+}
+
+// PutRevision implements Store.
+func (s *File) PutRevision(rev Revision) (Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.memory.PutRevision(rev)
+	if err != nil {
+		return Revision{}, err
+	}
+	return stored, s.write(fileRecord{Kind: "revision", Revision: &stored})
+}
+
+// GetRevision implements Store.
+func (s *File) GetRevision(id uint64) (Revision, bool, error) {
+	return s.memory.GetRevision(id)
+}
+
+// ListRevisions implements Store.
+func (s *File) ListRevisions(name string) ([]Revision, error) {
+	return s.memory.ListRevisions(name)
+}
+
+// AppendRollups implements Store.
+func (s *File) AppendRollups(rollups []Rollup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.memory.AppendRollups(rollups); err != nil {
+		return err
+	}
+	for i := range rollups {
+		if err := s.write(fileRecord{Kind: "rollup", Rollup: &rollups[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRollups implements Store.
+func (s *File) ListRollups(q RollupQuery) ([]Rollup, error) {
+	return s.memory.ListRollups(q)
+}
+
+// Close releases the underlying file.
+func (s *File) Close() error {
+	return s.file.Close()
+}
+
+func (s *File) write(rec fileRecord) error {
+	encoded, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(encoded, '\n'))
+	return err
+}
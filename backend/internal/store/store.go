@@ -0,0 +1,299 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxCachedRuns bounds how many run results are kept in memory; the oldest are evicted first.
+const maxCachedRuns = 256
+
+// MaxRollupsPerSeries bounds how many rollups are kept per link direction and resolution; the
+// oldest are evicted first. At one-minute resolution it retains two days.
+const MaxRollupsPerSeries = 2880
+
+// AuditEntry records a single mutating API call.
+type AuditEntry struct {
+	ID              uint64    `json:"id"`
+	Time            time.Time `json:"time"`
+	Actor           string    `json:"actor"`
+	Method          string    `json:"method"`
+	Path            string    `json:"path"`
+	Status          int       `json:"status"`
+	SnapshotVersion uint64    `json:"snapshotVersion,omitempty"`
+	// Body is the request payload with secrets redacted, when it is small enough to keep; a
+	// payload that is not JSON is kept as a string. BodyBytes and BodySHA256 identify every
+	// payload, so larger ones such as bundles can still be matched to the file sent.
+	Body       json.RawMessage `json:"body,omitempty"`
+	BodyBytes  int64           `json:"bodyBytes,omitempty"`
+	BodySHA256 string          `json:"bodySha256,omitempty"`
+}
+
+// CachedRun is a completed simulation run keyed by the hash of its scenario and run parameters.
+// Result holds the encoded run summary so the store stays independent of the simulation types.
+type CachedRun struct {
+	Hash    string          `json:"hash"`
+	Created time.Time       `json:"created"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// Revision is a saved version of a named scenario. Parent links each revision to the one it was
+// derived from, which may belong to another name when a scenario is forked, so the history of a
+// design forms a tree. Scenario holds the encoded config, keeping the store independent of the
+// simulation types.
+type Revision struct {
+	ID       uint64          `json:"id"`
+	Name     string          `json:"name"`
+	Parent   uint64          `json:"parent,omitempty"`
+	Message  string          `json:"message,omitempty"`
+	Created  time.Time       `json:"created"`
+	Scenario json.RawMessage `json:"scenario,omitempty"`
+}
+
+// Rollup aggregates one direction of a link's utilization in a session over a bucket of simulated
+// time starting at Start and ResolutionS seconds wide.
+type Rollup struct {
+	Session            string    `json:"session"`
+	From               string    `json:"from"`
+	To                 string    `json:"to"`
+	ResolutionS        float64   `json:"resolutionS"`
+	Start              time.Time `json:"start"`
+	Samples            int       `json:"samples"`
+	MeanLoadMbps       float64   `json:"meanLoadMbps"`
+	MaxLoadMbps        float64   `json:"maxLoadMbps"`
+	CapacityMbps       float64   `json:"capacityMbps,omitempty"`
+	MeanUtilizationPct float64   `json:"meanUtilizationPct"`
+	MaxUtilizationPct  float64   `json:"maxUtilizationPct"`
+}
+
+// RollupQuery selects the rollups of one link direction at one resolution that start in
+// [Start, End).
+type RollupQuery struct {
+	Session     string
+	From        string
+	To          string
+	ResolutionS float64
+	Start       time.Time
+	End         time.Time
+}
+
+// rollupSeries identifies the rollups of one link direction at one resolution.
+type rollupSeries struct {
+	session, from, to string
+	resolutionS       float64
+}
+
+// ErrUnknownRevision is returned when a revision names a parent that does not exist.
+var ErrUnknownRevision = errors.New("unknown revision")
+
+// Store persists server-side records that must outlive a single request.
+type Store interface {
+	// AppendAudit assigns the entry an ID and stores it.
+	AppendAudit(entry AuditEntry) (AuditEntry, error)
+	// ListAudit returns entries with an ID greater than afterID, oldest first, up to limit (0 for all).
+	ListAudit(afterID uint64, limit int) ([]AuditEntry, error)
+	// PutRun caches a run result, replacing any previous result for the same hash.
+	PutRun(run CachedRun) error
+	// GetRun returns the cached result for hash, reporting false when there is none.
+	GetRun(hash string) (CachedRun, bool, error)
+	// PutRevision assigns the revision an ID and stores it. A revision without a parent continues
+	// the latest revision of its name, so saving never discards earlier versions.
+	PutRevision(rev Revision) (Revision, error)
+	// GetRevision returns the revision with the given ID, reporting false when there is none.
+	GetRevision(id uint64) (Revision, bool, error)
+	// ListRevisions returns the revisions of name, or of every name when it is empty, oldest first.
+	ListRevisions(name string) ([]Revision, error)
+	// AppendRollups stores link utilization rollups, replacing any with the same series and
+	// start and evicting the oldest of a series beyond MaxRollupsPerSeries.
+	AppendRollups(rollups []Rollup) error
+	// ListRollups returns the rollups q selects, oldest first.
+	ListRollups(q RollupQuery) ([]Rollup, error)
+}
+
+// Memory is an in-process Store suitable for demos and tests.
+type Memory struct {
+	mu     sync.Mutex
+	audit  []AuditEntry
+	nextID uint64
+	runs   map[string]CachedRun
+	order  []string   // run hashes, oldest first
+	revs   []Revision // ordered by ID
+	// rollups holds each series ordered by start.
+	rollups map[rollupSeries][]Rollup
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{runs: make(map[string]CachedRun), rollups: make(map[rollupSeries][]Rollup)}
+}
+
+// AppendAudit implements Store.
+func (m *Memory) AppendAudit(entry AuditEntry) (AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	entry.ID = m.nextID
+	m.audit = append(m.audit, entry)
+	return entry, nil
+}
+
+// ListAudit implements Store.
+func (m *Memory) ListAudit(afterID uint64, limit int) ([]AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]AuditEntry, 0)
+	for _, entry := range m.audit {
+		if entry.ID <= afterID {
+			continue
+		}
+		out = append(out, entry)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// PutRun implements Store.
+func (m *Memory) PutRun(run CachedRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.putRunLocked(run)
+	return nil
+}
+
+// GetRun implements Store.
+func (m *Memory) GetRun(hash string) (CachedRun, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[hash]
+	return run, ok, nil
+}
+
+// PutRevision implements Store.
+func (m *Memory) PutRevision(rev Revision) (Revision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rev.Parent == 0 {
+		for _, existing := range m.revs {
+			if existing.Name == rev.Name {
+				rev.Parent = existing.ID
+			}
+		}
+	} else if _, ok := m.revisionLocked(rev.Parent); !ok {
+		return Revision{}, fmt.Errorf("%w %d", ErrUnknownRevision, rev.Parent)
+	}
+	rev.ID = uint64(len(m.revs)) + 1
+	m.revs = append(m.revs, rev)
+	return rev, nil
+}
+
+// GetRevision implements Store.
+func (m *Memory) GetRevision(id uint64) (Revision, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rev, ok := m.revisionLocked(id)
+	return rev, ok, nil
+}
+
+// ListRevisions implements Store.
+func (m *Memory) ListRevisions(name string) ([]Revision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Revision, 0)
+	for _, rev := range m.revs {
+		if name == "" || rev.Name == name {
+			out = append(out, rev)
+		}
+	}
+	return out, nil
+}
+
+// AppendRollups implements Store.
+func (m *Memory) AppendRollups(rollups []Rollup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rollup := range rollups {
+		m.appendRollupLocked(rollup)
+	}
+	return nil
+}
+
+// ListRollups implements Store.
+func (m *Memory) ListRollups(q RollupQuery) ([]Rollup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series := m.rollups[rollupSeries{q.Session, q.From, q.To, q.ResolutionS}]
+	first := sort.Search(len(series), func(i int) bool { return !series[i].Start.Before(q.Start) })
+	out := make([]Rollup, 0)
+	for _, rollup := range series[first:] {
+		if !rollup.Start.Before(q.End) {
+			break
+		}
+		out = append(out, rollup)
+	}
+	return out, nil
+}
+
+func (m *Memory) appendRollupLocked(rollup Rollup) {
+	key := rollupSeries{rollup.Session, rollup.From, rollup.To, rollup.ResolutionS}
+	series := m.rollups[key]
+	i := sort.Search(len(series), func(i int) bool { return !series[i].Start.Before(rollup.Start) })
+	switch {
+	case i < len(series) && series[i].Start.Equal(rollup.Start):
+		series[i] = rollup
+	case i == len(series):
+		series = append(series, rollup)
+	default:
+		series = append(series[:i+1], series[i:]...)
+		series[i] = rollup
+	}
+	if len(series) > MaxRollupsPerSeries {
+		series = append(series[:0:0], series[len(series)-MaxRollupsPerSeries:]...)
+	}
+	m.rollups[key] = series
+}
+
+// revisionLocked looks a revision up by ID; IDs are assigned sequentially from 1.
+func (m *Memory) revisionLocked(id uint64) (Revision, bool) {
+	if id == 0 || id > uint64(len(m.revs)) {
+		return Revision{}, false
+	}
+	return m.revs[id-1], true
+}
+
+func (m *Memory) putRunLocked(run CachedRun) {
+	if _, exists := m.runs[run.Hash]; !exists {
+		m.order = append(m.order, run.Hash)
+	}
+	m.runs[run.Hash] = run
+	for len(m.order) > maxCachedRuns {
+		delete(m.runs, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+// restore re-inserts a persisted record, keeping its original ID.
+func (m *Memory) restore(rec fileRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rec.Audit != nil {
+		m.audit = append(m.audit, *rec.Audit)
+		if rec.Audit.ID > m.nextID {
+			m.nextID = rec.Audit.ID
+		}
+	}
+	if rec.Run != nil {
+		m.putRunLocked(*rec.Run)
+	}
+	if rec.Revision != nil {
+		m.revs = append(m.revs, *rec.Revision)
+	}
+	if rec.Rollup != nil {
+		m.appendRollupLocked(*rec.Rollup)
+	}
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryAuditPaging(t *testing.T) {
+	m := NewMemory()
+	for _, path := range []string{"/a", "/b", "/c"} {
+		if _, err := m.AppendAudit(AuditEntry{Method: "POST", Path: path}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	all, err := m.ListAudit(0, 0)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("expected 3 entries, got %v (err %v)", all, err)
+	}
+	if all[0].ID != 1 || all[2].Path != "/c" {
+		t.Fatalf("entries should be ordered with sequential IDs: %+v", all)
+	}
+
+	page, _ := m.ListAudit(1, 1)
+	if len(page) != 1 || page[0].Path != "/b" {
+		t.Fatalf("expected single entry after ID 1, got %+v", page)
+	}
+}
+
+func TestFileStoreSurvivesReopen(t *testing.T) {
+	path := t.TempDir() + "/satnet.jsonl"
+	st, err := Open("file:" + path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if _, err := st.AppendAudit(AuditEntry{Method: "DELETE", Path: "/satellites/x"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	st.(*File).Close()
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	entries, _ := reopened.ListAudit(0, 0)
+	if len(entries) != 1 || entries[0].Path != "/satellites/x" {
+		t.Fatalf("expected persisted entry, got %+v", entries)
+	}
+	next, _ := reopened.AppendAudit(AuditEntry{Method: "POST"})
+	if next.ID != 2 {
+		t.Fatalf("expected IDs to continue after reload, got %d", next.ID)
+	}
+}
+
+func TestRunCacheSurvivesReopenAndEvictsOldest(t *testing.T) {
+	path := t.TempDir() + "/satnet.jsonl"
+	st, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if err := st.PutRun(CachedRun{Hash: "abc", Result: []byte(`{"steps":3}`)}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	st.Close()
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	run, ok, _ := reopened.GetRun("abc")
+	if !ok || string(run.Result) != `{"steps":3}` {
+		t.Fatalf("expected cached run after reload, got %+v (found %v)", run, ok)
+	}
+
+	m := NewMemory()
+	for i := 0; i <= maxCachedRuns; i++ {
+		_ = m.PutRun(CachedRun{Hash: fmt.Sprint(i)})
+	}
+	if _, ok, _ := m.GetRun("0"); ok {
+		t.Fatalf("expected the oldest run to be evicted")
+	}
+	if _, ok, _ := m.GetRun(fmt.Sprint(maxCachedRuns)); !ok {
+		t.Fatalf("expected the newest run to be cached")
+	}
+}
+
+func TestRevisionsFormATreeAndSurviveReopen(t *testing.T) {
+	path := t.TempDir() + "/satnet.jsonl"
+	st, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	first, _ := st.PutRevision(Revision{Name: "polar", Scenario: []byte(`{"elevationMask":0.1}`)})
+	second, _ := st.PutRevision(Revision{Name: "polar", Scenario: []byte(`{"elevationMask":0.2}`)})
+	fork, _ := st.PutRevision(Revision{Name: "polar-dense", Parent: first.ID})
+	if first.ID != 1 || first.Parent != 0 || second.Parent != first.ID || fork.Parent != first.ID {
+		t.Fatalf("unexpected lineage: %+v %+v %+v", first, second, fork)
+	}
+	if _, err := st.PutRevision(Revision{Name: "orphan", Parent: 9}); !errors.Is(err, ErrUnknownRevision) {
+		t.Fatalf("expected ErrUnknownRevision, got %v", err)
+	}
+	st.Close()
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	polar, _ := reopened.ListRevisions("polar")
+	all, _ := reopened.ListRevisions("")
+	if len(polar) != 2 || len(all) != 3 {
+		t.Fatalf("expected 2 of 3 revisions under polar, got %+v of %+v", polar, all)
+	}
+	rev, ok, _ := reopened.GetRevision(second.ID)
+	if !ok || string(rev.Scenario) != `{"elevationMask":0.2}` {
+		t.Fatalf("expected revision after reload, got %+v (found %v)", rev, ok)
+	}
+	next, _ := reopened.PutRevision(Revision{Name: "polar-dense"})
+	if next.ID != 4 || next.Parent != fork.ID {
+		t.Fatalf("expected IDs and lineage to continue after reload, got %+v", next)
+	}
+}
+
+func TestRollupsAreOrderedBoundedAndSurviveReopen(t *testing.T) {
+	path := t.TempDir() + "/satnet.jsonl"
+	st, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rollup := func(minute int, load float64) Rollup {
+		return Rollup{Session: "default", From: "a", To: "b", ResolutionS: 60, Start: start.Add(time.Duration(minute) * time.Minute), Samples: 1, MeanLoadMbps: load}
+	}
+	batch := []Rollup{rollup(2, 20), rollup(0, 0), rollup(1, 10), rollup(2, 25)}
+	batch = append(batch, Rollup{Session: "default", From: "a", To: "b", ResolutionS: 300, Start: start})
+	if err := st.AppendRollups(batch); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	st.Close()
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	got, _ := reopened.ListRollups(RollupQuery{Session: "default", From: "a", To: "b", ResolutionS: 60, Start: start.Add(time.Minute), End: start.Add(time.Hour)})
+	if len(got) != 2 || got[0].MeanLoadMbps != 10 || got[1].MeanLoadMbps != 25 {
+		t.Fatalf("expected minutes 1 and 2 with the replacement kept, got %+v", got)
+	}
+	if got, _ := reopened.ListRollups(RollupQuery{Session: "default", From: "b", To: "a", ResolutionS: 60, Start: start, End: start.Add(time.Hour)}); len(got) != 0 {
+		t.Fatalf("expected the reverse direction to be a separate series, got %+v", got)
+	}
+
+	m := NewMemory()
+	for minute := 0; minute < MaxRollupsPerSeries+10; minute++ {
+		m.AppendRollups([]Rollup{rollup(minute, float64(minute))})
+	}
+	all, _ := m.ListRollups(RollupQuery{Session: "default", From: "a", To: "b", ResolutionS: 60, Start: start, End: start.Add(30 * 24 * time.Hour)})
+	if len(all) != MaxRollupsPerSeries || all[0].MeanLoadMbps != 10 {
+		t.Fatalf("expected the oldest rollups evicted, got %d starting at %+v", len(all), all[0])
+	}
+}
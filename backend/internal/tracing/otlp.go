@@ -0,0 +1,300 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize     = 2048
+	batchSize     = 512
+	flushInterval = 5 * time.Second
+	exportTimeout = 10 * time.Second
+	// tracesPath is where OTLP/HTTP receivers accept spans.
+	tracesPath = "/v1/traces"
+)
+
+// Stats counts a tracer's exported spans. Export is best effort: a failed batch is not retried.
+type Stats struct {
+	Exported  uint64 `json:"exported"`
+	Failed    uint64 `json:"failed"`
+	Dropped   uint64 `json:"dropped"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Tracer starts spans and exports the sampled ones to an OTLP/HTTP receiver in JSON batches from
+// one goroutine, so a slow or unreachable collector never delays a request.
+type Tracer struct {
+	endpoint string
+	service  string
+	ratio    float64
+	client   *http.Client
+
+	queue chan *Span
+	start sync.Once
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New returns a tracer exporting to endpoint, an http(s) URL of an OTLP/HTTP receiver such as
+// http://collector:4318; /v1/traces is appended unless the path already ends with it. Root traces
+// are kept with probability sampleRatio, and traces continued from a caller keep its decision. The
+// exporter starts with the first finished span.
+func New(endpoint, service string, sampleRatio float64) (*Tracer, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("trace endpoint %q must be an http or https URL", endpoint)
+	}
+	if math.IsNaN(sampleRatio) || sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio %v must be between 0 and 1", sampleRatio)
+	}
+	if !strings.HasSuffix(u.Path, tracesPath) {
+		u.Path = strings.TrimSuffix(u.Path, "/") + tracesPath
+	}
+	t := &Tracer{
+		endpoint: u.String(),
+		service:  service,
+		ratio:    sampleRatio,
+		client:   &http.Client{Timeout: exportTimeout},
+		queue:    make(chan *Span, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	return t, nil
+}
+
+// Stats reports the tracer's counters.
+func (t *Tracer) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// Close exports the queued spans and stops the tracer. Spans ended afterwards are never exported.
+func (t *Tracer) Close() {
+	if t == nil {
+		return
+	}
+	t.start.Do(func() { close(t.done) })
+	t.once.Do(func() { close(t.stop) })
+	<-t.done
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.start.Do(func() { go t.run() })
+	select {
+	case t.queue <- span:
+	default:
+		t.count(0, 0, 1, "")
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.export(batch); err != nil {
+			t.count(0, uint64(len(batch)), 0, err.Error())
+			log.Printf("trace export: %v", err)
+		} else {
+			t.count(uint64(len(batch)), 0, 0, "")
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case span := <-t.queue:
+			if batch = append(batch, span); len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case span := <-t.queue:
+					if batch = append(batch, span); len(batch) == batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) count(exported, failed, dropped uint64, lastError string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Exported += exported
+	t.stats.Failed += failed
+	t.stats.Dropped += dropped
+	if lastError != "" {
+		t.stats.LastError = lastError
+	}
+}
+
+func (t *Tracer) export(spans []*Span) error {
+	body, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// The OTLP/JSON encoding of an ExportTraceServiceRequest. IDs are hex strings and 64-bit integers
+// are decimal strings, as the protocol's JSON mapping requires.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// statusError is OTLP's STATUS_CODE_ERROR.
+const statusError = 2
+
+func (t *Tracer) request(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		s := otlpSpan{
+			TraceID:           span.sc.TraceID.String(),
+			SpanID:            span.sc.SpanID.String(),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        attributes(span.attrs),
+		}
+		if span.parent != (SpanID{}) {
+			s.ParentSpanID = span.parent.String()
+		}
+		if span.err != "" {
+			s.Status = &otlpStatus{Code: statusError, Message: span.err}
+		}
+		span.mu.Unlock()
+		out = append(out, s)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: attributes(map[string]any{"service.name": t.service})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "satnet"}, Spans: out}},
+	}}}
+}
+
+// attributes encodes attrs sorted by key.
+func attributes(attrs map[string]any) []otlpAttribute {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]otlpAttribute, 0, len(keys))
+	for _, key := range keys {
+		var v otlpValue
+		switch value := attrs[key].(type) {
+		case string:
+			v.StringValue = &value
+		case bool:
+			v.BoolValue = &value
+		case int:
+			s := strconv.Itoa(value)
+			v.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(value, 10)
+			v.IntValue = &s
+		case uint64:
+			s := strconv.FormatUint(value, 10)
+			v.IntValue = &s
+		case float64:
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				s := strconv.FormatFloat(value, 'g', -1, 64)
+				v.StringValue = &s
+			} else {
+				v.DoubleValue = &value
+			}
+		default:
+			s := fmt.Sprint(value)
+			v.StringValue = &s
+		}
+		out = append(out, otlpAttribute{Key: key, Value: v})
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExportsOTLPJSON(t *testing.T) {
+	got := make(chan otlpRequest, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected export to %s (%s)", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		got <- req
+	}))
+	defer srv.Close()
+
+	tracer, err := New(srv.URL, "satnet-test", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := tracer.Start(context.Background(), "request")
+	start := time.Unix(100, 0)
+	_, child := tracer.StartAt(ctx, "routing", start)
+	child.SetAttribute("demands", 3)
+	child.SetAttribute("capacityAware", true)
+	child.SetAttribute("latencyMs", math.Inf(1))
+	child.SetError(errors.New("no route"))
+	child.EndAt(start.Add(time.Millisecond))
+	parent.End()
+	tracer.Close()
+
+	var req otlpRequest
+	select {
+	case req = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no export received")
+	}
+	rs := req.ResourceSpans[0]
+	if v := rs.Resource.Attributes[0]; v.Key != "service.name" || *v.Value.StringValue != "satnet-test" {
+		t.Fatalf("unexpected resource %+v", rs.Resource)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected both spans in one batch, got %d", len(spans))
+	}
+	routing := spans[0]
+	if routing.Name != "routing" || routing.ParentSpanID != spans[1].SpanID || routing.TraceID != spans[1].TraceID {
+		t.Fatalf("child span should reference its parent: %+v", routing)
+	}
+	if routing.StartTimeUnixNano != "100000000000" || routing.EndTimeUnixNano != "100001000000" {
+		t.Fatalf("unexpected times %s-%s", routing.StartTimeUnixNano, routing.EndTimeUnixNano)
+	}
+	if routing.Status == nil || routing.Status.Code != statusError || routing.Status.Message != "no route" {
+		t.Fatalf("unexpected status %+v", routing.Status)
+	}
+	attrs := routing.Attributes
+	if attrs[0].Key != "capacityAware" || !*attrs[0].Value.BoolValue ||
+		attrs[1].Key != "demands" || *attrs[1].Value.IntValue != "3" ||
+		attrs[2].Key != "latencyMs" || *attrs[2].Value.StringValue != "+Inf" {
+		t.Fatalf("unexpected attributes %+v", attrs)
+	}
+	if stats := tracer.Stats(); stats.Exported != 2 || stats.Failed != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCountsFailedExports(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	tracer, _ := New(srv.URL+"/v1/traces", "satnet-test", 1)
+	_, span := tracer.Start(context.Background(), "request")
+	span.End()
+	tracer.Close()
+	if stats := tracer.Stats(); stats.Failed != 1 || stats.LastError == "" {
+		t.Fatalf("expected the rejected batch to be counted: %+v", stats)
+	}
+}
+
+func TestNewRejectsBadSettings(t *testing.T) {
+	for _, c := range []struct {
+		endpoint string
+		ratio    float64
+	}{{"collector:4318", 1}, {"grpc://collector:4317", 1}, {"http://collector:4318", -0.1}, {"http://collector:4318", math.NaN()}} {
+		if _, err := New(c.endpoint, "test", c.ratio); err == nil {
+			t.Fatalf("expected %q with ratio %v to be rejected", c.endpoint, c.ratio)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package tracing records request and recompute spans and exports them to an OpenTelemetry
+// collector over OTLP/HTTP, so operators can see where a slow request spent its time.
+//
+// A nil *Tracer is valid and records nothing, so callers need not check whether tracing is on.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span kinds, as numbered by OTLP.
+const (
+	KindInternal = 1
+	KindServer   = 2
+)
+
+// TraceID and SpanID identify spans as in W3C Trace Context.
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span that propagates to its children, in process or across a
+// traceparent header.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// Valid reports whether sc names a span; the zero SpanContext does not.
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats sc as a W3C traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent reads a W3C traceparent header value. Unknown versions are read as version 00,
+// as the specification requires.
+func ParseTraceparent(header string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("malformed traceparent %q", header)
+	}
+	var sc SpanContext
+	var version, flags [1]byte
+	for _, field := range []struct {
+		dst []byte
+		src string
+	}{{version[:], parts[0]}, {sc.TraceID[:], parts[1]}, {sc.SpanID[:], parts[2]}, {flags[:], parts[3]}} {
+		if len(field.src) != 2*len(field.dst) || strings.ToLower(field.src) != field.src {
+			return SpanContext{}, fmt.Errorf("malformed traceparent %q", header)
+		}
+		if _, err := hex.Decode(field.dst, []byte(field.src)); err != nil {
+			return SpanContext{}, fmt.Errorf("malformed traceparent %q", header)
+		}
+	}
+	if !sc.Valid() {
+		return SpanContext{}, fmt.Errorf("traceparent %q has a zero ID", header)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// Span is one timed operation. Its methods are safe on a nil *Span, which records nothing.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent SpanID
+	name   string
+	kind   int
+	start  time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs map[string]any
+	err   string
+	ended bool
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx in which span is the parent of new spans.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// FromContext returns the span carried by ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Context returns the span's identity, or the zero SpanContext for a nil span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttribute attaches a string, bool, integer or float value to the span; other values are
+// formatted as strings.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil || !s.sc.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+// SetError marks the span as failed with err's message.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil || !s.sc.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span now and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	s.EndAt(time.Now())
+}
+
+// EndAt finishes the span at the given time and queues it for export.
+func (s *Span) EndAt(end time.Time) {
+	if s == nil || !s.sc.Sampled {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, end
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+// Start begins an internal span, a child of the span in ctx or else the root of a new trace, and
+// returns a context carrying it.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	return t.StartAt(ctx, name, time.Now())
+}
+
+// StartAt is Start for a span that began at start, for recording work timed elsewhere.
+func (t *Tracer) StartAt(ctx context.Context, name string, start time.Time) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := t.newSpan(FromContext(ctx).Context(), name, KindInternal, start)
+	return ContextWithSpan(ctx, span), span
+}
+
+// StartServer begins a span for an incoming request, continuing the trace named by its
+// traceparent header when the header is well formed.
+func (t *Tracer) StartServer(ctx context.Context, name, traceparent string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent, err := ParseTraceparent(traceparent)
+	if err != nil {
+		parent = SpanContext{}
+	}
+	span := t.newSpan(parent, name, KindServer, time.Now())
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) newSpan(parent SpanContext, name string, kind int, start time.Time) *Span {
+	span := &Span{tracer: t, name: name, kind: kind, start: start}
+	if parent.Valid() {
+		span.sc.TraceID, span.sc.Sampled, span.parent = parent.TraceID, parent.Sampled, parent.SpanID
+	} else {
+		span.sc.TraceID = newTraceID()
+		span.sc.Sampled = t.sampled(span.sc.TraceID)
+	}
+	span.sc.SpanID = newSpanID()
+	return span
+}
+
+// sampled keeps a root trace when the top 64 bits of its ID fall below the sample ratio, so every
+// server agrees on the decision for a given trace.
+func (t *Tracer) sampled(id TraceID) bool {
+	if t.ratio >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(id[:8])) < t.ratio*(1<<64)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for id == (TraceID{}) {
+		randomBytes(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for id == (SpanID{}) {
+		randomBytes(id[:])
+	}
+	return id
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to the clock regardless.
+		binary.BigEndian.PutUint64(b[len(b)-8:], uint64(time.Now().UnixNano()))
+	}
+}
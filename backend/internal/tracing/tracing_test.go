@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTraceparentRoundTrips(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.Sampled || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected span context %+v", sc)
+	}
+	if got := sc.Traceparent(); got != header {
+		t.Fatalf("expected %q, got %q", header, got)
+	}
+
+	// Later versions may append fields; version 00 may not.
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); err != nil {
+		t.Fatalf("expected a future version to be accepted: %v", err)
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01",
+		"0g-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestSpansNestAndContinueRemoteTraces(t *testing.T) {
+	tracer, err := New("http://collector:4318", "test", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, server := tracer.StartServer(context.Background(), "GET /x", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if server.sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.parent.String() != "00f067aa0ba902b7" || server.kind != KindServer {
+		t.Fatalf("server span should continue the remote trace: %+v", server)
+	}
+	_, child := tracer.Start(ctx, "compute")
+	if child.sc.TraceID != server.sc.TraceID || child.parent != server.sc.SpanID || child.kind != KindInternal {
+		t.Fatalf("child span should nest under the server span: %+v", child)
+	}
+
+	// An unsampled caller's decision carries through, so nothing is recorded.
+	_, unsampled := tracer.StartServer(context.Background(), "GET /x", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	unsampled.SetAttribute("k", "v")
+	unsampled.End()
+	if unsampled.attrs != nil || len(tracer.queue) != 0 {
+		t.Fatal("an unsampled span should record nothing")
+	}
+}
+
+func TestSampleRatioAppliesToRootTraces(t *testing.T) {
+	never, _ := New("http://collector:4318", "test", 0)
+	if _, span := never.Start(context.Background(), "root"); span.Context().Sampled {
+		t.Fatal("a zero ratio should sample nothing")
+	}
+	half, _ := New("http://collector:4318", "test", 0.5)
+	sampled := 0
+	for i := 0; i < 2000; i++ {
+		if _, span := half.Start(context.Background(), "root"); span.Context().Sampled {
+			sampled++
+		}
+	}
+	if sampled < 800 || sampled > 1200 {
+		t.Fatalf("expected about half of 2000 traces sampled, got %d", sampled)
+	}
+}
+
+func TestNilTracerAndSpanAreNoOps(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "x")
+	if span != nil || FromContext(ctx) != nil {
+		t.Fatal("a nil tracer should not start spans")
+	}
+	span.SetAttribute("k", 1)
+	span.SetError(errors.New("boom"))
+	span.End()
+	tracer.Close()
+}
@@ -0,0 +1,403 @@
+// Package webhook POSTs simulation events to external HTTP endpoints, so pipelines can react to
+// coverage drops, partitions and finished runs without holding a streaming connection open.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/example/satnet/backend/simulation"
+)
+
+// Event types a hook can subscribe to.
+const (
+	// CoverageBelowThreshold fires when a session's coverage falls below the hook's threshold.
+	CoverageBelowThreshold = "coverage.below_threshold"
+	// NetworkPartitioned fires when demands that had a route lose it because their endpoints are
+	// no longer connected. Demands blocked by admission control or left stale by the routing
+	// budget do not count.
+	NetworkPartitioned = "network.partitioned"
+	// RunCompleted fires when a POST /runs batch run finishes.
+	RunCompleted = "run.completed"
+	// AlertFiring fires when an alert rule has been violated for its full duration.
+	AlertFiring = "alert.firing"
+	// AlertResolved fires when a firing alert's condition clears.
+	AlertResolved = "alert.resolved"
+)
+
+var eventTypes = map[string]bool{
+	CoverageBelowThreshold: true, NetworkPartitioned: true, RunCompleted: true,
+	AlertFiring: true, AlertResolved: true,
+}
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body keyed by the hook secret.
+	SignatureHeader = "X-SatNet-Signature"
+	EventHeader     = "X-SatNet-Event"
+	DeliveryHeader  = "X-SatNet-Delivery"
+
+	queueSize   = 256
+	senders     = 2
+	maxAttempts = 3
+	sendTimeout = 10 * time.Second
+)
+
+// Hook is a registered endpoint. Secret is write-only: it signs deliveries but is never reported.
+type Hook struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// CoverageThreshold is the coverage percentage CoverageBelowThreshold compares against.
+	CoverageThreshold float64   `json:"coverageThreshold,omitempty"`
+	Secret            string    `json:"secret,omitempty"`
+	Created           time.Time `json:"created"`
+	Stats             Stats     `json:"stats"`
+}
+
+// Stats counts a hook's delivery outcomes. Failed deliveries have exhausted their retries.
+type Stats struct {
+	Delivered uint64    `json:"delivered"`
+	Failed    uint64    `json:"failed"`
+	Dropped   uint64    `json:"dropped"`
+	LastError string    `json:"lastError,omitempty"`
+	LastSent  time.Time `json:"lastSent"`
+}
+
+// Event is the JSON body POSTed to hooks.
+type Event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Session string    `json:"session,omitempty"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data"`
+}
+
+// CoverageData describes a CoverageBelowThreshold event.
+type CoverageData struct {
+	CoveragePercent float64   `json:"coveragePercent"`
+	Threshold       float64   `json:"threshold"`
+	SimTime         time.Time `json:"simTime"`
+	Version         uint64    `json:"version"`
+}
+
+// PartitionData describes a NetworkPartitioned event.
+type PartitionData struct {
+	Demands []string  `json:"demands"`
+	SimTime time.Time `json:"simTime"`
+	Version uint64    `json:"version"`
+}
+
+// RunData describes a RunCompleted event.
+type RunData struct {
+	Hash            string    `json:"hash"`
+	Steps           int       `json:"steps"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	CoveragePercent float64   `json:"coveragePercent"`
+}
+
+// Validate checks the URL, event filters and threshold of a hook being registered.
+func (h Hook) Validate() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https URL", h.URL)
+	}
+	if len(h.Events) == 0 {
+		return errors.New("at least one event type is required")
+	}
+	for _, typ := range h.Events {
+		if !eventTypes[typ] {
+			return fmt.Errorf("unknown event type %q", typ)
+		}
+	}
+	if h.CoverageThreshold < 0 || h.CoverageThreshold > 100 {
+		return errors.New("coverage threshold must be between 0 and 100")
+	}
+	return nil
+}
+
+func (h *Hook) wants(typ string) bool {
+	for _, t := range h.Events {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+type delivery struct {
+	hook  string
+	event Event
+	body  []byte
+}
+
+// sessionState is what Observe remembers about a session between recomputes.
+type sessionState struct {
+	routed map[string]bool
+	below  map[string]bool // hook IDs whose threshold coverage is currently under
+}
+
+// Dispatcher holds the registered hooks and delivers events to them in the background.
+type Dispatcher struct {
+	client *http.Client
+	queue  chan delivery
+	// retryDelay is the wait before the first retry; it doubles on each further attempt.
+	retryDelay time.Duration
+
+	mu       sync.Mutex
+	hooks    map[string]*Hook
+	sessions map[string]*sessionState
+}
+
+// New starts a dispatcher with no hooks.
+func New() *Dispatcher {
+	d := &Dispatcher{
+		client:     &http.Client{Timeout: sendTimeout},
+		queue:      make(chan delivery, queueSize),
+		retryDelay: time.Second,
+		hooks:      make(map[string]*Hook),
+		sessions:   make(map[string]*sessionState),
+	}
+	for i := 0; i < senders; i++ {
+		go d.send()
+	}
+	return d
+}
+
+// Register validates and adds a hook, returning it with its assigned ID and without the secret.
+func (d *Dispatcher) Register(h Hook) (Hook, error) {
+	if err := h.Validate(); err != nil {
+		return Hook{}, err
+	}
+	h.ID = newID()
+	h.Created = time.Now().UTC()
+	h.Stats = Stats{}
+	stored := h
+	d.mu.Lock()
+	d.hooks[h.ID] = &stored
+	d.mu.Unlock()
+	h.Secret = ""
+	return h, nil
+}
+
+// List returns the registered hooks, oldest first, without their secrets.
+func (d *Dispatcher) List() []Hook {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]Hook, 0, len(d.hooks))
+	for _, h := range d.hooks {
+		copied := *h
+		copied.Secret = ""
+		out = append(out, copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out
+}
+
+// Delete removes a hook, reporting false when there is none with that ID.
+func (d *Dispatcher) Delete(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.hooks[id]; !ok {
+		return false
+	}
+	delete(d.hooks, id)
+	for _, state := range d.sessions {
+		delete(state.below, id)
+	}
+	return true
+}
+
+// Observe compares a session's latest snapshot with the previous one it saw, emitting coverage and
+// partition events on the transitions.
+func (d *Dispatcher) Observe(session string, snap simulation.Snapshot) {
+	d.mu.Lock()
+	state, seen := d.sessions[session]
+	if !seen {
+		state = &sessionState{below: make(map[string]bool)}
+		d.sessions[session] = state
+	}
+
+	skip := make(map[string]bool, len(snap.StaleRoutes))
+	for _, id := range snap.StaleRoutes {
+		skip[id] = true
+	}
+	for id, alloc := range snap.Allocations {
+		if alloc.Status == simulation.StatusBlocked || alloc.Status == simulation.StatusPreempted {
+			skip[id] = true
+		}
+	}
+	var lost []string
+	for id := range state.routed {
+		if _, ok := snap.Routes[id]; !ok && !skip[id] {
+			lost = append(lost, id)
+		}
+	}
+	sort.Strings(lost)
+	state.routed = make(map[string]bool, len(snap.Routes))
+	for id := range snap.Routes {
+		state.routed[id] = true
+	}
+
+	var pending []delivery
+	now := time.Now().UTC()
+	for _, h := range d.hooks {
+		if h.wants(CoverageBelowThreshold) {
+			below := snap.Coverage.CoveragePercent < h.CoverageThreshold
+			if below && !state.below[h.ID] {
+				pending = append(pending, d.prepareLocked(h, Event{Type: CoverageBelowThreshold, Session: session, Time: now, Data: CoverageData{
+					CoveragePercent: snap.Coverage.CoveragePercent,
+					Threshold:       h.CoverageThreshold,
+					SimTime:         snap.SimTime,
+					Version:         snap.Version,
+				}}))
+			}
+			state.below[h.ID] = below
+		}
+		if len(lost) > 0 && h.wants(NetworkPartitioned) {
+			pending = append(pending, d.prepareLocked(h, Event{Type: NetworkPartitioned, Session: session, Time: now, Data: PartitionData{
+				Demands: lost,
+				SimTime: snap.SimTime,
+				Version: snap.Version,
+			}}))
+		}
+	}
+	d.mu.Unlock()
+	d.enqueue(pending)
+}
+
+// Forget drops what Observe remembers about a deleted session.
+func (d *Dispatcher) Forget(session string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sessions, session)
+}
+
+// Publish delivers an event of the given type to every hook subscribed to it.
+func (d *Dispatcher) Publish(typ, session string, data any) {
+	d.mu.Lock()
+	var pending []delivery
+	now := time.Now().UTC()
+	for _, h := range d.hooks {
+		if h.wants(typ) {
+			pending = append(pending, d.prepareLocked(h, Event{Type: typ, Session: session, Time: now, Data: data}))
+		}
+	}
+	d.mu.Unlock()
+	d.enqueue(pending)
+}
+
+// prepareLocked assigns the event a delivery ID and encodes it for one hook.
+func (d *Dispatcher) prepareLocked(h *Hook, evt Event) delivery {
+	evt.ID = newID()
+	body, err := json.Marshal(evt)
+	if err != nil {
+		// Event data is built from plain structs; failing to encode it is a programming error.
+		panic(err)
+	}
+	return delivery{hook: h.ID, event: evt, body: body}
+}
+
+// enqueue hands deliveries to the senders, dropping them when the queue is full so a slow
+// endpoint never stalls the simulation.
+func (d *Dispatcher) enqueue(pending []delivery) {
+	for _, job := range pending {
+		select {
+		case d.queue <- job:
+		default:
+			log.Printf("webhook %s: queue full, dropped %s event", job.hook, job.event.Type)
+			d.update(job.hook, func(s *Stats) { s.Dropped++ })
+		}
+	}
+}
+
+func (d *Dispatcher) send() {
+	for job := range d.queue {
+		var err error
+		delay := d.retryDelay
+		for attempt := 1; attempt <= maxAttempts; attempt++ {
+			if err = d.post(job); err == nil {
+				break
+			}
+			if attempt < maxAttempts {
+				time.Sleep(delay)
+				delay *= 2
+			}
+		}
+		if err != nil {
+			log.Printf("webhook %s: %s delivery failed: %v", job.hook, job.event.Type, err)
+			d.update(job.hook, func(s *Stats) { s.Failed++; s.LastError = err.Error() })
+			continue
+		}
+		d.update(job.hook, func(s *Stats) { s.Delivered++; s.LastSent = time.Now().UTC() })
+	}
+}
+
+func (d *Dispatcher) post(job delivery) error {
+	d.mu.Lock()
+	h, ok := d.hooks[job.hook]
+	var target, secret string
+	if ok {
+		target, secret = h.URL, h.Secret
+	}
+	d.mu.Unlock()
+	if !ok {
+		return nil // deleted while queued
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(job.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, job.event.Type)
+	req.Header.Set(DeliveryHeader, job.event.ID)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, job.body))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (d *Dispatcher) update(id string, fn func(*Stats)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if h, ok := d.hooks[id]; ok {
+		fn(&h.Stats)
+	}
+}
+
+// Sign returns the SignatureHeader value for body under secret, for receivers to compare against
+// with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("w-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+type received struct {
+	event     Event
+	signature string
+	body      []byte
+}
+
+func endpoint(t *testing.T, status int) (*httptest.Server, chan received) {
+	got := make(chan received, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var evt Event
+		if err := json.Unmarshal(body, &evt); err != nil {
+			t.Errorf("undecodable delivery: %v", err)
+		}
+		got <- received{event: evt, signature: r.Header.Get(SignatureHeader), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func next(t *testing.T, got chan received) received {
+	select {
+	case r := <-got:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+		return received{}
+	}
+}
+
+func TestObserveSignsAndFiltersTransitions(t *testing.T) {
+	srv, got := endpoint(t, http.StatusNoContent)
+	d := New()
+	hook, err := d.Register(Hook{URL: srv.URL, Events: []string{CoverageBelowThreshold, NetworkPartitioned}, CoverageThreshold: 90, Secret: "s3cret"})
+	if err != nil || hook.Secret != "" || d.List()[0].Secret != "" {
+		t.Fatalf("register: %+v, %v", hook, err)
+	}
+
+	snap := func(pct float64, routes ...string) simulation.Snapshot {
+		s := simulation.Snapshot{Version: uint64(len(routes)), Coverage: coverage.Summary{CoveragePercent: pct}, Routes: map[string]routing.Path{}}
+		for _, id := range routes {
+			s.Routes[id] = routing.Path{}
+		}
+		return s
+	}
+	d.Observe("a", snap(95, "x", "y"))
+	d.Observe("a", snap(80, "x"))
+	first, second := next(t, got), next(t, got)
+	if first.event.Type == NetworkPartitioned {
+		first, second = second, first
+	}
+	if first.event.Type != CoverageBelowThreshold || first.event.Session != "a" || second.event.Type != NetworkPartitioned {
+		t.Fatalf("unexpected deliveries %+v and %+v", first.event, second.event)
+	}
+	if !hmac.Equal([]byte(first.signature), []byte(Sign("s3cret", first.body))) {
+		t.Fatalf("bad signature %q", first.signature)
+	}
+	var partition PartitionData
+	raw, _ := json.Marshal(second.event.Data)
+	json.Unmarshal(raw, &partition)
+	if len(partition.Demands) != 1 || partition.Demands[0] != "y" {
+		t.Fatalf("partition data %+v", partition)
+	}
+
+	// Staying below the threshold is not a new event, and a stale route is not a partition.
+	stale := snap(70)
+	stale.StaleRoutes = []string{"x"}
+	d.Observe("a", stale)
+	d.Publish(RunCompleted, "", map[string]int{"steps": 1})
+	select {
+	case r := <-got:
+		t.Fatalf("unexpected delivery %+v", r.event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if !d.Delete(hook.ID) || d.Delete(hook.ID) {
+		t.Fatal("expected the hook to be deleted once")
+	}
+}
+
+func TestFailedDeliveriesRetryThenCount(t *testing.T) {
+	srv, got := endpoint(t, http.StatusInternalServerError)
+	d := New()
+	d.retryDelay = time.Millisecond
+	hook, _ := d.Register(Hook{URL: srv.URL, Events: []string{RunCompleted}})
+	d.Publish(RunCompleted, "", nil)
+	for i := 0; i < maxAttempts; i++ {
+		next(t, got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for d.List()[0].Stats.Failed != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats %+v", d.List()[0].Stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if stats := d.List()[0].Stats; stats.Delivered != 0 || stats.LastError == "" || hook.ID == "" {
+		t.Fatalf("stats %+v", stats)
+	}
+
+	for _, bad := range []Hook{
+		{URL: "ftp://example.com", Events: []string{RunCompleted}},
+		{URL: srv.URL},
+		{URL: srv.URL, Events: []string{"coverage.up"}},
+		{URL: srv.URL, Events: []string{CoverageBelowThreshold}, CoverageThreshold: 120},
+	} {
+		if _, err := d.Register(bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQueueFull is returned when the pool cannot accept more work without unbounded queuing.
+var ErrQueueFull = errors.New("worker queue is full")
+
+// ErrClosed is returned when work is submitted after Close.
+var ErrClosed = errors.New("worker pool is closed")
+
+// PanicError reports a task that panicked. The worker recovers and keeps serving the queue.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("worker task panicked: %v", e.Value)
+}
+
+// Pool runs submitted functions on a fixed number of goroutines behind a bounded queue, so
+// expensive recomputes cannot consume every CPU or pile up behind the HTTP handlers.
+type Pool struct {
+	jobs    chan func()
+	size    int
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+	running atomic.Int64
+	done    atomic.Uint64
+	reject  atomic.Uint64
+	panics  atomic.Uint64
+}
+
+// Stats is a point-in-time view of pool utilization.
+type Stats struct {
+	Workers   int    `json:"workers"`
+	Running   int64  `json:"running"`
+	Queued    int    `json:"queued"`
+	QueueSize int    `json:"queueSize"`
+	Completed uint64 `json:"completed"`
+	Rejected  uint64 `json:"rejected"`
+	Panicked  uint64 `json:"panicked"`
+}
+
+// New starts a pool with the given number of workers and queue capacity (minimum one each).
+func New(workers, queue int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queue < 1 {
+		queue = 1
+	}
+	p := &Pool{jobs: make(chan func(), queue), size: workers}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *Pool) run() {
+	defer p.wg.Done()
+	for fn := range p.jobs {
+		p.running.Add(1)
+		if err := p.call(fn); err != nil {
+			log.Printf("%v\n%s", err, err.Stack)
+		}
+		p.running.Add(-1)
+		p.done.Add(1)
+	}
+}
+
+// call runs fn, recovering a panic as a PanicError.
+func (p *Pool) call(fn func()) (err *PanicError) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.panics.Add(1)
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	fn()
+	return nil
+}
+
+// Submit queues fn without waiting for it to run.
+func (p *Pool) Submit(fn func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	select {
+	case p.jobs <- fn:
+		return nil
+	default:
+		p.reject.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Do queues fn and waits for it to finish or for ctx to end. When ctx ends first, fn still
+// runs to completion in the background; only the caller stops waiting. A panic in fn is
+// returned as a *PanicError.
+func (p *Pool) Do(ctx context.Context, fn func()) error {
+	finished := make(chan struct{})
+	var panicked *PanicError
+	if err := p.Submit(func() {
+		defer close(finished)
+		panicked = p.call(fn)
+	}); err != nil {
+		return err
+	}
+	select {
+	case <-finished:
+		if panicked != nil {
+			return panicked
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats reports current utilization.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:   p.size,
+		Running:   p.running.Load(),
+		Queued:    len(p.jobs),
+		QueueSize: cap(p.jobs),
+		Completed: p.done.Load(),
+		Rejected:  p.reject.Load(),
+		Panicked:  p.panics.Load(),
+	}
+}
+
+// Close stops accepting work and waits for queued work to drain.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.jobs)
+	p.mu.Unlock()
+	p.wg.Wait()
+}
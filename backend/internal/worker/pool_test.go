@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoolBoundsQueue(t *testing.T) {
+	p := New(1, 1)
+	defer p.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	if err := p.Submit(func() { close(started); <-release }); err != nil {
+		t.Fatalf("first submit failed: %v", err)
+	}
+	<-started
+	if err := p.Submit(func() {}); err != nil {
+		t.Fatalf("queued submit failed: %v", err)
+	}
+	if err := p.Submit(func() {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected full queue, got %v", err)
+	}
+	if stats := p.Stats(); stats.Running != 1 || stats.Queued != 1 || stats.Rejected != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	close(release)
+}
+
+func TestDoWaitsForResultOrContext(t *testing.T) {
+	p := New(2, 4)
+	defer p.Close()
+
+	result := 0
+	if err := p.Do(context.Background(), func() { result = 42 }); err != nil || result != 42 {
+		t.Fatalf("expected synchronous result, got %d (err %v)", result, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Do(ctx, func() { time.Sleep(200 * time.Millisecond) }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestPanickingTaskDoesNotStopTheWorker(t *testing.T) {
+	p := New(1, 2)
+	defer p.Close()
+
+	if err := p.Submit(func() { panic("submitted") }); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	var panicked *PanicError
+	if err := p.Do(context.Background(), func() { panic("boom") }); !errors.As(err, &panicked) || panicked.Value != "boom" {
+		t.Fatalf("expected the panic as the task's error, got %v", err)
+	}
+	ran := false
+	if err := p.Do(context.Background(), func() { ran = true }); err != nil || !ran {
+		t.Fatalf("expected the worker to keep serving, got ran=%v err=%v", ran, err)
+	}
+	if stats := p.Stats(); stats.Panicked != 2 {
+		t.Fatalf("expected two recovered panics, got %+v", stats)
+	}
+}
@@ -0,0 +1,153 @@
+package orbits
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// EarthJ2 is Earth's second zonal harmonic (EGM96, unnormalized).
+	EarthJ2 = 1.08262668e-3
+	// EarthEquatorialRadius is the WGS-84 equatorial radius in kilometers.
+	EarthEquatorialRadius = 6378.137
+	// EarthRotationRate is Earth's sidereal rotation rate in rad/s.
+	EarthRotationRate = 7.2921150e-5
+	// SunSynchronousRate is the nodal precession, in rad/s, that keeps pace with the mean Sun: one
+	// turn per tropical year.
+	SunSynchronousRate = twoPi / (365.2421897 * 86400)
+
+	// designTolerance is how closely, in kilometers, designed semi-major axes are converged.
+	designTolerance  = 1e-9
+	designIterations = 100
+)
+
+// SecularRates are the J2 secular drift rates of an orbit's node, periapsis and mean anomaly, in rad/s.
+type SecularRates struct {
+	RAAN                float64
+	ArgumentOfPeriapsis float64
+	MeanAnomaly         float64
+}
+
+// J2Rates returns the first-order J2 secular rates for a closed orbit.
+func J2Rates(semiMajorAxis, eccentricity, inclination float64) SecularRates {
+	return j2Rates(semiMajorAxis, eccentricity, inclination, EarthMu)
+}
+
+// j2Rates is J2Rates for a gravitational parameter other than EarthMu.
+func j2Rates(semiMajorAxis, eccentricity, inclination, mu float64) SecularRates {
+	n := math.Sqrt(mu / math.Pow(semiMajorAxis, 3))
+	p := semiMajorAxis * (1 - eccentricity*eccentricity)
+	factor := EarthJ2 * math.Pow(EarthEquatorialRadius/p, 2)
+	cosI := math.Cos(inclination)
+	return SecularRates{
+		RAAN:                -1.5 * n * factor * cosI,
+		ArgumentOfPeriapsis: 0.75 * n * factor * (5*cosI*cosI - 1),
+		MeanAnomaly:         n * (1 + 0.75*factor*math.Sqrt(1-eccentricity*eccentricity)*(3*cosI*cosI-1)),
+	}
+}
+
+// SunSynchronousInclination returns the inclination whose J2 nodal precession matches the mean
+// Sun at the given semi-major axis (km) and eccentricity. Orbits above about 12,350 km cannot
+// precess fast enough at any inclination.
+func SunSynchronousInclination(semiMajorAxis, eccentricity float64) (float64, error) {
+	if err := checkDesignOrbit(semiMajorAxis, eccentricity); err != nil {
+		return 0, err
+	}
+	// The nodal rate is proportional to cos i, so solve with the rate at i = 0.
+	polar := J2Rates(semiMajorAxis, eccentricity, 0).RAAN
+	cosI := SunSynchronousRate / polar
+	if cosI < -1 {
+		return 0, fmt.Errorf("no sun-synchronous inclination exists at %.0f km", semiMajorAxis)
+	}
+	return math.Acos(cosI), nil
+}
+
+// RepeatGroundTrack designs an orbit at the given inclination whose ground track repeats after
+// exactly revolutions orbits in days nodal days, accounting for the J2 drift of the node and
+// periapsis. The two counts must be coprime, or the track would repeat sooner. The returned
+// elements carry the shape only: node, periapsis and phase are zero and the epoch is left for the
+// scenario to fill in.
+func RepeatGroundTrack(revolutions, days int, eccentricity, inclination float64) (KeplerianElements, error) {
+	if err := checkRepeat(revolutions, days); err != nil {
+		return KeplerianElements{}, err
+	}
+	a, err := solveRepeatAxis(revolutions, days, eccentricity, func(float64) (float64, error) { return inclination, nil })
+	if err != nil {
+		return KeplerianElements{}, err
+	}
+	return KeplerianElements{SemiMajorAxis: a, Eccentricity: eccentricity, Inclination: inclination}, nil
+}
+
+// SunSynchronousRepeat designs a sun-synchronous orbit whose ground track repeats after revolutions
+// orbits in days days, solving the semi-major axis and inclination together, as for Earth
+// observation missions such as Landsat (233 revolutions in 16 days).
+func SunSynchronousRepeat(revolutions, days int, eccentricity float64) (KeplerianElements, error) {
+	if err := checkRepeat(revolutions, days); err != nil {
+		return KeplerianElements{}, err
+	}
+	a, err := solveRepeatAxis(revolutions, days, eccentricity, func(a float64) (float64, error) {
+		return SunSynchronousInclination(a, eccentricity)
+	})
+	if err != nil {
+		return KeplerianElements{}, err
+	}
+	inclination, err := SunSynchronousInclination(a, eccentricity)
+	if err != nil {
+		return KeplerianElements{}, err
+	}
+	return KeplerianElements{SemiMajorAxis: a, Eccentricity: eccentricity, Inclination: inclination}, nil
+}
+
+// solveRepeatAxis iterates the semi-major axis until revolutions nodal periods take as long as days
+// revolutions of Earth relative to the drifting node:
+//
+//	revolutions · 2π / (ω̇ + Ṁ) = days · 2π / (ωE − Ω̇)
+func solveRepeatAxis(revolutions, days int, eccentricity float64, inclinationAt func(a float64) (float64, error)) (float64, error) {
+	ratio := float64(revolutions) / float64(days)
+	// Start from the two-body orbit with ratio revolutions per sidereal day.
+	a := math.Cbrt(EarthMu / math.Pow(ratio*EarthRotationRate, 2))
+	for i := 0; i < designIterations; i++ {
+		if err := checkDesignOrbit(a, eccentricity); err != nil {
+			return 0, err
+		}
+		inclination, err := inclinationAt(a)
+		if err != nil {
+			return 0, err
+		}
+		rates := J2Rates(a, eccentricity, inclination)
+		n := math.Sqrt(EarthMu / math.Pow(a, 3))
+		// ω̇ + Ṁ scales with n at a fixed ratio for the current geometry.
+		want := ratio * (EarthRotationRate - rates.RAAN)
+		next := math.Cbrt(EarthMu / math.Pow(n*want/(rates.ArgumentOfPeriapsis+rates.MeanAnomaly), 2))
+		if math.Abs(next-a) <= designTolerance {
+			return next, checkDesignOrbit(next, eccentricity)
+		}
+		a = next
+	}
+	return 0, errors.New("repeat ground track design did not converge")
+}
+
+func checkRepeat(revolutions, days int) error {
+	if revolutions <= 0 || days <= 0 {
+		return errors.New("revolutions and days must be positive")
+	}
+	a, b := revolutions, days
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a != 1 {
+		return fmt.Errorf("%d revolutions in %d days repeat after %d revolutions in %d days; use the reduced counts", revolutions, days, revolutions/a, days/a)
+	}
+	return nil
+}
+
+func checkDesignOrbit(semiMajorAxis, eccentricity float64) error {
+	if eccentricity < 0 || eccentricity >= 1 {
+		return errors.New("designed orbits must have eccentricity in [0, 1)")
+	}
+	if perigee := semiMajorAxis * (1 - eccentricity); perigee <= EarthEquatorialRadius {
+		return fmt.Errorf("designed orbit's perigee at %.0f km radius is inside the Earth", perigee)
+	}
+	return nil
+}
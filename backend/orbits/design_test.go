@@ -0,0 +1,74 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+)
+
+const degree = math.Pi / 180
+
+func TestSunSynchronousInclination(t *testing.T) {
+	inclination, err := SunSynchronousInclination(EarthEquatorialRadius+700, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The textbook value for a 700 km circular sun-synchronous orbit is 98.19°.
+	if math.Abs(inclination/degree-98.19) > 0.01 {
+		t.Fatalf("inclination = %.3f°, want 98.19°", inclination/degree)
+	}
+	if rate := J2Rates(EarthEquatorialRadius+700, 0, inclination).RAAN; math.Abs(rate-SunSynchronousRate) > 1e-18 {
+		t.Fatalf("nodal rate %v, want %v", rate, SunSynchronousRate)
+	}
+
+	if _, err := SunSynchronousInclination(15000, 0); err == nil {
+		t.Fatal("expected no sun-synchronous inclination at 15,000 km")
+	}
+}
+
+func TestSunSynchronousRepeatMatchesLandsat(t *testing.T) {
+	elements, err := SunSynchronousRepeat(233, 16, 0.001)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Landsat's WRS-2 reference orbit has a 7077.7 km semi-major axis and 98.2° inclination.
+	if math.Abs(elements.SemiMajorAxis-7077.7) > 0.5 {
+		t.Fatalf("semi-major axis %.2f km, want about 7077.7 km", elements.SemiMajorAxis)
+	}
+	if math.Abs(elements.Inclination/degree-98.2) > 0.05 {
+		t.Fatalf("inclination %.3f°, want about 98.2°", elements.Inclination/degree)
+	}
+	if err := elements.Validate(); err != nil {
+		t.Fatalf("designed elements invalid: %v", err)
+	}
+}
+
+func TestRepeatGroundTrackCloses(t *testing.T) {
+	elements, err := RepeatGroundTrack(29, 2, 0, 53*degree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rates := J2Rates(elements.SemiMajorAxis, 0, elements.Inclination)
+	nodalPeriod := twoPi / (rates.ArgumentOfPeriapsis + rates.MeanAnomaly)
+	nodalDay := twoPi / (EarthRotationRate - rates.RAAN)
+	if drift := 29*nodalPeriod - 2*nodalDay; math.Abs(drift) > 1e-6 {
+		t.Fatalf("track misses closure by %v s", drift)
+	}
+	// J2 pulls a 53° orbit's node backwards, so the orbit sits below its two-body altitude.
+	twoBody := math.Cbrt(EarthMu / math.Pow(14.5*EarthRotationRate, 2))
+	if elements.SemiMajorAxis >= twoBody {
+		t.Fatalf("semi-major axis %v, expected below the two-body %v", elements.SemiMajorAxis, twoBody)
+	}
+}
+
+func TestRepeatGroundTrackRejectsBadInputs(t *testing.T) {
+	if _, err := RepeatGroundTrack(30, 2, 0, 0.9); err == nil {
+		t.Fatal("expected counts with a common factor to be rejected")
+	}
+	if _, err := RepeatGroundTrack(0, 1, 0, 0.9); err == nil {
+		t.Fatal("expected zero revolutions to be rejected")
+	}
+	// Twenty revolutions a day would need an orbit inside the Earth.
+	if _, err := RepeatGroundTrack(20, 1, 0, 0.9); err == nil {
+		t.Fatal("expected a sub-surface orbit to be rejected")
+	}
+}
@@ -0,0 +1,182 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// ShadowState is whether a satellite sees all, part, or none of the Sun's disk past the Earth.
+type ShadowState string
+
+const (
+	Sunlit ShadowState = "sunlit"
+	// Penumbra covers partial eclipses, including the annular case far from the Earth.
+	Penumbra ShadowState = "penumbra"
+	Umbra    ShadowState = "umbra"
+)
+
+// Illumination returns the fraction of the Sun's disk visible from position sat, from 0 in the
+// umbra to 1 in full sunlight, with the Sun at sun in the same frame. It uses the conical shadow
+// model of Montenbruck and Gill, with the Earth a sphere of WGS84 equatorial radius and without
+// atmospheric refraction or limb darkening.
+func Illumination(sat, sun visibility.Vector3) float64 {
+	toSun := visibility.Vector3{X: sun.X - sat.X, Y: sun.Y - sat.Y, Z: sun.Z - sat.Z}
+	r, d := norm(sat), norm(toSun)
+	if r <= visibility.WGS84SemiMajorAxis {
+		return 0
+	}
+	// a and b are the apparent radii of the Sun and the Earth, c the angle between their centers.
+	a := math.Asin(math.Min(1, SunRadius/d))
+	b := math.Asin(visibility.WGS84SemiMajorAxis / r)
+	c := math.Acos(math.Max(-1, math.Min(1, -dot(sat, toSun)/(r*d))))
+	switch {
+	case c >= a+b:
+		return 1
+	case c <= b-a:
+		return 0
+	case c <= a-b:
+		return 1 - b*b/(a*a)
+	}
+	x := (c*c + a*a - b*b) / (2 * c)
+	y := math.Sqrt(math.Max(0, a*a-x*x))
+	covered := a*a*math.Acos(math.Max(-1, math.Min(1, x/a))) + b*b*math.Acos(math.Max(-1, math.Min(1, (c-x)/b))) - c*y
+	return math.Max(0, math.Min(1, 1-covered/(math.Pi*a*a)))
+}
+
+// Shadow classifies the illumination of position sat with the Sun at sun.
+func Shadow(sat, sun visibility.Vector3) ShadowState {
+	switch f := Illumination(sat, sun); {
+	case f >= 1:
+		return Sunlit
+	case f <= 0:
+		return Umbra
+	default:
+		return Penumbra
+	}
+}
+
+// ShadowAt classifies the illumination of an inertial position at t, placing the Sun with
+// SunPosition.
+func ShadowAt(position visibility.Vector3, t time.Time) ShadowState {
+	return Shadow(position, SunPosition(t))
+}
+
+// Eclipse is one passage through the Earth's shadow. Start and End bound the whole eclipse,
+// penumbra included; UmbraStart and UmbraEnd bound the total part, when there is one. Eclipses
+// under way at either end of the searched window are cut at it.
+type Eclipse struct {
+	Start      time.Time  `json:"start"`
+	End        time.Time  `json:"end"`
+	UmbraStart *time.Time `json:"umbraStart,omitempty"`
+	UmbraEnd   *time.Time `json:"umbraEnd,omitempty"`
+}
+
+// eclipseTolerance is how finely Eclipses locates shadow boundaries.
+const eclipseTolerance = time.Millisecond
+
+// Eclipses finds the eclipses of the satellite p propagates between from and to. It samples the
+// orbit every step and bisects each change of shadow to within a millisecond, so shadows shorter
+// than step may be missed; a minute suits low orbits, whose penumbra lasts seconds but whose
+// eclipses last tens of minutes.
+func Eclipses(p Propagator, from, to time.Time, step time.Duration) ([]Eclipse, error) {
+	if step <= 0 {
+		return nil, errors.New("eclipse search step must be positive")
+	}
+	if to.Before(from) {
+		return nil, errors.New("eclipse search window ends before it starts")
+	}
+	illumination := func(t time.Time) (float64, error) {
+		state, err := p.StateAt(t)
+		if err != nil {
+			return 0, err
+		}
+		return Illumination(state.Position, SunPosition(t)), nil
+	}
+	// boundary bisects (t0, t1] for the first time the predicate inside differs from its value at
+	// t0.
+	boundary := func(t0, t1 time.Time, inside func(float64) bool) (time.Time, error) {
+		f, err := illumination(t0)
+		if err != nil {
+			return time.Time{}, err
+		}
+		before := inside(f)
+		for t1.Sub(t0) > eclipseTolerance {
+			mid := t0.Add(t1.Sub(t0) / 2)
+			if f, err = illumination(mid); err != nil {
+				return time.Time{}, err
+			}
+			if inside(f) == before {
+				t0 = mid
+			} else {
+				t1 = mid
+			}
+		}
+		return t1, nil
+	}
+	shadowed := func(f float64) bool { return f < 1 }
+	total := func(f float64) bool { return f <= 0 }
+
+	var eclipses []Eclipse
+	var current *Eclipse
+	prev := from
+	f, err := illumination(from)
+	if err != nil {
+		return nil, err
+	}
+	if shadowed(f) {
+		current = &Eclipse{Start: from}
+		if total(f) {
+			start := from
+			current.UmbraStart = &start
+		}
+	}
+	for prev.Before(to) {
+		next := prev.Add(step)
+		if next.After(to) {
+			next = to
+		}
+		g, err := illumination(next)
+		if err != nil {
+			return nil, err
+		}
+		var shadowAt, umbraAt time.Time
+		if shadowed(f) != shadowed(g) {
+			if shadowAt, err = boundary(prev, next, shadowed); err != nil {
+				return nil, err
+			}
+		}
+		if total(f) != total(g) {
+			if umbraAt, err = boundary(prev, next, total); err != nil {
+				return nil, err
+			}
+		}
+		// Apply the boundaries in the order a satellite crosses them: penumbra, umbra, and back.
+		if !shadowed(f) && shadowed(g) {
+			current = &Eclipse{Start: shadowAt}
+		}
+		if !total(f) && total(g) {
+			current.UmbraStart = &umbraAt
+		}
+		if total(f) && !total(g) {
+			current.UmbraEnd = &umbraAt
+		}
+		if shadowed(f) && !shadowed(g) {
+			current.End = shadowAt
+			eclipses = append(eclipses, *current)
+			current = nil
+		}
+		prev, f = next, g
+	}
+	if current != nil {
+		current.End = to
+		if current.UmbraStart != nil && current.UmbraEnd == nil {
+			end := to
+			current.UmbraEnd = &end
+		}
+		eclipses = append(eclipses, *current)
+	}
+	return eclipses, nil
+}
@@ -0,0 +1,78 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestIlluminationAcrossTheShadow(t *testing.T) {
+	sun := visibility.Vector3{X: AstronomicalUnit}
+	r := visibility.EarthRadius + 550
+	if f := Illumination(visibility.Vector3{X: r}, sun); f != 1 {
+		t.Fatalf("expected full sunlight on the day side, got %v", f)
+	}
+	if f := Illumination(visibility.Vector3{X: -r}, sun); f != 0 {
+		t.Fatalf("expected the umbra behind the Earth, got %v", f)
+	}
+	// Sweep across the shadow edge: illumination rises monotonically through a thin penumbra.
+	previous, penumbra := 0.0, 0
+	for y := 6350.0; y <= 6420; y += 0.5 {
+		f := Illumination(visibility.Vector3{X: -math.Sqrt(r*r - y*y), Y: y}, sun)
+		if f < previous-1e-12 {
+			t.Fatalf("illumination fell from %v to %v at y=%v", previous, f, y)
+		}
+		if f > 0 && f < 1 {
+			penumbra++
+		}
+		previous = f
+	}
+	if previous != 1 || penumbra == 0 {
+		t.Fatalf("expected to cross a penumbra into sunlight, ended at %v after %d penumbral samples", previous, penumbra)
+	}
+	if Shadow(visibility.Vector3{X: -r}, sun) != Umbra || Shadow(visibility.Vector3{Y: r}, sun) != Sunlit {
+		t.Fatal("unexpected shadow classification")
+	}
+}
+
+func TestEclipsesOfAnEquatorialOrbitAtEquinox(t *testing.T) {
+	// Near the March 2024 equinox the Sun lies in the equatorial plane, so a circular equatorial
+	// orbit at 550 km spends 2·asin(R/r) of every revolution in shadow.
+	from := time.Date(2024, time.March, 20, 3, 0, 0, 0, time.UTC)
+	orbit := KeplerianElements{SemiMajorAxis: visibility.WGS84SemiMajorAxis + 550, Epoch: from}
+	period := time.Duration(2 * math.Pi / orbit.MeanMotion() * float64(time.Second))
+	eclipses, err := Eclipses(ElementsPropagator(orbit), from, from.Add(3*period), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eclipses) < 3 || len(eclipses) > 4 {
+		t.Fatalf("expected an eclipse per revolution, got %+v", eclipses)
+	}
+	want := 2 * math.Asin(visibility.WGS84SemiMajorAxis/orbit.SemiMajorAxis) / (2 * math.Pi) * period.Seconds()
+	for _, e := range eclipses[1 : len(eclipses)-1] {
+		if e.UmbraStart == nil || e.UmbraEnd == nil {
+			t.Fatalf("expected a total eclipse, got %+v", e)
+		}
+		if got := e.End.Sub(e.Start).Seconds(); math.Abs(got-want) > 30 {
+			t.Fatalf("eclipse lasted %vs, expected about %vs", got, want)
+		}
+		entry, exit := e.UmbraStart.Sub(e.Start), e.End.Sub(*e.UmbraEnd)
+		if entry <= 0 || entry > 20*time.Second || exit <= 0 || exit > 20*time.Second {
+			t.Fatalf("expected a few seconds of penumbra either side, got %v and %v", entry, exit)
+		}
+		for _, at := range []time.Time{e.Start.Add(-time.Second), e.UmbraStart.Add(time.Second), e.End.Add(time.Second)} {
+			state := orbit.StateAt(at)
+			got := ShadowAt(state.Position, at)
+			want := map[bool]ShadowState{true: Umbra, false: Sunlit}[at.After(e.Start) && at.Before(e.End)]
+			if got != want {
+				t.Fatalf("at %v expected %s, got %s", at, want, got)
+			}
+		}
+	}
+
+	if _, err := Eclipses(ElementsPropagator(orbit), from, from.Add(-time.Hour), time.Minute); err == nil {
+		t.Fatal("expected a reversed window to fail")
+	}
+}
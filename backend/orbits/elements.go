@@ -0,0 +1,149 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+const (
+	// parabolicTolerance is how close to 1 an eccentricity computed from a state must be to be
+	// treated as exactly parabolic.
+	parabolicTolerance = 1e-10
+	// circularTolerance is the eccentricity below which a state's orbit is treated as circular.
+	circularTolerance = 1e-11
+)
+
+// EquinoctialElements are the prograde equinoctial elements, which stay defined for circular and
+// equatorial orbits where the argument of periapsis and RAAN are not:
+//
+//	h = e sin(ω + Ω)    k = e cos(ω + Ω)
+//	p = tan(i/2) sin Ω  q = tan(i/2) cos Ω
+//	λ = M + ω + Ω
+//
+// They are singular only for retrograde equatorial orbits (i = 180°) and, like the classical
+// elements here, describe closed orbits only.
+type EquinoctialElements struct {
+	SemiMajorAxis float64   `json:"semiMajorAxisKm"`
+	H             float64   `json:"h"`
+	K             float64   `json:"k"`
+	P             float64   `json:"p"`
+	Q             float64   `json:"q"`
+	MeanLongitude float64   `json:"meanLongitude"` // radians
+	Epoch         time.Time `json:"epoch"`
+	Mu            float64   `json:"mu,omitempty"`
+}
+
+// Equinoctial converts classical elements to equinoctial elements.
+func (k KeplerianElements) Equinoctial() EquinoctialElements {
+	longitude := k.ArgumentOfPeriapsis + k.RAAN
+	tanHalf := math.Tan(k.Inclination / 2)
+	return EquinoctialElements{
+		SemiMajorAxis: k.SemiMajorAxis,
+		H:             k.Eccentricity * math.Sin(longitude),
+		K:             k.Eccentricity * math.Cos(longitude),
+		P:             tanHalf * math.Sin(k.RAAN),
+		Q:             tanHalf * math.Cos(k.RAAN),
+		MeanLongitude: normalizeAngle(k.MeanAnomaly + longitude),
+		Epoch:         k.Epoch,
+		Mu:            k.Mu,
+	}
+}
+
+// Keplerian converts equinoctial elements back to classical elements. Angles that are undefined for
+// circular or equatorial orbits are set to zero and the phase carried by the mean anomaly.
+func (q EquinoctialElements) Keplerian() KeplerianElements {
+	e := math.Hypot(q.H, q.K)
+	tanHalf := math.Hypot(q.P, q.Q)
+	k := KeplerianElements{
+		SemiMajorAxis: q.SemiMajorAxis,
+		Eccentricity:  e,
+		Inclination:   2 * math.Atan(tanHalf),
+		Epoch:         q.Epoch,
+		Mu:            q.Mu,
+	}
+	if tanHalf > 0 {
+		k.RAAN = normalizeAngle(math.Atan2(q.P, q.Q))
+	}
+	longitude := k.RAAN
+	if e > 0 {
+		longitude = math.Atan2(q.H, q.K)
+		k.ArgumentOfPeriapsis = normalizeAngle(longitude - k.RAAN)
+	}
+	k.MeanAnomaly = normalizeAngle(q.MeanLongitude - longitude)
+	return k
+}
+
+// ElementsFromState returns the osculating elements of a two-body state at epoch. A zero mu selects
+// EarthMu. For circular orbits the argument of periapsis is zero and the mean anomaly is measured
+// from the ascending node; for equatorial ones RAAN is zero and the argument of periapsis is measured
+// from the reference direction.
+func ElementsFromState(state StateVector, mu float64, epoch time.Time) (KeplerianElements, error) {
+	if mu == 0 {
+		mu = EarthMu
+	}
+	r, v := state.Position, state.Velocity
+	radius := norm(r)
+	h := cross(r, v)
+	hNorm := norm(h)
+	if radius == 0 || hNorm == 0 {
+		return KeplerianElements{}, errors.New("state has no orbital plane")
+	}
+	speedSq := dot(v, v)
+	rdotv := dot(r, v)
+	eVec := combine((speedSq-mu/radius)/mu, r, -rdotv/mu, v)
+	e := norm(eVec)
+
+	k := KeplerianElements{Eccentricity: e, Epoch: epoch}
+	if mu != EarthMu {
+		k.Mu = mu
+	}
+	k.Inclination = math.Acos(math.Max(-1, math.Min(1, h.Z/hNorm)))
+	// Angles in the plane are measured from the ascending node, or from the reference direction when
+	// the orbit is equatorial and the node undefined.
+	node := visibility.Vector3{X: -h.Y, Y: h.X}
+	if norm(node) <= 1e-12*hNorm {
+		node = visibility.Vector3{X: 1}
+	} else {
+		k.RAAN = normalizeAngle(math.Atan2(node.Y, node.X))
+	}
+	var nu float64
+	if e > circularTolerance {
+		k.ArgumentOfPeriapsis = angleAbout(node, eVec, h)
+		nu = angleAbout(eVec, r, h)
+	} else {
+		k.Eccentricity, e = 0, 0
+		nu = angleAbout(node, r, h)
+	}
+
+	p := hNorm * hNorm / mu
+	sinNu, cosNu := math.Sincos(nu)
+	switch {
+	case math.Abs(e-1) <= parabolicTolerance:
+		k.Eccentricity = 1
+		k.PeriapsisRadius = p / 2
+		D := math.Tan(nu / 2)
+		k.MeanAnomaly = (D + D*D*D/3) / 2
+	case e < 1:
+		k.SemiMajorAxis = p / (1 - e*e)
+		E := math.Atan2(math.Sqrt(1-e*e)*sinNu, e+cosNu)
+		k.MeanAnomaly = MeanAnomalyFromEccentric(E, e)
+	default:
+		k.SemiMajorAxis = p / (1 - e*e)
+		H := 2 * math.Atanh(math.Sqrt((e-1)/(e+1))*math.Tan(nu/2))
+		k.MeanAnomaly = e*math.Sinh(H) - H
+	}
+	return k, nil
+}
+
+// angleAbout returns the angle from a to b, in [0, 2π), measured positively about h.
+func angleAbout(a, b, h visibility.Vector3) float64 {
+	c := cross(a, b)
+	angle := math.Atan2(norm(c), dot(a, b))
+	if dot(c, h) < 0 {
+		angle = twoPi - angle
+	}
+	return normalizeAngle(angle)
+}
@@ -0,0 +1,72 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var conversionCases = map[string]KeplerianElements{
+	"leo":                 {SemiMajorAxis: 6921, Eccentricity: 0.001, Inclination: 0.93, RAAN: 1.2, ArgumentOfPeriapsis: 0.4, MeanAnomaly: 2.1},
+	"molniya":             {SemiMajorAxis: 26600, Eccentricity: 0.74, Inclination: 1.1, RAAN: 5.9, ArgumentOfPeriapsis: 4.71, MeanAnomaly: 0.3},
+	"circular":            {SemiMajorAxis: 7000, Inclination: 1.7, RAAN: 3, MeanAnomaly: 1},
+	"equatorial":          {SemiMajorAxis: 42164, Eccentricity: 0.01, ArgumentOfPeriapsis: 2, MeanAnomaly: 4},
+	"circular equatorial": {SemiMajorAxis: 42164, MeanAnomaly: 5.5},
+}
+
+// sameOrbit compares elements by the states they produce, since angles such as the argument of
+// periapsis of a circular orbit have no unique value.
+func sameOrbit(t *testing.T, name string, got, want KeplerianElements) {
+	t.Helper()
+	for _, dt := range []time.Duration{0, 37 * time.Minute} {
+		at := want.Epoch.Add(dt)
+		g, w := got.StateAt(at), want.StateAt(at)
+		if !closeVectors(g.Position, w.Position, 1e-6) || !closeVectors(g.Velocity, w.Velocity, 1e-9) {
+			t.Fatalf("%s: states differ at %v: %+v vs %+v", name, dt, g, w)
+		}
+	}
+}
+
+func TestEquinoctialRoundTrip(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, k := range conversionCases {
+		k.Epoch = epoch
+		back := k.Equinoctial().Keplerian()
+		sameOrbit(t, name, back, k)
+		if name == "molniya" && (math.Abs(back.ArgumentOfPeriapsis-k.ArgumentOfPeriapsis) > 1e-12 || math.Abs(back.RAAN-k.RAAN) > 1e-12) {
+			t.Fatalf("well-defined angles changed: %+v", back)
+		}
+	}
+
+	q := KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.1, Inclination: math.Pi / 2, RAAN: 0.5, ArgumentOfPeriapsis: 0.25}.Equinoctial()
+	if math.Abs(math.Hypot(q.H, q.K)-0.1) > 1e-15 || math.Abs(math.Hypot(q.P, q.Q)-1) > 1e-15 {
+		t.Fatalf("unexpected equinoctial elements %+v", q)
+	}
+}
+
+func TestElementsFromStateRoundTrip(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := map[string]KeplerianElements{
+		"hyperbolic": {SemiMajorAxis: -20000, Eccentricity: 1.4, Inclination: 0.5, RAAN: 2, ArgumentOfPeriapsis: 1, MeanAnomaly: -2},
+		"parabolic":  {Eccentricity: 1, PeriapsisRadius: 7000, Inclination: 0.2, RAAN: 1, MeanAnomaly: 0.7},
+		"retrograde": {SemiMajorAxis: 8000, Eccentricity: 0.2, Inclination: math.Pi, ArgumentOfPeriapsis: 1, MeanAnomaly: 3},
+	}
+	for name, k := range conversionCases {
+		cases[name] = k
+	}
+	for name, k := range cases {
+		k.Epoch = epoch
+		elements, err := ElementsFromState(k.StateAt(epoch), 0, epoch)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		sameOrbit(t, name, elements, k)
+		if math.Abs(elements.Eccentricity-k.Eccentricity) > 1e-9 || math.Abs(elements.Inclination-k.Inclination) > 1e-9 {
+			t.Fatalf("%s: shape changed: %+v", name, elements)
+		}
+	}
+
+	if _, err := ElementsFromState(StateVector{}, 0, epoch); err == nil {
+		t.Fatal("expected a degenerate state to be rejected")
+	}
+}
@@ -0,0 +1,181 @@
+package orbits
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// Interpolation is the method an Ephemeris interpolates between its points with.
+type Interpolation string
+
+const (
+	// InterpolationLagrange fits positions and velocities separately with Lagrange polynomials.
+	InterpolationLagrange Interpolation = "lagrange"
+	// InterpolationHermite fits positions with Hermite polynomials that also match the velocities,
+	// and differentiates them for the velocity, keeping the two consistent.
+	InterpolationHermite Interpolation = "hermite"
+)
+
+// Default interpolation degrees, those flight dynamics tools commonly deliver ephemerides for.
+const (
+	DefaultLagrangeDegree = 7
+	DefaultHermiteDegree  = 5
+)
+
+// ErrOutsideEphemeris is returned for times an Ephemeris does not span. Ephemerides are not
+// extrapolated.
+var ErrOutsideEphemeris = errors.New("time outside the ephemeris")
+
+// EphemerisPoint is one time-tagged state of an Ephemeris, in km and km/s.
+type EphemerisPoint struct {
+	Time     time.Time          `json:"time"`
+	Position visibility.Vector3 `json:"position"`
+	Velocity visibility.Vector3 `json:"velocity"`
+}
+
+// Ephemeris is a table of time-tagged inertial states, such as those flight dynamics teams
+// deliver, interpolated in between so it can move a satellite in place of analytic propagation.
+//
+// Degree is the degree of the interpolating polynomial, as in CCSDS OEM files: Lagrange
+// interpolation uses Degree+1 points around the requested time, Hermite interpolation (Degree+1)/2
+// points and so an odd Degree. Zero selects DefaultLagrangeDegree or DefaultHermiteDegree. Tables
+// shorter than that interpolate over all their points.
+type Ephemeris struct {
+	// Interpolation defaults to InterpolationLagrange.
+	Interpolation Interpolation `json:"interpolation,omitempty"`
+	Degree        int           `json:"degree,omitempty"`
+	// Points are ordered by strictly increasing time.
+	Points []EphemerisPoint `json:"points"`
+}
+
+var _ Propagator = (*Ephemeris)(nil)
+
+// Validate reports whether the ephemeris can be interpolated: at least two points in time order and
+// a known method with a degree it supports.
+func (e *Ephemeris) Validate() error {
+	switch e.Interpolation {
+	case "", InterpolationLagrange:
+		if e.Degree < 0 {
+			return errors.New("ephemeris interpolation degree cannot be negative")
+		}
+	case InterpolationHermite:
+		if e.Degree < 0 || (e.Degree > 0 && e.Degree%2 == 0) {
+			return errors.New("hermite interpolation degree must be odd")
+		}
+	default:
+		return fmt.Errorf("unknown ephemeris interpolation %q", e.Interpolation)
+	}
+	if len(e.Points) < 2 {
+		return errors.New("ephemeris needs at least two points")
+	}
+	for i := 1; i < len(e.Points); i++ {
+		if !e.Points[i].Time.After(e.Points[i-1].Time) {
+			return fmt.Errorf("ephemeris point %d is not after the one before it", i)
+		}
+	}
+	return nil
+}
+
+// Start returns the time of the first point.
+func (e *Ephemeris) Start() time.Time {
+	return e.Points[0].Time
+}
+
+// End returns the time of the last point.
+func (e *Ephemeris) End() time.Time {
+	return e.Points[len(e.Points)-1].Time
+}
+
+// StateAt interpolates the state at t, failing with ErrOutsideEphemeris outside the points' span.
+// The ephemeris must be valid.
+func (e *Ephemeris) StateAt(t time.Time) (StateVector, error) {
+	if len(e.Points) == 0 || t.Before(e.Start()) || t.After(e.End()) {
+		return StateVector{}, fmt.Errorf("%w: %s", ErrOutsideEphemeris, t.UTC().Format(time.RFC3339Nano))
+	}
+	hermite := e.Interpolation == InterpolationHermite
+	degree := e.Degree
+	switch {
+	case degree > 0:
+	case hermite:
+		degree = DefaultHermiteDegree
+	default:
+		degree = DefaultLagrangeDegree
+	}
+	window := degree + 1
+	if hermite {
+		window = (degree + 1) / 2
+	}
+	if window > len(e.Points) {
+		window = len(e.Points)
+	}
+	// Center the window on t, sliding it inward at either end of the table.
+	next := sort.Search(len(e.Points), func(i int) bool { return e.Points[i].Time.After(t) })
+	start := next - window/2
+	if start < 0 {
+		start = 0
+	}
+	if start > len(e.Points)-window {
+		start = len(e.Points) - window
+	}
+	points := e.Points[start : start+window]
+	// Measure times from t, so the polynomials are evaluated at zero and stay well conditioned.
+	offsets := make([]float64, len(points))
+	for i, p := range points {
+		offsets[i] = p.Time.Sub(t).Seconds()
+	}
+	if hermite {
+		return hermiteAt(points, offsets), nil
+	}
+	return lagrangeAt(points, offsets), nil
+}
+
+// lagrangeAt evaluates the Lagrange polynomials through the positions and the velocities of points,
+// at offsets in seconds from the requested time, at that time.
+func lagrangeAt(points []EphemerisPoint, offsets []float64) StateVector {
+	var state StateVector
+	for i, p := range points {
+		weight := 1.0
+		for j, x := range offsets {
+			if j != i {
+				weight *= -x / (offsets[i] - x)
+			}
+		}
+		state.Position = combine(1, state.Position, weight, p.Position)
+		state.Velocity = combine(1, state.Velocity, weight, p.Velocity)
+	}
+	return state
+}
+
+// hermiteAt evaluates the Hermite polynomial matching the positions and velocities of points, and
+// its derivative, at the requested time. The Newton divided differences repeat every node, using the
+// velocity as the first difference of each pair.
+func hermiteAt(points []EphemerisPoint, offsets []float64) StateVector {
+	n := 2 * len(points)
+	nodes := make([]float64, n)
+	coefficients := make([]visibility.Vector3, n)
+	for i, p := range points {
+		nodes[2*i], nodes[2*i+1] = offsets[i], offsets[i]
+		coefficients[2*i], coefficients[2*i+1] = p.Position, p.Position
+	}
+	for order := 1; order < n; order++ {
+		for k := n - 1; k >= order; k-- {
+			if order == 1 && k%2 == 1 {
+				coefficients[k] = points[k/2].Velocity
+				continue
+			}
+			span := nodes[k] - nodes[k-order]
+			coefficients[k] = combine(1/span, coefficients[k], -1/span, coefficients[k-1])
+		}
+	}
+	// Horner's scheme at zero, carrying the derivative along.
+	position, velocity := coefficients[n-1], visibility.Vector3{}
+	for k := n - 2; k >= 0; k-- {
+		velocity = combine(-nodes[k], velocity, 1, position)
+		position = combine(-nodes[k], position, 1, coefficients[k])
+	}
+	return StateVector{Position: position, Velocity: velocity}
+}
@@ -0,0 +1,82 @@
+package orbits
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// sampledEphemeris tabulates orbit every step from its epoch, count points long.
+func sampledEphemeris(orbit KeplerianElements, step time.Duration, count int) *Ephemeris {
+	e := &Ephemeris{}
+	for i := 0; i < count; i++ {
+		t := orbit.Epoch.Add(time.Duration(i) * step)
+		state := orbit.StateAt(t)
+		e.Points = append(e.Points, EphemerisPoint{Time: t, Position: state.Position, Velocity: state.Velocity})
+	}
+	return e
+}
+
+func TestEphemerisInterpolatesBetweenPoints(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	orbit := KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.05, Inclination: 0.9, RAAN: 0.3, ArgumentOfPeriapsis: 0.2, Epoch: epoch}
+	cases := []struct {
+		interpolation       Interpolation
+		degree              int
+		positionKm, speedKm float64
+	}{
+		{InterpolationLagrange, 0, 1e-5, 1e-7},
+		{InterpolationHermite, 0, 1e-5, 1e-7},
+		{InterpolationLagrange, 1, 5, 0.01},
+		{InterpolationHermite, 3, 1e-2, 1e-4},
+	}
+	for _, c := range cases {
+		e := sampledEphemeris(orbit, time.Minute, 60)
+		e.Interpolation, e.Degree = c.interpolation, c.degree
+		if err := e.Validate(); err != nil {
+			t.Fatalf("%s degree %d: %v", c.interpolation, c.degree, err)
+		}
+		for _, at := range []time.Duration{0, 30 * time.Second, 17*time.Minute + 13*time.Second, 58*time.Minute + 50*time.Second, 59 * time.Minute} {
+			got, err := e.StateAt(epoch.Add(at))
+			if err != nil {
+				t.Fatalf("%s at %s: %v", c.interpolation, at, err)
+			}
+			want := orbit.StateAt(epoch.Add(at))
+			if d := norm(combine(1, got.Position, -1, want.Position)); d > c.positionKm {
+				t.Fatalf("%s degree %d at %s: position off by %g km", c.interpolation, c.degree, at, d)
+			}
+			if d := norm(combine(1, got.Velocity, -1, want.Velocity)); d > c.speedKm {
+				t.Fatalf("%s degree %d at %s: velocity off by %g km/s", c.interpolation, c.degree, at, d)
+			}
+		}
+	}
+}
+
+func TestEphemerisRejectsTimesOutsideItsSpan(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	e := sampledEphemeris(KeplerianElements{SemiMajorAxis: 7000, Epoch: epoch}, time.Minute, 10)
+	for _, at := range []time.Time{epoch.Add(-time.Second), e.End().Add(time.Second)} {
+		if _, err := e.StateAt(at); !errors.Is(err, ErrOutsideEphemeris) {
+			t.Fatalf("expected ErrOutsideEphemeris at %s, got %v", at, err)
+		}
+	}
+}
+
+func TestEphemerisValidation(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	valid := sampledEphemeris(KeplerianElements{SemiMajorAxis: 7000, Epoch: epoch}, time.Minute, 4)
+	unordered := sampledEphemeris(KeplerianElements{SemiMajorAxis: 7000, Epoch: epoch}, time.Minute, 4)
+	unordered.Points[2].Time = unordered.Points[1].Time
+	cases := map[string]*Ephemeris{
+		"one point":    {Points: valid.Points[:1]},
+		"unordered":    unordered,
+		"unknown":      {Interpolation: "spline", Points: valid.Points},
+		"even hermite": {Interpolation: InterpolationHermite, Degree: 4, Points: valid.Points},
+		"negative":     {Degree: -1, Points: valid.Points},
+	}
+	for name, e := range cases {
+		if err := e.Validate(); err == nil {
+			t.Fatalf("%s: expected the ephemeris to be rejected", name)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package orbits
 import (
 	"math"
 	"time"
+
+	"github.com/example/satnet/backend/visibility"
 )
 
 const (
@@ -11,37 +13,114 @@ const (
 	twoPi   = 2 * math.Pi
 )
 
-// KeplerianElements represents classical orbital elements referenced to an epoch.
+// KeplerianElements represents classical orbital elements referenced to an epoch. Hyperbolic
+// trajectories (e > 1) take a negative semi-major axis; parabolic ones (e = 1) have none and are
+// sized by PeriapsisRadius instead. For open trajectories MeanAnomaly is the mean motion times the
+// time since periapsis, negative before it, and is not wrapped.
 type KeplerianElements struct {
-	SemiMajorAxis       float64   // kilometers
-	Eccentricity        float64   // unitless, 0 <= e < 1
-	Inclination         float64   // radians
-	RAAN                float64   // radians
-	ArgumentOfPeriapsis float64   // radians
-	MeanAnomaly         float64   // radians at Epoch
-	Epoch               time.Time // reference epoch
-	Mu                  float64   // gravitational parameter, km^3/s^2
+	SemiMajorAxis       float64   `json:"semiMajorAxisKm"`     // kilometers
+	Eccentricity        float64   `json:"eccentricity"`        // unitless, e >= 0
+	Inclination         float64   `json:"inclination"`         // radians
+	RAAN                float64   `json:"raan"`                // radians
+	ArgumentOfPeriapsis float64   `json:"argumentOfPeriapsis"` // radians
+	MeanAnomaly         float64   `json:"meanAnomaly"`         // radians at Epoch
+	Epoch               time.Time `json:"epoch"`               // reference epoch, UTC
+	Mu                  float64   `json:"mu,omitempty"`        // gravitational parameter, km^3/s^2
+	// PeriapsisRadius sizes parabolic trajectories, in kilometers; it is ignored otherwise.
+	PeriapsisRadius float64 `json:"periapsisRadiusKm,omitempty"`
+	// J2 applies the secular drift of Earth's oblateness to closed orbits in Propagate and StateAt:
+	// the node regresses, the periapsis rotates and the mean motion shifts, at the rates J2Rates
+	// gives. The elements are then mean rather than osculating, and short-period terms are ignored.
+	J2 bool `json:"j2,omitempty"`
 }
 
-// MeanMotion returns the mean motion (rad/s) for the orbit.
+// MeanMotion returns the mean motion (rad/s) for the orbit. Hyperbolic trajectories use the
+// magnitude of the semi-major axis and parabolic ones sqrt(mu/p^3) with semi-latus rectum p.
 func (k KeplerianElements) MeanMotion() float64 {
-	mu := k.Mu
-	if mu == 0 {
-		mu = EarthMu
+	if k.Eccentricity == 1 {
+		p := 2 * k.PeriapsisRadius
+		return math.Sqrt(k.mu() / (p * p * p))
+	}
+	return math.Sqrt(k.mu() / math.Pow(math.Abs(k.SemiMajorAxis), 3))
+}
+
+// Periapsis returns the closest approach distance in kilometers.
+func (k KeplerianElements) Periapsis() float64 {
+	if k.Eccentricity == 1 {
+		return k.PeriapsisRadius
+	}
+	return k.SemiMajorAxis * (1 - k.Eccentricity)
+}
+
+// Apoapsis returns the farthest distance in kilometers, or +Inf for open trajectories.
+func (k KeplerianElements) Apoapsis() float64 {
+	if k.Eccentricity >= 1 {
+		return math.Inf(1)
+	}
+	return k.SemiMajorAxis * (1 + k.Eccentricity)
+}
+
+// PeriapsisAltitude returns the closest approach above a spherical Earth of
+// visibility.EarthRadius, in kilometers.
+func (k KeplerianElements) PeriapsisAltitude() float64 {
+	return k.Periapsis() - visibility.EarthRadius
+}
+
+// ApoapsisAltitude returns the farthest distance above a spherical Earth of
+// visibility.EarthRadius, in kilometers, or +Inf for open trajectories.
+func (k KeplerianElements) ApoapsisAltitude() float64 {
+	return k.Apoapsis() - visibility.EarthRadius
+}
+
+// Period returns the time of one revolution, or 0 for open trajectories, which never complete one.
+func (k KeplerianElements) Period() time.Duration {
+	if k.Eccentricity >= 1 {
+		return 0
 	}
+	return time.Duration(twoPi / k.MeanMotion() * float64(time.Second))
+}
 
-	return math.Sqrt(mu / math.Pow(k.SemiMajorAxis, 3))
+// SpecificEnergy returns the orbit's specific mechanical energy, -mu/2a, in km^2/s^2: negative for
+// closed orbits, zero for parabolic trajectories and positive for hyperbolic ones.
+func (k KeplerianElements) SpecificEnergy() float64 {
+	if k.Eccentricity == 1 {
+		return 0
+	}
+	return -k.mu() / (2 * k.SemiMajorAxis)
 }
 
-// Propagate advances the mean anomaly using a Keplerian two-body model by the provided duration.
+// Propagate advances the mean anomaly using a Keplerian two-body model by the provided duration,
+// along with the node and periapsis when J2 is set.
 func (k KeplerianElements) Propagate(dt time.Duration) KeplerianElements {
-	propagated := k
+	propagated := k.drift(dt.Seconds())
 	propagated.Epoch = k.Epoch.Add(dt)
-	propagated.MeanAnomaly = normalizeAngle(k.MeanAnomaly + k.MeanMotion()*dt.Seconds())
+	if k.Eccentricity < 1 {
+		propagated.MeanAnomaly = normalizeAngle(propagated.MeanAnomaly)
+	}
 
 	return propagated
 }
 
+// drift returns the elements seconds after their epoch, leaving the epoch itself unchanged.
+func (k KeplerianElements) drift(seconds float64) KeplerianElements {
+	if !k.J2 || k.Eccentricity >= 1 {
+		k.MeanAnomaly += k.MeanMotion() * seconds
+		return k
+	}
+	rates := j2Rates(k.SemiMajorAxis, k.Eccentricity, k.Inclination, k.mu())
+	k.RAAN = normalizeAngle(k.RAAN + rates.RAAN*seconds)
+	k.ArgumentOfPeriapsis = normalizeAngle(k.ArgumentOfPeriapsis + rates.ArgumentOfPeriapsis*seconds)
+	k.MeanAnomaly += rates.MeanAnomaly * seconds
+	return k
+}
+
+func (k KeplerianElements) mu() float64 {
+	if k.Mu == 0 {
+		return EarthMu
+	}
+	return k.Mu
+}
+
 // MeanAnomalyFromEccentric computes mean anomaly M from eccentric anomaly E.
 func MeanAnomalyFromEccentric(eccentricAnomaly, eccentricity float64) float64 {
 	if eccentricity == 0 {
@@ -67,26 +146,10 @@ func TrueAnomalyFromEccentric(eccentricAnomaly, eccentricity float64) float64 {
 	return normalizeAngle(math.Atan2(numerator, denominator))
 }
 
-// EccentricAnomalyFromMean solves Kepler's equation for the eccentric anomaly using Newton-Raphson iteration.
+// EccentricAnomalyFromMean solves Kepler's equation for the eccentric anomaly with SolveKepler. It
+// is defined for closed orbits only; open ones go through PropagateState.
 func EccentricAnomalyFromMean(meanAnomaly, eccentricity float64) float64 {
-	if eccentricity == 0 {
-		return normalizeAngle(meanAnomaly)
-	}
-
-	M := normalizeAngle(meanAnomaly)
-	E := initialGuess(M, eccentricity)
-	for i := 0; i < 50; i++ {
-		f := E - eccentricity*math.Sin(E) - M
-		fp := 1 - eccentricity*math.Cos(E)
-		delta := f / fp
-		E -= delta
-
-		if math.Abs(delta) < 1e-12 {
-			break
-		}
-	}
-
-	return normalizeAngle(E)
+	return SolveKepler(meanAnomaly, eccentricity).EccentricAnomaly
 }
 
 // TrueAnomalyFromMean converts mean anomaly directly to true anomaly.
@@ -101,13 +164,3 @@ func normalizeAngle(angle float64) float64 {
 	}
 	return wrapped
 }
-
-func initialGuess(meanAnomaly, eccentricity float64) float64 {
-	if eccentricity < 0.8 {
-		return meanAnomaly
-	}
-	if meanAnomaly < math.Pi {
-		return meanAnomaly + eccentricity/2
-	}
-	return meanAnomaly - eccentricity/2
-}
@@ -4,6 +4,8 @@ import (
 	"math"
 	"testing"
 	"time"
+
+	"github.com/example/satnet/backend/visibility"
 )
 
 func TestCircularAnomalies(t *testing.T) {
@@ -90,3 +92,41 @@ func TestTrueAnomalyFromMeanMatchesEccentricPath(t *testing.T) {
 		t.Fatalf("true anomaly conversion mismatch: %v vs %v", trueDirect, trueFromEcc)
 	}
 }
+
+func TestOrbitGeometry(t *testing.T) {
+	// A Molniya orbit: twelve-hour period, perigee near 500 km and apogee near 40000 km.
+	molniya := KeplerianElements{SemiMajorAxis: 26600, Eccentricity: 0.74}
+	if got := molniya.Period(); math.Abs(got.Hours()-12) > 0.01 {
+		t.Fatalf("expected a twelve-hour period, got %v", got)
+	}
+	if got := molniya.Periapsis(); math.Abs(got-6916) > 1e-6 {
+		t.Fatalf("expected a perigee radius of 6916 km, got %v", got)
+	}
+	if got := molniya.Apoapsis(); math.Abs(got-46284) > 1e-6 {
+		t.Fatalf("expected an apogee radius of 46284 km, got %v", got)
+	}
+	if got := molniya.PeriapsisAltitude(); math.Abs(got-(6916-visibility.EarthRadius)) > 1e-6 {
+		t.Fatalf("unexpected perigee altitude %v", got)
+	}
+	if got := molniya.ApoapsisAltitude(); math.Abs(got-(46284-visibility.EarthRadius)) > 1e-6 {
+		t.Fatalf("unexpected apogee altitude %v", got)
+	}
+	if got := molniya.SpecificEnergy(); math.Abs(got+EarthMu/53200) > 1e-9 {
+		t.Fatalf("expected -mu/2a, got %v", got)
+	}
+
+	// The specific energy is the vis-viva sum of kinetic and potential energy anywhere on the orbit.
+	state := KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.1, MeanAnomaly: 1}.StateAt(time.Time{})
+	v, r := norm(state.Velocity), norm(state.Position)
+	if energy := v*v/2 - EarthMu/r; math.Abs(energy-KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.1}.SpecificEnergy()) > 1e-9 {
+		t.Fatalf("energy %v does not match vis-viva", energy)
+	}
+
+	hyperbolic := KeplerianElements{SemiMajorAxis: -20000, Eccentricity: 1.5}
+	if hyperbolic.Period() != 0 || !math.IsInf(hyperbolic.Apoapsis(), 1) || hyperbolic.SpecificEnergy() <= 0 {
+		t.Fatalf("unexpected open-orbit geometry: period %v, apoapsis %v, energy %v", hyperbolic.Period(), hyperbolic.Apoapsis(), hyperbolic.SpecificEnergy())
+	}
+	if parabolic := (KeplerianElements{Eccentricity: 1, PeriapsisRadius: 7000}); parabolic.SpecificEnergy() != 0 || parabolic.Periapsis() != 7000 {
+		t.Fatal("unexpected parabolic geometry")
+	}
+}
@@ -0,0 +1,407 @@
+package orbits
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/satnet/backend/timescale"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// OEMVersion is the CCSDS_OEM_VERS WriteOEM declares.
+const OEMVersion = "2.0"
+
+// gpsMinusTAI is the fixed offset of GPS time from TAI.
+const gpsMinusTAI = -19 * time.Second
+
+// oemTimeLayout is the calendar form of OEM epochs WriteOEM writes, at microsecond resolution.
+const oemTimeLayout = "2006-01-02T15:04:05.000000"
+
+// OEMError reports why a line of an Orbit Ephemeris Message was rejected. Line counts from 1.
+type OEMError struct {
+	Line int
+	Err  error
+}
+
+func (e *OEMError) Error() string {
+	return fmt.Sprintf("oem line %d: %v", e.Line, e.Err)
+}
+
+func (e *OEMError) Unwrap() error {
+	return e.Err
+}
+
+// OEM is a CCSDS Orbit Ephemeris Message (CCSDS 502.0-B), the ephemeris exchange format of GMAT,
+// STK and most flight dynamics toolchains. Only the keyword-value (KVN) form is supported.
+type OEM struct {
+	Version      string       `json:"version"`
+	CreationDate time.Time    `json:"creationDate"`
+	Originator   string       `json:"originator"`
+	Segments     []OEMSegment `json:"segments"`
+}
+
+// OEMSegment is one metadata block of an OEM and its ephemeris lines. Times are UTC whatever the
+// segment's TimeSystem; the zero time stands for an absent optional field.
+type OEMSegment struct {
+	ObjectName string `json:"objectName"`
+	ObjectID   string `json:"objectId"`
+	CenterName string `json:"centerName"`
+	RefFrame   string `json:"refFrame"`
+	// TimeSystem is UTC, TAI, TT or GPS, the systems SatNet converts between.
+	TimeSystem       string    `json:"timeSystem"`
+	StartTime        time.Time `json:"startTime"`
+	UseableStartTime time.Time `json:"useableStartTime,omitempty"`
+	UseableStopTime  time.Time `json:"useableStopTime,omitempty"`
+	StopTime         time.Time `json:"stopTime"`
+	// Interpolation is the method named in the file, such as LAGRANGE or HERMITE; empty when absent.
+	Interpolation       string `json:"interpolation,omitempty"`
+	InterpolationDegree int    `json:"interpolationDegree,omitempty"`
+	// Points are the ephemeris lines in km and km/s. Accelerations and covariance are not kept.
+	Points []EphemerisPoint `json:"points"`
+}
+
+// inertialFrames are the reference frames whose states the simulator can use as is.
+var inertialFrames = map[string]bool{"EME2000": true, "GCRF": true, "ICRF": true, "TEME": true, "TOD": true, "MOD": true}
+
+// Ephemeris converts the segment into an Ephemeris. It requires an Earth-centered inertial frame and
+// maps LAGRANGE, HERMITE and LINEAR interpolation onto Ephemeris methods, keeping the degree; an
+// even Hermite degree is raised to the next odd one.
+func (s OEMSegment) Ephemeris() (*Ephemeris, error) {
+	if !strings.EqualFold(s.CenterName, "EARTH") {
+		return nil, fmt.Errorf("segment for %s is centered on %s, not the Earth", s.ObjectID, s.CenterName)
+	}
+	if !inertialFrames[strings.ToUpper(s.RefFrame)] {
+		return nil, fmt.Errorf("segment for %s is in frame %s; use an inertial frame such as EME2000 or GCRF", s.ObjectID, s.RefFrame)
+	}
+	e := &Ephemeris{Degree: s.InterpolationDegree, Points: s.Points}
+	switch strings.ToUpper(s.Interpolation) {
+	case "", "LAGRANGE":
+		e.Interpolation = InterpolationLagrange
+	case "LINEAR":
+		e.Interpolation, e.Degree = InterpolationLagrange, 1
+	case "HERMITE":
+		e.Interpolation = InterpolationHermite
+		if e.Degree > 0 && e.Degree%2 == 0 {
+			e.Degree++
+		}
+	default:
+		return nil, fmt.Errorf("segment for %s uses unsupported interpolation %s", s.ObjectID, s.Interpolation)
+	}
+	if err := e.Validate(); err != nil {
+		return nil, fmt.Errorf("segment for %s: %w", s.ObjectID, err)
+	}
+	return e, nil
+}
+
+// ParseOEM reads a KVN Orbit Ephemeris Message. Comments, blank lines, accelerations and covariance
+// blocks are skipped. Every segment must state the mandatory metadata, in a time system from
+// OEMSegment.TimeSystem, and list at least one ephemeris line; the first error stops parsing.
+func ParseOEM(r io.Reader) (*OEM, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	oem := &OEM{}
+	var (
+		segment    *OEMSegment
+		inMeta     bool
+		covariance bool
+		metaLine   int
+		line       int
+	)
+	fail := func(format string, args ...any) (*OEM, error) {
+		return nil, &OEMError{Line: line, Err: fmt.Errorf(format, args...)}
+	}
+	finish := func() error {
+		if segment != nil && len(segment.Points) == 0 {
+			return &OEMError{Line: metaLine, Err: errors.New("segment has no ephemeris lines")}
+		}
+		return nil
+	}
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "COMMENT") {
+			continue
+		}
+		if oem.Version == "" {
+			key, value, ok := oemKeyword(text)
+			if !ok || key != "CCSDS_OEM_VERS" {
+				return fail("expected CCSDS_OEM_VERS first")
+			}
+			oem.Version = value
+			continue
+		}
+		switch {
+		case text == "META_START":
+			if inMeta {
+				return fail("META_START inside a metadata block")
+			}
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			oem.Segments = append(oem.Segments, OEMSegment{})
+			segment, inMeta, covariance, metaLine = &oem.Segments[len(oem.Segments)-1], true, false, line
+		case text == "META_STOP":
+			if !inMeta {
+				return fail("META_STOP without META_START")
+			}
+			if err := segment.checkMetadata(); err != nil {
+				return fail("%v", err)
+			}
+			inMeta = false
+		case text == "COVARIANCE_START":
+			covariance = true
+		case text == "COVARIANCE_STOP":
+			covariance = false
+		case covariance:
+		case inMeta:
+			key, value, ok := oemKeyword(text)
+			if !ok {
+				return fail("expected a metadata keyword, got %q", text)
+			}
+			if err := segment.setMetadata(key, value); err != nil {
+				return fail("%s: %v", key, err)
+			}
+		case segment == nil:
+			key, value, ok := oemKeyword(text)
+			if !ok {
+				return fail("expected a header keyword, got %q", text)
+			}
+			switch key {
+			case "CREATION_DATE":
+				t, err := parseOEMTime(value)
+				if err != nil {
+					return fail("CREATION_DATE: %v", err)
+				}
+				oem.CreationDate = t
+			case "ORIGINATOR":
+				oem.Originator = value
+			}
+		default:
+			point, err := parseOEMPoint(text, segment.TimeSystem)
+			if err != nil {
+				return fail("%v", err)
+			}
+			if n := len(segment.Points); n > 0 && !point.Time.After(segment.Points[n-1].Time) {
+				return fail("ephemeris line is not after the one before it")
+			}
+			segment.Points = append(segment.Points, point)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inMeta {
+		return nil, &OEMError{Line: metaLine, Err: errors.New("metadata block is not closed")}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	if len(oem.Segments) == 0 {
+		return nil, errors.New("oem has no segments")
+	}
+	return oem, nil
+}
+
+// WriteOEM writes o as a KVN Orbit Ephemeris Message, with times in each segment's time system.
+// An empty Version is written as OEMVersion.
+func WriteOEM(w io.Writer, o *OEM) error {
+	bw := bufio.NewWriter(w)
+	version := o.Version
+	if version == "" {
+		version = OEMVersion
+	}
+	fmt.Fprintf(bw, "CCSDS_OEM_VERS = %s\n", version)
+	fmt.Fprintf(bw, "CREATION_DATE = %s\n", o.CreationDate.UTC().Format(oemTimeLayout))
+	fmt.Fprintf(bw, "ORIGINATOR = %s\n", o.Originator)
+	for _, s := range o.Segments {
+		if err := s.checkMetadata(); err != nil {
+			return fmt.Errorf("segment for %s: %w", s.ObjectID, err)
+		}
+		format := func(t time.Time) string {
+			t, _ = fromUTC(t, s.TimeSystem)
+			return t.Format(oemTimeLayout)
+		}
+		fmt.Fprintf(bw, "\nMETA_START\n")
+		fmt.Fprintf(bw, "OBJECT_NAME = %s\n", s.ObjectName)
+		fmt.Fprintf(bw, "OBJECT_ID = %s\n", s.ObjectID)
+		fmt.Fprintf(bw, "CENTER_NAME = %s\n", s.CenterName)
+		fmt.Fprintf(bw, "REF_FRAME = %s\n", s.RefFrame)
+		fmt.Fprintf(bw, "TIME_SYSTEM = %s\n", s.TimeSystem)
+		fmt.Fprintf(bw, "START_TIME = %s\n", format(s.StartTime))
+		if !s.UseableStartTime.IsZero() {
+			fmt.Fprintf(bw, "USEABLE_START_TIME = %s\n", format(s.UseableStartTime))
+		}
+		if !s.UseableStopTime.IsZero() {
+			fmt.Fprintf(bw, "USEABLE_STOP_TIME = %s\n", format(s.UseableStopTime))
+		}
+		fmt.Fprintf(bw, "STOP_TIME = %s\n", format(s.StopTime))
+		if s.Interpolation != "" {
+			fmt.Fprintf(bw, "INTERPOLATION = %s\n", s.Interpolation)
+			if s.InterpolationDegree > 0 {
+				fmt.Fprintf(bw, "INTERPOLATION_DEGREE = %d\n", s.InterpolationDegree)
+			}
+		}
+		fmt.Fprintf(bw, "META_STOP\n\n")
+		for _, p := range s.Points {
+			fmt.Fprintf(bw, "%s %.6f %.6f %.6f %.9f %.9f %.9f\n", format(p.Time),
+				p.Position.X, p.Position.Y, p.Position.Z, p.Velocity.X, p.Velocity.Y, p.Velocity.Z)
+		}
+	}
+	return bw.Flush()
+}
+
+// oemKeyword splits a "KEY = value" line.
+func oemKeyword(text string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(text, "=")
+	return strings.TrimSpace(key), strings.TrimSpace(value), ok
+}
+
+func (s *OEMSegment) setMetadata(key, value string) error {
+	switch key {
+	case "OBJECT_NAME":
+		s.ObjectName = value
+	case "OBJECT_ID":
+		s.ObjectID = value
+	case "CENTER_NAME":
+		s.CenterName = value
+	case "REF_FRAME":
+		s.RefFrame = value
+	case "TIME_SYSTEM":
+		if _, err := toUTC(time.Time{}, value); err != nil {
+			return err
+		}
+		s.TimeSystem = value
+	case "INTERPOLATION":
+		s.Interpolation = value
+	case "INTERPOLATION_DEGREE":
+		degree, err := strconv.Atoi(value)
+		if err != nil || degree < 0 {
+			return fmt.Errorf("invalid degree %q", value)
+		}
+		s.InterpolationDegree = degree
+	case "START_TIME", "USEABLE_START_TIME", "USEABLE_STOP_TIME", "STOP_TIME":
+		if s.TimeSystem == "" {
+			return errors.New("TIME_SYSTEM must come before the segment's times")
+		}
+		t, err := parseOEMTime(value)
+		if err == nil {
+			t, err = toUTC(t, s.TimeSystem)
+		}
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "START_TIME":
+			s.StartTime = t
+		case "USEABLE_START_TIME":
+			s.UseableStartTime = t
+		case "USEABLE_STOP_TIME":
+			s.UseableStopTime = t
+		default:
+			s.StopTime = t
+		}
+	}
+	return nil
+}
+
+// checkMetadata reports the first mandatory metadata field the segment lacks, or an unsupported
+// time system.
+func (s *OEMSegment) checkMetadata() error {
+	for _, field := range []struct {
+		key     string
+		missing bool
+	}{
+		{"OBJECT_NAME", s.ObjectName == ""},
+		{"OBJECT_ID", s.ObjectID == ""},
+		{"CENTER_NAME", s.CenterName == ""},
+		{"REF_FRAME", s.RefFrame == ""},
+		{"TIME_SYSTEM", s.TimeSystem == ""},
+		{"START_TIME", s.StartTime.IsZero()},
+		{"STOP_TIME", s.StopTime.IsZero()},
+	} {
+		if field.missing {
+			return fmt.Errorf("metadata lacks %s", field.key)
+		}
+	}
+	if _, err := toUTC(time.Time{}, s.TimeSystem); err != nil {
+		return err
+	}
+	if s.StopTime.Before(s.StartTime) {
+		return errors.New("STOP_TIME is before START_TIME")
+	}
+	return nil
+}
+
+// parseOEMPoint parses an ephemeris line: an epoch, the position and the velocity, optionally
+// followed by an acceleration that is discarded.
+func parseOEMPoint(text, system string) (EphemerisPoint, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 7 && len(fields) != 10 {
+		return EphemerisPoint{}, fmt.Errorf("ephemeris line has %d fields, want 7 or 10", len(fields))
+	}
+	t, err := parseOEMTime(fields[0])
+	if err == nil {
+		t, err = toUTC(t, system)
+	}
+	if err != nil {
+		return EphemerisPoint{}, err
+	}
+	var values [6]float64
+	for i := range values {
+		if values[i], err = strconv.ParseFloat(fields[i+1], 64); err != nil {
+			return EphemerisPoint{}, fmt.Errorf("invalid number %q", fields[i+1])
+		}
+	}
+	return EphemerisPoint{
+		Time:     t,
+		Position: visibility.Vector3{X: values[0], Y: values[1], Z: values[2]},
+		Velocity: visibility.Vector3{X: values[3], Y: values[4], Z: values[5]},
+	}, nil
+}
+
+// parseOEMTime parses a CCSDS calendar (YYYY-MM-DDThh:mm:ss) or day-of-year (YYYY-DDDThh:mm:ss)
+// epoch with optional fractional seconds and trailing Z, as a reading in no particular time system.
+func parseOEMTime(value string) (time.Time, error) {
+	value = strings.TrimSuffix(value, "Z")
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-002T15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid epoch %q", value)
+}
+
+// toUTC converts a reading in system to UTC.
+func toUTC(t time.Time, system string) (time.Time, error) {
+	switch system {
+	case "UTC":
+		return t, nil
+	case "TAI":
+		return timescale.TAIToUTC(t), nil
+	case "TT":
+		return timescale.TTToUTC(t), nil
+	case "GPS":
+		return timescale.TAIToUTC(t.Add(-gpsMinusTAI)), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported time system %q", system)
+}
+
+// fromUTC converts a UTC instant to a reading in system.
+func fromUTC(t time.Time, system string) (time.Time, error) {
+	switch system {
+	case "UTC":
+		return t.UTC(), nil
+	case "TAI":
+		return timescale.UTCToTAI(t), nil
+	case "TT":
+		return timescale.UTCToTT(t), nil
+	case "GPS":
+		return timescale.UTCToTAI(t).Add(gpsMinusTAI), nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported time system %q", system)
+}
@@ -0,0 +1,130 @@
+package orbits
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleOEM = `CCSDS_OEM_VERS = 2.0
+COMMENT written by a flight dynamics tool
+CREATION_DATE = 2024-061T12:00:00
+ORIGINATOR = OPS
+
+META_START
+OBJECT_NAME = RELAY-1
+OBJECT_ID = 2024-001A
+CENTER_NAME = EARTH
+REF_FRAME = EME2000
+TIME_SYSTEM = TAI
+START_TIME = 2024-03-01T00:00:37.000
+STOP_TIME = 2024-03-01T00:02:37.000
+INTERPOLATION = HERMITE
+INTERPOLATION_DEGREE = 4
+META_STOP
+
+2024-03-01T00:00:37.000 7000.0 0.0 0.0 0.0 5.0 5.0
+2024-061T00:01:37 6990.0 300.0 300.0 -0.3 5.0 5.0 0.001 0.0 0.0
+2024-03-01T00:02:37Z 6960.0 600.0 600.0 -0.6 4.9 4.9
+
+COVARIANCE_START
+EPOCH = 2024-03-01T00:00:37.000
+1.0
+COVARIANCE_STOP
+`
+
+func TestParseOEMReadsSegments(t *testing.T) {
+	oem, err := ParseOEM(strings.NewReader(sampleOEM))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if oem.Version != "2.0" || oem.Originator != "OPS" || !oem.CreationDate.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected header %+v", oem)
+	}
+	if len(oem.Segments) != 1 {
+		t.Fatalf("expected one segment, got %d", len(oem.Segments))
+	}
+	segment := oem.Segments[0]
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// TAI ran 37 s ahead of UTC in 2024.
+	if !segment.StartTime.Equal(epoch) || !segment.StopTime.Equal(epoch.Add(2*time.Minute)) {
+		t.Fatalf("expected TAI times converted to UTC, got %s to %s", segment.StartTime, segment.StopTime)
+	}
+	if len(segment.Points) != 3 || !segment.Points[1].Time.Equal(epoch.Add(time.Minute)) || segment.Points[1].Position.Y != 300 || segment.Points[2].Velocity.Z != 4.9 {
+		t.Fatalf("unexpected points %+v", segment.Points)
+	}
+
+	ephemeris, err := segment.Ephemeris()
+	if err != nil {
+		t.Fatalf("ephemeris: %v", err)
+	}
+	if ephemeris.Interpolation != InterpolationHermite || ephemeris.Degree != 5 {
+		t.Fatalf("expected Hermite interpolation raised to degree 5, got %s %d", ephemeris.Interpolation, ephemeris.Degree)
+	}
+	if state, err := ephemeris.StateAt(epoch.Add(time.Minute)); err != nil || state.Position.X != 6990 {
+		t.Fatalf("expected the ephemeris to pass through its points, got %+v, %v", state, err)
+	}
+
+	segment.RefFrame = "ITRF"
+	if _, err := segment.Ephemeris(); err == nil {
+		t.Fatalf("expected an Earth-fixed frame to be rejected")
+	}
+}
+
+func TestWriteOEMRoundTrips(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	orbit := KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.01, Inclination: 0.9, Epoch: epoch}
+	for _, system := range []string{"UTC", "TAI", "TT", "GPS"} {
+		points := sampledEphemeris(orbit, time.Minute, 5).Points
+		oem := &OEM{CreationDate: epoch, Originator: "SATNET", Segments: []OEMSegment{{
+			ObjectName: "sat", ObjectID: "sat-1", CenterName: "EARTH", RefFrame: "EME2000", TimeSystem: system,
+			StartTime: points[0].Time, StopTime: points[4].Time, Interpolation: "LAGRANGE", InterpolationDegree: 3,
+			Points: points,
+		}}}
+		var buf bytes.Buffer
+		if err := WriteOEM(&buf, oem); err != nil {
+			t.Fatalf("%s: write: %v", system, err)
+		}
+		parsed, err := ParseOEM(&buf)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", system, err)
+		}
+		if parsed.Version != OEMVersion {
+			t.Fatalf("%s: expected version %s, got %s", system, OEMVersion, parsed.Version)
+		}
+		got := parsed.Segments[0]
+		if got.ObjectID != "sat-1" || got.TimeSystem != system || got.InterpolationDegree != 3 || !got.StopTime.Equal(points[4].Time) {
+			t.Fatalf("%s: metadata did not survive the round trip: %+v", system, got)
+		}
+		for i, p := range got.Points {
+			if !p.Time.Equal(points[i].Time) || norm(combine(1, p.Position, -1, points[i].Position)) > 1e-5 ||
+				norm(combine(1, p.Velocity, -1, points[i].Velocity)) > 1e-8 {
+				t.Fatalf("%s: point %d changed from %+v to %+v", system, i, points[i], p)
+			}
+		}
+	}
+}
+
+func TestParseOEMReportsTheOffendingLine(t *testing.T) {
+	cases := map[string]struct {
+		text string
+		line int
+	}{
+		"no version":    {"META_START\n", 1},
+		"missing field": {"CCSDS_OEM_VERS = 2.0\nMETA_START\nOBJECT_NAME = a\nMETA_STOP\n", 4},
+		"time system":   {strings.Replace(sampleOEM, "TIME_SYSTEM = TAI", "TIME_SYSTEM = TDB", 1), 11},
+		"short line":    {strings.Replace(sampleOEM, "6960.0 600.0 600.0 -0.6 4.9 4.9", "6960.0", 1), 20},
+		"out of order":  {strings.Replace(sampleOEM, "2024-03-01T00:02:37Z", "2024-03-01T00:00:00", 1), 20},
+		"empty segment": {strings.Split(sampleOEM, "2024-03-01T00:00:37.000 7000.0")[0], 6},
+		"unclosed":      {"CCSDS_OEM_VERS = 2.0\nMETA_START\nOBJECT_NAME = a\n", 2},
+	}
+	for name, c := range cases {
+		_, err := ParseOEM(strings.NewReader(c.text))
+		var oemErr *OEMError
+		if !errors.As(err, &oemErr) || oemErr.Line != c.line {
+			t.Fatalf("%s: expected an error on line %d, got %v", name, c.line, err)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package orbits
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// Propagator reports a satellite's inertial state at any time. SGP4 implements it, and External
+// adapts a propagator running outside the process.
+type Propagator interface {
+	StateAt(t time.Time) (StateVector, error)
+}
+
+var _ Propagator = (*SGP4)(nil)
+
+// ElementsPropagator propagates classical elements with Kepler's equation as a Propagator.
+func ElementsPropagator(k KeplerianElements) Propagator {
+	return elementsPropagator(k)
+}
+
+type elementsPropagator KeplerianElements
+
+func (p elementsPropagator) StateAt(t time.Time) (StateVector, error) {
+	return KeplerianElements(p).StateAt(t), nil
+}
+
+// ExternalRequest is one line an External propagator writes to its process, asking for a
+// satellite's state at Time. Orbit and TLE carry the satellite's elements from the scenario, when
+// it has them, so the process can start from the same initial conditions.
+type ExternalRequest struct {
+	Satellite string             `json:"satellite"`
+	Time      time.Time          `json:"time"`
+	Orbit     *KeplerianElements `json:"orbit,omitempty"`
+	TLE       *TLEElements       `json:"tle,omitempty"`
+}
+
+// externalResponse is the line the process answers each request with: the inertial state in km
+// and km/s, or an error.
+type externalResponse struct {
+	Position *visibility.Vector3 `json:"position"`
+	Velocity *visibility.Vector3 `json:"velocity"`
+	Error    string              `json:"error"`
+}
+
+// ExternalTimeout is how long an External waits for its process to answer one request.
+const ExternalTimeout = 10 * time.Second
+
+// ErrExternalTimeout is returned when an External process does not answer within its timeout.
+var ErrExternalTimeout = errors.New("external propagator timed out")
+
+// External runs a propagator as a long-lived child process, such as a wrapper around an Orekit
+// service, and speaks newline-delimited JSON with it over stdin and stdout: one ExternalRequest
+// per line in, one response per line out, in order. A response is either
+// {"position": {"x","y","z"}, "velocity": {"x","y","z"}} or {"error": "..."}. The process's
+// stderr is passed through to this one's.
+//
+// Requests are serialized, so an External may be shared between goroutines and simulators. A
+// process that does not answer within ExternalTimeout, exits or breaks the protocol fails the
+// request and is killed; the next request starts it again.
+type External struct {
+	mu      sync.Mutex
+	name    string
+	args    []string
+	timeout time.Duration
+	proc    *externalProcess // nil until restarted after a failure
+	closed  bool
+}
+
+// externalProcess is one run of an External's command.
+type externalProcess struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	replies chan []byte   // stdout lines; closed once stdout ends, after err is set
+	err     error         // why stdout ended
+	done    chan struct{} // closed when the process is discarded, releasing the reader
+}
+
+// StartExternal starts name with args as an External propagator.
+func StartExternal(name string, args ...string) (*External, error) {
+	proc, err := startExternalProcess(name, args)
+	if err != nil {
+		return nil, err
+	}
+	return &External{name: name, args: args, timeout: ExternalTimeout, proc: proc}, nil
+}
+
+func startExternalProcess(name string, args []string) (*externalProcess, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start external propagator: %w", err)
+	}
+	p := &externalProcess{cmd: cmd, stdin: stdin, replies: make(chan []byte), done: make(chan struct{})}
+	go func() {
+		defer close(p.replies)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case p.replies <- append([]byte(nil), scanner.Bytes()...):
+			case <-p.done:
+				return
+			}
+		}
+		p.err = scanner.Err()
+		if p.err == nil {
+			p.err = io.ErrUnexpectedEOF
+		}
+	}()
+	return p, nil
+}
+
+// kill stops the process without waiting for it to finish its work.
+func (p *externalProcess) kill() {
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	close(p.done)
+	go p.cmd.Wait()
+}
+
+// Satellite returns the Propagator for one satellite. request names it and carries its elements;
+// its Time is replaced on every call.
+func (e *External) Satellite(request ExternalRequest) Propagator {
+	return externalSatellite{external: e, request: request}
+}
+
+// Close stops the process, letting it exit once its stdin closes, or killing it when it has not
+// exited within the timeout.
+func (e *External) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	p := e.proc
+	if p == nil {
+		return nil
+	}
+	e.proc = nil
+	p.stdin.Close()
+	exited := make(chan error, 1)
+	go func() { exited <- p.cmd.Wait() }()
+	select {
+	case err := <-exited:
+		close(p.done)
+		return err
+	case <-time.After(e.timeout):
+		p.cmd.Process.Kill()
+		close(p.done)
+		return fmt.Errorf("%w: killed on close", ErrExternalTimeout)
+	}
+}
+
+// fail discards the current process after a protocol failure and returns err.
+func (e *External) fail(err error) (StateVector, error) {
+	e.proc.kill()
+	e.proc = nil
+	return StateVector{}, fmt.Errorf("external propagator: %w", err)
+}
+
+func (e *External) stateAt(request ExternalRequest) (StateVector, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return StateVector{}, errors.New("external propagator closed")
+	}
+	line, err := json.Marshal(request)
+	if err != nil {
+		return StateVector{}, err
+	}
+	if e.proc == nil {
+		if e.proc, err = startExternalProcess(e.name, e.args); err != nil {
+			return StateVector{}, err
+		}
+	}
+	p := e.proc
+	// Killing the process on expiry also unblocks a write to a process that stopped reading.
+	expired := make(chan struct{})
+	timer := time.AfterFunc(e.timeout, func() {
+		close(expired)
+		p.cmd.Process.Kill()
+	})
+	defer timer.Stop()
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		if !timer.Stop() {
+			err = ErrExternalTimeout
+		}
+		return e.fail(err)
+	}
+	var reply []byte
+	select {
+	case r, ok := <-p.replies:
+		if !ok {
+			return e.fail(p.err)
+		}
+		reply = r
+	case <-expired:
+		return e.fail(ErrExternalTimeout)
+	}
+	if !timer.Stop() {
+		// The reply raced the expiry, which has already killed the process.
+		p.kill()
+		e.proc = nil
+	}
+	var response externalResponse
+	if err := json.Unmarshal(reply, &response); err != nil {
+		return e.fail(fmt.Errorf("malformed response: %w", err))
+	}
+	switch {
+	case response.Error != "":
+		return StateVector{}, fmt.Errorf("external propagator: %s", response.Error)
+	case response.Position == nil || response.Velocity == nil:
+		return StateVector{}, errors.New("external propagator: response lacks position or velocity")
+	}
+	return StateVector{Position: *response.Position, Velocity: *response.Velocity}, nil
+}
+
+// externalSatellite is the Propagator of one satellite through an External process.
+type externalSatellite struct {
+	external *External
+	request  ExternalRequest
+}
+
+func (p externalSatellite) StateAt(t time.Time) (StateVector, error) {
+	request := p.request
+	request.Time = t.UTC()
+	return p.external.stateAt(request)
+}
@@ -0,0 +1,119 @@
+package orbits
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExternalHelperProcess is the external propagator the tests start: it propagates the request's
+// orbit with Kepler's equation, fails for satellites named "bad" and never answers for "hang".
+func TestExternalHelperProcess(t *testing.T) {
+	if os.Getenv("SATNET_EXTERNAL_HELPER") != "1" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request ExternalRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil || request.Orbit == nil {
+			fmt.Println("not json")
+			continue
+		}
+		if request.Satellite == "hang" {
+			time.Sleep(time.Hour)
+		}
+		if request.Satellite == "bad" {
+			fmt.Println(`{"error": "no ephemeris for bad"}`)
+			continue
+		}
+		state := request.Orbit.StateAt(request.Time)
+		out, _ := json.Marshal(externalResponse{Position: &state.Position, Velocity: &state.Velocity})
+		fmt.Println(string(out))
+	}
+	os.Exit(0)
+}
+
+func startHelper(t *testing.T) *External {
+	t.Helper()
+	t.Setenv("SATNET_EXTERNAL_HELPER", "1")
+	external, err := StartExternal(os.Args[0], "-test.run=^TestExternalHelperProcess$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return external
+}
+
+func TestExternalPropagatorMatchesInProcess(t *testing.T) {
+	external := startHelper(t)
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	orbit := KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.01, Inclination: 0.9, RAAN: 1, MeanAnomaly: 2, Epoch: epoch}
+	propagator := external.Satellite(ExternalRequest{Satellite: "sat", Orbit: &orbit})
+	for _, dt := range []time.Duration{0, time.Minute, 90 * time.Minute} {
+		got, err := propagator.StateAt(epoch.Add(dt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := orbit.StateAt(epoch.Add(dt))
+		if math.Abs(got.Position.X-want.Position.X) > 1e-9 || math.Abs(got.Velocity.Z-want.Velocity.Z) > 1e-12 {
+			t.Fatalf("after %v got %+v, want %+v", dt, got, want)
+		}
+	}
+
+	// The process's errors fail one request; the process keeps serving.
+	if _, err := external.Satellite(ExternalRequest{Satellite: "bad", Orbit: &orbit}).StateAt(epoch); err == nil || !strings.Contains(err.Error(), "no ephemeris") {
+		t.Fatalf("expected the process's error, got %v", err)
+	}
+	if _, err := propagator.StateAt(epoch); err != nil {
+		t.Fatal(err)
+	}
+
+	// A malformed response fails the request and restarts the process for the next one.
+	if _, err := external.Satellite(ExternalRequest{Satellite: "sat"}).StateAt(epoch); err == nil {
+		t.Fatal("expected a malformed response to fail")
+	}
+	if _, err := propagator.StateAt(epoch); err != nil {
+		t.Fatalf("expected a restarted process to answer, got %v", err)
+	}
+	if err := external.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := propagator.StateAt(epoch); err == nil {
+		t.Fatal("expected requests after Close to fail")
+	}
+}
+
+func TestExternalPropagatorTimesOutAndRestarts(t *testing.T) {
+	external := startHelper(t)
+	defer external.Close()
+	external.timeout = 200 * time.Millisecond
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	orbit := KeplerianElements{SemiMajorAxis: 7000, Epoch: epoch}
+
+	started := time.Now()
+	if _, err := external.Satellite(ExternalRequest{Satellite: "hang", Orbit: &orbit}).StateAt(epoch); !errors.Is(err, ErrExternalTimeout) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("timeout took %v", elapsed)
+	}
+	if _, err := external.Satellite(ExternalRequest{Satellite: "sat", Orbit: &orbit}).StateAt(epoch); err != nil {
+		t.Fatalf("expected the restarted process to answer, got %v", err)
+	}
+}
+
+func TestExternalPropagatorReportsExit(t *testing.T) {
+	external, err := StartExternal("true")
+	if err != nil {
+		t.Skip("no true binary:", err)
+	}
+	defer external.Close()
+	if _, err := external.Satellite(ExternalRequest{Satellite: "sat"}).StateAt(time.Now()); err == nil {
+		t.Fatal("expected a process that exited to fail")
+	}
+}
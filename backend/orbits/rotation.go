@@ -0,0 +1,44 @@
+package orbits
+
+import (
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/timescale"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// GMST returns the Greenwich mean sidereal angle, in radians from 0 to 2π, at the UTC instant t:
+// the angle the Earth has turned between the inertial X axis and the Greenwich meridian. UT1 is
+// taken as UTC, which shifts the angle by at most 0.9 s of rotation, about 0.4 km at the equator.
+func GMST(t time.Time) float64 {
+	return greenwichSiderealAngle(timescale.JulianDate(t.UTC()))
+}
+
+// ECIToECEF rotates an inertial state, such as StateAt or an SGP4 propagator returns, into the
+// Earth-fixed frame at the UTC instant t. The rotation is about the Z axis by GMST, so precession,
+// nutation and polar motion are ignored; the velocity is relative to the rotating Earth.
+func ECIToECEF(state StateVector, t time.Time) StateVector {
+	theta := GMST(t)
+	r := rotateZ(state.Position, -theta)
+	v := rotateZ(state.Velocity, -theta)
+	// Subtract ω × r for the frame's rotation.
+	v.X += EarthRotationRate * r.Y
+	v.Y -= EarthRotationRate * r.X
+	return StateVector{Position: r, Velocity: v}
+}
+
+// ECEFToECI is the inverse of ECIToECEF.
+func ECEFToECI(state StateVector, t time.Time) StateVector {
+	theta := GMST(t)
+	v := state.Velocity
+	v.X -= EarthRotationRate * state.Position.Y
+	v.Y += EarthRotationRate * state.Position.X
+	return StateVector{Position: rotateZ(state.Position, theta), Velocity: rotateZ(v, theta)}
+}
+
+// rotateZ rotates v counterclockwise by angle radians about the Z axis.
+func rotateZ(v visibility.Vector3, angle float64) visibility.Vector3 {
+	sin, cos := math.Sincos(angle)
+	return visibility.Vector3{X: cos*v.X - sin*v.Y, Y: sin*v.X + cos*v.Y, Z: v.Z}
+}
@@ -0,0 +1,50 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestGMSTMatchesVallado(t *testing.T) {
+	// Vallado, Fundamentals of Astrodynamics, example 3-5: 1992 August 20, 12:14 UT1.
+	got := GMST(time.Date(1992, time.August, 20, 12, 14, 0, 0, time.UTC)) * 180 / math.Pi
+	if math.Abs(got-152.578787810) > 1e-6 {
+		t.Fatalf("GMST = %.9f°, want 152.578787810°", got)
+	}
+}
+
+func TestECIToECEFHoldsGeostationarySatellitesStill(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	geo := KeplerianElements{SemiMajorAxis: math.Cbrt(EarthMu / (EarthRotationRate * EarthRotationRate)), Epoch: epoch}
+	start := ECIToECEF(geo.StateAt(epoch), epoch)
+	if speed := length(start.Velocity); speed > 1e-6 {
+		t.Fatalf("expected no motion relative to the Earth, got %v km/s", speed)
+	}
+	for _, dt := range []time.Duration{time.Hour, 6 * time.Hour, 23 * time.Hour} {
+		at := epoch.Add(dt)
+		fixed := ECIToECEF(geo.StateAt(at), at)
+		if drift := length(visibility.Vector3{X: fixed.Position.X - start.Position.X, Y: fixed.Position.Y - start.Position.Y, Z: fixed.Position.Z - start.Position.Z}); drift > 0.05 {
+			t.Fatalf("after %v the satellite drifted %v km over the Earth", dt, drift)
+		}
+	}
+}
+
+func TestECEFToECIRoundTrip(t *testing.T) {
+	at := time.Date(2025, 6, 1, 7, 30, 0, 0, time.UTC)
+	state := KeplerianElements{SemiMajorAxis: 6921, Eccentricity: 0.01, Inclination: 1, RAAN: 2, MeanAnomaly: 3, Epoch: at}.StateAt(at)
+	back := ECEFToECI(ECIToECEF(state, at), at)
+	for _, pair := range [][2]float64{
+		{state.Position.X, back.Position.X}, {state.Position.Y, back.Position.Y}, {state.Position.Z, back.Position.Z},
+		{state.Velocity.X, back.Velocity.X}, {state.Velocity.Y, back.Velocity.Y}, {state.Velocity.Z, back.Velocity.Z},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Fatalf("round trip changed the state: %+v became %+v", state, back)
+		}
+	}
+	if fixed := ECIToECEF(state, at); math.Abs(length(fixed.Position)-length(state.Position)) > 1e-9 || fixed.Position.Z != state.Position.Z {
+		t.Fatalf("the rotation must keep the radius and the polar axis, got %+v", fixed)
+	}
+}
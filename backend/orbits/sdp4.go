@@ -0,0 +1,476 @@
+package orbits
+
+import "math"
+
+// Lunar and solar constants of SDP4: the eccentricities and mean motions (radians per minute) of the
+// Sun's and Moon's apparent orbits, and the solar and lunar perturbation strengths.
+const (
+	zes  = 0.01675
+	zel  = 0.05490
+	zns  = 1.19459e-5
+	znl  = 1.5835218e-4
+	c1ss = 2.9864797e-6
+	c1l  = 4.7968065e-7
+	// rptim is the Earth's rotation rate in radians per minute.
+	rptim = 4.37526908801129966e-3
+)
+
+// Resonance classes of deep-space orbits.
+const (
+	resonanceNone = iota
+	// resonanceSynchronous is a 24-hour orbit locked to the Earth's rotation.
+	resonanceSynchronous
+	// resonanceHalfDay is a 12-hour eccentric orbit, such as Molniya.
+	resonanceHalfDay
+)
+
+// deepSpace holds SDP4's lunar-solar and resonance coefficients for one element set.
+type deepSpace struct {
+	gsto float64 // Greenwich sidereal angle at epoch
+
+	// Lunar-solar periodic coefficients.
+	e3, ee2, se2, se3, sgh2, sgh3, sgh4, sh2, sh3, si2, si3, sl2, sl3, sl4 float64
+	xgh2, xgh3, xgh4, xh2, xh3, xi2, xi3, xl2, xl3, xl4, zmol, zmos        float64
+
+	// Lunar-solar secular rates.
+	dedt, didt, dmdt, dnodt, domdt float64
+
+	// Resonance terms.
+	irez                                                   int
+	d2201, d2211, d3210, d3222, d4410, d4422, d5220, d5232 float64
+	d5421, d5433, del1, del2, del3, xfact, xlamo           float64
+}
+
+// greenwichSiderealAngle returns the IAU-82 Greenwich mean sidereal angle, in radians, at Julian
+// date jdut1 in UT1.
+func greenwichSiderealAngle(jdut1 float64) float64 {
+	tut1 := (jdut1 - 2451545) / 36525
+	seconds := -6.2e-6*tut1*tut1*tut1 + 0.093104*tut1*tut1 + (876600*3600+8640184.812866)*tut1 + 67310.54841
+	return normalizeAngle(seconds * math.Pi / 180 / 240)
+}
+
+// newDeepSpace computes the deep-space coefficients of p, whose near-Earth terms are initialized.
+// epochDays counts days from sgp4EpochJD.
+func newDeepSpace(p *SGP4, epochDays, eccsq float64) *deepSpace {
+	d := &deepSpace{gsto: greenwichSiderealAngle(epochDays + sgp4EpochJD)}
+	c := d.lunarSolar(epochDays, p.ecco, p.argpo, p.inclo, p.nodeo, p.no)
+	d.resonance(p, c, eccsq)
+	return d
+}
+
+// lunarSolarTerms carries the intermediate lunar (s, z) and solar (ss, sz) terms into resonance.
+type lunarSolarTerms struct {
+	s1, s2, s3, s4, s5                           float64
+	ss1, ss2, ss3, ss4, ss5                      float64
+	z1, z3, z11, z13, z21, z23, z31, z33         float64
+	sz1, sz3, sz11, sz13, sz21, sz23, sz31, sz33 float64
+	sinim, cosim, emsq                           float64
+}
+
+// lunarSolar computes the lunar-solar periodic coefficients, as SDP4's dscom does.
+func (d *deepSpace) lunarSolar(epochDays, ep, argpp, inclp, nodep, np float64) lunarSolarTerms {
+	const (
+		zsinis = 0.39785416
+		zcosis = 0.91744867
+		zcosgs = 0.1945905
+		zsings = -0.98088458
+	)
+	var out lunarSolarTerms
+	snodm, cnodm := math.Sincos(nodep)
+	sinomm, cosomm := math.Sincos(argpp)
+	sinim, cosim := math.Sincos(inclp)
+	emsq := ep * ep
+	betasq := 1 - emsq
+	rtemsq := math.Sqrt(betasq)
+	out.sinim, out.cosim, out.emsq = sinim, cosim, emsq
+
+	// The Moon's node and its orbit's orientation relative to the equator.
+	day := epochDays + 18261.5
+	xnodce := normalizeAngle(4.5236020 - 9.2422029e-4*day)
+	stem, ctem := math.Sincos(xnodce)
+	zcosil := 0.91375164 - 0.03568096*ctem
+	zsinil := math.Sqrt(1 - zcosil*zcosil)
+	zsinhl := 0.089683511 * stem / zsinil
+	zcoshl := math.Sqrt(1 - zsinhl*zsinhl)
+	gam := 5.8351514 + 0.0019443680*day
+	zx := math.Atan2(0.39785416*stem/zsinil, zcoshl*ctem+0.91744867*zsinhl*stem)
+	zx = gam + zx - xnodce
+	zsingl, zcosgl := math.Sincos(zx)
+
+	// The first pass computes the solar terms, the second the lunar ones.
+	zcosg, zsing, zcosi, zsini := zcosgs, zsings, zcosis, zsinis
+	zcosh, zsinh := cnodm, snodm
+	cc := c1ss
+	xnoi := 1 / np
+	for pass := 0; pass < 2; pass++ {
+		a1 := zcosg*zcosh + zsing*zcosi*zsinh
+		a3 := -zsing*zcosh + zcosg*zcosi*zsinh
+		a7 := -zcosg*zsinh + zsing*zcosi*zcosh
+		a8 := zsing * zsini
+		a9 := zsing*zsinh + zcosg*zcosi*zcosh
+		a10 := zcosg * zsini
+		a2 := cosim*a7 + sinim*a8
+		a4 := cosim*a9 + sinim*a10
+		a5 := -sinim*a7 + cosim*a8
+		a6 := -sinim*a9 + cosim*a10
+
+		x1 := a1*cosomm + a2*sinomm
+		x2 := a3*cosomm + a4*sinomm
+		x3 := -a1*sinomm + a2*cosomm
+		x4 := -a3*sinomm + a4*cosomm
+		x5 := a5 * sinomm
+		x6 := a6 * sinomm
+		x7 := a5 * cosomm
+		x8 := a6 * cosomm
+
+		z31 := 12*x1*x1 - 3*x3*x3
+		z32 := 24*x1*x2 - 6*x3*x4
+		z33 := 12*x2*x2 - 3*x4*x4
+		z1 := 3*(a1*a1+a2*a2) + z31*emsq
+		z2 := 6*(a1*a3+a2*a4) + z32*emsq
+		z3 := 3*(a3*a3+a4*a4) + z33*emsq
+		z11 := -6*a1*a5 + emsq*(-24*x1*x7-6*x3*x5)
+		z12 := -6*(a1*a6+a3*a5) + emsq*(-24*(x2*x7+x1*x8)-6*(x3*x6+x4*x5))
+		z13 := -6*a3*a6 + emsq*(-24*x2*x8-6*x4*x6)
+		z21 := 6*a2*a5 + emsq*(24*x1*x5-6*x3*x7)
+		z22 := 6*(a4*a5+a2*a6) + emsq*(24*(x2*x5+x1*x6)-6*(x4*x7+x3*x8))
+		z23 := 6*a4*a6 + emsq*(24*x2*x6-6*x4*x8)
+		z1 = z1 + z1 + betasq*z31
+		z2 = z2 + z2 + betasq*z32
+		z3 = z3 + z3 + betasq*z33
+		s3 := cc * xnoi
+		s2 := -0.5 * s3 / rtemsq
+		s4 := s3 * rtemsq
+		s1 := -15 * ep * s4
+		s5 := x1*x3 + x2*x4
+		s6 := x2*x3 + x1*x4
+		s7 := x2*x4 - x1*x3
+
+		if pass == 0 {
+			out.ss1, out.ss2, out.ss3, out.ss4, out.ss5 = s1, s2, s3, s4, s5
+			out.sz1, out.sz3, out.sz11, out.sz13 = z1, z3, z11, z13
+			out.sz21, out.sz23, out.sz31, out.sz33 = z21, z23, z31, z33
+			d.se2 = 2 * s1 * s6
+			d.se3 = 2 * s1 * s7
+			d.si2 = 2 * s2 * z12
+			d.si3 = 2 * s2 * (z13 - z11)
+			d.sl2 = -2 * s3 * z2
+			d.sl3 = -2 * s3 * (z3 - z1)
+			d.sl4 = -2 * s3 * (-21 - 9*emsq) * zes
+			d.sgh2 = 2 * s4 * z32
+			d.sgh3 = 2 * s4 * (z33 - z31)
+			d.sgh4 = -18 * s4 * zes
+			d.sh2 = -2 * s2 * z22
+			d.sh3 = -2 * s2 * (z23 - z21)
+
+			zcosg, zsing, zcosi, zsini = zcosgl, zsingl, zcosil, zsinil
+			zcosh = zcoshl*cnodm + zsinhl*snodm
+			zsinh = snodm*zcoshl - cnodm*zsinhl
+			cc = c1l
+			continue
+		}
+		out.s1, out.s2, out.s3, out.s4, out.s5 = s1, s2, s3, s4, s5
+		out.z1, out.z3, out.z11, out.z13 = z1, z3, z11, z13
+		out.z21, out.z23, out.z31, out.z33 = z21, z23, z31, z33
+		d.ee2 = 2 * s1 * s6
+		d.e3 = 2 * s1 * s7
+		d.xi2 = 2 * s2 * z12
+		d.xi3 = 2 * s2 * (z13 - z11)
+		d.xl2 = -2 * s3 * z2
+		d.xl3 = -2 * s3 * (z3 - z1)
+		d.xl4 = -2 * s3 * (-21 - 9*emsq) * zel
+		d.xgh2 = 2 * s4 * z32
+		d.xgh3 = 2 * s4 * (z33 - z31)
+		d.xgh4 = -18 * s4 * zel
+		d.xh2 = -2 * s2 * z22
+		d.xh3 = -2 * s2 * (z23 - z21)
+	}
+	d.zmol = normalizeAngle(4.7199672 + 0.22997150*day - gam)
+	d.zmos = normalizeAngle(6.2565837 + 0.017201977*day)
+	return out
+}
+
+// resonance computes the lunar-solar secular rates and, for resonant orbits, the geopotential
+// resonance coefficients, as SDP4's dsinit does.
+func (d *deepSpace) resonance(p *SGP4, c lunarSolarTerms, eccsq float64) {
+	const (
+		q22    = 1.7891679e-6
+		q31    = 2.1460748e-6
+		q33    = 2.2123015e-7
+		root22 = 1.7891679e-6
+		root44 = 7.3636953e-9
+		root54 = 2.1765803e-9
+		root32 = 3.7393792e-7
+		root52 = 1.1428639e-7
+		// nearEquatorial is the inclination within which node rates are left out, since the node is
+		// undefined for equatorial orbits.
+		nearEquatorial = 5.2359877e-2
+	)
+	nm, em := p.no, p.ecco
+	sinim, cosim, emsq := c.sinim, c.cosim, c.emsq
+	switch {
+	case nm < 0.0052359877 && nm > 0.0034906585:
+		d.irez = resonanceSynchronous
+	case nm >= 8.26e-3 && nm <= 9.24e-3 && em >= 0.5:
+		d.irez = resonanceHalfDay
+	}
+	equatorial := p.inclo < nearEquatorial || p.inclo > math.Pi-nearEquatorial
+
+	// Solar terms.
+	ses := c.ss1 * zns * c.ss5
+	sis := c.ss2 * zns * (c.sz11 + c.sz13)
+	sls := -zns * c.ss3 * (c.sz1 + c.sz3 - 14 - 6*emsq)
+	sghs := c.ss4 * zns * (c.sz31 + c.sz33 - 6)
+	shs := -zns * c.ss2 * (c.sz21 + c.sz23)
+	if equatorial {
+		shs = 0
+	}
+	if sinim != 0 {
+		shs /= sinim
+	}
+	sgs := sghs - cosim*shs
+
+	// Lunar terms.
+	d.dedt = ses + c.s1*znl*c.s5
+	d.didt = sis + c.s2*znl*(c.z11+c.z13)
+	d.dmdt = sls - znl*c.s3*(c.z1+c.z3-14-6*emsq)
+	sghl := c.s4 * znl * (c.z31 + c.z33 - 6)
+	shll := -znl * c.s2 * (c.z21 + c.z23)
+	if equatorial {
+		shll = 0
+	}
+	d.domdt = sgs + sghl
+	d.dnodt = shs
+	if sinim != 0 {
+		d.domdt -= cosim / sinim * shll
+		d.dnodt += shll / sinim
+	}
+
+	if d.irez == resonanceNone {
+		return
+	}
+	theta := d.gsto
+	aonv := math.Pow(nm/xke(), 2.0/3)
+	xpidot := p.argpdot + p.nodedot
+	if d.irez == resonanceHalfDay {
+		cosisq := cosim * cosim
+		em, emsq := p.ecco, eccsq
+		eoc := em * emsq
+		g201 := -0.306 - (em-0.64)*0.440
+		var g211, g310, g322, g410, g422, g520, g521, g532, g533 float64
+		if em <= 0.65 {
+			g211 = 3.616 - 13.2470*em + 16.2900*emsq
+			g310 = -19.302 + 117.3900*em - 228.4190*emsq + 156.5910*eoc
+			g322 = -18.9068 + 109.7927*em - 214.6334*emsq + 146.5816*eoc
+			g410 = -41.122 + 242.6940*em - 471.0940*emsq + 313.9530*eoc
+			g422 = -146.407 + 841.8800*em - 1629.014*emsq + 1083.4350*eoc
+			g520 = -532.114 + 3017.977*em - 5740.032*emsq + 3708.2760*eoc
+		} else {
+			g211 = -72.099 + 331.819*em - 508.738*emsq + 266.724*eoc
+			g310 = -346.844 + 1582.851*em - 2415.925*emsq + 1246.113*eoc
+			g322 = -342.585 + 1554.908*em - 2366.899*emsq + 1215.972*eoc
+			g410 = -1052.797 + 4758.686*em - 7193.992*emsq + 3651.957*eoc
+			g422 = -3581.690 + 16178.110*em - 24462.770*emsq + 12422.520*eoc
+			if em > 0.715 {
+				g520 = -5149.66 + 29936.92*em - 54087.36*emsq + 31324.56*eoc
+			} else {
+				g520 = 1464.74 - 4664.75*em + 3763.64*emsq
+			}
+		}
+		if em < 0.7 {
+			g533 = -919.22770 + 4988.6100*em - 9064.7700*emsq + 5542.21*eoc
+			g521 = -822.71072 + 4568.6173*em - 8491.4146*emsq + 5337.524*eoc
+			g532 = -853.66600 + 4690.2500*em - 8624.7700*emsq + 5341.4*eoc
+		} else {
+			g533 = -37995.780 + 161616.52*em - 229838.20*emsq + 109377.94*eoc
+			g521 = -51752.104 + 218913.95*em - 309468.16*emsq + 146349.42*eoc
+			g532 = -40023.880 + 170470.89*em - 242699.48*emsq + 115605.82*eoc
+		}
+		sini2 := sinim * sinim
+		f220 := 0.75 * (1 + 2*cosim + cosisq)
+		f221 := 1.5 * sini2
+		f321 := 1.875 * sinim * (1 - 2*cosim - 3*cosisq)
+		f322 := -1.875 * sinim * (1 + 2*cosim - 3*cosisq)
+		f441 := 35 * sini2 * f220
+		f442 := 39.3750 * sini2 * sini2
+		f522 := 9.84375 * sinim * (sini2*(1-2*cosim-5*cosisq) + 0.33333333*(-2+4*cosim+6*cosisq))
+		f523 := sinim * (4.92187512*sini2*(-2-4*cosim+10*cosisq) + 6.56250012*(1+2*cosim-3*cosisq))
+		f542 := 29.53125 * sinim * (2 - 8*cosim + cosisq*(-12+8*cosim+10*cosisq))
+		f543 := 29.53125 * sinim * (-2 - 8*cosim + cosisq*(12+8*cosim-10*cosisq))
+		xno2 := nm * nm
+		ainv2 := aonv * aonv
+		temp1 := 3 * xno2 * ainv2
+		temp := temp1 * root22
+		d.d2201 = temp * f220 * g201
+		d.d2211 = temp * f221 * g211
+		temp1 *= aonv
+		temp = temp1 * root32
+		d.d3210 = temp * f321 * g310
+		d.d3222 = temp * f322 * g322
+		temp1 *= aonv
+		temp = 2 * temp1 * root44
+		d.d4410 = temp * f441 * g410
+		d.d4422 = temp * f442 * g422
+		temp1 *= aonv
+		temp = temp1 * root52
+		d.d5220 = temp * f522 * g520
+		d.d5232 = temp * f523 * g532
+		temp = 2 * temp1 * root54
+		d.d5421 = temp * f542 * g521
+		d.d5433 = temp * f543 * g533
+		d.xlamo = normalizeAngle(p.mo + p.nodeo + p.nodeo - theta - theta)
+		d.xfact = p.mdot + d.dmdt + 2*(p.nodedot+d.dnodt-rptim) - p.no
+		return
+	}
+
+	g200 := 1 + emsq*(-2.5+0.8125*emsq)
+	g310 := 1 + 2*emsq
+	g300 := 1 + emsq*(-6+6.60937*emsq)
+	f220 := 0.75 * (1 + cosim) * (1 + cosim)
+	f311 := 0.9375*sinim*sinim*(1+3*cosim) - 0.75*(1+cosim)
+	f330 := 1 + cosim
+	f330 = 1.875 * f330 * f330 * f330
+	del1 := 3 * nm * nm * aonv * aonv
+	d.del2 = 2 * del1 * f220 * g200 * q22
+	d.del3 = 3 * del1 * f330 * g300 * q33 * aonv
+	d.del1 = del1 * f311 * g310 * q31 * aonv
+	d.xlamo = normalizeAngle(p.mo + p.nodeo + p.argpo - theta)
+	d.xfact = p.mdot + xpidot - rptim + d.dmdt + d.domdt + d.dnodt - p.no
+}
+
+// secular applies the lunar-solar secular rates and integrates the resonance terms to t minutes
+// after epoch, as SDP4's dspace does. It returns the updated eccentricity, argument of perigee,
+// inclination, mean anomaly, node and mean motion.
+func (d *deepSpace) secular(p *SGP4, t, em, argpm, inclm, mm, nodem float64) (float64, float64, float64, float64, float64, float64) {
+	const (
+		fasx2 = 0.13130908
+		fasx4 = 2.8843198
+		fasx6 = 0.37448087
+		g22   = 5.7686396
+		g32   = 0.95240898
+		g44   = 1.8014998
+		g52   = 1.0508330
+		g54   = 4.4108898
+		// stepp is the integration step in minutes, and step2 half its square.
+		stepp = 720.0
+		step2 = 259200.0
+	)
+	theta := normalizeAngle(d.gsto + t*rptim)
+	em += d.dedt * t
+	inclm += d.didt * t
+	argpm += d.domdt * t
+	nodem += d.dnodt * t
+	mm += d.dmdt * t
+	if d.irez == resonanceNone {
+		return em, argpm, inclm, mm, nodem, p.no
+	}
+
+	// Integrate the resonant mean motion and longitude from epoch in half-day steps.
+	delt := stepp
+	if t < 0 {
+		delt = -stepp
+	}
+	atime, xni, xli := 0.0, p.no, d.xlamo
+	var xndt, xldot, xnddt, ft float64
+	for {
+		if d.irez == resonanceSynchronous {
+			xndt = d.del1*math.Sin(xli-fasx2) + d.del2*math.Sin(2*(xli-fasx4)) + d.del3*math.Sin(3*(xli-fasx6))
+			xldot = xni + d.xfact
+			xnddt = d.del1*math.Cos(xli-fasx2) + 2*d.del2*math.Cos(2*(xli-fasx4)) + 3*d.del3*math.Cos(3*(xli-fasx6))
+			xnddt *= xldot
+		} else {
+			xomi := p.argpo + p.argpdot*atime
+			x2omi := xomi + xomi
+			x2li := xli + xli
+			xndt = d.d2201*math.Sin(x2omi+xli-g22) + d.d2211*math.Sin(xli-g22) +
+				d.d3210*math.Sin(xomi+xli-g32) + d.d3222*math.Sin(-xomi+xli-g32) +
+				d.d4410*math.Sin(x2omi+x2li-g44) + d.d4422*math.Sin(x2li-g44) +
+				d.d5220*math.Sin(xomi+xli-g52) + d.d5232*math.Sin(-xomi+xli-g52) +
+				d.d5421*math.Sin(xomi+x2li-g54) + d.d5433*math.Sin(-xomi+x2li-g54)
+			xldot = xni + d.xfact
+			xnddt = d.d2201*math.Cos(x2omi+xli-g22) + d.d2211*math.Cos(xli-g22) +
+				d.d3210*math.Cos(xomi+xli-g32) + d.d3222*math.Cos(-xomi+xli-g32) +
+				d.d5220*math.Cos(xomi+xli-g52) + d.d5232*math.Cos(-xomi+xli-g52) +
+				2*(d.d4410*math.Cos(x2omi+x2li-g44)+d.d4422*math.Cos(x2li-g44)+
+					d.d5421*math.Cos(xomi+x2li-g54)+d.d5433*math.Cos(-xomi+x2li-g54))
+			xnddt *= xldot
+		}
+		if math.Abs(t-atime) < stepp {
+			ft = t - atime
+			break
+		}
+		xli += xldot*delt + xndt*step2
+		xni += xndt*delt + xnddt*step2
+		atime += delt
+	}
+	nm := xni + xndt*ft + xnddt*ft*ft*0.5
+	xl := xli + xldot*ft + xndt*ft*ft*0.5
+	if d.irez == resonanceSynchronous {
+		mm = xl - nodem - argpm + theta
+	} else {
+		mm = xl - 2*nodem + 2*theta
+	}
+	return em, argpm, inclm, mm, nodem, nm
+}
+
+// periodics adds the lunar-solar periodic perturbations at t minutes after epoch to the elements,
+// as SDP4's dpper does, using Lyddane's modification for inclinations below 0.2 radians. It returns
+// the perturbed eccentricity, inclination, node, argument of perigee and mean anomaly.
+func (d *deepSpace) periodics(t, ep, inclp, nodep, argpp, mp float64) (float64, float64, float64, float64, float64) {
+	zm := d.zmos + zns*t
+	zf := zm + 2*zes*math.Sin(zm)
+	sinzf, coszf := math.Sincos(zf)
+	f2 := 0.5*sinzf*sinzf - 0.25
+	f3 := -0.5 * sinzf * coszf
+	ses := d.se2*f2 + d.se3*f3
+	sis := d.si2*f2 + d.si3*f3
+	sls := d.sl2*f2 + d.sl3*f3 + d.sl4*sinzf
+	sghs := d.sgh2*f2 + d.sgh3*f3 + d.sgh4*sinzf
+	shs := d.sh2*f2 + d.sh3*f3
+
+	zm = d.zmol + znl*t
+	zf = zm + 2*zel*math.Sin(zm)
+	sinzf, coszf = math.Sincos(zf)
+	f2 = 0.5*sinzf*sinzf - 0.25
+	f3 = -0.5 * sinzf * coszf
+	sel := d.ee2*f2 + d.e3*f3
+	sil := d.xi2*f2 + d.xi3*f3
+	sll := d.xl2*f2 + d.xl3*f3 + d.xl4*sinzf
+	sghl := d.xgh2*f2 + d.xgh3*f3 + d.xgh4*sinzf
+	shll := d.xh2*f2 + d.xh3*f3
+
+	pe := ses + sel
+	pinc := sis + sil
+	pl := sls + sll
+	pgh := sghs + sghl
+	ph := shs + shll
+
+	inclp += pinc
+	ep += pe
+	sinip, cosip := math.Sincos(inclp)
+	if inclp >= 0.2 {
+		ph /= sinip
+		pgh -= cosip * ph
+		return ep, inclp, nodep + ph, argpp + pgh, mp + pl
+	}
+
+	// Lyddane's modification, which stays defined as the node becomes undefined.
+	sinop, cosop := math.Sincos(nodep)
+	alfdp := sinip*sinop + ph*cosop + pinc*cosip*sinop
+	betdp := sinip*cosop - ph*sinop + pinc*cosip*cosop
+	nodep = math.Mod(nodep, twoPi)
+	xls := mp + argpp + cosip*nodep
+	xls += pl + pgh - pinc*nodep*sinip
+	xnoh := nodep
+	nodep = math.Atan2(alfdp, betdp)
+	if math.Abs(xnoh-nodep) > math.Pi {
+		if nodep < xnoh {
+			nodep += twoPi
+		} else {
+			nodep -= twoPi
+		}
+	}
+	mp += pl
+	argpp = xls - mp - cosip*nodep
+	return ep, inclp, nodep, argpp, mp
+}
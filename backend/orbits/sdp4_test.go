@@ -0,0 +1,84 @@
+package orbits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// molniyaTLE is satellite 08195 from the SGP4 verification suite, a 12-hour resonant orbit.
+var molniyaTLE = TLEElements{
+	Epoch:                time.Date(2006, 6, 25, 7, 58, 18, 143616000, time.UTC),
+	InclinationDeg:       64.1586,
+	RAANDeg:              279.0717,
+	Eccentricity:         0.6877146,
+	ArgumentOfPerigeeDeg: 264.7651,
+	MeanAnomalyDeg:       20.2257,
+	MeanMotion:           2.00491383,
+	BStar:                0.11873e-3,
+}
+
+func TestSDP4HalfDayResonance(t *testing.T) {
+	p, err := NewSGP4(molniyaTLE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.DeepSpace() || p.deep.irez != resonanceHalfDay {
+		t.Fatal("a 12-hour eccentric orbit should use the half-day resonance")
+	}
+	state, err := p.StateAt(molniyaTLE.Epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := visibility.SlantRange(state.Position, visibility.Vector3{X: 2349.89483350, Y: -14785.93811562, Z: 0.02119378}); d > 1e-5 {
+		t.Fatalf("position %+v is %v km from the verification vector", state.Position, d)
+	}
+
+	// The orbit stays between its perigee and apogee, a little over 26,500 km from the center on
+	// average, forwards and backwards through the integrator's half-day steps.
+	a := molniyaTLE.Mean().SemiMajorAxis
+	for _, hours := range []float64{-36, 5, 30, 24 * 7} {
+		state, err := p.StateAt(molniyaTLE.Epoch.Add(time.Duration(hours * float64(time.Hour))))
+		if err != nil {
+			t.Fatalf("%vh: %v", hours, err)
+		}
+		r := norm(state.Position)
+		if r < a*(1-molniyaTLE.Eccentricity)-50 || r > a*(1+molniyaTLE.Eccentricity)+50 {
+			t.Fatalf("%vh: radius %v km outside the orbit", hours, r)
+		}
+	}
+}
+
+func TestSDP4SynchronousOrbit(t *testing.T) {
+	geo := TLEElements{
+		Epoch:          time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		InclinationDeg: 0.05,
+		RAANDeg:        80,
+		Eccentricity:   0.0002,
+		MeanAnomalyDeg: 120,
+		MeanMotion:     1.0027,
+	}
+	p, err := NewSGP4(geo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.DeepSpace() || p.deep.irez != resonanceSynchronous {
+		t.Fatal("a one-day orbit should use the synchronous resonance")
+	}
+	start, _ := p.StateAt(geo.Epoch)
+	for day := 1; day <= 10; day++ {
+		state, err := p.StateAt(geo.Epoch.Add(time.Duration(day) * 24 * time.Hour))
+		if err != nil {
+			t.Fatalf("day %d: %v", day, err)
+		}
+		if r := norm(state.Position); r < 42100 || r > 42230 {
+			t.Fatalf("day %d: radius %v km is not geosynchronous", day, r)
+		}
+		// A sidereal day is about four minutes short of a solar one, so the satellite is a degree
+		// ahead of where it started each day.
+		if d := visibility.SlantRange(state.Position, start.Position); d > float64(day)*1500 {
+			t.Fatalf("day %d: drifted %v km from its starting point", day, d)
+		}
+	}
+}
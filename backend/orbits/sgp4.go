@@ -0,0 +1,349 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/timescale"
+)
+
+// Further WGS-72 zonal harmonics used by SGP4.
+const (
+	wgs72J3 = -0.00000253881
+	wgs72J4 = -0.00000165597
+)
+
+const (
+	// deepSpacePeriodMin is the orbital period, in minutes, from which SDP4's lunar-solar and
+	// resonance terms apply.
+	deepSpacePeriodMin = 225.0
+	// sgp4EpochJD is the Julian date SGP4 counts its epoch days from, 1949 December 31 0h UT.
+	sgp4EpochJD = 2433281.5
+	// keplerIterations caps the Newton iteration for the long-period-corrected Kepler equation.
+	sgp4KeplerIterations = 10
+)
+
+// Errors reported by SGP4 when the elements or the propagated orbit leave the model's domain.
+var (
+	ErrMeanElements = errors.New("sgp4: mean elements out of range")
+	ErrDecayed      = errors.New("sgp4: satellite has decayed")
+)
+
+// SGP4 propagates a two-line element set with the SGP4 model, following Vallado et al.'s 2006
+// revision of Spacetrack Report #3. Orbits with periods of deepSpacePeriodMin or more use the SDP4
+// extension, which adds lunar-solar perturbations and the 12- and 24-hour resonances. States are in
+// the TEME frame of the element set, in kilometers and kilometers per second.
+//
+// Unlike the reference code, a propagator keeps no state between calls, so it may be shared between
+// goroutines and StateAt may be called at any time in any order.
+type SGP4 struct {
+	epoch time.Time
+	bstar float64
+	// Mean elements at epoch, with the Kozai mean motion converted to Brouwer's, in radians per
+	// minute and Earth radii.
+	ecco, argpo, inclo, mo, no, nodeo float64
+	ao                                float64
+
+	isimp                                     bool
+	con41, x1mth2, x7thm1                     float64
+	cc1, cc4, cc5, d2, d3, d4                 float64
+	delmo, eta, sinmao, omgcof, xmcof, nodecf float64
+	t2cof, t3cof, t4cof, t5cof, xlcof, aycof  float64
+	mdot, argpdot, nodedot                    float64
+	deep                                      *deepSpace
+}
+
+// NewSGP4 initializes SGP4 for an element set, failing when its mean elements are outside the
+// model's domain.
+func NewSGP4(tle TLEElements) (*SGP4, error) {
+	const degToRad = math.Pi / 180
+	p := &SGP4{
+		epoch: tle.Epoch,
+		bstar: tle.BStar,
+		ecco:  tle.Eccentricity,
+		argpo: tle.ArgumentOfPerigeeDeg * degToRad,
+		inclo: tle.InclinationDeg * degToRad,
+		mo:    tle.MeanAnomalyDeg * degToRad,
+		nodeo: tle.RAANDeg * degToRad,
+	}
+	if !(tle.Eccentricity >= 0 && tle.Eccentricity < 1) || !(tle.MeanMotion > 0) {
+		return nil, ErrMeanElements
+	}
+	for _, v := range []float64{tle.MeanMotion, tle.BStar, tle.RAANDeg, tle.ArgumentOfPerigeeDeg, tle.MeanAnomalyDeg} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, ErrMeanElements
+		}
+	}
+	if !(tle.InclinationDeg >= 0 && tle.InclinationDeg <= 180) {
+		return nil, ErrMeanElements
+	}
+
+	const (
+		ss     = 78/wgs72RadiusKm + 1
+		j2     = wgs72J2
+		j3oj2  = wgs72J3 / wgs72J2
+		x2o3   = 2.0 / 3
+		temp4  = 1.5e-12
+		qzms2t = (120 - 78) / wgs72RadiusKm
+	)
+	xke := xke()
+
+	// Recover Brouwer's mean motion and semi-major axis from the Kozai mean motion.
+	kozai := tle.MeanMotion * twoPi / minutesPerDay
+	eccsq := p.ecco * p.ecco
+	omeosq := 1 - eccsq
+	rteosq := math.Sqrt(omeosq)
+	cosio := math.Cos(p.inclo)
+	cosio2 := cosio * cosio
+	ak := math.Pow(xke/kozai, x2o3)
+	d1 := 0.75 * j2 * (3*cosio2 - 1) / (rteosq * omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1 - del*del - del*(1.0/3+134*del*del/81))
+	del = d1 / (adel * adel)
+	p.no = kozai / (1 + del)
+	p.ao = math.Pow(xke/p.no, x2o3)
+	sinio := math.Sin(p.inclo)
+	po := p.ao * omeosq
+	con42 := 1 - 5*cosio2
+	p.con41 = -con42 - cosio2 - cosio2
+	posq := po * po
+	rp := p.ao * (1 - p.ecco)
+
+	p.isimp = rp < 220/wgs72RadiusKm+1
+	sfour := ss
+	qzms24 := math.Pow(qzms2t, 4)
+	if perigee := (rp - 1) * wgs72RadiusKm; perigee < 156 {
+		sfour = perigee - 78
+		if perigee < 98 {
+			sfour = 20
+		}
+		qzms24 = math.Pow((120-sfour)/wgs72RadiusKm, 4)
+		sfour = sfour/wgs72RadiusKm + 1
+	}
+	pinvsq := 1 / posq
+	tsi := 1 / (p.ao - sfour)
+	p.eta = p.ao * p.ecco * tsi
+	etasq := p.eta * p.eta
+	eeta := p.ecco * p.eta
+	psisq := math.Abs(1 - etasq)
+	coef := qzms24 * math.Pow(tsi, 4)
+	coef1 := coef / math.Pow(psisq, 3.5)
+	cc2 := coef1 * p.no * (p.ao*(1+1.5*etasq+eeta*(4+etasq)) +
+		0.375*j2*tsi/psisq*p.con41*(8+3*etasq*(8+etasq)))
+	p.cc1 = p.bstar * cc2
+	cc3 := 0.0
+	if p.ecco > 1e-4 {
+		cc3 = -2 * coef * tsi * j3oj2 * p.no * sinio / p.ecco
+	}
+	p.x1mth2 = 1 - cosio2
+	p.cc4 = 2 * p.no * coef1 * p.ao * omeosq * (p.eta*(2+0.5*etasq) + p.ecco*(0.5+2*etasq) -
+		j2*tsi/(p.ao*psisq)*(-3*p.con41*(1-2*eeta+etasq*(1.5-0.5*eeta))+
+			0.75*p.x1mth2*(2*etasq-eeta*(1+etasq))*math.Cos(2*p.argpo)))
+	p.cc5 = 2 * coef1 * p.ao * omeosq * (1 + 2.75*(etasq+eeta) + eeta*etasq)
+	cosio4 := cosio2 * cosio2
+	temp1 := 1.5 * j2 * pinvsq * p.no
+	temp2 := 0.5 * temp1 * j2 * pinvsq
+	temp3 := -0.46875 * wgs72J4 * pinvsq * pinvsq * p.no
+	p.mdot = p.no + 0.5*temp1*rteosq*p.con41 + 0.0625*temp2*rteosq*(13-78*cosio2+137*cosio4)
+	p.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7-114*cosio2+395*cosio4) +
+		temp3*(3-36*cosio2+49*cosio4)
+	xhdot1 := -temp1 * cosio
+	p.nodedot = xhdot1 + (0.5*temp2*(4-19*cosio2)+2*temp3*(3-7*cosio2))*cosio
+	p.omgcof = p.bstar * cc3 * math.Cos(p.argpo)
+	if p.ecco > 1e-4 {
+		p.xmcof = -x2o3 * coef * p.bstar / eeta
+	}
+	p.nodecf = 3.5 * omeosq * xhdot1 * p.cc1
+	p.t2cof = 1.5 * p.cc1
+	// Guard the division for inclinations of exactly 180°.
+	p.xlcof = -0.25 * j3oj2 * sinio * (3 + 5*cosio) / math.Max(math.Abs(1+cosio), temp4)
+	p.aycof = -0.5 * j3oj2 * sinio
+	p.delmo = math.Pow(1+p.eta*math.Cos(p.mo), 3)
+	p.sinmao = math.Sin(p.mo)
+	p.x7thm1 = 7*cosio2 - 1
+
+	if twoPi/p.no >= deepSpacePeriodMin {
+		p.isimp = true
+		epochDays := timescale.JulianDate(p.epoch) - sgp4EpochJD
+		p.deep = newDeepSpace(p, epochDays, eccsq)
+	}
+	if !p.isimp {
+		cc1sq := p.cc1 * p.cc1
+		p.d2 = 4 * p.ao * tsi * cc1sq
+		temp := p.d2 * tsi * p.cc1 / 3
+		p.d3 = (17*p.ao + sfour) * temp
+		p.d4 = 0.5 * temp * p.ao * tsi * (221*p.ao + 31*sfour) * p.cc1
+		p.t3cof = p.d2 + 2*cc1sq
+		p.t4cof = 0.25 * (3*p.d3 + p.cc1*(12*p.d2+10*cc1sq))
+		p.t5cof = 0.2 * (3*p.d4 + 12*p.cc1*p.d3 + 6*p.d2*p.d2 + 15*cc1sq*(2*p.d2+cc1sq))
+	}
+
+	if _, err := p.propagate(0); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DeepSpace reports whether the propagator uses the SDP4 deep-space terms.
+func (p *SGP4) DeepSpace() bool {
+	return p.deep != nil
+}
+
+// Epoch returns the element set's epoch.
+func (p *SGP4) Epoch() time.Time {
+	return p.epoch
+}
+
+// StateAt propagates the element set to t, before or after its epoch. Elapsed time counts any leap
+// seconds between the epoch and t.
+func (p *SGP4) StateAt(t time.Time) (StateVector, error) {
+	return p.propagate(timescale.Elapsed(p.epoch, t).Minutes())
+}
+
+// propagate returns the state tsince minutes after epoch.
+func (p *SGP4) propagate(tsince float64) (StateVector, error) {
+	const (
+		j2    = wgs72J2
+		j3oj2 = wgs72J3 / wgs72J2
+		x2o3  = 2.0 / 3
+		temp4 = 1.5e-12
+	)
+	xke := xke()
+	vkmpersec := wgs72RadiusKm * xke / 60
+	t := tsince
+
+	// Secular gravity and atmospheric drag.
+	xmdf := p.mo + p.mdot*t
+	argpdf := p.argpo + p.argpdot*t
+	nodedf := p.nodeo + p.nodedot*t
+	argpm, mm := argpdf, xmdf
+	t2 := t * t
+	nodem := nodedf + p.nodecf*t2
+	tempa := 1 - p.cc1*t
+	tempe := p.bstar * p.cc4 * t
+	templ := p.t2cof * t2
+	if !p.isimp {
+		delomg := p.omgcof * t
+		delm := p.xmcof * (math.Pow(1+p.eta*math.Cos(xmdf), 3) - p.delmo)
+		temp := delomg + delm
+		mm = xmdf + temp
+		argpm = argpdf - temp
+		t3 := t2 * t
+		t4 := t3 * t
+		tempa = tempa - p.d2*t2 - p.d3*t3 - p.d4*t4
+		tempe += p.bstar * p.cc5 * (math.Sin(mm) - p.sinmao)
+		templ += p.t3cof*t3 + t4*(p.t4cof+t*p.t5cof)
+	}
+	nm, em, inclm := p.no, p.ecco, p.inclo
+	if p.deep != nil {
+		em, argpm, inclm, mm, nodem, nm = p.deep.secular(p, t, em, argpm, inclm, mm, nodem)
+	}
+	if nm <= 0 {
+		return StateVector{}, errors.New("sgp4: mean motion is no longer positive")
+	}
+	am := math.Pow(xke/nm, x2o3) * tempa * tempa
+	nm = xke / math.Pow(am, 1.5)
+	em -= tempe
+	if em >= 1 || em < -0.001 {
+		return StateVector{}, errors.New("sgp4: mean eccentricity out of range")
+	}
+	if em < 1e-6 {
+		em = 1e-6
+	}
+	mm += p.no * templ
+	xlm := mm + argpm + nodem
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+
+	// Lunar-solar periodics.
+	ep, xincp, argpp, nodep, mp := em, inclm, argpm, nodem, mm
+	sinip, cosip := math.Sincos(inclm)
+	con41, x1mth2, x7thm1 := p.con41, p.x1mth2, p.x7thm1
+	aycof, xlcof := p.aycof, p.xlcof
+	if p.deep != nil {
+		ep, xincp, nodep, argpp, mp = p.deep.periodics(t, ep, xincp, nodep, argpp, mp)
+		if xincp < 0 {
+			xincp = -xincp
+			nodep += math.Pi
+			argpp -= math.Pi
+		}
+		if ep < 0 || ep > 1 {
+			return StateVector{}, errors.New("sgp4: perturbed eccentricity out of range")
+		}
+		sinip, cosip = math.Sincos(xincp)
+		aycof = -0.5 * j3oj2 * sinip
+		xlcof = -0.25 * j3oj2 * sinip * (3 + 5*cosip) / math.Max(math.Abs(1+cosip), temp4)
+		cosisq := cosip * cosip
+		con41, x1mth2, x7thm1 = 3*cosisq-1, 1-cosisq, 7*cosisq-1
+	}
+
+	// Long-period periodics.
+	axnl := ep * math.Cos(argpp)
+	temp := 1 / (am * (1 - ep*ep))
+	aynl := ep*math.Sin(argpp) + temp*aycof
+	xl := mp + argpp + nodep + temp*xlcof*axnl
+
+	// Kepler's equation in the modified eccentric longitude.
+	u := math.Mod(xl-nodep, twoPi)
+	eo1 := u
+	var sineo1, coseo1 float64
+	for i, step := 0, math.Inf(1); math.Abs(step) >= 1e-12 && i < sgp4KeplerIterations; i++ {
+		sineo1, coseo1 = math.Sincos(eo1)
+		step = (u - aynl*coseo1 + axnl*sineo1 - eo1) / (1 - coseo1*axnl - sineo1*aynl)
+		if math.Abs(step) >= 0.95 {
+			step = math.Copysign(0.95, step)
+		}
+		eo1 += step
+	}
+
+	// Short-period periodics.
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1 - el2)
+	if pl < 0 {
+		return StateVector{}, errors.New("sgp4: semi-latus rectum is negative")
+	}
+	rl := am * (1 - ecose)
+	rdotl := math.Sqrt(am) * esine / rl
+	rvdotl := math.Sqrt(pl) / rl
+	betal := math.Sqrt(1 - el2)
+	temp = esine / (1 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := (cosu + cosu) * sinu
+	cos2u := 1 - 2*sinu*sinu
+	temp = 1 / pl
+	temp1 := 0.5 * j2 * temp
+	temp2 := temp1 * temp
+
+	mrt := rl*(1-1.5*temp2*betal*con41) + 0.5*temp1*x1mth2*cos2u
+	su -= 0.25 * temp2 * x7thm1 * sin2u
+	xnode := nodep + 1.5*temp2*cosip*sin2u
+	xinc := xincp + 1.5*temp2*cosip*sinip*cos2u
+	mvt := rdotl - nm*temp1*x1mth2*sin2u/xke
+	rvdot := rvdotl + nm*temp1*(x1mth2*cos2u+1.5*con41)/xke
+	if mrt < 1 {
+		return StateVector{}, ErrDecayed
+	}
+
+	sinsu, cossu := math.Sincos(su)
+	snod, cnod := math.Sincos(xnode)
+	sini, cosi := math.Sincos(xinc)
+	xmx := -snod * cosi
+	xmy := cnod * cosi
+	uv := [3]float64{xmx*sinsu + cnod*cossu, xmy*sinsu + snod*cossu, sini * sinsu}
+	vv := [3]float64{xmx*cossu - cnod*sinsu, xmy*cossu - snod*sinsu, sini * cossu}
+	var state StateVector
+	state.Position.X = mrt * uv[0] * wgs72RadiusKm
+	state.Position.Y = mrt * uv[1] * wgs72RadiusKm
+	state.Position.Z = mrt * uv[2] * wgs72RadiusKm
+	state.Velocity.X = (mvt*uv[0] + rvdot*vv[0]) * vkmpersec
+	state.Velocity.Y = (mvt*uv[1] + rvdot*vv[1]) * vkmpersec
+	state.Velocity.Z = (mvt*uv[2] + rvdot*vv[2]) * vkmpersec
+	return state, nil
+}
@@ -0,0 +1,106 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// vanguardTLE is satellite 00005 from the SGP4 verification suite, a near-Earth eccentric orbit.
+var vanguardTLE = TLEElements{
+	Epoch:                time.Date(2000, 6, 27, 18, 50, 19, 733568000, time.UTC),
+	InclinationDeg:       34.2682,
+	RAANDeg:              348.7242,
+	Eccentricity:         0.1859667,
+	ArgumentOfPerigeeDeg: 331.7664,
+	MeanAnomalyDeg:       19.3264,
+	MeanMotion:           10.82419157,
+	BStar:                0.28098e-4,
+}
+
+func assertState(t *testing.T, name string, got StateVector, position, velocity visibility.Vector3) {
+	t.Helper()
+	if d := visibility.SlantRange(got.Position, position); d > 1e-5 {
+		t.Fatalf("%s: position %+v is %v km from %+v", name, got.Position, d, position)
+	}
+	if d := visibility.SlantRange(got.Velocity, velocity); d > 1e-7 {
+		t.Fatalf("%s: velocity %+v is %v km/s from %+v", name, got.Velocity, d, velocity)
+	}
+}
+
+func TestSGP4MatchesVerificationVectors(t *testing.T) {
+	p, err := NewSGP4(vanguardTLE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DeepSpace() {
+		t.Fatal("a 133-minute orbit should use the near-Earth model")
+	}
+	state, err := p.StateAt(vanguardTLE.Epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertState(t, "epoch", state,
+		visibility.Vector3{X: 7022.46529266, Y: -1400.08296755, Z: 0.03995155},
+		visibility.Vector3{X: 1.893841015, Y: 6.405893759, Z: 4.534807250})
+	state, err = p.StateAt(vanguardTLE.Epoch.Add(6 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertState(t, "360 min", state,
+		visibility.Vector3{X: -7154.03120202, Y: -3783.17682504, Z: -3536.19412294},
+		visibility.Vector3{X: 4.741887409, Y: -4.151817765, Z: -2.093935425})
+}
+
+func TestSGP4AgreesWithMeanToOsculating(t *testing.T) {
+	// Without drag, SGP4 at epoch is the mean elements plus the short-period J2 terms, apart from
+	// the J3 long-period terms MeanToOsculating leaves out.
+	tle := issTLE
+	tle.BStar = 0
+	p, err := NewSGP4(tle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := p.StateAt(tle.Epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	osculating, err := MeanToOsculating(tle.Mean())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := visibility.SlantRange(state.Position, osculating.StateAt(tle.Epoch).Position); d > 10 {
+		t.Fatalf("SGP4 and the osculating elements differ by %v km at epoch", d)
+	}
+}
+
+func TestSGP4RejectsOutOfRangeElements(t *testing.T) {
+	for name, tle := range map[string]TLEElements{
+		"hyperbolic":       {Eccentricity: 1.2, MeanMotion: 15},
+		"no mean motion":   {Eccentricity: 0.001},
+		"bad inclination":  {Eccentricity: 0.001, MeanMotion: 15, InclinationDeg: 190},
+		"perigee too deep": {Eccentricity: 0.05, MeanMotion: 16, InclinationDeg: 51},
+		"infinite motion":  {Eccentricity: 0.001, MeanMotion: math.Inf(1)},
+		"NaN node":         {Eccentricity: 0.001, MeanMotion: 15, RAANDeg: math.NaN()},
+	} {
+		if _, err := NewSGP4(tle); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		} else if name == "perigee too deep" && !errors.Is(err, ErrDecayed) {
+			t.Fatalf("%s: expected ErrDecayed, got %v", name, err)
+		}
+	}
+
+	// Drag eventually brings a low orbit down.
+	low := vanguardTLE
+	low.MeanMotion, low.Eccentricity, low.BStar = 16.2, 0.001, 0.01
+	p, err := NewSGP4(low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.StateAt(low.Epoch.Add(365 * 24 * time.Hour)); err == nil {
+		t.Fatal("expected a heavily dragged orbit to decay within a year")
+	}
+}
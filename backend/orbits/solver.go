@@ -0,0 +1,100 @@
+package orbits
+
+import "math"
+
+const (
+	// keplerTolerance is the correction, relative to the anomaly, below which the next quartic step
+	// could not change the result in double precision.
+	keplerTolerance = 1e-14
+	// keplerIterations caps iteration; Danby's starter needs at most a handful even as e approaches 1.
+	keplerIterations = 20
+	// smallAngleSeries is the |E| below which E - sin E is summed as a series, where the direct
+	// difference cancels.
+	smallAngleSeries = 0.25
+	// highEccentricity is where Danby's starter, being a fixed offset from M, lands far from the
+	// root just past periapsis and the cubic starter takes over.
+	highEccentricity = 0.95
+)
+
+// KeplerSolution reports how Kepler's equation M = E - e sin E was solved, so that callers working
+// with highly eccentric orbits can tell a converged anomaly from a best effort.
+type KeplerSolution struct {
+	EccentricAnomaly float64 // radians in [0, 2π)
+	Iterations       int
+	// Residual is |E - e sin E - M| at the returned anomaly, in radians.
+	Residual  float64
+	Converged bool
+}
+
+// SolveKepler solves Kepler's equation for 0 <= e < 1 with Danby's starter, E0 = M + 0.85 e sign(M)
+// on [-π, π], and his quartically convergent correction. Above e = 0.95 anomalies near periapsis
+// start instead from the root of the cubic approximation, in the manner of Markley's starter.
+// Unlike Newton-Raphson from E0 = M, the iteration cannot stall near periapsis as e approaches 1.
+// The residual and the derivative are evaluated in cancellation-free forms there, so the result
+// stays accurate for the near-parabolic eccentricities of HEO studies.
+func SolveKepler(meanAnomaly, eccentricity float64) KeplerSolution {
+	e := eccentricity
+	// Kepler's equation is odd, so solve on [-π, π] where the starter's offset follows M's sign.
+	// Reducing M directly, rather than through [0, 2π), keeps small negative anomalies exact.
+	M := math.Remainder(meanAnomaly, twoPi)
+	if e == 0 || M == 0 {
+		return KeplerSolution{EccentricAnomaly: normalizeAngle(M), Converged: true}
+	}
+
+	solution := KeplerSolution{}
+	E := M + math.Copysign(0.85*e, M)
+	if e > highEccentricity {
+		if cubic := cubicStarter(M, e); math.Abs(cubic) < 1 {
+			E = cubic
+		}
+	}
+	for solution.Iterations < keplerIterations {
+		solution.Iterations++
+		sinE, cosE := math.Sincos(E)
+		f := keplerResidual(E, e, M)
+		// 1 - e cos E, written so that it keeps its precision when both e and cos E are near 1.
+		f1 := (1 - e) + 2*e*math.Pow(math.Sin(E/2), 2)
+		f2 := e * sinE
+		f3 := e * cosE
+		d1 := -f / f1
+		d2 := -f / (f1 + d1*f2/2)
+		d3 := -f / (f1 + d2*f2/2 + d2*d2*f3/6)
+		E += d3
+		if math.Abs(d3) <= keplerTolerance*math.Max(1, math.Abs(E)) {
+			solution.Converged = true
+			break
+		}
+	}
+	solution.Residual = math.Abs(keplerResidual(E, e, M))
+	solution.EccentricAnomaly = normalizeAngle(E)
+	return solution
+}
+
+// cubicStarter solves (1 - e) E + e E³/6 = M, Kepler's equation with sin E truncated after its cubic
+// term, in closed form. It is close to the root wherever |E| is well below 1.
+func cubicStarter(M, e float64) float64 {
+	p := 6 * (1 - e) / e
+	q := 6 * math.Abs(M) / e
+	w := math.Cbrt(q/2 + math.Sqrt(q*q/4+p*p*p/27))
+	return math.Copysign(w-p/(3*w), M)
+}
+
+// keplerResidual evaluates E - e sin E - M as (1 - e) E + e (E - sin E) - M, which avoids the
+// cancellation of the direct form for small E and e near 1.
+func keplerResidual(E, e, M float64) float64 {
+	return (1-e)*E + e*eMinusSin(E) - M
+}
+
+// eMinusSin returns E - sin E, summing its Taylor series for small |E|.
+func eMinusSin(E float64) float64 {
+	if math.Abs(E) >= smallAngleSeries {
+		return E - math.Sin(E)
+	}
+	term := E * E * E / 6
+	sum := term
+	for k := 2; math.Abs(term) > 1e-18*math.Abs(sum); k++ {
+		term *= -E * E / float64((2*k)*(2*k+1))
+		sum += term
+	}
+	return sum
+}
@@ -0,0 +1,118 @@
+package orbits
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// keplerCase draws mean anomalies over several revolutions in both directions and eccentricities
+// weighted towards the near-parabolic end, where solvers struggle.
+type keplerCase struct {
+	M, E float64
+}
+
+func (keplerCase) Generate(rng *rand.Rand, _ int) reflect.Value {
+	e := 1 - math.Pow(10, -12*rng.Float64())
+	if rng.Intn(4) == 0 {
+		e = rng.Float64()
+	}
+	M := (rng.Float64()*2 - 1) * 3 * twoPi
+	if rng.Intn(4) == 0 {
+		// Mean anomalies just past periapsis, the hardest region for high eccentricity.
+		M = math.Copysign(math.Pow(10, -10*rng.Float64()), M)
+	}
+	return reflect.ValueOf(keplerCase{M: M, E: e})
+}
+
+func angularDistance(a, b float64) float64 {
+	d := math.Abs(normalizeAngle(a) - normalizeAngle(b))
+	return math.Min(d, twoPi-d)
+}
+
+var quickConfig = &quick.Config{MaxCount: 20000, Rand: rand.New(rand.NewSource(1))}
+
+func TestSolveKeplerConvergesEverywhere(t *testing.T) {
+	property := func(c keplerCase) bool {
+		solution := SolveKepler(c.M, c.E)
+		if !solution.Converged || solution.Iterations > 6 || solution.Residual > 1e-14 {
+			t.Logf("M=%g e=%.15f: %+v", c.M, c.E, solution)
+			return false
+		}
+		return solution.EccentricAnomaly >= 0 && solution.EccentricAnomaly < twoPi
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSolveKeplerRoundTripsMeanAnomaly(t *testing.T) {
+	property := func(c keplerCase) bool {
+		E := EccentricAnomalyFromMean(c.M, c.E)
+		return angularDistance(MeanAnomalyFromEccentric(E, c.E), c.M) <= 1e-13
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSolveKeplerIsOddAndMonotonic(t *testing.T) {
+	property := func(c keplerCase) bool {
+		M := normalizeAngle(c.M)
+		E := EccentricAnomalyFromMean(M, c.E)
+		mirrored := EccentricAnomalyFromMean(-M, c.E)
+		if angularDistance(E, -mirrored) > 1e-12 {
+			return false
+		}
+		// Kepler's equation is increasing in E, so a later mean anomaly never gives an earlier
+		// eccentric anomaly within a revolution.
+		later := M + 1e-3
+		if later >= twoPi {
+			return true
+		}
+		return EccentricAnomalyFromMean(later, c.E) >= E
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSolveKeplerNearParabolic(t *testing.T) {
+	for _, c := range []struct{ M, e float64 }{
+		{1e-9, 0.999999999},
+		{1e-6, 0.9999},
+		{math.Pi, 0.9999999999},
+		{twoPi - 1e-8, 0.99999},
+	} {
+		solution := SolveKepler(c.M, c.e)
+		if !solution.Converged {
+			t.Fatalf("M=%g e=%g did not converge: %+v", c.M, c.e, solution)
+		}
+		// Recover M with the same cancellation-free residual and compare relative to M itself.
+		E := solution.EccentricAnomaly
+		if E > math.Pi {
+			E -= twoPi
+		}
+		M := c.M
+		if M > math.Pi {
+			M -= twoPi
+		}
+		if got := keplerResidual(E, c.e, 0); math.Abs(got-M) > 1e-12*math.Abs(M) {
+			t.Fatalf("M=%g e=%g: recovered mean anomaly %g", c.M, c.e, got)
+		}
+	}
+}
+
+func TestEMinusSinMatchesDirectForm(t *testing.T) {
+	for _, E := range []float64{-0.249, -1e-3, 0.1, 0.2499, 0.25, 1, 3} {
+		if got, want := eMinusSin(E), E-math.Sin(E); math.Abs(got-want) > 1e-15 {
+			t.Fatalf("E - sin E at %v: series %v, direct %v", E, got, want)
+		}
+	}
+	// Where the direct form cancels, the series keeps the leading E³/6 term.
+	if got := eMinusSin(1e-6); math.Abs(got-1e-18/6) > 1e-30 {
+		t.Fatalf("E - sin E at 1e-6 = %v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/example/satnet/backend/timescale"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// batchChunk is the smallest number of satellites worth handing to a goroutine; smaller batches are
+// propagated on the calling goroutine.
+const batchChunk = 256
+
+// StateVector is a position (km) and velocity (km/s) in the inertial frame the elements are
+// referenced to.
+type StateVector struct {
+	Position visibility.Vector3
+	Velocity visibility.Vector3
+}
+
+// Validate reports whether the elements describe a conic the solver supports: an ellipse with a
+// positive semi-major axis, a hyperbola with a negative one, or a parabola with a positive
+// PeriapsisRadius.
+func (k KeplerianElements) Validate() error {
+	switch e := k.Eccentricity; {
+	case e < 0 || math.IsNaN(e):
+		return errors.New("eccentricity cannot be negative")
+	case e < 1 && k.SemiMajorAxis <= 0:
+		return errors.New("elliptical orbits need a positive semi-major axis")
+	case e > 1 && k.SemiMajorAxis >= 0:
+		return errors.New("hyperbolic trajectories need a negative semi-major axis")
+	case e == 1 && k.PeriapsisRadius <= 0:
+		return errors.New("parabolic trajectories need a positive periapsis radius")
+	}
+	if k.Mu < 0 {
+		return errors.New("gravitational parameter cannot be negative")
+	}
+	if k.J2 && k.Eccentricity >= 1 {
+		return errors.New("J2 drift applies to closed orbits only")
+	}
+	return nil
+}
+
+// StateAt propagates the elements to t with the two-body model, plus the J2 secular drift when J2
+// is set, and converts them to a state vector by rotating the perifocal position and velocity
+// through the argument of periapsis, inclination and RAAN. Elapsed time counts any leap seconds between the epoch and t. Closed orbits solve
+// Kepler's equation directly; open trajectories propagate the periapsis state with universal
+// variables. A zero state is returned if the solver fails, which takes an arc long enough to
+// overflow the hyperbolic functions, far beyond any simulation.
+func (k KeplerianElements) StateAt(t time.Time) StateVector {
+	sinceEpoch := timescale.Elapsed(k.Epoch, t).Seconds()
+	if k.Eccentricity >= 1 {
+		state, err := PropagateState(k.perifocalState(k.Periapsis(), 0), k.mu(), k.MeanAnomaly/k.MeanMotion()+sinceEpoch)
+		if err != nil {
+			return StateVector{}
+		}
+		return state
+	}
+
+	k = k.drift(sinceEpoch)
+	e := k.Eccentricity
+	E := EccentricAnomalyFromMean(k.MeanAnomaly, e)
+	nu := TrueAnomalyFromEccentric(E, e)
+	return k.perifocalState(k.SemiMajorAxis*(1-e*math.Cos(E)), nu)
+}
+
+// perifocalState returns the state at radius r and true anomaly nu in the inertial frame.
+func (k KeplerianElements) perifocalState(r, nu float64) StateVector {
+	e := k.Eccentricity
+	p := k.Periapsis() * (1 + e)
+	sinNu, cosNu := math.Sincos(nu)
+	vScale := math.Sqrt(k.mu() / p)
+	position := [2]float64{r * cosNu, r * sinNu}
+	velocity := [2]float64{-vScale * sinNu, vScale * (e + cosNu)}
+
+	sinO, cosO := math.Sincos(k.RAAN)
+	sinI, cosI := math.Sincos(k.Inclination)
+	sinW, cosW := math.Sincos(k.ArgumentOfPeriapsis)
+	// Columns of the perifocal-to-inertial rotation for the in-plane P and Q axes.
+	P := visibility.Vector3{
+		X: cosO*cosW - sinO*sinW*cosI,
+		Y: sinO*cosW + cosO*sinW*cosI,
+		Z: sinW * sinI,
+	}
+	Q := visibility.Vector3{
+		X: -cosO*sinW - sinO*cosW*cosI,
+		Y: -sinO*sinW + cosO*cosW*cosI,
+		Z: cosW * sinI,
+	}
+	return StateVector{Position: combine(position[0], P, position[1], Q), Velocity: combine(velocity[0], P, velocity[1], Q)}
+}
+
+// PropagateBatch returns the state of every element set at t, in input order. Large batches are split
+// across GOMAXPROCS goroutines so a constellation is propagated with one call per tick.
+func PropagateBatch(elements []KeplerianElements, t time.Time) []StateVector {
+	states := make([]StateVector, len(elements))
+	workers := runtime.GOMAXPROCS(0)
+	chunk := (len(elements) + workers - 1) / workers
+	if chunk < batchChunk {
+		chunk = batchChunk
+	}
+	if chunk >= len(elements) {
+		for i, k := range elements {
+			states[i] = k.StateAt(t)
+		}
+		return states
+	}
+
+	var wg sync.WaitGroup
+	for start := 0; start < len(elements); start += chunk {
+		end := start + chunk
+		if end > len(elements) {
+			end = len(elements)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				states[i] = elements[i].StateAt(t)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return states
+}
@@ -0,0 +1,200 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func length(v visibility.Vector3) float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y + v.Z*v.Z)
+}
+
+func TestStateAtCircularOrbit(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := KeplerianElements{SemiMajorAxis: 6921, Inclination: 53 * math.Pi / 180, RAAN: 1, Epoch: epoch}
+
+	for _, dt := range []time.Duration{0, 17 * time.Minute, 3 * time.Hour} {
+		state := elements.StateAt(epoch.Add(dt))
+		if r := length(state.Position); math.Abs(r-6921) > 1e-6 {
+			t.Fatalf("radius at %v = %v, want 6921", dt, r)
+		}
+		if v, want := length(state.Velocity), math.Sqrt(EarthMu/6921); math.Abs(v-want) > 1e-9 {
+			t.Fatalf("speed at %v = %v, want %v", dt, v, want)
+		}
+		// The angular momentum stays on the plane normal fixed by the inclination.
+		h := visibility.Vector3{
+			X: state.Position.Y*state.Velocity.Z - state.Position.Z*state.Velocity.Y,
+			Y: state.Position.Z*state.Velocity.X - state.Position.X*state.Velocity.Z,
+			Z: state.Position.X*state.Velocity.Y - state.Position.Y*state.Velocity.X,
+		}
+		if inclination := math.Acos(h.Z / length(h)); math.Abs(inclination-elements.Inclination) > 1e-12 {
+			t.Fatalf("inclination at %v = %v, want %v", dt, inclination, elements.Inclination)
+		}
+	}
+
+	// Nothing rotates at the reference direction: the first point of Aries for an equatorial orbit.
+	equatorial := KeplerianElements{SemiMajorAxis: 7000, Epoch: epoch}.StateAt(epoch)
+	if math.Abs(equatorial.Position.X-7000) > 1e-9 || math.Abs(equatorial.Velocity.Z) > 1e-12 {
+		t.Fatalf("unexpected equatorial state %+v", equatorial)
+	}
+}
+
+func TestStateAtEllipticalOrbit(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := KeplerianElements{
+		SemiMajorAxis:       26600,
+		Eccentricity:        0.74,
+		Inclination:         63.4 * math.Pi / 180,
+		RAAN:                2,
+		ArgumentOfPeriapsis: 270 * math.Pi / 180,
+		Epoch:               epoch,
+	}
+
+	periapsis := elements.StateAt(epoch)
+	if r, want := length(periapsis.Position), 26600*(1-0.74); math.Abs(r-want) > 1e-6 {
+		t.Fatalf("periapsis radius = %v, want %v", r, want)
+	}
+	// A Molniya orbit's periapsis with ω = 270° lies over the southern hemisphere.
+	if periapsis.Position.Z >= 0 {
+		t.Fatalf("expected periapsis south of the equator, got %+v", periapsis.Position)
+	}
+
+	for _, dt := range []time.Duration{time.Hour, 5 * time.Hour, 11 * time.Hour} {
+		state := elements.StateAt(epoch.Add(dt))
+		r, v := length(state.Position), length(state.Velocity)
+		// Vis-viva: v² = μ(2/r − 1/a).
+		if want := math.Sqrt(EarthMu * (2/r - 1/elements.SemiMajorAxis)); math.Abs(v-want) > 1e-9 {
+			t.Fatalf("speed at %v = %v, vis-viva gives %v", dt, v, want)
+		}
+	}
+}
+
+func TestPropagateBatchMatchesStateAt(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := make([]KeplerianElements, 2000)
+	for i := range elements {
+		elements[i] = KeplerianElements{
+			SemiMajorAxis: 6900 + float64(i%7)*100,
+			Eccentricity:  float64(i%5) * 0.01,
+			Inclination:   float64(i%90) * math.Pi / 180,
+			RAAN:          float64(i%24) * math.Pi / 12,
+			MeanAnomaly:   float64(i) * 0.01,
+			Epoch:         epoch,
+		}
+	}
+
+	at := epoch.Add(42 * time.Minute)
+	states := PropagateBatch(elements, at)
+	if len(states) != len(elements) {
+		t.Fatalf("expected %d states, got %d", len(elements), len(states))
+	}
+	for i, k := range elements {
+		if states[i] != k.StateAt(at) {
+			t.Fatalf("state %d differs from StateAt", i)
+		}
+	}
+	if len(PropagateBatch(nil, at)) != 0 {
+		t.Fatal("expected no states for an empty batch")
+	}
+}
+
+func TestValidateElements(t *testing.T) {
+	valid := KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.1}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, k := range []KeplerianElements{
+		{SemiMajorAxis: 0},
+		{SemiMajorAxis: 7000, Eccentricity: 1},
+		{SemiMajorAxis: 7000, Eccentricity: -0.1},
+		{SemiMajorAxis: 7000, Mu: -1},
+		{SemiMajorAxis: -7000, Eccentricity: 1.5, J2: true},
+	} {
+		if k.Validate() == nil {
+			t.Fatalf("expected %+v to be rejected", k)
+		}
+	}
+}
+
+func TestJ2DriftsNodeAndPeriapsis(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := EarthEquatorialRadius + 700
+	inclination, err := SunSynchronousInclination(a, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := KeplerianElements{SemiMajorAxis: a, Eccentricity: 0.001, Inclination: inclination, RAAN: 1, ArgumentOfPeriapsis: 2, Epoch: epoch, J2: true}
+
+	// A sun-synchronous orbit's node keeps pace with the mean Sun, about a degree a day.
+	const days = 10
+	later := k.Propagate(days * 24 * time.Hour)
+	if drift := later.RAAN - k.RAAN; math.Abs(drift-SunSynchronousRate*days*86400) > 1e-9 {
+		t.Fatalf("expected the node to advance %v rad, got %v", SunSynchronousRate*days*86400, drift)
+	}
+	rates := J2Rates(a, 0.001, inclination)
+	if got := math.Remainder(later.ArgumentOfPeriapsis-k.ArgumentOfPeriapsis, twoPi); math.Abs(got-math.Remainder(rates.ArgumentOfPeriapsis*days*86400, twoPi)) > 1e-9 {
+		t.Fatalf("expected the periapsis to rotate by the J2 rate, got %v", got)
+	}
+
+	// StateAt agrees with propagating the elements first, and without J2 the plane stays put.
+	at := epoch.Add(days * 24 * time.Hour)
+	if d := length(combine(1, k.StateAt(at).Position, -1, later.StateAt(at).Position)); d > 1e-6 {
+		t.Fatalf("StateAt and Propagate disagree by %v km", d)
+	}
+	twoBody := k
+	twoBody.J2 = false
+	if twoBody.Propagate(days*24*time.Hour).RAAN != k.RAAN {
+		t.Fatal("expected the two-body node to stay fixed")
+	}
+	if d := length(combine(1, k.StateAt(at).Position, -1, twoBody.StateAt(at).Position)); d < 100 {
+		t.Fatalf("expected ten days of J2 drift to move the satellite, got %v km", d)
+	}
+}
+
+func BenchmarkPropagateBatch(b *testing.B) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := make([]KeplerianElements, 5000)
+	for i := range elements {
+		elements[i] = KeplerianElements{
+			SemiMajorAxis: 6921,
+			Inclination:   53 * math.Pi / 180,
+			RAAN:          float64(i%72) * math.Pi / 36,
+			MeanAnomaly:   float64(i/72) * math.Pi / 35,
+			Epoch:         epoch,
+		}
+	}
+	at := epoch.Add(time.Hour)
+
+	b.Run("loop", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, k := range elements {
+				_ = k.StateAt(at)
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			PropagateBatch(elements, at)
+		}
+	})
+}
+
+func TestStateAtCountsLeapSeconds(t *testing.T) {
+	epoch := time.Date(2016, 12, 31, 23, 0, 0, 0, time.UTC)
+	elements := KeplerianElements{SemiMajorAxis: 6921, Epoch: epoch}
+	state := elements.StateAt(time.Date(2017, 1, 1, 1, 0, 0, 0, time.UTC))
+
+	// Two hours of UTC across the 2016 leap second are 7201 SI seconds of flight.
+	angle := math.Atan2(state.Position.Y, state.Position.X)
+	want := math.Remainder(elements.MeanMotion()*7201, twoPi)
+	if math.Abs(angle-want) > 1e-9 {
+		t.Fatalf("orbit angle %v, want %v", angle, want)
+	}
+	// Ignoring the leap second would leave a LEO satellite one second, about 7.6 km, behind.
+	if gap := 6921 * math.Abs(angle-math.Remainder(elements.MeanMotion()*7200, twoPi)); gap < 7 || gap > 8 {
+		t.Fatalf("leap second moves the satellite %v km", gap)
+	}
+}
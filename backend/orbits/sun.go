@@ -0,0 +1,46 @@
+package orbits
+
+import (
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/timescale"
+	"github.com/example/satnet/backend/visibility"
+)
+
+const (
+	// SunRadius is the Sun's mean radius in kilometers.
+	SunRadius = 696000.0
+	// AstronomicalUnit is in kilometers.
+	AstronomicalUnit = 149597870.7
+)
+
+// SunPosition returns the Sun's geocentric position at t, in kilometers, in the inertial frame of
+// the mean equator and equinox of date. It uses the Astronomical Almanac's low-precision formulas,
+// good to about 0.01° in direction from 1950 to 2050, with UTC taken for UT1; rotate the result
+// with ECIToECEF for the Earth-fixed frame.
+func SunPosition(t time.Time) visibility.Vector3 {
+	const degToRad = math.Pi / 180
+	centuries := (timescale.JulianDate(t.UTC()) - 2451545.0) / 36525
+	meanLongitude := 280.460 + 36000.771*centuries
+	meanAnomaly := (357.5291092 + 35999.05034*centuries) * degToRad
+	longitude := (meanLongitude + 1.914666471*math.Sin(meanAnomaly) + 0.019994643*math.Sin(2*meanAnomaly)) * degToRad
+	distance := (1.000140612 - 0.016708617*math.Cos(meanAnomaly) - 0.000139589*math.Cos(2*meanAnomaly)) * AstronomicalUnit
+	obliquity := (23.439291 - 0.0130042*centuries) * degToRad
+	sinLon, cosLon := math.Sincos(longitude)
+	return visibility.Vector3{
+		X: distance * cosLon,
+		Y: distance * math.Cos(obliquity) * sinLon,
+		Z: distance * math.Sin(obliquity) * sinLon,
+	}
+}
+
+// SunSeparation returns the angle, in radians, between the Sun and target as seen from observer,
+// all in one frame. Small angles mean the Sun is in or near an antenna's beam pointed at target, as
+// in sun outages of ground stations, or inside an optical terminal's exclusion cone.
+func SunSeparation(observer, target, sun visibility.Vector3) float64 {
+	toTarget := visibility.Vector3{X: target.X - observer.X, Y: target.Y - observer.Y, Z: target.Z - observer.Z}
+	toSun := visibility.Vector3{X: sun.X - observer.X, Y: sun.Y - observer.Y, Z: sun.Z - observer.Z}
+	cos := dot(toTarget, toSun) / (norm(toTarget) * norm(toSun))
+	return math.Acos(math.Max(-1, math.Min(1, cos)))
+}
@@ -0,0 +1,44 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestSunPosition(t *testing.T) {
+	// Vallado, Fundamentals of Astrodynamics and Applications, example 5-1.
+	sun := SunPosition(time.Date(2006, time.April, 2, 0, 0, 0, 0, time.UTC))
+	want := visibility.Vector3{X: 0.9771945, Y: 0.1924424, Z: 0.0834308}
+	for _, pair := range [][2]float64{{sun.X, want.X}, {sun.Y, want.Y}, {sun.Z, want.Z}} {
+		if math.Abs(pair[0]/AstronomicalUnit-pair[1]) > 1e-5 {
+			t.Fatalf("expected the Sun at %+v AU, got %+v km", want, sun)
+		}
+	}
+
+	// Near the June solstice the Sun sits at the obliquity north of the equator, beyond 1 AU.
+	sun = SunPosition(time.Date(2024, time.June, 20, 21, 0, 0, 0, time.UTC))
+	if declination := math.Asin(sun.Z/norm(sun)) * 180 / math.Pi; math.Abs(declination-23.44) > 0.01 {
+		t.Fatalf("expected a solstice declination of 23.44°, got %v", declination)
+	}
+	if d := norm(sun) / AstronomicalUnit; d < 1.01 || d > 1.017 {
+		t.Fatalf("expected the Sun near aphelion, got %v AU", d)
+	}
+	if d := norm(SunPosition(time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC))) / AstronomicalUnit; d < 0.983 || d > 0.984 {
+		t.Fatalf("expected the Sun near perihelion, got %v AU", d)
+	}
+}
+
+func TestSunSeparation(t *testing.T) {
+	sun := visibility.Vector3{X: AstronomicalUnit}
+	station := visibility.Vector3{X: visibility.EarthRadius}
+	overhead := visibility.Vector3{X: visibility.EarthRadius + 35786}
+	if angle := SunSeparation(station, overhead, sun); angle > 1e-9 {
+		t.Fatalf("expected the Sun behind an overhead satellite, got %v rad", angle)
+	}
+	if angle := SunSeparation(station, visibility.Vector3{X: visibility.EarthRadius, Y: 1000}, sun); math.Abs(angle-math.Pi/2) > 1e-9 {
+		t.Fatalf("expected a satellite on the horizon a right angle from the Sun, got %v rad", angle)
+	}
+}
@@ -0,0 +1,188 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// WGS-72 constants, which two-line element sets are generated against.
+const (
+	wgs72RadiusKm = 6378.135
+	wgs72Mu       = 398600.8
+	wgs72J2       = 0.001082616
+
+	minutesPerDay = 1440.0
+	// meanElementIterations caps the fixed-point inversions below, which gain several digits per step.
+	meanElementIterations = 50
+)
+
+// TLEElements are the mean elements a two-line element set carries, in the format's units. They are
+// SGP4 mean elements: the mean motion is Kozai's, and none of them are osculating, so converting a TLE
+// to an orbit goes through Mean and then MeanToOsculating.
+type TLEElements struct {
+	Epoch                time.Time `json:"epoch"`
+	InclinationDeg       float64   `json:"inclinationDeg"`
+	RAANDeg              float64   `json:"raanDeg"`
+	Eccentricity         float64   `json:"eccentricity"`
+	ArgumentOfPerigeeDeg float64   `json:"argumentOfPerigeeDeg"`
+	MeanAnomalyDeg       float64   `json:"meanAnomalyDeg"`
+	MeanMotion           float64   `json:"meanMotionRevPerDay"` // Kozai mean motion
+	BStar                float64   `json:"bstar"`               // drag term, per Earth radius
+}
+
+// Mean returns the Brouwer mean elements SGP4 derives from the set, recovering the semi-major axis
+// from the Kozai mean motion with its J2 correction. The elements use the WGS-72 gravitational
+// parameter.
+func (t TLEElements) Mean() KeplerianElements {
+	inclination := t.InclinationDeg * math.Pi / 180
+	return KeplerianElements{
+		SemiMajorAxis:       brouwerSemiMajorAxis(t.MeanMotion*twoPi/minutesPerDay, t.Eccentricity, inclination) * wgs72RadiusKm,
+		Eccentricity:        t.Eccentricity,
+		Inclination:         inclination,
+		RAAN:                t.RAANDeg * math.Pi / 180,
+		ArgumentOfPeriapsis: t.ArgumentOfPerigeeDeg * math.Pi / 180,
+		MeanAnomaly:         t.MeanAnomalyDeg * math.Pi / 180,
+		Epoch:               t.Epoch,
+		Mu:                  wgs72Mu,
+	}
+}
+
+// TLEFromMean returns the two-line mean elements whose Mean is k, inverting the Kozai correction for
+// the mean motion. BStar is left zero since Keplerian elements carry no drag.
+func TLEFromMean(k KeplerianElements) (TLEElements, error) {
+	if k.Eccentricity < 0 || k.Eccentricity >= 1 || k.SemiMajorAxis <= 0 {
+		return TLEElements{}, errors.New("two-line elements describe closed orbits only")
+	}
+	target := k.SemiMajorAxis / wgs72RadiusKm
+	// Start from Kepler's third law and rescale until the corrected axis lands on the target.
+	n0 := xke() * math.Pow(target, -1.5)
+	for i := 0; i < meanElementIterations; i++ {
+		a := brouwerSemiMajorAxis(n0, k.Eccentricity, k.Inclination)
+		n0 *= math.Pow(a/target, 1.5)
+		if math.Abs(a-target) <= 1e-15*target {
+			break
+		}
+	}
+	return TLEElements{
+		Epoch:                k.Epoch,
+		InclinationDeg:       k.Inclination * 180 / math.Pi,
+		RAANDeg:              normalizeAngle(k.RAAN) * 180 / math.Pi,
+		Eccentricity:         k.Eccentricity,
+		ArgumentOfPerigeeDeg: normalizeAngle(k.ArgumentOfPeriapsis) * 180 / math.Pi,
+		MeanAnomalyDeg:       normalizeAngle(k.MeanAnomaly) * 180 / math.Pi,
+		MeanMotion:           n0 * minutesPerDay / twoPi,
+	}, nil
+}
+
+// xke is sqrt(mu) in Earth radii^1.5 per minute.
+func xke() float64 {
+	return 60 / math.Sqrt(wgs72RadiusKm*wgs72RadiusKm*wgs72RadiusKm/wgs72Mu)
+}
+
+// brouwerSemiMajorAxis undoes the Kozai mean motion's J2 correction the way SGP4 initializes,
+// returning the semi-major axis in Earth radii for a Kozai mean motion in radians per minute.
+func brouwerSemiMajorAxis(kozai, e, inclination float64) float64 {
+	k2 := wgs72J2 / 2
+	cosI := math.Cos(inclination)
+	beta3 := math.Pow(1-e*e, 1.5)
+	a1 := math.Pow(xke()/kozai, 2.0/3)
+	del1 := 1.5 * k2 * (3*cosI*cosI - 1) / (a1 * a1 * beta3)
+	a0 := a1 * (1 - del1*(1.0/3+del1*(1+134.0/81*del1)))
+	del0 := 1.5 * k2 * (3*cosI*cosI - 1) / (a0 * a0 * beta3)
+	return a0 / (1 - del0)
+}
+
+// MeanToOsculating adds the first-order J2 short-period variations SGP4 applies to mean elements,
+// giving the osculating elements of the state SGP4 would report at the elements' epoch. It uses the
+// WGS-72 J2 and Earth radius, like the element sets the mean elements come from.
+func MeanToOsculating(mean KeplerianElements) (KeplerianElements, error) {
+	if err := mean.Validate(); err != nil {
+		return KeplerianElements{}, err
+	}
+	if mean.Eccentricity >= 1 {
+		return KeplerianElements{}, errors.New("mean elements describe closed orbits only")
+	}
+	mu, a, e := mean.mu(), mean.SemiMajorAxis, mean.Eccentricity
+	n := mean.MeanMotion()
+	E := EccentricAnomalyFromMean(mean.MeanAnomaly, e)
+	sinE, cosE := math.Sincos(E)
+	r := a * (1 - e*cosE)
+	pl := a * (1 - e*e)
+	rdot := math.Sqrt(mu*a) * e * sinE / r
+	rfdot := math.Sqrt(mu*pl) / r
+	u := TrueAnomalyFromEccentric(E, e) + mean.ArgumentOfPeriapsis
+	sin2u, cos2u := math.Sincos(2 * u)
+
+	cosI, sinI := math.Cos(mean.Inclination), math.Sin(mean.Inclination)
+	x3thm1 := 3*cosI*cosI - 1
+	x1mth2 := 1 - cosI*cosI
+	x7thm1 := 7*cosI*cosI - 1
+	temp1 := wgs72J2 / 2 * wgs72RadiusKm * wgs72RadiusKm / pl
+	temp2 := temp1 / pl
+
+	rk := r*(1-1.5*temp2*math.Sqrt(1-e*e)*x3thm1) + 0.5*temp1*x1mth2*cos2u
+	uk := u - 0.25*temp2*x7thm1*sin2u
+	nodek := mean.RAAN + 1.5*temp2*cosI*sin2u
+	inclinationk := mean.Inclination + 1.5*temp2*cosI*sinI*cos2u
+	rdotk := rdot - n*temp1*x1mth2*sin2u
+	rfdotk := rfdot + n*temp1*(x1mth2*cos2u+1.5*x3thm1)
+
+	sinU, cosU := math.Sincos(uk)
+	sinN, cosN := math.Sincos(nodek)
+	sinIk, cosIk := math.Sincos(inclinationk)
+	radial := visibility.Vector3{X: -sinN*cosIk*sinU + cosN*cosU, Y: cosN*cosIk*sinU + sinN*cosU, Z: sinIk * sinU}
+	along := visibility.Vector3{X: -sinN*cosIk*cosU - cosN*sinU, Y: cosN*cosIk*cosU - sinN*sinU, Z: sinIk * cosU}
+	state := StateVector{
+		Position: combine(rk, radial, 0, along),
+		Velocity: combine(rdotk, radial, rfdotk, along),
+	}
+	osculating, err := ElementsFromState(state, mu, mean.Epoch)
+	if err != nil {
+		return KeplerianElements{}, err
+	}
+	osculating.Mu = mean.Mu
+	return osculating, nil
+}
+
+// OsculatingToMean inverts MeanToOsculating by fixed-point iteration in equinoctial elements, which
+// stay well defined for the near-circular orbits most element sets describe.
+func OsculatingToMean(osculating KeplerianElements) (KeplerianElements, error) {
+	if err := osculating.Validate(); err != nil {
+		return KeplerianElements{}, err
+	}
+	if osculating.Eccentricity >= 1 {
+		return KeplerianElements{}, errors.New("osculating elements describe closed orbits only")
+	}
+	target := osculating.Equinoctial()
+	mean := target
+	for i := 0; i < meanElementIterations; i++ {
+		guess, err := MeanToOsculating(mean.Keplerian())
+		if err != nil {
+			return KeplerianElements{}, err
+		}
+		got := guess.Equinoctial()
+		step := EquinoctialElements{
+			SemiMajorAxis: target.SemiMajorAxis - got.SemiMajorAxis,
+			H:             target.H - got.H,
+			K:             target.K - got.K,
+			P:             target.P - got.P,
+			Q:             target.Q - got.Q,
+			MeanLongitude: math.Remainder(target.MeanLongitude-got.MeanLongitude, twoPi),
+		}
+		mean.SemiMajorAxis += step.SemiMajorAxis
+		mean.H += step.H
+		mean.K += step.K
+		mean.P += step.P
+		mean.Q += step.Q
+		mean.MeanLongitude = normalizeAngle(mean.MeanLongitude + step.MeanLongitude)
+		if math.Abs(step.SemiMajorAxis) <= 1e-9*target.SemiMajorAxis &&
+			math.Max(math.Max(math.Abs(step.H), math.Abs(step.K)), math.Max(math.Abs(step.P), math.Abs(step.Q))) <= 1e-13 &&
+			math.Abs(step.MeanLongitude) <= 1e-13 {
+			return mean.Keplerian(), nil
+		}
+	}
+	return KeplerianElements{}, errors.New("mean elements did not converge")
+}
@@ -0,0 +1,94 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// issTLE is the ISS element set from the SGP4 verification suite era, in its published units.
+var issTLE = TLEElements{
+	Epoch:                time.Date(2008, 9, 20, 12, 25, 40, 104192000, time.UTC),
+	InclinationDeg:       51.6416,
+	RAANDeg:              247.4627,
+	Eccentricity:         0.0006703,
+	ArgumentOfPerigeeDeg: 130.5360,
+	MeanAnomalyDeg:       325.0288,
+	MeanMotion:           15.72125391,
+	BStar:                -0.11606e-4,
+}
+
+func TestTLEMeanElements(t *testing.T) {
+	mean := issTLE.Mean()
+	kepler := math.Cbrt(wgs72Mu / math.Pow(issTLE.MeanMotion*twoPi/86400, 2))
+	// The Kozai correction moves the axis by a few kilometers at ISS altitude, not more.
+	if diff := mean.SemiMajorAxis - kepler; math.Abs(diff) < 0.1 || math.Abs(diff) > 20 {
+		t.Fatalf("semi-major axis %v km differs from Kepler's %v km by %v", mean.SemiMajorAxis, kepler, diff)
+	}
+	if math.Abs(mean.Inclination-51.6416*math.Pi/180) > 1e-15 || !mean.Epoch.Equal(issTLE.Epoch) {
+		t.Fatalf("unexpected mean elements %+v", mean)
+	}
+
+	back, err := TLEFromMean(mean)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(back.MeanMotion-issTLE.MeanMotion) > 1e-10 {
+		t.Fatalf("mean motion %v, want %v", back.MeanMotion, issTLE.MeanMotion)
+	}
+	for _, pair := range [][2]float64{
+		{back.InclinationDeg, issTLE.InclinationDeg},
+		{back.RAANDeg, issTLE.RAANDeg},
+		{back.ArgumentOfPerigeeDeg, issTLE.ArgumentOfPerigeeDeg},
+		{back.MeanAnomalyDeg, issTLE.MeanAnomalyDeg},
+		{back.Eccentricity, issTLE.Eccentricity},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-10 {
+			t.Fatalf("element %v did not round-trip to %v", pair[0], pair[1])
+		}
+	}
+
+	if _, err := TLEFromMean(KeplerianElements{SemiMajorAxis: -7000, Eccentricity: 1.2}); err == nil {
+		t.Fatal("expected an open orbit to be rejected")
+	}
+}
+
+func TestMeanOsculatingRoundTrip(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, mean := range conversionCases {
+		mean.Epoch = epoch
+		osculating, err := MeanToOsculating(mean)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		// Short-period J2 terms shift a LEO axis by kilometers and vanish with distance.
+		shift := math.Abs(osculating.SemiMajorAxis - mean.SemiMajorAxis)
+		if shift > 20 || (name == "leo" && shift < 0.1) {
+			t.Fatalf("%s: osculating axis shifted by %v km", name, shift)
+		}
+
+		back, err := OsculatingToMean(osculating)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		sameOrbit(t, name, back, mean)
+	}
+}
+
+func TestShortPeriodTermsVaryAroundTheOrbit(t *testing.T) {
+	mean := issTLE.Mean()
+	minA, maxA := math.Inf(1), math.Inf(-1)
+	for step := 0; step < 36; step++ {
+		mean.MeanAnomaly = float64(step) * twoPi / 36
+		osculating, err := MeanToOsculating(mean)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		minA, maxA = math.Min(minA, osculating.SemiMajorAxis), math.Max(maxA, osculating.SemiMajorAxis)
+	}
+	// The osculating axis of a LEO orbit swings by roughly ±(3/2)J2(Re²/a)sin²i twice per orbit.
+	want := 3 * wgs72J2 * wgs72RadiusKm * wgs72RadiusKm / mean.SemiMajorAxis * math.Pow(math.Sin(mean.Inclination), 2)
+	if swing := maxA - minA; math.Abs(swing-want) > 0.2*want {
+		t.Fatalf("osculating axis swings by %v km, want about %v km", swing, want)
+	}
+}
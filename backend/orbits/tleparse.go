@@ -0,0 +1,302 @@
+package orbits
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tleLineLength is the width of both data lines, including the trailing checksum digit.
+const tleLineLength = 69
+
+// ErrTLEChecksum is wrapped by the TLEError of a data line whose checksum digit does not match.
+var ErrTLEChecksum = errors.New("checksum mismatch")
+
+// TLEError reports why a line of an element set was rejected. Line counts from 1 within the text
+// being parsed.
+type TLEError struct {
+	Line int
+	Err  error
+}
+
+func (e *TLEError) Error() string {
+	return fmt.Sprintf("tle line %d: %v", e.Line, e.Err)
+}
+
+func (e *TLEError) Unwrap() error {
+	return e.Err
+}
+
+// TLE is a parsed two- or three-line element set: the mean elements SGP4 propagates plus the
+// catalog fields that identify the object.
+type TLE struct {
+	// Name is the title line of a three-line set, empty for two-line sets.
+	Name string `json:"name,omitempty"`
+	// CatalogNumber is the five-character NORAD number, which may start with a letter in the
+	// Alpha-5 scheme for numbers above 99999.
+	CatalogNumber           string `json:"catalogNumber"`
+	Classification          string `json:"classification"`
+	InternationalDesignator string `json:"internationalDesignator,omitempty"`
+	// MeanMotionDot and MeanMotionDDot are the first and second time derivatives of mean motion
+	// as the set states them, already halved and divided by six, in revolutions per day squared
+	// and cubed. SGP4 ignores them.
+	MeanMotionDot    float64     `json:"meanMotionDot"`
+	MeanMotionDDot   float64     `json:"meanMotionDDot"`
+	ElementSetNumber int         `json:"elementSetNumber"`
+	RevolutionNumber int         `json:"revolutionNumber"`
+	Elements         TLEElements `json:"elements"`
+}
+
+// ParseTLE parses a single element set: two data lines, optionally preceded by a title line. Blank
+// lines are ignored, and a title line may carry the "0 " prefix of the three-line format.
+func ParseTLE(text string) (TLE, error) {
+	var lines []string
+	var numbers []int
+	for i, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			lines = append(lines, line)
+			numbers = append(numbers, i+1)
+		}
+	}
+	switch len(lines) {
+	case 2:
+		return parseTLELines("", lines[0], lines[1], numbers[0])
+	case 3:
+		return parseTLELines(tleName(lines[0]), lines[1], lines[2], numbers[1])
+	}
+	return TLE{}, fmt.Errorf("an element set has two or three lines, got %d", len(lines))
+}
+
+// ParseTLEFile parses every element set in r, such as a catalog file downloaded from a tracking
+// service, mixing two- and three-line sets freely. Sets that parse are returned in file order; each
+// rejected set contributes a *TLEError naming the offending line to the returned error, which joins
+// them all. Only a failure to read r stops parsing early.
+func ParseTLEFile(r io.Reader) ([]TLE, error) {
+	var (
+		sets []TLE
+		errs []error
+		// name is a title line awaiting its data lines; first holds a line 1 awaiting its line 2.
+		name, first         string
+		nameLine, firstLine int
+		scanner             = bufio.NewScanner(r)
+		number              int
+	)
+	for scanner.Scan() {
+		number++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		switch {
+		case line == "":
+			continue
+		case first != "" && strings.HasPrefix(line, "2 "):
+			set, err := parseTLELines(name, first, line, firstLine)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				sets = append(sets, set)
+			}
+			name, first = "", ""
+			continue
+		case first != "":
+			errs = append(errs, &TLEError{Line: firstLine, Err: errors.New("line 1 is not followed by line 2")})
+			first = ""
+		}
+
+		switch {
+		case strings.HasPrefix(line, "1 "):
+			first, firstLine = line, number
+		case strings.HasPrefix(line, "2 "):
+			errs = append(errs, &TLEError{Line: number, Err: errors.New("line 2 without a preceding line 1")})
+			name = ""
+		default:
+			if name != "" {
+				errs = append(errs, &TLEError{Line: nameLine, Err: errors.New("title line is not followed by an element set")})
+			}
+			name, nameLine = tleName(line), number
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return sets, err
+	}
+	switch {
+	case first != "":
+		errs = append(errs, &TLEError{Line: firstLine, Err: errors.New("line 1 is not followed by line 2")})
+	case name != "":
+		errs = append(errs, &TLEError{Line: nameLine, Err: errors.New("title line is not followed by an element set")})
+	}
+	return sets, errors.Join(errs...)
+}
+
+// tleName strips the "0 " prefix three-line sets from some catalogs put on title lines.
+func tleName(line string) string {
+	return strings.TrimSpace(strings.TrimPrefix(line, "0 "))
+}
+
+// parseTLELines parses the two data lines of a set, the first of which is line number first.
+func parseTLELines(name, line1, line2 string, first int) (TLE, error) {
+	fail := func(offset int, format string, args ...any) (TLE, error) {
+		return TLE{}, &TLEError{Line: first + offset, Err: fmt.Errorf(format, args...)}
+	}
+	for i, line := range []string{line1, line2} {
+		if len(line) != tleLineLength {
+			return fail(i, "data lines are %d characters, got %d", tleLineLength, len(line))
+		}
+		if want, got := tleChecksum(line), line[tleLineLength-1]; got != '0'+byte(want) {
+			return fail(i, "%w: the line sums to %d but ends in %c", ErrTLEChecksum, want, got)
+		}
+	}
+	if line1[0] != '1' || line2[0] != '2' {
+		return fail(0, "data lines must start with 1 and 2")
+	}
+
+	set := TLE{
+		Name:                    name,
+		CatalogNumber:           strings.TrimSpace(line1[2:7]),
+		Classification:          strings.TrimSpace(line1[7:8]),
+		InternationalDesignator: strings.TrimSpace(line1[9:17]),
+	}
+	if !validCatalogNumber(set.CatalogNumber) {
+		return fail(0, "invalid catalog number %q", line1[2:7])
+	}
+	if catalog := strings.TrimSpace(line2[2:7]); catalog != set.CatalogNumber {
+		return fail(1, "catalog number %q does not match line 1's %q", catalog, set.CatalogNumber)
+	}
+
+	epoch, err := tleEpoch(line1[18:20], line1[20:32])
+	if err != nil {
+		return fail(0, "epoch: %v", err)
+	}
+	var errField string
+	parse := func(field, raw string, parser func(string) (float64, error)) float64 {
+		value, err := parser(strings.TrimSpace(raw))
+		if err != nil && errField == "" {
+			errField = fmt.Sprintf("%s %q", field, raw)
+		}
+		return value
+	}
+	set.MeanMotionDot = parse("mean motion derivative", line1[33:43], parseTLEFloat)
+	set.MeanMotionDDot = parse("mean motion second derivative", line1[44:52], parseTLEExponent)
+	bstar := parse("bstar", line1[53:61], parseTLEExponent)
+	elementSet := parse("element set number", line1[64:68], parseTLEInt)
+	if errField != "" {
+		return fail(0, "invalid %s", errField)
+	}
+	set.ElementSetNumber = int(elementSet)
+
+	set.Elements = TLEElements{
+		Epoch:                epoch,
+		InclinationDeg:       parse("inclination", line2[8:16], parseTLEFloat),
+		RAANDeg:              parse("right ascension", line2[17:25], parseTLEFloat),
+		Eccentricity:         parse("eccentricity", line2[26:33], parseTLEFraction),
+		ArgumentOfPerigeeDeg: parse("argument of perigee", line2[34:42], parseTLEFloat),
+		MeanAnomalyDeg:       parse("mean anomaly", line2[43:51], parseTLEFloat),
+		MeanMotion:           parse("mean motion", line2[52:63], parseTLEFloat),
+		BStar:                bstar,
+	}
+	set.RevolutionNumber = int(parse("revolution number", line2[63:68], parseTLEInt))
+	if errField != "" {
+		return fail(1, "invalid %s", errField)
+	}
+	return set, nil
+}
+
+// tleChecksum sums the digits of a data line before its checksum, counting each minus sign as 1.
+func tleChecksum(line string) int {
+	sum := 0
+	for _, c := range line[:tleLineLength-1] {
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
+	}
+	return sum % 10
+}
+
+// validCatalogNumber accepts five digits, or a letter other than I and O followed by four digits.
+func validCatalogNumber(catalog string) bool {
+	if len(catalog) == 0 || len(catalog) > 5 {
+		return false
+	}
+	rest := catalog
+	if c := catalog[0]; len(catalog) == 5 && c >= 'A' && c <= 'Z' && c != 'I' && c != 'O' {
+		rest = catalog[1:]
+	}
+	for _, c := range rest {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// tleEpoch converts a two-digit year, 1957 through 2056, and a fractional day of the year.
+func tleEpoch(year, day string) (time.Time, error) {
+	y, err := strconv.Atoi(strings.TrimSpace(year))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid year %q", year)
+	}
+	if y < 57 {
+		y += 2000
+	} else {
+		y += 1900
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(day), 64)
+	if err != nil || d < 1 || d >= 367 {
+		return time.Time{}, fmt.Errorf("invalid day of year %q", day)
+	}
+	// Eight decimals of a day resolve 864 µs, so rounding to microseconds keeps the stated epoch.
+	offset := time.Duration(math.Round((d-1)*86400e6)) * time.Microsecond
+	return time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC).Add(offset), nil
+}
+
+// parseTLEFloat reads a decimal field, rejecting the NaN and Inf spellings strconv also accepts.
+func parseTLEFloat(raw string) (float64, error) {
+	value, err := strconv.ParseFloat(raw, 64)
+	if err == nil && (math.IsNaN(value) || math.IsInf(value, 0)) {
+		return 0, fmt.Errorf("invalid number %q", raw)
+	}
+	return value, err
+}
+
+func parseTLEInt(raw string) (float64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	return float64(n), err
+}
+
+// parseTLEFraction reads digits with an implied leading decimal point, as in the eccentricity.
+func parseTLEFraction(raw string) (float64, error) {
+	if raw == "" || strings.ContainsAny(raw, "+-.") {
+		return 0, fmt.Errorf("invalid fraction %q", raw)
+	}
+	return strconv.ParseFloat("0."+raw, 64)
+}
+
+// parseTLEExponent reads the format's compact scientific notation, a signed fraction with an
+// implied leading decimal point followed by a signed power of ten: "-11606-4" is -0.11606e-4.
+func parseTLEExponent(raw string) (float64, error) {
+	sign := ""
+	if raw != "" && (raw[0] == '-' || raw[0] == '+') {
+		sign, raw = raw[:1], raw[1:]
+	}
+	split := strings.LastIndexAny(raw, "+-")
+	if split <= 0 {
+		return 0, fmt.Errorf("invalid exponent notation %q", raw)
+	}
+	mantissa, exponent := raw[:split], raw[split:]
+	if strings.ContainsAny(mantissa, "+-.") {
+		return 0, fmt.Errorf("invalid exponent notation %q", raw)
+	}
+	if _, err := strconv.Atoi(exponent); err != nil {
+		return 0, fmt.Errorf("invalid exponent notation %q", raw)
+	}
+	return strconv.ParseFloat(sign+"0."+mantissa+"e"+exponent, 64)
+}
@@ -0,0 +1,140 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+const (
+	vanguardLine1 = "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753"
+	vanguardLine2 = "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667"
+	molniyaLine1  = "1 08195U 75081A   06176.33215444  .00000099  00000-0  11873-3 0   813"
+	molniyaLine2  = "2 08195  64.1586 279.0717 6877146 264.7651  20.2257  2.00491383225656"
+)
+
+// withChecksum replaces the checksum digit of an edited data line.
+func withChecksum(line string) string {
+	return line[:68] + string(rune('0'+tleChecksum(line)))
+}
+
+func TestParseTLEReadsTheVerificationSets(t *testing.T) {
+	set, err := ParseTLE(vanguardLine1 + "\n" + vanguardLine2 + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Elements != vanguardTLE {
+		t.Fatalf("expected %+v, got %+v", vanguardTLE, set.Elements)
+	}
+	if set.CatalogNumber != "00005" || set.Classification != "U" || set.InternationalDesignator != "58002B" ||
+		set.MeanMotionDot != 0.23e-6 || set.ElementSetNumber != 475 || set.RevolutionNumber != 41366 || set.Name != "" {
+		t.Fatalf("unexpected catalog fields %+v", set)
+	}
+
+	set, err = ParseTLE("0 MOLNIYA 2-14\r\n" + molniyaLine1 + "\r\n" + molniyaLine2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Name != "MOLNIYA 2-14" || set.Elements != molniyaTLE {
+		t.Fatalf("expected %+v, got %+v", molniyaTLE, set)
+	}
+	if set.Elements.BStar != 0.11873e-3 {
+		t.Fatalf("expected bstar 1.1873e-4, got %v", set.Elements.BStar)
+	}
+}
+
+func TestParseTLERejectsMalformedLines(t *testing.T) {
+	corrupt := strings.Replace(vanguardLine2, "34.2682", "34.2683", 1)
+	var tleErr *TLEError
+	if _, err := ParseTLE(vanguardLine1 + "\n" + corrupt); !errors.Is(err, ErrTLEChecksum) || !errors.As(err, &tleErr) || tleErr.Line != 2 {
+		t.Fatalf("expected a checksum error on line 2, got %v", err)
+	}
+	for name, tc := range map[string]struct {
+		line1, line2, want string
+	}{
+		"short line":           {vanguardLine1[:68], vanguardLine2, "characters"},
+		"catalog mismatch":     {vanguardLine1, withChecksum("2 00006" + vanguardLine2[7:]), "does not match"},
+		"swapped lines":        {vanguardLine2, vanguardLine1, "start with 1 and 2"},
+		"invalid exponent":     {withChecksum(strings.Replace(vanguardLine1, "28098-4", "2809804", 1)), vanguardLine2, "bstar"},
+		"invalid epoch day":    {withChecksum(strings.Replace(vanguardLine1, "00179.", "00379.", 1)), vanguardLine2, "day of year"},
+		"signed eccentricity":  {vanguardLine1, withChecksum(strings.Replace(vanguardLine2, "1859667", "-859667", 1)), "eccentricity"},
+		"NaN mean anomaly":     {vanguardLine1, withChecksum(strings.Replace(vanguardLine2, " 19.3264", "     NaN", 1)), "mean anomaly"},
+		"infinite inclination": {vanguardLine1, withChecksum(strings.Replace(vanguardLine2, " 34.2682", "    +Inf", 1)), "inclination"},
+	} {
+		_, err := ParseTLE(tc.line1 + "\n" + tc.line2)
+		if !errors.As(err, &tleErr) || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected a TLE error mentioning %q, got %v", name, tc.want, err)
+		}
+	}
+	if _, err := ParseTLE(vanguardLine1); err == nil {
+		t.Fatal("expected an error for a lone line")
+	}
+}
+
+func TestParseTLEFileReportsEachBadSet(t *testing.T) {
+	file := strings.Join([]string{
+		"VANGUARD 1",
+		vanguardLine1,
+		vanguardLine2,
+		"",
+		molniyaLine1,
+		molniyaLine2,
+		"BROKEN",
+		vanguardLine1,
+		strings.Replace(vanguardLine2, "34.2682", "34.2683", 1),
+		molniyaLine2,
+		"ORPHAN",
+	}, "\n")
+	sets, err := ParseTLEFile(strings.NewReader(file))
+	if len(sets) != 2 || sets[0].Name != "VANGUARD 1" || sets[1].Name != "" || sets[1].CatalogNumber != "08195" {
+		t.Fatalf("expected the two valid sets, got %+v", sets)
+	}
+	var lines []int
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var tleErr *TLEError
+		if !errors.As(err, &tleErr) {
+			t.Fatalf("expected TLE errors, got %v", err)
+		}
+		lines = append(lines, tleErr.Line)
+	}
+	if len(lines) != 3 || lines[0] != 9 || lines[1] != 10 || lines[2] != 11 {
+		t.Fatalf("expected errors on lines 9, 10 and 11, got %v: %v", lines, err)
+	}
+	if !errors.Is(err, ErrTLEChecksum) {
+		t.Fatalf("expected the checksum failure among the errors, got %v", err)
+	}
+}
+
+func TestTLEExponentNotation(t *testing.T) {
+	for raw, want := range map[string]float64{"-11606-4": -0.11606e-4, "00000-0": 0, "+12345+1": 1.2345, "50000-10": 0.5e-10} {
+		if got, err := parseTLEExponent(raw); err != nil || got != want {
+			t.Fatalf("%s: expected %v, got %v, %v", raw, want, got, err)
+		}
+	}
+}
+
+func FuzzParseTLE(f *testing.F) {
+	f.Add(vanguardLine1 + "\n" + vanguardLine2)
+	f.Add("MOLNIYA 1-29\n" + molniyaLine1 + "\n" + molniyaLine2)
+	f.Add(vanguardLine1 + "\n" + withChecksum(strings.Replace(vanguardLine2, " 19.3264", "     NaN", 1)))
+	f.Add(vanguardLine1 + "\n" + withChecksum(strings.Replace(vanguardLine2, "10.82419157", "      +Inf ", 1)))
+	f.Add(vanguardLine2 + "\n" + vanguardLine1)
+	f.Add("1 \n2 ")
+	f.Fuzz(func(t *testing.T, text string) {
+		sets, _ := ParseTLEFile(strings.NewReader(text))
+		if set, err := ParseTLE(text); err == nil {
+			sets = append(sets, set)
+		}
+		for _, set := range sets {
+			e := set.Elements
+			for _, v := range []float64{e.InclinationDeg, e.RAANDeg, e.Eccentricity, e.ArgumentOfPerigeeDeg, e.MeanAnomalyDeg, e.MeanMotion, e.BStar} {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					t.Fatalf("parsed a non-finite element from %q: %+v", text, e)
+				}
+			}
+			// SGP4 may reject the elements, but must not panic on anything the parser accepts.
+			NewSGP4(e)
+		}
+	})
+}
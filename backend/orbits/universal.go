@@ -0,0 +1,137 @@
+package orbits
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// universalTolerance is the relative change in the universal anomaly at which iteration stops.
+	universalTolerance = 1e-12
+	// universalIterations caps Laguerre iteration, which converges in a handful of steps for any
+	// conic from the guesses below.
+	universalIterations = 100
+	// laguerreOrder is the polynomial degree assumed by the Laguerre-Conway iteration.
+	laguerreOrder = 5
+	// stumpffSeriesLimit is the |ψ| below which the Stumpff functions use their Taylor series, where
+	// the closed forms lose precision to cancellation.
+	stumpffSeriesLimit = 1e-6
+)
+
+// ErrNoConvergence is returned when the universal Kepler equation cannot be solved, typically
+// because a hyperbolic arc runs so long that its hyperbolic functions overflow.
+var ErrNoConvergence = errors.New("universal Kepler equation did not converge")
+
+// PropagateState advances a two-body state by dt seconds (negative to go back) using the universal
+// variable formulation, which treats elliptical, parabolic and hyperbolic trajectories alike. A zero
+// mu selects EarthMu.
+func PropagateState(state StateVector, mu, dt float64) (StateVector, error) {
+	if mu == 0 {
+		mu = EarthMu
+	}
+	if dt == 0 {
+		return state, nil
+	}
+	r0v, v0v := state.Position, state.Velocity
+	r0 := norm(r0v)
+	if r0 == 0 {
+		return StateVector{}, errors.New("cannot propagate a state at the central body's center")
+	}
+	sqrtMu := math.Sqrt(mu)
+	rdotv := dot(r0v, v0v)
+	v0sq := dot(v0v, v0v)
+	// alpha is the reciprocal semi-major axis: positive for ellipses, zero for parabolas and negative
+	// for hyperbolas.
+	alpha := 2/r0 - v0sq/mu
+	sigma0 := rdotv / sqrtMu
+
+	// kepler evaluates the universal Kepler equation and its first two derivatives at chi; the
+	// first derivative is the radius at chi.
+	kepler := func(chi float64) (f, df, ddf float64) {
+		psi := chi * chi * alpha
+		c2, c3 := stumpff(psi)
+		f = chi*chi*chi*c3 + sigma0*chi*chi*c2 + r0*chi*(1-psi*c3) - sqrtMu*dt
+		df = chi*chi*c2 + sigma0*chi*(1-psi*c3) + r0*(1-psi*c2)
+		ddf = sigma0*(1-psi*c2) + (1-alpha*r0)*chi*(1-psi*c3)
+		return f, df, ddf
+	}
+
+	chi := universalGuess(r0, rdotv, alpha, mu, dt)
+	converged := false
+	for i := 0; i < universalIterations; i++ {
+		f, df, ddf := kepler(chi)
+		const n = laguerreOrder
+		root := math.Sqrt(math.Abs((n-1)*(n-1)*df*df - n*(n-1)*f*ddf))
+		delta := n * f / (df + math.Copysign(root, df))
+		if math.IsNaN(delta) || math.IsInf(delta, 0) {
+			break
+		}
+		chi -= delta
+		if math.Abs(delta) <= universalTolerance*math.Max(1, math.Abs(chi)) {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return StateVector{}, ErrNoConvergence
+	}
+
+	psi := chi * chi * alpha
+	c2, c3 := stumpff(psi)
+	r := chi*chi*c2 + sigma0*chi*(1-psi*c3) + r0*(1-psi*c2)
+	f := 1 - chi*chi/r0*c2
+	g := dt - chi*chi*chi/sqrtMu*c3
+	fdot := sqrtMu / (r * r0) * chi * (psi*c3 - 1)
+	gdot := 1 - chi*chi/r*c2
+
+	next := StateVector{
+		Position: combine(f, r0v, g, v0v),
+		Velocity: combine(fdot, r0v, gdot, v0v),
+	}
+	if !finite(next) {
+		return StateVector{}, ErrNoConvergence
+	}
+	return next, nil
+}
+
+// universalGuess returns a starting universal anomaly: the elliptical mean-motion estimate, the
+// hyperbolic estimate of Vallado's Algorithm 8, or one based on the current radius near parabolic.
+func universalGuess(r0, rdotv, alpha, mu, dt float64) float64 {
+	sqrtMu := math.Sqrt(mu)
+	switch {
+	case alpha > stumpffSeriesLimit/r0:
+		return sqrtMu * dt * alpha
+	case alpha < -stumpffSeriesLimit/r0:
+		a := 1 / alpha
+		sign := math.Copysign(1, dt)
+		num := -2 * mu * alpha * dt
+		den := rdotv + sign*math.Sqrt(-mu*a)*(1-r0*alpha)
+		if guess := sign * math.Sqrt(-a) * math.Log(num/den); !math.IsNaN(guess) && !math.IsInf(guess, 0) {
+			return guess
+		}
+	}
+	return sqrtMu * dt / r0
+}
+
+// stumpff returns the Stumpff functions c2(ψ) and c3(ψ).
+func stumpff(psi float64) (c2, c3 float64) {
+	switch {
+	case psi > stumpffSeriesLimit:
+		s := math.Sqrt(psi)
+		return (1 - math.Cos(s)) / psi, (s - math.Sin(s)) / (psi * s)
+	case psi < -stumpffSeriesLimit:
+		s := math.Sqrt(-psi)
+		return (math.Cosh(s) - 1) / -psi, (math.Sinh(s) - s) / (-psi * s)
+	default:
+		return 1.0/2 - psi/24 + psi*psi/720, 1.0/6 - psi/120 + psi*psi/5040
+	}
+}
+
+func finite(s StateVector) bool {
+	for _, x := range []float64{s.Position.X, s.Position.Y, s.Position.Z, s.Velocity.X, s.Velocity.Y, s.Velocity.Z} {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package orbits
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func energy(s StateVector, mu float64) float64 {
+	return length(s.Velocity)*length(s.Velocity)/2 - mu/length(s.Position)
+}
+
+func closeVectors(a, b visibility.Vector3, tolerance float64) bool {
+	return length(visibility.Vector3{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}) <= tolerance
+}
+
+func TestPropagateStateMatchesEllipticalSolver(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := KeplerianElements{
+		SemiMajorAxis:       26600,
+		Eccentricity:        0.74,
+		Inclination:         1.1,
+		RAAN:                0.3,
+		ArgumentOfPeriapsis: 4.7,
+		MeanAnomaly:         0.5,
+		Epoch:               epoch,
+	}
+	start := elements.StateAt(epoch)
+	for _, dt := range []float64{60, 3600, 43082, 5 * 86400, -7200} {
+		got, err := PropagateState(start, 0, dt)
+		if err != nil {
+			t.Fatalf("dt %v: %v", dt, err)
+		}
+		want := elements.StateAt(epoch.Add(time.Duration(dt * float64(time.Second))))
+		if !closeVectors(got.Position, want.Position, 1e-5) || !closeVectors(got.Velocity, want.Velocity, 1e-8) {
+			t.Fatalf("dt %v: universal %+v, Kepler %+v", dt, got, want)
+		}
+	}
+}
+
+func TestHyperbolicTrajectory(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := KeplerianElements{SemiMajorAxis: -20000, Eccentricity: 1.4, Inclination: 0.5, MeanAnomaly: -2, Epoch: epoch}
+	if err := elements.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := EarthMu / (2 * 20000) // specific energy of a hyperbola is +mu/2|a|
+
+	for _, dt := range []time.Duration{0, time.Hour, 24 * time.Hour, 90 * 24 * time.Hour} {
+		state := elements.StateAt(epoch.Add(dt))
+		if !finite(state) || state == (StateVector{}) {
+			t.Fatalf("state at %v is not usable: %+v", dt, state)
+		}
+		if got := energy(state, EarthMu); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("energy at %v = %v, want %v", dt, got, want)
+		}
+		// The radius follows from the hyperbolic anomaly solving M = e sinh H - H.
+		M := elements.MeanAnomaly + elements.MeanMotion()*dt.Seconds()
+		H := math.Asinh(M / elements.Eccentricity)
+		for i := 0; i < 100; i++ {
+			H -= (elements.Eccentricity*math.Sinh(H) - H - M) / (elements.Eccentricity*math.Cosh(H) - 1)
+		}
+		if r, wantR := length(state.Position), 20000*(elements.Eccentricity*math.Cosh(H)-1); math.Abs(r-wantR) > 1e-6*wantR {
+			t.Fatalf("radius at %v = %v, want %v", dt, r, wantR)
+		}
+	}
+
+	// Before periapsis the trajectory is inbound.
+	inbound := elements.StateAt(epoch)
+	if inbound.Position.X*inbound.Velocity.X+inbound.Position.Y*inbound.Velocity.Y+inbound.Position.Z*inbound.Velocity.Z >= 0 {
+		t.Fatal("expected negative radial velocity before periapsis")
+	}
+}
+
+func TestParabolicTrajectory(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	elements := KeplerianElements{Eccentricity: 1, PeriapsisRadius: 7000, Epoch: epoch}
+	if err := elements.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := 2 * elements.PeriapsisRadius
+
+	for _, dt := range []time.Duration{0, 10 * time.Minute, 6 * time.Hour, -3 * time.Hour} {
+		state := elements.StateAt(epoch.Add(dt))
+		if got := energy(state, EarthMu); math.Abs(got) > 1e-9 {
+			t.Fatalf("energy at %v = %v, want 0", dt, got)
+		}
+		// Barker's equation: n·t = (D + D³/3) / 2 with D = tan(ν/2) and r = q(1 + D²).
+		r := length(state.Position)
+		D := math.Copysign(math.Sqrt(r/elements.PeriapsisRadius-1), dt.Seconds())
+		if got, want := (D+D*D*D/3)/2, math.Sqrt(EarthMu/(p*p*p))*dt.Seconds(); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("Barker's equation at %v: %v, want %v", dt, got, want)
+		}
+	}
+}
+
+func TestPropagateStateRoundTrip(t *testing.T) {
+	states := []StateVector{
+		{Position: visibility.Vector3{X: 7000}, Velocity: visibility.Vector3{Y: 7.5}},                           // near-circular
+		{Position: visibility.Vector3{X: 7000}, Velocity: visibility.Vector3{Y: math.Sqrt(2 * EarthMu / 7000)}}, // parabolic
+		{Position: visibility.Vector3{X: 7000, Z: 100}, Velocity: visibility.Vector3{X: 1, Y: 12}},              // hyperbolic
+	}
+	for i, start := range states {
+		forward, err := PropagateState(start, 0, 7200)
+		if err != nil {
+			t.Fatalf("state %d: %v", i, err)
+		}
+		back, err := PropagateState(forward, 0, -7200)
+		if err != nil {
+			t.Fatalf("state %d: %v", i, err)
+		}
+		if !closeVectors(back.Position, start.Position, 1e-6) || !closeVectors(back.Velocity, start.Velocity, 1e-9) {
+			t.Fatalf("state %d did not return: %+v vs %+v", i, back, start)
+		}
+		if math.Abs(energy(forward, EarthMu)-energy(start, EarthMu)) > 1e-9 {
+			t.Fatalf("state %d: energy not conserved", i)
+		}
+	}
+
+	if _, err := PropagateState(StateVector{}, 0, 60); err == nil {
+		t.Fatal("expected a state at the center to be rejected")
+	}
+}
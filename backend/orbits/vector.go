@@ -0,0 +1,24 @@
+package orbits
+
+import (
+	"math"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func dot(a, b visibility.Vector3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func norm(v visibility.Vector3) float64 {
+	return math.Sqrt(dot(v, v))
+}
+
+func cross(a, b visibility.Vector3) visibility.Vector3 {
+	return visibility.Vector3{X: a.Y*b.Z - a.Z*b.Y, Y: a.Z*b.X - a.X*b.Z, Z: a.X*b.Y - a.Y*b.X}
+}
+
+// combine returns a·u + b·v.
+func combine(a float64, u visibility.Vector3, b float64, v visibility.Vector3) visibility.Vector3 {
+	return visibility.Vector3{X: a*u.X + b*v.X, Y: a*u.Y + b*v.Y, Z: a*u.Z + b*v.Z}
+}
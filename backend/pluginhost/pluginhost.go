@@ -0,0 +1,312 @@
+// Package pluginhost loads routers, visibility models and link budgets from plugin processes at
+// startup, so proprietary models can run inside SatNet without forking it.
+//
+// A plugin is any executable. The host starts it once and speaks newline-delimited JSON with it
+// over stdin and stdout, one request per line in and one response per line out, in order. The
+// plugin's stderr is passed through to the host's. A plugin first writes a handshake line naming
+// what it provides:
+//
+//	{"protocol": 1, "routers": ["..."], "visibilityModels": ["..."], "linkBudgets": ["..."]}
+//
+// It then answers requests, each naming one of its models:
+//
+//	{"method": "route", "model", "graph": {"nodes", "edges"}, "from", "to"} -> {"path": ["..."]}
+//	{"method": "visible", "model", "links": [{"a", "b", "latencyMs"}]}     -> {"visible": [true]}
+//	{"method": "throughput", "model", "links": [...]}                      -> {"throughput": [1.5]}
+//
+// Nodes and edges are routing.Node and routing.Edge in their JSON form, as shard workers exchange
+// them. Any request may instead be answered with {"error": "..."}.
+package pluginhost
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+// Protocol is the plugin protocol version this host speaks.
+const Protocol = 1
+
+// HandshakeTimeout bounds how long a plugin may take to start and write its handshake.
+var HandshakeTimeout = 10 * time.Second
+
+// handshake is the first line a plugin writes.
+type handshake struct {
+	Protocol         int      `json:"protocol"`
+	Routers          []string `json:"routers"`
+	VisibilityModels []string `json:"visibilityModels"`
+	LinkBudgets      []string `json:"linkBudgets"`
+}
+
+type wireGraph struct {
+	Nodes []routing.Node `json:"nodes"`
+	Edges []routing.Edge `json:"edges"`
+}
+
+type wireLink struct {
+	A         routing.Node `json:"a"`
+	B         routing.Node `json:"b"`
+	LatencyMS float64      `json:"latencyMs"`
+}
+
+type request struct {
+	Method string     `json:"method"`
+	Model  string     `json:"model"`
+	Graph  *wireGraph `json:"graph,omitempty"`
+	From   string     `json:"from,omitempty"`
+	To     string     `json:"to,omitempty"`
+	Links  []wireLink `json:"links,omitempty"`
+}
+
+type response struct {
+	Path       []string  `json:"path"`
+	Visible    []bool    `json:"visible"`
+	Throughput []float64 `json:"throughput"`
+	Error      string    `json:"error"`
+}
+
+// Host owns the running plugin processes and the models they provide.
+type Host struct {
+	plugins []*process
+	models  simulation.Plugins
+}
+
+// Load starts each command, given as the executable followed by its arguments, and collects the
+// models the plugins provide. Two plugins may not provide models of the same kind and name. On
+// failure the plugins already started are stopped.
+func Load(commands [][]string) (*Host, error) {
+	h := &Host{models: simulation.Plugins{
+		Routers:          make(map[string]simulation.Router),
+		VisibilityModels: make(map[string]simulation.VisibilityModel),
+		LinkBudgets:      make(map[string]simulation.LinkBudget),
+	}}
+	for _, command := range commands {
+		if err := h.load(command); err != nil {
+			h.Close()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func (h *Host) load(command []string) error {
+	if len(command) == 0 {
+		return errors.New("plugin command cannot be empty")
+	}
+	p, err := start(command)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", command[0], err)
+	}
+	h.plugins = append(h.plugins, p)
+	for _, name := range p.provides.Routers {
+		if _, exists := h.models.Routers[name]; exists {
+			return fmt.Errorf("plugin %s: router %q is already provided", command[0], name)
+		}
+		h.models.Routers[name] = router{p, name}
+	}
+	for _, name := range p.provides.VisibilityModels {
+		if _, exists := h.models.VisibilityModels[name]; exists {
+			return fmt.Errorf("plugin %s: visibility model %q is already provided", command[0], name)
+		}
+		h.models.VisibilityModels[name] = visibilityModel{p, name}
+	}
+	for _, name := range p.provides.LinkBudgets {
+		if _, exists := h.models.LinkBudgets[name]; exists {
+			return fmt.Errorf("plugin %s: link budget %q is already provided", command[0], name)
+		}
+		h.models.LinkBudgets[name] = linkBudget{p, name}
+	}
+	return nil
+}
+
+// Plugins returns the loaded models, ready for simulation.Config.Plugins.
+func (h *Host) Plugins() simulation.Plugins {
+	return h.models
+}
+
+// Names lists the loaded models by kind, sorted, for logging.
+func (h *Host) Names() map[string][]string {
+	names := map[string][]string{}
+	for name := range h.models.Routers {
+		names["routers"] = append(names["routers"], name)
+	}
+	for name := range h.models.VisibilityModels {
+		names["visibilityModels"] = append(names["visibilityModels"], name)
+	}
+	for name := range h.models.LinkBudgets {
+		names["linkBudgets"] = append(names["linkBudgets"], name)
+	}
+	for _, list := range names {
+		sort.Strings(list)
+	}
+	return names
+}
+
+// Close stops every plugin, letting each exit once its stdin closes.
+func (h *Host) Close() error {
+	var errs []error
+	for _, p := range h.plugins {
+		errs = append(errs, p.close())
+	}
+	return errors.Join(errs...)
+}
+
+// process is one running plugin. Requests are serialized; once the plugin fails to answer, every
+// later request fails too.
+type process struct {
+	name     string
+	provides handshake
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	err    error
+}
+
+func start(command []string) (*process, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	p := &process{name: command[0], cmd: cmd, stdin: stdin, stdout: scanner}
+
+	read := make(chan error, 1)
+	go func() {
+		if !scanner.Scan() {
+			read <- fmt.Errorf("no handshake: %w", scanErr(scanner))
+			return
+		}
+		read <- json.Unmarshal(scanner.Bytes(), &p.provides)
+	}()
+	select {
+	case err = <-read:
+	case <-time.After(HandshakeTimeout):
+		err = fmt.Errorf("no handshake within %v", HandshakeTimeout)
+	}
+	if err == nil && p.provides.Protocol != Protocol {
+		err = fmt.Errorf("speaks protocol %d, want %d", p.provides.Protocol, Protocol)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		p.close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func scanErr(scanner *bufio.Scanner) error {
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+func (p *process) call(req request) (response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return response{}, p.err
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		return response{}, err
+	}
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		p.err = fmt.Errorf("plugin %s: %w", p.name, err)
+		return response{}, p.err
+	}
+	if !p.stdout.Scan() {
+		p.err = fmt.Errorf("plugin %s: %w", p.name, scanErr(p.stdout))
+		return response{}, p.err
+	}
+	var resp response
+	if err := json.Unmarshal(p.stdout.Bytes(), &resp); err != nil {
+		p.err = fmt.Errorf("plugin %s: malformed response: %w", p.name, err)
+		return response{}, p.err
+	}
+	if resp.Error != "" {
+		return response{}, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+func (p *process) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = fmt.Errorf("plugin %s closed", p.name)
+	}
+	p.stdin.Close()
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	return nil
+}
+
+func wireLinks(links []simulation.Link) []wireLink {
+	out := make([]wireLink, len(links))
+	for i, link := range links {
+		out[i] = wireLink{A: link.A, B: link.B, LatencyMS: link.LatencyMS}
+	}
+	return out
+}
+
+type router struct {
+	p    *process
+	name string
+}
+
+func (r router) Route(graph *routing.Graph, from, to string) ([]string, error) {
+	wire := &wireGraph{Nodes: make([]routing.Node, 0, len(graph.Nodes))}
+	for _, node := range graph.Nodes {
+		wire.Nodes = append(wire.Nodes, node)
+	}
+	sort.Slice(wire.Nodes, func(i, j int) bool { return wire.Nodes[i].ID < wire.Nodes[j].ID })
+	for _, node := range wire.Nodes {
+		wire.Edges = append(wire.Edges, graph.Adj[node.ID]...)
+	}
+	resp, err := r.p.call(request{Method: "route", Model: r.name, Graph: wire, From: from, To: to})
+	return resp.Path, err
+}
+
+type visibilityModel struct {
+	p    *process
+	name string
+}
+
+func (m visibilityModel) Visible(links []simulation.Link) ([]bool, error) {
+	resp, err := m.p.call(request{Method: "visible", Model: m.name, Links: wireLinks(links)})
+	return resp.Visible, err
+}
+
+type linkBudget struct {
+	p    *process
+	name string
+}
+
+func (b linkBudget) Throughput(links []simulation.Link) ([]float64, error) {
+	resp, err := b.p.call(request{Method: "throughput", Model: b.name, Links: wireLinks(links)})
+	return resp.Throughput, err
+}
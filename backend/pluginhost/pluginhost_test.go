@@ -0,0 +1,122 @@
+package pluginhost
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/simulation"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// TestPluginHelperProcess is the plugin the tests start. Its "direct" router takes the one-hop
+// link when there is one, "no-relay" hides every link of satellite relay, and "flat" rates every
+// link at 42. With SATNET_PLUGIN_HELPER=old it speaks a protocol the host does not.
+func TestPluginHelperProcess(t *testing.T) {
+	mode := os.Getenv("SATNET_PLUGIN_HELPER")
+	if mode == "" {
+		return
+	}
+	out := json.NewEncoder(os.Stdout)
+	if mode == "old" {
+		out.Encode(map[string]any{"protocol": 0})
+		os.Exit(0)
+	}
+	out.Encode(handshake{Protocol: Protocol, Routers: []string{"direct"}, VisibilityModels: []string{"no-relay"}, LinkBudgets: []string{"flat"}})
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		var req request
+		json.Unmarshal(scanner.Bytes(), &req)
+		switch req.Method {
+		case "route":
+			var resp response
+			for _, e := range req.Graph.Edges {
+				if e.From == req.From && e.To == req.To {
+					resp.Path = []string{req.From, req.To}
+				}
+			}
+			if resp.Path == nil {
+				resp.Error = "no direct link"
+			}
+			out.Encode(resp)
+		case "visible":
+			resp := response{Visible: make([]bool, len(req.Links))}
+			for i, link := range req.Links {
+				resp.Visible[i] = link.A.ID != "relay" && link.B.ID != "relay"
+			}
+			out.Encode(resp)
+		case "throughput":
+			resp := response{Throughput: make([]float64, len(req.Links))}
+			for i := range resp.Throughput {
+				resp.Throughput[i] = 42
+			}
+			out.Encode(resp)
+		default:
+			fmt.Println(`{"error": "unknown method"}`)
+		}
+	}
+	os.Exit(0)
+}
+
+func helperCommand(t *testing.T, mode string) []string {
+	t.Setenv("SATNET_PLUGIN_HELPER", mode)
+	return []string{os.Args[0], "-test.run=^TestPluginHelperProcess$"}
+}
+
+func TestHostRunsPluginModelsInsideTheSimulator(t *testing.T) {
+	host, err := Load([][]string{helperCommand(t, "models")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer host.Close()
+	if names := host.Names(); len(names["routers"]) != 1 || names["linkBudgets"][0] != "flat" {
+		t.Fatalf("unexpected models %v", names)
+	}
+
+	sat := func(id string, y float64) simulation.Satellite {
+		return simulation.Satellite{ID: id, Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: y}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}}
+	}
+	cfg := simulation.Config{
+		GridConfig:      coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites:      []simulation.Satellite{sat("sat", 0), sat("relay", 50)},
+		GroundStations:  []simulation.GroundStation{{ID: "gs", Location: &visibility.Geodetic{}}},
+		Traffic:         []simulation.TrafficDemand{{ID: "up", FromID: "gs", ToID: "sat", Policy: "direct"}, {ID: "relay", FromID: "gs", ToID: "relay"}},
+		Plugins:         host.Plugins(),
+		VisibilityModel: "no-relay",
+		LinkBudget:      "flat",
+	}
+	sim, err := simulation.NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := sim.Snapshot()
+	if path, ok := snap.Routes["up"]; !ok || strings.Join(path.Nodes, ",") != "gs,sat" || path.BottleneckThroughput != 42 {
+		t.Fatalf("expected the plugin router's direct path at the plugin budget's rate, got %+v", snap.Routes)
+	}
+	if _, ok := snap.Routes["relay"]; ok {
+		t.Fatal("expected the visibility model to hide every link of relay")
+	}
+
+	cfg.LinkBudget = "missing"
+	if _, err := simulation.NewSimulator(cfg); err == nil {
+		t.Fatal("expected an unknown link budget to be rejected")
+	}
+}
+
+func TestLoadRejectsBadPlugins(t *testing.T) {
+	if _, err := Load([][]string{helperCommand(t, "old")}); err == nil || !strings.Contains(err.Error(), "protocol") {
+		t.Fatalf("expected a protocol mismatch, got %v", err)
+	}
+	command := helperCommand(t, "models")
+	if _, err := Load([][]string{command, command}); err == nil || !strings.Contains(err.Error(), "already provided") {
+		t.Fatalf("expected duplicate models to be rejected, got %v", err)
+	}
+	if _, err := Load([][]string{{"true"}}); err == nil {
+		t.Fatal("expected a plugin without a handshake to be rejected")
+	}
+}
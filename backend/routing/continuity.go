@@ -0,0 +1,25 @@
+package routing
+
+// RetainPreviousPath models operators' reluctance to churn routes. When the previous path is still
+// valid in g and its latency is within tolerancePct percent of the optimal path, the previous path is
+// returned with ContinuityPenaltyMS set to the latency given up; otherwise optimal is returned unchanged.
+func RetainPreviousPath(g *Graph, previous, optimal Path, tolerancePct float64) Path {
+	if tolerancePct <= 0 || len(previous.Nodes) < 2 || equalPrefix(previous.Nodes, optimal.Nodes) {
+		return optimal
+	}
+	if previous.Nodes[0] != optimal.Nodes[0] || previous.Nodes[len(previous.Nodes)-1] != optimal.Nodes[len(optimal.Nodes)-1] {
+		return optimal
+	}
+
+	current, err := g.pathMetrics(previous.Nodes)
+	if err != nil {
+		// A hop disappeared, so the previous path cannot be kept.
+		return optimal
+	}
+	if current.LatencyMS > optimal.LatencyMS*(1+tolerancePct/100) {
+		return optimal
+	}
+
+	current.ContinuityPenaltyMS = current.LatencyMS - optimal.LatencyMS
+	return current
+}
@@ -0,0 +1,39 @@
+package routing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRetainPreviousPathWithinTolerance(t *testing.T) {
+	g, err := BuildGraph(testNodes())
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	paths, err := KAlternativeRoutes(g, "ground-a", "ground-b", 2)
+	if err != nil || len(paths) < 2 {
+		t.Fatalf("expected two routes, got %v (err %v)", paths, err)
+	}
+	optimal, previous := paths[0], paths[1]
+	slowdownPct := (previous.LatencyMS/optimal.LatencyMS - 1) * 100
+
+	kept := RetainPreviousPath(g, previous, optimal, slowdownPct+1)
+	if !equalPrefix(kept.Nodes, previous.Nodes) {
+		t.Fatalf("expected previous path to be retained, got %v", kept.Nodes)
+	}
+	if math.Abs(kept.ContinuityPenaltyMS-(previous.LatencyMS-optimal.LatencyMS)) > 1e-9 {
+		t.Fatalf("unexpected continuity penalty %.6f", kept.ContinuityPenaltyMS)
+	}
+
+	switched := RetainPreviousPath(g, previous, optimal, slowdownPct/2)
+	if !equalPrefix(switched.Nodes, optimal.Nodes) || switched.ContinuityPenaltyMS != 0 {
+		t.Fatalf("expected optimal path when previous exceeds tolerance, got %+v", switched)
+	}
+
+	broken := g.Clone()
+	broken.RemoveEdge(previous.Nodes[0], previous.Nodes[1])
+	if got := RetainPreviousPath(broken, previous, optimal, 1000); !equalPrefix(got.Nodes, optimal.Nodes) {
+		t.Fatalf("expected optimal path when previous path lost a hop, got %v", got.Nodes)
+	}
+}
@@ -1,12 +1,17 @@
 package routing
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 
 	"github.com/example/satnet/backend/visibility"
 )
 
+// ErrUnknownNode is returned when an edge or path query names a node the graph does not contain.
+var ErrUnknownNode = errors.New("unknown node")
+
 // NodeType differentiates between satellites and ground stations.
 type NodeType string
 
@@ -22,6 +27,15 @@ type Node struct {
 	ID       string
 	Type     NodeType
 	Position visibility.Vector3
+	Velocity visibility.Vector3 // km/s; zero for nodes that do not move
+	// ElevationMask (radians) overrides the graph-wide mask for ground nodes when it is stricter.
+	ElevationMask float64
+	// Bands lists the frequency bands the node's terminals operate in, for WithBands; empty means
+	// every band.
+	Bands []string
+	// User marks a ground node as a user terminal rather than a gateway, so its satellite links
+	// are user links instead of feeder links.
+	User bool
 }
 
 // Edge captures link characteristics between two nodes.
@@ -30,6 +44,14 @@ type Edge struct {
 	To         string
 	LatencyMS  float64
 	Throughput float64
+	ValidForS  float64 // estimated seconds until orbital motion breaks the link, capped at StabilityHorizon
+	Type       LinkType
+	// CapacityMbps is the direction's own capacity when it differs from the network's uniform one,
+	// such as a gateway uplink's against its downlink; zero leaves it to the caller's default.
+	CapacityMbps float64
+	// Metadata carries caller annotations, such as a scenario's per-type link metadata. Routing
+	// ignores it; edges may share one map, so replace it rather than writing to it.
+	Metadata map[string]string
 }
 
 // Graph stores connectivity and edge weights.
@@ -41,11 +63,70 @@ type Graph struct {
 // SpeedOfLightKMPerS defines the propagation speed for latency approximation.
 const SpeedOfLightKMPerS = 299792.458
 
-// BuildGraph constructs a bidirectional connectivity graph using line-of-sight rules.
-// Latency is approximated as slant range divided by the speed of light (milliseconds),
-// while throughput is inversely proportional to latency to represent distance loss.
-func BuildGraph(nodes []Node, elevationMask float64) (*Graph, error) {
-	g := &Graph{Nodes: make(map[string]Node), Adj: make(map[string][]Edge)}
+// BuildGraph constructs a bidirectional connectivity graph using line-of-sight rules, narrowed
+// and weighed by opts. Latency is approximated as slant range divided by the speed of light
+// (milliseconds), while throughput is inversely proportional to latency to represent distance
+// loss unless a link budget is given. Each link is annotated with an estimate of how long it
+// remains visible given node velocities.
+func BuildGraph(nodes []Node, opts ...Option) (*Graph, error) {
+	return NewGraph(nodes, BuildEdges(nodes, nil, opts...))
+}
+
+// BuildEdges evaluates line-of-sight links for node pairs (i < j) accepted by include, returning
+// each direction of a visible link that closes on its own throughput. A nil include evaluates
+// every pair; sharded callers pass disjoint filters so the pairwise work can be split across
+// machines and merged with NewGraph.
+// A spatial hash limits the geometry tests to pairs within line-of-sight range of each other, so
+// edges are grouped by i but partners appear in spatial rather than index order.
+func BuildEdges(nodes []Node, include func(i, j int) bool, opts ...Option) []Edge {
+	o := newGraphOptions(opts)
+	horizons := make([]float64, len(nodes))
+	maxHorizon := 0.0
+	for i, n := range nodes {
+		horizons[i] = horizonDistance(n)
+		maxHorizon = math.Max(maxHorizon, horizons[i])
+	}
+	index := newSpatialHash(nodes, 2*maxHorizon+linkRangeSlackKm)
+
+	var edges []Edge
+	var candidates []int
+	for i := range nodes {
+		candidates = index.appendNear(candidates[:0], nodes[i].Position, i)
+		for _, j := range candidates {
+			a, b := nodes[i], nodes[j]
+			if visibility.SlantRange(a.Position, b.Position) > horizons[i]+horizons[j]+linkRangeSlackKm {
+				continue
+			}
+			if include != nil && !include(i, j) {
+				continue
+			}
+			if !o.allowed(a, b) {
+				continue
+			}
+			mask := o.mask(a, b)
+			if !LinkVisible(a, b, mask) {
+				continue
+			}
+			forward, reverse := o.throughputs(a, b)
+			if forward <= 0 && reverse <= 0 {
+				continue
+			}
+			validFor := EstimateLinkLifetime(a, b, mask)
+			if forward > 0 {
+				edges = append(edges, newEdge(a, b, validFor, forward))
+			}
+			if reverse > 0 {
+				edges = append(edges, newEdge(b, a, validFor, reverse))
+			}
+		}
+	}
+	return edges
+}
+
+// NewGraph assembles a graph from nodes and precomputed edges. Edges must reference known nodes.
+// Adjacency lists are carved from a single allocation sized from the node degrees.
+func NewGraph(nodes []Node, edges []Edge) (*Graph, error) {
+	g := &Graph{Nodes: make(map[string]Node, len(nodes))}
 	for _, n := range nodes {
 		if n.ID == "" {
 			return nil, errors.New("node ID cannot be empty")
@@ -53,41 +134,65 @@ func BuildGraph(nodes []Node, elevationMask float64) (*Graph, error) {
 		g.Nodes[n.ID] = n
 	}
 
-	addEdge := func(a, b Node) {
-		dist := visibility.SlantRange(a.Position, b.Position)
-		latency := (dist / SpeedOfLightKMPerS) * 1000
-		throughput := 1.0 / (1.0 + latency)
-		edge := Edge{From: a.ID, To: b.ID, LatencyMS: latency, Throughput: throughput}
-		g.Adj[a.ID] = append(g.Adj[a.ID], edge)
+	degree := make(map[string]int, len(nodes))
+	for _, e := range edges {
+		if _, ok := g.Nodes[e.From]; !ok {
+			return nil, fmt.Errorf("edge references %w %q", ErrUnknownNode, e.From)
+		}
+		if _, ok := g.Nodes[e.To]; !ok {
+			return nil, fmt.Errorf("edge references %w %q", ErrUnknownNode, e.To)
+		}
+		degree[e.From]++
 	}
 
-	for i := 0; i < len(nodes); i++ {
-		for j := i + 1; j < len(nodes); j++ {
-			a, b := nodes[i], nodes[j]
+	g.Adj = make(map[string][]Edge, len(degree))
+	backing := make([]Edge, 0, len(edges))
+	for _, n := range nodes {
+		if d := degree[n.ID]; d > 0 {
+			start := len(backing)
+			backing = backing[:start+d]
+			// The capacity limit keeps a later append on one list from overwriting the next.
+			g.Adj[n.ID] = backing[start : start : start+d]
+			delete(degree, n.ID)
+		}
+	}
+	for _, e := range edges {
+		g.Adj[e.From] = append(g.Adj[e.From], e)
+	}
+	return g, nil
+}
 
-			switch {
-			case a.Type == Satellite && b.Type == Satellite:
-				if visibility.SatelliteToSatelliteVisible(a.Position, b.Position) {
-					addEdge(a, b)
-					addEdge(b, a)
-				}
-			case a.Type == Ground && b.Type == Satellite:
-				if visibility.GroundToSatelliteVisible(a.Position, b.Position, elevationMask) {
-					addEdge(a, b)
-					addEdge(b, a)
-				}
-			case a.Type == Satellite && b.Type == Ground:
-				if visibility.GroundToSatelliteVisible(b.Position, a.Position, elevationMask) {
-					addEdge(a, b)
-					addEdge(b, a)
-				}
-			default:
-				// Ground-to-ground links not supported in this model.
-			}
+// EdgesFrom calls yield for each edge leaving id, in insertion order, until yield returns false.
+// Unlike reading Adj directly it never copies the adjacency list, and yield may not modify the graph.
+func (g *Graph) EdgesFrom(id string, yield func(Edge) bool) {
+	edges := g.Adj[id]
+	for i := range edges {
+		if !yield(edges[i]) {
+			return
 		}
 	}
+}
 
-	return g, nil
+func newEdge(a, b Node, validFor, throughput float64) Edge {
+	dist := visibility.SlantRange(a.Position, b.Position)
+	latency := (dist / SpeedOfLightKMPerS) * 1000
+	return Edge{From: a.ID, To: b.ID, LatencyMS: latency, Throughput: throughput, ValidForS: validFor, Type: LinkTypeOf(a, b)}
+}
+
+// LinkVisible applies the line-of-sight rules for the node type pairing, as BuildGraph does when
+// creating edges. elevationMask (radians) is the graph-wide mask.
+func LinkVisible(a, b Node, elevationMask float64) bool {
+	switch {
+	case a.Type == Satellite && b.Type == Satellite:
+		return visibility.SatelliteToSatelliteVisible(a.Position, b.Position)
+	case a.Type == Ground && b.Type == Satellite:
+		return visibility.GroundToSatelliteVisible(a.Position, b.Position, math.Max(elevationMask, a.ElevationMask))
+	case a.Type == Satellite && b.Type == Ground:
+		return visibility.GroundToSatelliteVisible(b.Position, a.Position, math.Max(elevationMask, b.ElevationMask))
+	default:
+		// Ground-to-ground links not supported in this model.
+		return false
+	}
 }
 
 // Clone creates a deep copy of the graph for algorithms that mutate state.
@@ -142,35 +247,75 @@ func (g *Graph) Heuristic(from, to string) float64 {
 }
 
 // Path represents an ordered path with cumulative metrics.
+// StabilityS is the shortest remaining lifetime of any hop, i.e. how long the path is expected to stay valid.
+// ContinuityPenaltyMS is the latency accepted over the optimum when a previous path was retained.
 type Path struct {
-	Nodes                []string
-	LatencyMS            float64
-	BottleneckThroughput float64
+	Nodes                []string `json:"nodes"`
+	LatencyMS            float64  `json:"latencyMs"`
+	BottleneckThroughput float64  `json:"bottleneckThroughput"`
+	StabilityS           float64  `json:"stabilityS"`
+	ContinuityPenaltyMS  float64  `json:"continuityPenaltyMs,omitempty"`
+}
+
+// pathJSON is Path with an optional bottleneck: JSON has no infinity, which is the bottleneck of
+// a single-node path.
+type pathJSON struct {
+	Nodes                []string `json:"nodes"`
+	LatencyMS            float64  `json:"latencyMs"`
+	BottleneckThroughput *float64 `json:"bottleneckThroughput"`
+	StabilityS           float64  `json:"stabilityS"`
+	ContinuityPenaltyMS  float64  `json:"continuityPenaltyMs,omitempty"`
+}
+
+// MarshalJSON encodes an unbounded BottleneckThroughput as null.
+func (p Path) MarshalJSON() ([]byte, error) {
+	out := pathJSON{Nodes: p.Nodes, LatencyMS: p.LatencyMS, StabilityS: p.StabilityS, ContinuityPenaltyMS: p.ContinuityPenaltyMS}
+	if !math.IsInf(p.BottleneckThroughput, 1) {
+		out.BottleneckThroughput = &p.BottleneckThroughput
+	}
+	return json.Marshal(out)
 }
 
-// computePathMetrics evaluates latency and bottleneck throughput along a path.
-func (g *Graph) computePathMetrics(sequence []string) (float64, float64, error) {
-	if len(sequence) < 2 {
-		return 0, math.Inf(1), nil
+// UnmarshalJSON decodes a null BottleneckThroughput as unbounded.
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var in pathJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
 	}
-	totalLatency := 0.0
-	bottleneck := math.Inf(1)
+	*p = Path{Nodes: in.Nodes, LatencyMS: in.LatencyMS, BottleneckThroughput: math.Inf(1), StabilityS: in.StabilityS, ContinuityPenaltyMS: in.ContinuityPenaltyMS}
+	if in.BottleneckThroughput != nil {
+		p.BottleneckThroughput = *in.BottleneckThroughput
+	}
+	return nil
+}
+
+// PathAlong evaluates the metrics of a fixed hop sequence, failing when a hop is not a link in g.
+func (g *Graph) PathAlong(sequence []string) (Path, error) {
+	return g.pathMetrics(sequence)
+}
+
+// pathMetrics evaluates latency, bottleneck throughput, and stability along a path.
+func (g *Graph) pathMetrics(sequence []string) (Path, error) {
+	path := Path{Nodes: sequence, BottleneckThroughput: math.Inf(1), StabilityS: StabilityHorizon.Seconds()}
 	for i := 0; i < len(sequence)-1; i++ {
 		from, to := sequence[i], sequence[i+1]
 		edgeFound := false
 		for _, e := range g.Adj[from] {
 			if e.To == to {
-				totalLatency += e.LatencyMS
-				if e.Throughput < bottleneck {
-					bottleneck = e.Throughput
+				path.LatencyMS += e.LatencyMS
+				if e.Throughput < path.BottleneckThroughput {
+					path.BottleneckThroughput = e.Throughput
+				}
+				if e.ValidForS < path.StabilityS {
+					path.StabilityS = e.ValidForS
 				}
 				edgeFound = true
 				break
 			}
 		}
 		if !edgeFound {
-			return 0, 0, errors.New("path references missing edge")
+			return Path{}, fmt.Errorf("%w: path references missing edge", ErrNoRoute)
 		}
 	}
-	return totalLatency, bottleneck, nil
+	return path, nil
 }
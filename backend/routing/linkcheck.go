@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// LinkCheck breaks down the line-of-sight test LinkVisible applies to a pair of nodes. Angles are
+// in degrees. The elevation fields are set only for ground-to-satellite pairs.
+type LinkCheck struct {
+	Visible      bool    `json:"visible"`
+	SlantRangeKm float64 `json:"slantRangeKm"`
+	LatencyMS    float64 `json:"latencyMs"`
+	// EarthBlocked reports whether the straight line between the nodes passes through the Earth.
+	EarthBlocked bool `json:"earthBlocked"`
+	// LimbClearanceKm is the lowest altitude of the line of sight; negative when it cuts the Earth.
+	LimbClearanceKm float64 `json:"limbClearanceKm"`
+	// ElevationDeg is the satellite's elevation seen from the ground node, and MaskDeg the stricter
+	// of the graph-wide and station masks it must meet.
+	ElevationDeg *float64 `json:"elevationDeg,omitempty"`
+	MaskDeg      *float64 `json:"maskDeg,omitempty"`
+	MeetsMask    *bool    `json:"meetsMask,omitempty"`
+	// OffNadirDeg is the ground node's angle from the satellite's nadir.
+	OffNadirDeg *float64 `json:"offNadirDeg,omitempty"`
+	// Reason says why the link is not visible.
+	Reason string `json:"reason,omitempty"`
+}
+
+// CheckLink explains LinkVisible for a and b under the graph-wide elevationMask (radians).
+func CheckLink(a, b Node, elevationMask float64) LinkCheck {
+	dist := visibility.SlantRange(a.Position, b.Position)
+	check := LinkCheck{
+		Visible:         LinkVisible(a, b, elevationMask),
+		SlantRangeKm:    dist,
+		LatencyMS:       dist / SpeedOfLightKMPerS * 1000,
+		EarthBlocked:    !visibility.SatelliteToSatelliteVisible(a.Position, b.Position),
+		LimbClearanceKm: visibility.LimbClearance(a.Position, b.Position),
+	}
+
+	ground, sat := a, b
+	switch {
+	case a.Type == Ground && b.Type == Ground:
+		check.Reason = "ground stations do not link to each other"
+		return check
+	case a.Type == Satellite && b.Type == Satellite:
+		if check.EarthBlocked {
+			check.Reason = "the Earth blocks the line of sight"
+		}
+		return check
+	case a.Type == Satellite:
+		ground, sat = b, a
+	}
+	mask := math.Max(elevationMask, ground.ElevationMask)
+	elevation := visibility.Elevation(ground.Position, sat.Position)
+	elevationDeg, maskDeg := elevation*180/math.Pi, mask*180/math.Pi
+	meets := elevation >= mask
+	offNadir := visibility.OffNadirAngle(sat.Position, ground.Position) * 180 / math.Pi
+	check.ElevationDeg, check.MaskDeg, check.MeetsMask, check.OffNadirDeg = &elevationDeg, &maskDeg, &meets, &offNadir
+	switch {
+	case !meets:
+		check.Reason = fmt.Sprintf("elevation %.1f° is below the %.1f° mask", elevationDeg, maskDeg)
+	case check.EarthBlocked:
+		check.Reason = "the Earth blocks the line of sight"
+	}
+	return check
+}
@@ -0,0 +1,47 @@
+package routing
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestCheckLinkAgreesWithLinkVisible(t *testing.T) {
+	nodes := testNodes()
+	for _, a := range nodes {
+		for _, b := range nodes {
+			if a.ID == b.ID {
+				continue
+			}
+			check := CheckLink(a, b, 0)
+			if check.Visible != LinkVisible(a, b, 0) {
+				t.Fatalf("%s-%s: check says visible=%v", a.ID, b.ID, check.Visible)
+			}
+			if check.Visible == (check.Reason != "") {
+				t.Fatalf("%s-%s: visible=%v with reason %q", a.ID, b.ID, check.Visible, check.Reason)
+			}
+		}
+	}
+}
+
+func TestCheckLinkReportsElevationMask(t *testing.T) {
+	er := visibility.EarthRadius
+	ground := Node{ID: "gw", Type: Ground, Position: visibility.Vector3{X: er}, ElevationMask: 20 * math.Pi / 180}
+	low := Node{ID: "low", Type: Satellite, Position: visibility.FromGeodetic(0, 15, 550)}
+
+	check := CheckLink(low, ground, 10*math.Pi/180)
+	if check.Visible || check.MeetsMask == nil || *check.MeetsMask || check.EarthBlocked {
+		t.Fatalf("expected a clear line of sight below the mask, got %+v", check)
+	}
+	if *check.MaskDeg != 20 || *check.ElevationDeg <= 0 || *check.ElevationDeg >= 20 {
+		t.Fatalf("expected the station's 20° mask and a low elevation, got mask %v elevation %v", *check.MaskDeg, *check.ElevationDeg)
+	}
+	if !strings.Contains(check.Reason, "below the 20.0° mask") {
+		t.Fatalf("unexpected reason %q", check.Reason)
+	}
+	if *check.OffNadirDeg <= 0 || *check.OffNadirDeg >= 90 {
+		t.Fatalf("unexpected off-nadir angle %v", *check.OffNadirDeg)
+	}
+}
@@ -0,0 +1,46 @@
+package routing
+
+import "fmt"
+
+// LinkType is the class of a link, derived from its endpoints, so frontends can style link
+// categories apart and analyses can filter by them.
+type LinkType string
+
+const (
+	// LinkISL is an inter-satellite link.
+	LinkISL LinkType = "isl"
+	// LinkFeeder connects a satellite to a gateway ground station.
+	LinkFeeder LinkType = "feeder"
+	// LinkUser connects a satellite to a user terminal.
+	LinkUser LinkType = "user"
+	// LinkTerrestrial connects two ground nodes.
+	LinkTerrestrial LinkType = "terrestrial"
+)
+
+// LinkTypes lists every link type.
+var LinkTypes = []LinkType{LinkISL, LinkFeeder, LinkUser, LinkTerrestrial}
+
+// ParseLinkType returns the link type named s.
+func ParseLinkType(s string) (LinkType, error) {
+	for _, t := range LinkTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown link type %q", s)
+}
+
+// LinkTypeOf classifies the link between a and b. Satellite links to ground nodes marked User are
+// user links and to other ground nodes feeder links.
+func LinkTypeOf(a, b Node) LinkType {
+	switch {
+	case a.Type == Satellite && b.Type == Satellite:
+		return LinkISL
+	case a.Type != Satellite && b.Type != Satellite:
+		return LinkTerrestrial
+	case a.User || b.User:
+		return LinkUser
+	default:
+		return LinkFeeder
+	}
+}
@@ -0,0 +1,66 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestLinkTypeOfClassifiesByEndpoints(t *testing.T) {
+	sat := Node{ID: "sat", Type: Satellite}
+	gateway := Node{ID: "gateway", Type: Ground}
+	terminal := Node{ID: "terminal", Type: Ground, User: true}
+	cases := []struct {
+		a, b Node
+		want LinkType
+	}{
+		{sat, sat, LinkISL},
+		{sat, gateway, LinkFeeder},
+		{gateway, sat, LinkFeeder},
+		{terminal, sat, LinkUser},
+		{sat, terminal, LinkUser},
+		{gateway, terminal, LinkTerrestrial},
+	}
+	for _, c := range cases {
+		if got := LinkTypeOf(c.a, c.b); got != c.want {
+			t.Fatalf("%s-%s: expected %s, got %s", c.a.ID, c.b.ID, c.want, got)
+		}
+	}
+
+	if _, err := ParseLinkType("feeder"); err != nil {
+		t.Fatalf("expected feeder to parse: %v", err)
+	}
+	if _, err := ParseLinkType("laser"); err == nil {
+		t.Fatalf("expected an unknown link type to be rejected")
+	}
+}
+
+func TestBuildGraphTypesEdges(t *testing.T) {
+	nodes := []Node{
+		{ID: "sat-a", Type: Satellite, Position: visibility.Vector3{X: 7000}},
+		{ID: "sat-b", Type: Satellite, Position: visibility.Vector3{X: 6900, Y: 1000}},
+		{ID: "gateway", Type: Ground, Position: visibility.Vector3{X: 6371}},
+		{ID: "terminal", Type: Ground, Position: visibility.Vector3{X: 6371, Z: 10}, User: true},
+	}
+	g, err := BuildGraph(nodes)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	want := map[[2]string]LinkType{
+		{"sat-a", "sat-b"}:    LinkISL,
+		{"gateway", "sat-a"}:  LinkFeeder,
+		{"sat-a", "terminal"}: LinkUser,
+	}
+	for link, linkType := range want {
+		var got LinkType
+		g.EdgesFrom(link[0], func(e Edge) bool {
+			if e.To == link[1] {
+				got = e.Type
+			}
+			return got == ""
+		})
+		if got != linkType {
+			t.Fatalf("expected a %s edge %s->%s, got %q", linkType, link[0], link[1], got)
+		}
+	}
+}
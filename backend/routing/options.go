@@ -0,0 +1,188 @@
+package routing
+
+import (
+	"github.com/example/satnet/backend/visibility"
+)
+
+// Option configures how BuildGraph and BuildEdges decide which links close and how they are
+// weighed. Without options every pair in line of sight links, with no elevation mask beyond the
+// ground nodes' own and throughput estimated from distance.
+type Option func(*graphOptions)
+
+// ISLPolicy decides whether two satellites in line of sight of each other may link.
+type ISLPolicy func(a, b Node) bool
+
+// NoISLs is the ISLPolicy of bent-pipe constellations: satellites only relay between the ground
+// nodes they see.
+var NoISLs ISLPolicy = func(a, b Node) bool { return false }
+
+// LinkBudget returns the throughput of the link direction from a to b, slantRangeKm apart, in place
+// of the distance-based estimate. It is asked for each direction separately, so uplinks and
+// downlinks may differ; directions without positive throughput do not close.
+type LinkBudget func(a, b Node, slantRangeKm float64) float64
+
+type graphOptions struct {
+	elevationMask float64
+	gatewayMask   *float64
+	terminalMask  *float64
+	maxISLRangeKm float64
+	islPolicy     ISLPolicy
+	linkBudget    LinkBudget
+	bands         map[string]bool
+}
+
+// WithElevationMask sets the graph-wide elevation mask, in radians, that satellites must clear
+// above ground nodes. A node's own stricter mask still applies.
+func WithElevationMask(mask float64) Option {
+	return func(o *graphOptions) { o.elevationMask = mask }
+}
+
+// WithGatewayMask sets the elevation mask, in radians, for gateways (ground nodes that are not
+// User terminals) in place of the graph-wide one.
+func WithGatewayMask(mask float64) Option {
+	return func(o *graphOptions) { o.gatewayMask = &mask }
+}
+
+// WithTerminalMask sets the elevation mask, in radians, for User terminals in place of the
+// graph-wide one, so they can be held to a different mask than gateways.
+func WithTerminalMask(mask float64) Option {
+	return func(o *graphOptions) { o.terminalMask = &mask }
+}
+
+// WithMaxISLRange drops inter-satellite links longer than km, the reach of the terminals. Zero
+// leaves it unlimited. Link lifetimes still account for line of sight only.
+func WithMaxISLRange(km float64) Option {
+	return func(o *graphOptions) { o.maxISLRangeKm = km }
+}
+
+// WithISLPolicy restricts inter-satellite links to the pairs policy accepts.
+func WithISLPolicy(policy ISLPolicy) Option {
+	return func(o *graphOptions) { o.islPolicy = policy }
+}
+
+// WithLinkBudget computes every link's throughput with budget.
+func WithLinkBudget(budget LinkBudget) Option {
+	return func(o *graphOptions) { o.linkBudget = budget }
+}
+
+// WithBands only closes links on one of bands that both nodes operate in. Nodes that list no
+// bands operate in all of them, and no bands at all leaves links unfiltered.
+func WithBands(bands ...string) Option {
+	return func(o *graphOptions) {
+		if len(bands) == 0 {
+			o.bands = nil
+			return
+		}
+		o.bands = make(map[string]bool, len(bands))
+		for _, band := range bands {
+			o.bands[band] = true
+		}
+	}
+}
+
+// LinkSettings is the serializable form of the options, for building edges in another process
+// such as a shard worker. Link budgets and ISL policies other than NoISLs are functions and have no
+// settings form.
+type LinkSettings struct {
+	ElevationMask float64  `json:"elevationMask"`
+	GatewayMask   *float64 `json:"gatewayMask,omitempty"`
+	TerminalMask  *float64 `json:"terminalMask,omitempty"`
+	MaxISLRangeKm float64  `json:"maxIslRangeKm,omitempty"`
+	NoISLs        bool     `json:"noIsls,omitempty"`
+	Bands         []string `json:"bands,omitempty"`
+}
+
+// Options returns the options the settings describe.
+func (l LinkSettings) Options() []Option {
+	opts := []Option{WithElevationMask(l.ElevationMask)}
+	if l.GatewayMask != nil {
+		opts = append(opts, WithGatewayMask(*l.GatewayMask))
+	}
+	if l.TerminalMask != nil {
+		opts = append(opts, WithTerminalMask(*l.TerminalMask))
+	}
+	if l.MaxISLRangeKm > 0 {
+		opts = append(opts, WithMaxISLRange(l.MaxISLRangeKm))
+	}
+	if l.NoISLs {
+		opts = append(opts, WithISLPolicy(NoISLs))
+	}
+	if len(l.Bands) > 0 {
+		opts = append(opts, WithBands(l.Bands...))
+	}
+	return opts
+}
+
+func newGraphOptions(opts []Option) graphOptions {
+	var o graphOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// mask returns the graph-wide elevation mask for a link between a and b: the gateway or terminal
+// mask of the ground-side node when one is set.
+func (o graphOptions) mask(a, b Node) float64 {
+	for _, n := range [2]Node{a, b} {
+		if n.Type == Satellite {
+			continue
+		}
+		if n.User && o.terminalMask != nil {
+			return *o.terminalMask
+		}
+		if !n.User && o.gatewayMask != nil {
+			return *o.gatewayMask
+		}
+	}
+	return o.elevationMask
+}
+
+// allowed applies the policy, range and band filters, which need no line-of-sight test.
+func (o graphOptions) allowed(a, b Node) bool {
+	if a.Type == Satellite && b.Type == Satellite {
+		if o.islPolicy != nil && !o.islPolicy(a, b) {
+			return false
+		}
+		if o.maxISLRangeKm > 0 && visibility.SlantRange(a.Position, b.Position) > o.maxISLRangeKm {
+			return false
+		}
+	}
+	return o.bands == nil || o.sharedBand(a, b)
+}
+
+func (o graphOptions) sharedBand(a, b Node) bool {
+	supports := func(n Node, band string) bool {
+		if len(n.Bands) == 0 {
+			return true
+		}
+		for _, b := range n.Bands {
+			if b == band {
+				return true
+			}
+		}
+		return false
+	}
+	for band := range o.bands {
+		if supports(a, band) && supports(b, band) {
+			return true
+		}
+	}
+	return false
+}
+
+// throughputs returns the link budget's throughput from a to b and back, or the distance-based
+// estimate for both.
+func (o graphOptions) throughputs(a, b Node) (forward, reverse float64) {
+	dist := visibility.SlantRange(a.Position, b.Position)
+	if o.linkBudget != nil {
+		return o.linkBudget(a, b, dist), o.linkBudget(b, a, dist)
+	}
+	throughput := distanceThroughput(dist)
+	return throughput, throughput
+}
+
+// distanceThroughput is inversely proportional to latency, representing distance loss.
+func distanceThroughput(slantRangeKm float64) float64 {
+	return 1.0 / (1.0 + slantRangeKm/SpeedOfLightKMPerS*1000)
+}
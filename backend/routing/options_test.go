@@ -0,0 +1,159 @@
+package routing
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func hasEdge(g *Graph, from, to string) bool {
+	for _, e := range g.Adj[from] {
+		if e.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+func edgeBetween(g *Graph, from, to string) *Edge {
+	for _, e := range g.Adj[from] {
+		if e.To == to {
+			return &e
+		}
+	}
+	return nil
+}
+
+func TestGraphOptionsNarrowLinks(t *testing.T) {
+	base, err := BuildGraph(testNodes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range [][2]string{{"sat-alpha", "sat-beta"}, {"sat-alpha", "sat-gamma"}, {"ground-a", "sat-alpha"}} {
+		if !hasEdge(base, link[0], link[1]) || !hasEdge(base, link[1], link[0]) {
+			t.Fatalf("expected the default graph to link %v", link)
+		}
+	}
+
+	bentPipe, err := BuildGraph(testNodes(), WithISLPolicy(NoISLs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasEdge(bentPipe, "sat-alpha", "sat-gamma") || !hasEdge(bentPipe, "ground-a", "sat-alpha") {
+		t.Fatal("expected NoISLs to drop only satellite-to-satellite links")
+	}
+
+	// sat-alpha and sat-beta are 3536 km apart, sat-alpha and sat-gamma 2500 km.
+	ranged, err := BuildGraph(testNodes(), WithMaxISLRange(3000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasEdge(ranged, "sat-alpha", "sat-beta") || !hasEdge(ranged, "sat-alpha", "sat-gamma") {
+		t.Fatal("expected the ISL range to drop only the longer link")
+	}
+
+	masked, err := BuildGraph(testNodes(), WithElevationMask(0), WithGatewayMask(89*math.Pi/180))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only sat-alpha and sat-beta, straight above ground-b, clear an 89° mask.
+	if len(masked.Adj["ground-a"]) != 0 || hasEdge(masked, "ground-b", "sat-gamma") || !hasEdge(masked, "ground-b", "sat-alpha") || !hasEdge(masked, "sat-alpha", "sat-beta") {
+		t.Fatal("expected the gateway mask to drop the low ground links and keep the ISLs")
+	}
+
+	// With ground-a a user terminal, the gateway mask no longer applies to it, but the terminal mask does.
+	nodes := testNodes()
+	nodes[0].User = true
+	split, err := BuildGraph(nodes, WithElevationMask(0), WithGatewayMask(89*math.Pi/180))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split.Adj["ground-a"]) == 0 || hasEdge(split, "ground-b", "sat-gamma") {
+		t.Fatal("expected the gateway mask to spare the user terminal")
+	}
+	terminals, err := BuildGraph(nodes, WithElevationMask(0), WithTerminalMask(89*math.Pi/180))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(terminals.Adj["ground-a"]) != 0 || !hasEdge(terminals, "ground-b", "sat-gamma") {
+		t.Fatal("expected the terminal mask to apply to the user terminal only")
+	}
+
+	nodes = testNodes()
+	nodes[0].Bands, nodes[2].Bands = []string{"Ka"}, []string{"Ku"}
+	banded, err := BuildGraph(nodes, WithBands("Ka", "Ku"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasEdge(banded, "ground-a", "sat-alpha") || !hasEdge(banded, "ground-a", "sat-gamma") {
+		t.Fatal("expected links only between nodes sharing a band, with unbanded nodes in every band")
+	}
+	unfiltered, err := BuildGraph(nodes, WithBands())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasEdge(unfiltered, "ground-a", "sat-alpha") {
+		t.Fatal("expected no bands to leave links unfiltered")
+	}
+}
+
+func TestLinkBudgetOptionSetsThroughput(t *testing.T) {
+	budget := func(a, b Node, slantRangeKm float64) float64 {
+		if a.ID == "sat-beta" || b.ID == "sat-beta" {
+			return 0
+		}
+		return 1000 / slantRangeKm
+	}
+	g, err := BuildGraph(testNodes(), WithLinkBudget(budget))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Adj["sat-beta"]) != 0 {
+		t.Fatalf("expected links without throughput to be dropped, got %+v", g.Adj["sat-beta"])
+	}
+	for _, e := range g.Adj["sat-alpha"] {
+		if want := 1000 / (e.LatencyMS / 1000 * SpeedOfLightKMPerS); math.Abs(e.Throughput-want) > 1e-9 {
+			t.Fatalf("expected throughput %v from the budget, got %+v", want, e)
+		}
+	}
+}
+
+func TestLinkBudgetRatesEachDirection(t *testing.T) {
+	// Ground stations transmit at 10 Mbps and receive at 100; the uplink from ground-b is down.
+	budget := func(a, b Node, slantRangeKm float64) float64 {
+		switch {
+		case a.ID == "ground-b":
+			return 0
+		case a.Type == Ground:
+			return 10
+		}
+		return 100
+	}
+	g, err := BuildGraph(testNodes(), WithLinkBudget(budget))
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, down := edgeBetween(g, "ground-a", "sat-alpha"), edgeBetween(g, "sat-alpha", "ground-a")
+	if up == nil || down == nil || up.Throughput != 10 || down.Throughput != 100 {
+		t.Fatalf("expected a 10 Mbps uplink and a 100 Mbps downlink, got %+v and %+v", up, down)
+	}
+	if hasEdge(g, "ground-b", "sat-alpha") || !hasEdge(g, "sat-alpha", "ground-b") {
+		t.Fatal("expected ground-b's downlink to close without its uplink")
+	}
+}
+
+func TestLinkSettingsDescribeTheirOptions(t *testing.T) {
+	gateway := 0.2
+	settings := LinkSettings{ElevationMask: 0.1, GatewayMask: &gateway, MaxISLRangeKm: 3000, NoISLs: true, Bands: []string{"ka"}}
+	want, err := BuildGraph(testNodes(), WithElevationMask(0.1), WithGatewayMask(gateway), WithMaxISLRange(3000), WithISLPolicy(NoISLs), WithBands("ka"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := BuildGraph(testNodes(), settings.Options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want.Adj, got.Adj) {
+		t.Fatalf("expected the settings to build %v, got %v", want.Adj, got.Adj)
+	}
+}
@@ -4,14 +4,21 @@ import (
 	"container/heap"
 	"errors"
 	"fmt"
+	"math"
 )
 
+// ErrNoRoute is returned when no usable path joins the requested nodes.
+var ErrNoRoute = errors.New("no route available")
+
 type nodeCost struct {
-	id    string
-	cost  float64
-	g     float64
-	path  []string
-	index int
+	id   string
+	cost float64
+	g    float64
+	// parent links search entries back to the start so A* does not copy a path per push;
+	// path is used instead by Yen's candidate queue, which holds complete paths.
+	parent *nodeCost
+	path   []string
+	index  int
 }
 
 type priorityQueue []*nodeCost
@@ -42,19 +49,32 @@ func (pq *priorityQueue) Pop() any {
 
 // ShortestPath returns the latency-optimal path using Dijkstra or A* when a heuristic is provided.
 func ShortestPath(g *Graph, start, goal string, heuristic func(string) float64) (Path, error) {
+	return shortestPath(g, start, goal, heuristic, func(e Edge) float64 { return e.LatencyMS })
+}
+
+// shortestPath runs A* with a caller-supplied edge cost. The heuristic must not exceed the
+// remaining cost, which holds for latency estimates as long as edgeCost is at least the latency.
+// Edges costing +Inf are treated as absent.
+func shortestPath(g *Graph, start, goal string, heuristic func(string) float64, edgeCost func(Edge) float64) (Path, error) {
+	return search(g, start, goal, heuristic, func(e Edge, _ float64) float64 { return edgeCost(e) })
+}
+
+// search is shortestPath with an edge cost that may also depend on the cost of reaching the edge's
+// start. Later arrivals must never make an edge cheaper, or the search may miss the best path.
+func search(g *Graph, start, goal string, heuristic func(string) float64, edgeCost func(e Edge, reached float64) float64) (Path, error) {
 	if heuristic == nil {
 		heuristic = func(string) float64 { return 0 }
 	}
 	if _, ok := g.Nodes[start]; !ok {
-		return Path{}, fmt.Errorf("unknown start node %s", start)
+		return Path{}, fmt.Errorf("route start: %w %q", ErrUnknownNode, start)
 	}
 	if _, ok := g.Nodes[goal]; !ok {
-		return Path{}, fmt.Errorf("unknown goal node %s", goal)
+		return Path{}, fmt.Errorf("route goal: %w %q", ErrUnknownNode, goal)
 	}
 
 	openSet := &priorityQueue{}
 	heap.Init(openSet)
-	heap.Push(openSet, &nodeCost{id: start, cost: heuristic(start), g: 0, path: []string{start}})
+	heap.Push(openSet, &nodeCost{id: start, cost: heuristic(start), g: 0})
 
 	visited := make(map[string]float64)
 
@@ -66,22 +86,74 @@ func ShortestPath(g *Graph, start, goal string, heuristic func(string) float64)
 		visited[current.id] = current.g
 
 		if current.id == goal {
-			latency, throughput, err := g.computePathMetrics(current.path)
-			if err != nil {
-				return Path{}, err
+			return g.pathMetrics(current.sequence())
+		}
+
+		g.EdgesFrom(current.id, func(edge Edge) bool {
+			cost := edgeCost(edge, current.g)
+			if math.IsInf(cost, 1) {
+				return true
+			}
+			tentativeG := current.g + cost
+			if prev, ok := visited[edge.To]; ok && tentativeG >= prev {
+				return true
 			}
-			return Path{Nodes: current.path, LatencyMS: latency, BottleneckThroughput: throughput}, nil
+			heap.Push(openSet, &nodeCost{id: edge.To, cost: tentativeG + heuristic(edge.To), g: tentativeG, parent: current})
+			return true
+		})
+	}
+
+	return Path{}, ErrNoRoute
+}
+
+// CostsTo returns the least cost from every node that can reach goal to goal, charging edgeCost
+// per edge; edges costing +Inf are treated as absent. It runs Dijkstra's algorithm backwards from
+// goal, so one call prices every candidate next hop toward the same destination.
+func CostsTo(g *Graph, goal string, edgeCost func(Edge) float64) map[string]float64 {
+	if _, ok := g.Nodes[goal]; !ok {
+		return nil
+	}
+	incoming := make(map[string][]Edge, len(g.Nodes))
+	for _, edges := range g.Adj {
+		for _, e := range edges {
+			incoming[e.To] = append(incoming[e.To], e)
 		}
+	}
 
-		for _, edge := range g.Adj[current.id] {
-			tentativeG := current.g + edge.LatencyMS
-			estimate := tentativeG + heuristic(edge.To)
-			newPath := append(append([]string{}, current.path...), edge.To)
-			heap.Push(openSet, &nodeCost{id: edge.To, cost: estimate, g: tentativeG, path: newPath})
+	costs := make(map[string]float64)
+	queue := &priorityQueue{}
+	heap.Push(queue, &nodeCost{id: goal})
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(*nodeCost)
+		if _, done := costs[current.id]; done {
+			continue
+		}
+		costs[current.id] = current.cost
+		for _, e := range incoming[current.id] {
+			cost := edgeCost(e)
+			if math.IsInf(cost, 1) {
+				continue
+			}
+			if _, done := costs[e.From]; !done {
+				heap.Push(queue, &nodeCost{id: e.From, cost: current.cost + cost})
+			}
 		}
 	}
+	return costs
+}
 
-	return Path{}, errors.New("no route available")
+// sequence walks parent links back to the start and returns the node IDs in travel order.
+func (n *nodeCost) sequence() []string {
+	depth := 0
+	for step := n; step != nil; step = step.parent {
+		depth++
+	}
+	nodes := make([]string, depth)
+	for step := n; step != nil; step = step.parent {
+		depth--
+		nodes[depth] = step.id
+	}
+	return nodes
 }
 
 // KAlternativeRoutes computes up to k loopless shortest paths using Yen's algorithm.
@@ -123,12 +195,12 @@ func KAlternativeRoutes(g *Graph, start, goal string, k int) ([]Path, error) {
 			}
 
 			newPathNodes := append(append([]string{}, rootPath[:len(rootPath)-1]...), spurPath.Nodes...)
-			latency, throughput, err := base.computePathMetrics(newPathNodes)
+			candidate, err := base.pathMetrics(newPathNodes)
 			if err != nil {
 				continue
 			}
 
-			heap.Push(potential, &nodeCost{id: "", cost: latency, g: throughput, path: newPathNodes})
+			heap.Push(potential, &nodeCost{id: "", cost: candidate.LatencyMS, path: candidate.Nodes})
 		}
 
 		if potential.Len() == 0 {
@@ -136,7 +208,11 @@ func KAlternativeRoutes(g *Graph, start, goal string, k int) ([]Path, error) {
 		}
 
 		candidate := heap.Pop(potential).(*nodeCost)
-		paths = append(paths, Path{Nodes: candidate.path, LatencyMS: candidate.cost, BottleneckThroughput: candidate.g})
+		next, err := base.pathMetrics(candidate.path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, next)
 	}
 
 	return paths, nil
@@ -1,7 +1,10 @@
 package routing
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"reflect"
 	"testing"
 
 	"github.com/example/satnet/backend/visibility"
@@ -20,7 +23,7 @@ func testNodes() []Node {
 }
 
 func TestShortestPathPrefersLowerLatency(t *testing.T) {
-	g, err := BuildGraph(testNodes(), 0)
+	g, err := BuildGraph(testNodes())
 	if err != nil {
 		t.Fatalf("failed to build graph: %v", err)
 	}
@@ -46,7 +49,7 @@ func TestShortestPathPrefersLowerLatency(t *testing.T) {
 }
 
 func TestKAlternativeRoutesProvidesBackup(t *testing.T) {
-	g, err := BuildGraph(testNodes(), 0)
+	g, err := BuildGraph(testNodes())
 	if err != nil {
 		t.Fatalf("failed to build graph: %v", err)
 	}
@@ -68,7 +71,7 @@ func TestKAlternativeRoutesProvidesBackup(t *testing.T) {
 }
 
 func TestFailureHandlingReroutes(t *testing.T) {
-	g, err := BuildGraph(testNodes(), 0)
+	g, err := BuildGraph(testNodes())
 	if err != nil {
 		t.Fatalf("failed to build graph: %v", err)
 	}
@@ -91,3 +94,117 @@ func TestFailureHandlingReroutes(t *testing.T) {
 		t.Fatalf("invalid path metrics after reroute: %+v", path)
 	}
 }
+
+func TestShortestPathReportsTypedErrors(t *testing.T) {
+	g, err := BuildGraph(testNodes())
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	if _, err := ShortestPath(g, "ground-a", "nowhere", nil); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("expected ErrUnknownNode, got %v", err)
+	}
+
+	isolated := g.Clone()
+	for _, id := range []string{"sat-alpha", "sat-beta", "sat-gamma"} {
+		isolated.RemoveNode(id)
+	}
+	if _, err := ShortestPath(isolated, "ground-a", "ground-b", nil); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("expected ErrNoRoute, got %v", err)
+	}
+	if _, err := KAlternativeRoutes(isolated, "ground-a", "ground-b", 2); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("expected ErrNoRoute from alternatives, got %v", err)
+	}
+}
+
+func TestCostsToMatchesShortestPaths(t *testing.T) {
+	g, err := BuildGraph(testNodes())
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	costs := CostsTo(g, "ground-b", func(e Edge) float64 { return e.LatencyMS })
+	for id := range g.Nodes {
+		path, err := ShortestPath(g, id, "ground-b", nil)
+		cost, ok := costs[id]
+		if err != nil {
+			if ok {
+				t.Fatalf("%s cannot reach ground-b but was priced at %v", id, cost)
+			}
+			continue
+		}
+		if !ok || math.Abs(cost-path.LatencyMS) > 1e-9 {
+			t.Fatalf("%s costs %v to ground-b, shortest path latency is %v", id, cost, path.LatencyMS)
+		}
+	}
+}
+
+func TestEdgesFromStopsEarly(t *testing.T) {
+	g, err := BuildGraph(testNodes())
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	var all []string
+	g.EdgesFrom("ground-a", func(e Edge) bool {
+		all = append(all, e.To)
+		return true
+	})
+	if len(all) != len(g.Adj["ground-a"]) || len(all) < 2 {
+		t.Fatalf("expected every edge from ground-a, got %v", all)
+	}
+
+	visited := 0
+	g.EdgesFrom("ground-a", func(Edge) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Fatalf("expected iteration to stop after the first edge, visited %d", visited)
+	}
+}
+
+func TestNewGraphAdjacencyListsAreIndependent(t *testing.T) {
+	nodes := testNodes()
+	g, err := NewGraph(nodes, BuildEdges(nodes, nil))
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	before := append([]Edge(nil), g.Adj["ground-b"]...)
+	g.Adj["ground-a"] = append(g.Adj["ground-a"], Edge{From: "ground-a", To: "sat-gamma"})
+	for i, e := range g.Adj["ground-b"] {
+		if !reflect.DeepEqual(e, before[i]) {
+			t.Fatalf("appending to one adjacency list corrupted another: %+v", g.Adj["ground-b"])
+		}
+	}
+
+	if _, err := NewGraph(nodes, []Edge{{From: "ground-a", To: "missing"}}); err == nil {
+		t.Fatalf("expected edges to unknown nodes to be rejected")
+	}
+}
+
+func BenchmarkShortestPathDenseGraph(b *testing.B) {
+	er := visibility.EarthRadius
+	nodes := []Node{
+		{ID: "src", Type: Ground, Position: visibility.Vector3{X: er}},
+		{ID: "dst", Type: Ground, Position: visibility.Vector3{Y: er}},
+	}
+	for i := 0; i < 300; i++ {
+		angle := float64(i) * 2 * math.Pi / 300
+		nodes = append(nodes, Node{
+			ID:       fmt.Sprintf("sat-%d", i),
+			Type:     Satellite,
+			Position: visibility.Vector3{X: (er + 1200) * math.Cos(angle), Y: (er + 1200) * math.Sin(angle), Z: float64(i%7) * 300},
+		})
+	}
+	g, err := BuildGraph(nodes)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ShortestPath(g, "src", "dst", func(id string) float64 { return g.Heuristic(id, "dst") }); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package routing
+
+import (
+	"math"
+	"sort"
+)
+
+// hopCostMS is what MinHopPathWhere charges per hop on top of latency. It dwarfs any route's total
+// latency, so hop count decides and latency only breaks ties.
+const hopCostMS = 1e6
+
+// MinHopPathWhere returns the path with the fewest hops, preferring the lowest latency among paths
+// of equal length, over edges accepted by usable (nil accepts all). Latency heuristics stay
+// admissible because every hop costs at least its latency.
+func MinHopPathWhere(g *Graph, start, goal string, heuristic func(string) float64, usable func(Edge) bool) (Path, error) {
+	return shortestPath(g, start, goal, heuristic, func(e Edge) float64 {
+		if usable != nil && !usable(e) {
+			return math.Inf(1)
+		}
+		return hopCostMS + e.LatencyMS
+	})
+}
+
+// MostStablePathWhere returns the path whose shortest-lived link lasts longest, preferring the lowest
+// latency among equally stable paths, over edges accepted by usable (nil accepts all). Unlike
+// StableShortestPath it never trades lifetime for latency.
+func MostStablePathWhere(g *Graph, start, goal string, heuristic func(string) float64, usable func(Edge) bool) (Path, error) {
+	lasting := func(minS float64) (Path, error) {
+		return shortestPath(g, start, goal, heuristic, func(e Edge) float64 {
+			if e.ValidForS < minS || usable != nil && !usable(e) {
+				return math.Inf(1)
+			}
+			return e.LatencyMS
+		})
+	}
+	best, err := lasting(math.Inf(-1))
+	if err != nil {
+		return Path{}, err
+	}
+
+	var lifetimes []float64
+	for _, edges := range g.Adj {
+		for _, e := range edges {
+			if e.ValidForS > best.StabilityS && (usable == nil || usable(e)) {
+				lifetimes = append(lifetimes, e.ValidForS)
+			}
+		}
+	}
+	sort.Float64s(lifetimes)
+	// Binary search for the longest lifetime every link of some route still reaches.
+	lo, hi := 0, len(lifetimes)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		path, err := lasting(lifetimes[mid])
+		if err != nil {
+			hi = mid
+			continue
+		}
+		best, lo = path, mid+1
+		for lo < hi && lifetimes[lo] <= path.StabilityS {
+			lo++
+		}
+	}
+	return best, nil
+}
+
+// ContactGraphPathWhere routes by contact graph routing over the contact plan formed by predicted
+// link lifetimes: each link is a contact open from now until ValidForS seconds ahead, and a hop is
+// only taken if data sent along the route so far reaches its far end before that contact closes.
+// Of the routes left it returns the earliest delivery, over edges accepted by usable (nil accepts all).
+func ContactGraphPathWhere(g *Graph, start, goal string, heuristic func(string) float64, usable func(Edge) bool) (Path, error) {
+	return search(g, start, goal, heuristic, func(e Edge, reached float64) float64 {
+		if usable != nil && !usable(e) || reached+e.LatencyMS > e.ValidForS*1000 {
+			return math.Inf(1)
+		}
+		return e.LatencyMS
+	})
+}
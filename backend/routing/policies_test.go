@@ -0,0 +1,75 @@
+package routing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// policyGraph links a to d three ways: a fast three-hop chain through b and c whose a-b link is
+// about to drop, a slower two-hop route through e that lasts five minutes, and a route through f
+// that lasts as long but is slower still.
+func policyGraph(t *testing.T) *Graph {
+	t.Helper()
+	var nodes []Node
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		nodes = append(nodes, Node{ID: id, Type: Satellite})
+	}
+	var edges []Edge
+	link := func(from, to string, latencyMS, validForS float64) {
+		edges = append(edges,
+			Edge{From: from, To: to, LatencyMS: latencyMS, Throughput: 1, ValidForS: validForS},
+			Edge{From: to, To: from, LatencyMS: latencyMS, Throughput: 1, ValidForS: validForS})
+	}
+	link("a", "b", 1, 0)
+	link("b", "c", 1, 600)
+	link("c", "d", 1, 600)
+	link("a", "e", 10, 300)
+	link("e", "d", 10, 600)
+	link("a", "f", 20, 300)
+	link("f", "d", 20, 300)
+	g, err := NewGraph(nodes, edges)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	return g
+}
+
+func TestRoutingPolicies(t *testing.T) {
+	g := policyGraph(t)
+	for name, tc := range map[string]struct {
+		route func(*Graph, string, string, func(string) float64, func(Edge) bool) (Path, error)
+		want  []string
+	}{
+		"min hops":      {MinHopPathWhere, []string{"a", "e", "d"}},
+		"max stability": {MostStablePathWhere, []string{"a", "e", "d"}},
+		"cgr":           {ContactGraphPathWhere, []string{"a", "e", "d"}},
+	} {
+		path, err := tc.route(g, "a", "d", nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(path.Nodes, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", name, tc.want, path.Nodes)
+		}
+	}
+	if fastest, _ := ShortestPath(g, "a", "d", nil); len(fastest.Nodes) != 4 {
+		t.Fatalf("expected the latency-optimal path through b and c, got %v", fastest.Nodes)
+	}
+
+	// Without e, the most stable route is through f even though b and c are faster.
+	noE := func(e Edge) bool { return e.To != "e" && e.From != "e" }
+	if path, _ := MostStablePathWhere(g, "a", "d", nil, noE); !reflect.DeepEqual(path.Nodes, []string{"a", "f", "d"}) || path.StabilityS != 300 {
+		t.Fatalf("expected the route through f, got %+v", path)
+	}
+	if path, _ := MinHopPathWhere(g, "a", "d", nil, noE); !reflect.DeepEqual(path.Nodes, []string{"a", "f", "d"}) {
+		t.Fatalf("expected the two-hop route through f, got %v", path.Nodes)
+	}
+	onlyChain := func(e Edge) bool { return e.To != "e" && e.From != "e" && e.To != "f" && e.From != "f" }
+	if _, err := ContactGraphPathWhere(g, "a", "d", nil, onlyChain); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("expected no contact route once only the closing a-b contact is left, got %v", err)
+	}
+	if _, err := MostStablePathWhere(g, "a", "missing", nil, nil); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("expected an unknown node error, got %v", err)
+	}
+}
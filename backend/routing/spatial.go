@@ -0,0 +1,129 @@
+package routing
+
+import (
+	"math"
+	"sort"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// linkRangeSlackKm pads the line-of-sight range bound so rounding never prunes a grazing link.
+const linkRangeSlackKm = 1.0
+
+// horizonDistance is the distance from a node to its horizon on the Earth's surface. A segment that
+// clears the Earth is no longer than the sum of its endpoints' horizon distances, which bounds the
+// range of every link regardless of node type or elevation mask.
+func horizonDistance(n Node) float64 {
+	r := visibility.SlantRange(visibility.Vector3{}, n.Position)
+	return math.Sqrt(math.Max(0, r*r-visibility.EarthRadius*visibility.EarthRadius))
+}
+
+type cellKey [3]int
+
+// cellsPerRange is how many hash cells span the longest possible link. Finer cells let the search
+// follow the ball of reachable positions more closely at the cost of more cell visits.
+const cellsPerRange = 4
+
+// maxDenseCells bounds the dense grid. Nodes spread far apart relative to the link range, such as
+// ground stations left without satellites, would need an enormous grid, so they are bucketed sparsely.
+const maxDenseCells = 1 << 20
+
+// spatialHash buckets node positions into a grid of cubes so that the potential link partners of a
+// node are found by visiting only the cubes that come within the longest possible link range. The
+// grid is dense when it is small enough and a map otherwise.
+type spatialHash struct {
+	size    float64
+	min     cellKey
+	dims    cellKey
+	cells   [][]int
+	sparse  map[cellKey][]int
+	offsets []cellKey
+}
+
+func newSpatialHash(nodes []Node, maxRange float64) *spatialHash {
+	h := &spatialHash{size: maxRange / cellsPerRange}
+	keys := make([]cellKey, len(nodes))
+	var max cellKey
+	for i, n := range nodes {
+		keys[i] = h.key(n.Position)
+		for axis := range keys[i] {
+			if i == 0 || keys[i][axis] < h.min[axis] {
+				h.min[axis] = keys[i][axis]
+			}
+			if i == 0 || keys[i][axis] > max[axis] {
+				max[axis] = keys[i][axis]
+			}
+		}
+	}
+	// Count the dense cells in floating point: the product of three spans can overflow an int.
+	total := 1.0
+	for axis := range h.dims {
+		h.dims[axis] = max[axis] - h.min[axis] + 1
+		total *= float64(h.dims[axis])
+	}
+	if total <= maxDenseCells {
+		h.cells = make([][]int, h.dims[0]*h.dims[1]*h.dims[2])
+		for i, key := range keys {
+			cell, _ := h.cell(key)
+			h.cells[cell] = append(h.cells[cell], i)
+		}
+	} else {
+		h.sparse = make(map[cellKey][]int, len(nodes))
+		for i, key := range keys {
+			h.sparse[key] = append(h.sparse[key], i)
+		}
+	}
+
+	// Keep the offsets whose cube can hold a point within maxRange of some point in the center cube.
+	reach := cellsPerRange + 1
+	gap := func(d int) float64 { return math.Max(0, math.Abs(float64(d))-1) }
+	for dx := -reach; dx <= reach; dx++ {
+		for dy := -reach; dy <= reach; dy++ {
+			for dz := -reach; dz <= reach; dz++ {
+				if gap(dx)*gap(dx)+gap(dy)*gap(dy)+gap(dz)*gap(dz) <= cellsPerRange*cellsPerRange {
+					h.offsets = append(h.offsets, cellKey{dx, dy, dz})
+				}
+			}
+		}
+	}
+	return h
+}
+
+func (h *spatialHash) key(p visibility.Vector3) cellKey {
+	return cellKey{int(math.Floor(p.X / h.size)), int(math.Floor(p.Y / h.size)), int(math.Floor(p.Z / h.size))}
+}
+
+// members returns the indices, in order, of the nodes in the cube at key.
+func (h *spatialHash) members(key cellKey) []int {
+	if h.sparse != nil {
+		return h.sparse[key]
+	}
+	if cell, ok := h.cell(key); ok {
+		return h.cells[cell]
+	}
+	return nil
+}
+
+// cell returns the index of key in the dense grid, or false when it lies outside every node's cube.
+func (h *spatialHash) cell(key cellKey) (int, bool) {
+	index := 0
+	for axis := range key {
+		local := key[axis] - h.min[axis]
+		if local < 0 || local >= h.dims[axis] {
+			return 0, false
+		}
+		index = index*h.dims[axis] + local
+	}
+	return index, true
+}
+
+// appendNear appends the indices above i of nodes in cubes within range of the cube holding p.
+func (h *spatialHash) appendNear(dst []int, p visibility.Vector3, i int) []int {
+	center := h.key(p)
+	for _, offset := range h.offsets {
+		// Cells list their nodes in index order, so the partners above i form a suffix.
+		members := h.members(cellKey{center[0] + offset[0], center[1] + offset[1], center[2] + offset[2]})
+		dst = append(dst, members[sort.SearchInts(members, i+1):]...)
+	}
+	return dst
+}
@@ -0,0 +1,133 @@
+package routing
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// shellNodes scatters stationary satellites over a 550 km shell, with every tenth node a ground station.
+func shellNodes(n int, seed int64) []Node {
+	rng := rand.New(rand.NewSource(seed))
+	nodes := make([]Node, 0, n)
+	for i := 0; i < n; i++ {
+		lat, lon := rng.Float64()*180-90, rng.Float64()*360-180
+		if i%10 == 0 {
+			nodes = append(nodes, Node{ID: fmt.Sprintf("ground-%d", i), Type: Ground, Position: visibility.FromGeodetic(lat, lon, 0)})
+			continue
+		}
+		nodes = append(nodes, Node{ID: fmt.Sprintf("sat-%d", i), Type: Satellite, Position: visibility.FromGeodetic(lat, lon, 300)})
+	}
+	return nodes
+}
+
+// bruteForceEdges is BuildEdges without the spatial hash.
+func bruteForceEdges(nodes []Node, elevationMask float64) []Edge {
+	var edges []Edge
+	for i := 0; i < len(nodes); i++ {
+		for j := i + 1; j < len(nodes); j++ {
+			a, b := nodes[i], nodes[j]
+			if LinkVisible(a, b, elevationMask) {
+				validFor := EstimateLinkLifetime(a, b, elevationMask)
+				throughput := distanceThroughput(visibility.SlantRange(a.Position, b.Position))
+				edges = append(edges, newEdge(a, b, validFor, throughput), newEdge(b, a, validFor, throughput))
+			}
+		}
+	}
+	return edges
+}
+
+func TestBuildEdgesMatchesExhaustiveSearch(t *testing.T) {
+	for _, mask := range []float64{0, 0.3} {
+		nodes := shellNodes(400, 7)
+		got, want := BuildEdges(nodes, nil, WithElevationMask(mask)), bruteForceEdges(nodes, mask)
+		if len(want) == 0 {
+			t.Fatal("expected the shell to have links")
+		}
+		sortEdges(got)
+		sortEdges(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("mask %.1f: got %d edges, want %d", mask, len(got), len(want))
+		}
+	}
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+func TestHorizonDistanceBoundsLinkRange(t *testing.T) {
+	er := visibility.EarthRadius
+	// Two satellites whose connecting segment just grazes the Earth.
+	reach := horizonDistance(Node{Position: visibility.Vector3{X: er + 550}})
+	a := Node{ID: "a", Type: Satellite, Position: visibility.Vector3{X: -reach + 10, Y: er + 1}}
+	b := Node{ID: "b", Type: Satellite, Position: visibility.Vector3{X: reach - 10, Y: er + 1}}
+	if !LinkVisible(a, b, 0) {
+		t.Fatal("expected the grazing link to be visible")
+	}
+	if visibility.SlantRange(a.Position, b.Position) > horizonDistance(a)+horizonDistance(b) {
+		t.Fatal("visible link exceeds the horizon distance bound")
+	}
+}
+
+func BenchmarkBuildEdges(b *testing.B) {
+	nodes := shellNodes(2000, 1)
+	b.Run("spatial-hash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BuildEdges(nodes, nil, WithElevationMask(0.4))
+		}
+	})
+	b.Run("exhaustive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bruteForceEdges(nodes, 0.4)
+		}
+	})
+}
+
+func TestBuildGraphWithoutSatellites(t *testing.T) {
+	// With only surface nodes the link range is about a kilometer, so a dense hash spanning the
+	// stations would need trillions of cells.
+	nodes := []Node{
+		{ID: "gw-a", Type: Ground, Position: visibility.FromGeodetic(51.5, 0, 0)},
+		{ID: "gw-b", Type: Ground, Position: visibility.FromGeodetic(-33.9, 151.2, 0)},
+		{ID: "gw-c", Type: Ground, Position: visibility.FromGeodetic(40.7, -74, 0)},
+	}
+	graph, err := BuildGraph(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edges := BuildEdges(nodes, nil); len(edges) != 0 {
+		t.Fatalf("expected ground stations alone to have no links, got %d", len(edges))
+	}
+	if graph == nil {
+		t.Fatal("expected a graph of the ground stations")
+	}
+}
+
+func TestSparseSpatialHashMatchesDense(t *testing.T) {
+	nodes := shellNodes(400, 3)
+	dense := newSpatialHash(nodes, 2000)
+	if dense.sparse != nil {
+		t.Fatal("expected the shell to use a dense hash")
+	}
+	sparse := newSpatialHash(nodes, 2000)
+	sparse.cells, sparse.sparse = nil, make(map[cellKey][]int)
+	for i, n := range nodes {
+		key := sparse.key(n.Position)
+		sparse.sparse[key] = append(sparse.sparse[key], i)
+	}
+	for i, n := range nodes {
+		if got, want := sparse.appendNear(nil, n.Position, i), dense.appendNear(nil, n.Position, i); !reflect.DeepEqual(got, want) {
+			t.Fatalf("node %d: sparse hash found %v, dense %v", i, got, want)
+		}
+	}
+}
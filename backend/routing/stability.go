@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+const (
+	// StabilityHorizon bounds how far ahead link lifetimes are predicted.
+	StabilityHorizon = 10 * time.Minute
+	// stabilityStep is the sampling interval used when searching for link loss.
+	stabilityStep = 10 * time.Second
+)
+
+// EstimateLinkLifetime predicts how many seconds the link between two nodes stays visible.
+// Satellites are advanced along circular orbits using their velocity; the search is capped
+// at StabilityHorizon, which is also returned for links between nodes that never move.
+func EstimateLinkLifetime(a, b Node, elevationMask float64) float64 {
+	horizon := StabilityHorizon.Seconds()
+	if isStationary(a) && isStationary(b) {
+		return horizon
+	}
+
+	step := stabilityStep.Seconds()
+	for t := step; t <= horizon; t += step {
+		futureA, futureB := a, b
+		futureA.Position, _ = visibility.PropagateCircular(a.Position, a.Velocity, t)
+		futureB.Position, _ = visibility.PropagateCircular(b.Position, b.Velocity, t)
+		if !LinkVisible(futureA, futureB, elevationMask) {
+			// The link was last confirmed one step earlier.
+			return t - step
+		}
+	}
+	return horizon
+}
+
+// StableShortestPath trades latency against route stability. Each hop costs its latency plus
+// stabilityWeight milliseconds scaled by the fraction of StabilityHorizon the link will not survive,
+// so a weight of zero yields the latency-optimal path.
+func StableShortestPath(g *Graph, start, goal string, stabilityWeight float64, heuristic func(string) float64) (Path, error) {
+	return StableShortestPathWhere(g, start, goal, stabilityWeight, heuristic, nil)
+}
+
+// StableShortestPathWhere is StableShortestPath restricted to edges accepted by usable, letting
+// capacity-aware callers route around links without room for a demand. A nil usable accepts every edge.
+func StableShortestPathWhere(g *Graph, start, goal string, stabilityWeight float64, heuristic func(string) float64, usable func(Edge) bool) (Path, error) {
+	return shortestPath(g, start, goal, heuristic, func(e Edge) float64 {
+		if usable != nil && !usable(e) {
+			return math.Inf(1)
+		}
+		return StableEdgeCost(e, stabilityWeight)
+	})
+}
+
+// StableEdgeCost is the cost StableShortestPath charges for crossing e.
+func StableEdgeCost(e Edge, stabilityWeight float64) float64 {
+	instability := 1 - e.ValidForS/StabilityHorizon.Seconds()
+	if instability < 0 {
+		instability = 0
+	}
+	return e.LatencyMS + stabilityWeight*instability
+}
+
+func isStationary(n Node) bool {
+	return n.Velocity == (visibility.Vector3{})
+}
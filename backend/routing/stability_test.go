@@ -0,0 +1,84 @@
+package routing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestEstimateLinkLifetimeForPassingSatellite(t *testing.T) {
+	er := visibility.EarthRadius
+	ground := Node{ID: "ground", Type: Ground, Position: visibility.Vector3{X: er, Y: 0, Z: 0}}
+	overhead := Node{ID: "sat", Type: Satellite, Position: visibility.Vector3{X: er + 550, Y: 0, Z: 0}, Velocity: visibility.Vector3{X: 0, Y: 7.6, Z: 0}}
+
+	lifetime := EstimateLinkLifetime(ground, overhead, 0)
+	if lifetime <= 0 || lifetime >= StabilityHorizon.Seconds() {
+		t.Fatalf("expected a finite pass duration, got %.0fs", lifetime)
+	}
+
+	static := overhead
+	static.Velocity = visibility.Vector3{}
+	if got := EstimateLinkLifetime(ground, static, 0); got != StabilityHorizon.Seconds() {
+		t.Fatalf("static link should last the full horizon, got %.0fs", got)
+	}
+}
+
+func TestStableShortestPathAvoidsSettingSatellite(t *testing.T) {
+	er := visibility.EarthRadius
+	nodes := []Node{
+		{ID: "ground-a", Type: Ground, Position: visibility.Vector3{X: er, Y: 0, Z: 0}},
+		{ID: "ground-b", Type: Ground, Position: visibility.Vector3{X: er, Y: 20, Z: 0}},
+		// Low and close, but moving quickly towards the horizon.
+		{ID: "setting", Type: Satellite, Position: visibility.Vector3{X: er + 500, Y: 10, Z: 0}, Velocity: visibility.Vector3{X: 0, Y: 7.6, Z: 0}},
+		// Higher and parked overhead, so the link outlives the horizon.
+		{ID: "steady", Type: Satellite, Position: visibility.Vector3{X: er + 900, Y: 10, Z: 0}},
+	}
+	g, err := BuildGraph(nodes)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	fastest, err := StableShortestPath(g, "ground-a", "ground-b", 0, nil)
+	if err != nil {
+		t.Fatalf("expected path, got error: %v", err)
+	}
+	if fastest.Nodes[1] != "setting" {
+		t.Fatalf("zero weight should pick the lowest latency path, got %v", fastest.Nodes)
+	}
+	if fastest.StabilityS >= StabilityHorizon.Seconds() {
+		t.Fatalf("expected path stability to reflect the setting satellite, got %.0fs", fastest.StabilityS)
+	}
+
+	stable, err := StableShortestPath(g, "ground-a", "ground-b", 100, nil)
+	if err != nil {
+		t.Fatalf("expected path, got error: %v", err)
+	}
+	if stable.Nodes[1] != "steady" {
+		t.Fatalf("stability weight should prefer the long-lived path, got %v", stable.Nodes)
+	}
+	if stable.LatencyMS <= fastest.LatencyMS || math.Abs(stable.StabilityS-StabilityHorizon.Seconds()) > 1e-9 {
+		t.Fatalf("unexpected trade-off metrics: fastest %+v, stable %+v", fastest, stable)
+	}
+}
+
+func TestStableShortestPathWhereSkipsUnusableEdges(t *testing.T) {
+	g, err := BuildGraph(testNodes())
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	avoidAlpha := func(e Edge) bool { return e.To != "sat-alpha" && e.From != "sat-alpha" }
+	path, err := StableShortestPathWhere(g, "ground-a", "ground-b", 0, nil, avoidAlpha)
+	if err != nil {
+		t.Fatalf("expected a detour, got %v", err)
+	}
+	for _, id := range path.Nodes {
+		if id == "sat-alpha" {
+			t.Fatalf("path used an excluded link: %v", path.Nodes)
+		}
+	}
+
+	if _, err := StableShortestPathWhere(g, "ground-a", "ground-b", 0, nil, func(Edge) bool { return false }); err == nil {
+		t.Fatalf("expected no route when every edge is unusable")
+	}
+}
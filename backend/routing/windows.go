@@ -0,0 +1,265 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+const (
+	// windowTolerance is how precisely, in seconds, rise and set times are located.
+	windowTolerance = 1e-3
+	// schedulePositionToleranceKm is how far a node may drift from its predicted position before a
+	// schedule no longer describes it.
+	schedulePositionToleranceKm = 1.0
+)
+
+// Window is an interval, in seconds after a schedule's epoch, during which a link is visible.
+// Set is +Inf for links still visible at the end of the sampled span.
+type Window struct {
+	Rise float64
+	Set  float64
+}
+
+type linkEvent struct {
+	at   float64
+	link int
+	up   bool
+}
+
+// LinkSchedule precomputes when each node pair can see each other, propagating satellites along
+// their circular orbits, so that graphs for later times toggle links at the precomputed rise and set
+// times instead of re-testing the geometry of every pair. Orbits are sampled every stabilityStep and
+// crossings refined by bisection, so windows shorter than the step may be missed.
+type LinkSchedule struct {
+	nodes     []Node
+	index     map[string]int
+	opts      graphOptions
+	horizonS  float64
+	links     [][2]int   // candidate pairs (i < j) visible at some point, in index order
+	windows   [][]Window // per candidate link
+	events    []linkEvent
+	next      int
+	active    []float64 // set time of each candidate's current window, or -1 when down
+	elapsedS  float64
+	toggledAt int
+}
+
+// NewLinkSchedule samples visibility for every node pair from the nodes' current state until
+// horizon, plus StabilityHorizon so that link lifetimes stay exact up to the end of the horizon.
+// opts filter and weigh links as they do for BuildGraph, after the graph-wide elevationMask.
+func NewLinkSchedule(nodes []Node, elevationMask float64, horizon time.Duration, opts ...Option) (*LinkSchedule, error) {
+	if horizon <= 0 {
+		return nil, errors.New("schedule horizon must be positive")
+	}
+	s := &LinkSchedule{
+		nodes:    append([]Node(nil), nodes...),
+		index:    make(map[string]int, len(nodes)),
+		opts:     newGraphOptions(append([]Option{WithElevationMask(elevationMask)}, opts...)),
+		horizonS: horizon.Seconds(),
+	}
+	for i, n := range nodes {
+		if n.ID == "" {
+			return nil, errors.New("node ID cannot be empty")
+		}
+		if _, dup := s.index[n.ID]; dup {
+			return nil, fmt.Errorf("duplicate node %q", n.ID)
+		}
+		s.index[n.ID] = i
+	}
+
+	step := stabilityStep.Seconds()
+	span := s.horizonS + StabilityHorizon.Seconds()
+	samples := int(math.Ceil(span/step)) + 1
+	positions := make([][]visibility.Vector3, len(nodes))
+	for i := range nodes {
+		positions[i] = make([]visibility.Vector3, samples)
+		for k := range positions[i] {
+			positions[i][k] = s.positionAt(i, float64(k)*step)
+		}
+	}
+
+	for i := 0; i < len(nodes); i++ {
+		for j := i + 1; j < len(nodes); j++ {
+			windows := s.sampleWindows(i, j, positions, samples, step)
+			if len(windows) == 0 {
+				continue
+			}
+			link := len(s.links)
+			s.links = append(s.links, [2]int{i, j})
+			s.windows = append(s.windows, windows)
+			for _, w := range windows {
+				if w.Rise > 0 {
+					s.events = append(s.events, linkEvent{at: w.Rise, link: link, up: true})
+				}
+				if !math.IsInf(w.Set, 1) {
+					s.events = append(s.events, linkEvent{at: w.Set, link: link})
+				}
+			}
+		}
+	}
+	sort.SliceStable(s.events, func(a, b int) bool { return s.events[a].at < s.events[b].at })
+
+	s.active = make([]float64, len(s.links))
+	for link, windows := range s.windows {
+		s.active[link] = -1
+		if windows[0].Rise == 0 {
+			s.active[link] = windows[0].Set
+		}
+	}
+	return s, nil
+}
+
+// sampleWindows finds the visibility windows of pair (i, j) over the sampled span.
+func (s *LinkSchedule) sampleWindows(i, j int, positions [][]visibility.Vector3, samples int, step float64) []Window {
+	visibleAt := func(k int) bool {
+		a, b := s.nodes[i], s.nodes[j]
+		a.Position, b.Position = positions[i][k], positions[j][k]
+		return s.linkUp(a, b)
+	}
+	if isStationary(s.nodes[i]) && isStationary(s.nodes[j]) {
+		if visibleAt(0) {
+			return []Window{{Rise: 0, Set: math.Inf(1)}}
+		}
+		return nil
+	}
+
+	var windows []Window
+	up := visibleAt(0)
+	if up {
+		windows = append(windows, Window{Rise: 0, Set: math.Inf(1)})
+	}
+	for k := 1; k < samples; k++ {
+		if visibleAt(k) == up {
+			continue
+		}
+		crossing := s.refineCrossing(i, j, float64(k-1)*step, float64(k)*step, up)
+		if up {
+			windows[len(windows)-1].Set = crossing
+		} else {
+			windows = append(windows, Window{Rise: crossing, Set: math.Inf(1)})
+		}
+		up = !up
+	}
+	return windows
+}
+
+// refineCrossing bisects (lo, hi] for the first time the pair's visibility differs from was.
+func (s *LinkSchedule) refineCrossing(i, j int, lo, hi float64, was bool) float64 {
+	for hi-lo > windowTolerance {
+		mid := (lo + hi) / 2
+		a, b := s.nodes[i], s.nodes[j]
+		a.Position, b.Position = s.positionAt(i, mid), s.positionAt(j, mid)
+		if s.linkUp(a, b) == was {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// linkUp reports whether a and b, at their given positions, link under the schedule's options.
+func (s *LinkSchedule) linkUp(a, b Node) bool {
+	return s.opts.allowed(a, b) && LinkVisible(a, b, s.opts.mask(a, b))
+}
+
+func (s *LinkSchedule) positionAt(i int, seconds float64) visibility.Vector3 {
+	position, _ := visibility.PropagateCircular(s.nodes[i].Position, s.nodes[i].Velocity, seconds)
+	return position
+}
+
+// Windows returns the visibility windows of the link between a and b, in seconds after the epoch.
+func (s *LinkSchedule) Windows(a, b string) []Window {
+	i, okA := s.index[a]
+	j, okB := s.index[b]
+	if !okA || !okB {
+		return nil
+	}
+	if i > j {
+		i, j = j, i
+	}
+	for link, pair := range s.links {
+		if pair == [2]int{i, j} {
+			return append([]Window(nil), s.windows[link]...)
+		}
+	}
+	return nil
+}
+
+// Covers reports whether the schedule describes nodes at elapsed seconds after its epoch: the node
+// set is unchanged, the time is within the horizon and not before the last graph, and every node is
+// where its orbit predicts.
+func (s *LinkSchedule) Covers(nodes []Node, elapsed float64) bool {
+	if len(nodes) != len(s.nodes) || elapsed < s.elapsedS || elapsed > s.horizonS {
+		return false
+	}
+	for _, n := range nodes {
+		i, ok := s.index[n.ID]
+		if !ok || n.Type != s.nodes[i].Type || n.ElevationMask != s.nodes[i].ElevationMask {
+			return false
+		}
+		if visibility.SlantRange(n.Position, s.positionAt(i, elapsed)) > schedulePositionToleranceKm {
+			return false
+		}
+	}
+	return true
+}
+
+// GraphAt builds the graph for elapsed seconds after the epoch. Only the rise and set events since the
+// previous call are applied to the set of live links; latency and throughput are then taken from
+// the positions in nodes, which must be covered by the schedule. Calls must not go back in time.
+func (s *LinkSchedule) GraphAt(nodes []Node, elapsed float64) (*Graph, error) {
+	if !s.Covers(nodes, elapsed) {
+		return nil, errors.New("schedule does not cover the requested nodes and time")
+	}
+	s.toggledAt = 0
+	for ; s.next < len(s.events) && s.events[s.next].at <= elapsed; s.next++ {
+		event := s.events[s.next]
+		s.active[event.link] = -1
+		if event.up {
+			s.active[event.link] = s.setAfter(event.link, event.at)
+		}
+		s.toggledAt++
+	}
+	s.elapsedS = elapsed
+
+	current := make([]Node, len(s.nodes))
+	for _, n := range nodes {
+		current[s.index[n.ID]] = n
+	}
+	edges := make([]Edge, 0, 2*len(s.links))
+	for link, set := range s.active {
+		if set < 0 {
+			continue
+		}
+		validFor := math.Min(set-elapsed, StabilityHorizon.Seconds())
+		a, b := current[s.links[link][0]], current[s.links[link][1]]
+		forward, reverse := s.opts.throughputs(a, b)
+		if forward > 0 {
+			edges = append(edges, newEdge(a, b, validFor, forward))
+		}
+		if reverse > 0 {
+			edges = append(edges, newEdge(b, a, validFor, reverse))
+		}
+	}
+	return NewGraph(nodes, edges)
+}
+
+// Toggled returns the number of link rise and set events applied by the last GraphAt call.
+func (s *LinkSchedule) Toggled() int {
+	return s.toggledAt
+}
+
+func (s *LinkSchedule) setAfter(link int, rise float64) float64 {
+	for _, w := range s.windows[link] {
+		if w.Rise == rise {
+			return w.Set
+		}
+	}
+	return math.Inf(1)
+}
@@ -0,0 +1,121 @@
+package routing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+func movingNodes() []Node {
+	er := visibility.EarthRadius
+	return []Node{
+		{ID: "ground-a", Type: Ground, Position: visibility.Vector3{X: er, Y: 0, Z: 0}},
+		{ID: "ground-b", Type: Ground, Position: visibility.FromGeodetic(10, 15, 0), ElevationMask: 0.1},
+		{ID: "sat-1", Type: Satellite, Position: visibility.Vector3{X: er + 550, Y: 0, Z: 0}, Velocity: visibility.Vector3{X: 0, Y: 7.6, Z: 0}},
+		{ID: "sat-2", Type: Satellite, Position: visibility.FromGeodetic(-20, 0, 800), Velocity: visibility.Vector3{X: 0, Y: 0, Z: 7.4}},
+		{ID: "sat-3", Type: Satellite, Position: visibility.FromGeodetic(40, 40, 1200), Velocity: visibility.Vector3{X: -5, Y: 5, Z: 0}},
+		{ID: "parked", Type: Satellite, Position: visibility.FromGeodetic(5, 5, 900)},
+	}
+}
+
+func nodesAt(nodes []Node, seconds float64) []Node {
+	moved := append([]Node(nil), nodes...)
+	for i := range moved {
+		moved[i].Position, moved[i].Velocity = visibility.PropagateCircular(nodes[i].Position, nodes[i].Velocity, seconds)
+	}
+	return moved
+}
+
+func TestLinkScheduleMatchesBuildGraph(t *testing.T) {
+	nodes := movingNodes()
+	schedule, err := NewLinkSchedule(nodes, 0.05, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to build schedule: %v", err)
+	}
+	toggled := 0
+	for elapsed := 0.0; elapsed <= 1800; elapsed += 45 {
+		current := nodesAt(nodes, elapsed)
+		got, err := schedule.GraphAt(current, elapsed)
+		if err != nil {
+			t.Fatalf("t=%.0f: %v", elapsed, err)
+		}
+		toggled += schedule.Toggled()
+		want, err := BuildGraph(current, WithElevationMask(0.05))
+		if err != nil {
+			t.Fatalf("failed to build graph: %v", err)
+		}
+		for _, n := range nodes {
+			gotEdges, wantEdges := got.Adj[n.ID], want.Adj[n.ID]
+			if len(gotEdges) != len(wantEdges) {
+				t.Fatalf("t=%.0f: %s has edges %v, want %v", elapsed, n.ID, gotEdges, wantEdges)
+			}
+			sortEdges(gotEdges)
+			sortEdges(wantEdges)
+			for i, e := range wantEdges {
+				g := gotEdges[i]
+				if g.To != e.To || math.Abs(g.LatencyMS-e.LatencyMS) > 1e-9 {
+					t.Fatalf("t=%.0f: edge %+v, want %+v", elapsed, g, e)
+				}
+				// BuildGraph's lifetimes are sampled, so they undershoot by up to one step.
+				if g.ValidForS < e.ValidForS || g.ValidForS > e.ValidForS+stabilityStep.Seconds() {
+					t.Fatalf("t=%.0f: %s->%s valid for %.1fs, sampled %.1fs", elapsed, e.From, e.To, g.ValidForS, e.ValidForS)
+				}
+			}
+		}
+	}
+	if toggled == 0 {
+		t.Fatal("expected links to rise or set over the horizon")
+	}
+}
+
+func TestLinkScheduleWindows(t *testing.T) {
+	schedule, err := NewLinkSchedule(movingNodes(), 0, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to build schedule: %v", err)
+	}
+	if got := schedule.Windows("ground-a", "parked"); len(got) != 1 || got[0].Rise != 0 || !math.IsInf(got[0].Set, 1) {
+		t.Fatalf("expected a parked satellite to stay visible, got %v", got)
+	}
+	pass := schedule.Windows("sat-1", "ground-a")
+	if len(pass) == 0 || pass[0].Rise != 0 || math.IsInf(pass[0].Set, 1) {
+		t.Fatalf("expected the overhead pass to set, got %v", pass)
+	}
+	ground, sat := movingNodes()[0], movingNodes()[2]
+	before, after := sat, sat
+	before.Position, _ = visibility.PropagateCircular(sat.Position, sat.Velocity, pass[0].Set-2*windowTolerance)
+	after.Position, _ = visibility.PropagateCircular(sat.Position, sat.Velocity, pass[0].Set)
+	if !LinkVisible(ground, before, 0) || LinkVisible(ground, after, 0) {
+		t.Fatalf("set time %.3fs is not at the visibility boundary", pass[0].Set)
+	}
+	if schedule.Windows("ground-a", "ground-b") != nil {
+		t.Fatal("ground stations should never link")
+	}
+}
+
+func TestLinkScheduleCovers(t *testing.T) {
+	nodes := movingNodes()
+	schedule, err := NewLinkSchedule(nodes, 0, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to build schedule: %v", err)
+	}
+	if !schedule.Covers(nodesAt(nodes, 300), 300) {
+		t.Fatal("expected propagated nodes inside the horizon to be covered")
+	}
+	if schedule.Covers(nodesAt(nodes, 900), 900) {
+		t.Fatal("expected times past the horizon to need a new schedule")
+	}
+	if schedule.Covers(nodes[:len(nodes)-1], 0) {
+		t.Fatal("expected a changed node set to need a new schedule")
+	}
+	if schedule.Covers(nodesAt(nodes, 300), 0) {
+		t.Fatal("expected moved nodes to need a new schedule")
+	}
+	if _, err := schedule.GraphAt(nodesAt(nodes, 300), 300); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := schedule.GraphAt(nodesAt(nodes, 60), 60); err == nil {
+		t.Fatal("expected going back in time to be rejected")
+	}
+}
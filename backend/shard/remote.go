@@ -0,0 +1,103 @@
+package shard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ComputePath is where Handler is mounted on shard worker nodes.
+const ComputePath = "/shard/compute"
+
+// maxTaskBytes bounds the request bodies a shard worker accepts.
+const maxTaskBytes = 256 << 20
+
+// DefaultTimeout bounds a task sent by a Remote without its own Client.
+const DefaultTimeout = 30 * time.Second
+
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// Remote sends tasks to a shard worker over HTTP as JSON.
+type Remote struct {
+	BaseURL string
+	// Token is sent as a bearer token; workers mounted by the API server require one.
+	Token  string
+	Client *http.Client // defaults to a client that gives up after DefaultTimeout
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Compute posts the task to the worker and decodes its result.
+func (r Remote) Compute(ctx context.Context, task Task) (Result, error) {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(r.BaseURL, "/")+ComputePath, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure errorBody
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(raw, &failure) != nil || failure.Error == "" {
+			failure.Error = strings.TrimSpace(string(raw))
+		}
+		return Result{}, fmt.Errorf("%s: %s: %s", r.BaseURL, resp.Status, failure.Error)
+	}
+	var result Result
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Result{}, fmt.Errorf("%s: decode result: %w", r.BaseURL, err)
+	}
+	return result, nil
+}
+
+// Handler serves shard tasks from remote coordinators using the given worker.
+func Handler(worker Worker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, errorBody{Error: "shard tasks must be POSTed"})
+			return
+		}
+		var task Task
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTaskBytes)).Decode(&task); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return
+		}
+		result, err := worker.Compute(r.Context(), task)
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, errorBody{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,247 @@
+// Package shard splits the per-step visibility and coverage work of large constellations
+// across workers, partitioning satellites by orbital plane, and merges the partial results.
+package shard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+)
+
+// PlaneResolutionDeg is the granularity used to group satellites into orbital planes.
+const PlaneResolutionDeg = 1.0
+
+// Task is the unit of work sent to one shard. Every shard receives all nodes because links
+// cross plane boundaries, but evaluates only the node pairs assigned to it.
+type Task struct {
+	Shard int            `json:"shard"`
+	Nodes []routing.Node `json:"nodes"`
+	// Owners maps each node index to the shard that owns it.
+	Owners []int `json:"owners"`
+	// Links are the scenario's link settings, so shards close the same links a single process would.
+	Links routing.LinkSettings `json:"links"`
+	Grid  coverage.GridConfig  `json:"grid"`
+	// Footprints belong to the satellites this shard owns.
+	Footprints []coverage.Footprint `json:"footprints"`
+}
+
+// Result is a shard's share of the graph edges and coverage grid.
+type Result struct {
+	Edges    []routing.Edge          `json:"edges"`
+	Coverage []coverage.CellCoverage `json:"coverage"`
+}
+
+// Worker computes shard tasks, either in process or on another machine.
+type Worker interface {
+	Compute(ctx context.Context, task Task) (Result, error)
+}
+
+// Local computes tasks in the calling process.
+type Local struct{}
+
+// Compute evaluates the task's node pairs and footprints.
+func (Local) Compute(ctx context.Context, task Task) (Result, error) {
+	if len(task.Owners) != len(task.Nodes) {
+		return Result{}, errors.New("task owners must list one shard per node")
+	}
+	grid, err := coverage.AcquireGrid(task.Grid)
+	if err != nil {
+		return Result{}, err
+	}
+	defer coverage.ReleaseGrid(grid)
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	edges := routing.BuildEdges(task.Nodes, func(i, j int) bool {
+		return pairOwner(task.Owners, i, j) == task.Shard
+	}, task.Links.Options()...)
+	grid.ApplyFootprints(task.Footprints)
+	return Result{Edges: edges, Coverage: grid.Contributions()}, nil
+}
+
+// pairOwner alternates pair ownership between the two endpoints so cross-plane work is spread
+// evenly instead of landing on whichever shard owns the lower-indexed node.
+func pairOwner(owners []int, i, j int) int {
+	if (i+j)%2 == 0 {
+		return owners[i]
+	}
+	return owners[j]
+}
+
+// Coordinator fans a recompute out to its workers and merges their results.
+type Coordinator struct {
+	workers []Worker
+}
+
+// NewCoordinator builds a coordinator over at least one worker.
+func NewCoordinator(workers ...Worker) (*Coordinator, error) {
+	if len(workers) == 0 {
+		return nil, errors.New("sharding requires at least one worker")
+	}
+	return &Coordinator{workers: workers}, nil
+}
+
+// Compute builds the routing graph and coverage grid for the nodes, closing links by links.
+// footprints is keyed by satellite node ID; satellites without an entry contribute no coverage.
+// The grid comes from coverage.AcquireGrid, so callers may hand it back with coverage.ReleaseGrid
+// once done.
+func (c *Coordinator) Compute(
+	ctx context.Context,
+	nodes []routing.Node,
+	footprints map[string]coverage.Footprint,
+	links routing.LinkSettings,
+	gridConfig coverage.GridConfig,
+) (*routing.Graph, *coverage.CoverageGrid, error) {
+	owners := Partition(nodes, len(c.workers))
+	tasks := make([]Task, len(c.workers))
+	for i := range tasks {
+		tasks[i] = Task{Shard: i, Nodes: nodes, Owners: owners, Links: links, Grid: gridConfig}
+	}
+	for i, n := range nodes {
+		if fp, ok := footprints[n.ID]; ok {
+			tasks[owners[i]].Footprints = append(tasks[owners[i]].Footprints, fp)
+		}
+	}
+
+	edges, grid, err := c.run(ctx, tasks, gridConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	graph, err := routing.NewGraph(nodes, edges)
+	if err != nil {
+		coverage.ReleaseGrid(grid)
+		return nil, nil, err
+	}
+	return graph, grid, nil
+}
+
+// Cover computes a coverage grid alone, dealing the footprints out to the workers in turn. The
+// grid comes from coverage.AcquireGrid like Compute's.
+func (c *Coordinator) Cover(ctx context.Context, footprints []coverage.Footprint, gridConfig coverage.GridConfig) (*coverage.CoverageGrid, error) {
+	tasks := make([]Task, len(c.workers))
+	for i := range tasks {
+		tasks[i] = Task{Shard: i, Grid: gridConfig}
+	}
+	for i, fp := range footprints {
+		tasks[i%len(tasks)].Footprints = append(tasks[i%len(tasks)].Footprints, fp)
+	}
+	_, grid, err := c.run(ctx, tasks, gridConfig)
+	return grid, err
+}
+
+// run sends one task to each worker and merges their edges and coverage.
+func (c *Coordinator) run(ctx context.Context, tasks []Task, gridConfig coverage.GridConfig) ([]routing.Edge, *coverage.CoverageGrid, error) {
+	grid, err := coverage.AcquireGrid(gridConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]Result, len(tasks))
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+	for i := range tasks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = c.workers[i].Compute(ctx, tasks[i])
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var edges []routing.Edge
+	for i, res := range results {
+		if errs[i] != nil {
+			coverage.ReleaseGrid(grid)
+			return nil, nil, fmt.Errorf("shard %d: %w", i, errs[i])
+		}
+		edges = append(edges, res.Edges...)
+		if err := grid.Merge(res.Coverage); err != nil {
+			coverage.ReleaseGrid(grid)
+			return nil, nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return edges, grid, nil
+}
+
+// Partition assigns every node to one of n shards. Satellites sharing an orbital plane stay
+// together; planes are placed largest first on the least-loaded shard. Ground nodes, which
+// have no plane, fill in afterwards the same way.
+func Partition(nodes []routing.Node, n int) []int {
+	owners := make([]int, len(nodes))
+	if n <= 1 {
+		return owners
+	}
+
+	planes := make(map[string][]int)
+	var ground []int
+	for i, node := range nodes {
+		if node.Type == routing.Ground {
+			ground = append(ground, i)
+			continue
+		}
+		key := PlaneKey(node)
+		planes[key] = append(planes[key], i)
+	}
+	keys := make([]string, 0, len(planes))
+	for key := range planes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if len(planes[keys[a]]) != len(planes[keys[b]]) {
+			return len(planes[keys[a]]) > len(planes[keys[b]])
+		}
+		return keys[a] < keys[b]
+	})
+
+	load := make([]int, n)
+	assign := func(members []int) {
+		target := 0
+		for s := 1; s < n; s++ {
+			if load[s] < load[target] {
+				target = s
+			}
+		}
+		for _, idx := range members {
+			owners[idx] = target
+		}
+		load[target] += len(members)
+	}
+	for _, key := range keys {
+		assign(planes[key])
+	}
+	for _, idx := range ground {
+		assign([]int{idx})
+	}
+	return owners
+}
+
+// PlaneKey identifies a satellite's orbital plane by its inclination and right ascension of the
+// ascending node, both rounded to PlaneResolutionDeg. Nodes without velocity share the empty key.
+func PlaneKey(node routing.Node) string {
+	r, v := node.Position, node.Velocity
+	hx := r.Y*v.Z - r.Z*v.Y
+	hy := r.Z*v.X - r.X*v.Z
+	hz := r.X*v.Y - r.Y*v.X
+	h := math.Sqrt(hx*hx + hy*hy + hz*hz)
+	if h == 0 {
+		return ""
+	}
+	inclination := math.Acos(hz/h) * 180 / math.Pi
+	raan := math.Mod(math.Atan2(hx, -hy)*180/math.Pi+360, 360)
+	if inclination < PlaneResolutionDeg/2 || inclination > 180-PlaneResolutionDeg/2 {
+		// The node line is undefined for equatorial orbits.
+		raan = 0
+	}
+	round := func(deg float64) float64 { return math.Round(deg/PlaneResolutionDeg) * PlaneResolutionDeg }
+	return fmt.Sprintf("i%g/raan%g", round(inclination), math.Mod(round(raan), 360))
+}
@@ -0,0 +1,195 @@
+package shard
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// walkerNodes builds planes×perPlane satellites on circular polar orbits plus a few ground stations.
+func walkerNodes(planes, perPlane int) ([]routing.Node, map[string]coverage.Footprint) {
+	const altitude = 1200.0
+	radius := visibility.EarthRadius + altitude
+	speed := math.Sqrt(398600.4418 / radius)
+
+	var nodes []routing.Node
+	footprints := make(map[string]coverage.Footprint)
+	for p := 0; p < planes; p++ {
+		raan := 2 * math.Pi * float64(p) / float64(planes)
+		for k := 0; k < perPlane; k++ {
+			u := 2 * math.Pi * float64(k) / float64(perPlane)
+			// Polar orbit: rotate the in-plane position by RAAN about Z.
+			x, z := radius*math.Cos(u), radius*math.Sin(u)
+			vx, vz := -speed*math.Sin(u), speed*math.Cos(u)
+			id := fmt.Sprintf("sat-%d-%d", p, k)
+			node := routing.Node{
+				ID:       id,
+				Type:     routing.Satellite,
+				Position: visibility.Vector3{X: x * math.Cos(raan), Y: x * math.Sin(raan), Z: z},
+				Velocity: visibility.Vector3{X: vx * math.Cos(raan), Y: vx * math.Sin(raan), Z: vz},
+			}
+			nodes = append(nodes, node)
+			sub := visibility.ToGeodetic(node.Position)
+			footprints[id] = coverage.Footprint{CenterLat: sub.LatDeg, CenterLon: sub.LonDeg, RadiusKm: 2500, LinkStrength: float64(k + 1)}
+		}
+	}
+	for i, lon := range []float64{0, 90, -120} {
+		nodes = append(nodes, routing.Node{ID: fmt.Sprintf("ground-%d", i), Type: routing.Ground, Position: visibility.FromGeodetic(20, lon, 0)})
+	}
+	return nodes, footprints
+}
+
+func sortedEdges(g *routing.Graph) []routing.Edge {
+	var edges []routing.Edge
+	for _, adj := range g.Adj {
+		edges = append(edges, adj...)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+func TestShardedComputeMatchesSingleProcess(t *testing.T) {
+	nodes, footprints := walkerNodes(6, 8)
+	nodes[len(nodes)-1].User = true
+	nodes[len(nodes)-1].Bands = []string{"ku"}
+	gridConfig := coverage.GridConfig{LatStep: 15, LonStep: 15}
+	mask := 10 * math.Pi / 180
+	gatewayMask := 25 * math.Pi / 180
+
+	server := httptest.NewServer(Handler(Local{}))
+	defer server.Close()
+	coordinator, err := NewCoordinator(Local{}, Remote{BaseURL: server.URL}, Local{})
+	if err != nil {
+		t.Fatalf("new coordinator: %v", err)
+	}
+
+	for _, links := range []routing.LinkSettings{
+		{ElevationMask: mask},
+		{ElevationMask: mask, GatewayMask: &gatewayMask, MaxISLRangeKm: 4000, Bands: []string{"ka"}},
+		{ElevationMask: mask, NoISLs: true},
+	} {
+		wantGraph, err := routing.BuildGraph(nodes, links.Options()...)
+		if err != nil {
+			t.Fatalf("build graph: %v", err)
+		}
+		wantGrid, _ := coverage.NewCoverageGrid(gridConfig)
+		var list []coverage.Footprint
+		for _, fp := range footprints {
+			list = append(list, fp)
+		}
+		wantGrid.ApplyFootprints(list)
+
+		graph, grid, err := coordinator.Compute(context.Background(), nodes, footprints, links, gridConfig)
+		if err != nil {
+			t.Fatalf("sharded compute: %v", err)
+		}
+
+		want, got := sortedEdges(wantGraph), sortedEdges(graph)
+		if len(want) != len(got) {
+			t.Fatalf("%+v: expected %d edges, got %d", links, len(want), len(got))
+		}
+		for i := range want {
+			if !reflect.DeepEqual(want[i], got[i]) {
+				t.Fatalf("%+v: edge %d differs: want %+v, got %+v", links, i, want[i], got[i])
+			}
+		}
+		if wantGrid.Summarize().CoveredCells != grid.Summarize().CoveredCells {
+			t.Fatalf("coverage differs: want %+v, got %+v", wantGrid.Summarize(), grid.Summarize())
+		}
+		wantCells, gotCells := wantGrid.Cells(), grid.Cells()
+		for i := range wantCells {
+			if wantCells[i] != gotCells[i] {
+				t.Fatalf("cell %d differs: want %+v, got %+v", i, wantCells[i], gotCells[i])
+			}
+		}
+	}
+}
+
+func TestShardedCoverMatchesSingleProcess(t *testing.T) {
+	_, footprints := walkerNodes(4, 6)
+	gridConfig := coverage.GridConfig{LatStep: 10, LonStep: 10}
+	var list []coverage.Footprint
+	for _, fp := range footprints {
+		list = append(list, fp)
+	}
+	want, _ := coverage.NewCoverageGrid(gridConfig)
+	want.ApplyFootprints(list)
+
+	coordinator, err := NewCoordinator(Local{}, Local{}, Local{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err := coordinator.Cover(context.Background(), list, gridConfig)
+	if err != nil {
+		t.Fatalf("sharded cover: %v", err)
+	}
+	if !reflect.DeepEqual(want.Cells(), grid.Cells()) {
+		t.Fatalf("coverage differs: want %+v, got %+v", want.Summarize(), grid.Summarize())
+	}
+}
+
+func TestPartitionKeepsPlanesTogether(t *testing.T) {
+	nodes, _ := walkerNodes(4, 5)
+	owners := Partition(nodes, 2)
+
+	planeOwner := make(map[string]int)
+	load := make([]int, 2)
+	for i, node := range nodes {
+		load[owners[i]]++
+		if node.Type != routing.Satellite {
+			continue
+		}
+		key := PlaneKey(node)
+		if owner, seen := planeOwner[key]; seen && owner != owners[i] {
+			t.Fatalf("plane %s split across shards %d and %d", key, owner, owners[i])
+		}
+		planeOwner[key] = owners[i]
+	}
+	if len(planeOwner) != 4 {
+		t.Fatalf("expected 4 distinct planes, got %v", planeOwner)
+	}
+	if load[0] == 0 || load[1] == 0 {
+		t.Fatalf("expected both shards to receive work, got %v", load)
+	}
+}
+
+func TestRemoteReportsWorkerErrors(t *testing.T) {
+	server := httptest.NewServer(Handler(Local{}))
+	defer server.Close()
+
+	_, err := Remote{BaseURL: server.URL}.Compute(context.Background(), Task{Nodes: []routing.Node{{ID: "a"}}})
+	if err == nil {
+		t.Fatalf("expected an error for a task without owners")
+	}
+}
+
+func TestRemoteSendsItsToken(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		Handler(Local{}).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	task := Task{Nodes: []routing.Node{{ID: "a"}}, Owners: []int{0}, Grid: coverage.GridConfig{LatStep: 90, LonStep: 180}}
+	if _, err := (Remote{BaseURL: server.URL, Token: "secret"}).Compute(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	if got != "Bearer secret" {
+		t.Fatalf("expected the bearer token, got %q", got)
+	}
+}
@@ -0,0 +1,309 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// AdmissionPolicy decides what happens to a demand that does not fit in the remaining link capacity.
+type AdmissionPolicy string
+
+const (
+	// AdmitReject blocks the demand.
+	AdmitReject AdmissionPolicy = "reject"
+	// AdmitDegrade admits the demand at whatever bandwidth the best path still has spare.
+	AdmitDegrade AdmissionPolicy = "degrade"
+	// AdmitPreempt tears down lower-priority demands on the path until the demand fits.
+	AdmitPreempt AdmissionPolicy = "preempt"
+)
+
+// AllocationStatus is the admission outcome of one demand in one recompute.
+type AllocationStatus string
+
+const (
+	StatusAdmitted  AllocationStatus = "admitted"
+	StatusDegraded  AllocationStatus = "degraded"
+	StatusBlocked   AllocationStatus = "blocked"
+	StatusPreempted AllocationStatus = "preempted"
+)
+
+// capacityEpsilon absorbs floating-point error when comparing bandwidth against spare capacity.
+const capacityEpsilon = 1e-9
+
+// Allocation records the bandwidth granted to a demand.
+type Allocation struct {
+	Status        AllocationStatus `json:"status"`
+	Priority      int              `json:"priority"`
+	RequestedMbps float64          `json:"requestedMbps"`
+	AllocatedMbps float64          `json:"allocatedMbps"`
+	// QueueingDelayMS is the modeled queueing delay summed over the route's hops.
+	QueueingDelayMS float64 `json:"queueingDelayMs,omitempty"`
+}
+
+// BlockingStats accumulates admission outcomes for one priority class over the steps of a run.
+// BlockingProbability is the share of offered demand-steps that were blocked or preempted.
+type BlockingStats struct {
+	Offered             int     `json:"offered"`
+	Admitted            int     `json:"admitted"`
+	Degraded            int     `json:"degraded"`
+	Blocked             int     `json:"blocked"`
+	Preempted           int     `json:"preempted"`
+	BlockingProbability float64 `json:"blockingProbability"`
+}
+
+func validateAdmission(policy AdmissionPolicy) error {
+	switch policy {
+	case "", AdmitReject, AdmitDegrade, AdmitPreempt:
+		return nil
+	default:
+		return fmt.Errorf("unknown admission policy %q", policy)
+	}
+}
+
+type linkKey struct{ from, to string }
+
+// sortedLinks returns the links of used ordered by endpoints.
+func sortedLinks(used map[linkKey]float64) []linkKey {
+	links := make([]linkKey, 0, len(used))
+	for link := range used {
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].from != links[j].from {
+			return links[i].from < links[j].from
+		}
+		return links[i].to < links[j].to
+	})
+	return links
+}
+
+// capacityState tracks spare capacity per link direction and which demands hold it.
+type capacityState struct {
+	capacity float64             // per link direction, unless directed overrides it
+	directed map[linkKey]float64 // directions with their own capacity, already scaled
+	used     map[linkKey]float64
+	holders  map[linkKey][]string // demand IDs, in admission order
+	links    map[string][]linkKey
+	granted  map[string]Allocation
+}
+
+// newCapacityState grants share of every link direction's capacity: capacity, or the direction's
+// own in directed.
+func newCapacityState(share, capacity float64, directed map[linkKey]float64) *capacityState {
+	c := &capacityState{
+		capacity: share * capacity,
+		directed: make(map[linkKey]float64, len(directed)),
+		used:     make(map[linkKey]float64),
+		holders:  make(map[linkKey][]string),
+		links:    make(map[string][]linkKey),
+		granted:  make(map[string]Allocation),
+	}
+	for key, mbps := range directed {
+		c.directed[key] = share * mbps
+	}
+	return c
+}
+
+// limit returns the capacity granted on one link direction.
+func (c *capacityState) limit(key linkKey) float64 {
+	if mbps, ok := c.directed[key]; ok {
+		return mbps
+	}
+	return c.capacity
+}
+
+func (c *capacityState) spare(e routing.Edge) float64 {
+	key := linkKey{e.From, e.To}
+	return c.limit(key) - c.used[key]
+}
+
+// preemptible sums the bandwidth on a link held by demands with lower priority.
+func (c *capacityState) preemptible(e routing.Edge, priority int) float64 {
+	total := 0.0
+	for _, id := range c.holders[linkKey{e.From, e.To}] {
+		if alloc := c.granted[id]; alloc.Priority < priority {
+			total += alloc.AllocatedMbps
+		}
+	}
+	return total
+}
+
+func (c *capacityState) fits(path routing.Path, bandwidth float64) bool {
+	for _, key := range pathLinks(path) {
+		if c.limit(key)-c.used[key] < bandwidth-capacityEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *capacityState) reserve(id string, path routing.Path, alloc Allocation) {
+	keys := pathLinks(path)
+	for _, key := range keys {
+		c.used[key] += alloc.AllocatedMbps
+		c.holders[key] = append(c.holders[key], id)
+	}
+	c.links[id] = keys
+	c.granted[id] = alloc
+}
+
+func (c *capacityState) release(id string) {
+	alloc := c.granted[id]
+	for _, key := range c.links[id] {
+		c.used[key] -= alloc.AllocatedMbps
+		holders := c.holders[key]
+		for i, holder := range holders {
+			if holder == id {
+				c.holders[key] = append(holders[:i:i], holders[i+1:]...)
+				break
+			}
+		}
+	}
+	delete(c.links, id)
+	delete(c.granted, id)
+}
+
+// preemptFor releases the lowest-priority holders on each link of path until bandwidth fits,
+// returning the IDs of the demands torn down.
+func (c *capacityState) preemptFor(path routing.Path, bandwidth float64, priority int) []string {
+	var victims []string
+	for _, key := range pathLinks(path) {
+		for c.limit(key)-c.used[key] < bandwidth-capacityEpsilon {
+			candidates := append([]string(nil), c.holders[key]...)
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return c.granted[candidates[i]].Priority < c.granted[candidates[j]].Priority
+			})
+			if len(candidates) == 0 || c.granted[candidates[0]].Priority >= priority {
+				break
+			}
+			victims = append(victims, candidates[0])
+			c.release(candidates[0])
+		}
+	}
+	return victims
+}
+
+func pathLinks(path routing.Path) []linkKey {
+	keys := make([]linkKey, 0, len(path.Nodes))
+	for i := 0; i+1 < len(path.Nodes); i++ {
+		keys = append(keys, linkKey{path.Nodes[i], path.Nodes[i+1]})
+	}
+	return keys
+}
+
+// routeWithCapacityLocked admits demands in configuration (arrival) order against per-direction
+// link capacity, applying the admission policy to demands that do not fit.
+// Each slice, and the shared remainder, is admitted against its own reserved capacity.
+// Demands beyond the routing budget are admitted on their previous route, if it still fits.
+func (s *Simulator) routeWithCapacityLocked(graph *routing.Graph, budget *routingBudget) (map[string]routing.Path, map[string]Allocation, float64, map[string]*capacityState) {
+	states := s.newSliceStates()
+	routes := make(map[string]routing.Path, len(s.traffic))
+	allocations := make(map[string]Allocation, len(s.traffic))
+	continuityPenalty := 0.0
+
+	for _, demand := range s.routingOrderLocked() {
+		demand := demand
+		state := states[demandSlice(demand)]
+		bandwidth := demand.BandwidthMbps
+		stale := budget.exhausted()
+		find := func(usable func(routing.Edge) bool) (routing.Path, error) {
+			if stale {
+				return s.previousPathLocked(graph, demand, usable)
+			}
+			return s.findPathLocked(graph, demand, usable)
+		}
+		if stale {
+			budget.stale = append(budget.stale, demand.ID)
+		} else {
+			budget.computed++
+		}
+
+		alloc := Allocation{Status: StatusAdmitted, Priority: demand.Priority, RequestedMbps: bandwidth, AllocatedMbps: bandwidth}
+		path, err := find(func(e routing.Edge) bool { return state.spare(e) >= bandwidth-capacityEpsilon })
+		if err != nil && bandwidth > 0 {
+			switch s.admission {
+			case AdmitDegrade:
+				if path, err = find(func(e routing.Edge) bool { return state.spare(e) > capacityEpsilon }); err == nil {
+					alloc.Status, alloc.AllocatedMbps = StatusDegraded, bottleneckSpare(state, path)
+				}
+			case AdmitPreempt:
+				path, err = find(func(e routing.Edge) bool {
+					return state.spare(e)+state.preemptible(e, demand.Priority) >= bandwidth-capacityEpsilon
+				})
+				if err == nil {
+					for _, victim := range state.preemptFor(path, bandwidth, demand.Priority) {
+						continuityPenalty -= routes[victim].ContinuityPenaltyMS
+						delete(routes, victim)
+						preempted := allocations[victim]
+						preempted.Status, preempted.AllocatedMbps = StatusPreempted, 0
+						allocations[victim] = preempted
+					}
+				}
+			}
+		}
+		if err != nil {
+			alloc.Status, alloc.AllocatedMbps = StatusBlocked, 0
+			allocations[demand.ID] = alloc
+			continue
+		}
+
+		if previous, ok := s.routes[demand.ID]; ok && keepsPreviousRoute(demand) && !stale {
+			if kept := routing.RetainPreviousPath(graph, previous, path, s.continuityPct); state.fits(kept, alloc.AllocatedMbps) {
+				path = kept
+			}
+		}
+		state.reserve(demand.ID, path, alloc)
+		routes[demand.ID] = path
+		allocations[demand.ID] = alloc
+		continuityPenalty += path.ContinuityPenaltyMS
+	}
+	return routes, allocations, continuityPenalty, states
+}
+
+func bottleneckSpare(state *capacityState, path routing.Path) float64 {
+	spare := math.Inf(1)
+	for _, key := range pathLinks(path) {
+		spare = math.Min(spare, state.limit(key)-state.used[key])
+	}
+	return spare
+}
+
+// admissionRecorder accumulates BlockingStats per priority class.
+type admissionRecorder struct {
+	classes map[int]*BlockingStats
+}
+
+func newAdmissionRecorder() *admissionRecorder {
+	return &admissionRecorder{classes: make(map[int]*BlockingStats)}
+}
+
+func (r *admissionRecorder) record(alloc Allocation) {
+	stats, ok := r.classes[alloc.Priority]
+	if !ok {
+		stats = &BlockingStats{}
+		r.classes[alloc.Priority] = stats
+	}
+	stats.Offered++
+	switch alloc.Status {
+	case StatusAdmitted:
+		stats.Admitted++
+	case StatusDegraded:
+		stats.Degraded++
+	case StatusBlocked:
+		stats.Blocked++
+	case StatusPreempted:
+		stats.Preempted++
+	}
+	stats.BlockingProbability = float64(stats.Blocked+stats.Preempted) / float64(stats.Offered)
+}
+
+func (r *admissionRecorder) summary() map[int]BlockingStats {
+	out := make(map[int]BlockingStats, len(r.classes))
+	for priority, stats := range r.classes {
+		out[priority] = *stats
+	}
+	return out
+}
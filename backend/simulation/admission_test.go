@@ -0,0 +1,137 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// bottleneckConfig routes two 60 Mbps demands over a single satellite hop capped at 100 Mbps.
+func bottleneckConfig(policy AdmissionPolicy) Config {
+	return Config{
+		GridConfig:       coverage.GridConfig{LatStep: 180, LonStep: 360},
+		LinkCapacityMbps: 100,
+		Admission:        policy,
+		Satellites: []Satellite{
+			{ID: "relay", Position: visibility.Vector3{X: visibility.EarthRadius + 500, Y: 0, Z: 0}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "ground-a", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 0, Z: 0}},
+			{ID: "ground-b", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 20, Z: 0}},
+		},
+		Traffic: []TrafficDemand{
+			{ID: "bulk", FromID: "ground-a", ToID: "ground-b", BandwidthMbps: 60, Priority: 0},
+			{ID: "voice", FromID: "ground-a", ToID: "ground-b", BandwidthMbps: 60, Priority: 1},
+		},
+	}
+}
+
+func TestAdmissionPolicies(t *testing.T) {
+	cases := []struct {
+		policy      AdmissionPolicy
+		bulk, voice Allocation
+		routed      []string
+	}{
+		{
+			policy: AdmitReject,
+			bulk:   Allocation{Status: StatusAdmitted, RequestedMbps: 60, AllocatedMbps: 60},
+			voice:  Allocation{Status: StatusBlocked, Priority: 1, RequestedMbps: 60},
+			routed: []string{"bulk"},
+		},
+		{
+			policy: AdmitDegrade,
+			bulk:   Allocation{Status: StatusAdmitted, RequestedMbps: 60, AllocatedMbps: 60},
+			voice:  Allocation{Status: StatusDegraded, Priority: 1, RequestedMbps: 60, AllocatedMbps: 40},
+			routed: []string{"bulk", "voice"},
+		},
+		{
+			policy: AdmitPreempt,
+			bulk:   Allocation{Status: StatusPreempted, RequestedMbps: 60},
+			voice:  Allocation{Status: StatusAdmitted, Priority: 1, RequestedMbps: 60, AllocatedMbps: 60},
+			routed: []string{"voice"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			sim, err := NewSimulator(bottleneckConfig(tc.policy))
+			if err != nil {
+				t.Fatalf("failed to build simulator: %v", err)
+			}
+			snapshot := sim.Snapshot()
+			if got := snapshot.Allocations["bulk"]; !sameAllocation(got, tc.bulk) {
+				t.Fatalf("bulk allocation = %+v, want %+v", got, tc.bulk)
+			}
+			if got := snapshot.Allocations["voice"]; !sameAllocation(got, tc.voice) {
+				t.Fatalf("voice allocation = %+v, want %+v", got, tc.voice)
+			}
+			if len(snapshot.Routes) != len(tc.routed) {
+				t.Fatalf("expected routes for %v, got %d routes", tc.routed, len(snapshot.Routes))
+			}
+			for _, id := range tc.routed {
+				if _, ok := snapshot.Routes[id]; !ok {
+					t.Fatalf("expected a route for %s", id)
+				}
+			}
+		})
+	}
+}
+
+func TestUncapacitatedLinksSkipAdmission(t *testing.T) {
+	cfg := bottleneckConfig("")
+	cfg.LinkCapacityMbps = 0
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	snapshot := sim.Snapshot()
+	if snapshot.Allocations != nil {
+		t.Fatalf("expected no allocations without link capacity, got %v", snapshot.Allocations)
+	}
+	if len(snapshot.Routes) != 2 {
+		t.Fatalf("expected both demands routed, got %d", len(snapshot.Routes))
+	}
+}
+
+func TestBlockingProbabilityAccumulatesPerClass(t *testing.T) {
+	sim, err := NewSimulator(bottleneckConfig(AdmitReject))
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	summary, err := sim.Run(3, time.Second)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	bulk, voice := summary.Blocking[0], summary.Blocking[1]
+	if bulk.Offered != 3 || bulk.Admitted != 3 || bulk.BlockingProbability != 0 {
+		t.Fatalf("unexpected bulk stats: %+v", bulk)
+	}
+	if voice.Offered != 3 || voice.Blocked != 3 || voice.BlockingProbability != 1 {
+		t.Fatalf("unexpected voice stats: %+v", voice)
+	}
+	if got := sim.AdmissionSummary(); got[1] != voice {
+		t.Fatalf("AdmissionSummary = %+v, want %+v", got[1], voice)
+	}
+}
+
+func TestInvalidAdmissionConfigRejected(t *testing.T) {
+	cfg := bottleneckConfig("first-come")
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected unknown admission policy to be rejected")
+	}
+
+	cfg = bottleneckConfig(AdmitReject)
+	cfg.Traffic[0].BandwidthMbps = -1
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected negative bandwidth to be rejected")
+	}
+}
+
+func sameAllocation(a, b Allocation) bool {
+	return a.Status == b.Status && a.Priority == b.Priority && a.RequestedMbps == b.RequestedMbps &&
+		math.Abs(a.AllocatedMbps-b.AllocatedMbps) < 1e-9
+}
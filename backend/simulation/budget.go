@@ -0,0 +1,94 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// errNoPreviousRoute reports a stale demand with no still-valid route to fall back on.
+var errNoPreviousRoute = fmt.Errorf("%w: no previous route to reuse", routing.ErrNoRoute)
+
+// routingBudget bounds the wall time spent finding routes in one recompute. At least one demand is
+// always routed, so demands left stale are guaranteed to be reached on later ticks.
+type routingBudget struct {
+	limit    time.Duration
+	started  time.Time
+	computed int
+	stale    []string
+}
+
+func (s *Simulator) newRoutingBudget() *routingBudget {
+	return &routingBudget{limit: time.Duration(s.routingBudgetMS * float64(time.Millisecond)), started: time.Now()}
+}
+
+// exhausted reports whether the next demand should reuse its previous route instead of searching.
+func (b *routingBudget) exhausted() bool {
+	return b.limit > 0 && b.computed > 0 && time.Since(b.started) > b.limit
+}
+
+// staleIDs lists the demands skipped this recompute, sorted.
+func (b *routingBudget) staleIDs() []string {
+	sort.Strings(b.stale)
+	return b.stale
+}
+
+// routingOrderLocked lists the demands active at the simulation clock, putting those that have gone
+// longest without a fresh route first, so a budget that cannot cover every demand still refreshes
+// each of them in turn.
+func (s *Simulator) routingOrderLocked() []TrafficDemand {
+	order := s.traffic
+	if len(s.demandWindows) > 0 || len(s.deferred) > 0 {
+		order = make([]TrafficDemand, 0, len(s.traffic))
+		for _, demand := range s.traffic {
+			if s.activeLocked(demand) {
+				order = append(order, demand)
+			}
+		}
+	}
+	if len(s.stale) == 0 {
+		return order
+	}
+	order = append([]TrafficDemand(nil), order...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return s.stale[order[i].ID] > s.stale[order[j].ID]
+	})
+	return order
+}
+
+// previousPathLocked re-evaluates a demand's previous route on the new graph, failing when a hop has
+// disappeared or is rejected by usable.
+func (s *Simulator) previousPathLocked(graph *routing.Graph, demand TrafficDemand, usable func(routing.Edge) bool) (routing.Path, error) {
+	previous, ok := s.routes[demand.ID]
+	if !ok {
+		return routing.Path{}, errNoPreviousRoute
+	}
+	path, err := graph.PathAlong(previous.Nodes)
+	if err != nil || !pathUsable(graph, path, usable) {
+		return routing.Path{}, errNoPreviousRoute
+	}
+	return path, nil
+}
+
+// pathUsable reports whether every hop of path is accepted by usable; a nil usable accepts all.
+func pathUsable(graph *routing.Graph, path routing.Path, usable func(routing.Edge) bool) bool {
+	if usable == nil {
+		return true
+	}
+	for i := 0; i+1 < len(path.Nodes); i++ {
+		accepted := false
+		graph.EdgesFrom(path.Nodes[i], func(e routing.Edge) bool {
+			if e.To == path.Nodes[i+1] {
+				accepted = usable(e)
+				return false
+			}
+			return true
+		})
+		if !accepted {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package simulation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRoutingBudgetRoutesStaleDemandsFirst(t *testing.T) {
+	cfg := bottleneckConfig(AdmitReject)
+	cfg.LinkCapacityMbps = 0
+	cfg.RoutingBudgetMS = 1e-6
+	cfg.Traffic = []TrafficDemand{
+		{ID: "a", FromID: "ground-a", ToID: "ground-b"},
+		{ID: "b", FromID: "ground-a", ToID: "ground-b"},
+		{ID: "c", FromID: "ground-b", ToID: "ground-a"},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+
+	// Each recompute routes one demand; the rest keep their previous route, if they have one.
+	snapshot := sim.Snapshot()
+	if !reflect.DeepEqual(snapshot.StaleRoutes, []string{"b", "c"}) || len(snapshot.Routes) != 1 {
+		t.Fatalf("expected only a to be routed, got routes %v stale %v", snapshot.Routes, snapshot.StaleRoutes)
+	}
+	wantStale := [][]string{{"a", "c"}, {"a", "b"}, {"b", "c"}}
+	for i, want := range wantStale {
+		snapshot, err = sim.Step(time.Second)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(snapshot.StaleRoutes, want) {
+			t.Fatalf("step %d: stale = %v, want %v", i, snapshot.StaleRoutes, want)
+		}
+	}
+	if len(snapshot.Routes) != 3 {
+		t.Fatalf("expected stale demands to keep their previous routes, got %v", snapshot.Routes)
+	}
+}
+
+func TestRoutingBudgetWithCapacityKeepsAllocations(t *testing.T) {
+	cfg := bottleneckConfig(AdmitReject)
+	cfg.RoutingBudgetMS = 1e-6
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if _, err := sim.Step(time.Second); err != nil {
+		t.Fatalf("step: %v", err)
+	}
+	snapshot := sim.Snapshot()
+	if len(snapshot.StaleRoutes) != 1 {
+		t.Fatalf("expected one stale demand, got %v", snapshot.StaleRoutes)
+	}
+	admitted := 0
+	for _, alloc := range snapshot.Allocations {
+		if alloc.Status == StatusAdmitted {
+			admitted++
+		}
+	}
+	if admitted != 1 {
+		t.Fatalf("expected the stale route to respect capacity, got %+v", snapshot.Allocations)
+	}
+}
+
+func TestNegativeRoutingBudgetIsRejected(t *testing.T) {
+	cfg := bottleneckConfig(AdmitReject)
+	cfg.RoutingBudgetMS = -1
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a negative routing budget to be rejected")
+	}
+}
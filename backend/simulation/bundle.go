@@ -0,0 +1,193 @@
+package simulation
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	bundleFormat  = "satnet-bundle"
+	bundleVersion = 1
+	// maxBundleFileBytes bounds each decompressed bundle file, so a small archive cannot expand
+	// without limit.
+	maxBundleFileBytes = 256 << 20
+)
+
+// BundleManifest identifies a bundle and the state it captured.
+type BundleManifest struct {
+	Format          string    `json:"format"`
+	Version         int       `json:"version"`
+	Exported        time.Time `json:"exported"`
+	SnapshotVersion uint64    `json:"snapshotVersion"`
+	SimTime         time.Time `json:"simTime"`
+}
+
+// Bundle is a portable record of a simulator run: the scenario and journal reproduce it, while the
+// buffered snapshots and the report preserve what it produced. As a zip archive it holds
+// manifest.json, scenario.json, events.json, report.json and one snapshots/<version>.json per
+// buffered snapshot.
+type Bundle struct {
+	Manifest  BundleManifest
+	Scenario  Config
+	Events    []Operation
+	Snapshots []Snapshot // the history buffer, oldest first
+	// Report summarizes the run from the scenario epoch to the current snapshot.
+	Report RunSummary
+}
+
+// Bundle captures the simulator's scenario, journal, history and statistics.
+func (s *Simulator) Bundle() Bundle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := Bundle{
+		Manifest: BundleManifest{
+			Format:          bundleFormat,
+			Version:         bundleVersion,
+			Exported:        time.Now().UTC(),
+			SnapshotVersion: s.snapshot.Version,
+			SimTime:         s.clock,
+		},
+		Scenario: s.scenario,
+		Events:   append([]Operation{}, s.journal...),
+		Report:   RunSummary{Start: s.scenario.Epoch, End: s.clock, Latency: s.latency.summary(), Jitter: s.jitter.summary(), Snapshot: s.snapshot},
+	}
+	for _, op := range s.journal {
+		if op.Op == OpStep {
+			b.Report.Steps += op.Count
+		}
+	}
+	if s.linkCapacity > 0 {
+		b.Report.Blocking = s.admissions.summary()
+	}
+	if s.history != nil {
+		for _, summary := range s.history.Summaries() {
+			if snapshot, ok := s.history.Get(summary.Version); ok {
+				b.Snapshots = append(b.Snapshots, snapshot)
+			}
+		}
+	}
+	return b
+}
+
+// Reproduces reports whether snapshot, typically from replaying the bundle's events against its
+// scenario, reached the state the bundle captured.
+func (b Bundle) Reproduces(snapshot Snapshot) error {
+	if snapshot.Version != b.Manifest.SnapshotVersion || !snapshot.SimTime.Equal(b.Manifest.SimTime) {
+		return fmt.Errorf("replay reached version %d at %s, bundle captured version %d at %s",
+			snapshot.Version, snapshot.SimTime.Format(time.RFC3339Nano), b.Manifest.SnapshotVersion, b.Manifest.SimTime.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+// WriteBundle encodes b as a zip archive.
+func WriteBundle(w io.Writer, b Bundle) error {
+	type file struct {
+		name  string
+		value any
+	}
+	archive := zip.NewWriter(w)
+	files := []file{
+		{"manifest.json", b.Manifest},
+		{"scenario.json", b.Scenario},
+		{"events.json", b.Events},
+		{"report.json", b.Report},
+	}
+	for _, snapshot := range b.Snapshots {
+		files = append(files, file{fmt.Sprintf("snapshots/%d.json", snapshot.Version), snapshot})
+	}
+	for _, file := range files {
+		out, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.value); err != nil {
+			return fmt.Errorf("%s: %w", file.name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// ReadBundle decodes a zip archive written by WriteBundle. The scenario is parsed as strictly as a
+// scenario file.
+func ReadBundle(r io.ReaderAt, size int64) (Bundle, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("open bundle: %w", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	read := func(name string) ([]byte, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("bundle has no %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(io.LimitReader(rc, maxBundleFileBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(data) > maxBundleFileBytes {
+			return nil, fmt.Errorf("%s exceeds %d bytes", name, maxBundleFileBytes)
+		}
+		return data, nil
+	}
+	decode := func(name string, into any) error {
+		data, err := read(name)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, into); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+
+	var b Bundle
+	if err := decode("manifest.json", &b.Manifest); err != nil {
+		return Bundle{}, err
+	}
+	if b.Manifest.Format != bundleFormat || b.Manifest.Version != bundleVersion {
+		return Bundle{}, fmt.Errorf("unsupported bundle %q version %d", b.Manifest.Format, b.Manifest.Version)
+	}
+	scenario, err := read("scenario.json")
+	if err != nil {
+		return Bundle{}, err
+	}
+	if b.Scenario, err = ParseScenario(bytes.NewReader(scenario)); err != nil {
+		return Bundle{}, fmt.Errorf("scenario.json: %w", err)
+	}
+	if err := decode("events.json", &b.Events); err != nil {
+		return Bundle{}, err
+	}
+	if err := decode("report.json", &b.Report); err != nil {
+		return Bundle{}, err
+	}
+
+	for _, f := range archive.File {
+		if path.Dir(f.Name) != "snapshots" || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		var snapshot Snapshot
+		if err := decode(f.Name, &snapshot); err != nil {
+			return Bundle{}, err
+		}
+		b.Snapshots = append(b.Snapshots, snapshot)
+	}
+	sort.Slice(b.Snapshots, func(i, j int) bool { return b.Snapshots[i].Version < b.Snapshots[j].Version })
+	return b, nil
+}
@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBundleRoundTripReproducesRun(t *testing.T) {
+	cfg, _ := PresetScenario("hybrid")
+	cfg.HistorySize = 4
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := sim.Step(time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sim.DisableSatellite("iridium-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.SetShellEnabled("geo", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, sim.Bundle()); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	b, err := ReadBundle(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+	if len(b.Events) != 4 || b.Events[0].Count != 3 || b.Report.Steps != 4 || len(b.Snapshots) != 4 {
+		t.Fatalf("unexpected bundle: events %+v, %d steps, %d snapshots", b.Events, b.Report.Steps, len(b.Snapshots))
+	}
+	for i := 1; i < len(b.Snapshots); i++ {
+		if b.Snapshots[i].Version <= b.Snapshots[i-1].Version {
+			t.Fatal("snapshots out of order")
+		}
+	}
+
+	replayed, err := NewSimulator(b.Scenario)
+	if err != nil {
+		t.Fatalf("failed to rebuild simulator: %v", err)
+	}
+	if err := replayed.Replay(b.Events); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	want, got := sim.Snapshot(), replayed.Snapshot()
+	if err := b.Reproduces(got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Coverage.CoveragePercent != want.Coverage.CoveragePercent || len(got.DisabledSatellites) != len(want.DisabledSatellites) {
+		t.Fatalf("replay diverged: %v%% vs %v%%", got.Coverage.CoveragePercent, want.Coverage.CoveragePercent)
+	}
+	if journal := replayed.Journal(); len(journal) != len(b.Events) {
+		t.Fatalf("replayed journal %+v", journal)
+	}
+
+	if err := replayed.Replay([]Operation{{Op: OpRemoveSatellite, Target: "missing"}}); err == nil {
+		t.Fatal("expected replaying an unknown satellite to fail")
+	}
+	if _, err := ReadBundle(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Fatal("expected a non-zip bundle to be rejected")
+	}
+}
+
+func TestBundleEncodesSelfDemandRoutes(t *testing.T) {
+	cfg, _ := PresetScenario("hybrid")
+	station := cfg.GroundStations[0].ID
+	cfg.Traffic = append(cfg.Traffic, TrafficDemand{ID: "loop", FromID: station, ToID: station})
+	cfg.HistorySize = 2
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if path := sim.Snapshot().Routes["loop"]; !math.IsInf(path.BottleneckThroughput, 1) {
+		t.Fatalf("expected the single-node route to have an unbounded bottleneck, got %+v", path)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, sim.Bundle()); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	b, err := ReadBundle(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+	last := b.Snapshots[len(b.Snapshots)-1]
+	if path := last.Routes["loop"]; len(path.Nodes) != 1 || !math.IsInf(path.BottleneckThroughput, 1) {
+		t.Fatalf("expected the route to read back unbounded, got %+v", path)
+	}
+}
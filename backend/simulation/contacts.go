@@ -0,0 +1,229 @@
+package simulation
+
+import (
+	"sort"
+	"time"
+)
+
+// PassStatus is the outcome of a satellite's pass over the command stations.
+type PassStatus string
+
+const (
+	// PassInProgress passes have not ended at the latest step.
+	PassInProgress PassStatus = "in-progress"
+	// PassTracked passes had an antenna for at least one step.
+	PassTracked PassStatus = "tracked"
+	// PassUnservable passes ended without a free antenna at any station in view.
+	PassUnservable PassStatus = "unservable"
+)
+
+// Pass is one period during which a satellite links with at least one command station. Passes
+// open and close on steps, so their times are as coarse as the steps.
+type Pass struct {
+	Satellite string     `json:"satellite"`
+	Status    PassStatus `json:"status"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+	// Stations lists the stations whose antennas tracked the satellite during the pass, in the
+	// order they took it.
+	Stations []string `json:"stations,omitempty"`
+	// TrackedS is the simulated time an antenna tracked the satellite, at most the pass duration.
+	TrackedS float64 `json:"trackedS"`
+	// ConflictS is the simulated time the satellite was in view but every antenna in reach was
+	// busy with other satellites.
+	ConflictS float64 `json:"conflictS"`
+}
+
+// AntennaTrack is a command-station antenna tracking a satellite at the latest step.
+type AntennaTrack struct {
+	Station string `json:"station"`
+	// Antenna numbers the station's antennas from 1.
+	Antenna   int     `json:"antenna"`
+	Satellite string  `json:"satellite"`
+	LatencyMS float64 `json:"latencyMs"`
+}
+
+// contactSchedule assigns command-station antennas to the satellites in view on every step and
+// keeps the passes they make.
+type contactSchedule struct {
+	at     time.Time
+	tracks map[string]AntennaTrack // by satellite
+	// blocked holds the satellites in view without an antenna at the latest step.
+	blocked map[string]bool
+	passes  []Pass
+	open    map[string]int // satellite to its index in passes
+}
+
+func newContactSchedule(at time.Time) *contactSchedule {
+	return &contactSchedule{at: at, tracks: make(map[string]AntennaTrack), blocked: make(map[string]bool), open: make(map[string]int)}
+}
+
+// Contacts returns the antennas tracking satellites at the latest step, by station and antenna,
+// and every pass so far, oldest first. It returns ErrTTCNotModeled without a ttc section.
+func (s *Simulator) Contacts() ([]AntennaTrack, []Pass, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ttc == nil {
+		return nil, nil, ErrTTCNotModeled
+	}
+	tracks := make([]AntennaTrack, 0, len(s.contacts.tracks))
+	for _, track := range s.contacts.tracks {
+		tracks = append(tracks, track)
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		if tracks[i].Station != tracks[j].Station {
+			return tracks[i].Station < tracks[j].Station
+		}
+		return tracks[i].Antenna < tracks[j].Antenna
+	})
+	passes := make([]Pass, len(s.contacts.passes))
+	for i, pass := range s.contacts.passes {
+		pass.Stations = append([]string(nil), pass.Stations...)
+		passes[i] = pass
+	}
+	return tracks, passes, nil
+}
+
+// scheduleContactsLocked credits the time since the previous schedule to the passes in progress,
+// closes the passes of satellites no longer in view, and assigns antennas for the next step from
+// the latest recompute.
+//
+// Satellites keep the antenna they were tracked by while it stays in view, so passes are not
+// handed over. The others take a free antenna in this order: satellites with commands awaiting
+// contact, then those in view of the fewest stations, then by ID; each takes the station with
+// the shortest link among those with an antenna free.
+func (s *Simulator) scheduleContactsLocked() {
+	c := s.contacts
+	elapsed := s.clock.Sub(c.at).Seconds()
+	c.at = s.clock
+	for id, i := range c.open {
+		if _, ok := c.tracks[id]; ok {
+			c.passes[i].TrackedS += elapsed
+		} else if c.blocked[id] {
+			c.passes[i].ConflictS += elapsed
+		}
+	}
+
+	// inView maps each satellite to the latency of its links to command stations.
+	inView := make(map[string]map[string]float64)
+	if s.graph != nil {
+		for id, sat := range s.satellites {
+			if !sat.Active {
+				continue
+			}
+			for _, e := range s.graph.Adj[id] {
+				if !s.commandStationLocked(e.To) {
+					continue
+				}
+				if inView[id] == nil {
+					inView[id] = make(map[string]float64)
+				}
+				if latency, ok := inView[id][e.To]; !ok || e.LatencyMS < latency {
+					inView[id][e.To] = e.LatencyMS
+				}
+			}
+		}
+	}
+	for id, i := range c.open {
+		if _, ok := inView[id]; ok {
+			continue
+		}
+		end := s.clock
+		c.passes[i].End = &end
+		c.passes[i].Status = PassUnservable
+		if c.passes[i].TrackedS > 0 {
+			c.passes[i].Status = PassTracked
+		}
+		delete(c.open, id)
+	}
+
+	busy := make(map[string]map[int]bool) // station to the antennas taken
+	take := func(track AntennaTrack) {
+		if busy[track.Station] == nil {
+			busy[track.Station] = make(map[int]bool)
+		}
+		busy[track.Station][track.Antenna] = true
+	}
+	tracks := make(map[string]AntennaTrack, len(c.tracks))
+	for id, track := range c.tracks {
+		if latency, ok := inView[id][track.Station]; ok {
+			track.LatencyMS = latency
+			tracks[id] = track
+			take(track)
+		}
+	}
+	pending := make(map[string]bool)
+	for _, cmd := range s.commands {
+		if cmd.Status == CommandAwaitingContact {
+			pending[cmd.Satellite] = true
+		}
+	}
+	var waiting []string
+	for id := range inView {
+		if _, ok := tracks[id]; !ok {
+			waiting = append(waiting, id)
+		}
+	}
+	sort.Slice(waiting, func(i, j int) bool {
+		a, b := waiting[i], waiting[j]
+		if pending[a] != pending[b] {
+			return pending[a]
+		}
+		if len(inView[a]) != len(inView[b]) {
+			return len(inView[a]) < len(inView[b])
+		}
+		return a < b
+	})
+	blocked := make(map[string]bool)
+	for _, id := range waiting {
+		best := AntennaTrack{Satellite: id}
+		for station, latency := range inView[id] {
+			antenna := s.freeAntennaLocked(station, busy[station])
+			if antenna == 0 {
+				continue
+			}
+			if best.Antenna == 0 || latency < best.LatencyMS || latency == best.LatencyMS && station < best.Station {
+				best.Station, best.Antenna, best.LatencyMS = station, antenna, latency
+			}
+		}
+		if best.Antenna == 0 {
+			blocked[id] = true
+			continue
+		}
+		tracks[id] = best
+		take(best)
+	}
+	c.tracks, c.blocked = tracks, blocked
+
+	ids := make([]string, 0, len(inView))
+	for id := range inView {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		i, ok := c.open[id]
+		if !ok {
+			i = len(c.passes)
+			c.passes = append(c.passes, Pass{Satellite: id, Status: PassInProgress, Start: s.clock})
+			c.open[id] = i
+		}
+		if track, ok := tracks[id]; ok {
+			stations := c.passes[i].Stations
+			if len(stations) == 0 || stations[len(stations)-1] != track.Station {
+				c.passes[i].Stations = append(stations, track.Station)
+			}
+		}
+	}
+}
+
+// freeAntennaLocked returns the lowest-numbered antenna of a station not in busy, or 0 when all
+// are. Stations without an antenna count have one antenna per satellite.
+func (s *Simulator) freeAntennaLocked(station string, busy map[int]bool) int {
+	count, limited := s.ttc.Antennas[station]
+	for antenna := 1; !limited || antenna <= count; antenna++ {
+		if !busy[antenna] {
+			return antenna
+		}
+	}
+	return 0
+}
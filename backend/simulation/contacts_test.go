@@ -0,0 +1,141 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestContactSchedulerSharesOneAntenna(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites: []Satellite{
+			{ID: "a", Position: visibility.Vector3{X: visibility.EarthRadius + 550}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}},
+			{ID: "b", Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: 100}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{{ID: "tt&c", Location: &visibility.Geodetic{}}},
+		Epoch:          time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		TTC:            &TTCConfig{Antennas: map[string]int{"tt&c": 1}},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks, _, err := sim.Contacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 || tracks[0].Satellite != "a" || tracks[0].Antenna != 1 {
+		t.Fatalf("expected the only antenna on satellite a, got %+v", tracks)
+	}
+
+	// A command for b does not take the antenna from a mid-pass.
+	cmd, err := sim.CommandDisableSatellite("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Status != CommandAwaitingContact {
+		t.Fatalf("expected the command to wait for a free antenna, got %+v", cmd)
+	}
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if cmd = sim.Commands()[0]; cmd.Status != CommandAwaitingContact {
+		t.Fatalf("expected the command to keep waiting while a holds the antenna, got %+v", cmd)
+	}
+
+	if _, err := sim.RemoveSatellite("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if cmd = sim.Commands()[0]; cmd.Status != CommandUplinked || cmd.Station != "tt&c" {
+		t.Fatalf("expected the command uplinked once the antenna freed up, got %+v", cmd)
+	}
+	tracks, passes, err := sim.Contacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 || tracks[0].Satellite != "b" {
+		t.Fatalf("expected the antenna to move to b, got %+v", tracks)
+	}
+	if len(passes) != 2 {
+		t.Fatalf("expected a pass per satellite, got %+v", passes)
+	}
+	a, b := passes[0], passes[1]
+	if a.Satellite != "a" || a.Status != PassTracked || a.End == nil || a.TrackedS != 120 || a.ConflictS != 0 {
+		t.Fatalf("expected a's pass tracked throughout and closed, got %+v", a)
+	}
+	if b.Satellite != "b" || b.Status != PassInProgress || b.End != nil || b.TrackedS != 0 || b.ConflictS != 120 || len(b.Stations) != 1 {
+		t.Fatalf("expected b's pass open after two minutes of conflict, got %+v", b)
+	}
+
+	cfg.TTC = &TTCConfig{Stations: []string{"tt&c"}, Antennas: map[string]int{"tt&c": 0}}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a station without antennas to be rejected")
+	}
+	cfg.GroundStations = append(cfg.GroundStations, GroundStation{ID: "gateway", Location: &visibility.Geodetic{LonDeg: 90}})
+	cfg.TTC = &TTCConfig{Stations: []string{"tt&c"}, Antennas: map[string]int{"gateway": 1}}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected antennas at a station that cannot command to be rejected")
+	}
+}
+
+func TestUnservablePassesAreReported(t *testing.T) {
+	cfg := ttcConfig()
+	cfg.Satellites = append(cfg.Satellites, Satellite{
+		ID:        "rival",
+		Orbit:     cfg.Satellites[0].Orbit,
+		Footprint: coverage.Footprint{Auto: true, LinkStrength: 1},
+	})
+	cfg.TTC.Antennas = map[string]int{"tt&c": 1}
+	// passes runs two hours, in which two satellites on the same orbit pass the station together
+	// once, and returns the closed passes by satellite.
+	passes := func(command bool) map[string]Pass {
+		sim, err := NewSimulator(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if command {
+			if _, err := sim.CommandDisableSatellite("sat"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 120; i++ {
+			if _, err := sim.Step(time.Minute); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, all, err := sim.Contacts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		closed := map[string]Pass{}
+		for _, pass := range all {
+			if pass.End != nil {
+				closed[pass.Satellite] = pass
+			}
+		}
+		return closed
+	}
+
+	// With equal links, the first satellite by ID keeps the antenna for the whole pass.
+	got := passes(false)
+	rival, sat := got["rival"], got["sat"]
+	if rival.Status != PassTracked || sat.Status != PassUnservable || len(sat.Stations) != 0 {
+		t.Fatalf("expected rival tracked and sat unservable, got %+v", got)
+	}
+	if duration := sat.End.Sub(sat.Start).Seconds(); sat.ConflictS != duration || rival.TrackedS != duration {
+		t.Fatalf("expected sat in conflict for all %vs of the pass, got %+v", duration, got)
+	}
+
+	// A command awaiting contact puts its satellite first.
+	got = passes(true)
+	rival, sat = got["rival"], got["sat"]
+	if sat.Status != PassTracked || sat.ConflictS != 0 || rival.ConflictS == 0 || !sat.Start.Equal(rival.Start) {
+		t.Fatalf("expected sat tracked from the start of the pass, got %+v", got)
+	}
+}
@@ -0,0 +1,208 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// Weightings accepted by DemandGenerator.Weighting.
+const (
+	// WeightUniform spreads sources evenly over the Earth's surface.
+	WeightUniform = "uniform"
+	// WeightPopulation draws sources around the world's largest metropolitan areas, in proportion
+	// to their population.
+	WeightPopulation = "population"
+	// WeightDensity draws sources around the points of DemandGenerator.Density, in proportion to
+	// their weights.
+	WeightDensity = "density"
+)
+
+// maxGeneratedDemands bounds DemandGenerator.Count.
+const maxGeneratedDemands = 100000
+
+// defaultDensityRadiusKm spreads sources around a density point or metro area without a radius.
+const defaultDensityRadiusKm = 100
+
+// DemandGenerator samples a population of location-sourced demands for Monte Carlo load studies.
+// The same seed always yields the same demands, so a run is reproducible and a study varies only
+// the seed between runs.
+type DemandGenerator struct {
+	Count int   `json:"count"`
+	Seed  int64 `json:"seed"`
+	// Weighting is WeightUniform (the default), WeightPopulation, or WeightDensity.
+	Weighting string         `json:"weighting,omitempty"`
+	Density   []DensityPoint `json:"density,omitempty"`
+	// To lists the ground stations demands are sent to, each drawn uniformly; empty selects every
+	// ground station.
+	To            []string `json:"to,omitempty"`
+	BandwidthMbps float64  `json:"bandwidthMbps,omitempty"`
+	Priority      int      `json:"priority,omitempty"`
+	Class         string   `json:"class,omitempty"`
+}
+
+// DensityPoint is one weighted area of a density map. Sources drawn from it are spread evenly
+// over the cap of RadiusKm around it.
+type DensityPoint struct {
+	LatDeg   float64 `json:"latDeg"`
+	LonDeg   float64 `json:"lonDeg"`
+	Weight   float64 `json:"weight"`
+	RadiusKm float64 `json:"radiusKm,omitempty"`
+}
+
+// populationCenters approximates where people live with the largest metropolitan areas, weighted
+// by population in millions.
+var populationCenters = []DensityPoint{
+	{LatDeg: 35.68, LonDeg: 139.69, Weight: 37.2, RadiusKm: 80},  // Tokyo
+	{LatDeg: 28.61, LonDeg: 77.21, Weight: 32.9, RadiusKm: 60},   // Delhi
+	{LatDeg: 31.23, LonDeg: 121.47, Weight: 29.2, RadiusKm: 70},  // Shanghai
+	{LatDeg: 23.81, LonDeg: 90.41, Weight: 23.2, RadiusKm: 40},   // Dhaka
+	{LatDeg: -23.55, LonDeg: -46.63, Weight: 22.6, RadiusKm: 60}, // São Paulo
+	{LatDeg: 19.43, LonDeg: -99.13, Weight: 22.3, RadiusKm: 60},  // Mexico City
+	{LatDeg: 30.04, LonDeg: 31.24, Weight: 22.2, RadiusKm: 50},   // Cairo
+	{LatDeg: 39.90, LonDeg: 116.41, Weight: 21.8, RadiusKm: 70},  // Beijing
+	{LatDeg: 19.08, LonDeg: 72.88, Weight: 21.3, RadiusKm: 50},   // Mumbai
+	{LatDeg: 34.69, LonDeg: 135.50, Weight: 19.0, RadiusKm: 60},  // Osaka
+	{LatDeg: 29.56, LonDeg: 106.55, Weight: 17.3, RadiusKm: 60},  // Chongqing
+	{LatDeg: 24.86, LonDeg: 67.01, Weight: 17.2, RadiusKm: 50},   // Karachi
+	{LatDeg: 41.01, LonDeg: 28.98, Weight: 15.8, RadiusKm: 60},   // Istanbul
+	{LatDeg: 22.57, LonDeg: 88.36, Weight: 15.5, RadiusKm: 50},   // Kolkata
+	{LatDeg: -34.60, LonDeg: -58.38, Weight: 15.5, RadiusKm: 60}, // Buenos Aires
+	{LatDeg: 6.52, LonDeg: 3.38, Weight: 15.9, RadiusKm: 50},     // Lagos
+	{LatDeg: -4.44, LonDeg: 15.27, Weight: 16.3, RadiusKm: 40},   // Kinshasa
+	{LatDeg: 14.60, LonDeg: 120.98, Weight: 14.7, RadiusKm: 40},  // Manila
+	{LatDeg: 40.71, LonDeg: -74.01, Weight: 18.9, RadiusKm: 80},  // New York
+	{LatDeg: 34.05, LonDeg: -118.24, Weight: 12.5, RadiusKm: 80}, // Los Angeles
+	{LatDeg: 55.76, LonDeg: 37.62, Weight: 12.6, RadiusKm: 60},   // Moscow
+	{LatDeg: 51.51, LonDeg: -0.13, Weight: 9.6, RadiusKm: 50},    // London
+	{LatDeg: 48.86, LonDeg: 2.35, Weight: 11.2, RadiusKm: 50},    // Paris
+	{LatDeg: -6.21, LonDeg: 106.85, Weight: 11.2, RadiusKm: 50},  // Jakarta
+	{LatDeg: 13.76, LonDeg: 100.50, Weight: 11.1, RadiusKm: 50},  // Bangkok
+	{LatDeg: 37.57, LonDeg: 126.98, Weight: 10.0, RadiusKm: 50},  // Seoul
+	{LatDeg: 35.69, LonDeg: 51.39, Weight: 9.5, RadiusKm: 50},    // Tehran
+	{LatDeg: -12.05, LonDeg: -77.04, Weight: 11.2, RadiusKm: 50}, // Lima
+	{LatDeg: 4.71, LonDeg: -74.07, Weight: 11.5, RadiusKm: 40},   // Bogotá
+	{LatDeg: -33.87, LonDeg: 151.21, Weight: 5.3, RadiusKm: 60},  // Sydney
+}
+
+func (g DemandGenerator) validate() error {
+	if g.Count <= 0 || g.Count > maxGeneratedDemands {
+		return fmt.Errorf("demand generator count must be between 1 and %d", maxGeneratedDemands)
+	}
+	if !(g.BandwidthMbps >= 0) {
+		return errors.New("demand generator bandwidth cannot be negative")
+	}
+	switch g.Weighting {
+	case "", WeightUniform, WeightPopulation:
+		if len(g.Density) > 0 {
+			return errors.New("demand generator density is only used with density weighting")
+		}
+	case WeightDensity:
+		if len(g.Density) == 0 {
+			return errors.New("demand generator density weighting needs at least one density point")
+		}
+		total := 0.0
+		for i, p := range g.Density {
+			switch {
+			case !(p.LatDeg >= -90 && p.LatDeg <= 90) || !(p.LonDeg >= -180 && p.LonDeg <= 180):
+				return fmt.Errorf("demand generator density point %d is off the globe", i)
+			case !(p.Weight >= 0) || math.IsInf(p.Weight, 1):
+				return fmt.Errorf("demand generator density point %d weight must be a non-negative number", i)
+			case !(p.RadiusKm >= 0) || math.IsInf(p.RadiusKm, 1):
+				return fmt.Errorf("demand generator density point %d radius must be a non-negative number", i)
+			}
+			total += p.Weight
+		}
+		if !(total > 0) || math.IsInf(total, 1) {
+			return errors.New("demand generator density weights must sum to a positive number")
+		}
+	default:
+		return fmt.Errorf("unknown demand generator weighting %q", g.Weighting)
+	}
+	return nil
+}
+
+// GenerateDemands samples gen.Count demands sourced at random locations and sent to the given
+// ground stations, or to gen.To when set. Demand IDs are "generated-1", "generated-2", and so on.
+func GenerateDemands(gen DemandGenerator, stations []string) ([]TrafficDemand, error) {
+	if err := gen.validate(); err != nil {
+		return nil, err
+	}
+	destinations := gen.To
+	if len(destinations) == 0 {
+		destinations = append([]string(nil), stations...)
+		sort.Strings(destinations)
+	}
+	known := make(map[string]bool, len(stations))
+	for _, id := range stations {
+		known[id] = true
+	}
+	for _, id := range gen.To {
+		if !known[id] {
+			return nil, fmt.Errorf("demand generator sends to %q, which is not a ground station", id)
+		}
+	}
+	if len(destinations) == 0 {
+		return nil, errors.New("demand generator has no ground stations to send demands to")
+	}
+
+	points := gen.Density
+	if gen.Weighting == WeightPopulation {
+		points = populationCenters
+	}
+	cumulative := make([]float64, len(points))
+	total := 0.0
+	for i, p := range points {
+		total += p.Weight
+		cumulative[i] = total
+	}
+
+	rng := rand.New(rand.NewSource(gen.Seed))
+	demands := make([]TrafficDemand, gen.Count)
+	for i := range demands {
+		var location visibility.Geodetic
+		if len(points) == 0 {
+			location.LatDeg = math.Asin(2*rng.Float64()-1) * 180 / math.Pi
+			location.LonDeg = rng.Float64()*360 - 180
+		} else {
+			k := sort.SearchFloat64s(cumulative, rng.Float64()*total)
+			for k < len(points)-1 && points[k].Weight == 0 {
+				k++
+			}
+			location = sampleCap(rng, points[k])
+		}
+		demands[i] = TrafficDemand{
+			ID:            fmt.Sprintf("generated-%d", i+1),
+			FromLocation:  &location,
+			ToID:          destinations[rng.Intn(len(destinations))],
+			BandwidthMbps: gen.BandwidthMbps,
+			Priority:      gen.Priority,
+			Class:         gen.Class,
+		}
+	}
+	return demands, nil
+}
+
+// sampleCap draws a location evenly over the cap of p.RadiusKm (or defaultDensityRadiusKm) around p.
+func sampleCap(rng *rand.Rand, p DensityPoint) visibility.Geodetic {
+	const degToRad = math.Pi / 180
+	radius := p.RadiusKm
+	if radius == 0 {
+		radius = defaultDensityRadiusKm
+	}
+	maxAngle := math.Min(radius/visibility.EarthRadius, math.Pi)
+	// Uniform in cos(distance) is uniform in area over the cap.
+	distance := math.Acos(1 - rng.Float64()*(1-math.Cos(maxAngle)))
+	bearing := rng.Float64() * 2 * math.Pi
+
+	lat, lon := p.LatDeg*degToRad, p.LonDeg*degToRad
+	sinLat := math.Sin(lat)*math.Cos(distance) + math.Cos(lat)*math.Sin(distance)*math.Cos(bearing)
+	outLat := math.Asin(math.Max(-1, math.Min(1, sinLat)))
+	outLon := lon + math.Atan2(math.Sin(bearing)*math.Sin(distance)*math.Cos(lat), math.Cos(distance)-math.Sin(lat)*sinLat)
+	outLon = math.Remainder(outLon, 2*math.Pi)
+	return visibility.Geodetic{LatDeg: outLat / degToRad, LonDeg: outLon / degToRad}
+}
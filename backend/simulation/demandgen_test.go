@@ -0,0 +1,148 @@
+package simulation
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// greatCircleKm is the surface distance between two geodetic points.
+func greatCircleKm(a, b visibility.Geodetic) float64 {
+	const degToRad = math.Pi / 180
+	cos := math.Sin(a.LatDeg*degToRad)*math.Sin(b.LatDeg*degToRad) +
+		math.Cos(a.LatDeg*degToRad)*math.Cos(b.LatDeg*degToRad)*math.Cos((a.LonDeg-b.LonDeg)*degToRad)
+	return visibility.EarthRadius * math.Acos(math.Max(-1, math.Min(1, cos)))
+}
+
+func TestGenerateDemandsIsSeeded(t *testing.T) {
+	gen := DemandGenerator{Count: 50, Seed: 7, BandwidthMbps: 5}
+	stations := []string{"b", "a"}
+	first, err := GenerateDemands(gen, stations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := GenerateDemands(gen, stations)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("the same seed should generate the same demands")
+	}
+	gen.Seed = 8
+	other, _ := GenerateDemands(gen, stations)
+	if reflect.DeepEqual(first, other) {
+		t.Fatal("a different seed should generate different demands")
+	}
+	if first[0].ID != "generated-1" || first[49].ID != "generated-50" || first[0].BandwidthMbps != 5 {
+		t.Fatalf("unexpected demand %+v", first[0])
+	}
+	for _, d := range first {
+		if d.FromLocation == nil || (d.ToID != "a" && d.ToID != "b") {
+			t.Fatalf("expected a located source sent to a station, got %+v", d)
+		}
+	}
+}
+
+func TestGenerateDemandsWeighting(t *testing.T) {
+	// Uniform sampling is even in area: half of the Earth lies within 30° of the equator.
+	uniform, err := GenerateDemands(DemandGenerator{Count: 4000, Seed: 1}, []string{"gw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tropics := 0
+	for _, d := range uniform {
+		if math.Abs(d.FromLocation.LatDeg) < 30 {
+			tropics++
+		}
+	}
+	if share := float64(tropics) / float64(len(uniform)); math.Abs(share-0.5) > 0.04 {
+		t.Fatalf("expected about half the sources within 30° of the equator, got %v", share)
+	}
+
+	density := DemandGenerator{Count: 500, Seed: 2, Weighting: WeightDensity, Density: []DensityPoint{
+		{LatDeg: 60, LonDeg: 179.9, Weight: 1, RadiusKm: 200},
+		{LatDeg: -10, LonDeg: 20, Weight: 0},
+	}}
+	clustered, err := GenerateDemands(density, []string{"gw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	center := visibility.Geodetic{LatDeg: 60, LonDeg: 179.9}
+	for _, d := range clustered {
+		if km := greatCircleKm(*d.FromLocation, center); km > 200+1e-6 || math.Abs(d.FromLocation.LonDeg) > 180 {
+			t.Fatalf("source %+v lies %v km from the only weighted point", d.FromLocation, km)
+		}
+	}
+
+	population, err := GenerateDemands(DemandGenerator{Count: 200, Seed: 3, Weighting: WeightPopulation}, []string{"gw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range population {
+		nearest := math.Inf(1)
+		for _, metro := range populationCenters {
+			nearest = math.Min(nearest, greatCircleKm(*d.FromLocation, visibility.Geodetic{LatDeg: metro.LatDeg, LonDeg: metro.LonDeg}))
+		}
+		if nearest > 80+1e-6 {
+			t.Fatalf("source %+v is %v km from every metro area", d.FromLocation, nearest)
+		}
+	}
+}
+
+func TestGenerateDemandsValidation(t *testing.T) {
+	for name, gen := range map[string]DemandGenerator{
+		"no demands":        {},
+		"unknown weighting": {Count: 1, Weighting: "census"},
+		"missing density":   {Count: 1, Weighting: WeightDensity},
+		"stray density":     {Count: 1, Density: []DensityPoint{{Weight: 1}}},
+		"zero weights":      {Count: 1, Weighting: WeightDensity, Density: []DensityPoint{{Weight: 0}}},
+		"unknown station":   {Count: 1, To: []string{"nowhere"}},
+	} {
+		if _, err := GenerateDemands(gen, []string{"gw"}); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGeneratedDemandsFeedBlocking(t *testing.T) {
+	auto := coverage.Footprint{Auto: true, LinkStrength: 1}
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 30, LonStep: 30},
+		Satellites: []Satellite{
+			{ID: "sat", Location: &visibility.Geodetic{AltKm: 1200}, Footprint: auto},
+		},
+		GroundStations:   []GroundStation{{ID: "gateway", Location: &visibility.Geodetic{LonDeg: 3}}},
+		LinkCapacityMbps: 30,
+		DemandGenerator: &DemandGenerator{Count: 20, Seed: 11, Weighting: WeightDensity, BandwidthMbps: 10,
+			Density: []DensityPoint{{Weight: 1, RadiusKm: 500}}},
+		Epoch: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := sim.Run(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The gateway downlink carries three 10 Mbps demands; the rest are blocked.
+	admitted := 0
+	for _, alloc := range summary.Snapshot.Allocations {
+		if alloc.Status == StatusAdmitted {
+			admitted++
+		}
+	}
+	if len(summary.Snapshot.Allocations) != 20 || admitted != 3 || summary.Blocking[0].Blocked == 0 {
+		t.Fatalf("expected 3 of 20 generated demands admitted, got %d of %d, blocking %+v",
+			admitted, len(summary.Snapshot.Allocations), summary.Blocking)
+	}
+	if got := sim.Scenario().Traffic; len(got) != 0 {
+		t.Fatalf("the scenario should keep only its generator, got %d demands", len(got))
+	}
+
+	cfg.Traffic = []TrafficDemand{{ID: "generated-1", FromID: "gateway", ToID: "gateway"}}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a configured demand using a generated ID to be rejected")
+	}
+}
@@ -0,0 +1,393 @@
+// Package des is a discrete-event packet simulator. It replays the demands of a simulation
+// snapshot as Poisson packet flows over their routes, through links with finite FIFO queues, and
+// reports measured delay and loss distributions in place of the analytic queueing approximations.
+package des
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+)
+
+const (
+	defaultQueuePackets = 64
+	defaultPacketBytes  = 1500
+	defaultMaxPackets   = 1_000_000
+)
+
+// ErrTooManyPackets is returned when the offered load over the duration would exceed Config.MaxPackets.
+var ErrTooManyPackets = errors.New("packet budget exceeded")
+
+// Flow is a stream of packets injected at the first node of Nodes and forwarded hop by hop.
+type Flow struct {
+	ID       string
+	Nodes    []string
+	RateMbps float64
+}
+
+// Config describes one discrete-event run. Zero values select a 64-packet queue per link,
+// 1500-byte packets, and a budget of one million packets.
+type Config struct {
+	Graph *routing.Graph
+	Flows []Flow
+	// LinkCapacityMbps is the capacity of every link direction whose edge does not set its own.
+	LinkCapacityMbps float64
+	QueuePackets     int
+	PacketBytes      int
+	Duration         time.Duration
+	Seed             int64
+	MaxPackets       int
+}
+
+// FlowStats summarizes the packets of one flow. Delays are end to end, covering queueing,
+// transmission, and propagation, with percentiles taken by nearest rank over delivered packets.
+type FlowStats struct {
+	Sent        int     `json:"sent"`
+	Delivered   int     `json:"delivered"`
+	Dropped     int     `json:"dropped"`
+	LossRate    float64 `json:"lossRate"`
+	MeanDelayMS float64 `json:"meanDelayMs"`
+	P50DelayMS  float64 `json:"p50DelayMs"`
+	P95DelayMS  float64 `json:"p95DelayMs"`
+	P99DelayMS  float64 `json:"p99DelayMs"`
+	MaxDelayMS  float64 `json:"maxDelayMs"`
+}
+
+// LinkStats reports the load seen by one link direction.
+type LinkStats struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Packets     int     `json:"packets"`
+	Dropped     int     `json:"dropped"`
+	MaxQueue    int     `json:"maxQueue"`
+	Utilization float64 `json:"utilization"`
+}
+
+// Result is the outcome of Run. Links are sorted by From, then To.
+type Result struct {
+	Flows    map[string]FlowStats `json:"flows"`
+	Links    []LinkStats          `json:"links"`
+	Events   int                  `json:"events"`
+	SimTimeS float64              `json:"simTimeS"`
+}
+
+// Validate reports configurations Run cannot execute.
+func (c Config) Validate() error {
+	switch {
+	case c.Graph == nil:
+		return errors.New("a topology graph is required")
+	case c.LinkCapacityMbps <= 0:
+		return errors.New("link capacity must be positive")
+	case c.Duration <= 0:
+		return errors.New("duration must be positive")
+	case c.QueuePackets < 0 || c.PacketBytes < 0 || c.MaxPackets < 0:
+		return errors.New("queue, packet size, and packet budget cannot be negative")
+	}
+	for _, flow := range c.Flows {
+		if flow.RateMbps < 0 {
+			return fmt.Errorf("flow %q rate cannot be negative", flow.ID)
+		}
+		for i := 0; i+1 < len(flow.Nodes); i++ {
+			if _, ok := findEdge(c.Graph, flow.Nodes[i], flow.Nodes[i+1]); !ok {
+				return fmt.Errorf("flow %q hop %s->%s is not a link", flow.ID, flow.Nodes[i], flow.Nodes[i+1])
+			}
+		}
+	}
+	return nil
+}
+
+// FromTopology builds a configuration replaying every routed demand of a simulator topology as a
+// flow at its allocated bandwidth, or its requested bandwidth when link capacity is not modeled.
+// Callers set Duration and, unless the scenario capacitates links, LinkCapacityMbps.
+func FromTopology(topology simulation.Topology) Config {
+	cfg := Config{Graph: topology.Graph, LinkCapacityMbps: topology.LinkCapacityMbps}
+	for _, demand := range topology.Traffic {
+		path, ok := topology.Snapshot.Routes[demand.ID]
+		if !ok {
+			continue
+		}
+		rate := demand.BandwidthMbps
+		if alloc, ok := topology.Snapshot.Allocations[demand.ID]; ok {
+			rate = alloc.AllocatedMbps
+		}
+		cfg.Flows = append(cfg.Flows, Flow{ID: demand.ID, Nodes: path.Nodes, RateMbps: rate})
+	}
+	return cfg
+}
+
+type linkKey struct{ from, to string }
+
+type link struct {
+	propagationS float64
+	txS          float64
+	busy         bool
+	busySince    float64
+	busyS        float64
+	queue        []*packet
+	stats        LinkStats
+}
+
+type packet struct {
+	flow    int
+	hop     int
+	created float64
+}
+
+type eventKind int
+
+const (
+	flowArrival eventKind = iota // next packet of a flow is generated
+	hopArrival                   // a packet reaches the node at its current hop
+	txDone                       // a link finishes transmitting its head packet
+)
+
+type event struct {
+	at   float64
+	seq  uint64
+	kind eventKind
+	flow int
+	pkt  *packet
+	link *link
+}
+
+type eventQueue []event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+type engine struct {
+	cfg      Config
+	rng      *rand.Rand
+	now      float64
+	seq      uint64
+	events   eventQueue
+	links    map[linkKey]*link
+	packetMb float64
+	sent     []int
+	dropped  []int
+	delays   [][]float64
+	interval []float64 // mean seconds between packets, per flow
+}
+
+// Run simulates the flows until every packet generated within the duration is delivered or dropped.
+// Runs with the same configuration and seed produce identical results.
+func Run(cfg Config) (Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return Result{}, err
+	}
+	if cfg.QueuePackets == 0 {
+		cfg.QueuePackets = defaultQueuePackets
+	}
+	if cfg.PacketBytes == 0 {
+		cfg.PacketBytes = defaultPacketBytes
+	}
+	if cfg.MaxPackets == 0 {
+		cfg.MaxPackets = defaultMaxPackets
+	}
+	packetMb := float64(cfg.PacketBytes) * 8 / 1e6
+
+	expected := 0.0
+	for _, flow := range cfg.Flows {
+		expected += flow.RateMbps / packetMb * cfg.Duration.Seconds()
+	}
+	if expected > float64(cfg.MaxPackets) {
+		return Result{}, fmt.Errorf("%w: about %.0f packets offered, limit %d", ErrTooManyPackets, expected, cfg.MaxPackets)
+	}
+
+	e := &engine{
+		cfg:      cfg,
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+		links:    make(map[linkKey]*link),
+		packetMb: packetMb,
+		sent:     make([]int, len(cfg.Flows)),
+		dropped:  make([]int, len(cfg.Flows)),
+		delays:   make([][]float64, len(cfg.Flows)),
+		interval: make([]float64, len(cfg.Flows)),
+	}
+	for i, flow := range cfg.Flows {
+		if flow.RateMbps == 0 || len(flow.Nodes) == 0 {
+			continue
+		}
+		e.interval[i] = packetMb / flow.RateMbps
+		e.scheduleArrival(i, 0)
+	}
+
+	processed := 0
+	for e.events.Len() > 0 {
+		evt := heap.Pop(&e.events).(event)
+		e.now = evt.at
+		processed++
+		switch evt.kind {
+		case flowArrival:
+			e.generate(evt.flow)
+		case hopArrival:
+			e.arrive(evt.pkt)
+		case txDone:
+			e.finish(evt.link, evt.pkt)
+		}
+	}
+	return e.result(processed), nil
+}
+
+func (e *engine) schedule(evt event) {
+	e.seq++
+	evt.seq = e.seq
+	heap.Push(&e.events, evt)
+}
+
+func (e *engine) generate(flow int) {
+	e.sent[flow]++
+	e.arrive(&packet{flow: flow, created: e.now})
+	e.scheduleArrival(flow, e.now)
+}
+
+// scheduleArrival draws the flow's next Poisson arrival after from, if it falls within the duration.
+func (e *engine) scheduleArrival(flow int, from float64) {
+	next := from + e.rng.ExpFloat64()*e.interval[flow]
+	if next < e.cfg.Duration.Seconds() {
+		e.schedule(event{at: next, kind: flowArrival, flow: flow})
+	}
+}
+
+// arrive handles a packet at the node of its current hop: delivery at the last node, otherwise
+// transmission on the outgoing link or a place in its queue.
+func (e *engine) arrive(pkt *packet) {
+	nodes := e.cfg.Flows[pkt.flow].Nodes
+	if pkt.hop == len(nodes)-1 {
+		e.delays[pkt.flow] = append(e.delays[pkt.flow], (e.now-pkt.created)*1000)
+		return
+	}
+	l := e.link(nodes[pkt.hop], nodes[pkt.hop+1])
+	l.stats.Packets++
+	if !l.busy {
+		e.transmit(l, pkt)
+		return
+	}
+	if len(l.queue) >= e.cfg.QueuePackets {
+		l.stats.Dropped++
+		e.dropped[pkt.flow]++
+		return
+	}
+	l.queue = append(l.queue, pkt)
+	if len(l.queue) > l.stats.MaxQueue {
+		l.stats.MaxQueue = len(l.queue)
+	}
+}
+
+func (e *engine) transmit(l *link, pkt *packet) {
+	if !l.busy {
+		l.busy, l.busySince = true, e.now
+	}
+	e.schedule(event{at: e.now + l.txS, kind: txDone, pkt: pkt, link: l})
+}
+
+// finish forwards the packet whose transmission completed and starts on the next queued one.
+func (e *engine) finish(l *link, pkt *packet) {
+	pkt.hop++
+	e.schedule(event{at: e.now + l.propagationS, kind: hopArrival, pkt: pkt})
+	if len(l.queue) == 0 {
+		l.busy = false
+		l.busyS += e.now - l.busySince
+		return
+	}
+	next := l.queue[0]
+	l.queue = l.queue[1:]
+	e.transmit(l, next)
+}
+
+func (e *engine) link(from, to string) *link {
+	key := linkKey{from, to}
+	l, ok := e.links[key]
+	if !ok {
+		edge, _ := findEdge(e.cfg.Graph, from, to)
+		capacity := e.cfg.LinkCapacityMbps
+		if edge.CapacityMbps > 0 {
+			capacity = edge.CapacityMbps
+		}
+		l = &link{propagationS: edge.LatencyMS / 1000, txS: e.packetMb / capacity, stats: LinkStats{From: from, To: to}}
+		e.links[key] = l
+	}
+	return l
+}
+
+func (e *engine) result(processed int) Result {
+	res := Result{Flows: make(map[string]FlowStats, len(e.cfg.Flows)), Events: processed, SimTimeS: e.now}
+	for i, flow := range e.cfg.Flows {
+		stats := summarizeDelays(e.delays[i])
+		stats.Sent, stats.Dropped = e.sent[i], e.dropped[i]
+		if stats.Sent > 0 {
+			stats.LossRate = float64(stats.Dropped) / float64(stats.Sent)
+		}
+		res.Flows[flow.ID] = stats
+	}
+	for _, l := range e.links {
+		if e.now > 0 {
+			l.stats.Utilization = l.busyS / e.now
+		}
+		res.Links = append(res.Links, l.stats)
+	}
+	sort.Slice(res.Links, func(i, j int) bool {
+		if res.Links[i].From != res.Links[j].From {
+			return res.Links[i].From < res.Links[j].From
+		}
+		return res.Links[i].To < res.Links[j].To
+	})
+	return res
+}
+
+func summarizeDelays(delays []float64) FlowStats {
+	stats := FlowStats{Delivered: len(delays)}
+	if len(delays) == 0 {
+		return stats
+	}
+	sorted := append([]float64(nil), delays...)
+	sort.Float64s(sorted)
+	total := 0.0
+	for _, d := range sorted {
+		total += d
+	}
+	rank := func(p float64) float64 {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return sorted[idx]
+	}
+	stats.MeanDelayMS = total / float64(len(sorted))
+	stats.P50DelayMS, stats.P95DelayMS, stats.P99DelayMS = rank(0.50), rank(0.95), rank(0.99)
+	stats.MaxDelayMS = sorted[len(sorted)-1]
+	return stats
+}
+
+func findEdge(g *routing.Graph, from, to string) (routing.Edge, bool) {
+	var found routing.Edge
+	ok := false
+	g.EdgesFrom(from, func(e routing.Edge) bool {
+		if e.To == to {
+			found, ok = e, true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
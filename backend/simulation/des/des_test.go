@@ -0,0 +1,189 @@
+package des
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/simulation"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// lineGraph is a -> b -> c with 5 ms and 10 ms of propagation.
+func lineGraph(t *testing.T) *routing.Graph {
+	t.Helper()
+	nodes := []routing.Node{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	g, err := routing.NewGraph(nodes, []routing.Edge{
+		{From: "a", To: "b", LatencyMS: 5},
+		{From: "b", To: "c", LatencyMS: 10},
+	})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	return g
+}
+
+func TestLightLoadDelayIsPropagationPlusTransmission(t *testing.T) {
+	res, err := Run(Config{
+		Graph:            lineGraph(t),
+		Flows:            []Flow{{ID: "f", Nodes: []string{"a", "b", "c"}, RateMbps: 0.1}},
+		LinkCapacityMbps: 100,
+		Duration:         10 * time.Second,
+		Seed:             1,
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	stats := res.Flows["f"]
+	if stats.Sent == 0 || stats.Dropped != 0 || stats.Delivered != stats.Sent {
+		t.Fatalf("expected lossless delivery, got %+v", stats)
+	}
+	// Two hops of 0.12 ms transmission plus 15 ms propagation; queueing is negligible.
+	if want := 15.24; math.Abs(stats.P50DelayMS-want) > 0.01 {
+		t.Fatalf("median delay %.3f ms, want about %.2f", stats.P50DelayMS, want)
+	}
+}
+
+func TestEdgesCarryTheirOwnCapacity(t *testing.T) {
+	g := lineGraph(t)
+	g.Adj["b"][0].CapacityMbps = 10
+	res, err := Run(Config{
+		Graph:            g,
+		Flows:            []Flow{{ID: "f", Nodes: []string{"a", "b", "c"}, RateMbps: 0.1}},
+		LinkCapacityMbps: 100,
+		Duration:         10 * time.Second,
+		Seed:             1,
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	// 0.12 ms to transmit on a->b and 1.2 ms on the narrower b->c, plus 15 ms propagation.
+	if want := 16.32; math.Abs(res.Flows["f"].P50DelayMS-want) > 0.01 {
+		t.Fatalf("median delay %.3f ms, want about %.2f", res.Flows["f"].P50DelayMS, want)
+	}
+}
+
+func TestQueueingMatchesMD1(t *testing.T) {
+	// Poisson arrivals into a fixed-service-time link form an M/D/1 queue, whose mean wait is
+	// ρ / (2μ(1-ρ)). At half load on a 12 Mbps link μ is 1000 packets/s, so the wait is 0.5 ms.
+	g, err := routing.NewGraph([]routing.Node{{ID: "a"}, {ID: "b"}}, []routing.Edge{{From: "a", To: "b"}})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	res, err := Run(Config{
+		Graph:            g,
+		Flows:            []Flow{{ID: "f", Nodes: []string{"a", "b"}, RateMbps: 6}},
+		LinkCapacityMbps: 12,
+		QueuePackets:     1000,
+		Duration:         200 * time.Second,
+		Seed:             7,
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	wait := res.Flows["f"].MeanDelayMS - 1 // minus the 1 ms transmission time
+	if math.Abs(wait-0.5) > 0.05 {
+		t.Fatalf("mean queueing delay %.3f ms, want about 0.5", wait)
+	}
+	if u := res.Links[0].Utilization; math.Abs(u-0.5) > 0.02 {
+		t.Fatalf("utilization %.3f, want about 0.5", u)
+	}
+}
+
+func TestOverloadDropsAtFiniteQueue(t *testing.T) {
+	res, err := Run(Config{
+		Graph:            lineGraph(t),
+		Flows:            []Flow{{ID: "f", Nodes: []string{"a", "b", "c"}, RateMbps: 24}},
+		LinkCapacityMbps: 12,
+		QueuePackets:     16,
+		Duration:         5 * time.Second,
+		Seed:             3,
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	stats := res.Flows["f"]
+	if stats.LossRate < 0.4 || stats.LossRate > 0.6 {
+		t.Fatalf("expected about half the packets lost at twice capacity, got %+v", stats)
+	}
+	if stats.Sent != stats.Delivered+stats.Dropped {
+		t.Fatalf("packets unaccounted for: %+v", stats)
+	}
+	first := res.Links[0]
+	if first.From != "a" || first.MaxQueue != 16 || first.Dropped != stats.Dropped {
+		t.Fatalf("expected the first hop to fill its queue and drop every lost packet: %+v", first)
+	}
+}
+
+func TestRunIsDeterministicPerSeed(t *testing.T) {
+	cfg := Config{
+		Graph:            lineGraph(t),
+		Flows:            []Flow{{ID: "x", Nodes: []string{"a", "b", "c"}, RateMbps: 8}, {ID: "y", Nodes: []string{"b", "c"}, RateMbps: 5}},
+		LinkCapacityMbps: 12,
+		Duration:         2 * time.Second,
+		Seed:             42,
+	}
+	first, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	second, _ := Run(cfg)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced different results")
+	}
+	cfg.Seed = 43
+	if third, _ := Run(cfg); reflect.DeepEqual(first, third) {
+		t.Fatalf("different seeds produced identical results")
+	}
+}
+
+func TestValidation(t *testing.T) {
+	g := lineGraph(t)
+	if _, err := Run(Config{Graph: g, Flows: []Flow{{ID: "f", Nodes: []string{"a", "c"}, RateMbps: 1}}, LinkCapacityMbps: 10, Duration: time.Second}); err == nil {
+		t.Fatalf("expected a hop without a link to be rejected")
+	}
+	if _, err := Run(Config{Graph: g, LinkCapacityMbps: 0, Duration: time.Second}); err == nil {
+		t.Fatalf("expected missing capacity to be rejected")
+	}
+	_, err := Run(Config{Graph: g, Flows: []Flow{{ID: "f", Nodes: []string{"a", "b"}, RateMbps: 1000}}, LinkCapacityMbps: 10, Duration: time.Hour})
+	if !errors.Is(err, ErrTooManyPackets) {
+		t.Fatalf("expected ErrTooManyPackets, got %v", err)
+	}
+}
+
+func TestFromTopologyReplaysRoutedDemands(t *testing.T) {
+	sim, err := simulation.NewSimulator(simulation.Config{
+		GridConfig:       coverage.GridConfig{LatStep: 180, LonStep: 360},
+		LinkCapacityMbps: 100,
+		Satellites: []simulation.Satellite{
+			{ID: "relay", Position: visibility.Vector3{X: visibility.EarthRadius + 500}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []simulation.GroundStation{
+			{ID: "ground-a", Position: visibility.Vector3{X: visibility.EarthRadius}},
+			{ID: "ground-b", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 20}},
+		},
+		Traffic: []simulation.TrafficDemand{
+			{ID: "kept", FromID: "ground-a", ToID: "ground-b", BandwidthMbps: 60},
+			{ID: "blocked", FromID: "ground-a", ToID: "ground-b", BandwidthMbps: 60},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	cfg := FromTopology(sim.Topology())
+	if len(cfg.Flows) != 1 || cfg.Flows[0].ID != "kept" || cfg.Flows[0].RateMbps != 60 || cfg.LinkCapacityMbps != 100 {
+		t.Fatalf("unexpected flows %+v at %v Mbps", cfg.Flows, cfg.LinkCapacityMbps)
+	}
+	cfg.Duration = time.Second
+	res, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if res.Flows["kept"].Delivered == 0 {
+		t.Fatalf("expected packets delivered over the routed path: %+v", res.Flows["kept"])
+	}
+}
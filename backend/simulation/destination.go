@@ -0,0 +1,54 @@
+package simulation
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// errNoPrefixRoute reports a demand whose source has no advertised route covering its address.
+var errNoPrefixRoute = fmt.Errorf("%w: no advertised route to address", routing.ErrNoRoute)
+
+func validateDestination(demand TrafficDemand, bgpEnabled bool) error {
+	if demand.ToAddress == "" {
+		return nil
+	}
+	if demand.ToID != "" {
+		return fmt.Errorf("demand %q sets both to and toAddress", demand.ID)
+	}
+	if !bgpEnabled {
+		return fmt.Errorf("demand %q routes to an address but the scenario has no bgp section", demand.ID)
+	}
+	if _, err := netip.ParseAddr(demand.ToAddress); err != nil {
+		return fmt.Errorf("demand %q: %w", demand.ID, err)
+	}
+	return nil
+}
+
+// findPathLocked routes a demand over graph using only edges accepted by usable (nil accepts all).
+// Node destinations are routed by the demand's policy; address destinations follow the source's
+// best advertised route and fail if any hop of it is unusable.
+func (s *Simulator) findPathLocked(graph *routing.Graph, demand TrafficDemand, usable func(routing.Edge) bool) (routing.Path, error) {
+	if s.trace != nil {
+		defer s.trace.route(demand.ID, time.Now())
+	}
+	if demand.ToAddress == "" {
+		return s.policyPathLocked(graph, demand, usable)
+	}
+
+	addr, _ := netip.ParseAddr(demand.ToAddress) // validated at construction
+	route, ok := s.bgp.Lookup(demand.FromID, addr)
+	if !ok {
+		return routing.Path{}, errNoPrefixRoute
+	}
+	path, err := graph.PathAlong(route.Path)
+	if err != nil {
+		return routing.Path{}, err
+	}
+	if !pathUsable(graph, path, usable) {
+		return routing.Path{}, fmt.Errorf("%w: advertised route lacks capacity", routing.ErrNoRoute)
+	}
+	return path, nil
+}
@@ -0,0 +1,99 @@
+package simulation
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/example/satnet/backend/bgp"
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func prefixConfig() Config {
+	return Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "primary", Position: visibility.Vector3{X: visibility.EarthRadius + 300}, Footprint: coverage.Footprint{RadiusKm: 1200, LinkStrength: 1}},
+			{ID: "backup", Position: visibility.Vector3{X: visibility.EarthRadius + 900, Y: 200}, Footprint: coverage.Footprint{RadiusKm: 400, LinkStrength: 0.5}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "user", Position: visibility.Vector3{X: visibility.EarthRadius}},
+			{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 20}},
+		},
+		BGP:     &bgp.Config{Advertisements: []bgp.Advertisement{{Gateway: "gateway", Prefix: netip.MustParsePrefix("198.51.100.0/24")}}},
+		Traffic: []TrafficDemand{{ID: "web", FromID: "user", ToAddress: "198.51.100.7"}},
+	}
+}
+
+func TestAddressDemandsFollowAdvertisedRoutes(t *testing.T) {
+	sim, err := NewSimulator(prefixConfig())
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	snapshot := sim.Snapshot()
+	path, ok := snapshot.Routes["web"]
+	if !ok || path.Nodes[0] != "user" || path.Nodes[len(path.Nodes)-1] != "gateway" {
+		t.Fatalf("expected a route from user to the advertising gateway, got %+v", path)
+	}
+	if snapshot.BGP == nil || snapshot.BGP.Routes == 0 || snapshot.BGP.TimeMS <= 0 {
+		t.Fatalf("expected convergence to be reported, got %+v", snapshot.BGP)
+	}
+
+	updated, err := sim.DisableSatellite(path.Nodes[1])
+	if err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	rerouted, ok := updated.Routes["web"]
+	if !ok || contains(rerouted.Nodes, path.Nodes[1]) {
+		t.Fatalf("expected the advertisement to reconverge around the disabled relay, got %+v", rerouted)
+	}
+	if updated.BGP.Withdrawals == 0 {
+		t.Fatalf("expected withdrawals after the relay failed, got %+v", updated.BGP)
+	}
+}
+
+func TestUnadvertisedAddressIsUnrouted(t *testing.T) {
+	cfg := prefixConfig()
+	cfg.Traffic[0].ToAddress = "192.0.2.1"
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if _, ok := sim.Snapshot().Routes["web"]; ok {
+		t.Fatalf("expected no route to an unadvertised address")
+	}
+}
+
+func TestAddressDestinationValidation(t *testing.T) {
+	cfg := prefixConfig()
+	cfg.Traffic[0].ToID = "gateway"
+	if _, err := NewSimulator(cfg); err == nil || !strings.Contains(err.Error(), "both") {
+		t.Fatalf("expected to and toAddress together to be rejected, got %v", err)
+	}
+
+	cfg = prefixConfig()
+	cfg.BGP = nil
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected an address demand without bgp to be rejected")
+	}
+
+	cfg = prefixConfig()
+	cfg.Traffic[0].ToAddress = "not-an-ip"
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected a malformed address to be rejected")
+	}
+}
+
+func TestBGPConfigDecodesFromScenarioJSON(t *testing.T) {
+	var cfg Config
+	raw := `{"bgp": {"advertisements": [{"gateway": "gw", "prefix": "10.0.0.0/8", "localPref": 50}], "mraiMs": 1000}}`
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	ad := cfg.BGP.Advertisements[0]
+	if ad.Prefix != netip.MustParsePrefix("10.0.0.0/8") || ad.LocalPref != 50 || cfg.BGP.MRAIMS != 1000 {
+		t.Fatalf("unexpected decoded config %+v", cfg.BGP)
+	}
+}
@@ -0,0 +1,97 @@
+package simulation
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Divergence lists the differences between two runs' snapshots after the same step. Changes use
+// the paths of ScenarioChange, applied to the JSON form of Snapshot.
+type Divergence struct {
+	// Step is the number of steps taken; 0 is the initial recompute.
+	Step    int              `json:"step"`
+	SimTime time.Time        `json:"simTime"`
+	Changes []ScenarioChange `json:"changes"`
+}
+
+// DeterminismReport is the result of VerifyDeterminism.
+type DeterminismReport struct {
+	Steps       int          `json:"steps"`
+	Snapshots   int          `json:"snapshots"`
+	Divergences []Divergence `json:"divergences,omitempty"`
+}
+
+// Deterministic reports whether every snapshot of the two runs matched.
+func (r DeterminismReport) Deterministic() bool {
+	return len(r.Divergences) == 0
+}
+
+// VerifyDeterminism builds two simulators from cfg, steps both steps times by dt, and diffs their
+// snapshots after the initial recompute and every step. Only the wall-clock Timestamp is ignored.
+// A scenario without an epoch gets one shared by both runs. Scenarios with a routing budget are
+// refused: the budget is wall time, so the routes it leaves stale legitimately differ.
+func VerifyDeterminism(cfg Config, steps int, dt time.Duration) (DeterminismReport, error) {
+	if steps < 0 {
+		return DeterminismReport{}, errors.New("steps cannot be negative")
+	}
+	if cfg.RoutingBudgetMS > 0 {
+		return DeterminismReport{}, errors.New("routing budgets depend on wall time and cannot be verified for determinism")
+	}
+	if cfg.Epoch.IsZero() {
+		cfg.Epoch = time.Now().UTC()
+	}
+	first, err := NewSimulator(cfg)
+	if err != nil {
+		return DeterminismReport{}, err
+	}
+	second, err := NewSimulator(cfg)
+	if err != nil {
+		return DeterminismReport{}, err
+	}
+
+	report := DeterminismReport{Steps: steps}
+	compare := func(step int, a, b Snapshot) error {
+		report.Snapshots++
+		changes, err := diffSnapshots(a, b)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			report.Divergences = append(report.Divergences, Divergence{Step: step, SimTime: a.SimTime, Changes: changes})
+		}
+		return nil
+	}
+	if err := compare(0, first.Snapshot(), second.Snapshot()); err != nil {
+		return DeterminismReport{}, err
+	}
+	for step := 1; step <= steps; step++ {
+		a, err := first.Step(dt)
+		if err != nil {
+			return DeterminismReport{}, err
+		}
+		b, err := second.Step(dt)
+		if err != nil {
+			return DeterminismReport{}, err
+		}
+		if err := compare(step, a, b); err != nil {
+			return DeterminismReport{}, err
+		}
+	}
+	return report, nil
+}
+
+func diffSnapshots(a, b Snapshot) ([]ScenarioChange, error) {
+	a.Timestamp, b.Timestamp = time.Time{}, time.Time{}
+	from, err := genericJSON(a)
+	if err != nil {
+		return nil, err
+	}
+	to, err := genericJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	changes := diffJSON("", from, to, nil)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
@@ -0,0 +1,57 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyDeterminismOnPreset(t *testing.T) {
+	cfg, err := PresetScenario("hybrid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.LinkCapacityMbps = 100
+	for i := range cfg.Traffic {
+		cfg.Traffic[i].BandwidthMbps = 40
+	}
+	report, err := VerifyDeterminism(cfg, 5, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Snapshots != 6 {
+		t.Fatalf("expected the initial recompute and 5 steps compared, got %d", report.Snapshots)
+	}
+	if !report.Deterministic() {
+		t.Fatalf("expected identical runs, got %+v", report.Divergences[0])
+	}
+}
+
+func TestVerifyDeterminismOnRandomScenarios(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		report, err := VerifyDeterminism(scenarioFromSeed(seed), 4, 2*time.Minute)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !report.Deterministic() {
+			t.Fatalf("seed %d diverged: %+v", seed, report.Divergences[0])
+		}
+	}
+}
+
+func TestVerifyDeterminismReportsDivergence(t *testing.T) {
+	a := Snapshot{Version: 1, Timestamp: time.Now(), ActiveSatellites: []string{"a", "b"}}
+	b := a
+	b.Timestamp = a.Timestamp.Add(time.Second)
+	if changes, err := diffSnapshots(a, b); err != nil || len(changes) != 0 {
+		t.Fatalf("wall-clock timestamps should be ignored, got %v (%v)", changes, err)
+	}
+	b.ActiveSatellites = []string{"a", "c"}
+	changes, err := diffSnapshots(a, b)
+	if err != nil || len(changes) != 1 || changes[0].Path != "activeSatellites[1]" {
+		t.Fatalf("expected one changed satellite, got %+v (%v)", changes, err)
+	}
+
+	if _, err := VerifyDeterminism(Config{RoutingBudgetMS: 5}, 1, time.Second); err == nil {
+		t.Fatal("routing budgets should be refused")
+	}
+}
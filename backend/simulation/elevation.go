@@ -0,0 +1,36 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+)
+
+// maskAgreementRad is how far the legacy radian mask and the degree mask may differ and still be
+// read as the same setting.
+const maskAgreementRad = 1e-9
+
+// elevationMaskRadians resolves an elevation mask given in degrees, the preferred form, or in
+// radians, the legacy form kept so existing scenario files still load. A mask set both ways must
+// agree. A radian mask above π/2 is refused as the likely result of writing degrees into the
+// radian field. subject names the setting in errors.
+func elevationMaskRadians(subject string, deg, rad float64) (float64, error) {
+	if math.IsNaN(deg) || deg < 0 || deg > 90 {
+		return 0, fmt.Errorf("%s elevationMaskDeg %v must be between 0 and 90", subject, deg)
+	}
+	if math.IsNaN(rad) || rad < 0 {
+		return 0, fmt.Errorf("%s elevationMask %v cannot be negative", subject, rad)
+	}
+	if rad > math.Pi/2 {
+		return 0, fmt.Errorf("%s elevationMask %v exceeds π/2 radians; set elevationMaskDeg to give the mask in degrees", subject, rad)
+	}
+	fromDeg := deg * math.Pi / 180
+	switch {
+	case deg == 0:
+		return rad, nil
+	case rad == 0:
+		return fromDeg, nil
+	case math.Abs(fromDeg-rad) > maskAgreementRad:
+		return 0, fmt.Errorf("%s sets elevationMaskDeg %v and elevationMask %v radians, which disagree; keep only elevationMaskDeg", subject, deg, rad)
+	}
+	return fromDeg, nil
+}
@@ -0,0 +1,188 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// BackpressurePolicy decides what happens when a subscriber's buffer is full.
+type BackpressurePolicy string
+
+const (
+	// DropNewest discards the event being published; queued events are kept.
+	DropNewest BackpressurePolicy = "drop-newest"
+	// DropOldest discards the oldest queued event to make room for the new one.
+	DropOldest BackpressurePolicy = "drop-oldest"
+	// BlockWithTimeout waits up to SubscribeOptions.Timeout for room, stalling the simulator,
+	// and drops the event if none frees up.
+	BlockWithTimeout BackpressurePolicy = "block"
+	// CoalesceLatest, when the buffer is full, discards everything queued so the subscriber's next
+	// read is the latest snapshot.
+	CoalesceLatest BackpressurePolicy = "coalesce"
+)
+
+const (
+	defaultEventBuffer  = 8
+	defaultBlockTimeout = 100 * time.Millisecond
+)
+
+// SubscribeOptions configure a Subscription. Zero values select an 8-event buffer, DropNewest,
+// and a 100ms timeout for BlockWithTimeout.
+type SubscribeOptions struct {
+	Name    string
+	Buffer  int
+	Policy  BackpressurePolicy
+	Timeout time.Duration
+}
+
+// Validate reports unknown policies and negative sizes.
+func (o SubscribeOptions) Validate() error {
+	switch o.Policy {
+	case "", DropNewest, DropOldest, BlockWithTimeout, CoalesceLatest:
+	default:
+		return fmt.Errorf("unknown backpressure policy %q", o.Policy)
+	}
+	if o.Buffer < 0 || o.Timeout < 0 {
+		return errors.New("buffer and timeout cannot be negative")
+	}
+	return nil
+}
+
+// Subscription receives simulator events according to its backpressure policy.
+type Subscription struct {
+	opts      SubscribeOptions
+	ch        chan Event
+	sim       *Simulator
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// SubscriberStats counts a subscription's deliveries and drops. Delivered counts events that were
+// queued; events later evicted by DropOldest or CoalesceLatest are also counted in Dropped.
+type SubscriberStats struct {
+	Name      string             `json:"name"`
+	Policy    BackpressurePolicy `json:"policy"`
+	Buffer    int                `json:"buffer"`
+	Queued    int                `json:"queued"`
+	Delivered uint64             `json:"delivered"`
+	Dropped   uint64             `json:"dropped"`
+}
+
+// Subscribe registers a new event subscriber. Call Close when done so publishing stops
+// accounting for it.
+func (s *Simulator) Subscribe(opts SubscribeOptions) (*Subscription, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribeLocked(opts), nil
+}
+
+func (s *Simulator) subscribeLocked(opts SubscribeOptions) *Subscription {
+	if opts.Buffer == 0 {
+		opts.Buffer = defaultEventBuffer
+	}
+	if opts.Policy == "" {
+		opts.Policy = DropNewest
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultBlockTimeout
+	}
+	sub := &Subscription{opts: opts, ch: make(chan Event, opts.Buffer), sim: s}
+	s.subscribers = append(s.subscribers, sub)
+	return sub
+}
+
+// Events returns the channel events are delivered on. It is closed by Close.
+func (sub *Subscription) Events() <-chan Event {
+	return sub.ch
+}
+
+// Close unsubscribes and closes the event channel. It is safe to call more than once.
+func (sub *Subscription) Close() {
+	s := sub.sim
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, candidate := range s.subscribers {
+		if candidate == sub {
+			s.subscribers = append(s.subscribers[:i], s.subscribers[i+1:]...)
+			close(sub.ch)
+			return
+		}
+	}
+}
+
+// Stats reports the subscription's counters.
+func (sub *Subscription) Stats() SubscriberStats {
+	return SubscriberStats{
+		Name:      sub.opts.Name,
+		Policy:    sub.opts.Policy,
+		Buffer:    sub.opts.Buffer,
+		Queued:    len(sub.ch),
+		Delivered: sub.delivered.Load(),
+		Dropped:   sub.dropped.Load(),
+	}
+}
+
+// EventStats lists the counters of every live subscription, including the default one behind Events.
+func (s *Simulator) EventStats() []SubscriberStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]SubscriberStats, 0, len(s.subscribers))
+	for _, sub := range s.subscribers {
+		out = append(out, sub.Stats())
+	}
+	return out
+}
+
+// deliver applies the subscription's policy. Publishing happens under the simulator lock, so
+// deliver is never called concurrently for one subscription and never races with Close.
+func (sub *Subscription) deliver(evt Event) {
+	select {
+	case sub.ch <- evt:
+		sub.delivered.Add(1)
+		return
+	default:
+	}
+
+	switch sub.opts.Policy {
+	case DropOldest, CoalesceLatest:
+		sub.evict(sub.opts.Policy == CoalesceLatest)
+		select {
+		case sub.ch <- evt:
+			sub.delivered.Add(1)
+		default:
+			sub.dropped.Add(1)
+		}
+	case BlockWithTimeout:
+		timer := time.NewTimer(sub.opts.Timeout)
+		defer timer.Stop()
+		select {
+		case sub.ch <- evt:
+			sub.delivered.Add(1)
+		case <-timer.C:
+			sub.dropped.Add(1)
+		}
+	default:
+		sub.dropped.Add(1)
+	}
+}
+
+// evict discards the oldest queued event, or every queued event when all is set. The consumer
+// may be draining concurrently, so an empty buffer simply ends the eviction.
+func (sub *Subscription) evict(all bool) {
+	for {
+		select {
+		case <-sub.ch:
+			sub.dropped.Add(1)
+			if !all {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+)
+
+func publishN(sim *Simulator, n int) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	for i := 0; i < n; i++ {
+		sim.version++
+		sim.publishEvent(EventCoverageUpdated, Snapshot{Version: sim.version})
+	}
+}
+
+func drain(sub *Subscription) []uint64 {
+	var versions []uint64
+	for {
+		select {
+		case evt := <-sub.Events():
+			versions = append(versions, evt.Snapshot.Version)
+		default:
+			return versions
+		}
+	}
+}
+
+func TestBackpressurePolicies(t *testing.T) {
+	cases := []struct {
+		policy      BackpressurePolicy
+		wantFirst   uint64
+		wantLast    uint64
+		wantQueued  int
+		wantDropped uint64
+	}{
+		{DropNewest, 1, 3, 3, 2},
+		{DropOldest, 3, 5, 3, 2},
+		{CoalesceLatest, 4, 5, 2, 3},
+		{BlockWithTimeout, 1, 3, 3, 2},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			sim := NewDemoSimulator()
+			sub, err := sim.Subscribe(SubscribeOptions{Name: "test", Buffer: 3, Policy: tc.policy, Timeout: time.Millisecond})
+			if err != nil {
+				t.Fatalf("subscribe failed: %v", err)
+			}
+			defer sub.Close()
+			base := sim.Snapshot().Version
+
+			publishN(sim, 5)
+			got := drain(sub)
+			if len(got) != tc.wantQueued || got[0]-base != tc.wantFirst || got[len(got)-1]-base != tc.wantLast {
+				t.Fatalf("unexpected queued versions (relative to %d): %v", base, got)
+			}
+			if dropped := sub.Stats().Dropped; dropped != tc.wantDropped {
+				t.Fatalf("expected %d dropped events, got %d", tc.wantDropped, dropped)
+			}
+		})
+	}
+}
+
+func TestBlockWithTimeoutWaitsForConsumer(t *testing.T) {
+	sim := NewDemoSimulator()
+	sub, _ := sim.Subscribe(SubscribeOptions{Buffer: 1, Policy: BlockWithTimeout, Timeout: time.Second})
+	defer sub.Close()
+
+	done := make(chan []uint64)
+	go func() {
+		var got []uint64
+		for len(got) < 3 {
+			got = append(got, (<-sub.Events()).Snapshot.Version)
+		}
+		done <- got
+	}()
+	publishN(sim, 3)
+	if got := <-done; len(got) != 3 || sub.Stats().Dropped != 0 {
+		t.Fatalf("expected every event delivered, got %v with %d dropped", got, sub.Stats().Dropped)
+	}
+}
+
+func TestSubscriptionCloseStopsDelivery(t *testing.T) {
+	sim := NewDemoSimulator()
+	sub, _ := sim.Subscribe(SubscribeOptions{Name: "ui"})
+	if len(sim.EventStats()) != 2 {
+		t.Fatalf("expected default and ui subscriptions, got %+v", sim.EventStats())
+	}
+	sub.Close()
+	sub.Close()
+	if _, open := <-sub.Events(); open {
+		t.Fatalf("expected the channel to be closed")
+	}
+	publishN(sim, 1)
+	if stats := sim.EventStats(); len(stats) != 1 || stats[0].Name != "default" {
+		t.Fatalf("expected only the default subscription, got %+v", stats)
+	}
+
+	if _, err := sim.Subscribe(SubscribeOptions{Policy: "bogus"}); err == nil {
+		t.Fatalf("expected unknown policy to be rejected")
+	}
+}
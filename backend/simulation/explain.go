@@ -0,0 +1,337 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// ErrUnknownDemand is returned when explaining a demand the scenario does not define.
+var ErrUnknownDemand = errors.New("unknown demand")
+
+// CandidateVerdict says why a hop did or did not continue over a candidate link.
+type CandidateVerdict string
+
+const (
+	// CandidateChosen is the link the route takes.
+	CandidateChosen CandidateVerdict = "chosen"
+	// CandidateHigherCost is a usable link whose cheapest onward route costs more than the chosen one.
+	CandidateHigherCost CandidateVerdict = "higher_cost"
+	// CandidateOverCapacity is a link without spare capacity for the demand's bandwidth.
+	CandidateOverCapacity CandidateVerdict = "over_capacity"
+	// CandidateExcluded is a link ruled out by configuration: a disabled satellite or shell, a
+	// demand pinned to its advertised BGP route, or a route kept for continuity or budget reasons.
+	CandidateExcluded CandidateVerdict = "excluded_by_policy"
+	// CandidateNoOnwardRoute is a usable link to a node from which the destination is unreachable.
+	CandidateNoOnwardRoute CandidateVerdict = "no_onward_route"
+	// CandidateRevisits is a link back to a node the route already passed through.
+	CandidateRevisits CandidateVerdict = "revisits_path"
+	// CandidateNotVisible is a node the hop has no line of sight to.
+	CandidateNotVisible CandidateVerdict = "not_visible"
+)
+
+// maxHiddenCandidates bounds how many of the nearest out-of-sight nodes each hop lists.
+const maxHiddenCandidates = 5
+
+// verdictOrder ranks verdicts for listing, from the chosen link to the least relevant.
+var verdictOrder = map[CandidateVerdict]int{
+	CandidateChosen: 0, CandidateHigherCost: 1, CandidateOverCapacity: 2, CandidateExcluded: 3,
+	CandidateNoOnwardRoute: 4, CandidateRevisits: 5, CandidateNotVisible: 6,
+}
+
+// RouteExplanation describes how the latest recompute routed one demand: for each hop, the links
+// it could have taken and why each one was or was not used.
+type RouteExplanation struct {
+	Version  uint64 `json:"version"`
+	DemandID string `json:"demandId"`
+	From     string `json:"from"`
+	// To is the destination node; for address demands it is the end of the advertised route.
+	To     string        `json:"to,omitempty"`
+	Routed bool          `json:"routed"`
+	Path   *routing.Path `json:"path,omitempty"`
+	// CostMS is the routing cost of the path: latency plus the stability penalty of each hop.
+	CostMS float64 `json:"costMs,omitempty"`
+	// Reason explains an unrouted demand, or a route that is not the cheapest available.
+	Reason string           `json:"reason,omitempty"`
+	Hops   []HopExplanation `json:"hops"`
+}
+
+// HopExplanation lists the candidate links leaving one node of the route. Next is empty when the
+// demand is unrouted and the hop is its source.
+type HopExplanation struct {
+	Node       string          `json:"node"`
+	Next       string          `json:"next,omitempty"`
+	Candidates []CandidateLink `json:"candidates"`
+}
+
+// CandidateLink is one link a hop could have taken. CostMS is the cost of reaching the destination
+// over it, for links with an onward route.
+type CandidateLink struct {
+	To        string           `json:"to"`
+	Verdict   CandidateVerdict `json:"verdict"`
+	LatencyMS float64          `json:"latencyMs"`
+	CostMS    float64          `json:"costMs,omitempty"`
+	SpareMbps *float64         `json:"spareMbps,omitempty"`
+	Detail    string           `json:"detail,omitempty"`
+}
+
+// ExplainRoute explains the route the latest recompute chose for the demand with the given ID.
+// Capacity is judged as the recompute left it, after every demand was admitted.
+func (s *Simulator) ExplainRoute(demandID string) (RouteExplanation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var demand TrafficDemand
+	found := false
+	for _, d := range s.traffic {
+		if d.ID == demandID {
+			demand, found = d, true
+			break
+		}
+	}
+	if !found {
+		return RouteExplanation{}, fmt.Errorf("%w %q", ErrUnknownDemand, demandID)
+	}
+
+	exp := RouteExplanation{Version: s.version, DemandID: demand.ID, From: demand.FromID, To: demand.ToID, Hops: []HopExplanation{}}
+	path, routed := s.snapshot.Routes[demand.ID]
+	if routed {
+		exp.Routed, exp.Path = true, &path
+		exp.To = path.Nodes[len(path.Nodes)-1]
+	}
+
+	need := demand.BandwidthMbps
+	switch alloc, ok := s.snapshot.Allocations[demand.ID]; {
+	case ok && alloc.Status == StatusBlocked:
+		exp.Reason = fmt.Sprintf("blocked: no path has %.3g Mbps spare", demand.BandwidthMbps)
+	case ok && alloc.Status == StatusPreempted:
+		exp.Reason = "preempted by a higher-priority demand"
+	case ok && alloc.Status == StatusDegraded:
+		exp.Reason = fmt.Sprintf("degraded to %.3g of %.3g Mbps: no path had room for the full bandwidth", alloc.AllocatedMbps, demand.BandwidthMbps)
+		need = alloc.AllocatedMbps
+	}
+	policy := ""
+	switch {
+	case s.stale[demand.ID] > 0:
+		policy = "the routing budget ran out, so the previous route was reused"
+	case routed && path.ContinuityPenaltyMS > 0:
+		policy = fmt.Sprintf("the previous route was kept; it is %.3g ms slower than the optimum, within routeContinuityPct", path.ContinuityPenaltyMS)
+	case demand.ToAddress != "":
+		policy = "the demand follows its advertised BGP route"
+	case demand.Policy != "":
+		policy = fmt.Sprintf("the demand is routed by the %s policy", demand.Policy)
+	}
+	if exp.Reason == "" {
+		exp.Reason = policy
+	}
+	if exp.Reason == "" && !routed {
+		exp.Reason = routing.ErrNoRoute.Error()
+	}
+	if exp.To == "" {
+		// An unrouted address demand has no destination node to explain hops toward.
+		return exp, nil
+	}
+
+	usable, spare := s.explainCapacityLocked(demand, need)
+	costs := routing.CostsTo(s.graph, exp.To, func(e routing.Edge) float64 {
+		if !usable(e) {
+			return math.Inf(1)
+		}
+		return routing.StableEdgeCost(e, s.stabilityWeight)
+	})
+	explainer := hopExplainer{sim: s, demand: demand, need: need, usable: usable, spare: spare, costs: costs, policy: policy}
+	if !routed {
+		exp.Hops = append(exp.Hops, explainer.hop(demand.FromID, "", map[string]bool{demand.FromID: true}, 0))
+		return exp, nil
+	}
+
+	visited := make(map[string]bool, len(path.Nodes))
+	spent := 0.0
+	for i := 0; i+1 < len(path.Nodes); i++ {
+		node, next := path.Nodes[i], path.Nodes[i+1]
+		visited[node] = true
+		exp.Hops = append(exp.Hops, explainer.hop(node, next, visited, spent))
+		if e, ok := s.edgeLocked(node, next); ok {
+			spent += routing.StableEdgeCost(e, s.stabilityWeight)
+		}
+	}
+	exp.CostMS = spent
+	return exp, nil
+}
+
+// explainCapacityLocked returns which links still have need Mbps spare for demand after the last
+// recompute, counting the demand's own reservation as spare, and the spare bandwidth of each link
+// when capacity is modeled.
+func (s *Simulator) explainCapacityLocked(demand TrafficDemand, need float64) (usable func(routing.Edge) bool, spare func(routing.Edge) (float64, bool)) {
+	state := s.capacity[demandSlice(demand)]
+	if state == nil {
+		return func(routing.Edge) bool { return true }, func(routing.Edge) (float64, bool) { return 0, false }
+	}
+	own := make(map[linkKey]bool, len(state.links[demand.ID]))
+	for _, key := range state.links[demand.ID] {
+		own[key] = true
+	}
+	spare = func(e routing.Edge) (float64, bool) {
+		free := state.spare(e)
+		if own[linkKey{e.From, e.To}] {
+			free += state.granted[demand.ID].AllocatedMbps
+		}
+		return free, true
+	}
+	usable = func(e routing.Edge) bool {
+		free, _ := spare(e)
+		return free >= need-capacityEpsilon
+	}
+	return usable, spare
+}
+
+func (s *Simulator) edgeLocked(from, to string) (routing.Edge, bool) {
+	return graphEdge(s.graph, from, to)
+}
+
+// hopExplainer classifies the candidate links of each hop of one demand's route.
+type hopExplainer struct {
+	sim    *Simulator
+	demand TrafficDemand
+	need   float64
+	usable func(routing.Edge) bool
+	spare  func(routing.Edge) (float64, bool)
+	costs  map[string]float64
+	// policy, when set, is why the route may not be the cheapest one available.
+	policy string
+}
+
+// hop lists the links leaving node: every link in the routing graph, disabled satellites it would
+// see, and the nearest nodes it cannot see. spent is the route's cost up to node.
+func (h hopExplainer) hop(node, next string, visited map[string]bool, spent float64) HopExplanation {
+	s := h.sim
+	hop := HopExplanation{Node: node, Next: next, Candidates: []CandidateLink{}}
+	linked := map[string]bool{node: true}
+	chosenCost := math.Inf(1)
+	if e, ok := s.edgeLocked(node, next); ok {
+		if rest, ok := h.costs[next]; ok {
+			chosenCost = routing.StableEdgeCost(e, s.stabilityWeight) + rest
+		}
+	}
+
+	s.graph.EdgesFrom(node, func(e routing.Edge) bool {
+		linked[e.To] = true
+		c := CandidateLink{To: e.To, LatencyMS: e.LatencyMS}
+		if free, ok := h.spare(e); ok {
+			c.SpareMbps = &free
+		}
+		rest, reachable := h.costs[e.To]
+		if reachable {
+			c.CostMS = spent + routing.StableEdgeCost(e, s.stabilityWeight) + rest
+		}
+		switch {
+		case e.To == next:
+			c.Verdict = CandidateChosen
+		case visited[e.To]:
+			c.Verdict, c.Detail = CandidateRevisits, "the route already passed through this node"
+		case !h.usable(e):
+			c.Verdict = CandidateOverCapacity
+			c.Detail = fmt.Sprintf("%.3g Mbps spare, demand needs %.3g Mbps", *c.SpareMbps, h.need)
+		case !reachable:
+			c.Verdict, c.Detail = CandidateNoOnwardRoute, "no usable route onward to "+h.destination()
+		case h.policy != "":
+			c.Verdict, c.Detail = CandidateExcluded, h.policy
+		case spent+chosenCost < c.CostMS:
+			c.Verdict = CandidateHigherCost
+			c.Detail = fmt.Sprintf("%.3g ms more than the chosen link", c.CostMS-spent-chosenCost)
+		default:
+			c.Verdict, c.Detail = CandidateHigherCost, "equal cost; the chosen link won the tie"
+		}
+		hop.Candidates = append(hop.Candidates, c)
+		return true
+	})
+
+	from, ok := s.routingNodeLocked(node)
+	if !ok {
+		return hop
+	}
+	var hidden []CandidateLink
+	notVisible := func(id string, check routing.LinkCheck) CandidateLink {
+		detail := check.Reason
+		if detail == "" {
+			detail = "no link in the routing graph"
+		}
+		return CandidateLink{To: id, Verdict: CandidateNotVisible, LatencyMS: check.LatencyMS, Detail: detail}
+	}
+	for _, sat := range s.satellites {
+		if linked[sat.ID] {
+			continue
+		}
+		check := routing.CheckLink(from, sat.routingNode(), s.elevationMask)
+		if reason := s.disabledReasonLocked(sat); reason != "" {
+			if check.Visible {
+				hop.Candidates = append(hop.Candidates, CandidateLink{To: sat.ID, Verdict: CandidateExcluded, LatencyMS: check.LatencyMS, Detail: reason})
+			}
+			continue
+		}
+		hidden = append(hidden, notVisible(sat.ID, check))
+	}
+	for _, gs := range s.ground {
+		if !linked[gs.ID] {
+			hidden = append(hidden, notVisible(gs.ID, routing.CheckLink(from, gs.routingNode(), s.elevationMask)))
+		}
+	}
+	sort.Slice(hidden, func(i, j int) bool {
+		// The destination is always listed, since it is the link a reader most often expects.
+		if a, b := hidden[i].To == h.destination(), hidden[j].To == h.destination(); a != b {
+			return a
+		}
+		return hidden[i].LatencyMS < hidden[j].LatencyMS
+	})
+	if len(hidden) > maxHiddenCandidates {
+		hidden = hidden[:maxHiddenCandidates]
+	}
+	hop.Candidates = append(hop.Candidates, hidden...)
+
+	sort.SliceStable(hop.Candidates, func(i, j int) bool {
+		a, b := hop.Candidates[i], hop.Candidates[j]
+		if verdictOrder[a.Verdict] != verdictOrder[b.Verdict] {
+			return verdictOrder[a.Verdict] < verdictOrder[b.Verdict]
+		}
+		if a.CostMS != b.CostMS {
+			return a.CostMS < b.CostMS
+		}
+		if a.LatencyMS != b.LatencyMS {
+			return a.LatencyMS < b.LatencyMS
+		}
+		return a.To < b.To
+	})
+	return hop
+}
+
+func (h hopExplainer) destination() string {
+	if h.demand.ToID != "" {
+		return h.demand.ToID
+	}
+	return h.demand.ToAddress
+}
+
+// routingNodeLocked returns the routing graph node for id, including disabled satellites.
+func (s *Simulator) routingNodeLocked(id string) (routing.Node, bool) {
+	if node, ok := s.graph.Nodes[id]; ok {
+		return node, true
+	}
+	if sat, ok := s.satellites[id]; ok {
+		return sat.routingNode(), true
+	}
+	return routing.Node{}, false
+}
+
+// disabledReasonLocked says why sat is out of the routing graph, or returns "" when it is active.
+func (s *Simulator) disabledReasonLocked(sat *Satellite) string {
+	switch {
+	case !sat.Active:
+		return "satellite is disabled"
+	case s.disabledShells[sat.shellKey()]:
+		return fmt.Sprintf("shell %s is disabled", sat.shellKey())
+	}
+	return ""
+}
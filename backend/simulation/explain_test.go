@@ -0,0 +1,128 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func explainConfig() Config {
+	er := visibility.EarthRadius
+	return Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "near", Position: visibility.Vector3{X: er + 400, Y: 5}},
+			{ID: "far", Position: visibility.Vector3{X: er + 1200, Y: 5}},
+			{ID: "spare", Position: visibility.Vector3{X: er + 600, Y: 5}},
+			{ID: "antipode", Position: visibility.Vector3{X: -er - 500}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "a", Position: visibility.Vector3{X: er}},
+			{ID: "b", Position: visibility.Vector3{X: er, Y: 10}},
+		},
+		Traffic: []TrafficDemand{
+			{ID: "first", FromID: "a", ToID: "b", BandwidthMbps: 8},
+			{ID: "second", FromID: "a", ToID: "b", BandwidthMbps: 8},
+		},
+		LinkCapacityMbps: 10,
+	}
+}
+
+func verdicts(hop HopExplanation) map[string]CandidateLink {
+	out := make(map[string]CandidateLink, len(hop.Candidates))
+	for _, c := range hop.Candidates {
+		out[c.To] = c
+	}
+	return out
+}
+
+func TestExplainRouteClassifiesCandidates(t *testing.T) {
+	cfg := explainConfig()
+	cfg.Traffic = cfg.Traffic[:1]
+	cfg.LinkCapacityMbps = 0
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if _, err := sim.DisableSatellite("spare"); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := sim.ExplainRoute("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Routed || len(first.Hops) != 2 || first.Hops[0].Next != "near" {
+		t.Fatalf("expected first demand via near, got %+v", first)
+	}
+	hop := verdicts(first.Hops[0])
+	if hop["near"].Verdict != CandidateChosen || hop["far"].Verdict != CandidateHigherCost {
+		t.Fatalf("unexpected first-hop verdicts %+v", first.Hops[0].Candidates)
+	}
+	if hop["far"].CostMS <= hop["near"].CostMS {
+		t.Fatalf("higher-cost candidate should cost more: %+v", first.Hops[0].Candidates)
+	}
+	if hop["spare"].Verdict != CandidateExcluded || hop["spare"].Detail != "satellite is disabled" {
+		t.Fatalf("disabled satellite should be excluded by policy, got %+v", hop["spare"])
+	}
+	if hop["antipode"].Verdict != CandidateNotVisible || hop["b"].Verdict != CandidateNotVisible {
+		t.Fatalf("expected out-of-sight nodes, got %+v", first.Hops[0].Candidates)
+	}
+	if first.Hops[0].Candidates[0].To != "near" {
+		t.Fatalf("the chosen link should be listed first, got %+v", first.Hops[0].Candidates)
+	}
+
+	if _, err := sim.ExplainRoute("missing"); !errors.Is(err, ErrUnknownDemand) {
+		t.Fatalf("expected ErrUnknownDemand, got %v", err)
+	}
+}
+
+func TestExplainRouteReportsCapacity(t *testing.T) {
+	sim, err := NewSimulator(explainConfig())
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	second, err := sim.ExplainRoute("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Routed || second.Hops[0].Next != "spare" {
+		t.Fatalf("expected second demand to detour via spare, got %+v", second)
+	}
+	if near := verdicts(second.Hops[0])["near"]; near.Verdict != CandidateOverCapacity || near.SpareMbps == nil || *near.SpareMbps > 2.01 {
+		t.Fatalf("expected the link used by the first demand to be over capacity, got %+v", near)
+	}
+	if chosen := verdicts(second.Hops[0])["spare"]; chosen.Verdict != CandidateChosen || *chosen.SpareMbps < 8 {
+		t.Fatalf("the demand's own reservation should count as spare, got %+v", chosen)
+	}
+}
+
+func TestExplainRouteReportsUnroutedDemand(t *testing.T) {
+	cfg := explainConfig()
+	cfg.Traffic = cfg.Traffic[:1]
+	cfg.LinkCapacityMbps = 0
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	for _, id := range []string{"near", "far", "spare"} {
+		if _, err := sim.DisableSatellite(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := sim.ExplainRoute("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.Routed || exp.Reason == "" || len(exp.Hops) != 1 || exp.Hops[0].Next != "" {
+		t.Fatalf("expected an unrouted explanation from the source, got %+v", exp)
+	}
+	for _, c := range exp.Hops[0].Candidates {
+		if c.Verdict == CandidateChosen || c.Verdict == CandidateHigherCost {
+			t.Fatalf("no candidate should be usable, got %+v", c)
+		}
+	}
+}
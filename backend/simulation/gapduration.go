@@ -0,0 +1,81 @@
+package simulation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+// GapDurationMap is the longest uncovered interval of every grid cell over a window simulated
+// ahead of a simulator's current state: the revisit figure for constellations that cover the
+// ground only intermittently.
+type GapDurationMap struct {
+	// Version is the snapshot version the window was simulated from.
+	Version uint64    `json:"version"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	StepS   float64   `json:"stepS"`
+	Samples int       `json:"samples"`
+	// LatStep and LonStep are the grid spacing in degrees; each cell spans one step centered on
+	// its coordinates.
+	LatStep float64 `json:"latStep"`
+	LonStep float64 `json:"lonStep"`
+	// MaxGapS is the longest gap of any cell.
+	MaxGapS float64            `json:"maxGapS"`
+	Cells   []coverage.CellGap `json:"cells"`
+}
+
+// GapDurations simulates window ahead of the current state in steps of dt and reports each cell's
+// longest uncovered interval. It runs on a copy rebuilt from the scenario and journal, so the
+// simulator itself is not advanced.
+func (s *Simulator) GapDurations(window, dt time.Duration) (GapDurationMap, error) {
+	if window <= 0 || dt <= 0 {
+		return GapDurationMap{}, errors.New("window and step must be positive")
+	}
+	s.mu.Lock()
+	cfg, ops, version, grid := s.scenario, append([]Operation(nil), s.journal...), s.snapshot.Version, s.gridConfig
+	s.mu.Unlock()
+
+	// The tracker reads every step's heatmap, so the copy materializes it.
+	cfg.StreamHeatmap = false
+	copied, err := NewSimulator(cfg)
+	if err != nil {
+		return GapDurationMap{}, err
+	}
+	if err := copied.Replay(ops); err != nil {
+		return GapDurationMap{}, err
+	}
+
+	var tracker coverage.GapTracker
+	snap := copied.Snapshot()
+	result := GapDurationMap{
+		Version: version,
+		Start:   snap.SimTime,
+		End:     snap.SimTime.Add(window),
+		StepS:   dt.Seconds(),
+		LatStep: grid.LatStep,
+		LonStep: grid.LonStep,
+	}
+	for {
+		if err := tracker.Observe(snap.SimTime, snap.Heatmap); err != nil {
+			return GapDurationMap{}, err
+		}
+		result.Samples++
+		if snap.SimTime.Add(dt).After(result.End) {
+			break
+		}
+		if snap, err = copied.Step(dt); err != nil {
+			return GapDurationMap{}, err
+		}
+	}
+	if result.Cells, err = tracker.Gaps(result.End); err != nil {
+		return GapDurationMap{}, err
+	}
+	for _, cell := range result.Cells {
+		if cell.MaxGapS > result.MaxGapS {
+			result.MaxGapS = cell.MaxGapS
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestGapDurationsOverADay(t *testing.T) {
+	orbit := orbits.KeplerianElements{SemiMajorAxis: visibility.EarthRadius + 550, Inclination: 53 * math.Pi / 180}
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 30, LonStep: 30},
+		Satellites: []Satellite{
+			{ID: "sat", Orbit: &orbit, Footprint: coverage.Footprint{RadiusKm: 2000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{{ID: "gateway", Location: &visibility.Geodetic{}}},
+		Epoch:          time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Step(10 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	before := sim.Snapshot()
+
+	day := 24 * time.Hour
+	result, err := sim.GapDurations(day, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after := sim.Snapshot(); after.Version != before.Version || !after.SimTime.Equal(before.SimTime) {
+		t.Fatal("computing gap durations should not advance the simulator")
+	}
+	if !result.Start.Equal(before.SimTime) || result.Samples != 289 || len(result.Cells) != len(before.Heatmap) {
+		t.Fatalf("unexpected window: start %v, %d samples, %d cells", result.Start, result.Samples, len(result.Cells))
+	}
+
+	// The ground track never reaches the polar caps, which stay uncovered all day, while cells it
+	// passes over are revisited every orbit.
+	revisited := 0
+	for _, cell := range result.Cells {
+		if math.Abs(cell.Lat) > 70 && (cell.MaxGapS != day.Seconds() || cell.CoveredFraction != 0) {
+			t.Fatalf("polar cell %+v should be uncovered all day", cell)
+		}
+		if cell.MaxGapS > 0 && cell.MaxGapS < 2*time.Hour.Seconds() && cell.CoveredFraction > 0 {
+			revisited++
+		}
+	}
+	if revisited == 0 {
+		t.Fatal("expected cells under the ground track to be revisited within an orbit")
+	}
+	if result.MaxGapS != day.Seconds() {
+		t.Fatalf("expected the worst gap to span the day, got %v", result.MaxGapS)
+	}
+
+	if _, err := sim.GapDurations(day, 0); err == nil {
+		t.Fatal("expected a zero step to be rejected")
+	}
+}
@@ -0,0 +1,30 @@
+package simulation
+
+import "github.com/example/satnet/backend/coverage"
+
+// GapReport groups the uncovered cells of the latest recompute into contiguous regions.
+type GapReport struct {
+	Version      uint64               `json:"version"`
+	TotalAreaKm2 float64              `json:"totalAreaKm2"`
+	Regions      []coverage.GapRegion `json:"regions"`
+}
+
+// CoverageGaps clusters the coverage gaps of the latest recompute, largest region first.
+func (s *Simulator) CoverageGaps() (GapReport, error) {
+	s.mu.Lock()
+	heatmap, version, grid := s.heatmapLocked(), s.snapshot.Version, s.gridConfig
+	s.mu.Unlock()
+
+	regions, err := coverage.ClusterGaps(heatmap, grid)
+	if err != nil {
+		return GapReport{}, err
+	}
+	report := GapReport{Version: version, Regions: regions}
+	if report.Regions == nil {
+		report.Regions = []coverage.GapRegion{}
+	}
+	for _, region := range regions {
+		report.TotalAreaKm2 += region.AreaKm2
+	}
+	return report, nil
+}
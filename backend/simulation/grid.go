@@ -0,0 +1,167 @@
+package simulation
+
+import (
+	"errors"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+// ErrGridRebuildInProgress is returned when a grid change is requested while another is still
+// being built.
+var ErrGridRebuildInProgress = errors.New("a coverage grid rebuild is already in progress")
+
+// GridStatus reports the coverage grid a simulator uses and any change under way.
+type GridStatus struct {
+	Grid coverage.GridConfig `json:"grid"`
+	// Pending is the grid being built in the background; it replaces Grid once ready.
+	Pending *coverage.GridConfig `json:"pending,omitempty"`
+	// Error is why the latest rebuild failed, until the next one starts.
+	Error string `json:"error,omitempty"`
+}
+
+// GridStatus returns the grid in use and the rebuild in progress, if any.
+func (s *Simulator) GridStatus() GridStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := GridStatus{Grid: s.gridConfig, Error: s.gridError}
+	if s.gridPending != nil {
+		pending := *s.gridPending
+		status.Pending = &pending
+	}
+	return status
+}
+
+// gridRebuildAttempts bounds how many times a rebuild recomputes the fine coverage without holding
+// the simulator because the simulation moved on meanwhile. The last attempt recomputes it under
+// the lock instead, so a rebuild lands even on a simulation that steps faster than it can build.
+const gridRebuildAttempts = 3
+
+// SetGrid changes the coverage grid's resolution and recomputes coverage on it. The new grid is
+// allocated and covered without holding the simulator, so steps and reads carry on at the old
+// resolution while a fine grid is built; it is then swapped in at once, if no step or mutation
+// happened meanwhile, and the change journaled. Only one change may be in progress at a time.
+func (s *Simulator) SetGrid(grid coverage.GridConfig) (Snapshot, error) {
+	if err := s.claimGridRebuild(grid); err != nil {
+		return Snapshot{}, err
+	}
+	return s.rebuildGrid(grid)
+}
+
+// StartGridRebuild starts SetGrid in the background and returns once the grid is validated.
+// submit schedules the rebuild, such as on a worker pool; nil runs it on its own goroutine. An
+// error from submit abandons the rebuild. GridStatus reports its progress and any failure.
+func (s *Simulator) StartGridRebuild(grid coverage.GridConfig, submit func(func()) error) error {
+	if err := s.claimGridRebuild(grid); err != nil {
+		return err
+	}
+	if submit == nil {
+		go s.rebuildGrid(grid)
+		return nil
+	}
+	if err := submit(func() { s.rebuildGrid(grid) }); err != nil {
+		s.mu.Lock()
+		s.gridPending = nil
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *Simulator) claimGridRebuild(grid coverage.GridConfig) error {
+	if err := grid.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.gridPending != nil {
+		return ErrGridRebuildInProgress
+	}
+	s.gridPending, s.gridError = &grid, ""
+	return nil
+}
+
+func (s *Simulator) rebuildGrid(grid coverage.GridConfig) (Snapshot, error) {
+	for attempt := 1; ; attempt++ {
+		s.mu.Lock()
+		version, footprints, regions, sharded := s.version, s.activeFootprintsLocked(), s.scenario.CoverageRegions, s.sharder != nil
+		s.mu.Unlock()
+
+		// Shard workers compute the global coverage themselves, so only allocate the grids here.
+		built, err := coverage.NewCoverageGrid(grid)
+		var regionGrids []*coverage.CoverageGrid
+		if err == nil {
+			regionGrids, err = newRegionGrids(grid, regions)
+		}
+		if err == nil && !sharded {
+			built.ApplyFootprints(sortedFootprints(footprints))
+		}
+
+		s.mu.Lock()
+		if err == nil && s.version != version && attempt < gridRebuildAttempts {
+			s.mu.Unlock()
+			continue
+		}
+		var snapshot Snapshot
+		if err == nil {
+			snapshot, err = s.swapGridLocked(grid, built, regionGrids, s.version == version && !sharded)
+		}
+		s.gridPending = nil
+		if err != nil {
+			s.gridError = err.Error()
+		}
+		s.mu.Unlock()
+		return snapshot, err
+	}
+}
+
+// swapGridLocked switches to grid after a rebuild built it and its regional grids. covered
+// reports whether built already holds the coverage of the current step, which the recompute then
+// publishes instead of covering the grid again.
+func (s *Simulator) swapGridLocked(grid coverage.GridConfig, built *coverage.CoverageGrid, regionGrids []*coverage.CoverageGrid, covered bool) (Snapshot, error) {
+	if covered {
+		s.prebuiltGrid = built
+		defer func() { s.prebuiltGrid = nil }()
+	} else {
+		// The next recompute acquires the grid just built instead of allocating another.
+		coverage.ReleaseGrid(built)
+	}
+	return s.applyGridLocked(grid, regionGrids)
+}
+
+// activeFootprintsLocked returns the footprints the next recompute covers the grid with, keyed by
+// satellite ID.
+func (s *Simulator) activeFootprintsLocked() map[string]coverage.Footprint {
+	footprints := make(map[string]coverage.Footprint, len(s.satellites))
+	for _, sat := range s.satellites {
+		if sat.Active && !s.disabledShells[sat.shellKey()] {
+			footprints[sat.ID] = sat.coverageFootprint(s.elevationMask)
+		}
+	}
+	return footprints
+}
+
+func (s *Simulator) setGridLocked(grid coverage.GridConfig) (Snapshot, error) {
+	if err := grid.Validate(); err != nil {
+		return Snapshot{}, err
+	}
+	// Regional grids keep their own resolution but follow the global grid's other settings.
+	regionGrids, err := newRegionGrids(grid, s.scenario.CoverageRegions)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return s.applyGridLocked(grid, regionGrids)
+}
+
+// applyGridLocked switches to a validated grid and its regional grids and recomputes on them,
+// restoring the previous grids if the recompute fails.
+func (s *Simulator) applyGridLocked(grid coverage.GridConfig, regionGrids []*coverage.CoverageGrid) (Snapshot, error) {
+	previous, previousRegions := s.gridConfig, s.regionGrids
+	s.gridConfig, s.regionGrids = grid, regionGrids
+	snapshot, err := s.recomputeLocked()
+	if err != nil {
+		s.gridConfig, s.regionGrids = previous, previousRegions
+		return Snapshot{}, err
+	}
+	s.record(Operation{Op: OpSetGrid, Grid: &grid})
+	return snapshot, nil
+}
@@ -0,0 +1,99 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func gridTestConfig() Config {
+	return Config{
+		GridConfig: coverage.GridConfig{LatStep: 30, LonStep: 30},
+		Satellites: []Satellite{
+			{ID: "sat", Location: &visibility.Geodetic{AltKm: 1200}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{{ID: "gw", Location: &visibility.Geodetic{}}},
+		Epoch:          time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSetGridSwapsResolution(t *testing.T) {
+	sim, err := NewSimulator(gridTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cells := sim.Snapshot().Coverage.TotalCells; cells != 72 {
+		t.Fatalf("expected 72 cells at 30°, got %d", cells)
+	}
+	fine := coverage.GridConfig{LatStep: 5, LonStep: 5}
+	snap, err := sim.SetGrid(fine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Coverage.TotalCells != 36*72 || len(snap.Heatmap) != 36*72 {
+		t.Fatalf("expected %d cells at 5°, got %d", 36*72, snap.Coverage.TotalCells)
+	}
+	if status := sim.GridStatus(); status.Grid != fine || status.Pending != nil {
+		t.Fatalf("expected the fine grid in use, got %+v", status)
+	}
+	// The coverage built off the lock matches covering the grid during a recompute.
+	again, err := sim.Recompute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Coverage.CoveredCells != snap.Coverage.CoveredCells || again.Coverage.CoveragePercent != snap.Coverage.CoveragePercent {
+		t.Fatalf("expected the rebuilt coverage %+v to match a recompute, got %+v", snap.Coverage, again.Coverage)
+	}
+	if _, err := sim.SetGrid(coverage.GridConfig{LatStep: -1, LonStep: 5}); err == nil {
+		t.Fatal("expected an invalid grid to be rejected")
+	}
+
+	// The journal carries the change, so replays end at the same resolution.
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := NewSimulator(sim.Scenario())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayed.Replay(sim.Journal()); err != nil {
+		t.Fatal(err)
+	}
+	if got := replayed.Snapshot().Coverage.TotalCells; got != 36*72 {
+		t.Fatalf("expected the replay to use the fine grid, got %d cells", got)
+	}
+}
+
+func TestStartGridRebuildRunsInTheBackground(t *testing.T) {
+	sim, err := NewSimulator(gridTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.claimGridRebuild(coverage.GridConfig{LatStep: 10, LonStep: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.StartGridRebuild(coverage.GridConfig{LatStep: 1, LonStep: 1}, nil); !errors.Is(err, ErrGridRebuildInProgress) {
+		t.Fatalf("expected a second rebuild to be refused, got %v", err)
+	}
+	sim.rebuildGrid(coverage.GridConfig{LatStep: 10, LonStep: 10})
+
+	if err := sim.StartGridRebuild(coverage.GridConfig{LatStep: 2, LonStep: 2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for sim.GridStatus().Pending != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("rebuild did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if status := sim.GridStatus(); status.Grid.LatStep != 2 || status.Error != "" {
+		t.Fatalf("expected the 2° grid in use, got %+v", status)
+	}
+	if cells := sim.Snapshot().Coverage.TotalCells; cells != 90*180 {
+		t.Fatalf("expected %d cells, got %d", 90*180, cells)
+	}
+}
@@ -0,0 +1,217 @@
+package simulation
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+// keyframeInterval caps how many entries share a keyframe before a fresh one is taken.
+const keyframeInterval = 64
+
+// History keeps the most recent snapshots in a fixed-size ring, compressed so long runs at fine
+// grid resolution fit in memory. Heatmaps are delta-encoded against a periodic keyframe, strengths
+// and bandwidths are quantized to float32, and coverage gaps are rebuilt from the heatmap on read.
+// Cell coordinates are shared between keyframes of the same grid.
+type History struct {
+	entries []historyEntry
+	start   int // index of the oldest entry
+	count   int
+	last    *historyFrame
+	sinceKF int
+}
+
+// HistorySummary describes a buffered snapshot without decompressing it.
+type HistorySummary struct {
+	Version         uint64    `json:"version"`
+	SimTime         time.Time `json:"simTime"`
+	CoveragePercent float64   `json:"coveragePercent"`
+}
+
+type historyFrame struct {
+	lats, lons []float64
+	counts     []int32
+	strengths  []float32
+	bandwidths []float32 // nil when no cell carries bandwidth
+}
+
+type cellDelta struct {
+	index     uint32
+	count     int32
+	strength  float32
+	bandwidth float32
+}
+
+type historyEntry struct {
+	meta   Snapshot // heatmap and coverage gaps stripped
+	frame  *historyFrame
+	deltas []cellDelta
+}
+
+// NewHistory returns a buffer retaining up to capacity snapshots (minimum one).
+func NewHistory(capacity int) *History {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &History{entries: make([]historyEntry, capacity)}
+}
+
+// Append compresses and stores a snapshot, evicting the oldest when the buffer is full.
+func (h *History) Append(snapshot Snapshot) {
+	entry := historyEntry{meta: snapshot}
+	entry.meta.Heatmap = nil
+	entry.meta.Coverage.UncoveredSamples = nil
+
+	if h.last != nil && h.sinceKF < keyframeInterval && sameGrid(h.last, snapshot.Heatmap) {
+		deltas := diffFrame(h.last, snapshot.Heatmap)
+		// Once most cells have drifted from the keyframe, a new keyframe is cheaper.
+		if len(deltas) < len(snapshot.Heatmap)/4 {
+			entry.frame, entry.deltas = h.last, deltas
+			h.sinceKF++
+		}
+	}
+	if entry.frame == nil {
+		entry.frame = newFrame(h.last, snapshot.Heatmap)
+		h.last = entry.frame
+		h.sinceKF = 0
+	}
+
+	slot := (h.start + h.count) % len(h.entries)
+	if h.count == len(h.entries) {
+		h.start = (h.start + 1) % len(h.entries)
+	} else {
+		h.count++
+	}
+	h.entries[slot] = entry
+}
+
+// Len reports how many snapshots are buffered.
+func (h *History) Len() int {
+	return h.count
+}
+
+// Summaries lists the buffered snapshots, oldest first.
+func (h *History) Summaries() []HistorySummary {
+	out := make([]HistorySummary, 0, h.count)
+	for i := 0; i < h.count; i++ {
+		meta := h.entries[(h.start+i)%len(h.entries)].meta
+		out = append(out, HistorySummary{Version: meta.Version, SimTime: meta.SimTime, CoveragePercent: meta.Coverage.CoveragePercent})
+	}
+	return out
+}
+
+// Get decompresses the buffered snapshot with the given version. Strengths and bandwidths come back
+// at float32 precision; everything else round-trips exactly.
+func (h *History) Get(version uint64) (Snapshot, bool) {
+	for i := 0; i < h.count; i++ {
+		entry := h.entries[(h.start+i)%len(h.entries)]
+		if entry.meta.Version == version {
+			return entry.decode(), true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// SizeBytes approximates the memory held by the compressed heatmaps. Keyframes and coordinate
+// slices shared between entries are counted once.
+func (h *History) SizeBytes() int64 {
+	frames := make(map[*historyFrame]bool)
+	coords := make(map[*float64]bool)
+	var total int64
+	for i := 0; i < h.count; i++ {
+		entry := h.entries[(h.start+i)%len(h.entries)]
+		total += int64(len(entry.deltas)) * int64(unsafe.Sizeof(cellDelta{}))
+		if frames[entry.frame] {
+			continue
+		}
+		frames[entry.frame] = true
+		total += int64(len(entry.frame.counts)) * int64(unsafe.Sizeof(int32(0))+unsafe.Sizeof(float32(0)))
+		total += int64(len(entry.frame.bandwidths)) * int64(unsafe.Sizeof(float32(0)))
+		if len(entry.frame.lats) > 0 && !coords[&entry.frame.lats[0]] {
+			coords[&entry.frame.lats[0]] = true
+			total += int64(len(entry.frame.lats)) * 2 * int64(unsafe.Sizeof(float64(0)))
+		}
+	}
+	return total
+}
+
+func (e historyEntry) decode() Snapshot {
+	snapshot := e.meta
+	frame := e.frame
+	cells := make([]coverage.HeatmapCell, len(frame.counts))
+	for i := range cells {
+		cells[i] = coverage.HeatmapCell{Lat: frame.lats[i], Lon: frame.lons[i], Count: int(frame.counts[i]), Strength: float64(frame.strengths[i]), BandwidthMHz: float64(frame.bandwidth(i))}
+	}
+	for _, d := range e.deltas {
+		cells[d.index].Count = int(d.count)
+		cells[d.index].Strength = float64(d.strength)
+		cells[d.index].BandwidthMHz = float64(d.bandwidth)
+	}
+
+	var gaps []coverage.GapSample
+	for i := range cells {
+		cells[i].Covered = cells[i].Count > 0
+		if !cells[i].Covered {
+			gaps = append(gaps, coverage.GapSample{Lat: cells[i].Lat, Lon: cells[i].Lon})
+		}
+	}
+	if len(cells) > 0 {
+		snapshot.Heatmap = cells
+	}
+	snapshot.Coverage.UncoveredSamples = gaps
+	return snapshot
+}
+
+// newFrame builds a keyframe, reusing the previous frame's coordinates when the grid is unchanged.
+func newFrame(previous *historyFrame, heatmap []coverage.HeatmapCell) *historyFrame {
+	frame := &historyFrame{counts: make([]int32, len(heatmap)), strengths: make([]float32, len(heatmap))}
+	if previous != nil && sameGrid(previous, heatmap) {
+		frame.lats, frame.lons = previous.lats, previous.lons
+	} else {
+		frame.lats, frame.lons = make([]float64, len(heatmap)), make([]float64, len(heatmap))
+		for i, cell := range heatmap {
+			frame.lats[i], frame.lons[i] = cell.Lat, cell.Lon
+		}
+	}
+	for i, cell := range heatmap {
+		frame.counts[i], frame.strengths[i] = int32(cell.Count), float32(cell.Strength)
+		if cell.BandwidthMHz != 0 {
+			if frame.bandwidths == nil {
+				frame.bandwidths = make([]float32, len(heatmap))
+			}
+			frame.bandwidths[i] = float32(cell.BandwidthMHz)
+		}
+	}
+	return frame
+}
+
+func sameGrid(frame *historyFrame, heatmap []coverage.HeatmapCell) bool {
+	if len(frame.lats) != len(heatmap) {
+		return false
+	}
+	for i, cell := range heatmap {
+		if frame.lats[i] != cell.Lat || frame.lons[i] != cell.Lon {
+			return false
+		}
+	}
+	return true
+}
+
+func diffFrame(frame *historyFrame, heatmap []coverage.HeatmapCell) []cellDelta {
+	var deltas []cellDelta
+	for i, cell := range heatmap {
+		count, strength, bandwidth := int32(cell.Count), float32(cell.Strength), float32(cell.BandwidthMHz)
+		if count != frame.counts[i] || strength != frame.strengths[i] || bandwidth != frame.bandwidth(i) {
+			deltas = append(deltas, cellDelta{index: uint32(i), count: count, strength: strength, bandwidth: bandwidth})
+		}
+	}
+	return deltas
+}
+
+func (f *historyFrame) bandwidth(i int) float32 {
+	if f.bandwidths == nil {
+		return 0
+	}
+	return f.bandwidths[i]
+}
@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+func testHeatmapSnapshot(t *testing.T, version uint64, footprints []coverage.Footprint) Snapshot {
+	t.Helper()
+	grid, err := coverage.NewCoverageGrid(coverage.GridConfig{LatStep: 5, LonStep: 5})
+	if err != nil {
+		t.Fatalf("grid: %v", err)
+	}
+	grid.ApplyFootprints(footprints)
+	return Snapshot{
+		Version:  version,
+		SimTime:  time.Unix(int64(version), 0).UTC(),
+		Coverage: grid.Summarize(),
+		Heatmap:  grid.HeatmapData(),
+	}
+}
+
+func TestHistoryRoundTripsCompressedSnapshots(t *testing.T) {
+	history := NewHistory(50)
+	var originals []Snapshot
+	for v := uint64(1); v <= 120; v++ {
+		// A footprint sweeping east with an irrational strength exercises deltas and quantization.
+		fp := coverage.Footprint{CenterLat: 10, CenterLon: float64(v%36) * 10, RadiusKm: 1500, LinkStrength: math.Pi * float64(v)}
+		if v%3 == 0 {
+			// Bandwidth comes and goes, so some keyframes carry it and some do not.
+			fp.BandwidthMHz, fp.Color = math.E*float64(v), 1
+		}
+		snap := testHeatmapSnapshot(t, v, []coverage.Footprint{fp})
+		originals = append(originals, snap)
+		history.Append(snap)
+	}
+
+	if history.Len() != 50 {
+		t.Fatalf("expected the ring to hold 50 snapshots, got %d", history.Len())
+	}
+	if _, ok := history.Get(70); ok {
+		t.Fatalf("expected version 70 to be evicted")
+	}
+	summaries := history.Summaries()
+	if summaries[0].Version != 71 || summaries[49].Version != 120 {
+		t.Fatalf("unexpected buffered range: %d..%d", summaries[0].Version, summaries[49].Version)
+	}
+
+	for _, want := range originals[70:] {
+		got, ok := history.Get(want.Version)
+		if !ok {
+			t.Fatalf("version %d missing", want.Version)
+		}
+		if len(got.Heatmap) != len(want.Heatmap) || len(got.Coverage.UncoveredSamples) != len(want.Coverage.UncoveredSamples) {
+			t.Fatalf("version %d: heatmap or gaps lost cells", want.Version)
+		}
+		for i, cell := range want.Heatmap {
+			g := got.Heatmap[i]
+			if g.Lat != cell.Lat || g.Lon != cell.Lon || g.Count != cell.Count || g.Covered != cell.Covered {
+				t.Fatalf("version %d cell %d: want %+v, got %+v", want.Version, i, cell, g)
+			}
+			if math.Abs(g.Strength-cell.Strength) > 1e-6*math.Max(1, cell.Strength) {
+				t.Fatalf("version %d cell %d strength %v beyond float32 precision of %v", want.Version, i, g.Strength, cell.Strength)
+			}
+			if math.Abs(g.BandwidthMHz-cell.BandwidthMHz) > 1e-6*math.Max(1, cell.BandwidthMHz) {
+				t.Fatalf("version %d cell %d bandwidth %v beyond float32 precision of %v", want.Version, i, g.BandwidthMHz, cell.BandwidthMHz)
+			}
+		}
+		if got.Coverage.CoveredCells != want.Coverage.CoveredCells || got.SimTime != want.SimTime {
+			t.Fatalf("version %d: metadata changed", want.Version)
+		}
+	}
+
+	raw := int64(50 * len(originals[0].Heatmap) * int(unsafe.Sizeof(coverage.HeatmapCell{})+unsafe.Sizeof(coverage.GapSample{})))
+	if size := history.SizeBytes(); size*4 > raw {
+		t.Fatalf("expected at least 4x compression, got %d bytes vs %d raw", size, raw)
+	}
+}
+
+func TestSimulatorRecordsHistory(t *testing.T) {
+	sim := NewDemoSimulator()
+	sim.SetHistorySize(3)
+	for i := 0; i < 5; i++ {
+		if _, err := sim.Step(time.Second); err != nil {
+			t.Fatalf("step failed: %v", err)
+		}
+	}
+	summaries, size := sim.HistorySummaries()
+	if len(summaries) != 3 || size <= 0 {
+		t.Fatalf("expected 3 buffered snapshots, got %d (%d bytes)", len(summaries), size)
+	}
+	latest := sim.Snapshot()
+	if snap, ok := sim.HistorySnapshot(latest.Version); !ok || snap.Coverage.TotalCells != latest.Coverage.TotalCells {
+		t.Fatalf("expected latest snapshot in history")
+	}
+}
@@ -0,0 +1,451 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HookOp is an action a scenario hook takes.
+type HookOp string
+
+const (
+	HookDisableSatellite HookOp = "disable-satellite"
+	HookRemoveSatellite  HookOp = "remove-satellite"
+	HookEnableShell      HookOp = "enable-shell"
+	HookDisableShell     HookOp = "disable-shell"
+	// HookAddDemand starts routing a demand, typically one the scenario declares Deferred.
+	HookAddDemand HookOp = "add-demand"
+	// HookDropDemand stops routing a demand, as if it were deferred.
+	HookDropDemand HookOp = "drop-demand"
+)
+
+// HookAction is one change a hook applies: Op on the satellite, shell or demand named by Target.
+type HookAction struct {
+	Op     HookOp `json:"op"`
+	Target string `json:"target"`
+}
+
+// Hook lets a scenario change itself as it runs, without recompiling the simulator. After every
+// step, each hook evaluates When against the step's snapshot and applies its actions when the
+// condition turns true, so a hook fires once per crossing rather than on every step the condition
+// holds. Once limits the hook to its first firing.
+//
+// When is an expression over these variables:
+//
+//	t                 seconds since the scenario epoch
+//	coverage          coverage percent
+//	activeSatellites  satellites in routing and coverage
+//	demands           demands active at the step
+//	routed            active demands with a route
+//
+// with numbers, arithmetic (+ - * / %), comparisons (< <= > >= == !=), the logical operators
+// && || !, and parentheses; for example "t >= 600" or "coverage < 90 && routed < demands".
+type Hook struct {
+	Name string       `json:"name"`
+	When string       `json:"when"`
+	Once bool         `json:"once,omitempty"`
+	Do   []HookAction `json:"do"`
+}
+
+// hookVariables are the variables a hook condition may read.
+var hookVariables = []string{"t", "coverage", "activeSatellites", "demands", "routed"}
+
+// scenarioHook is a Hook with its condition compiled and the state it needs to fire on edges.
+type scenarioHook struct {
+	Hook
+	when  expr
+	held  bool // whether the condition held at the previous step
+	fired bool
+}
+
+// compileHooks parses the scenario's hooks and checks their actions against the simulator's
+// satellites, shells and demands.
+func (s *Simulator) compileHooks(hooks []Hook) ([]*scenarioHook, error) {
+	compiled := make([]*scenarioHook, 0, len(hooks))
+	names := make(map[string]bool, len(hooks))
+	demands := make(map[string]bool, len(s.traffic))
+	for _, demand := range s.traffic {
+		demands[demand.ID] = true
+	}
+	shells := make(map[string]bool)
+	for _, sat := range s.satellites {
+		shells[sat.shellKey()] = true
+	}
+	for _, hook := range hooks {
+		if hook.Name == "" {
+			return nil, errors.New("hook name cannot be empty")
+		}
+		if names[hook.Name] {
+			return nil, fmt.Errorf("duplicate hook %q", hook.Name)
+		}
+		names[hook.Name] = true
+		when, err := parseExpr(hook.When)
+		if err != nil {
+			return nil, fmt.Errorf("hook %q: %w", hook.Name, err)
+		}
+		if !when.boolean() {
+			return nil, fmt.Errorf("hook %q: condition %q is not a comparison", hook.Name, hook.When)
+		}
+		if len(hook.Do) == 0 {
+			return nil, fmt.Errorf("hook %q has no actions", hook.Name)
+		}
+		for _, action := range hook.Do {
+			var known bool
+			switch action.Op {
+			case HookDisableSatellite, HookRemoveSatellite:
+				known = s.satellites[action.Target] != nil
+			case HookEnableShell, HookDisableShell:
+				known = shells[action.Target]
+			case HookAddDemand, HookDropDemand:
+				known = demands[action.Target]
+			default:
+				return nil, fmt.Errorf("hook %q: unknown action %q", hook.Name, action.Op)
+			}
+			if !known {
+				return nil, fmt.Errorf("hook %q: %s names unknown target %q", hook.Name, action.Op, action.Target)
+			}
+		}
+		compiled = append(compiled, &scenarioHook{Hook: hook, when: when})
+	}
+	return compiled, nil
+}
+
+// runHooksLocked evaluates the hooks against the snapshot of the step just taken and applies the
+// actions of those that fire, returning their names in scenario order. Actions are not journaled:
+// replayed steps fire the same hooks again.
+func (s *Simulator) runHooksLocked(snapshot Snapshot) []string {
+	vars := map[string]float64{
+		"t":                s.clock.Sub(s.scenario.Epoch).Seconds(),
+		"coverage":         snapshot.Coverage.CoveragePercent,
+		"activeSatellites": float64(len(snapshot.ActiveSatellites)),
+	}
+	for _, demand := range s.traffic {
+		if !s.activeLocked(demand) {
+			continue
+		}
+		vars["demands"]++
+		if _, ok := snapshot.Routes[demand.ID]; ok {
+			vars["routed"]++
+		}
+	}
+	var fired []string
+	for _, hook := range s.hooks {
+		holds := hook.when.eval(vars) != 0
+		edge := holds && !hook.held
+		hook.held = holds
+		if !edge || hook.Once && hook.fired {
+			continue
+		}
+		hook.fired = true
+		fired = append(fired, hook.Name)
+		for _, action := range hook.Do {
+			s.applyHookActionLocked(action)
+		}
+	}
+	return fired
+}
+
+// applyHookActionLocked applies one action. Satellites removed since the scenario started are
+// skipped.
+func (s *Simulator) applyHookActionLocked(action HookAction) {
+	switch action.Op {
+	case HookDisableSatellite:
+		if sat, ok := s.satellites[action.Target]; ok {
+			sat.Active = false
+		}
+	case HookRemoveSatellite:
+		delete(s.satellites, action.Target)
+	case HookEnableShell:
+		delete(s.disabledShells, action.Target)
+	case HookDisableShell:
+		s.disabledShells[action.Target] = true
+	case HookAddDemand:
+		delete(s.deferred, action.Target)
+	case HookDropDemand:
+		s.deferred[action.Target] = true
+	}
+}
+
+// expr is a compiled hook condition. Booleans evaluate to 1 and 0.
+type expr interface {
+	eval(vars map[string]float64) float64
+	boolean() bool
+}
+
+type numberExpr float64
+
+func (e numberExpr) eval(map[string]float64) float64 { return float64(e) }
+func (numberExpr) boolean() bool                     { return false }
+
+type variableExpr string
+
+func (e variableExpr) eval(vars map[string]float64) float64 { return vars[string(e)] }
+func (variableExpr) boolean() bool                          { return false }
+
+type unaryExpr struct {
+	op      string
+	operand expr
+}
+
+func (e unaryExpr) eval(vars map[string]float64) float64 {
+	v := e.operand.eval(vars)
+	if e.op == "!" {
+		return truth(v == 0)
+	}
+	return -v
+}
+
+func (e unaryExpr) boolean() bool { return e.op == "!" }
+
+type binaryExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e binaryExpr) eval(vars map[string]float64) float64 {
+	l := e.left.eval(vars)
+	switch e.op {
+	case "&&":
+		return truth(l != 0 && e.right.eval(vars) != 0)
+	case "||":
+		return truth(l != 0 || e.right.eval(vars) != 0)
+	}
+	r := e.right.eval(vars)
+	switch e.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		return l / r
+	case "%":
+		return math.Mod(l, r)
+	case "<":
+		return truth(l < r)
+	case "<=":
+		return truth(l <= r)
+	case ">":
+		return truth(l > r)
+	case ">=":
+		return truth(l >= r)
+	case "==":
+		return truth(l == r)
+	default: // "!="
+		return truth(l != r)
+	}
+}
+
+func (e binaryExpr) boolean() bool {
+	switch e.op {
+	case "+", "-", "*", "/", "%":
+		return false
+	}
+	return true
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// exprParser is a recursive-descent parser over the tokens of a hook condition, binding from
+// loosest to tightest: ||, &&, !, comparisons, + -, * / %, unary minus.
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func parseExpr(source string) (expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("condition cannot be empty")
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// binary parses operands with next, joined by any of ops. Logical operators need boolean
+// operands and the others numeric ones.
+func (p *exprParser) binary(next func() (expr, error), logical bool, ops ...string) (expr, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		found := false
+		for _, candidate := range ops {
+			found = found || op == candidate
+		}
+		if !found {
+			return left, nil
+		}
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if left.boolean() != logical || right.boolean() != logical {
+			if logical {
+				return nil, fmt.Errorf("%s needs comparisons on both sides", op)
+			}
+			return nil, fmt.Errorf("%s needs numbers on both sides", op)
+		}
+		left = binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) or() (expr, error) { return p.binary(p.and, true, "||") }
+
+func (p *exprParser) and() (expr, error) { return p.binary(p.not, true, "&&") }
+
+func (p *exprParser) not() (expr, error) {
+	if p.peek() != "!" {
+		return p.comparison()
+	}
+	p.pos++
+	operand, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	if !operand.boolean() {
+		return nil, errors.New("! needs a comparison")
+	}
+	return unaryExpr{op: "!", operand: operand}, nil
+}
+
+func (p *exprParser) comparison() (expr, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek(); op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		p.pos++
+		right, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if left.boolean() || right.boolean() {
+			return nil, fmt.Errorf("%s needs numbers on both sides", op)
+		}
+		return binaryExpr{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) sum() (expr, error) { return p.binary(p.product, false, "+", "-") }
+
+func (p *exprParser) product() (expr, error) { return p.binary(p.unary, false, "*", "/", "%") }
+
+func (p *exprParser) unary() (expr, error) {
+	if p.peek() == "-" {
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.boolean() {
+			return nil, errors.New("- needs a number")
+		}
+		return unaryExpr{op: "-", operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (expr, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of condition")
+	case token == "(":
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return e, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		p.pos++
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", token)
+		}
+		return numberExpr(v), nil
+	case unicode.IsLetter(rune(token[0])):
+		p.pos++
+		for _, name := range hookVariables {
+			if name == token {
+				return variableExpr(token), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown variable %q; conditions may read %s", token, strings.Join(hookVariables, ", "))
+	}
+	return nil, fmt.Errorf("unexpected %q", token)
+}
+
+var twoCharOperators = map[string]bool{"<=": true, ">=": true, "==": true, "!=": true, "&&": true, "||": true}
+
+// tokenize splits a condition into numbers, identifiers, operators and parentheses.
+func tokenize(source string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(source) && (unicode.IsDigit(rune(source[j])) || source[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		case unicode.IsLetter(c):
+			j := i
+			for j < len(source) && (unicode.IsLetter(rune(source[j])) || unicode.IsDigit(rune(source[j]))) {
+				j++
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		case i+1 < len(source) && twoCharOperators[source[i:i+2]]:
+			tokens = append(tokens, source[i:i+2])
+			i += 2
+		case strings.ContainsRune("+-*/%<>!()", c):
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
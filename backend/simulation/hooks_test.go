@@ -0,0 +1,126 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestParseExpr(t *testing.T) {
+	vars := map[string]float64{"t": 600, "coverage": 85, "demands": 3, "routed": 2}
+	for source, want := range map[string]bool{
+		"t >= 600":                            true,
+		"t > 600":                             false,
+		"coverage < 90 && routed < demands":   true,
+		"coverage < 80 || !(routed == 2)":     false,
+		"t % 300 == 0":                        true,
+		"-t + 2 * 300 == 0":                   true,
+		"(coverage - 5) / 10 != 8":            false,
+		"activeSatellites == 0 && t / 60 > 9": true,
+	} {
+		e, err := parseExpr(source)
+		if err != nil {
+			t.Fatalf("%q: %v", source, err)
+		}
+		if !e.boolean() {
+			t.Fatalf("%q: expected a boolean expression", source)
+		}
+		if got := e.eval(vars) != 0; got != want {
+			t.Fatalf("%q: expected %v, got %v", source, want, got)
+		}
+	}
+
+	for _, source := range []string{"", "t", "t >", "t = 5", "altitude > 5", "(t > 5", "t > 5 && 3", "!t", "t < 1 < 2", "t > 5 #"} {
+		if e, err := parseExpr(source); err == nil && e.boolean() {
+			t.Fatalf("%q: expected an error", source)
+		}
+	}
+}
+
+func TestHooksChangeTheScenarioAsItRuns(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites: []Satellite{
+			{ID: "sat-1", Shell: "plane-1", Location: &visibility.Geodetic{AltKm: 1200}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}},
+			{ID: "sat-2", Shell: "plane-2", Location: &visibility.Geodetic{AltKm: 1200, LonDeg: 1}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "west", Location: &visibility.Geodetic{LonDeg: -3}},
+			{ID: "east", Location: &visibility.Geodetic{LonDeg: 3}},
+		},
+		Traffic: []TrafficDemand{
+			{ID: "primary", FromID: "west", ToID: "east"},
+			{ID: "backup", FromID: "east", ToID: "west", Deferred: true},
+		},
+		Epoch: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Hooks: []Hook{
+			{Name: "outage", When: "t >= 600", Do: []HookAction{{Op: HookDisableShell, Target: "plane-2"}}},
+			{Name: "failover", When: "activeSatellites < 2", Once: true, Do: []HookAction{{Op: HookAddDemand, Target: "backup"}}},
+		},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap := sim.Snapshot(); len(snap.InactiveDemands) != 1 || snap.InactiveDemands[0] != "backup" {
+		t.Fatalf("expected the deferred demand to be inactive, got %v", snap.InactiveDemands)
+	}
+
+	snap, err := sim.Step(300 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.FiredHooks) != 0 || len(snap.ActiveSatellites) != 2 {
+		t.Fatalf("expected no hook before t=600, got %v with %v active", snap.FiredHooks, snap.ActiveSatellites)
+	}
+	snap, err = sim.Step(300 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.FiredHooks) != 1 || snap.FiredHooks[0] != "outage" || len(snap.ActiveSatellites) != 1 {
+		t.Fatalf("expected the outage to fire at t=600, got %v with %v active", snap.FiredHooks, snap.ActiveSatellites)
+	}
+	// The failover condition reads the snapshot of the step, so it sees the outage one step later.
+	snap, err = sim.Step(300 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.FiredHooks) != 1 || snap.FiredHooks[0] != "failover" || len(snap.InactiveDemands) != 0 {
+		t.Fatalf("expected the failover to add the backup demand, got %v with %v inactive", snap.FiredHooks, snap.InactiveDemands)
+	}
+	if _, ok := snap.Routes["backup"]; !ok {
+		t.Fatalf("expected the backup demand to be routed, got %v", snap.Routes)
+	}
+	// Both conditions keep holding, so neither fires again.
+	if snap, err = sim.Step(300 * time.Second); err != nil || len(snap.FiredHooks) != 0 {
+		t.Fatalf("expected no further firings, got %v (%v)", snap.FiredHooks, err)
+	}
+
+	replayed, err := NewSimulator(sim.Scenario())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayed.Replay(sim.Journal()); err != nil {
+		t.Fatal(err)
+	}
+	if got := replayed.Snapshot(); len(got.ActiveSatellites) != 1 || len(got.Routes) != 2 {
+		t.Fatalf("expected replay to fire the same hooks, got %v active and routes %v", got.ActiveSatellites, got.Routes)
+	}
+
+	for name, hooks := range map[string][]Hook{
+		"bad condition":   {{Name: "h", When: "t >", Do: []HookAction{{Op: HookAddDemand, Target: "backup"}}}},
+		"numeric":         {{Name: "h", When: "t + 1", Do: []HookAction{{Op: HookAddDemand, Target: "backup"}}}},
+		"unknown target":  {{Name: "h", When: "t > 1", Do: []HookAction{{Op: HookDisableSatellite, Target: "sat-9"}}}},
+		"unknown action":  {{Name: "h", When: "t > 1", Do: []HookAction{{Op: "launch", Target: "sat-1"}}}},
+		"no actions":      {{Name: "h", When: "t > 1"}},
+		"duplicate names": {{Name: "h", When: "t > 1", Do: []HookAction{{Op: HookAddDemand, Target: "backup"}}}, {Name: "h", When: "t > 2", Do: []HookAction{{Op: HookAddDemand, Target: "backup"}}}},
+	} {
+		bad := cfg
+		bad.Hooks = hooks
+		if _, err := NewSimulator(bad); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// Invariants checked by CheckInvariants.
+const (
+	// InvariantRouteEdges: every route starts at its demand's source, ends at its destination, and
+	// only uses links present in the routing graph.
+	InvariantRouteEdges = "route-edges"
+	// InvariantCoverageRange: coverage percentages lie in [0, 100] and covered cells never exceed
+	// the grid.
+	InvariantCoverageRange = "coverage-range"
+	// InvariantGraphSymmetry: every link is bidirectional, with the same latency both ways.
+	InvariantGraphSymmetry = "graph-symmetry"
+)
+
+// symmetryToleranceMS is how far the two directions of a link may differ in latency.
+const symmetryToleranceMS = 1e-9
+
+// ErrInvariantViolated is wrapped by recompute errors when Config.ValidateInvariants is set and
+// the new state breaks an invariant.
+var ErrInvariantViolated = errors.New("simulation invariant violated")
+
+// InvariantViolation describes one broken invariant.
+type InvariantViolation struct {
+	Invariant string `json:"invariant"`
+	Detail    string `json:"detail"`
+}
+
+func (v InvariantViolation) String() string {
+	return v.Invariant + ": " + v.Detail
+}
+
+// InvariantReport is the outcome of checking the latest recompute.
+type InvariantReport struct {
+	Version    uint64               `json:"version"`
+	Checked    []string             `json:"checked"`
+	Violations []InvariantViolation `json:"violations"`
+}
+
+// OK reports whether every invariant held.
+func (r InvariantReport) OK() bool {
+	return len(r.Violations) == 0
+}
+
+// InvariantError is returned by recomputes in validation mode. It matches ErrInvariantViolated.
+type InvariantError struct {
+	Version    uint64
+	Violations []InvariantViolation
+}
+
+func (e *InvariantError) Error() string {
+	details := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		details[i] = v.String()
+	}
+	return fmt.Sprintf("%v at version %d: %s", ErrInvariantViolated, e.Version, strings.Join(details, "; "))
+}
+
+func (e *InvariantError) Unwrap() error {
+	return ErrInvariantViolated
+}
+
+// CheckInvariants checks the latest recompute against the simulator's invariants.
+func (s *Simulator) CheckInvariants() InvariantReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return InvariantReport{
+		Version:    s.snapshot.Version,
+		Checked:    []string{InvariantRouteEdges, InvariantCoverageRange, InvariantGraphSymmetry},
+		Violations: CheckInvariants(s.graph, s.snapshot, s.traffic),
+	}
+}
+
+// CheckInvariants checks a snapshot against the graph it was routed on and the demands it routed,
+// returning the violations found in a stable order.
+func CheckInvariants(g *routing.Graph, snap Snapshot, traffic []TrafficDemand) []InvariantViolation {
+	var out []InvariantViolation
+	out = append(out, checkRouteEdges(g, snap.Routes, traffic)...)
+	out = append(out, checkCoverageRange(snap)...)
+	out = append(out, checkGraphSymmetry(g)...)
+	return out
+}
+
+func checkRouteEdges(g *routing.Graph, routes map[string]routing.Path, traffic []TrafficDemand) []InvariantViolation {
+	demands := make(map[string]TrafficDemand, len(traffic))
+	for _, demand := range traffic {
+		demands[demand.ID] = demand
+	}
+	ids := make([]string, 0, len(routes))
+	for id := range routes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var out []InvariantViolation
+	violate := func(format string, args ...any) {
+		out = append(out, InvariantViolation{Invariant: InvariantRouteEdges, Detail: fmt.Sprintf(format, args...)})
+	}
+	for _, id := range ids {
+		nodes := routes[id].Nodes
+		demand, ok := demands[id]
+		switch {
+		case !ok:
+			violate("route %q has no demand", id)
+			continue
+		case len(nodes) == 0:
+			violate("route %q is empty", id)
+			continue
+		case nodes[0] != demand.FromID:
+			violate("route %q starts at %q, not its source %q", id, nodes[0], demand.FromID)
+		case demand.ToID != "" && nodes[len(nodes)-1] != demand.ToID:
+			violate("route %q ends at %q, not its destination %q", id, nodes[len(nodes)-1], demand.ToID)
+		}
+		for i := 1; i < len(nodes); i++ {
+			if !hasEdge(g, nodes[i-1], nodes[i]) {
+				violate("route %q uses %s → %s, which is not in the graph", id, nodes[i-1], nodes[i])
+			}
+		}
+	}
+	return out
+}
+
+func checkCoverageRange(snap Snapshot) []InvariantViolation {
+	var out []InvariantViolation
+	check := func(subject string, pct float64) {
+		if math.IsNaN(pct) || pct < 0 || pct > 100 {
+			out = append(out, InvariantViolation{Invariant: InvariantCoverageRange, Detail: fmt.Sprintf("%s is %v%%", subject, pct)})
+		}
+	}
+	summary := snap.Coverage
+	check("coverage", summary.CoveragePercent)
+	if summary.CoveredCells < 0 || summary.CoveredCells > summary.TotalCells {
+		out = append(out, InvariantViolation{Invariant: InvariantCoverageRange, Detail: fmt.Sprintf("%d of %d cells covered", summary.CoveredCells, summary.TotalCells)})
+	}
+	shells := make([]string, 0, len(snap.Shells))
+	for name := range snap.Shells {
+		shells = append(shells, name)
+	}
+	sort.Strings(shells)
+	for _, name := range shells {
+		check(fmt.Sprintf("shell %q coverage", name), snap.Shells[name].CoveragePercent)
+		check(fmt.Sprintf("shell %q unique coverage", name), snap.Shells[name].UniquePercent)
+	}
+	return out
+}
+
+func checkGraphSymmetry(g *routing.Graph) []InvariantViolation {
+	if g == nil {
+		return nil
+	}
+	from := make([]string, 0, len(g.Adj))
+	for id := range g.Adj {
+		from = append(from, id)
+	}
+	sort.Strings(from)
+
+	var out []InvariantViolation
+	for _, id := range from {
+		for _, edge := range g.Adj[id] {
+			reverse, ok := graphEdge(g, edge.To, edge.From)
+			switch {
+			case !ok:
+				out = append(out, InvariantViolation{Invariant: InvariantGraphSymmetry, Detail: fmt.Sprintf("%s → %s has no reverse link", edge.From, edge.To)})
+			case math.Abs(reverse.LatencyMS-edge.LatencyMS) > symmetryToleranceMS && edge.From < edge.To:
+				out = append(out, InvariantViolation{Invariant: InvariantGraphSymmetry, Detail: fmt.Sprintf("%s ↔ %s latency differs: %v ms vs %v ms", edge.From, edge.To, edge.LatencyMS, reverse.LatencyMS)})
+			}
+		}
+	}
+	return out
+}
+
+func hasEdge(g *routing.Graph, from, to string) bool {
+	_, ok := graphEdge(g, from, to)
+	return ok
+}
+
+// graphEdge returns the link from → to of g.
+func graphEdge(g *routing.Graph, from, to string) (routing.Edge, bool) {
+	var found routing.Edge
+	ok := false
+	if g == nil {
+		return found, ok
+	}
+	g.EdgesFrom(from, func(e routing.Edge) bool {
+		if e.To == to {
+			found, ok = e, true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
@@ -0,0 +1,153 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// randomScenario is a scenario drawn for property tests: a mix of orbiting and stationary
+// satellites, gateways, and demands, with capacity and route continuity switched on at random.
+type randomScenario struct {
+	Config Config
+}
+
+func (randomScenario) Generate(rng *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(randomScenario{Config: scenarioFromSeed(rng.Int63())})
+}
+
+func scenarioFromSeed(seed int64) Config {
+	rng := rand.New(rand.NewSource(seed))
+	cfg := Config{
+		GridConfig:       coverage.GridConfig{LatStep: 30, LonStep: 30},
+		ElevationMaskDeg: rng.Float64() * 20,
+		Epoch:            time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		StabilityWeight:  rng.Float64() * 5,
+	}
+	if rng.Intn(2) == 0 {
+		cfg.LinkCapacityMbps = 10 + rng.Float64()*40
+	}
+	if rng.Intn(2) == 0 {
+		cfg.RouteContinuityPct = rng.Float64() * 20
+	}
+	for i, n := 0, 4+rng.Intn(20); i < n; i++ {
+		sat := Satellite{ID: fmt.Sprintf("sat-%d", i), Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}}
+		altitude := 400 + rng.Float64()*1600
+		if rng.Intn(2) == 0 {
+			sat.Orbit = &orbits.KeplerianElements{
+				SemiMajorAxis: visibility.EarthRadius + altitude,
+				Inclination:   rng.Float64() * math.Pi,
+				RAAN:          rng.Float64() * 2 * math.Pi,
+				MeanAnomaly:   rng.Float64() * 2 * math.Pi,
+			}
+		} else {
+			sat.Location = &visibility.Geodetic{LatDeg: rng.Float64()*180 - 90, LonDeg: rng.Float64()*360 - 180, AltKm: altitude}
+		}
+		cfg.Satellites = append(cfg.Satellites, sat)
+	}
+	for i, n := 0, 2+rng.Intn(5); i < n; i++ {
+		cfg.GroundStations = append(cfg.GroundStations, GroundStation{
+			ID:       fmt.Sprintf("gw-%d", i),
+			Location: &visibility.Geodetic{LatDeg: rng.Float64()*120 - 60, LonDeg: rng.Float64()*360 - 180},
+		})
+	}
+	for i, n := 0, 1+rng.Intn(6); i < n; i++ {
+		from := rng.Intn(len(cfg.GroundStations))
+		to := (from + 1 + rng.Intn(len(cfg.GroundStations)-1)) % len(cfg.GroundStations)
+		cfg.Traffic = append(cfg.Traffic, TrafficDemand{
+			ID:            fmt.Sprintf("demand-%d", i),
+			FromID:        cfg.GroundStations[from].ID,
+			ToID:          cfg.GroundStations[to].ID,
+			BandwidthMbps: rng.Float64() * 20,
+		})
+	}
+	cfg.ValidateInvariants = true
+	return cfg
+}
+
+// exerciseInvariants runs a validating simulator through steps and a satellite failure; any
+// recompute that breaks an invariant fails with an *InvariantError.
+func exerciseInvariants(cfg Config) error {
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := sim.Step(2 * time.Minute); err != nil {
+			return err
+		}
+	}
+	if _, err := sim.DisableSatellite(cfg.Satellites[0].ID); err != nil {
+		return err
+	}
+	if report := sim.CheckInvariants(); !report.OK() {
+		return &InvariantError{Version: report.Version, Violations: report.Violations}
+	}
+	return nil
+}
+
+func TestRecomputesHoldInvariants(t *testing.T) {
+	property := func(s randomScenario) bool {
+		if err := exerciseInvariants(s.Config); err != nil {
+			t.Log(err)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 40, Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func FuzzRecomputeInvariants(f *testing.F) {
+	for _, seed := range []int64{0, 1, 42, 2024} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		if err := exerciseInvariants(scenarioFromSeed(seed)); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+	})
+}
+
+func TestCheckInvariantsReportsViolations(t *testing.T) {
+	g := &routing.Graph{
+		Nodes: map[string]routing.Node{"a": {ID: "a"}, "s": {ID: "s"}, "b": {ID: "b"}},
+		Adj: map[string][]routing.Edge{
+			"a": {{From: "a", To: "s", LatencyMS: 2}},
+			"s": {{From: "s", To: "a", LatencyMS: 3}, {From: "s", To: "b", LatencyMS: 2}},
+		},
+	}
+	snap := Snapshot{
+		Coverage: coverage.Summary{TotalCells: 4, CoveredCells: 5, CoveragePercent: 125},
+		Routes: map[string]routing.Path{
+			"ok":     {Nodes: []string{"a", "s", "b"}},
+			"broken": {Nodes: []string{"a", "b"}},
+		},
+	}
+	traffic := []TrafficDemand{{ID: "ok", FromID: "a", ToID: "b"}, {ID: "broken", FromID: "a", ToID: "b"}}
+
+	counts := make(map[string]int)
+	for _, v := range CheckInvariants(g, snap, traffic) {
+		counts[v.Invariant]++
+	}
+	want := map[string]int{InvariantRouteEdges: 1, InvariantCoverageRange: 2, InvariantGraphSymmetry: 2}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("expected %v, got %v", want, counts)
+	}
+
+	err := error(&InvariantError{Violations: []InvariantViolation{{Invariant: InvariantRouteEdges, Detail: "x"}}})
+	if !errors.Is(err, ErrInvariantViolated) {
+		t.Fatalf("InvariantError should match ErrInvariantViolated: %v", err)
+	}
+}
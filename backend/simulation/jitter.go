@@ -0,0 +1,113 @@
+package simulation
+
+import (
+	"math"
+	"sort"
+)
+
+// JitterStats summarizes how a demand's latency varies between consecutive time steps. Deltas are
+// taken only between consecutive routed steps; an outage breaks the sequence. A handover is a
+// step on which the route's node sequence changed.
+type JitterStats struct {
+	Intervals   int     `json:"intervals"`
+	MeanDeltaMS float64 `json:"meanDeltaMs"`
+	P95DeltaMS  float64 `json:"p95DeltaMs"`
+	MaxDeltaMS  float64 `json:"maxDeltaMs"`
+	// InterarrivalJitterMS is the RFC 3550 smoothed estimate, J += (|D| - J) / 16, at the end of the run.
+	InterarrivalJitterMS float64 `json:"interarrivalJitterMs"`
+	Handovers            int     `json:"handovers"`
+	MeanHandoverStepMS   float64 `json:"meanHandoverStepMs"`
+	MaxHandoverStepMS    float64 `json:"maxHandoverStepMs"`
+	// Outages counts transitions from routed to unrouted.
+	Outages int `json:"outages"`
+}
+
+type jitterState struct {
+	routed    bool
+	latencyMS float64
+	nodes     []string
+	deltas    []float64
+	handovers []float64
+	smoothed  float64
+	outages   int
+}
+
+// jitterRecorder tracks per-demand latency deltas for time-stepped runs.
+type jitterRecorder struct {
+	demands map[string]*jitterState
+}
+
+func newJitterRecorder() *jitterRecorder {
+	return &jitterRecorder{demands: make(map[string]*jitterState)}
+}
+
+func (r *jitterRecorder) record(demandID string, nodes []string, latencyMS float64, routed bool) {
+	state, ok := r.demands[demandID]
+	if !ok {
+		state = &jitterState{}
+		r.demands[demandID] = state
+	}
+	if !routed {
+		if state.routed {
+			state.outages++
+		}
+		state.routed = false
+		return
+	}
+	if state.routed {
+		delta := math.Abs(latencyMS - state.latencyMS)
+		state.deltas = append(state.deltas, delta)
+		state.smoothed += (delta - state.smoothed) / 16
+		if !sameNodes(nodes, state.nodes) {
+			state.handovers = append(state.handovers, delta)
+		}
+	}
+	state.routed, state.latencyMS, state.nodes = true, latencyMS, nodes
+}
+
+func (r *jitterRecorder) summary() map[string]JitterStats {
+	out := make(map[string]JitterStats, len(r.demands))
+	for id, state := range r.demands {
+		stats := JitterStats{
+			Intervals:            len(state.deltas),
+			InterarrivalJitterMS: state.smoothed,
+			Handovers:            len(state.handovers),
+			Outages:              state.outages,
+		}
+		if len(state.deltas) > 0 {
+			sorted := append([]float64(nil), state.deltas...)
+			sort.Float64s(sorted)
+			stats.MeanDeltaMS = mean(sorted)
+			stats.P95DeltaMS = percentile(sorted, 95)
+			stats.MaxDeltaMS = sorted[len(sorted)-1]
+		}
+		if len(state.handovers) > 0 {
+			stats.MeanHandoverStepMS = mean(state.handovers)
+			for _, step := range state.handovers {
+				stats.MaxHandoverStepMS = math.Max(stats.MaxHandoverStepMS, step)
+			}
+		}
+		out[id] = stats
+	}
+	return out
+}
+
+func mean(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+func sameNodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
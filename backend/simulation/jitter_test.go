@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestJitterRecorderTracksHandoversAndOutages(t *testing.T) {
+	r := newJitterRecorder()
+	r.record("voip", []string{"a", "s1", "b"}, 10, true)
+	r.record("voip", []string{"a", "s1", "b"}, 12, true)
+	r.record("voip", []string{"a", "s2", "b"}, 20, true) // handover: +8 ms
+	r.record("voip", nil, 0, false)
+	r.record("voip", []string{"a", "s2", "b"}, 5, true) // first sample after the outage: no delta
+	r.record("voip", []string{"a", "s2", "b"}, 6, true)
+
+	stats := r.summary()["voip"]
+	if stats.Intervals != 3 || stats.Handovers != 1 || stats.Outages != 1 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+	if stats.MaxDeltaMS != 8 || stats.MeanHandoverStepMS != 8 || stats.MaxHandoverStepMS != 8 {
+		t.Fatalf("unexpected handover step: %+v", stats)
+	}
+	if math.Abs(stats.MeanDeltaMS-11.0/3) > 1e-12 {
+		t.Fatalf("mean delta %v, want %v", stats.MeanDeltaMS, 11.0/3)
+	}
+	// J = 2/16, then += (8-J)/16, then += (1-J)/16.
+	if math.Abs(stats.InterarrivalJitterMS-0.64111328125) > 1e-12 {
+		t.Fatalf("interarrival jitter %v, want 0.64111328125", stats.InterarrivalJitterMS)
+	}
+}
+
+func TestRunReportsHandoverJitter(t *testing.T) {
+	// Two satellites moving in opposite directions over the ground pair; the preferred relay changes
+	// as they pass, producing handovers.
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "east", Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: -800}, Velocity: visibility.Vector3{Y: 7.6}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+			{ID: "west", Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: 800}, Velocity: visibility.Vector3{Y: -7.6}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "ground-a", Position: visibility.Vector3{X: visibility.EarthRadius}},
+			{ID: "ground-b", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 20}},
+		},
+		Traffic: []TrafficDemand{{ID: "voip", FromID: "ground-a", ToID: "ground-b"}},
+		Epoch:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	summary, err := sim.Run(40, 5*time.Second)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	stats := summary.Jitter["voip"]
+	if stats.Intervals == 0 || stats.Handovers == 0 {
+		t.Fatalf("expected handovers as the satellites cross, got %+v", stats)
+	}
+	if stats.MaxHandoverStepMS > stats.MaxDeltaMS || stats.P95DeltaMS > stats.MaxDeltaMS {
+		t.Fatalf("inconsistent jitter summary: %+v", stats)
+	}
+	if got := sim.JitterSummary()["voip"]; got != stats {
+		t.Fatalf("JitterSummary = %+v, want %+v", got, stats)
+	}
+}
@@ -0,0 +1,111 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+// OperationType names a change applied to a simulator after construction.
+type OperationType string
+
+const (
+	OpStep             OperationType = "step"
+	OpRecompute        OperationType = "recompute"
+	OpDisableSatellite OperationType = "disable-satellite"
+	OpRemoveSatellite  OperationType = "remove-satellite"
+	OpEnableShell      OperationType = "enable-shell"
+	OpDisableShell     OperationType = "disable-shell"
+	// OpCommandDisableSatellite issues a disable command over the TT&C path; replayed steps
+	// deliver and execute it as they did originally.
+	OpCommandDisableSatellite OperationType = "command-disable-satellite"
+	// OpSetGrid changes the coverage grid's resolution to Grid.
+	OpSetGrid OperationType = "set-grid"
+)
+
+// Operation is one entry of a simulator's journal. Replaying the journal against the scenario the
+// simulator was built from reproduces its state.
+type Operation struct {
+	Op OperationType `json:"op"`
+	// DT is the step duration; Count folds consecutive steps of the same duration into one entry,
+	// so clock-driven sessions keep a short journal.
+	DT    time.Duration `json:"dt,omitempty"`
+	Count int           `json:"count,omitempty"`
+	// Target is the satellite or shell the operation applies to.
+	Target string               `json:"target,omitempty"`
+	Grid   *coverage.GridConfig `json:"grid,omitempty"`
+}
+
+// Scenario returns the configuration the simulator was built from, with the epoch resolved.
+func (s *Simulator) Scenario() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scenario
+}
+
+// Journal returns the operations applied since construction, oldest first.
+func (s *Simulator) Journal() []Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Operation(nil), s.journal...)
+}
+
+// Replay applies operations in order, as recorded by another simulator's Journal.
+func (s *Simulator) Replay(ops []Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case OpStep:
+			if op.Count < 1 {
+				err = errors.New("step count must be positive")
+			}
+			for n := 0; n < op.Count && err == nil; n++ {
+				_, err = s.stepLocked(op.DT)
+			}
+		case OpRecompute:
+			s.record(op)
+			_, err = s.recomputeLocked()
+		case OpDisableSatellite:
+			_, err = s.disableSatelliteLocked(op.Target)
+		case OpRemoveSatellite:
+			_, err = s.removeSatelliteLocked(op.Target)
+		case OpEnableShell, OpDisableShell:
+			_, err = s.setShellEnabledLocked(op.Target, op.Op == OpEnableShell)
+		case OpCommandDisableSatellite:
+			_, err = s.commandLocked(op.Target, CommandDisable)
+		case OpSetGrid:
+			if op.Grid == nil {
+				err = errors.New("set-grid needs a grid")
+			} else {
+				_, err = s.setGridLocked(*op.Grid)
+			}
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if err != nil {
+			return fmt.Errorf("operation %d (%s): %w", i+1, op.Op, err)
+		}
+	}
+	return nil
+}
+
+// record appends an applied operation to the journal, extending the previous entry when it
+// is a step of the same duration. Operations other than steps and recomputes bump the topology
+// version.
+func (s *Simulator) record(op Operation) {
+	if op.Op != OpStep && op.Op != OpRecompute {
+		s.topologyVersion++
+	}
+	if op.Op == OpStep {
+		if last := len(s.journal) - 1; last >= 0 && s.journal[last].Op == OpStep && s.journal[last].DT == op.DT {
+			s.journal[last].Count++
+			return
+		}
+		op.Count = 1
+	}
+	s.journal = append(s.journal, op)
+}
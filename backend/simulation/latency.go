@@ -0,0 +1,84 @@
+package simulation
+
+import (
+	"math"
+	"sort"
+)
+
+// LatencyStats summarizes the latency distribution observed for a demand across simulation steps.
+// Percentiles use the nearest-rank method over routed samples; UnroutedSteps counts ticks without a path.
+type LatencyStats struct {
+	Samples       int     `json:"samples"`
+	UnroutedSteps int     `json:"unroutedSteps"`
+	MeanMS        float64 `json:"meanMs"`
+	P50MS         float64 `json:"p50Ms"`
+	P95MS         float64 `json:"p95Ms"`
+	P99MS         float64 `json:"p99Ms"`
+	MaxMS         float64 `json:"maxMs"`
+}
+
+// latencyRecorder accumulates per-demand latency samples for time-stepped runs.
+type latencyRecorder struct {
+	samples  map[string][]float64
+	unrouted map[string]int
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{samples: make(map[string][]float64), unrouted: make(map[string]int)}
+}
+
+func (r *latencyRecorder) record(demandID string, latencyMS float64, routed bool) {
+	if !routed {
+		r.unrouted[demandID]++
+		return
+	}
+	r.samples[demandID] = append(r.samples[demandID], latencyMS)
+}
+
+func (r *latencyRecorder) summary() map[string]LatencyStats {
+	out := make(map[string]LatencyStats, len(r.samples)+len(r.unrouted))
+	for id, samples := range r.samples {
+		out[id] = summarizeLatencies(samples)
+	}
+	for id, count := range r.unrouted {
+		stats := out[id]
+		stats.UnroutedSteps = count
+		out[id] = stats
+	}
+	return out
+}
+
+func summarizeLatencies(samples []float64) LatencyStats {
+	if len(samples) == 0 {
+		return LatencyStats{}
+	}
+	sorted := make([]float64, len(samples))
+	copy(sorted, samples)
+	sort.Float64s(sorted)
+
+	total := 0.0
+	for _, v := range sorted {
+		total += v
+	}
+
+	return LatencyStats{
+		Samples: len(sorted),
+		MeanMS:  total / float64(len(sorted)),
+		P50MS:   percentile(sorted, 50),
+		P95MS:   percentile(sorted, 95),
+		P99MS:   percentile(sorted, 99),
+		MaxMS:   sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,66 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestPercentileNearestRank(t *testing.T) {
+	samples := make([]float64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, float64(i))
+	}
+
+	stats := summarizeLatencies(samples)
+	if stats.Samples != 100 || stats.P50MS != 50 || stats.P95MS != 95 || stats.P99MS != 99 || stats.MaxMS != 100 {
+		t.Fatalf("unexpected percentile summary: %+v", stats)
+	}
+	if stats.MeanMS != 50.5 {
+		t.Fatalf("expected mean 50.5, got %.2f", stats.MeanMS)
+	}
+}
+
+func TestRunAccumulatesLatencyDistribution(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "mover", Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: 0, Z: 0}, Velocity: visibility.Vector3{X: 0, Y: 7.6, Z: 0}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "ground-a", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 0, Z: 0}},
+			{ID: "ground-b", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 20, Z: 0}},
+		},
+		Traffic: []TrafficDemand{{ID: "demand", FromID: "ground-a", ToID: "ground-b"}},
+		Epoch:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+
+	summary, err := sim.Run(60, 10*time.Second)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !summary.End.Equal(cfg.Epoch.Add(10 * time.Minute)) {
+		t.Fatalf("unexpected run end %v", summary.End)
+	}
+
+	stats := summary.Latency["demand"]
+	if stats.Samples+stats.UnroutedSteps != 60 {
+		t.Fatalf("expected one observation per step, got %+v", stats)
+	}
+	if stats.Samples == 0 || stats.UnroutedSteps == 0 {
+		t.Fatalf("expected the satellite to rise and set during the run, got %+v", stats)
+	}
+	if !(stats.P50MS <= stats.P95MS && stats.P95MS <= stats.P99MS && stats.P99MS <= stats.MaxMS) {
+		t.Fatalf("percentiles must be ordered: %+v", stats)
+	}
+	if stats.MaxMS <= stats.P50MS {
+		t.Fatalf("expected a latency tail as the satellite moves away, got %+v", stats)
+	}
+}
@@ -0,0 +1,104 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"unsafe"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+)
+
+// Limits caps the resources a single simulation session may consume. Zero disables a limit.
+type Limits struct {
+	MaxSatellites  int           `json:"maxSatellites"`
+	MaxGridCells   int           `json:"maxGridCells"`
+	MinStepPeriod  time.Duration `json:"minStepPeriod"` // minimum wall-clock time between steps
+	MaxMemoryBytes int64         `json:"maxMemoryBytes"`
+}
+
+// LimitError reports which limit a configuration exceeds.
+type LimitError struct {
+	Field string
+	Limit float64
+	Value float64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s %.0f exceeds the session limit of %.0f", e.Field, e.Value, e.Limit)
+}
+
+// CheckLimits validates a configuration against the limits before any state is allocated.
+func (l Limits) CheckLimits(cfg Config) error {
+	if l.MaxSatellites > 0 && len(cfg.Satellites) > l.MaxSatellites {
+		return &LimitError{Field: "satellites", Limit: float64(l.MaxSatellites), Value: float64(len(cfg.Satellites))}
+	}
+	cells := gridCells(cfg.GridConfig) + regionCells(cfg.CoverageRegions)
+	if l.MaxGridCells > 0 && cells > float64(l.MaxGridCells) {
+		return &LimitError{Field: "grid", Limit: float64(l.MaxGridCells), Value: cells}
+	}
+	if l.MaxMemoryBytes > 0 {
+		if estimate := EstimateMemory(cfg); estimate > l.MaxMemoryBytes {
+			return &LimitError{Field: "memoryBytes", Limit: float64(l.MaxMemoryBytes), Value: float64(estimate)}
+		}
+	}
+	return nil
+}
+
+// GridCellCount returns how many cells NewCoverageGrid will allocate for the configuration, or
+// math.MaxInt for steps too fine to count.
+func GridCellCount(grid coverage.GridConfig) int {
+	cells := gridCells(grid)
+	if cells >= math.MaxInt {
+		return math.MaxInt
+	}
+	return int(cells)
+}
+
+// gridCells counts the cells of grid in floating point, so that steps too fine to allocate cannot
+// overflow the count before it is checked against a limit.
+func gridCells(grid coverage.GridConfig) float64 {
+	if !(grid.LatStep > 0 && grid.LonStep > 0) {
+		return 0
+	}
+	return math.Ceil(180/grid.LatStep) * math.Ceil(360/grid.LonStep)
+}
+
+// regionCells counts the cells the fine grids of regions allocate together, like gridCells.
+func regionCells(regions []coverage.Region) float64 {
+	cells := 0.0
+	for _, r := range regions {
+		if r.LatStep > 0 && r.LonStep > 0 {
+			cells += math.Ceil((r.MaxLat-r.MinLat)/r.LatStep) * math.Ceil((r.MaxLon-r.MinLon)/r.LonStep)
+		}
+	}
+	return cells
+}
+
+// EstimateMemory approximates the steady-state bytes a simulator needs for the configuration:
+// the coverage grid, regional grids and heatmap, a worst-case fully connected routing graph, and the snapshot
+// history assuming a keyframe every keyframeInterval entries and deltas touching a quarter of the cells.
+func EstimateMemory(cfg Config) int64 {
+	cells := gridCells(cfg.GridConfig)
+	nodes := float64(len(cfg.Satellites) + len(cfg.GroundStations))
+
+	cellBytes := float64(unsafe.Sizeof(coverage.Cell{})+unsafe.Sizeof(coverage.HeatmapCell{})) + float64(cfg.GridConfig.CellVectorBytes())
+	if cfg.StreamHeatmap {
+		// The grid is streamed rather than copied into a heatmap, and history keeps no heatmaps.
+		cellBytes -= float64(unsafe.Sizeof(coverage.HeatmapCell{}))
+	}
+	gridBytes := (cells + regionCells(cfg.CoverageRegions)) * cellBytes
+	edgeBytes := nodes * nodes * float64(unsafe.Sizeof(routing.Edge{}))
+	nodeBytes := nodes * float64(unsafe.Sizeof(routing.Node{})+unsafe.Sizeof(Satellite{}))
+	keyframes := float64(cfg.HistorySize/keyframeInterval + 1)
+	historyBytes := math.Floor(float64(cfg.HistorySize)*cells/4)*float64(unsafe.Sizeof(cellDelta{})) + keyframes*cells*8
+	if cfg.HistorySize == 0 || cfg.StreamHeatmap {
+		historyBytes = 0
+	}
+	// Counted in floating point like the cells, saturating rather than overflowing for huge grids.
+	if total := gridBytes + edgeBytes + nodeBytes + historyBytes; total < math.MaxInt64 {
+		return int64(total)
+	}
+	return math.MaxInt64
+}
@@ -0,0 +1,41 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+func TestCheckLimits(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 1, LonStep: 1},
+		Satellites: make([]Satellite, 10),
+	}
+
+	if got := GridCellCount(cfg.GridConfig); got != 180*360 {
+		t.Fatalf("expected %d cells, got %d", 180*360, got)
+	}
+	if err := (Limits{}).CheckLimits(cfg); err != nil {
+		t.Fatalf("zero limits should allow anything, got %v", err)
+	}
+
+	var limitErr *LimitError
+	if err := (Limits{MaxSatellites: 5}).CheckLimits(cfg); !errors.As(err, &limitErr) || limitErr.Field != "satellites" {
+		t.Fatalf("expected satellite limit error, got %v", err)
+	}
+	if err := (Limits{MaxGridCells: 1000}).CheckLimits(cfg); !errors.As(err, &limitErr) || limitErr.Field != "grid" {
+		t.Fatalf("expected grid limit error, got %v", err)
+	}
+	// A step too fine to allocate must exceed the limit rather than overflow the count.
+	fine := Config{GridConfig: coverage.GridConfig{LatStep: 1e-300, LonStep: 1}}
+	if err := (Limits{MaxGridCells: 1000}).CheckLimits(fine); !errors.As(err, &limitErr) || limitErr.Field != "grid" {
+		t.Fatalf("expected grid limit error for a tiny step, got %v", err)
+	}
+	if err := (Limits{MaxMemoryBytes: 1 << 30}).CheckLimits(fine); !errors.As(err, &limitErr) || limitErr.Field != "memoryBytes" {
+		t.Fatalf("expected memory limit error for a tiny step, got %v", err)
+	}
+	if err := (Limits{MaxMemoryBytes: EstimateMemory(cfg) - 1}).CheckLimits(cfg); !errors.As(err, &limitErr) || limitErr.Field != "memoryBytes" {
+		t.Fatalf("expected memory limit error, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// VisibilityCheck explains whether two nodes can link at the latest recompute: the geometry of
+// the line of sight, whether the routing graph holds the link, and, for ground-to-satellite pairs,
+// whether the ground node lies in the satellite's antenna footprint.
+type VisibilityCheck struct {
+	Version uint64       `json:"version"`
+	From    LinkEndpoint `json:"from"`
+	To      LinkEndpoint `json:"to"`
+	routing.LinkCheck
+	// InGraph reports whether the routing graph of the latest recompute holds the link.
+	InGraph bool `json:"inGraph"`
+	// Antenna is set for ground-to-satellite pairs.
+	Antenna *AntennaCheck `json:"antenna,omitempty"`
+}
+
+// LinkEndpoint is one node of a VisibilityCheck. Inactive satellites are out of the routing graph;
+// Detail says why.
+type LinkEndpoint struct {
+	ID       string              `json:"id"`
+	Type     routing.NodeType    `json:"type"`
+	Location visibility.Geodetic `json:"location"`
+	Active   bool                `json:"active"`
+	Detail   string              `json:"detail,omitempty"`
+}
+
+// AntennaCheck reports whether a ground node falls inside a satellite's coverage footprint, the
+// area its antenna serves. Footprints shape coverage only; links do not depend on them.
+type AntennaCheck struct {
+	Satellite   string `json:"satellite"`
+	InFootprint bool   `json:"inFootprint"`
+}
+
+// CheckVisibility explains whether the nodes with the given IDs, satellites or ground stations,
+// can link. It returns an error wrapping routing.ErrUnknownNode for IDs the simulation lacks.
+func (s *Simulator) CheckVisibility(fromID, toID string) (VisibilityCheck, error) {
+	if fromID == toID {
+		return VisibilityCheck{}, errors.New("from and to must name different nodes")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, err := s.linkEndpointLocked(fromID)
+	if err != nil {
+		return VisibilityCheck{}, err
+	}
+	to, err := s.linkEndpointLocked(toID)
+	if err != nil {
+		return VisibilityCheck{}, err
+	}
+	a, _ := s.routingNodeLocked(fromID)
+	b, _ := s.routingNodeLocked(toID)
+
+	check := VisibilityCheck{Version: s.version, From: from, To: to, LinkCheck: routing.CheckLink(a, b, s.elevationMask)}
+	_, check.InGraph = s.edgeLocked(fromID, toID)
+
+	ground, sat := a, b
+	if a.Type == routing.Satellite {
+		ground, sat = b, a
+	}
+	if ground.Type == routing.Ground && sat.Type == routing.Satellite {
+		location := geodetic(ground.Position, s.scenario.WGS84)
+		footprint := s.satellites[sat.ID].coverageFootprint(s.elevationMask)
+		check.Antenna = &AntennaCheck{Satellite: sat.ID, InFootprint: footprint.Contains(location.LatDeg, location.LonDeg)}
+	}
+	return check, nil
+}
+
+func (s *Simulator) linkEndpointLocked(id string) (LinkEndpoint, error) {
+	if sat, ok := s.satellites[id]; ok {
+		reason := s.disabledReasonLocked(sat)
+		return LinkEndpoint{ID: id, Type: routing.Satellite, Location: sat.subPoint(), Active: reason == "", Detail: reason}, nil
+	}
+	if gs, ok := s.ground[id]; ok {
+		return LinkEndpoint{ID: id, Type: routing.Ground, Location: geodetic(gs.Position, s.scenario.WGS84), Active: true}, nil
+	}
+	return LinkEndpoint{}, fmt.Errorf("%w %q", routing.ErrUnknownNode, id)
+}
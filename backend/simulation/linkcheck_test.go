@@ -0,0 +1,46 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+func TestCheckVisibilityExplainsLinks(t *testing.T) {
+	sim, err := NewSimulator(explainConfig())
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if _, err := sim.DisableSatellite("spare"); err != nil {
+		t.Fatal(err)
+	}
+
+	up, err := sim.CheckVisibility("a", "near")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !up.Visible || !up.InGraph || up.Antenna == nil || up.Antenna.Satellite != "near" || up.ElevationDeg == nil {
+		t.Fatalf("expected a visible uplink in the graph, got %+v", up)
+	}
+
+	blocked, err := sim.CheckVisibility("near", "antipode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocked.Visible || blocked.InGraph || !blocked.EarthBlocked || blocked.LimbClearanceKm >= 0 || blocked.Antenna != nil {
+		t.Fatalf("expected the Earth to block the cross link, got %+v", blocked)
+	}
+
+	disabled, err := sim.CheckVisibility("a", "spare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !disabled.Visible || disabled.InGraph || disabled.To.Active || disabled.To.Detail == "" {
+		t.Fatalf("expected a visible link to a disabled satellite outside the graph, got %+v", disabled)
+	}
+
+	if _, err := sim.CheckVisibility("a", "nowhere"); !errors.Is(err, routing.ErrUnknownNode) {
+		t.Fatalf("expected ErrUnknownNode, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package simulation
+
+import "github.com/example/satnet/backend/routing"
+
+// validateLinkMetadata rejects metadata for link types the simulator does not know.
+func validateLinkMetadata(metadata map[routing.LinkType]map[string]string) error {
+	for linkType := range metadata {
+		if _, err := routing.ParseLinkType(string(linkType)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotateLinks attaches the metadata configured for each edge's link type to every edge of graph.
+// Edges of one type share the configured map.
+func annotateLinks(graph *routing.Graph, metadata map[routing.LinkType]map[string]string) {
+	if len(metadata) == 0 {
+		return
+	}
+	for _, edges := range graph.Adj {
+		for i := range edges {
+			edges[i].Metadata = metadata[edges[i].Type]
+		}
+	}
+}
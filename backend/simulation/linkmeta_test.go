@@ -0,0 +1,48 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+func TestLinksCarryTheirTypeAndMetadata(t *testing.T) {
+	cfg := locationSourcedConfig()
+	cfg.LinkMetadata = map[routing.LinkType]map[string]string{
+		routing.LinkFeeder: {"band": "ka"},
+		routing.LinkUser:   {"band": "ku"},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+
+	types := make(map[routing.LinkType]int)
+	for _, link := range sim.Weathermap().Links {
+		types[link.Type]++
+		want := cfg.LinkMetadata[link.Type]["band"]
+		if link.Metadata["band"] != want {
+			t.Fatalf("expected %s link %s->%s to carry band %q, got %v", link.Type, link.From, link.To, want, link.Metadata)
+		}
+	}
+	if types[routing.LinkISL] == 0 || types[routing.LinkFeeder] == 0 || types[routing.LinkUser] != 2 || types[routing.LinkTerrestrial] != 0 {
+		t.Fatalf("expected ISL, feeder and both directions of one user link, got %v", types)
+	}
+	if users := sim.Weathermap().OfTypes(routing.LinkUser).Links; len(users) != 2 || users[0].Type != routing.LinkUser {
+		t.Fatalf("expected the user links alone, got %+v", users)
+	}
+
+	trace, err := sim.Traceroute(TerminalID("user"), "gateway")
+	if err != nil {
+		t.Fatalf("traceroute failed: %v", err)
+	}
+	last := len(trace.Hops) - 1
+	if !trace.Reached || trace.Hops[0].LinkType != routing.LinkUser || trace.Hops[last].LinkType != routing.LinkFeeder {
+		t.Fatalf("expected the route to leave on a user link and land on a feeder link, got %+v", trace)
+	}
+
+	cfg.LinkMetadata = map[routing.LinkType]map[string]string{"laser": {"band": "optical"}}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected metadata for an unknown link type to be rejected")
+	}
+}
@@ -0,0 +1,52 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// ISL modes accepted by Config.ISLs.
+const (
+	// ISLsAll links every pair of satellites in line of sight, the default.
+	ISLsAll = "all"
+	// ISLsNone models a bent-pipe constellation: satellites only relay between the ground nodes
+	// they see.
+	ISLsNone = "none"
+)
+
+// linkSettings translates the scenario's link settings into the routing settings the graph is
+// built with, in process or on shard workers. It also returns the mask user terminals link at.
+func linkSettings(cfg Config, elevationMask float64) (routing.LinkSettings, float64, error) {
+	links := routing.LinkSettings{ElevationMask: elevationMask, Bands: cfg.Bands}
+	terminalMask := elevationMask
+	if cfg.GatewayMaskDeg != nil {
+		mask, err := elevationMaskRadians("scenario gateway", *cfg.GatewayMaskDeg, 0)
+		if err != nil {
+			return routing.LinkSettings{}, 0, err
+		}
+		links.GatewayMask = &mask
+	}
+	if cfg.TerminalMaskDeg != nil {
+		mask, err := elevationMaskRadians("scenario terminal", *cfg.TerminalMaskDeg, 0)
+		if err != nil {
+			return routing.LinkSettings{}, 0, err
+		}
+		links.TerminalMask = &mask
+		terminalMask = mask
+	}
+	if math.IsNaN(cfg.MaxISLRangeKm) || math.IsInf(cfg.MaxISLRangeKm, 0) || cfg.MaxISLRangeKm < 0 {
+		return routing.LinkSettings{}, 0, errors.New("maxIslRangeKm must be a finite, non-negative distance")
+	}
+	links.MaxISLRangeKm = cfg.MaxISLRangeKm
+	switch cfg.ISLs {
+	case "", ISLsAll:
+	case ISLsNone:
+		links.NoISLs = true
+	default:
+		return routing.LinkSettings{}, 0, fmt.Errorf("unknown isls mode %q; use %q or %q", cfg.ISLs, ISLsAll, ISLsNone)
+	}
+	return links, terminalMask, nil
+}
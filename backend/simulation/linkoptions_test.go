@@ -0,0 +1,133 @@
+package simulation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/shard"
+)
+
+func TestScenarioLinkOptionsShapeTheGraph(t *testing.T) {
+	routed := func(cfg Config) bool {
+		t.Helper()
+		sim, err := NewSimulator(cfg)
+		if err != nil {
+			t.Fatalf("failed to build simulator: %v", err)
+		}
+		_, ok := sim.Snapshot().Routes["user"]
+		return ok
+	}
+	deg := func(v float64) *float64 { return &v }
+	// A 10° mask hides overhead from the gateway, so the user demand must cross the overhead-east
+	// ISL down to the gateway below east.
+	base := func() Config {
+		cfg := locationSourcedConfig()
+		cfg.ElevationMaskDeg = 10
+		return cfg
+	}
+
+	if !routed(base()) {
+		t.Fatal("expected the demand to route by default")
+	}
+	cfg := base()
+	cfg.ISLs = ISLsNone
+	if routed(cfg) {
+		t.Fatal("expected a bent-pipe scenario to leave the demand unrouted")
+	}
+	cfg = base()
+	cfg.MaxISLRangeKm = 100
+	if routed(cfg) {
+		t.Fatal("expected a short ISL range to drop the overhead-east link")
+	}
+	cfg = base()
+	cfg.Bands = []string{"Ka", "Ku"}
+	cfg.Satellites[0].Bands, cfg.Satellites[1].Bands = []string{"Ka"}, []string{"Ku"}
+	if routed(cfg) {
+		t.Fatal("expected satellites on different bands not to link")
+	}
+
+	// The gateway sees east straight overhead, but the terminal sees its satellite lower.
+	cfg = base()
+	cfg.GatewayMaskDeg = deg(89)
+	if !routed(cfg) {
+		t.Fatal("expected the gateway mask to spare the zenith gateway link and the terminal")
+	}
+	cfg = base()
+	cfg.TerminalMaskDeg = deg(89)
+	if routed(cfg) {
+		t.Fatal("expected the terminal mask to leave the terminal without a serving satellite")
+	}
+
+	cfg = base()
+	cfg.ISLs = "mesh"
+	if _, err := NewSimulator(cfg); err == nil || !strings.Contains(err.Error(), "isls") {
+		t.Fatalf("expected an unknown ISL mode to be rejected, got %v", err)
+	}
+	cfg = base()
+	cfg.GatewayMaskDeg = deg(95)
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a gateway mask above 90° to be rejected")
+	}
+}
+
+func TestShardedRecomputesMatchLocal(t *testing.T) {
+	deg := func(v float64) *float64 { return &v }
+	// Each scenario but the schedule changes the routes from those of the 10° mask alone.
+	scenarios := map[string]func(*Config){
+		"masks": func(cfg *Config) {
+			cfg.GatewayMaskDeg, cfg.TerminalMaskDeg = deg(0), deg(5)
+		},
+		"isl-range": func(cfg *Config) { cfg.MaxISLRangeKm = 100 },
+		"bands": func(cfg *Config) {
+			cfg.Bands = []string{"Ka", "Ku"}
+			cfg.Satellites[0].Bands, cfg.Satellites[1].Bands = []string{"Ka"}, []string{"Ku"}
+		},
+		"bent-pipe": func(cfg *Config) { cfg.ISLs = ISLsNone },
+		"schedule":  func(cfg *Config) { cfg.VisibilityHorizonS = 600 },
+	}
+	for name, apply := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			run := func(sharded bool) []Snapshot {
+				t.Helper()
+				cfg := locationSourcedConfig()
+				cfg.ElevationMaskDeg = 10
+				apply(&cfg)
+				if sharded {
+					coordinator, err := shard.NewCoordinator(shard.Local{}, shard.Local{})
+					if err != nil {
+						t.Fatal(err)
+					}
+					cfg.Sharder = coordinator
+				}
+				sim, err := NewSimulator(cfg)
+				if err != nil {
+					t.Fatalf("failed to build simulator: %v", err)
+				}
+				snapshots := []Snapshot{sim.Snapshot()}
+				for i := 0; i < 3; i++ {
+					snapshot, err := sim.Step(2 * time.Minute)
+					if err != nil {
+						t.Fatal(err)
+					}
+					snapshots = append(snapshots, snapshot)
+				}
+				return snapshots
+			}
+			local, sharded := run(false), run(true)
+			for i := range local {
+				want, got := local[i], sharded[i]
+				if !reflect.DeepEqual(want.Routes, got.Routes) {
+					t.Fatalf("step %d: sharded routes %+v differ from local %+v", i, got.Routes, want.Routes)
+				}
+				if !reflect.DeepEqual(want.ServingSatellites, got.ServingSatellites) {
+					t.Fatalf("step %d: sharded serving satellites %v differ from local %v", i, got.ServingSatellites, want.ServingSatellites)
+				}
+				if !reflect.DeepEqual(want.Coverage, got.Coverage) || !reflect.DeepEqual(want.Heatmap, got.Heatmap) {
+					t.Fatalf("step %d: sharded coverage %+v differs from local %+v", i, got.Coverage, want.Coverage)
+				}
+			}
+		})
+	}
+}
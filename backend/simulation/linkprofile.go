@@ -0,0 +1,111 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// LinkDirection selects one direction of the links between the ground and space.
+type LinkDirection string
+
+const (
+	// DirectionBoth applies a profile to both directions of a link.
+	DirectionBoth LinkDirection = ""
+	// DirectionUp is the direction from a ground node to a satellite.
+	DirectionUp LinkDirection = "up"
+	// DirectionDown is the direction from a satellite to a ground node.
+	DirectionDown LinkDirection = "down"
+)
+
+// LinkProfile sets the characteristics of one direction of every link of a type, for networks
+// whose uplinks and downlinks differ, such as a gateway's wide feeder downlink against its narrower
+// uplink. A profile for one direction takes precedence over one for both.
+type LinkProfile struct {
+	Type routing.LinkType `json:"type"`
+	// Direction is DirectionUp or DirectionDown for feeder and user links, or empty for both.
+	Direction LinkDirection `json:"direction,omitempty"`
+	// CapacityMbps replaces LinkCapacityMbps on these link directions; zero keeps it.
+	CapacityMbps float64 `json:"capacityMbps,omitempty"`
+	// ExtraLatencyMS adds processing or queuing delay, such as a gateway's modem, to the propagation
+	// delay of these link directions.
+	ExtraLatencyMS float64 `json:"extraLatencyMs,omitempty"`
+}
+
+// validateLinkProfiles rejects profiles for unknown link types or directions, duplicates, and
+// capacities on a scenario that does not model capacity.
+func validateLinkProfiles(profiles []LinkProfile, linkCapacity float64) error {
+	seen := make(map[LinkProfile]bool, len(profiles))
+	for _, p := range profiles {
+		if _, err := routing.ParseLinkType(string(p.Type)); err != nil {
+			return err
+		}
+		switch p.Direction {
+		case DirectionBoth:
+		case DirectionUp, DirectionDown:
+			if p.Type != routing.LinkFeeder && p.Type != routing.LinkUser {
+				return fmt.Errorf("%s links have no %s direction", p.Type, p.Direction)
+			}
+		default:
+			return fmt.Errorf("unknown link direction %q", p.Direction)
+		}
+		if p.CapacityMbps < 0 || p.ExtraLatencyMS < 0 {
+			return fmt.Errorf("%s link profile values cannot be negative", p.Type)
+		}
+		if p.CapacityMbps > 0 && linkCapacity <= 0 {
+			return errors.New("link profile capacities require linkCapacityMbps")
+		}
+		key := LinkProfile{Type: p.Type, Direction: p.Direction}
+		if seen[key] {
+			return fmt.Errorf("duplicate %s link profile for direction %q", p.Type, p.Direction)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// applyLinkProfiles adds each profile's latency to the matching edges of graph and sets their
+// capacity, returning the capacity of every link direction a profile sets.
+func applyLinkProfiles(graph *routing.Graph, profiles []LinkProfile) map[linkKey]float64 {
+	if len(profiles) == 0 {
+		return nil
+	}
+	byKey := make(map[LinkProfile]LinkProfile, len(profiles))
+	for _, p := range profiles {
+		byKey[LinkProfile{Type: p.Type, Direction: p.Direction}] = p
+	}
+	directed := make(map[linkKey]float64)
+	for from, edges := range graph.Adj {
+		for i := range edges {
+			e := &edges[i]
+			direction := DirectionBoth
+			if e.Type == routing.LinkFeeder || e.Type == routing.LinkUser {
+				direction = DirectionDown
+				if graph.Nodes[from].Type != routing.Satellite {
+					direction = DirectionUp
+				}
+			}
+			p, ok := byKey[LinkProfile{Type: e.Type, Direction: direction}]
+			if !ok {
+				if p, ok = byKey[LinkProfile{Type: e.Type}]; !ok {
+					continue
+				}
+			}
+			e.LatencyMS += p.ExtraLatencyMS
+			if p.CapacityMbps > 0 {
+				e.CapacityMbps = p.CapacityMbps
+				directed[linkKey{e.From, e.To}] = p.CapacityMbps
+			}
+		}
+	}
+	return directed
+}
+
+// capacityOfLocked returns the capacity of one link direction in the latest recompute.
+func (s *Simulator) capacityOfLocked(key linkKey) float64 {
+	if mbps, ok := s.directedCapacity[key]; ok {
+		return mbps
+	}
+	return s.linkCapacity
+}
@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+func TestLinkProfilesMakeFeederLinksAsymmetric(t *testing.T) {
+	cfg := locationSourcedConfig()
+	cfg.LinkCapacityMbps = 100
+	cfg.Traffic[0].BandwidthMbps = 30
+	cfg.Traffic = append(cfg.Traffic, TrafficDemand{ID: "uplink", FromID: "gateway", ToID: "east", BandwidthMbps: 30})
+	cfg.LinkProfiles = []LinkProfile{
+		{Type: routing.LinkFeeder, ExtraLatencyMS: 5},
+		{Type: routing.LinkFeeder, Direction: DirectionUp, CapacityMbps: 20, ExtraLatencyMS: 8},
+		{Type: routing.LinkFeeder, Direction: DirectionDown, CapacityMbps: 200},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+
+	snapshot := sim.Snapshot()
+	if alloc := snapshot.Allocations["user"]; alloc.Status != StatusAdmitted {
+		t.Fatalf("expected the demand to fit the 200 Mbps downlink, got %+v", alloc)
+	}
+	if alloc := snapshot.Allocations["uplink"]; alloc.Status != StatusBlocked {
+		t.Fatalf("expected the demand not to fit the 20 Mbps uplink, got %+v", alloc)
+	}
+
+	capacity := make(map[LinkID]float64)
+	for _, link := range sim.Weathermap().Links {
+		capacity[LinkID{From: link.From, To: link.To}] = link.CapacityMbps
+	}
+	up, down := LinkID{From: "gateway", To: "east"}, LinkID{From: "east", To: "gateway"}
+	if capacity[up] != 20 || capacity[down] != 200 || capacity[LinkID{From: "overhead", To: "east"}] != 100 {
+		t.Fatalf("expected 20 Mbps up, 200 Mbps down and 100 Mbps elsewhere, got %v", capacity)
+	}
+
+	sim.mu.Lock()
+	upEdge, _ := graphEdge(sim.graph, up.From, up.To)
+	downEdge, _ := graphEdge(sim.graph, down.From, down.To)
+	sim.mu.Unlock()
+	// The downlink's own profile replaces the one for both directions, extra latency included.
+	if math.Abs(upEdge.LatencyMS-downEdge.LatencyMS-8) > 1e-9 {
+		t.Fatalf("expected the uplink alone to add 8 ms, got %v and %v", upEdge.LatencyMS, downEdge.LatencyMS)
+	}
+}
+
+func TestLinkProfilesAreValidated(t *testing.T) {
+	for name, profiles := range map[string][]LinkProfile{
+		"unknown type":        {{Type: "laser"}},
+		"unknown direction":   {{Type: routing.LinkUser, Direction: "sideways"}},
+		"isl direction":       {{Type: routing.LinkISL, Direction: DirectionUp}},
+		"negative capacity":   {{Type: routing.LinkUser, CapacityMbps: -1}},
+		"negative latency":    {{Type: routing.LinkUser, ExtraLatencyMS: -1}},
+		"duplicate":           {{Type: routing.LinkUser, Direction: DirectionDown}, {Type: routing.LinkUser, Direction: DirectionDown, CapacityMbps: 5}},
+		"uncapacitated model": {{Type: routing.LinkISL, CapacityMbps: 5}},
+	} {
+		cfg := locationSourcedConfig()
+		cfg.LinkProfiles = profiles
+		if name != "uncapacitated model" {
+			cfg.LinkCapacityMbps = 100
+		}
+		if _, err := NewSimulator(cfg); err == nil {
+			t.Fatalf("%s: expected the profiles to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// OEMOriginator is the ORIGINATOR of the messages OEM exports.
+const OEMOriginator = "SATNET"
+
+// OEM exports the trajectories of the satellites with the given IDs, or of every satellite when
+// ids is empty, as an Orbit Ephemeris Message: one segment per satellite, in ID order, sampled every
+// step for span from the current simulation time. States are the inertial ones the satellites are
+// propagated in, before any Earth rotation, so satellites with a tle are written in TEME and the
+// others in EME2000. The simulator itself is not advanced. Unknown IDs are reported as
+// routing.ErrUnknownNode.
+func (s *Simulator) OEM(ids []string, span, step time.Duration) (*orbits.OEM, error) {
+	if span <= 0 || step <= 0 {
+		return nil, errors.New("span and step must be positive")
+	}
+	s.mu.Lock()
+	start := s.clock
+	if len(ids) == 0 {
+		for id := range s.satellites {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+	sats := make([]Satellite, len(ids))
+	for i, id := range ids {
+		sat, ok := s.satellites[id]
+		if !ok {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%w %q", routing.ErrUnknownNode, id)
+		}
+		sats[i] = *sat
+	}
+	s.mu.Unlock()
+
+	oem := &orbits.OEM{Version: orbits.OEMVersion, CreationDate: time.Now().UTC(), Originator: OEMOriginator}
+	for _, sat := range sats {
+		segment := orbits.OEMSegment{
+			ObjectName: sat.ID, ObjectID: sat.ID, CenterName: "EARTH", RefFrame: "EME2000", TimeSystem: "UTC",
+			StartTime: start, Interpolation: "LAGRANGE", InterpolationDegree: orbits.DefaultLagrangeDegree,
+		}
+		if sat.TLE != nil && sat.Propagator == "" {
+			segment.RefFrame = "TEME"
+		}
+		for offset := time.Duration(0); offset <= span; offset += step {
+			t := start.Add(offset)
+			state, err := sat.inertialStateAt(t, start)
+			if err != nil {
+				return nil, fmt.Errorf("satellite %q: %w", sat.ID, err)
+			}
+			segment.Points = append(segment.Points, orbits.EphemerisPoint{Time: t, Position: state.Position, Velocity: state.Velocity})
+			segment.StopTime = t
+		}
+		oem.Segments = append(oem.Segments, segment)
+	}
+	return oem, nil
+}
+
+// inertialStateAt returns the satellite's state at t as its propagation source reports it, before
+// Earth rotation. Satellites on the circular model continue from their state at now.
+func (sat *Satellite) inertialStateAt(t, now time.Time) (orbits.StateVector, error) {
+	switch {
+	case sat.propagator != nil:
+		return sat.propagator.StateAt(t)
+	case sat.Orbit != nil:
+		return sat.Orbit.StateAt(t), nil
+	case sat.Velocity == (visibility.Vector3{}):
+		return orbits.StateVector{Position: sat.Position}, nil
+	}
+	position, velocity := visibility.PropagateCircular(sat.Position, sat.Velocity, t.Sub(now).Seconds())
+	return orbits.StateVector{Position: position, Velocity: velocity}, nil
+}
@@ -0,0 +1,72 @@
+package simulation
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestOEMExportDrivesAnEphemerisSatellite(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "relay", Orbit: &orbits.KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.01, Inclination: 0.9}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}},
+		},
+		Epoch:         epoch,
+		EarthRotation: true,
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	oem, err := sim.OEM(nil, time.Hour, time.Minute)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(oem.Segments) != 1 || len(oem.Segments[0].Points) != 61 || !oem.Segments[0].StopTime.Equal(epoch.Add(time.Hour)) {
+		t.Fatalf("expected an hour of minute states, got %+v", oem.Segments)
+	}
+
+	var buf bytes.Buffer
+	if err := orbits.WriteOEM(&buf, oem); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	parsed, err := orbits.ParseOEM(&buf)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	ephemeris, err := parsed.Segments[0].Ephemeris()
+	if err != nil {
+		t.Fatalf("ephemeris: %v", err)
+	}
+	cfg.Satellites[0].Orbit, cfg.Satellites[0].Ephemeris = nil, ephemeris
+	replayed, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator from the export: %v", err)
+	}
+	for _, dt := range []time.Duration{17*time.Minute + 20*time.Second, 31 * time.Minute} {
+		if _, err := sim.Step(dt); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := replayed.Step(dt); err != nil {
+			t.Fatal(err)
+		}
+		if d := visibility.SlantRange(sim.satellites["relay"].Position, replayed.satellites["relay"].Position); d > 1e-3 {
+			t.Fatalf("expected the exported ephemeris to retrace the orbit, off by %g km", d)
+		}
+	}
+
+	if _, err := sim.OEM([]string{"missing"}, time.Hour, time.Minute); !errors.Is(err, routing.ErrUnknownNode) {
+		t.Fatalf("expected an unknown satellite to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+// Phasing objectives.
+const (
+	// ObjectiveMaxGap minimizes the longest gap of any cell.
+	ObjectiveMaxGap = "max-gap"
+	// ObjectiveRevisit minimizes the mean over cells of each cell's longest gap.
+	ObjectiveRevisit = "revisit"
+)
+
+// ErrTooFewPlanes is returned by OptimizePhasing for scenarios without two orbital planes to
+// phase against each other.
+var ErrTooFewPlanes = errors.New("phasing needs satellites with orbits in at least two planes")
+
+// DefaultPhasingEvaluations bounds the layouts OptimizePhasing simulates when PhasingOptions
+// leaves MaxEvaluations unset.
+const DefaultPhasingEvaluations = 40
+
+// PhasingOptions configures an OptimizePhasing run.
+type PhasingOptions struct {
+	// Objective is ObjectiveMaxGap (the default) or ObjectiveRevisit.
+	Objective string
+	// Window is how long each candidate is simulated from the scenario epoch, in steps of Step.
+	Window, Step time.Duration
+	// MaxEvaluations bounds the candidates simulated, the starting layout included.
+	MaxEvaluations int
+}
+
+// PhasingMetrics are the gap statistics of one layout over the window.
+type PhasingMetrics struct {
+	MaxGapS float64 `json:"maxGapS"`
+	// MeanGapS averages each cell's longest gap: the constellation's revisit time.
+	MeanGapS float64 `json:"meanGapS"`
+	// CoveredFraction averages the share of the window cells spent covered.
+	CoveredFraction float64 `json:"coveredFraction"`
+}
+
+// PlaneOffset is how far the optimizer moved one orbital plane from the scenario's layout.
+type PlaneOffset struct {
+	Satellites     []string `json:"satellites"`
+	RAANOffsetDeg  float64  `json:"raanOffsetDeg"`
+	PhaseOffsetDeg float64  `json:"phaseOffsetDeg"` // added to every satellite's mean anomaly
+}
+
+// PhasingResult reports an optimized layout against the scenario's own.
+type PhasingResult struct {
+	Objective   string         `json:"objective"`
+	Before      PhasingMetrics `json:"before"`
+	After       PhasingMetrics `json:"after"`
+	Evaluations int            `json:"evaluations"`
+	// Planes lists every plane in order of right ascension; the first is the fixed reference.
+	Planes []PlaneOffset `json:"planes"`
+	// Satellites are the optimized satellites, ready to replace the scenario's.
+	Satellites []Satellite `json:"satellites"`
+}
+
+// orbitPlane is the indices of the scenario satellites sharing an orbital plane.
+type orbitPlane struct {
+	raan       float64
+	satellites []int
+}
+
+// OptimizePhasing searches for right ascension and mean anomaly offsets of the scenario's orbital
+// planes that shorten its coverage gaps, keeping the satellite count and every orbit's size and
+// shape. Satellites with Keplerian orbits sharing an inclination and right ascension form a plane;
+// the first plane is held fixed and each other plane is rotated and phased as a whole by a pattern
+// search that halves its steps whenever no single move helps. Every candidate is simulated from
+// the scenario epoch as configured, without the session's later changes; ties on the objective are
+// broken by the other gap statistic. The simulator itself is not changed.
+func (s *Simulator) OptimizePhasing(opts PhasingOptions) (PhasingResult, error) {
+	if opts.Objective == "" {
+		opts.Objective = ObjectiveMaxGap
+	}
+	if opts.Objective != ObjectiveMaxGap && opts.Objective != ObjectiveRevisit {
+		return PhasingResult{}, fmt.Errorf("unknown phasing objective %q", opts.Objective)
+	}
+	if opts.Window <= 0 || opts.Step <= 0 {
+		return PhasingResult{}, errors.New("window and step must be positive")
+	}
+	if opts.MaxEvaluations == 0 {
+		opts.MaxEvaluations = DefaultPhasingEvaluations
+	}
+	if opts.MaxEvaluations < 1 {
+		return PhasingResult{}, errors.New("max evaluations must be positive")
+	}
+	s.mu.Lock()
+	cfg := s.scenario
+	s.mu.Unlock()
+
+	planes := orbitPlanes(cfg.Satellites)
+	if len(planes) < 2 {
+		return PhasingResult{}, ErrTooFewPlanes
+	}
+	perPlane := 0
+	for _, plane := range planes {
+		if len(plane.satellites) > perPlane {
+			perPlane = len(plane.satellites)
+		}
+	}
+
+	evaluations := 0
+	evaluate := func(offsets []float64) (PhasingMetrics, error) {
+		evaluations++
+		return phasingMetrics(phasedConfig(cfg, planes, offsets), opts.Window, opts.Step)
+	}
+	better := func(a, b PhasingMetrics) bool {
+		primary, secondary := a.MaxGapS-b.MaxGapS, a.MeanGapS-b.MeanGapS
+		if opts.Objective == ObjectiveRevisit {
+			primary, secondary = secondary, primary
+		}
+		const epsilon = 1e-9
+		return primary < -epsilon || (math.Abs(primary) <= epsilon && secondary < -epsilon)
+	}
+
+	// offsets holds a right ascension and a mean anomaly offset, in radians, per plane but the first.
+	offsets := make([]float64, 2*(len(planes)-1))
+	before, err := evaluate(offsets)
+	if err != nil {
+		return PhasingResult{}, err
+	}
+	best := before
+	// Start at half the spacing between planes and between satellites in a plane, and stop once
+	// the steps are finer than the grid can resolve.
+	steps := []float64{math.Pi / float64(len(planes)), math.Pi / float64(perPlane)}
+	minStep := math.Min(cfg.GridConfig.LatStep, cfg.GridConfig.LonStep) * math.Pi / 180 / 4
+search:
+	for steps[0] >= minStep || steps[1] >= minStep {
+		improved := false
+		for i := range offsets {
+			step := steps[i%2]
+			if step < minStep {
+				continue
+			}
+			for _, sign := range []float64{1, -1} {
+				if evaluations >= opts.MaxEvaluations {
+					break search
+				}
+				candidate := append([]float64(nil), offsets...)
+				candidate[i] += sign * step
+				metrics, err := evaluate(candidate)
+				if err != nil {
+					return PhasingResult{}, err
+				}
+				if better(metrics, best) {
+					offsets, best, improved = candidate, metrics, true
+					break
+				}
+			}
+		}
+		if !improved {
+			steps[0], steps[1] = steps[0]/2, steps[1]/2
+		}
+	}
+
+	result := PhasingResult{
+		Objective:   opts.Objective,
+		Before:      before,
+		After:       best,
+		Evaluations: evaluations,
+		Planes:      make([]PlaneOffset, len(planes)),
+		Satellites:  phasedConfig(cfg, planes, offsets).Satellites,
+	}
+	for p, plane := range planes {
+		offset := PlaneOffset{}
+		if p > 0 {
+			offset.RAANOffsetDeg = normalizeDegrees(offsets[2*(p-1)] * 180 / math.Pi)
+			offset.PhaseOffsetDeg = normalizeDegrees(offsets[2*(p-1)+1] * 180 / math.Pi)
+		}
+		for _, i := range plane.satellites {
+			offset.Satellites = append(offset.Satellites, cfg.Satellites[i].ID)
+		}
+		result.Planes[p] = offset
+	}
+	return result, nil
+}
+
+// orbitPlanes groups the satellites with Keplerian orbits by inclination and right ascension,
+// ordered by right ascension.
+func orbitPlanes(sats []Satellite) []orbitPlane {
+	type planeKey struct{ inclination, raan float64 }
+	// Round away the noise of elements computed from state vectors.
+	round := func(angle float64) float64 { return math.Round(angle*1e6) / 1e6 }
+	index := make(map[planeKey]int)
+	var planes []orbitPlane
+	for i, sat := range sats {
+		if sat.Orbit == nil {
+			continue
+		}
+		key := planeKey{round(sat.Orbit.Inclination), round(sat.Orbit.RAAN)}
+		p, ok := index[key]
+		if !ok {
+			p = len(planes)
+			index[key] = p
+			planes = append(planes, orbitPlane{raan: sat.Orbit.RAAN})
+		}
+		planes[p].satellites = append(planes[p].satellites, i)
+	}
+	sort.SliceStable(planes, func(i, j int) bool { return planes[i].raan < planes[j].raan })
+	return planes
+}
+
+// phasedConfig copies cfg with every plane but the first shifted by its offsets.
+func phasedConfig(cfg Config, planes []orbitPlane, offsets []float64) Config {
+	sats := append([]Satellite(nil), cfg.Satellites...)
+	for p := 1; p < len(planes); p++ {
+		for _, i := range planes[p].satellites {
+			orbit := *sats[i].Orbit
+			orbit.RAAN = math.Mod(orbit.RAAN+offsets[2*(p-1)]+2*math.Pi, 2*math.Pi)
+			orbit.MeanAnomaly = math.Mod(orbit.MeanAnomaly+offsets[2*(p-1)+1]+2*math.Pi, 2*math.Pi)
+			sats[i].Orbit = &orbit
+		}
+	}
+	cfg.Satellites = sats
+	return cfg
+}
+
+// phasingMetrics simulates cfg over window in steps of step and summarizes its cells' gaps.
+func phasingMetrics(cfg Config, window, step time.Duration) (PhasingMetrics, error) {
+	// The tracker reads every step's heatmap, so materialize it.
+	cfg.StreamHeatmap = false
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		return PhasingMetrics{}, err
+	}
+	var tracker coverage.GapTracker
+	snap := sim.Snapshot()
+	end := snap.SimTime.Add(window)
+	for {
+		if err := tracker.Observe(snap.SimTime, snap.Heatmap); err != nil {
+			return PhasingMetrics{}, err
+		}
+		if snap.SimTime.Add(step).After(end) {
+			break
+		}
+		if snap, err = sim.Step(step); err != nil {
+			return PhasingMetrics{}, err
+		}
+	}
+	gaps, err := tracker.Gaps(end)
+	if err != nil {
+		return PhasingMetrics{}, err
+	}
+	var metrics PhasingMetrics
+	for _, cell := range gaps {
+		metrics.MaxGapS = math.Max(metrics.MaxGapS, cell.MaxGapS)
+		metrics.MeanGapS += cell.MaxGapS
+		metrics.CoveredFraction += cell.CoveredFraction
+	}
+	if len(gaps) > 0 {
+		metrics.MeanGapS /= float64(len(gaps))
+		metrics.CoveredFraction /= float64(len(gaps))
+	}
+	return metrics, nil
+}
+
+// normalizeDegrees wraps an angle into (-180°, 180°].
+func normalizeDegrees(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	switch {
+	case deg > 180:
+		deg -= 360
+	case deg <= -180:
+		deg += 360
+	}
+	return deg
+}
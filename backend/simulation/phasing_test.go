@@ -0,0 +1,67 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+func TestOptimizePhasingSpreadsBunchedPlanes(t *testing.T) {
+	// Two planes a degree apart in right ascension, their satellites in step: almost a single plane.
+	cfg := presetConfig(10, walker("bunched", 8, 2, 0, 1000, 60, 2))
+	cfg.GridConfig = coverage.GridConfig{LatStep: 30, LonStep: 30}
+	cfg.Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := sim.OptimizePhasing(PhasingOptions{Objective: ObjectiveRevisit, Window: 2 * time.Hour, Step: 5 * time.Minute, MaxEvaluations: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Evaluations > 20 {
+		t.Fatalf("expected at most 20 evaluations, got %d", result.Evaluations)
+	}
+	if !(result.After.MeanGapS < result.Before.MeanGapS) {
+		t.Fatalf("expected a shorter revisit time, got %+v before and %+v after", result.Before, result.After)
+	}
+	if len(result.Planes) != 2 || len(result.Planes[1].Satellites) != 4 || result.Planes[0].RAANOffsetDeg != 0 {
+		t.Fatalf("expected two planes with the first fixed, got %+v", result.Planes)
+	}
+	if len(result.Satellites) != 8 {
+		t.Fatalf("expected the satellite count kept, got %d", len(result.Satellites))
+	}
+	for i, sat := range result.Satellites {
+		if sat.Orbit.SemiMajorAxis != cfg.Satellites[i].Orbit.SemiMajorAxis {
+			t.Fatalf("expected %s to keep its altitude", sat.ID)
+		}
+	}
+
+	// The reported layout reproduces the reported metrics, and the session is untouched.
+	cfg.Satellites = result.Satellites
+	after, err := phasingMetrics(cfg, 2*time.Hour, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != result.After {
+		t.Fatalf("expected %+v from the optimized satellites, got %+v", result.After, after)
+	}
+	if raan := sim.Scenario().Satellites[4].Orbit.RAAN; math.Abs(raan-math.Pi/180) > 1e-12 {
+		t.Fatalf("expected the scenario's second plane left at 1°, got %v rad", raan)
+	}
+}
+
+func TestOptimizePhasingRejectsSinglePlane(t *testing.T) {
+	sim, err := NewSimulator(presetConfig(10, walker("ring", 4, 1, 0, 1000, 0, 360)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.OptimizePhasing(PhasingOptions{Window: time.Hour, Step: time.Minute}); err == nil {
+		t.Fatal("expected a single plane to be rejected")
+	}
+	if _, err := sim.OptimizePhasing(PhasingOptions{Objective: "coverage", Window: time.Hour, Step: time.Minute}); err == nil {
+		t.Fatal("expected an unknown objective to be rejected")
+	}
+}
@@ -0,0 +1,167 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// Router routes demands whose TrafficDemand.Policy names it in Plugins.Routers. It returns the
+// node sequence from from to to; the simulator checks every hop against the graph and measures
+// the path itself.
+type Router interface {
+	Route(graph *routing.Graph, from, to string) ([]string, error)
+}
+
+// Link is one bidirectional link of the routing graph, as visibility models and link budgets see
+// it.
+type Link struct {
+	A, B      routing.Node
+	LatencyMS float64
+}
+
+// VisibilityModel refines the geometric line-of-sight test, for instance with terrain masks or
+// antenna pointing limits. It reports, for each link, whether the link can close; it cannot add
+// links the geometric test rejected.
+type VisibilityModel interface {
+	Visible(links []Link) ([]bool, error)
+}
+
+// LinkBudget computes each link's throughput, in place of the distance-based estimate. Links
+// whose throughput is not positive do not close and are removed.
+type LinkBudget interface {
+	Throughput(links []Link) ([]float64, error)
+}
+
+// Plugins are models supplied from outside the simulator, such as those pluginhost loads. Demands
+// select routers by name through their policy; scenarios select one visibility model and one link
+// budget through Config.VisibilityModel and Config.LinkBudget.
+type Plugins struct {
+	Routers          map[string]Router
+	VisibilityModels map[string]VisibilityModel
+	LinkBudgets      map[string]LinkBudget
+}
+
+// validate checks that the scenario's plugin names are available and that no router takes the
+// name of a built-in policy.
+func (p Plugins) validate(cfg Config) error {
+	for name := range p.Routers {
+		switch name {
+		case "", DefaultPolicy, PolicyMinLatency, PolicyMinHops, PolicyMaxStability, PolicyCGR, PolicyPinned:
+			return fmt.Errorf("router %q takes the name of a built-in policy", name)
+		}
+	}
+	if _, ok := p.VisibilityModels[cfg.VisibilityModel]; cfg.VisibilityModel != "" && !ok {
+		return fmt.Errorf("unknown visibility model %q", cfg.VisibilityModel)
+	}
+	if _, ok := p.LinkBudgets[cfg.LinkBudget]; cfg.LinkBudget != "" && !ok {
+		return fmt.Errorf("unknown link budget %q", cfg.LinkBudget)
+	}
+	return nil
+}
+
+// applyLinkModelsLocked runs the scenario's visibility model and link budget over the links of
+// a freshly built graph, removing those that do not close.
+func (s *Simulator) applyLinkModelsLocked(graph *routing.Graph) error {
+	model := s.plugins.VisibilityModels[s.scenario.VisibilityModel]
+	budget := s.plugins.LinkBudgets[s.scenario.LinkBudget]
+	if model == nil && budget == nil {
+		return nil
+	}
+	var links []Link
+	for from, edges := range graph.Adj {
+		for _, e := range edges {
+			if from < e.To {
+				links = append(links, Link{A: graph.Nodes[from], B: graph.Nodes[e.To], LatencyMS: e.LatencyMS})
+			}
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	// Map order is random; sort so models see the same links in the same order on every run.
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].A.ID != links[j].A.ID {
+			return links[i].A.ID < links[j].A.ID
+		}
+		return links[i].B.ID < links[j].B.ID
+	})
+	closes := make(map[linkKey]bool, len(links))
+	throughput := make(map[linkKey]float64, len(links))
+	for _, link := range links {
+		closes[linkKey{link.A.ID, link.B.ID}] = true
+	}
+	if model != nil {
+		visible, err := model.Visible(links)
+		if err != nil {
+			return fmt.Errorf("visibility model %q: %w", s.scenario.VisibilityModel, err)
+		}
+		if len(visible) != len(links) {
+			return fmt.Errorf("visibility model %q answered %d of %d links", s.scenario.VisibilityModel, len(visible), len(links))
+		}
+		for i, link := range links {
+			closes[linkKey{link.A.ID, link.B.ID}] = visible[i]
+		}
+	}
+	if budget != nil {
+		rates, err := budget.Throughput(links)
+		if err != nil {
+			return fmt.Errorf("link budget %q: %w", s.scenario.LinkBudget, err)
+		}
+		if len(rates) != len(links) {
+			return fmt.Errorf("link budget %q answered %d of %d links", s.scenario.LinkBudget, len(rates), len(links))
+		}
+		for i, link := range links {
+			key := linkKey{link.A.ID, link.B.ID}
+			throughput[key] = rates[i]
+			closes[key] = closes[key] && rates[i] > 0
+		}
+	}
+	for from, edges := range graph.Adj {
+		kept := edges[:0]
+		for _, e := range edges {
+			key := linkKey{from, e.To}
+			if e.To < from {
+				key = linkKey{e.To, from}
+			}
+			if !closes[key] {
+				continue
+			}
+			if rate, ok := throughput[key]; ok {
+				e.Throughput = rate
+			}
+			kept = append(kept, e)
+		}
+		if len(kept) == 0 {
+			delete(graph.Adj, from)
+			continue
+		}
+		graph.Adj[from] = kept
+	}
+	return nil
+}
+
+// pluginPathLocked routes a demand with the router its policy names, over the edges accepted by
+// usable.
+func (s *Simulator) pluginPathLocked(router Router, graph *routing.Graph, demand TrafficDemand, usable func(routing.Edge) bool) (routing.Path, error) {
+	view := graph
+	if usable != nil {
+		view = &routing.Graph{Nodes: graph.Nodes, Adj: make(map[string][]routing.Edge, len(graph.Adj))}
+		for from, edges := range graph.Adj {
+			for _, e := range edges {
+				if usable(e) {
+					view.Adj[from] = append(view.Adj[from], e)
+				}
+			}
+		}
+	}
+	sequence, err := router.Route(view, demand.FromID, demand.ToID)
+	if err != nil {
+		return routing.Path{}, fmt.Errorf("%w: router %q: %v", routing.ErrNoRoute, demand.Policy, err)
+	}
+	if len(sequence) == 0 || sequence[0] != demand.FromID || sequence[len(sequence)-1] != demand.ToID {
+		return routing.Path{}, fmt.Errorf("router %q returned a path that does not run from %q to %q", demand.Policy, demand.FromID, demand.ToID)
+	}
+	return view.PathAlong(sequence)
+}
@@ -0,0 +1,74 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+type routerFunc func(graph *routing.Graph, from, to string) ([]string, error)
+
+func (f routerFunc) Route(graph *routing.Graph, from, to string) ([]string, error) {
+	return f(graph, from, to)
+}
+
+type budgetFunc func(links []Link) ([]float64, error)
+
+func (f budgetFunc) Throughput(links []Link) ([]float64, error) { return f(links) }
+
+func pluginConfig() Config {
+	sat := func(id string, y float64) Satellite {
+		return Satellite{ID: id, Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: y}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}}
+	}
+	return Config{
+		GridConfig:     coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites:     []Satellite{sat("a", 0), sat("b", 100)},
+		GroundStations: []GroundStation{{ID: "gs", Location: &visibility.Geodetic{}}},
+		Traffic:        []TrafficDemand{{ID: "d", FromID: "gs", ToID: "b", Policy: "via-a"}},
+	}
+}
+
+func TestPluginRoutersAndLinkBudgets(t *testing.T) {
+	cfg := pluginConfig()
+	cfg.Plugins.Routers = map[string]Router{"via-a": routerFunc(func(graph *routing.Graph, from, to string) ([]string, error) {
+		return []string{from, "a", to}, nil
+	})}
+	cfg.Plugins.LinkBudgets = map[string]LinkBudget{"budget": budgetFunc(func(links []Link) ([]float64, error) {
+		rates := make([]float64, len(links))
+		for i, link := range links {
+			if link.A.ID == "a" && link.B.ID == "b" {
+				rates[i] = 7
+			} else {
+				rates[i] = 100
+			}
+		}
+		return rates, nil
+	})}
+	cfg.LinkBudget = "budget"
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, ok := sim.Snapshot().Routes["d"]
+	if !ok || len(path.Nodes) != 3 || path.Nodes[1] != "a" || path.BottleneckThroughput != 7 {
+		t.Fatalf("expected the plugin's route through a, bottlenecked by the budget, got %+v", path)
+	}
+
+	// A budget that fails fails the recompute.
+	cfg.Plugins.LinkBudgets["budget"] = budgetFunc(func([]Link) ([]float64, error) { return nil, errors.New("no model") })
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected the failing link budget to fail construction")
+	}
+
+	cfg = pluginConfig()
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a policy naming no router to be rejected")
+	}
+	cfg.Plugins.Routers = map[string]Router{PolicyCGR: routerFunc(nil), "via-a": routerFunc(nil)}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a router shadowing a built-in policy to be rejected")
+	}
+}
@@ -0,0 +1,171 @@
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// Routing policies accepted by TrafficDemand.Policy. A demand without one uses the scenario's
+// stability-weighted shortest path, reported under DefaultPolicy.
+const (
+	// PolicyMinLatency takes the lowest-latency path, ignoring Config.StabilityWeight.
+	PolicyMinLatency = "min-latency"
+	// PolicyMinHops takes the path with the fewest hops, breaking ties by latency.
+	PolicyMinHops = "min-hops"
+	// PolicyMaxStability takes the path whose shortest-lived link lasts longest, breaking ties by
+	// latency.
+	PolicyMaxStability = "max-stability"
+	// PolicyCGR routes by contact graph routing over predicted link lifetimes, skipping links that
+	// close before the demand's data would reach their far end.
+	PolicyCGR = "cgr"
+	// PolicyPinned follows TrafficDemand.Path and goes unrouted whenever a hop of it is missing.
+	PolicyPinned = "pinned"
+)
+
+// DefaultPolicy names demands without a policy in per-policy metrics.
+const DefaultPolicy = "default"
+
+// PolicyMetrics compares how the demands sharing a routing policy fared.
+type PolicyMetrics struct {
+	Demands int `json:"demands"`
+	Routed  int `json:"routed"`
+	// MeanLatencyMS and MeanHops average over the routed demands.
+	MeanLatencyMS float64 `json:"meanLatencyMs"`
+	MeanHops      float64 `json:"meanHops"`
+}
+
+// policyName is the key a demand's policy is reported under.
+func policyName(demand TrafficDemand) string {
+	if demand.Policy == "" {
+		return DefaultPolicy
+	}
+	return demand.Policy
+}
+
+// validatePolicy checks a demand's routing policy. source is the node its routes start from, and
+// routers the plugin routers it may name.
+func validatePolicy(demand TrafficDemand, source string, routers map[string]Router) error {
+	switch _, plugin := routers[demand.Policy]; {
+	case plugin:
+		if len(demand.Path) > 0 {
+			return fmt.Errorf("demand %q sets a path without the %s policy", demand.ID, PolicyPinned)
+		}
+	default:
+		if err := validateBuiltinPolicy(demand, source); err != nil {
+			return err
+		}
+	}
+	if demand.Policy != "" && demand.ToAddress != "" {
+		return fmt.Errorf("demand %q routes to an address, which follows its advertised route instead of a policy", demand.ID)
+	}
+	return nil
+}
+
+func validateBuiltinPolicy(demand TrafficDemand, source string) error {
+	switch demand.Policy {
+	case "", PolicyMinLatency, PolicyMinHops, PolicyMaxStability, PolicyCGR:
+		if len(demand.Path) > 0 {
+			return fmt.Errorf("demand %q sets a path without the %s policy", demand.ID, PolicyPinned)
+		}
+	case PolicyPinned:
+		if len(demand.Path) < 2 {
+			return fmt.Errorf("demand %q pinned path needs at least two nodes", demand.ID)
+		}
+		if demand.Path[0] != source || demand.Path[len(demand.Path)-1] != demand.ToID {
+			return fmt.Errorf("demand %q pinned path must run from %q to %q", demand.ID, source, demand.ToID)
+		}
+	default:
+		return fmt.Errorf("demand %q has unknown routing policy %q", demand.ID, demand.Policy)
+	}
+	return nil
+}
+
+// policyPathLocked routes a node-destined demand by its policy over edges accepted by usable.
+func (s *Simulator) policyPathLocked(graph *routing.Graph, demand TrafficDemand, usable func(routing.Edge) bool) (routing.Path, error) {
+	heuristic := func(id string) float64 {
+		return graph.Heuristic(id, demand.ToID)
+	}
+	switch demand.Policy {
+	case PolicyMinLatency:
+		return routing.StableShortestPathWhere(graph, demand.FromID, demand.ToID, 0, heuristic, usable)
+	case PolicyMinHops:
+		return routing.MinHopPathWhere(graph, demand.FromID, demand.ToID, heuristic, usable)
+	case PolicyMaxStability:
+		return routing.MostStablePathWhere(graph, demand.FromID, demand.ToID, heuristic, usable)
+	case PolicyCGR:
+		return routing.ContactGraphPathWhere(graph, demand.FromID, demand.ToID, heuristic, usable)
+	case PolicyPinned:
+		path, err := graph.PathAlong(demand.Path)
+		if err != nil {
+			return routing.Path{}, err
+		}
+		if !pathUsable(graph, path, usable) {
+			return routing.Path{}, fmt.Errorf("%w: pinned path lacks capacity", routing.ErrNoRoute)
+		}
+		return path, nil
+	}
+	if router, ok := s.plugins.Routers[demand.Policy]; ok {
+		return s.pluginPathLocked(router, graph, demand, usable)
+	}
+	return routing.StableShortestPathWhere(graph, demand.FromID, demand.ToID, s.stabilityWeight, heuristic, usable)
+}
+
+// keepsPreviousRoute reports whether a demand's route may be held within routeContinuityPct of the
+// optimum; only latency-ranked policies compare routes that way.
+func keepsPreviousRoute(demand TrafficDemand) bool {
+	return demand.ToAddress == "" && (demand.Policy == "" || demand.Policy == PolicyMinLatency)
+}
+
+// policyMetricsLocked summarizes the active demands per routing policy, or returns nil when no
+// demand chooses one.
+func (s *Simulator) policyMetricsLocked(routes map[string]routing.Path) map[string]PolicyMetrics {
+	if !s.policies {
+		return nil
+	}
+	metrics := make(map[string]PolicyMetrics)
+	for _, demand := range s.traffic {
+		if !s.activeLocked(demand) {
+			continue
+		}
+		name := policyName(demand)
+		m := metrics[name]
+		m.Demands++
+		if path, ok := routes[demand.ID]; ok {
+			m.Routed++
+			m.MeanLatencyMS += path.LatencyMS
+			m.MeanHops += float64(len(path.Nodes) - 1)
+		}
+		metrics[name] = m
+	}
+	for name, m := range metrics {
+		if m.Routed > 0 {
+			m.MeanLatencyMS /= float64(m.Routed)
+			m.MeanHops /= float64(m.Routed)
+		}
+		metrics[name] = m
+	}
+	return metrics
+}
+
+// policyLatencyLocked pools the latency samples of time-stepped runs per routing policy, or returns
+// nil when no demand chooses one.
+func (s *Simulator) policyLatencyLocked() map[string]LatencyStats {
+	if !s.policies {
+		return nil
+	}
+	samples := make(map[string][]float64)
+	unrouted := make(map[string]int)
+	for _, demand := range s.traffic {
+		name := policyName(demand)
+		samples[name] = append(samples[name], s.latency.samples[demand.ID]...)
+		unrouted[name] += s.latency.unrouted[demand.ID]
+	}
+	out := make(map[string]LatencyStats, len(samples))
+	for name, pooled := range samples {
+		stats := summarizeLatencies(pooled)
+		stats.UnroutedSteps = unrouted[name]
+		out[name] = stats
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package simulation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func policyConfig() Config {
+	auto := coverage.Footprint{Auto: true, LinkStrength: 1}
+	return Config{
+		GridConfig: coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites: []Satellite{
+			{ID: "low", Location: &visibility.Geodetic{AltKm: 550}, Footprint: auto},
+			{ID: "high", Location: &visibility.Geodetic{AltKm: 1200}, Footprint: auto},
+		},
+		GroundStations: []GroundStation{
+			{ID: "west", Location: &visibility.Geodetic{LonDeg: -3}},
+			{ID: "east", Location: &visibility.Geodetic{LonDeg: 3}},
+		},
+		Traffic: []TrafficDemand{
+			{ID: "default", FromID: "west", ToID: "east"},
+			{ID: "hops", FromID: "west", ToID: "east", Policy: PolicyMinHops},
+			{ID: "pinned", FromID: "west", ToID: "east", Policy: PolicyPinned, Path: []string{"west", "high", "east"}},
+		},
+		Epoch: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestDemandsRouteByTheirPolicy(t *testing.T) {
+	sim, err := NewSimulator(policyConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := sim.Snapshot()
+	if got := snap.Routes["default"].Nodes; !reflect.DeepEqual(got, []string{"west", "low", "east"}) {
+		t.Fatalf("expected the default route through the low satellite, got %v", got)
+	}
+	if got := snap.Routes["hops"].Nodes; !reflect.DeepEqual(got, []string{"west", "low", "east"}) {
+		t.Fatalf("expected min-hops to break the tie by latency, got %v", got)
+	}
+	pinned := snap.Routes["pinned"]
+	if !reflect.DeepEqual(pinned.Nodes, []string{"west", "high", "east"}) || pinned.LatencyMS <= snap.Routes["default"].LatencyMS {
+		t.Fatalf("expected the pinned route through the high satellite, got %+v", pinned)
+	}
+	if m := snap.Policies[PolicyPinned]; m.Demands != 1 || m.Routed != 1 || m.MeanHops != 2 || m.MeanLatencyMS != pinned.LatencyMS {
+		t.Fatalf("unexpected pinned metrics %+v", m)
+	}
+	if len(snap.Policies) != 3 || snap.Policies[DefaultPolicy].Demands != 1 {
+		t.Fatalf("expected metrics for each policy, got %+v", snap.Policies)
+	}
+
+	// A pinned demand does not reroute around its failed hop.
+	updated, err := sim.DisableSatellite("high")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, routed := updated.Routes["pinned"]; routed || updated.Policies[PolicyPinned].Routed != 0 {
+		t.Fatalf("expected the pinned demand to go unrouted, got %+v", updated.Routes["pinned"])
+	}
+	summary, err := sim.Run(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := summary.Policies[PolicyPinned]; stats.UnroutedSteps != 2 || summary.Policies[PolicyMinHops].Samples != 2 {
+		t.Fatalf("unexpected per-policy latency %+v", summary.Policies)
+	}
+
+	plain := policyConfig()
+	plain.Traffic = plain.Traffic[:1]
+	sim, err = NewSimulator(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap := sim.Snapshot(); snap.Policies != nil {
+		t.Fatalf("expected no policy metrics without policies, got %+v", snap.Policies)
+	}
+}
+
+func TestRoutingPolicyValidation(t *testing.T) {
+	for name, mutate := range map[string]func(*Config){
+		"unknown policy":   func(cfg *Config) { cfg.Traffic[1].Policy = "shortest" },
+		"path unpinned":    func(cfg *Config) { cfg.Traffic[1].Path = []string{"west", "east"} },
+		"short path":       func(cfg *Config) { cfg.Traffic[2].Path = []string{"west"} },
+		"path wrong start": func(cfg *Config) { cfg.Traffic[2].Path = []string{"low", "east"} },
+		"path wrong end":   func(cfg *Config) { cfg.Traffic[2].Path = []string{"west", "low"} },
+	} {
+		cfg := policyConfig()
+		mutate(&cfg)
+		if _, err := NewSimulator(cfg); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// PresetInfo describes a built-in scenario.
+type PresetInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Satellites  int    `json:"satellites"`
+}
+
+// ErrUnknownPreset is returned by PresetScenario for names not in Presets.
+var ErrUnknownPreset = errors.New("unknown preset")
+
+type preset struct {
+	name        string
+	description string
+	build       func() Config
+}
+
+// presets are realistic starting points modeled on public constellation filings. Satellites orbit
+// from the scenario epoch, footprints are derived from altitude and the elevation mask, and the
+// same gateways and demands are shared so presets can be compared directly.
+var presets = []preset{
+	{
+		name:        "iridium",
+		description: "Iridium-like Walker star: 66 satellites in 6 polar planes at 780 km.",
+		build: func() Config {
+			return presetConfig(8.2, walker("iridium", 66, 6, 2, 780, 86.4, 180))
+		},
+	},
+	{
+		name:        "starlink-shell1",
+		description: "Starlink shell 1: 1584 satellites in 72 planes at 550 km and 53°.",
+		build: func() Config {
+			return presetConfig(25, walker("starlink", 1584, 72, 1, 550, 53, 360))
+		},
+	},
+	{
+		name:        "o3b",
+		description: "O3b-like MEO ring: 20 equatorial satellites at 8062 km.",
+		build: func() Config {
+			return presetConfig(10, walker("o3b", 20, 1, 0, 8062, 0, 360))
+		},
+	},
+	{
+		name:        "geo-trio",
+		description: "Three geostationary satellites 120° apart.",
+		build: func() Config {
+			return presetConfig(5, geoTrio())
+		},
+	},
+	{
+		name:        "hybrid",
+		description: "The GEO trio over the Iridium-like LEO constellation, as separate shells.",
+		build: func() Config {
+			sats := append(geoTrio(), walker("iridium", 66, 6, 2, 780, 86.4, 180)...)
+			for i := range sats {
+				sats[i].Shell = "leo"
+				if sats[i].Orbit == nil {
+					sats[i].Shell = "geo"
+				}
+			}
+			return presetConfig(8.2, sats)
+		},
+	},
+}
+
+// Presets lists the built-in scenarios.
+func Presets() []PresetInfo {
+	out := make([]PresetInfo, 0, len(presets))
+	for _, p := range presets {
+		out = append(out, PresetInfo{Name: p.name, Description: p.description, Satellites: len(p.build().Satellites)})
+	}
+	return out
+}
+
+// PresetScenario returns a fresh copy of the named built-in scenario.
+func PresetScenario(name string) (Config, error) {
+	for _, p := range presets {
+		if p.name == name {
+			return p.build(), nil
+		}
+	}
+	return Config{}, fmt.Errorf("%w %q", ErrUnknownPreset, name)
+}
+
+// walker lays out a Walker constellation of total satellites in planes evenly spread over
+// raanSpreadDeg of right ascension: 360° for a delta pattern, 180° for a star. Phasing offsets
+// adjacent planes by phasing·360°/total in mean anomaly.
+func walker(prefix string, total, planes, phasing int, altitudeKm, inclinationDeg, raanSpreadDeg float64) []Satellite {
+	perPlane := total / planes
+	sats := make([]Satellite, 0, total)
+	for p := 0; p < planes; p++ {
+		for s := 0; s < perPlane; s++ {
+			sats = append(sats, Satellite{
+				ID: fmt.Sprintf("%s-%02d-%02d", prefix, p+1, s+1),
+				Orbit: &orbits.KeplerianElements{
+					SemiMajorAxis: visibility.EarthRadius + altitudeKm,
+					Inclination:   inclinationDeg * math.Pi / 180,
+					RAAN:          float64(p) * raanSpreadDeg / float64(planes) * math.Pi / 180,
+					MeanAnomaly:   2 * math.Pi * (float64(s)/float64(perPlane) + float64(phasing*p)/float64(total)),
+				},
+				Footprint: coverage.Footprint{Auto: true, LinkStrength: 1},
+			})
+		}
+	}
+	return sats
+}
+
+// geoTrio places three satellites over the equator. The simulation frame does not rotate with the
+// Earth, so fixed positions are geostationary.
+func geoTrio() []Satellite {
+	sats := make([]Satellite, 0, 3)
+	for i, lon := range []float64{-60, 60, 180} {
+		sats = append(sats, Satellite{
+			ID:        fmt.Sprintf("geo-%d", i+1),
+			Location:  &visibility.Geodetic{LonDeg: lon, AltKm: 35786},
+			Footprint: coverage.Footprint{Auto: true, LinkStrength: 1},
+		})
+	}
+	return sats
+}
+
+// presetGateways are the ground stations every preset shares.
+var presetGateways = []struct {
+	id       string
+	lat, lon float64
+}{
+	{"svalbard", 78.23, 15.41},
+	{"frankfurt", 50.11, 8.68},
+	{"new-york", 40.71, -74.01},
+	{"hawaii", 19.82, -155.47},
+	{"singapore", 1.35, 103.82},
+	{"johannesburg", -26.20, 28.05},
+	{"perth", -31.95, 115.86},
+	{"santiago", -33.45, -70.67},
+}
+
+func presetConfig(elevationMaskDeg float64, sats []Satellite) Config {
+	ground := make([]GroundStation, 0, len(presetGateways))
+	for _, g := range presetGateways {
+		ground = append(ground, GroundStation{ID: g.id, Location: &visibility.Geodetic{LatDeg: g.lat, LonDeg: g.lon}})
+	}
+	return Config{
+		Satellites:     sats,
+		GroundStations: ground,
+		Traffic: []TrafficDemand{
+			{ID: "transatlantic", FromID: "new-york", ToID: "frankfurt"},
+			{ID: "indian-ocean", FromID: "singapore", ToID: "johannesburg"},
+			{ID: "pacific", FromID: "santiago", ToID: "hawaii"},
+		},
+		GridConfig:       coverage.GridConfig{LatStep: 5, LonStep: 5},
+		ElevationMaskDeg: elevationMaskDeg,
+	}
+}
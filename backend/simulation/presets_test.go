@@ -0,0 +1,76 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPresetsBuild(t *testing.T) {
+	want := map[string]int{"iridium": 66, "starlink-shell1": 1584, "o3b": 20, "geo-trio": 3, "hybrid": 69}
+	infos := Presets()
+	if len(infos) != len(want) {
+		t.Fatalf("expected %d presets, got %+v", len(want), infos)
+	}
+	for _, info := range infos {
+		if want[info.Name] != info.Satellites || info.Description == "" {
+			t.Fatalf("unexpected preset %+v", info)
+		}
+		cfg, err := PresetScenario(info.Name)
+		if err != nil {
+			t.Fatalf("%s: %v", info.Name, err)
+		}
+		sim, err := NewSimulator(cfg)
+		if err != nil {
+			t.Fatalf("%s: failed to build simulator: %v", info.Name, err)
+		}
+		snap := sim.Snapshot()
+		if len(snap.ActiveSatellites) != info.Satellites || snap.Coverage.CoveragePercent <= 0 {
+			t.Fatalf("%s: %d satellites covering %v%%", info.Name, len(snap.ActiveSatellites), snap.Coverage.CoveragePercent)
+		}
+		if info.Name == "hybrid" && (snap.Shells["geo"].Satellites != 3 || snap.Shells["leo"].Satellites != 66) {
+			t.Fatalf("hybrid shells %+v", snap.Shells)
+		}
+	}
+
+	// Each call hands out a fresh copy.
+	a, _ := PresetScenario("iridium")
+	a.Satellites[0].Orbit.RAAN = 1
+	if b, _ := PresetScenario("iridium"); b.Satellites[0].Orbit.RAAN != 0 {
+		t.Fatal("presets share state between calls")
+	}
+	if _, err := PresetScenario("molniya"); !errors.Is(err, ErrUnknownPreset) {
+		t.Fatalf("expected ErrUnknownPreset, got %v", err)
+	}
+}
+
+func TestDemoSimulatorPropagatesAWalkerConstellation(t *testing.T) {
+	sim := NewDemoSimulator()
+	cfg := sim.Scenario()
+	if len(cfg.Satellites) != 12 || len(orbitPlanes(cfg.Satellites)) != 3 {
+		t.Fatalf("expected 12 satellites in 3 planes, got %d", len(cfg.Satellites))
+	}
+	if len(cfg.GroundStations) != len(presetGateways) || len(cfg.Traffic) == 0 {
+		t.Fatalf("expected the preset gateways and demands, got %d and %d", len(cfg.GroundStations), len(cfg.Traffic))
+	}
+	before := sim.Snapshot()
+	routed := 0
+	for i := 0; i < 24; i++ {
+		snap, err := sim.Step(5 * time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		routed += len(snap.Routes)
+	}
+	after := sim.Snapshot()
+	if after.Coverage.CoveragePercent <= 0 || routed == 0 {
+		t.Fatalf("expected coverage and routes over two hours, got %v%% and %d routes", after.Coverage.CoveragePercent, routed)
+	}
+	moved := false
+	for i := range before.Heatmap {
+		moved = moved || before.Heatmap[i].Covered != after.Heatmap[i].Covered
+	}
+	if !moved {
+		t.Fatal("expected the footprints to sweep the grid as the satellites move")
+	}
+}
@@ -0,0 +1,156 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// DefaultClass is the QoS class of demands that do not name one. It has weight 1 unless
+// Config.QoSClasses overrides it.
+const DefaultClass = "default"
+
+const (
+	// packetSizeMb is the mean packet size used by the queueing model (1500 bytes).
+	packetSizeMb = 1500 * 8 / 1e6
+	// maxQueueingDelayMS bounds the per-hop delay of a saturated class, standing in for a finite buffer.
+	maxQueueingDelayMS = 100.0
+)
+
+// QoSClass assigns a weighted-fair-queuing weight to a traffic class. On a congested link each class
+// is guaranteed a share of capacity proportional to its weight among the classes using the link.
+type QoSClass struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// ClassMetrics aggregates the queueing experienced by one class's routed demands in a snapshot.
+type ClassMetrics struct {
+	Demands             int     `json:"demands"`
+	CarriedMbps         float64 `json:"carriedMbps"`
+	MeanQueueingDelayMS float64 `json:"meanQueueingDelayMs"`
+	MaxQueueingDelayMS  float64 `json:"maxQueueingDelayMs"`
+	// CongestedHops counts hops where the class's queueing delay reached the buffer cap.
+	CongestedHops int `json:"congestedHops"`
+}
+
+func qosWeights(classes []QoSClass, traffic []TrafficDemand) (map[string]float64, error) {
+	weights := map[string]float64{DefaultClass: 1}
+	for _, class := range classes {
+		if class.Name == "" {
+			return nil, errors.New("QoS class name cannot be empty")
+		}
+		if class.Weight <= 0 {
+			return nil, fmt.Errorf("QoS class %q weight must be positive", class.Name)
+		}
+		weights[class.Name] = class.Weight
+	}
+	for _, demand := range traffic {
+		if _, ok := weights[demandClass(demand)]; !ok {
+			return nil, fmt.Errorf("demand %q uses unknown QoS class %q", demand.ID, demand.Class)
+		}
+	}
+	return weights, nil
+}
+
+func demandClass(demand TrafficDemand) string {
+	if demand.Class == "" {
+		return DefaultClass
+	}
+	return demand.Class
+}
+
+// applyQoSLocked estimates per-hop queueing delay for each admitted demand and records it on the
+// demand's allocation, returning per-class metrics.
+//
+// The link scheduler is modeled as generalized processor sharing, the fluid limit of weighted fair
+// queuing: a class is served at no less than its weighted share of capacity, nor less than the
+// capacity the other classes leave unused. Each class then queues as an M/M/1 system at that rate.
+func (s *Simulator) applyQoSLocked(routes map[string]routing.Path, allocations map[string]Allocation) map[string]ClassMetrics {
+	load := make(map[linkKey]map[string]float64)
+	for _, demand := range s.traffic {
+		path, ok := routes[demand.ID]
+		if !ok {
+			continue
+		}
+		class := demandClass(demand)
+		for _, key := range pathLinks(path) {
+			if load[key] == nil {
+				load[key] = make(map[string]float64)
+			}
+			load[key][class] += allocations[demand.ID].AllocatedMbps
+		}
+	}
+
+	delays := make(map[linkKey]map[string]float64, len(load))
+	for key, classes := range load {
+		delays[key] = s.linkQueueingDelays(s.capacityOfLocked(key), classes)
+	}
+
+	metrics := make(map[string]ClassMetrics)
+	for _, demand := range s.traffic {
+		path, ok := routes[demand.ID]
+		if !ok {
+			continue
+		}
+		class := demandClass(demand)
+		m := metrics[class]
+		delay := 0.0
+		for _, key := range pathLinks(path) {
+			hop := delays[key][class]
+			if hop >= maxQueueingDelayMS {
+				m.CongestedHops++
+			}
+			delay += hop
+		}
+		alloc := allocations[demand.ID]
+		alloc.QueueingDelayMS = delay
+		allocations[demand.ID] = alloc
+
+		m.Demands++
+		m.CarriedMbps += alloc.AllocatedMbps
+		m.MeanQueueingDelayMS += delay
+		m.MaxQueueingDelayMS = math.Max(m.MaxQueueingDelayMS, delay)
+		metrics[class] = m
+	}
+	for class, m := range metrics {
+		m.MeanQueueingDelayMS /= float64(m.Demands)
+		metrics[class] = m
+	}
+	return metrics
+}
+
+// linkQueueingDelays returns the mean queueing delay in milliseconds of each class on one link
+// direction of the given capacity.
+func (s *Simulator) linkQueueingDelays(capacity float64, classLoad map[string]float64) map[string]float64 {
+	totalWeight, totalLoad := 0.0, 0.0
+	for class, mbps := range classLoad {
+		totalWeight += s.qosWeights[class]
+		totalLoad += mbps
+	}
+
+	delays := make(map[string]float64, len(classLoad))
+	for class, mbps := range classLoad {
+		guaranteed := capacity * s.qosWeights[class] / totalWeight
+		leftover := capacity - (totalLoad - mbps)
+		rate := math.Max(guaranteed, leftover)
+		delays[class] = mm1QueueingDelayMS(mbps, rate)
+	}
+	return delays
+}
+
+// mm1QueueingDelayMS is the M/M/1 mean waiting time for load and service rate in Mbps, capped at
+// maxQueueingDelayMS once the queue is unstable.
+func mm1QueueingDelayMS(load, rate float64) float64 {
+	if load <= 0 {
+		return 0
+	}
+	if rate <= load {
+		return maxQueueingDelayMS
+	}
+	// Wq = ρ / (μ - λ) with μ and λ in packets per second.
+	seconds := packetSizeMb * load / (rate * (rate - load))
+	return math.Min(seconds*1000, maxQueueingDelayMS)
+}
@@ -0,0 +1,66 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func qosConfig(realtimeWeight float64) Config {
+	cfg := bottleneckConfig(AdmitReject)
+	cfg.QoSClasses = []QoSClass{{Name: "realtime", Weight: realtimeWeight}, {Name: "bulk", Weight: 1}}
+	cfg.Traffic[0].BandwidthMbps, cfg.Traffic[0].Class = 45, "bulk"
+	cfg.Traffic[1].BandwidthMbps, cfg.Traffic[1].Class = 45, "realtime"
+	return cfg
+}
+
+func TestWeightedClassSeesLessQueueing(t *testing.T) {
+	sim, err := NewSimulator(qosConfig(4))
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	snapshot := sim.Snapshot()
+	realtime, bulk := snapshot.Classes["realtime"], snapshot.Classes["bulk"]
+	if realtime.Demands != 1 || bulk.Demands != 1 || realtime.CarriedMbps != 45 {
+		t.Fatalf("unexpected class metrics: realtime %+v, bulk %+v", realtime, bulk)
+	}
+	if realtime.MeanQueueingDelayMS <= 0 || realtime.MeanQueueingDelayMS >= bulk.MeanQueueingDelayMS {
+		t.Fatalf("expected realtime to queue less than bulk: %v vs %v ms", realtime.MeanQueueingDelayMS, bulk.MeanQueueingDelayMS)
+	}
+	if got := snapshot.Allocations["voice"].QueueingDelayMS; got != realtime.MaxQueueingDelayMS {
+		t.Fatalf("allocation delay %v does not match class max %v", got, realtime.MaxQueueingDelayMS)
+	}
+
+	equal, err := NewSimulator(qosConfig(1))
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	classes := equal.Snapshot().Classes
+	if math.Abs(classes["realtime"].MeanQueueingDelayMS-classes["bulk"].MeanQueueingDelayMS) > 1e-12 {
+		t.Fatalf("equal weights and loads should queue equally: %+v", classes)
+	}
+}
+
+func TestMM1QueueingDelay(t *testing.T) {
+	// 50 Mbps offered to a 100 Mbps server: Wq = 0.012 Mb * 50 / (100 * 50) s = 0.12 ms.
+	if got := mm1QueueingDelayMS(50, 100); math.Abs(got-0.12) > 1e-9 {
+		t.Fatalf("mm1QueueingDelayMS(50, 100) = %v, want 0.12", got)
+	}
+	if got := mm1QueueingDelayMS(100, 100); got != maxQueueingDelayMS {
+		t.Fatalf("saturated queue should hit the cap, got %v", got)
+	}
+	if got := mm1QueueingDelayMS(0, 100); got != 0 {
+		t.Fatalf("idle class should not queue, got %v", got)
+	}
+}
+
+func TestUnknownQoSClassRejected(t *testing.T) {
+	cfg := qosConfig(4)
+	cfg.Traffic[0].Class = "scavenger"
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected unknown class to be rejected")
+	}
+	cfg = qosConfig(0)
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected non-positive weight to be rejected")
+	}
+}
@@ -0,0 +1,51 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestCoverageRegionsAreSummarizedWithTheGlobalGrid(t *testing.T) {
+	cfg := gridTestConfig()
+	cfg.Satellites[0].Location = &visibility.Geodetic{LatDeg: 50, LonDeg: 10, AltKm: 550}
+	cfg.CoverageRegions = []coverage.Region{
+		{Name: "europe", MinLat: 35, MaxLat: 71, MinLon: -10, MaxLon: 40, LatStep: 0.5, LonStep: 0.5},
+		{Name: "pacific", MinLat: -10, MaxLat: 10, MinLon: -170, MaxLon: -150, LatStep: 1, LonStep: 1},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := sim.Snapshot().Coverage
+	europe, pacific := summary.Regions["europe"], summary.Regions["pacific"]
+	if europe.TotalCells != 72*100 || europe.CoveredCells == 0 || europe.UncoveredSamples != nil {
+		t.Fatalf("expected a covered fine grid over Europe without gap samples, got %+v", europe)
+	}
+	if pacific.TotalCells != 400 || pacific.CoveredCells != 0 {
+		t.Fatalf("expected an uncovered Pacific grid, got %+v", pacific)
+	}
+	if summary.TotalCells != 72 {
+		t.Fatalf("expected the global grid to stay coarse, got %d cells", summary.TotalCells)
+	}
+
+	// Changing the global resolution keeps the regions at theirs.
+	snap, err := sim.SetGrid(coverage.GridConfig{LatStep: 10, LonStep: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Coverage.Regions["europe"].TotalCells != 72*100 {
+		t.Fatalf("expected the regional resolution to survive a grid change, got %+v", snap.Coverage.Regions)
+	}
+
+	var limitErr *LimitError
+	if err := (Limits{MaxGridCells: 5000}).CheckLimits(cfg); !errors.As(err, &limitErr) {
+		t.Fatalf("expected regional cells to count against the grid limit, got %v", err)
+	}
+	cfg.CoverageRegions = append(cfg.CoverageRegions, cfg.CoverageRegions[0])
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected duplicate region names to be rejected")
+	}
+}
@@ -0,0 +1,29 @@
+package simulation
+
+import (
+	"sort"
+
+	"github.com/example/satnet/backend/coverage"
+)
+
+// expectedCoverageLocked weighs coverage on grid by the failure probabilities of the satellites
+// behind footprints, or returns nil when none of them may fail.
+func (s *Simulator) expectedCoverageLocked(grid *coverage.CoverageGrid, footprints map[string]coverage.Footprint) *coverage.ExpectedCoverage {
+	ids := make([]string, 0, len(footprints))
+	unreliable := false
+	for id := range footprints {
+		ids = append(ids, id)
+		unreliable = unreliable || s.satellites[id].FailureProbability > 0
+	}
+	if !unreliable {
+		return nil
+	}
+	sort.Strings(ids)
+	list := make([]coverage.Footprint, len(ids))
+	failures := make([]float64, len(ids))
+	for i, id := range ids {
+		list[i], failures[i] = footprints[id], s.satellites[id].FailureProbability
+	}
+	expected := grid.Expected(list, failures)
+	return &expected
+}
@@ -0,0 +1,107 @@
+package simulation
+
+import (
+	"sort"
+	"time"
+)
+
+// RollupResolutions are the widths of the buckets of simulated time link utilization is rolled up
+// into, finest first.
+var RollupResolutions = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
+// LinkRollup aggregates the weathermaps observed while one bucket of simulated time was open for
+// one direction of a link. Samples counts every observation in the bucket, so means include the
+// samples in which the link was idle or down.
+type LinkRollup struct {
+	From               string    `json:"from"`
+	To                 string    `json:"to"`
+	ResolutionS        float64   `json:"resolutionS"`
+	Start              time.Time `json:"start"`
+	Samples            int       `json:"samples"`
+	MeanLoadMbps       float64   `json:"meanLoadMbps"`
+	MaxLoadMbps        float64   `json:"maxLoadMbps"`
+	CapacityMbps       float64   `json:"capacityMbps,omitempty"`
+	MeanUtilizationPct float64   `json:"meanUtilizationPct"`
+	MaxUtilizationPct  float64   `json:"maxUtilizationPct"`
+}
+
+// RollupAggregator rolls a sequence of weathermaps up into LinkRollups at each of
+// RollupResolutions, so utilization trends can be kept without storing every recompute. Only links
+// that carried load during a bucket produce a rollup for it. The zero value is ready to use; it is
+// not safe for concurrent use.
+type RollupAggregator struct {
+	buckets map[time.Duration]*rollupBucket
+}
+
+// rollupBucket accumulates the open bucket of one resolution.
+type rollupBucket struct {
+	start   time.Time
+	samples int
+	links   map[LinkID]*LinkRollup
+}
+
+// Observe adds a weathermap to the open bucket of every resolution and returns the rollups of the
+// buckets it closed, ordered by resolution and link. A bucket closes when a weathermap from a later
+// bucket arrives; weathermaps older than the open bucket are ignored.
+func (a *RollupAggregator) Observe(w Weathermap) []LinkRollup {
+	if a.buckets == nil {
+		a.buckets = make(map[time.Duration]*rollupBucket, len(RollupResolutions))
+	}
+	var closed []LinkRollup
+	for _, resolution := range RollupResolutions {
+		start := w.SimTime.Truncate(resolution)
+		bucket := a.buckets[resolution]
+		switch {
+		case bucket != nil && start.Before(bucket.start):
+			continue
+		case bucket != nil && start.After(bucket.start):
+			closed = append(closed, bucket.rollups()...)
+			bucket = nil
+		}
+		if bucket == nil {
+			bucket = &rollupBucket{start: start, links: make(map[LinkID]*LinkRollup)}
+			a.buckets[resolution] = bucket
+		}
+		bucket.samples++
+		for _, link := range w.Links {
+			if link.LoadMbps <= 0 {
+				continue
+			}
+			id := LinkID{link.From, link.To}
+			rollup, ok := bucket.links[id]
+			if !ok {
+				rollup = &LinkRollup{From: link.From, To: link.To, ResolutionS: resolution.Seconds(), Start: start}
+				bucket.links[id] = rollup
+			}
+			// Sums for now; rollups divides them by the bucket's samples.
+			rollup.MeanLoadMbps += link.LoadMbps
+			rollup.MeanUtilizationPct += link.UtilizationPct
+			if link.LoadMbps > rollup.MaxLoadMbps {
+				rollup.MaxLoadMbps = link.LoadMbps
+			}
+			if link.UtilizationPct > rollup.MaxUtilizationPct {
+				rollup.MaxUtilizationPct = link.UtilizationPct
+			}
+			rollup.CapacityMbps = link.CapacityMbps
+		}
+	}
+	return closed
+}
+
+// rollups finalizes the bucket's link rollups, ordered by link.
+func (b *rollupBucket) rollups() []LinkRollup {
+	out := make([]LinkRollup, 0, len(b.links))
+	for _, rollup := range b.links {
+		rollup.Samples = b.samples
+		rollup.MeanLoadMbps /= float64(b.samples)
+		rollup.MeanUtilizationPct /= float64(b.samples)
+		out = append(out, *rollup)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	return out
+}
@@ -0,0 +1,46 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollupAggregatorClosesBuckets(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(offset time.Duration, loadMbps float64) Weathermap {
+		return Weathermap{SimTime: start.Add(offset), Links: []LinkUtilization{
+			{From: "a", To: "b", LoadMbps: loadMbps, CapacityMbps: 100, UtilizationPct: loadMbps},
+			{From: "b", To: "a"},
+		}}
+	}
+
+	var agg RollupAggregator
+	for i, load := range []float64{40, 0, 20, 60} {
+		if closed := agg.Observe(sample(time.Duration(i)*15*time.Second, load)); len(closed) != 0 {
+			t.Fatalf("expected the first minute to stay open, got %+v", closed)
+		}
+	}
+	closed := agg.Observe(sample(time.Minute, 10))
+	if len(closed) != 1 {
+		t.Fatalf("expected one minute rollup for the loaded direction, got %+v", closed)
+	}
+	minute := closed[0]
+	if minute.From != "a" || minute.ResolutionS != 60 || !minute.Start.Equal(start) || minute.Samples != 4 {
+		t.Fatalf("unexpected rollup %+v", minute)
+	}
+	if minute.MeanLoadMbps != 30 || minute.MaxLoadMbps != 60 || minute.MeanUtilizationPct != 30 || minute.MaxUtilizationPct != 60 || minute.CapacityMbps != 100 {
+		t.Fatalf("expected the idle sample to pull the mean down, got %+v", minute)
+	}
+
+	// An hour later every resolution closes.
+	closed = agg.Observe(sample(time.Hour, 0))
+	if len(closed) != 3 || closed[0].ResolutionS != 60 || closed[1].ResolutionS != 300 || closed[2].ResolutionS != 3600 {
+		t.Fatalf("expected a rollup per resolution, got %+v", closed)
+	}
+	if hour := closed[2]; hour.Samples != 5 || hour.MeanLoadMbps != 26 || hour.MaxLoadMbps != 60 {
+		t.Fatalf("unexpected hourly rollup %+v", hour)
+	}
+	if closed := agg.Observe(sample(2*time.Hour, 0)); len(closed) != 0 {
+		t.Fatalf("expected idle buckets to produce no rollups, got %+v", closed)
+	}
+}
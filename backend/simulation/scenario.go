@@ -0,0 +1,193 @@
+package simulation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// LoadScenario reads a JSON scenario file into a Config.
+func LoadScenario(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+
+	cfg, err := ParseScenario(f)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseScenario decodes a JSON scenario, rejecting unknown fields so typos surface early.
+func ParseScenario(r io.Reader) (Config, error) {
+	var cfg Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("decode scenario: %w", err)
+	}
+	return cfg, nil
+}
+
+// scenarioHashVersion is bumped whenever simulation changes would alter the results of an
+// identical scenario, invalidating previously cached runs. Version 2 drops runs cached before the
+// engine revision was part of the hash.
+const scenarioHashVersion = 2
+
+// engineVersion is the VCS revision the binary was built from, if recorded, so a rebuilt engine
+// does not reuse runs cached by an older one even when scenarioHashVersion was not bumped.
+var engineVersion = func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				modified = "+dirty"
+			}
+		}
+	}
+	return revision + modified
+}()
+
+// ScenarioHash identifies a run of cfg for steps steps of dt: identical scenarios and run
+// parameters hash to the same hex string, so completed results can be cached and reused. A
+// scenario without an epoch starts at the wall clock, so its hash identifies no repeatable run;
+// callers that cache should set the epoch first.
+func ScenarioHash(cfg Config, steps int, dt time.Duration) (string, error) {
+	encoded, err := json.Marshal(struct {
+		Version int           `json:"version"`
+		Engine  string        `json:"engine,omitempty"`
+		Config  Config        `json:"config"`
+		Steps   int           `json:"steps"`
+		DT      time.Duration `json:"dt"`
+	}{scenarioHashVersion, engineVersion, cfg, steps, dt})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ScenarioChange is one difference between two scenarios. Path addresses the field in the JSON
+// form of Config, with list entries that carry an "id" addressed by it, such as
+// "satellites[sat-1].orbit.raan". From is absent for additions and To for removals.
+type ScenarioChange struct {
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// DiffScenarios lists the changes that turn scenario a into b, ordered by path.
+func DiffScenarios(a, b Config) ([]ScenarioChange, error) {
+	from, err := genericJSON(a)
+	if err != nil {
+		return nil, err
+	}
+	to, err := genericJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	changes := diffJSON("", from, to, nil)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func genericJSON(v any) (any, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	return out, json.Unmarshal(encoded, &out)
+}
+
+func diffJSON(path string, a, b any, changes []ScenarioChange) []ScenarioChange {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			for key, value := range av {
+				changes = diffJSON(joinPath(path, key), value, bv[key], changes)
+			}
+			for key, value := range bv {
+				if _, ok := av[key]; !ok {
+					changes = diffJSON(joinPath(path, key), nil, value, changes)
+				}
+			}
+			return changes
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			aKeys, aByKey := keyedEntries(av)
+			bKeys, bByKey := keyedEntries(bv)
+			if aKeys == nil || bKeys == nil {
+				for i := 0; i < len(av) || i < len(bv); i++ {
+					var x, y any
+					if i < len(av) {
+						x = av[i]
+					}
+					if i < len(bv) {
+						y = bv[i]
+					}
+					changes = diffJSON(fmt.Sprintf("%s[%d]", path, i), x, y, changes)
+				}
+				return changes
+			}
+			for _, key := range aKeys {
+				changes = diffJSON(fmt.Sprintf("%s[%s]", path, key), aByKey[key], bByKey[key], changes)
+			}
+			for _, key := range bKeys {
+				if _, ok := aByKey[key]; !ok {
+					changes = diffJSON(fmt.Sprintf("%s[%s]", path, key), nil, bByKey[key], changes)
+				}
+			}
+			return changes
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		changes = append(changes, ScenarioChange{Path: path, From: a, To: b})
+	}
+	return changes
+}
+
+// keyedEntries indexes a list by its entries' "id" fields, returning nil keys when any entry lacks
+// a unique one so the list is compared by position instead.
+func keyedEntries(list []any) ([]string, map[string]any) {
+	keys := make([]string, 0, len(list))
+	byKey := make(map[string]any, len(list))
+	for _, entry := range list {
+		object, ok := entry.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		id, ok := object["id"].(string)
+		if _, dup := byKey[id]; !ok || dup {
+			return nil, nil
+		}
+		keys = append(keys, id)
+		byKey[id] = entry
+	}
+	return keys, byKey
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
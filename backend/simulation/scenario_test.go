@@ -0,0 +1,195 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestParseScenarioBuildsSimulator(t *testing.T) {
+	input := `{
+		"grid": {"latStep": 180, "lonStep": 360},
+		"satellites": [{"id": "sat", "location": {"latDeg": 0, "lonDeg": 0, "altKm": 550}, "footprint": {"radiusKm": 1000, "linkStrength": 1}}],
+		"groundStations": [
+			{"id": "a", "location": {"latDeg": 0, "lonDeg": 0}},
+			{"id": "b", "location": {"latDeg": 1, "lonDeg": 0}}
+		],
+		"traffic": [{"id": "a-b", "from": "a", "to": "b"}],
+		"epoch": "2024-03-01T00:00:00Z"
+	}`
+	cfg, err := ParseScenario(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("scenario should build a simulator: %v", err)
+	}
+	snap := sim.Snapshot()
+	if _, ok := snap.Routes["a-b"]; !ok {
+		t.Fatalf("expected route for scenario demand")
+	}
+	if snap.SimTime.Year() != 2024 {
+		t.Fatalf("expected scenario epoch to seed the clock, got %v", snap.SimTime)
+	}
+}
+
+func TestParseScenarioRejectsUnknownFields(t *testing.T) {
+	if _, err := ParseScenario(strings.NewReader(`{"satelites": []}`)); err == nil {
+		t.Fatalf("expected misspelled field to be rejected")
+	}
+}
+
+func TestScenarioElevationMaskUnits(t *testing.T) {
+	for _, tc := range []struct {
+		name, mask string
+		want       float64
+		wantErr    string
+	}{
+		{name: "degrees", mask: `"elevationMaskDeg": 25`, want: 25 * math.Pi / 180},
+		{name: "legacy radians", mask: `"elevationMask": 0.25`, want: 0.25},
+		{name: "both agreeing", mask: `"elevationMaskDeg": 90, "elevationMask": 1.5707963267948966`, want: math.Pi / 2},
+		{name: "degrees out of range", mask: `"elevationMaskDeg": 95`, wantErr: "between 0 and 90"},
+		{name: "degrees in radian field", mask: `"elevationMask": 25`, wantErr: "set elevationMaskDeg"},
+		{name: "both disagreeing", mask: `"elevationMaskDeg": 10, "elevationMask": 0.5`, wantErr: "disagree"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ParseScenario(strings.NewReader(`{"grid": {"latStep": 90, "lonStep": 90}, "satellites": [{"id": "sat", "position": {"x": 7000}}], "groundStations": [{"id": "gw", "position": {"x": 6371}}], ` + tc.mask + `}`))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			sim, err := NewSimulator(cfg)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(sim.elevationMask-tc.want) > 1e-12 {
+				t.Fatalf("mask %v radians, want %v", sim.elevationMask, tc.want)
+			}
+		})
+	}
+}
+
+func TestGroundStationElevationMaskDegrees(t *testing.T) {
+	cfg := Config{
+		GridConfig:     coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites:     []Satellite{{ID: "sat", Position: visibility.Vector3{X: 7000}}},
+		GroundStations: []GroundStation{{ID: "gw", ElevationMaskDeg: 30}},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if got := sim.ground["gw"].ElevationMask; math.Abs(got-math.Pi/6) > 1e-12 {
+		t.Fatalf("station mask %v radians, want π/6", got)
+	}
+
+	cfg.GroundStations[0] = GroundStation{ID: "gw", ElevationMask: 30}
+	if _, err := NewSimulator(cfg); err == nil || !strings.Contains(err.Error(), `ground station "gw"`) {
+		t.Fatalf("expected the station mask to be rejected, got %v", err)
+	}
+}
+
+func TestScenarioHashIdentifiesRunParameters(t *testing.T) {
+	cfg := Config{GridConfig: coverage.GridConfig{LatStep: 90, LonStep: 90}, ElevationMask: 0.1}
+	base, err := ScenarioHash(cfg, 10, time.Second)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	again, _ := ScenarioHash(cfg, 10, time.Second)
+	if base != again {
+		t.Fatalf("identical scenarios should hash identically: %s vs %s", base, again)
+	}
+
+	cfg.Sharder = fakeSharder{}
+	if sharded, _ := ScenarioHash(cfg, 10, time.Second); sharded != base {
+		t.Fatalf("sharding should not change the scenario hash")
+	}
+	cfg.ElevationMask = 0.2
+	if changed, _ := ScenarioHash(cfg, 10, time.Second); changed == base {
+		t.Fatalf("changing the scenario should change the hash")
+	}
+	cfg.ElevationMask = 0.1
+	if longer, _ := ScenarioHash(cfg, 11, time.Second); longer == base {
+		t.Fatalf("changing the step count should change the hash")
+	}
+}
+
+func TestDiffScenariosMatchesEntriesByID(t *testing.T) {
+	before, _ := PresetScenario("geo-trio")
+	after, _ := PresetScenario("geo-trio")
+	after.ElevationMask = 0.1
+	after.Satellites = append(after.Satellites[1:], Satellite{ID: "geo-4", Footprint: coverage.Footprint{RadiusKm: 100}})
+	after.Satellites[0].Location.LonDeg = 61
+
+	changes, err := DiffScenarios(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	want := "elevationMask satellites[geo-1] satellites[geo-2].location.lonDeg satellites[geo-4]"
+	if got := strings.Join(paths, " "); got != want {
+		t.Fatalf("changed paths %q, want %q", got, want)
+	}
+	if changes[1].To != nil || changes[3].From != nil || changes[2].From != 60.0 || changes[2].To != 61.0 {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+
+	if same, _ := DiffScenarios(before, before); len(same) != 0 {
+		t.Fatalf("expected no changes, got %+v", same)
+	}
+}
+
+type fakeSharder struct{}
+
+func (fakeSharder) Compute(context.Context, []routing.Node, map[string]coverage.Footprint, routing.LinkSettings, coverage.GridConfig) (*routing.Graph, *coverage.CoverageGrid, error) {
+	return nil, nil, nil
+}
+
+func (fakeSharder) Cover(context.Context, []coverage.Footprint, coverage.GridConfig) (*coverage.CoverageGrid, error) {
+	return nil, nil
+}
+
+// fuzzLimits keeps fuzzed scenarios small enough to simulate quickly, as the server's session
+// limits do for uploads.
+var fuzzLimits = Limits{MaxSatellites: 64, MaxGridCells: 20000, MaxMemoryBytes: 64 << 20}
+
+func FuzzParseScenario(f *testing.F) {
+	f.Add(`{"grid": {"latStep": 180, "lonStep": 360},
+		"satellites": [{"id": "sat", "location": {"latDeg": 0, "lonDeg": 0, "altKm": 550}, "footprint": {"radiusKm": 1000, "linkStrength": 1}}],
+		"groundStations": [{"id": "a", "location": {"latDeg": 0, "lonDeg": 0}}, {"id": "b", "location": {"latDeg": 1, "lonDeg": 0}}],
+		"traffic": [{"id": "a-b", "from": "a", "to": "b", "bandwidthMbps": 5}],
+		"linkCapacityMbps": 10, "epoch": "2024-03-01T00:00:00Z"}`)
+	f.Add(`{"grid": {"latStep": 30, "lonStep": 30}, "elevationMaskDeg": 10,
+		"satellites": [{"id": "s", "orbit": {"semiMajorAxisKm": 7000, "eccentricity": 0.01, "inclination": 0.9}, "footprint": {"auto": true, "linkStrength": 1}}],
+		"groundStations": [{"id": "g", "position": {"x": 6371}}]}`)
+	f.Add(`{}`)
+	f.Fuzz(func(t *testing.T, input string) {
+		cfg, err := ParseScenario(strings.NewReader(input))
+		if err != nil || fuzzLimits.CheckLimits(cfg) != nil {
+			return
+		}
+		sim, err := NewSimulator(cfg)
+		if err != nil {
+			return
+		}
+		if _, err := sim.Step(time.Minute); err != nil {
+			t.Fatalf("a scenario that builds should step: %v", err)
+		}
+	})
+}
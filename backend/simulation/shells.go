@@ -0,0 +1,157 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// ShellMetrics reports what one constellation shell contributes on its own. The coverage figures
+// come from a grid holding only the shell's active satellites, so shells can be compared directly
+// even while toggled off.
+type ShellMetrics struct {
+	Enabled    bool `json:"enabled"`
+	Satellites int  `json:"satellites"`
+	// CoveragePercent is the share of cells the shell covers alone; UniquePercent the share no
+	// other shell covers.
+	CoveragePercent  float64 `json:"coveragePercent"`
+	UniquePercent    float64 `json:"uniquePercent"`
+	MeanBandwidthMHz float64 `json:"meanBandwidthMHz,omitempty"`
+}
+
+// ErrUnknownShell is returned when toggling a shell no satellite belongs to.
+var ErrUnknownShell = errors.New("unknown shell")
+
+// shellKey names the shell a satellite belongs to: its Shell label when set, otherwise its
+// altitude rounded to 10 km and inclination rounded to a degree, such as "550km-53deg". Orbiting
+// satellites use their semi-major axis, so eccentric orbits stay in one shell as they move.
+func (sat *Satellite) shellKey() string {
+	if sat.Shell != "" {
+		return sat.Shell
+	}
+	var radius, inclination float64
+	switch {
+	case sat.Orbit != nil && sat.Orbit.Eccentricity < 1:
+		radius, inclination = sat.Orbit.SemiMajorAxis, sat.Orbit.Inclination
+	case sat.TLE != nil:
+		mean := sat.TLE.Mean()
+		radius, inclination = mean.SemiMajorAxis, mean.Inclination
+	default:
+		r, v := sat.Position, sat.Velocity
+		radius = math.Sqrt(r.X*r.X + r.Y*r.Y + r.Z*r.Z)
+		h := visibility.Vector3{X: r.Y*v.Z - r.Z*v.Y, Y: r.Z*v.X - r.X*v.Z, Z: r.X*v.Y - r.Y*v.X}
+		norm := math.Sqrt(h.X*h.X + h.Y*h.Y + h.Z*h.Z)
+		if norm == 0 {
+			return fmt.Sprintf("%.0fkm", math.Round((radius-visibility.EarthRadius)/10)*10)
+		}
+		inclination = math.Acos(h.Z / norm)
+	}
+	return fmt.Sprintf("%.0fkm-%.0fdeg", math.Round((radius-visibility.EarthRadius)/10)*10, math.Round(inclination*180/math.Pi))
+}
+
+// SetShellEnabled toggles every satellite of a shell in or out of routing and coverage, for
+// comparing the constellation with and without it, and recomputes the network.
+func (s *Simulator) SetShellEnabled(shell string, enabled bool) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.setShellEnabledLocked(shell, enabled)
+}
+
+// SetShellEnabledIfMatch toggles a shell only when version matches the current topology version.
+func (s *Simulator) SetShellEnabledIfMatch(shell string, enabled bool, version uint64) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topologyVersion != version {
+		return Snapshot{}, ErrVersionConflict
+	}
+	return s.setShellEnabledLocked(shell, enabled)
+}
+
+func (s *Simulator) setShellEnabledLocked(shell string, enabled bool) (Snapshot, error) {
+	known := false
+	for _, sat := range s.satellites {
+		if sat.shellKey() == shell {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return Snapshot{}, fmt.Errorf("%w %q", ErrUnknownShell, shell)
+	}
+	if enabled {
+		delete(s.disabledShells, shell)
+		s.record(Operation{Op: OpEnableShell, Target: shell})
+	} else {
+		s.disabledShells[shell] = true
+		s.record(Operation{Op: OpDisableShell, Target: shell})
+	}
+	return s.recomputeLocked()
+}
+
+// shellMetricsLocked builds a grid per shell from the active satellites' footprints. It reports
+// nothing for single-shell constellations, whose breakdown would repeat the snapshot's coverage.
+func (s *Simulator) shellMetricsLocked() (map[string]ShellMetrics, error) {
+	footprints := make(map[string][]coverage.Footprint)
+	metrics := make(map[string]ShellMetrics)
+	for _, sat := range s.satellites {
+		key := sat.shellKey()
+		m := metrics[key]
+		m.Enabled = !s.disabledShells[key]
+		if sat.Active {
+			m.Satellites++
+			footprints[key] = append(footprints[key], sat.coverageFootprint(s.elevationMask))
+		}
+		metrics[key] = m
+	}
+	if len(metrics) < 2 {
+		return nil, nil
+	}
+
+	// covering[i] counts the shells covering cell i, owner the last one seen, and weight its share
+	// of the grid.
+	var covering []int
+	var owner []string
+	var weight []float64
+	for key := range metrics {
+		grid, err := coverage.AcquireGrid(s.gridConfig)
+		if err != nil {
+			return nil, err
+		}
+		grid.ApplyFootprints(footprints[key])
+		summary := grid.Summarize()
+		cells := grid.Cells()
+		coverage.ReleaseGrid(grid)
+
+		if covering == nil {
+			covering, owner, weight = make([]int, len(cells)), make([]string, len(cells)), make([]float64, len(cells))
+			for i, cell := range cells {
+				weight[i] = s.gridConfig.CellWeight(cell.Lat)
+			}
+		}
+		for i, cell := range cells {
+			if cell.Covered() {
+				covering[i]++
+				owner[i] = key
+			}
+		}
+		m := metrics[key]
+		m.CoveragePercent, m.MeanBandwidthMHz = summary.CoveragePercent, summary.MeanBandwidthMHz
+		metrics[key] = m
+	}
+	unique := make(map[string]float64)
+	total := 0.0
+	for i, n := range covering {
+		total += weight[i]
+		if n == 1 {
+			unique[owner[i]] += weight[i]
+		}
+	}
+	for key, m := range metrics {
+		m.UniquePercent = unique[key] / total * 100
+		metrics[key] = m
+	}
+	return metrics, nil
+}
@@ -0,0 +1,95 @@
+package simulation
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func shellScenario() Config {
+	return Config{
+		GridConfig: coverage.GridConfig{LatStep: 2, LonStep: 2},
+		Satellites: []Satellite{
+			{ID: "low-a", Location: &visibility.Geodetic{AltKm: 550}, Footprint: coverage.Footprint{RadiusKm: 1500, LinkStrength: 1}},
+			{ID: "high-a", Location: &visibility.Geodetic{LonDeg: 90, AltKm: 1200}, Footprint: coverage.Footprint{CenterLon: 90, RadiusKm: 2500, LinkStrength: 1}},
+			{ID: "high-b", Location: &visibility.Geodetic{AltKm: 1200}, Footprint: coverage.Footprint{RadiusKm: 500, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}}},
+	}
+}
+
+func TestShellBreakdown(t *testing.T) {
+	sim, err := NewSimulator(shellScenario())
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	snap := sim.Snapshot()
+	low, high := snap.Shells["550km"], snap.Shells["1200km"]
+	if len(snap.Shells) != 2 || low.Satellites != 1 || high.Satellites != 2 || !low.Enabled || !high.Enabled {
+		t.Fatalf("unexpected shells %+v", snap.Shells)
+	}
+	// The low shell's ring around high-b's small footprint is its own; the high shell adds the
+	// footprint at 90°E.
+	if low.UniquePercent <= 0 || high.UniquePercent <= 0 || low.UniquePercent >= low.CoveragePercent {
+		t.Fatalf("unexpected unique coverage: low %+v, high %+v", low, high)
+	}
+	if total := snap.Coverage.CoveragePercent; math.Abs(total-(low.CoveragePercent+high.CoveragePercent-(low.CoveragePercent-low.UniquePercent))) > 1e-9 {
+		t.Fatalf("shell coverage %+v and %+v do not add up to %v", low, high, total)
+	}
+
+	off, err := sim.SetShellEnabled("550km", false)
+	if err != nil {
+		t.Fatalf("disable shell: %v", err)
+	}
+	if off.Coverage.CoveragePercent != high.CoveragePercent || !contains(off.DisabledSatellites, "low-a") {
+		t.Fatalf("expected only the high shell to remain, got %v%% and disabled %v", off.Coverage.CoveragePercent, off.DisabledSatellites)
+	}
+	if got := off.Shells["550km"]; got.Enabled || got.CoveragePercent != low.CoveragePercent {
+		t.Fatalf("a disabled shell still reports its standalone coverage, got %+v", got)
+	}
+	on, err := sim.SetShellEnabled("550km", true)
+	if err != nil || on.Coverage.CoveragePercent != snap.Coverage.CoveragePercent {
+		t.Fatalf("re-enabling the shell: %v%%, %v", on.Coverage.CoveragePercent, err)
+	}
+
+	if _, err := sim.SetShellEnabled("9000km", false); !errors.Is(err, ErrUnknownShell) {
+		t.Fatalf("expected ErrUnknownShell, got %v", err)
+	}
+	if _, err := sim.SetShellEnabledIfMatch("550km", false, on.TopologyVersion-1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected a version conflict, got %v", err)
+	}
+}
+
+func TestShellKeys(t *testing.T) {
+	labeled := Satellite{Shell: "gen2", Position: visibility.Vector3{X: visibility.EarthRadius + 550}}
+	if got := labeled.shellKey(); got != "gen2" {
+		t.Fatalf("labeled shell = %q", got)
+	}
+	orbiting := Satellite{Orbit: &orbits.KeplerianElements{SemiMajorAxis: visibility.EarthRadius + 548, Eccentricity: 0.001, Inclination: 53.05 * math.Pi / 180}}
+	if got := orbiting.shellKey(); got != "550km-53deg" {
+		t.Fatalf("orbiting shell = %q", got)
+	}
+	speed := math.Sqrt(orbits.EarthMu / (visibility.EarthRadius + 1200))
+	moving := Satellite{
+		Position: visibility.Vector3{X: visibility.EarthRadius + 1203},
+		Velocity: visibility.Vector3{Y: speed * math.Cos(87.9*math.Pi/180), Z: speed * math.Sin(87.9*math.Pi/180)},
+	}
+	if got := moving.shellKey(); got != "1200km-88deg" {
+		t.Fatalf("moving shell = %q", got)
+	}
+
+	// A single shell needs no breakdown.
+	cfg := shellScenario()
+	cfg.Satellites = cfg.Satellites[1:]
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if shells := sim.Snapshot().Shells; shells != nil {
+		t.Fatalf("expected no breakdown for one shell, got %+v", shells)
+	}
+}
@@ -0,0 +1,156 @@
+// Package simulationtest builds small, well-understood scenarios for tests that run against the
+// simulator, so callers do not have to hand-place satellites and ground stations each time.
+//
+// Every fixture starts at Epoch, uses a coarse coverage grid, and documents the IDs it creates and
+// how its demands route. Fixtures return a plain simulation.Config that tests may adjust before
+// passing it to New or simulation.NewSimulator.
+package simulationtest
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/simulation"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// Epoch is the start time of every fixture, fixed so runs are reproducible.
+var Epoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// LEOAltitudeKm is the altitude of the fixtures that do not take one.
+const LEOAltitudeKm = 550
+
+// New builds a simulator from cfg, failing the test if the scenario is rejected.
+func New(tb testing.TB, cfg simulation.Config) *simulation.Simulator {
+	tb.Helper()
+	sim, err := simulation.NewSimulator(cfg)
+	if err != nil {
+		tb.Fatalf("simulationtest: building simulator: %v", err)
+	}
+	return sim
+}
+
+// SingleSatellite is one stationary satellite, "sat", over latitude 0, longitude 0, with ground
+// stations "west" and "east" two degrees either side of it. Demand "west-east" routes
+// west → sat → east.
+func SingleSatellite() simulation.Config {
+	return config(
+		[]simulation.Satellite{satelliteAt("sat", 0, 0, LEOAltitudeKm)},
+		[]simulation.GroundStation{groundAt("west", 0, -2), groundAt("east", 0, 2)},
+		[]simulation.TrafficDemand{{ID: "west-east", FromID: "west", ToID: "east"}},
+	)
+}
+
+// Ring is n stationary satellites, "ring-01" to "ring-NN", evenly spaced around the equator at
+// altitudeKm, with "ring-01" at longitude 0. Each satellite sees its neighbours, so the ring is
+// connected. Ground station "gw-a" is under "ring-01" and "gw-b" under the satellite furthest
+// around the ring; demand "a-b" routes between them across half the ring. Ring panics if n is
+// below 3 or the Earth would block neighbouring satellites at that altitude.
+func Ring(n int, altitudeKm float64) simulation.Config {
+	if n < 3 {
+		panic(fmt.Sprintf("simulationtest: a ring needs at least 3 satellites, got %d", n))
+	}
+	if (visibility.EarthRadius+altitudeKm)*math.Cos(math.Pi/float64(n)) <= visibility.EarthRadius {
+		panic(fmt.Sprintf("simulationtest: %d satellites at %v km cannot see their ring neighbours", n, altitudeKm))
+	}
+	step := 360 / float64(n)
+	sats := make([]simulation.Satellite, 0, n)
+	for i := 0; i < n; i++ {
+		sats = append(sats, satelliteAt(fmt.Sprintf("ring-%02d", i+1), 0, float64(i)*step, altitudeKm))
+	}
+	far := float64(n/2) * step
+	return config(
+		sats,
+		[]simulation.GroundStation{groundAt("gw-a", 0, 0), groundAt("gw-b", 0, far)},
+		[]simulation.TrafficDemand{{ID: "a-b", FromID: "gw-a", ToID: "gw-b"}},
+	)
+}
+
+// TwoPlaneWalker is a Walker delta of two orbiting planes 180° apart in right ascension, each of
+// perPlane satellites at 1200 km and 53°, named "walker-P-SS" by plane and slot from 1. Ground
+// stations "gw-a" and "gw-b" sit on the equator 30° apart and demand "a-b" joins them. Unlike the
+// other fixtures the satellites move, so tests can step the clock and watch links change.
+// TwoPlaneWalker panics if perPlane is below 1.
+func TwoPlaneWalker(perPlane int) simulation.Config {
+	if perPlane < 1 {
+		panic(fmt.Sprintf("simulationtest: a Walker plane needs at least 1 satellite, got %d", perPlane))
+	}
+	const altitudeKm, inclinationDeg, planes = 1200, 53, 2
+	total := planes * perPlane
+	sats := make([]simulation.Satellite, 0, total)
+	for p := 0; p < planes; p++ {
+		for s := 0; s < perPlane; s++ {
+			sats = append(sats, simulation.Satellite{
+				ID: fmt.Sprintf("walker-%d-%02d", p+1, s+1),
+				Orbit: &orbits.KeplerianElements{
+					SemiMajorAxis: visibility.EarthRadius + altitudeKm,
+					Inclination:   inclinationDeg * math.Pi / 180,
+					RAAN:          float64(p) * math.Pi,
+					MeanAnomaly:   2 * math.Pi * (float64(s)/float64(perPlane) + float64(p)/float64(total)),
+				},
+				Footprint: coverage.Footprint{Auto: true, LinkStrength: 1},
+			})
+		}
+	}
+	return config(
+		sats,
+		[]simulation.GroundStation{groundAt("gw-a", 0, 0), groundAt("gw-b", 0, 30)},
+		[]simulation.TrafficDemand{{ID: "a-b", FromID: "gw-a", ToID: "gw-b"}},
+	)
+}
+
+// Partitioned is two islands of stationary satellites on opposite sides of the Earth, which
+// blocks every link between them. The west island holds "west-1" and "west-2" over longitude 0
+// with ground stations "west-a" and "west-b"; the east island mirrors it over longitude 180.
+// Demands "west-local" and "east-local" route within an island, while "across", from "west-a" to
+// "east-a", has no route.
+func Partitioned() simulation.Config {
+	var (
+		sats   []simulation.Satellite
+		ground []simulation.GroundStation
+	)
+	for _, island := range []struct {
+		name string
+		lon  float64
+	}{{"west", 0}, {"east", 180}} {
+		sats = append(sats,
+			satelliteAt(island.name+"-1", 0, island.lon-1, LEOAltitudeKm),
+			satelliteAt(island.name+"-2", 0, island.lon+1, LEOAltitudeKm),
+		)
+		ground = append(ground,
+			groundAt(island.name+"-a", 0, island.lon-2),
+			groundAt(island.name+"-b", 0, island.lon+2),
+		)
+	}
+	return config(sats, ground, []simulation.TrafficDemand{
+		{ID: "west-local", FromID: "west-a", ToID: "west-b"},
+		{ID: "east-local", FromID: "east-a", ToID: "east-b"},
+		{ID: "across", FromID: "west-a", ToID: "east-a"},
+	})
+}
+
+func satelliteAt(id string, latDeg, lonDeg, altitudeKm float64) simulation.Satellite {
+	return simulation.Satellite{
+		ID:        id,
+		Location:  &visibility.Geodetic{LatDeg: latDeg, LonDeg: lonDeg, AltKm: altitudeKm},
+		Footprint: coverage.Footprint{Auto: true, LinkStrength: 1},
+	}
+}
+
+func groundAt(id string, latDeg, lonDeg float64) simulation.GroundStation {
+	return simulation.GroundStation{ID: id, Location: &visibility.Geodetic{LatDeg: latDeg, LonDeg: lonDeg}}
+}
+
+func config(sats []simulation.Satellite, ground []simulation.GroundStation, traffic []simulation.TrafficDemand) simulation.Config {
+	return simulation.Config{
+		Satellites:     sats,
+		GroundStations: ground,
+		Traffic:        traffic,
+		GridConfig:     coverage.GridConfig{LatStep: 30, LonStep: 30},
+		Epoch:          Epoch,
+	}
+}
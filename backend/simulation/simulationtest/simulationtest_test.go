@@ -0,0 +1,80 @@
+package simulationtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSingleSatelliteRoutesThroughTheSatellite(t *testing.T) {
+	snap := New(t, SingleSatellite()).Snapshot()
+	path, ok := snap.Routes["west-east"]
+	if !ok || !reflect.DeepEqual(path.Nodes, []string{"west", "sat", "east"}) {
+		t.Fatalf("expected west → sat → east, got %+v (routed %v)", path, ok)
+	}
+	if !snap.SimTime.Equal(Epoch) {
+		t.Fatalf("expected the fixture to start at Epoch, got %v", snap.SimTime)
+	}
+}
+
+func TestRingRoutesAcrossHalfTheRing(t *testing.T) {
+	sim := New(t, Ring(6, 1200))
+	snap := sim.Snapshot()
+	if len(snap.ActiveSatellites) != 6 {
+		t.Fatalf("expected 6 satellites, got %v", snap.ActiveSatellites)
+	}
+	path, ok := snap.Routes["a-b"]
+	if !ok {
+		t.Fatal("expected demand a-b to route around the ring")
+	}
+	// gw-a, three ring hops to the opposite satellite, gw-b.
+	if len(path.Nodes) != 6 || path.Nodes[1] != "ring-01" || path.Nodes[4] != "ring-04" {
+		t.Fatalf("expected the route to cross half the ring, got %v", path.Nodes)
+	}
+}
+
+func TestRingRejectsEarthBlockedNeighbours(t *testing.T) {
+	for _, tc := range []struct {
+		n          int
+		altitudeKm float64
+	}{{2, 1200}, {6, 550}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Ring(%d, %v) should panic", tc.n, tc.altitudeKm)
+				}
+			}()
+			Ring(tc.n, tc.altitudeKm)
+		}()
+	}
+}
+
+func TestTwoPlaneWalkerMoves(t *testing.T) {
+	sim := New(t, TwoPlaneWalker(8))
+	before := sim.Topology()
+	if len(before.Snapshot.ActiveSatellites) != 16 {
+		t.Fatalf("expected 16 satellites, got %v", before.Snapshot.ActiveSatellites)
+	}
+	if _, ok := before.Snapshot.Routes["a-b"]; !ok {
+		t.Fatal("expected demand a-b to route at the epoch")
+	}
+	if _, err := sim.Step(10 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	after := sim.Topology()
+	if before.Graph.Nodes["walker-1-01"].Position == after.Graph.Nodes["walker-1-01"].Position {
+		t.Fatal("expected the Walker satellites to move with the clock")
+	}
+}
+
+func TestPartitionedLeavesCrossIslandDemandUnrouted(t *testing.T) {
+	snap := New(t, Partitioned()).Snapshot()
+	for _, id := range []string{"west-local", "east-local"} {
+		if _, ok := snap.Routes[id]; !ok {
+			t.Errorf("expected %s to route within its island", id)
+		}
+	}
+	if path, ok := snap.Routes["across"]; ok {
+		t.Fatalf("expected no route between the islands, got %v", path.Nodes)
+	}
+}
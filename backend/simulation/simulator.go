@@ -1,11 +1,19 @@
 package simulation
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/example/satnet/backend/bgp"
+	"github.com/example/satnet/backend/catalog"
 	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
 	"github.com/example/satnet/backend/routing"
 	"github.com/example/satnet/backend/visibility"
 )
@@ -28,56 +36,415 @@ type Event struct {
 
 // Satellite represents an on-orbit node with a configurable coverage footprint.
 type Satellite struct {
-	ID        string
-	Position  visibility.Vector3
-	Footprint coverage.Footprint
-	Active    bool
+	ID       string             `json:"id"`
+	Position visibility.Vector3 `json:"position"`
+	// Location, when set, replaces Position with the converted latitude/longitude/altitude.
+	Location *visibility.Geodetic `json:"location,omitempty"`
+	Velocity visibility.Vector3   `json:"velocity"` // km/s, used to estimate route stability
+	// Orbit, when set, replaces Position and Velocity with the elements' state and propagates the
+	// satellite with Kepler's equation instead of the circular model. A zero epoch means the
+	// simulation's epoch.
+	Orbit *orbits.KeplerianElements `json:"orbit,omitempty"`
+	// TLE, used instead of Orbit, places the satellite from a two-line element set and propagates it
+	// with SGP4, or SDP4 for deep-space orbits. A zero epoch means the simulation's epoch.
+	TLE *orbits.TLEElements `json:"tle,omitempty"`
+	// Ephemeris, used instead of Orbit and TLE, moves the satellite along an externally provided
+	// table of inertial states, such as a flight dynamics team's predictions. The simulation may
+	// not run outside the table's span.
+	Ephemeris *orbits.Ephemeris `json:"ephemeris,omitempty"`
+	// Propagator, when set, names an entry of Config.Propagators that moves the satellite instead,
+	// typically a high-fidelity propagator outside the process. It overrides Orbit, TLE and
+	// Ephemeris; Orbit and TLE are handed to it as initial conditions.
+	Propagator string             `json:"propagator,omitempty"`
+	Footprint  coverage.Footprint `json:"footprint"`
+	// Shell groups the satellite with others for per-shell breakdowns; when empty it is derived
+	// from the altitude and inclination.
+	Shell string `json:"shell,omitempty"`
+	// FailureProbability is the chance the satellite is out of service at any moment, from 0 to 1.
+	// Coverage summaries weigh it into an expected coverage; routing still treats it as working.
+	FailureProbability float64 `json:"failureProbability,omitempty"`
+	// Bands lists the frequency bands the satellite's terminals operate in; empty means every band.
+	Bands  []string `json:"bands,omitempty"`
+	Active bool     `json:"-"`
+	// propagator moves satellites with a tle, an ephemeris or a named propagator on each step.
+	propagator orbits.Propagator
+	wgs84      bool // whether sub-satellite points are geodetic on the WGS84 ellipsoid
+}
+
+// routingNode returns the satellite as a node of the routing graph.
+func (sat *Satellite) routingNode() routing.Node {
+	return routing.Node{ID: sat.ID, Type: routing.Satellite, Position: sat.Position, Velocity: sat.Velocity, Bands: sat.Bands}
+}
+
+// centerFootprint moves the footprint with a moving satellite, centering it on the sub-satellite point.
+func (sat *Satellite) centerFootprint() {
+	subPoint := sat.subPoint()
+	sat.Footprint.CenterLat, sat.Footprint.CenterLon = subPoint.LatDeg, subPoint.LonDeg
+}
+
+// subPoint returns the latitude and longitude below the satellite: geodetic on the WGS84 ellipsoid
+// when the scenario sets wgs84, geocentric on the sphere otherwise.
+func (sat *Satellite) subPoint() visibility.Geodetic {
+	return geodetic(sat.Position, sat.wgs84)
+}
+
+// locate converts a scenario location on the WGS84 ellipsoid or the sphere.
+func locate(loc visibility.Geodetic, wgs84 bool) visibility.Vector3 {
+	if wgs84 {
+		return loc.WGS84Vector()
+	}
+	return loc.Vector()
+}
+
+// geodetic is the inverse of locate.
+func geodetic(p visibility.Vector3, wgs84 bool) visibility.Geodetic {
+	if wgs84 {
+		return visibility.ToWGS84(p)
+	}
+	return visibility.ToGeodetic(p)
+}
+
+// coverageFootprint returns the footprint applied to the coverage grid. Auto footprints are centered
+// on the sub-satellite point and sized from the altitude and the scenario's elevation mask (radians).
+func (sat *Satellite) coverageFootprint(elevationMask float64) coverage.Footprint {
+	footprint := sat.Footprint
+	if footprint.Auto {
+		subPoint := sat.subPoint()
+		footprint.CenterLat, footprint.CenterLon = subPoint.LatDeg, subPoint.LonDeg
+		// The radius formula assumes a spherical Earth, so it takes the altitude above the sphere.
+		footprint.RadiusKm = coverage.FootprintRadiusForAltitude(visibility.ToGeodetic(sat.Position).AltKm, elevationMask*180/math.Pi)
+	}
+	return footprint
 }
 
 // GroundStation represents a user gateway used as a traffic endpoint.
 type GroundStation struct {
-	ID       string
-	Position visibility.Vector3
+	ID       string             `json:"id"`
+	Position visibility.Vector3 `json:"position"`
+	// Location, when set, replaces Position with the converted latitude/longitude/altitude.
+	Location *visibility.Geodetic `json:"location,omitempty"`
+	// ElevationMaskDeg applies to this station in addition to the scenario-wide mask.
+	ElevationMaskDeg float64 `json:"elevationMaskDeg,omitempty"`
+	// ElevationMask is the station mask in radians. Deprecated: set ElevationMaskDeg; this field is
+	// still read so older scenario files load unchanged.
+	ElevationMask float64 `json:"elevationMask,omitempty"`
+	// Bands lists the frequency bands the station operates in; empty means every band.
+	Bands []string `json:"bands,omitempty"`
+}
+
+// routingNode returns the station as a node of the routing graph.
+func (gs GroundStation) routingNode() routing.Node {
+	return routing.Node{ID: gs.ID, Type: routing.Ground, Position: gs.Position, ElevationMask: gs.ElevationMask, Bands: gs.Bands}
+}
+
+// GroundStationsFromCatalog converts imported catalog entries into simulator ground stations,
+// using the catalog name as the station ID.
+func GroundStationsFromCatalog(entries []catalog.GroundStation) []GroundStation {
+	stations := make([]GroundStation, 0, len(entries))
+	for _, entry := range entries {
+		stations = append(stations, GroundStation{
+			ID:               entry.Name,
+			Position:         entry.Position,
+			ElevationMaskDeg: entry.ElevationMaskDeg,
+		})
+	}
+	return stations
 }
 
 // TrafficDemand specifies a flow between two nodes for which routing is computed.
 type TrafficDemand struct {
-	ID     string
-	FromID string
-	ToID   string
+	ID     string `json:"id"`
+	FromID string `json:"from"`
+	// FromLocation, used instead of FromID, sources the demand at a user terminal at that location.
+	// Each recompute links the terminal to its serving satellite, so no ground station is needed
+	// per location; routes start at the node named by TerminalID.
+	FromLocation *visibility.Geodetic `json:"fromLocation,omitempty"`
+	ToID         string               `json:"to"`
+	// ToAddress, used instead of ToID, routes the demand to an IP address along the path selected by
+	// the simulated prefix advertisements of Config.BGP.
+	ToAddress string `json:"toAddress,omitempty"`
+	// BandwidthMbps is reserved on every link of the route when Config.LinkCapacityMbps is set.
+	BandwidthMbps float64 `json:"bandwidthMbps,omitempty"`
+	// Priority ranks demands for preemption; higher values are more important.
+	Priority int `json:"priority,omitempty"`
+	// Class names the QoS class the demand is scheduled in; empty selects DefaultClass.
+	Class string `json:"class,omitempty"`
+	// Slice names the capacity reservation the demand is admitted against; empty selects SharedSlice.
+	Slice string `json:"slice,omitempty"`
+	// ActiveWindows limits the demand to times of day; outside them it is not routed. Empty keeps
+	// the demand active throughout.
+	ActiveWindows []DailyWindow `json:"activeWindows,omitempty"`
+	// TimeZone reads ActiveWindows in an IANA time zone such as "Europe/Berlin", or in mean solar
+	// time at the source with LocalSolarTime; empty selects UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Policy selects how the demand is routed, letting demands with different needs share a
+	// scenario; empty selects the scenario's stability-weighted shortest path.
+	Policy string `json:"policy,omitempty"`
+	// Path is the hop sequence a PolicyPinned demand follows, from its source to ToID.
+	Path []string `json:"path,omitempty"`
+	// Deferred demands are validated with the scenario but not routed until a hook adds them.
+	Deferred bool `json:"deferred,omitempty"`
 }
 
 // Config wires a simulator with nodes, demands, and modeling parameters.
+// Its JSON form is the scenario file format read by LoadScenario.
 type Config struct {
-	Satellites     []Satellite
-	GroundStations []GroundStation
-	Traffic        []TrafficDemand
-	GridConfig     coverage.GridConfig
-	ElevationMask  float64
+	Satellites     []Satellite         `json:"satellites"`
+	GroundStations []GroundStation     `json:"groundStations"`
+	Traffic        []TrafficDemand     `json:"traffic"`
+	GridConfig     coverage.GridConfig `json:"grid"`
+	// CoverageRegions sample named regions on fine grids alongside the global grid; their coverage
+	// is reported under Coverage.Regions.
+	CoverageRegions []coverage.Region `json:"coverageRegions,omitempty"`
+	// ElevationMaskDeg is the minimum elevation, in degrees from 0 to 90, at which a ground station
+	// sees a satellite.
+	ElevationMaskDeg float64 `json:"elevationMaskDeg,omitempty"`
+	// ElevationMask is the scenario mask in radians. Deprecated: set ElevationMaskDeg; this field is
+	// still read so older scenario files load unchanged.
+	ElevationMask float64 `json:"elevationMask,omitempty"`
+	// GatewayMaskDeg and TerminalMaskDeg, in degrees, replace ElevationMaskDeg for the links of
+	// ground stations and of location-sourced user terminals respectively. A station's own mask
+	// still applies when stricter.
+	GatewayMaskDeg  *float64 `json:"gatewayMaskDeg,omitempty"`
+	TerminalMaskDeg *float64 `json:"terminalMaskDeg,omitempty"`
+	// MaxISLRangeKm drops inter-satellite links longer than the terminals reach; zero leaves it
+	// unlimited.
+	MaxISLRangeKm float64 `json:"maxIslRangeKm,omitempty"`
+	// ISLs selects which satellites may link with each other: ISLsAll (the default) or ISLsNone.
+	ISLs string `json:"isls,omitempty"`
+	// Bands, when set, only closes links on a band both ends operate in, as listed by the
+	// satellites' and ground stations' Bands.
+	Bands []string `json:"bands,omitempty"`
+	// StabilityWeight is the latency (ms) a route may give up to avoid links that are about to break.
+	StabilityWeight float64 `json:"stabilityWeight"`
+	// RouteContinuityPct keeps a demand on its previous route while that route's latency is within
+	// this percentage of the optimum, modeling networks' reluctance to churn routes.
+	RouteContinuityPct float64 `json:"routeContinuityPct"`
+	// Epoch is the simulation start time; it defaults to the wall clock at construction.
+	Epoch time.Time `json:"epoch"`
+	// LinkCapacityMbps caps the bandwidth each link direction can carry. When set, demands are
+	// admitted in order against the remaining capacity and Admission decides what happens to
+	// those that do not fit (default AdmitReject). Zero leaves links uncapacitated.
+	LinkCapacityMbps float64         `json:"linkCapacityMbps,omitempty"`
+	Admission        AdmissionPolicy `json:"admission,omitempty"`
+	// LinkLossRate is the independent packet loss probability of every hop, used by TCP estimates.
+	LinkLossRate float64 `json:"linkLossRate,omitempty"`
+	// TCP describes the connection assumed by TCPEstimates.
+	TCP TCPParams `json:"tcp,omitempty"`
+	// BGP enables prefix advertisement from gateways; see package bgp.
+	BGP *bgp.Config `json:"bgp,omitempty"`
+	// RoutingBudgetMS bounds the wall time spent routing demands per recompute. Demands beyond it
+	// keep their previous route and are refreshed first next time. Zero disables the budget.
+	RoutingBudgetMS float64 `json:"routingBudgetMs,omitempty"`
+	// VisibilityHorizonS precomputes link rise and set times this many seconds ahead, so recomputes
+	// within the horizon toggle links instead of re-testing every pair. The schedule is rebuilt when
+	// the horizon runs out or the topology changes. Zero tests visibility on every recompute.
+	VisibilityHorizonS float64 `json:"visibilityHorizonS,omitempty"`
+	// DemandGenerator, when set, adds a seeded sample of location-sourced demands to Traffic at
+	// construction, for Monte Carlo load studies.
+	DemandGenerator *DemandGenerator `json:"demandGenerator,omitempty"`
+	// Slices reserve fractions of every link's capacity for tenant groups of demands.
+	Slices []Slice `json:"slices,omitempty"`
+	// QoSClasses weights the traffic classes sharing capacitated links. Classes not listed other
+	// than DefaultClass are rejected.
+	QoSClasses []QoSClass `json:"qosClasses,omitempty"`
+	// HistorySize is how many recent snapshots to keep in a compressed buffer; zero disables it.
+	HistorySize int `json:"historySize,omitempty"`
+	// StreamHeatmap keeps each recompute's coverage grid instead of copying it into
+	// Snapshot.Heatmap, which is left empty; read the heatmap with StreamHeatmap. Use it for grids
+	// too large to hold twice.
+	StreamHeatmap bool `json:"streamHeatmap,omitempty"`
+	// Sharder, when set, computes visibility and coverage on shard workers instead of in process.
+	Sharder Sharder `json:"-"`
+	// Propagators are the propagators satellites may name in their Propagator field.
+	Propagators map[string]PropagatorFactory `json:"-"`
+	// Plugins are the routers, visibility models and link budgets the scenario may name.
+	Plugins Plugins `json:"-"`
+	// VisibilityModel and LinkBudget name entries of Plugins that refine every recompute's links;
+	// empty keeps the geometric line-of-sight test and distance-based throughput.
+	VisibilityModel string `json:"visibilityModel,omitempty"`
+	LinkBudget      string `json:"linkBudget,omitempty"`
+	// LinkMetadata attaches free-form annotations to every link of a type, such as the band or
+	// operator of feeder links, for frontends and analyses to read back.
+	LinkMetadata map[routing.LinkType]map[string]string `json:"linkMetadata,omitempty"`
+	// LinkProfiles give link directions their own capacity and latency, such as asymmetric gateway
+	// uplinks and downlinks.
+	LinkProfiles []LinkProfile `json:"linkProfiles,omitempty"`
+	// ValidateInvariants checks every recompute with CheckInvariants and fails it with an
+	// *InvariantError instead of publishing a state that breaks one. It is meant for tests and
+	// debugging; the checks cost time on large constellations.
+	ValidateInvariants bool `json:"validateInvariants,omitempty"`
+	// EarthRotation rotates the inertial states of satellites with an orbit or tle into the
+	// Earth-fixed frame of ground stations by Greenwich mean sidereal time, so the Earth turns under
+	// their orbits. Without it, those orbits are drawn over a non-rotating Earth.
+	EarthRotation bool `json:"earthRotation,omitempty"`
+	// WGS84 reads every location in the scenario, of satellites, ground stations and demand
+	// sources, as geodetic coordinates on the WGS84 ellipsoid, and centers footprints on the
+	// geodetic sub-satellite point. Without it, locations are geocentric on a sphere of EarthRadius.
+	WGS84 bool `json:"wgs84,omitempty"`
+	// TTC, when set, delays satellite commands until they can be uplinked at a contact; see
+	// CommandDisableSatellite.
+	TTC *TTCConfig `json:"ttc,omitempty"`
+	// Hooks change the scenario as it runs when their conditions turn true; see Hook.
+	Hooks []Hook `json:"hooks,omitempty"`
+}
+
+// PropagatorFactory returns the propagator for one satellite of a scenario, which it may read for
+// the satellite's ID and elements; see orbits.External.
+type PropagatorFactory func(sat Satellite) (orbits.Propagator, error)
+
+// ExternalPropagator adapts an external propagator process into a PropagatorFactory. Each
+// satellite's requests carry its ID and its orbit or tle with a zero epoch set to the scenario's.
+func ExternalPropagator(external *orbits.External) PropagatorFactory {
+	return func(sat Satellite) (orbits.Propagator, error) {
+		return external.Satellite(orbits.ExternalRequest{Satellite: sat.ID, Orbit: sat.Orbit, TLE: sat.TLE}), nil
+	}
+}
+
+// releaseGrid returns a recompute's grid to the pool; tests count the calls.
+var releaseGrid = coverage.ReleaseGrid
+
+// ShardTimeout bounds one sharded recompute; a Sharder that takes longer fails the step.
+const ShardTimeout = 30 * time.Second
+
+// Sharder distributes the per-step graph and coverage work; *shard.Coordinator implements it.
+// The simulator hands the returned grid to coverage.ReleaseGrid once it has been summarized, unless
+// Config.StreamHeatmap keeps it.
+type Sharder interface {
+	Compute(
+		ctx context.Context,
+		nodes []routing.Node,
+		footprints map[string]coverage.Footprint,
+		links routing.LinkSettings,
+		grid coverage.GridConfig,
+	) (*routing.Graph, *coverage.CoverageGrid, error)
+	// Cover computes a coverage grid alone, such as those bounding footprint uncertainty.
+	Cover(ctx context.Context, footprints []coverage.Footprint, grid coverage.GridConfig) (*coverage.CoverageGrid, error)
 }
 
 // Snapshot captures the network state and metrics exposed to the frontend.
 type Snapshot struct {
+	Version uint64 `json:"version"`
+	// TopologyVersion counts the mutations applied so far, such as disabled satellites or shells. It
+	// stays put across steps, so conditional mutations compare against it rather than Version.
+	TopologyVersion    uint64                  `json:"topologyVersion"`
 	Timestamp          time.Time               `json:"timestamp"`
+	SimTime            time.Time               `json:"simTime"`
 	ActiveSatellites   []string                `json:"activeSatellites"`
 	DisabledSatellites []string                `json:"disabledSatellites"`
 	Coverage           coverage.Summary        `json:"coverage"`
 	Heatmap            []coverage.HeatmapCell  `json:"heatmap"`
 	Routes             map[string]routing.Path `json:"routes"`
+	// ContinuityPenaltyMS totals the latency sacrificed across demands to keep previous routes.
+	ContinuityPenaltyMS float64 `json:"continuityPenaltyMs"`
+	// Allocations reports each demand's admission outcome when link capacity is modeled.
+	Allocations map[string]Allocation `json:"allocations,omitempty"`
+	// BGP reports how the prefix advertisements converged on this topology.
+	BGP *bgp.Convergence `json:"bgp,omitempty"`
+	// StaleRoutes lists demands whose route was not recomputed because the routing budget ran out;
+	// they carry their previous route, if still valid, and are routed first on the next recompute.
+	StaleRoutes []string `json:"staleRoutes,omitempty"`
+	// Slices reports reservation use per slice, including SharedSlice, when link capacity is modeled.
+	Slices map[string]SliceMetrics `json:"slices,omitempty"`
+	// Classes reports queueing per QoS class when link capacity is modeled.
+	Classes map[string]ClassMetrics `json:"classes,omitempty"`
+	// Shells breaks coverage down by constellation shell when there is more than one.
+	Shells map[string]ShellMetrics `json:"shells,omitempty"`
+	// ServingSatellites maps each demand sourced at a location to the satellite serving its terminal.
+	ServingSatellites map[string]string `json:"servingSatellites,omitempty"`
+	// InactiveDemands lists demands outside their active windows or deferred, which are not routed.
+	InactiveDemands []string `json:"inactiveDemands,omitempty"`
+	// FiredHooks names the scenario hooks that fired on the step that produced this snapshot.
+	FiredHooks []string `json:"firedHooks,omitempty"`
+	// Policies compares routing policies side by side when any demand chooses one.
+	Policies map[string]PolicyMetrics `json:"policies,omitempty"`
 }
 
+// maxVisibilityHorizon bounds Config.VisibilityHorizonS: the schedule samples every node's
+// position across the horizon up front, so longer horizons cost memory without adding accuracy.
+const maxVisibilityHorizon = 24 * time.Hour
+
 // Simulator manages network state, recomputes routing/coverage, and broadcasts updates.
 type Simulator struct {
-	mu            sync.Mutex
-	elevationMask float64
-	gridConfig    coverage.GridConfig
-	satellites    map[string]*Satellite
-	ground        map[string]GroundStation
-	traffic       []TrafficDemand
-	graph         *routing.Graph
-	routes        map[string]routing.Path
-	events        chan Event
-	snapshot      Snapshot
+	mu                sync.Mutex
+	elevationMask     float64
+	links             routing.LinkSettings // the scenario's link settings, including elevationMask
+	terminalMask      float64              // the mask user terminals link to their serving satellite at
+	stabilityWeight   float64
+	continuityPct     float64
+	linkCapacity      float64
+	admission         AdmissionPolicy
+	qosWeights        map[string]float64
+	linkLoss          float64
+	tcp               TCPParams
+	bgp               *bgp.Network
+	slices            []Slice
+	routingBudgetMS   float64
+	validate          bool
+	stale             map[string]int // consecutive recomputes each demand has gone without routing
+	visibilityHorizon time.Duration
+	schedule          *routing.LinkSchedule
+	scheduleEpoch     time.Time
+	gridConfig        coverage.GridConfig
+	gridPending       *coverage.GridConfig     // the grid a rebuild in progress will swap in
+	gridError         string                   // why the latest rebuild failed
+	regionGrids       []*coverage.CoverageGrid // fine grids of the scenario's coverage regions, in order
+	prebuiltGrid      *coverage.CoverageGrid   // covered off the lock by a grid rebuild for the next recompute
+	streamHeatmap     bool
+	heatmapGrid       *coverage.CoverageGrid // the latest recompute's grid when streamHeatmap is set; never modified
+	sharder           Sharder
+	history           *History
+	satellites        map[string]*Satellite
+	disabledShells    map[string]bool
+	ground            map[string]GroundStation
+	traffic           []TrafficDemand
+	demandWindows     map[string]demandWindows
+	deferred          map[string]bool // demands not routed until a hook adds them
+	policies          bool            // whether any demand chooses a routing policy
+	graph             *routing.Graph
+	routes            map[string]routing.Path
+	capacity          map[string]*capacityState // link capacity left per slice by the last recompute
+	directedCapacity  map[linkKey]float64       // link directions whose profile sets their capacity
+	events            *Subscription
+	subscribers       []*Subscription
+	snapshot          Snapshot
+	clock             time.Time
+	latency           *latencyRecorder
+	jitter            *jitterRecorder
+	admissions        *admissionRecorder
+	load              loadRecorder
+	timings           recomputeRecorder
+	trace             *recomputeTrace // the recompute in progress
+	version           uint64
+	topologyVersion   uint64 // bumped by mutations only; see Snapshot.TopologyVersion
+	scenario          Config
+	journal           []Operation
+	ttc               *TTCConfig
+	commands          []Command
+	contacts          *contactSchedule
+	plugins           Plugins
+	hooks             []*scenarioHook
+	firedHooks        []string // hooks fired by the step in progress
+}
+
+// ErrVersionConflict is returned when a conditional mutation names a topology version that is no
+// longer current, meaning another caller changed the topology first.
+var ErrVersionConflict = errors.New("snapshot version conflict")
+
+// ErrUnknownSatellite is returned when a mutation names a satellite the simulation does not have.
+var ErrUnknownSatellite = errors.New("unknown satellite")
+
+// ErrInvalidStep is returned when a step duration is not positive.
+var ErrInvalidStep = errors.New("step duration must be positive")
+
+// ErrDuplicateID is returned when a scenario gives two nodes the same ID. Satellites and ground
+// stations share one namespace, since both become nodes of the routing graph.
+type ErrDuplicateID struct {
+	ID string
+}
+
+func (e ErrDuplicateID) Error() string {
+	return fmt.Sprintf("duplicate node ID %q", e.ID)
 }
 
 // NewSimulator constructs a simulator from the provided configuration and computes the initial state.
@@ -85,13 +452,105 @@ func NewSimulator(cfg Config) (*Simulator, error) {
 	if err := cfg.GridConfig.Validate(); err != nil {
 		return nil, err
 	}
+	regionGrids, err := newRegionGrids(cfg.GridConfig, cfg.CoverageRegions)
+	if err != nil {
+		return nil, err
+	}
 	if len(cfg.Satellites) == 0 {
 		return nil, errors.New("simulation requires at least one satellite")
 	}
 	if len(cfg.GroundStations) == 0 {
 		return nil, errors.New("simulation requires at least one ground station")
 	}
+	if err := validateAdmission(cfg.Admission); err != nil {
+		return nil, err
+	}
+	elevationMask, err := elevationMaskRadians("scenario", cfg.ElevationMaskDeg, cfg.ElevationMask)
+	if err != nil {
+		return nil, err
+	}
+	links, terminalMask, err := linkSettings(cfg, elevationMask)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.LinkCapacityMbps < 0 {
+		return nil, errors.New("link capacity cannot be negative")
+	}
+	traffic := append([]TrafficDemand(nil), cfg.Traffic...)
+	if cfg.DemandGenerator != nil {
+		stations := make([]string, len(cfg.GroundStations))
+		for i, gs := range cfg.GroundStations {
+			stations[i] = gs.ID
+		}
+		generated, err := GenerateDemands(*cfg.DemandGenerator, stations)
+		if err != nil {
+			return nil, err
+		}
+		for _, demand := range cfg.Traffic {
+			if strings.HasPrefix(demand.ID, "generated-") {
+				return nil, fmt.Errorf("demand %q uses an ID reserved for generated demands", demand.ID)
+			}
+		}
+		traffic = append(traffic, generated...)
+	}
+	for _, demand := range traffic {
+		if demand.BandwidthMbps < 0 {
+			return nil, fmt.Errorf("demand %q bandwidth cannot be negative", demand.ID)
+		}
+		if err := validateDestination(demand, cfg.BGP != nil); err != nil {
+			return nil, err
+		}
+		if err := validateSource(demand); err != nil {
+			return nil, err
+		}
+	}
+	var network *bgp.Network
+	if cfg.BGP != nil {
+		var err error
+		if network, err = bgp.NewNetwork(*cfg.BGP); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.LinkLossRate < 0 || cfg.LinkLossRate >= 1 {
+		return nil, errors.New("link loss rate must be in [0, 1)")
+	}
+	if err := validateLinkMetadata(cfg.LinkMetadata); err != nil {
+		return nil, err
+	}
+	if err := validateLinkProfiles(cfg.LinkProfiles, cfg.LinkCapacityMbps); err != nil {
+		return nil, err
+	}
+	if err := cfg.TCP.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.VisibilityHorizonS < 0 {
+		return nil, errors.New("visibility horizon cannot be negative")
+	}
+	if cfg.VisibilityHorizonS > maxVisibilityHorizon.Seconds() {
+		return nil, fmt.Errorf("visibility horizon cannot exceed %v s", maxVisibilityHorizon.Seconds())
+	}
+	if cfg.RoutingBudgetMS < 0 {
+		return nil, errors.New("routing budget cannot be negative")
+	}
+	if cfg.TTC != nil {
+		if err := cfg.TTC.validate(cfg.GroundStations); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.Plugins.validate(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateSlices(cfg.Slices, traffic, cfg.LinkCapacityMbps); err != nil {
+		return nil, err
+	}
+	weights, err := qosWeights(cfg.QoSClasses, traffic)
+	if err != nil {
+		return nil, err
+	}
 
+	if cfg.Epoch.IsZero() {
+		cfg.Epoch = time.Now().UTC()
+	}
 	sats := make(map[string]*Satellite, len(cfg.Satellites))
 	for i := range cfg.Satellites {
 		sat := cfg.Satellites[i]
@@ -99,7 +558,85 @@ func NewSimulator(cfg Config) (*Simulator, error) {
 			return nil, errors.New("satellite ID cannot be empty")
 		}
 		if _, exists := sats[sat.ID]; exists {
-			return nil, errors.New("duplicate satellite ID")
+			return nil, ErrDuplicateID{ID: sat.ID}
+		}
+		if err := sat.Footprint.Validate(); err != nil {
+			return nil, fmt.Errorf("satellite %q footprint: %w", sat.ID, err)
+		}
+		if sat.FailureProbability < 0 || sat.FailureProbability > 1 {
+			return nil, fmt.Errorf("satellite %q failure probability must be between 0 and 1", sat.ID)
+		}
+		sat.wgs84 = cfg.WGS84
+		if sat.Location != nil {
+			sat.Position = locate(*sat.Location, cfg.WGS84)
+		}
+		if sat.Orbit != nil {
+			if err := sat.Orbit.Validate(); err != nil {
+				return nil, fmt.Errorf("satellite %q orbit: %w", sat.ID, err)
+			}
+			orbit := *sat.Orbit
+			if orbit.Epoch.IsZero() {
+				orbit.Epoch = cfg.Epoch
+			}
+			sat.Orbit = &orbit
+			state := earthFixed(cfg.EarthRotation, orbit.StateAt(cfg.Epoch), cfg.Epoch)
+			sat.Position, sat.Velocity = state.Position, state.Velocity
+			sat.centerFootprint()
+		}
+		if sat.TLE != nil {
+			if sat.Orbit != nil {
+				return nil, fmt.Errorf("satellite %q sets both orbit and tle", sat.ID)
+			}
+			tle := *sat.TLE
+			if tle.Epoch.IsZero() {
+				tle.Epoch = cfg.Epoch
+			}
+			propagator, err := orbits.NewSGP4(tle)
+			if err != nil {
+				return nil, fmt.Errorf("satellite %q tle: %w", sat.ID, err)
+			}
+			state, err := propagator.StateAt(cfg.Epoch)
+			if err != nil {
+				return nil, fmt.Errorf("satellite %q tle: %w", sat.ID, err)
+			}
+			state = earthFixed(cfg.EarthRotation, state, cfg.Epoch)
+			sat.TLE, sat.propagator = &tle, propagator
+			sat.Position, sat.Velocity = state.Position, state.Velocity
+			sat.centerFootprint()
+		}
+		if sat.Ephemeris != nil {
+			if sat.Orbit != nil || sat.TLE != nil {
+				return nil, fmt.Errorf("satellite %q sets an ephemeris along with an orbit or tle", sat.ID)
+			}
+			if err := sat.Ephemeris.Validate(); err != nil {
+				return nil, fmt.Errorf("satellite %q: %w", sat.ID, err)
+			}
+			state, err := sat.Ephemeris.StateAt(cfg.Epoch)
+			if err != nil {
+				return nil, fmt.Errorf("satellite %q ephemeris: %w", sat.ID, err)
+			}
+			state = earthFixed(cfg.EarthRotation, state, cfg.Epoch)
+			sat.propagator = sat.Ephemeris
+			sat.Position, sat.Velocity = state.Position, state.Velocity
+			sat.centerFootprint()
+		}
+		if sat.Propagator != "" {
+			factory, ok := cfg.Propagators[sat.Propagator]
+			if !ok {
+				return nil, fmt.Errorf("satellite %q: unknown propagator %q", sat.ID, sat.Propagator)
+			}
+			propagator, err := factory(sat)
+			if err != nil {
+				return nil, fmt.Errorf("satellite %q propagator: %w", sat.ID, err)
+			}
+			state, err := propagator.StateAt(cfg.Epoch)
+			if err != nil {
+				return nil, fmt.Errorf("satellite %q propagator: %w", sat.ID, err)
+			}
+			state = earthFixed(cfg.EarthRotation, state, cfg.Epoch)
+			sat.propagator = propagator
+			sat.Position, sat.Velocity = state.Position, state.Velocity
+			sat.centerFootprint()
 		}
 		sat.Active = true
 		sats[sat.ID] = &sat
@@ -110,43 +647,132 @@ func NewSimulator(cfg Config) (*Simulator, error) {
 		if gs.ID == "" {
 			return nil, errors.New("ground station ID cannot be empty")
 		}
+		if _, exists := ground[gs.ID]; exists {
+			return nil, ErrDuplicateID{ID: gs.ID}
+		}
+		if _, exists := sats[gs.ID]; exists {
+			return nil, ErrDuplicateID{ID: gs.ID}
+		}
+		if gs.Location != nil {
+			gs.Position = locate(*gs.Location, cfg.WGS84)
+		}
+		mask, err := elevationMaskRadians(fmt.Sprintf("ground station %q", gs.ID), gs.ElevationMaskDeg, gs.ElevationMask)
+		if err != nil {
+			return nil, err
+		}
+		gs.ElevationMask = mask
 		ground[gs.ID] = gs
 	}
+	// Location-sourced demands route from their terminal node; the scenario keeps the location.
+	for i, demand := range traffic {
+		if demand.FromLocation == nil {
+			continue
+		}
+		id := TerminalID(demand.ID)
+		if _, exists := sats[id]; exists {
+			return nil, ErrDuplicateID{ID: id}
+		}
+		if _, exists := ground[id]; exists {
+			return nil, ErrDuplicateID{ID: id}
+		}
+		traffic[i].FromID = id
+	}
+	windows := make(map[string]demandWindows)
+	deferred := make(map[string]bool)
+	policies := false
+	for _, demand := range traffic {
+		if demand.Deferred {
+			deferred[demand.ID] = true
+		}
+		if err := validatePolicy(demand, demand.FromID, cfg.Plugins.Routers); err != nil {
+			return nil, err
+		}
+		policies = policies || demand.Policy != ""
+		var source visibility.Vector3
+		switch {
+		case demand.FromLocation != nil:
+			source = locate(*demand.FromLocation, cfg.WGS84)
+		case sats[demand.FromID] != nil:
+			source = sats[demand.FromID].Position
+		default:
+			source = ground[demand.FromID].Position
+		}
+		parsed, err := parseDemandWindows(demand, source)
+		if err != nil {
+			return nil, err
+		}
+		if parsed != nil {
+			windows[demand.ID] = *parsed
+		}
+	}
 
 	sim := &Simulator{
-		elevationMask: cfg.ElevationMask,
-		gridConfig:    cfg.GridConfig,
-		satellites:    sats,
-		ground:        ground,
-		traffic:       cfg.Traffic,
-		routes:        make(map[string]routing.Path),
-		events:        make(chan Event, 8),
+		elevationMask:     elevationMask,
+		links:             links,
+		terminalMask:      terminalMask,
+		stabilityWeight:   cfg.StabilityWeight,
+		continuityPct:     cfg.RouteContinuityPct,
+		linkCapacity:      cfg.LinkCapacityMbps,
+		admission:         cfg.Admission,
+		qosWeights:        weights,
+		linkLoss:          cfg.LinkLossRate,
+		tcp:               cfg.TCP,
+		bgp:               network,
+		slices:            cfg.Slices,
+		routingBudgetMS:   cfg.RoutingBudgetMS,
+		validate:          cfg.ValidateInvariants,
+		visibilityHorizon: time.Duration(cfg.VisibilityHorizonS * float64(time.Second)),
+		gridConfig:        cfg.GridConfig,
+		regionGrids:       regionGrids,
+		streamHeatmap:     cfg.StreamHeatmap,
+		sharder:           cfg.Sharder,
+		satellites:        sats,
+		disabledShells:    make(map[string]bool),
+		ground:            ground,
+		traffic:           traffic,
+		demandWindows:     windows,
+		deferred:          deferred,
+		policies:          policies,
+		routes:            make(map[string]routing.Path),
+		clock:             cfg.Epoch,
+		latency:           newLatencyRecorder(),
+		jitter:            newJitterRecorder(),
+		admissions:        newAdmissionRecorder(),
+		scenario:          cfg,
+		ttc:               cfg.TTC,
+		plugins:           cfg.Plugins,
+	}
+	sim.events = sim.subscribeLocked(SubscribeOptions{Name: "default"})
+	if cfg.HistorySize > 0 {
+		sim.history = NewHistory(cfg.HistorySize)
+	}
+	if sim.hooks, err = sim.compileHooks(cfg.Hooks); err != nil {
+		return nil, err
 	}
 
 	if _, err := sim.recomputeLocked(); err != nil {
 		return nil, err
 	}
+	if sim.ttc != nil {
+		sim.contacts = newContactSchedule(sim.clock)
+		sim.scheduleContactsLocked()
+	}
 
 	return sim, nil
 }
 
-// NewDemoSimulator builds a simple network useful for manual testing of the API server.
+// DemoConfig is the network the API server runs without a scenario: a 12-satellite Walker delta
+// constellation in three planes at 3000 km and 53°, propagating from the current time, over the
+// preset gateways and demands. It is small enough to recompute on every tick yet shows satellites
+// moving, coverage sweeping and routes handing over, and its demands lose their route now and
+// then when no satellite bridges the gap.
+func DemoConfig() Config {
+	return presetConfig(10, walker("demo", 12, 3, 1, 3000, 53, 360))
+}
+
+// NewDemoSimulator builds a simulator for DemoConfig.
 func NewDemoSimulator() *Simulator {
-	cfg := Config{
-		GridConfig:    coverage.GridConfig{LatStep: 180, LonStep: 360},
-		ElevationMask: 0,
-		Satellites: []Satellite{
-			{ID: "sat-alpha", Position: visibility.Vector3{X: visibility.EarthRadius + 400, Y: 0, Z: 0}, Footprint: coverage.Footprint{CenterLat: 0, CenterLon: 0, RadiusKm: 900, LinkStrength: 1}},
-			{ID: "sat-beta", Position: visibility.Vector3{X: visibility.EarthRadius + 800, Y: 200, Z: 0}, Footprint: coverage.Footprint{CenterLat: 45, CenterLon: 90, RadiusKm: 900, LinkStrength: 0.8}},
-		},
-		GroundStations: []GroundStation{
-			{ID: "ground-1", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 0, Z: 0}},
-			{ID: "ground-2", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 10, Z: 0}},
-		},
-		Traffic: []TrafficDemand{{ID: "demo", FromID: "ground-1", ToID: "ground-2"}},
-	}
-
-	sim, err := NewSimulator(cfg)
+	sim, err := NewSimulator(DemoConfig())
 	if err != nil {
 		// The demo should never fail; panic to surface configuration issues.
 		panic(err)
@@ -154,9 +780,11 @@ func NewDemoSimulator() *Simulator {
 	return sim
 }
 
-// Events exposes a read-only channel of simulator updates for streaming to the frontend.
+// Events exposes a read-only channel of simulator updates for streaming to the frontend. It is
+// the built-in "default" subscription: 8 events buffered with DropNewest. Use Subscribe for
+// other policies.
 func (s *Simulator) Events() <-chan Event {
-	return s.events
+	return s.events.Events()
 }
 
 // Snapshot returns the latest computed state.
@@ -166,26 +794,85 @@ func (s *Simulator) Snapshot() Snapshot {
 	return s.snapshot
 }
 
+// StreamHeatmap returns the latest snapshot with a function that calls yield with each cell of its
+// heatmap, in grid order, until yield returns false. Under Config.StreamHeatmap the cells are read
+// from the retained coverage grid, so they are never copied; the function may run without holding
+// the simulator.
+func (s *Simulator) StreamHeatmap() (Snapshot, func(yield func(coverage.HeatmapCell) bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, grid := s.snapshot, s.heatmapGrid
+	if grid == nil || !s.streamHeatmap {
+		return snap, func(yield func(coverage.HeatmapCell) bool) {
+			for _, cell := range snap.Heatmap {
+				if !yield(cell) {
+					return
+				}
+			}
+		}
+	}
+	return snap, grid.StreamHeatmap
+}
+
+// heatmapLocked returns the latest heatmap, materializing it from the retained grid under
+// Config.StreamHeatmap.
+func (s *Simulator) heatmapLocked() []coverage.HeatmapCell {
+	if s.streamHeatmap && s.heatmapGrid != nil {
+		return s.heatmapGrid.HeatmapData()
+	}
+	return s.snapshot.Heatmap
+}
+
 // DisableSatellite marks a satellite inactive and recomputes the network.
 func (s *Simulator) DisableSatellite(id string) (Snapshot, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	sat, ok := s.satellites[id]
-	if !ok {
-		return Snapshot{}, errors.New("unknown satellite")
+	return s.disableSatelliteLocked(id)
+}
+
+// DisableSatelliteIfMatch disables a satellite only when version matches the current topology version.
+func (s *Simulator) DisableSatelliteIfMatch(id string, version uint64) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topologyVersion != version {
+		return Snapshot{}, ErrVersionConflict
 	}
-	sat.Active = false
-	return s.recomputeLocked()
+	return s.disableSatelliteLocked(id)
+}
+
+// RemoveSatelliteIfMatch removes a satellite only when version matches the current topology version.
+func (s *Simulator) RemoveSatelliteIfMatch(id string, version uint64) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topologyVersion != version {
+		return Snapshot{}, ErrVersionConflict
+	}
+	return s.removeSatelliteLocked(id)
 }
 
 // RemoveSatellite deletes a satellite entirely and recomputes the network.
 func (s *Simulator) RemoveSatellite(id string) (Snapshot, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.removeSatelliteLocked(id)
+}
+
+func (s *Simulator) disableSatelliteLocked(id string) (Snapshot, error) {
+	sat, ok := s.satellites[id]
+	if !ok {
+		return Snapshot{}, fmt.Errorf("%w %q", ErrUnknownSatellite, id)
+	}
+	sat.Active = false
+	s.record(Operation{Op: OpDisableSatellite, Target: id})
+	return s.recomputeLocked()
+}
+
+func (s *Simulator) removeSatelliteLocked(id string) (Snapshot, error) {
 	if _, ok := s.satellites[id]; !ok {
-		return Snapshot{}, errors.New("unknown satellite")
+		return Snapshot{}, fmt.Errorf("%w %q", ErrUnknownSatellite, id)
 	}
 	delete(s.satellites, id)
+	s.record(Operation{Op: OpRemoveSatellite, Target: id})
 	return s.recomputeLocked()
 }
 
@@ -193,62 +880,357 @@ func (s *Simulator) RemoveSatellite(id string) (Snapshot, error) {
 func (s *Simulator) Recompute() (Snapshot, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.record(Operation{Op: OpRecompute})
 	return s.recomputeLocked()
 }
 
+// RunSummary reports aggregate statistics for a time-stepped run.
+type RunSummary struct {
+	Steps   int                     `json:"steps"`
+	Start   time.Time               `json:"start"`
+	End     time.Time               `json:"end"`
+	Latency map[string]LatencyStats `json:"latency"`
+	Jitter  map[string]JitterStats  `json:"jitter"`
+	// Blocking is keyed by demand priority and only populated when link capacity is modeled.
+	Blocking map[int]BlockingStats `json:"blocking,omitempty"`
+	// Hourly breaks the load carried down by UTC hour of the day, for hours the run stepped through.
+	Hourly []HourlyLoad `json:"hourly,omitempty"`
+	// Policies pools latency per routing policy when any demand chooses one.
+	Policies map[string]LatencyStats `json:"policies,omitempty"`
+	Snapshot Snapshot                `json:"snapshot"`
+}
+
+// Step advances the simulation clock by dt, moves satellites along their orbits, recomputes the
+// network, and records per-demand latency samples.
+func (s *Simulator) Step(dt time.Duration) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stepLocked(dt)
+}
+
+// Run executes the requested number of steps and returns the latency distributions accumulated so far.
+func (s *Simulator) Run(steps int, dt time.Duration) (RunSummary, error) {
+	if steps <= 0 {
+		return RunSummary{}, errors.New("steps must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := s.clock
+	var snapshot Snapshot
+	for i := 0; i < steps; i++ {
+		var err error
+		if snapshot, err = s.stepLocked(dt); err != nil {
+			return RunSummary{}, err
+		}
+	}
+
+	summary := RunSummary{Steps: steps, Start: start, End: s.clock, Latency: s.latency.summary(), Jitter: s.jitter.summary(), Hourly: s.load.summary(), Policies: s.policyLatencyLocked(), Snapshot: snapshot}
+	if s.linkCapacity > 0 {
+		summary.Blocking = s.admissions.summary()
+	}
+	return summary, nil
+}
+
+// LatencySummary returns the per-demand latency distributions recorded by time-stepped runs.
+func (s *Simulator) LatencySummary() map[string]LatencyStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latency.summary()
+}
+
+// JitterSummary returns the per-demand latency variation recorded by time-stepped runs.
+func (s *Simulator) JitterSummary() map[string]JitterStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jitter.summary()
+}
+
+// RIB returns a node's best advertised routes, or nil when the scenario has no BGP section or the
+// node is not in the current topology.
+func (s *Simulator) RIB(node string) []bgp.Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bgp == nil {
+		return nil
+	}
+	return s.bgp.RIB(node)
+}
+
+// Topology is a consistent view of the routing graph and routed demands from one recompute.
+type Topology struct {
+	Graph            *routing.Graph
+	Snapshot         Snapshot
+	Traffic          []TrafficDemand
+	LinkCapacityMbps float64
+}
+
+// Topology returns the graph and snapshot of the latest recompute. The graph is not modified after
+// a recompute and may be read concurrently.
+func (s *Simulator) Topology() Topology {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	traffic := make([]TrafficDemand, len(s.traffic))
+	copy(traffic, s.traffic)
+	return Topology{Graph: s.graph, Snapshot: s.snapshot, Traffic: traffic, LinkCapacityMbps: s.linkCapacity}
+}
+
+// AdmissionSummary returns the per-priority blocking statistics recorded by time-stepped runs.
+func (s *Simulator) AdmissionSummary() map[int]BlockingStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.admissions.summary()
+}
+
+func (s *Simulator) stepLocked(dt time.Duration) (Snapshot, error) {
+	if dt <= 0 {
+		return Snapshot{}, ErrInvalidStep
+	}
+
+	s.clock = s.clock.Add(dt)
+	s.record(Operation{Op: OpStep, DT: dt})
+	var orbiting []*Satellite
+	var elements []orbits.KeplerianElements
+	for _, sat := range s.satellites {
+		if sat.propagator != nil {
+			state, err := sat.propagator.StateAt(s.clock)
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("satellite %q: %w", sat.ID, err)
+			}
+			state = earthFixed(s.scenario.EarthRotation, state, s.clock)
+			sat.Position, sat.Velocity = state.Position, state.Velocity
+			sat.centerFootprint()
+			continue
+		}
+		if sat.Orbit != nil {
+			orbiting = append(orbiting, sat)
+			elements = append(elements, *sat.Orbit)
+			continue
+		}
+		if sat.Velocity == (visibility.Vector3{}) {
+			continue
+		}
+		sat.Position, sat.Velocity = visibility.PropagateCircular(sat.Position, sat.Velocity, dt.Seconds())
+		sat.centerFootprint()
+	}
+	for i, state := range orbits.PropagateBatch(elements, s.clock) {
+		state = earthFixed(s.scenario.EarthRotation, state, s.clock)
+		orbiting[i].Position, orbiting[i].Velocity = state.Position, state.Velocity
+		orbiting[i].centerFootprint()
+	}
+	if s.ttc != nil {
+		s.applyDueCommandsLocked()
+	}
+
+	snapshot, err := s.recomputeLocked()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if fired := s.runHooksLocked(snapshot); len(fired) > 0 {
+		s.firedHooks = fired
+		snapshot, err = s.recomputeLocked()
+		s.firedHooks = nil
+		if err != nil {
+			return Snapshot{}, err
+		}
+	}
+	if s.ttc != nil {
+		s.scheduleContactsLocked()
+		s.uplinkCommandsLocked()
+	}
+	active, routed, carried := 0, 0, 0.0
+	for _, demand := range s.traffic {
+		if !s.activeLocked(demand) {
+			continue
+		}
+		active++
+		path, ok := snapshot.Routes[demand.ID]
+		s.latency.record(demand.ID, path.LatencyMS, ok)
+		s.jitter.record(demand.ID, path.Nodes, path.LatencyMS, ok)
+		alloc, allocated := snapshot.Allocations[demand.ID]
+		if allocated {
+			s.admissions.record(alloc)
+		}
+		switch {
+		case allocated && (alloc.Status == StatusAdmitted || alloc.Status == StatusDegraded):
+			routed++
+			carried += alloc.AllocatedMbps
+		case !allocated && ok:
+			routed++
+			carried += demand.BandwidthMbps
+		}
+	}
+	s.load.record(s.clock, active, routed, carried)
+	return snapshot, nil
+}
+
+// earthFixed rotates an inertial state into the Earth-fixed frame at t when rotate is set.
+func earthFixed(rotate bool, state orbits.StateVector, t time.Time) orbits.StateVector {
+	if !rotate {
+		return state
+	}
+	return orbits.ECIToECEF(state, t)
+}
+
 func (s *Simulator) recomputeLocked() (Snapshot, error) {
+	var timings PhaseTimings
+	started := time.Now()
+	s.trace = &recomputeTrace{}
+	defer func() { s.trace = nil }()
 	nodes := make([]routing.Node, 0, len(s.satellites)+len(s.ground))
 	activeIDs := make([]string, 0, len(s.satellites))
 	disabledIDs := make([]string, 0)
-	footprints := make([]coverage.Footprint, 0, len(s.satellites))
+	footprints := make(map[string]coverage.Footprint, len(s.satellites))
 
 	for _, sat := range s.satellites {
-		if sat.Active {
-			nodes = append(nodes, routing.Node{ID: sat.ID, Type: routing.Satellite, Position: sat.Position})
+		if sat.Active && !s.disabledShells[sat.shellKey()] {
+			nodes = append(nodes, sat.routingNode())
 			activeIDs = append(activeIDs, sat.ID)
-			footprints = append(footprints, sat.Footprint)
+			footprints[sat.ID] = sat.coverageFootprint(s.elevationMask)
 		} else {
 			disabledIDs = append(disabledIDs, sat.ID)
 		}
 	}
 	for _, gs := range s.ground {
-		nodes = append(nodes, routing.Node{ID: gs.ID, Type: routing.Ground, Position: gs.Position})
+		nodes = append(nodes, gs.routingNode())
 	}
+	// Map iteration order is random; sort so identical runs publish identical snapshots.
+	sort.Strings(activeIDs)
+	sort.Strings(disabledIDs)
 
-	graph, err := routing.BuildGraph(nodes, s.elevationMask)
+	graph, grid, err := s.buildLocked(nodes, footprints, &timings)
 	if err != nil {
 		return Snapshot{}, err
 	}
+	// The grid returns to the pool on every path except a streamed snapshot being published: readers
+	// may still hold a streamed grid, so it is never returned once kept.
+	kept := false
+	defer func() {
+		if !kept {
+			releaseGrid(grid)
+		}
+	}()
+	if err := s.applyLinkModelsLocked(graph); err != nil {
+		return Snapshot{}, err
+	}
+	serving := s.attachTerminalsLocked(graph, activeIDs)
+	annotateLinks(graph, s.scenario.LinkMetadata)
+	s.directedCapacity = applyLinkProfiles(graph, s.scenario.LinkProfiles)
 	s.graph = graph
 
-	routes := make(map[string]routing.Path, len(s.traffic))
-	for _, demand := range s.traffic {
-		path, err := routing.ShortestPath(graph, demand.FromID, demand.ToID, func(id string) float64 {
-			return graph.Heuristic(id, demand.ToID)
-		})
-		if err == nil {
-			routes[demand.ID] = path
+	phase := time.Now()
+	budget := s.newRoutingBudget()
+	var convergence *bgp.Convergence
+	if s.bgp != nil {
+		bgpStarted := time.Now()
+		result := s.bgp.Converge(graph)
+		convergence = &result
+		s.trace.stage("bgp", bgpStarted, nil)
+	}
+	var routes map[string]routing.Path
+	var allocations map[string]Allocation
+	var classes map[string]ClassMetrics
+	var slices map[string]SliceMetrics
+	continuityPenalty := 0.0
+	if s.linkCapacity > 0 {
+		routes, allocations, continuityPenalty, s.capacity = s.routeWithCapacityLocked(graph, budget)
+		classes = s.applyQoSLocked(routes, allocations)
+		slices = s.sliceMetrics(s.capacity, allocations)
+	} else {
+		routes = make(map[string]routing.Path, len(s.traffic))
+		for _, demand := range s.routingOrderLocked() {
+			if budget.exhausted() {
+				budget.stale = append(budget.stale, demand.ID)
+				if path, err := s.previousPathLocked(graph, demand, nil); err == nil {
+					routes[demand.ID] = path
+				}
+				continue
+			}
+			budget.computed++
+			path, err := s.findPathLocked(graph, demand, nil)
+			if err == nil {
+				if previous, ok := s.routes[demand.ID]; ok && keepsPreviousRoute(demand) {
+					path = routing.RetainPreviousPath(graph, previous, path, s.continuityPct)
+				}
+				routes[demand.ID] = path
+				continuityPenalty += path.ContinuityPenaltyMS
+			}
 		}
 	}
 	s.routes = routes
+	stale := budget.staleIDs()
+	ages := make(map[string]int, len(stale))
+	for _, id := range stale {
+		ages[id] = s.stale[id] + 1
+	}
+	s.stale = ages
+	timings.Routing = time.Since(phase)
+	s.trace.stage("routing", phase, map[string]any{
+		"demands":        len(s.traffic),
+		"stale":          len(stale),
+		"capacityAware":  s.linkCapacity > 0,
+		"untracedRoutes": s.trace.untraced,
+	})
 
-	grid, err := coverage.NewCoverageGrid(s.gridConfig)
+	phase = time.Now()
+	summary := grid.Summarize()
+	summary.Expected = s.expectedCoverageLocked(grid, footprints)
+	if summary.Bounds, err = coverage.Bounds(summary, grid, sortedFootprints(footprints), s.coverLocked); err != nil {
+		return Snapshot{}, err
+	}
+	var heatmap []coverage.HeatmapCell
+	var streamed *coverage.CoverageGrid
+	if s.streamHeatmap {
+		streamed = grid
+	} else {
+		heatmap = grid.HeatmapData()
+	}
+	summary.Regions = s.regionSummariesLocked(footprints)
+	shells, err := s.shellMetricsLocked()
 	if err != nil {
 		return Snapshot{}, err
 	}
-	grid.ApplyFootprints(footprints)
-	summary := grid.Summarize()
+	timings.Snapshot = time.Since(phase)
+	s.trace.stage("snapshot", phase, nil)
 
+	s.version++
 	snapshot := Snapshot{
-		Timestamp:          time.Now().UTC(),
-		ActiveSatellites:   activeIDs,
-		DisabledSatellites: disabledIDs,
-		Coverage:           summary,
-		Heatmap:            grid.HeatmapData(),
-		Routes:             routes,
+		Version:             s.version,
+		TopologyVersion:     s.topologyVersion,
+		Timestamp:           time.Now().UTC(),
+		SimTime:             s.clock,
+		ActiveSatellites:    activeIDs,
+		DisabledSatellites:  disabledIDs,
+		Coverage:            summary,
+		Heatmap:             heatmap,
+		Routes:              routes,
+		ContinuityPenaltyMS: continuityPenalty,
+		Allocations:         allocations,
+		BGP:                 convergence,
+		Classes:             classes,
+		Slices:              slices,
+		StaleRoutes:         stale,
+		Shells:              shells,
+		ServingSatellites:   serving,
+		InactiveDemands:     s.inactiveLocked(),
+		FiredHooks:          s.firedHooks,
+		Policies:            s.policyMetricsLocked(routes),
+	}
+	if s.validate {
+		if violations := CheckInvariants(graph, snapshot, s.traffic); len(violations) > 0 {
+			return Snapshot{}, &InvariantError{Version: snapshot.Version, Violations: violations}
+		}
 	}
 
-	s.snapshot = snapshot
+	s.snapshot, s.heatmapGrid = snapshot, streamed
+	kept = streamed != nil
+	if s.history != nil {
+		s.history.Append(snapshot)
+	}
+	timings.Total = time.Since(started)
+	s.timings.record(timings)
+	s.trace.version = s.version
+	s.timings.keep(*s.trace)
 
 	s.publishEvent(EventTopologyUpdated, snapshot)
 	s.publishEvent(EventCoverageUpdated, snapshot)
@@ -256,10 +1238,130 @@ func (s *Simulator) recomputeLocked() (Snapshot, error) {
 	return snapshot, nil
 }
 
+// RecomputeStats reports how long recomputes have taken, broken down by phase.
+func (s *Simulator) RecomputeStats() RecomputeStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timings.stats()
+}
+
+// RecomputeTrace returns the stage spans of the recompute that produced the snapshot version, while
+// it is among the last few recomputes.
+func (s *Simulator) RecomputeTrace(version uint64) ([]StageSpan, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timings.trace(version)
+}
+
+// SetHistorySize replaces the snapshot history with an empty buffer of n entries; zero disables it.
+func (s *Simulator) SetHistorySize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = nil
+	if n > 0 {
+		s.history = NewHistory(n)
+	}
+}
+
+// HistorySummaries lists the buffered snapshots, oldest first, and the compressed size in bytes.
+func (s *Simulator) HistorySummaries() ([]HistorySummary, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.history == nil {
+		return nil, 0
+	}
+	return s.history.Summaries(), s.history.SizeBytes()
+}
+
+// HistorySnapshot returns the buffered snapshot with the given version.
+func (s *Simulator) HistorySnapshot(version uint64) (Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.history == nil {
+		return Snapshot{}, false
+	}
+	return s.history.Get(version)
+}
+
+// buildLocked computes the routing graph and coverage grid, on shard workers when configured.
+// With a visibility horizon the graph comes from the link schedule in process, since the schedule
+// exists to avoid re-testing every pair, and only the coverage goes to the shards; a grid already
+// covered by a rebuild is used as is.
+func (s *Simulator) buildLocked(nodes []routing.Node, footprints map[string]coverage.Footprint, timings *PhaseTimings) (*routing.Graph, *coverage.CoverageGrid, error) {
+	started := time.Now()
+	if s.sharder != nil && s.visibilityHorizon <= 0 && s.prebuiltGrid == nil {
+		defer func() {
+			timings.Sharded = time.Since(started)
+			s.trace.stage("sharded", started, map[string]any{"nodes": len(nodes), "footprints": len(footprints)})
+		}()
+		// The simulator stays locked while the shards compute, so a stalled worker must not hold it forever.
+		ctx, cancel := context.WithTimeout(context.Background(), ShardTimeout)
+		defer cancel()
+		return s.sharder.Compute(ctx, nodes, footprints, s.links, s.gridConfig)
+	}
+
+	graph, err := s.graphLocked(nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	timings.Visibility = time.Since(started)
+	s.trace.stage("visibility", started, map[string]any{"nodes": len(nodes)})
+
+	started = time.Now()
+	defer func() {
+		timings.Coverage = time.Since(started)
+		s.trace.stage("coverage", started, map[string]any{"footprints": len(footprints)})
+	}()
+	if grid := s.prebuiltGrid; grid != nil {
+		s.prebuiltGrid = nil
+		return graph, grid, nil
+	}
+	list := make([]coverage.Footprint, 0, len(footprints))
+	for _, fp := range footprints {
+		list = append(list, fp)
+	}
+	grid, err := s.coverLocked(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	return graph, grid, nil
+}
+
+// coverLocked computes a coverage grid for footprints the way a recompute does: on the shards
+// when sharding, in process otherwise. The grid comes from coverage.AcquireGrid.
+func (s *Simulator) coverLocked(footprints []coverage.Footprint) (*coverage.CoverageGrid, error) {
+	if s.sharder != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), ShardTimeout)
+		defer cancel()
+		return s.sharder.Cover(ctx, footprints, s.gridConfig)
+	}
+	grid, err := coverage.AcquireGrid(s.gridConfig)
+	if err != nil {
+		return nil, err
+	}
+	grid.ApplyFootprints(footprints)
+	return grid, nil
+}
+
+// graphLocked builds the routing graph, from the precomputed link schedule when one is configured.
+func (s *Simulator) graphLocked(nodes []routing.Node) (*routing.Graph, error) {
+	if s.visibilityHorizon <= 0 {
+		return routing.BuildGraph(nodes, s.links.Options()...)
+	}
+	elapsed := s.clock.Sub(s.scheduleEpoch).Seconds()
+	if s.schedule == nil || !s.schedule.Covers(nodes, elapsed) {
+		schedule, err := routing.NewLinkSchedule(nodes, s.elevationMask, s.visibilityHorizon, s.links.Options()...)
+		if err != nil {
+			return nil, err
+		}
+		s.schedule, s.scheduleEpoch, elapsed = schedule, s.clock, 0
+	}
+	return s.schedule.GraphAt(nodes, elapsed)
+}
+
 func (s *Simulator) publishEvent(eventType EventType, snapshot Snapshot) {
-	select {
-	case s.events <- Event{Type: eventType, Snapshot: snapshot}:
-	default:
-		// Drop the event when the channel is full to avoid blocking the caller.
+	evt := Event{Type: eventType, Snapshot: snapshot}
+	for _, sub := range s.subscribers {
+		sub.deliver(evt)
 	}
 }
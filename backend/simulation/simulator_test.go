@@ -1,10 +1,17 @@
 package simulation
 
 import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/routing"
 	"github.com/example/satnet/backend/visibility"
 )
 
@@ -97,6 +104,257 @@ func TestCoverageUpdatesAfterRemoval(t *testing.T) {
 	}
 }
 
+func TestGeodeticLocationsAreConverted(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "overhead", Location: &visibility.Geodetic{LatDeg: 10, LonDeg: 20, AltKm: 550}, Footprint: coverage.Footprint{CenterLat: 10, CenterLon: 20, RadiusKm: 1000}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "gateway", Location: &visibility.Geodetic{LatDeg: 10, LonDeg: 20}},
+			{ID: "user", Location: &visibility.Geodetic{LatDeg: 11, LonDeg: 20}},
+		},
+		Traffic: []TrafficDemand{{ID: "link", FromID: "gateway", ToID: "user"}},
+	}
+
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+
+	path, ok := sim.Snapshot().Routes["link"]
+	if !ok {
+		t.Fatalf("expected route through the overhead satellite")
+	}
+	// Straight up and back down: roughly 2 x 550 km at the speed of light.
+	if path.LatencyMS < 3.6 || path.LatencyMS > 4.0 {
+		t.Fatalf("unexpected latency %.3f ms for geodetic placement", path.LatencyMS)
+	}
+}
+
+func TestOrbitElementsDriveSatelliteMotion(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	orbit := orbits.KeplerianElements{SemiMajorAxis: visibility.EarthRadius + 550, Inclination: math.Pi / 3}
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "kepler", Orbit: &orbit, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}},
+		},
+		Epoch: epoch,
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	// A zero orbit epoch is taken to be the simulation's.
+	orbit.Epoch = epoch
+	if got, want := sim.satellites["kepler"].Position, orbit.StateAt(epoch).Position; got != want {
+		t.Fatalf("initial position %+v, want %+v", got, want)
+	}
+
+	for step := 0; step < 3; step++ {
+		if _, err := sim.Step(5 * time.Minute); err != nil {
+			t.Fatalf("step %d: %v", step, err)
+		}
+	}
+	sat := sim.satellites["kepler"]
+	want := orbit.StateAt(epoch.Add(15 * time.Minute))
+	if sat.Position != want.Position || sat.Velocity != want.Velocity {
+		t.Fatalf("state after 15 minutes %+v/%+v, want %+v", sat.Position, sat.Velocity, want)
+	}
+	subPoint := visibility.ToGeodetic(sat.Position)
+	if sat.Footprint.CenterLat != subPoint.LatDeg || sat.Footprint.CenterLon != subPoint.LonDeg {
+		t.Fatalf("footprint not centered on the sub-satellite point: %+v", sat.Footprint)
+	}
+
+	cfg.Satellites[0].Orbit = &orbits.KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 1.2}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a hyperbolic orbit with a positive semi-major axis to be rejected")
+	}
+}
+
+func TestTLEDrivesSatelliteMotion(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tle := orbits.TLEElements{InclinationDeg: 53, Eccentricity: 0.0001, MeanMotion: 15.06, BStar: 1e-4}
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "starlink", TLE: &tle, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}},
+		},
+		Epoch: epoch,
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	// A zero element set epoch is taken to be the simulation's.
+	tle.Epoch = epoch
+	propagator, err := orbits.NewSGP4(tle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Step(20 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := propagator.StateAt(epoch.Add(20 * time.Minute))
+	if sat := sim.satellites["starlink"]; sat.Position != want.Position || sat.Velocity != want.Velocity {
+		t.Fatalf("state after 20 minutes %+v/%+v, want %+v", sat.Position, sat.Velocity, want)
+	}
+	if shells := sim.Snapshot().Shells; len(shells) != 0 {
+		t.Fatalf("expected a single shell, got %v", shells)
+	}
+
+	cfg.Satellites[0].Orbit = &orbits.KeplerianElements{SemiMajorAxis: 7000}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a satellite with both orbit and tle to be rejected")
+	}
+	cfg.Satellites[0].Orbit = nil
+	cfg.Satellites[0].TLE = &orbits.TLEElements{Eccentricity: 0.05, MeanMotion: 16}
+	if _, err := NewSimulator(cfg); !errors.Is(err, orbits.ErrDecayed) {
+		t.Fatalf("expected an element set below the surface to be rejected, got %v", err)
+	}
+}
+
+func TestEphemerisDrivesSatelliteMotion(t *testing.T) {
+	epoch := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	orbit := orbits.KeplerianElements{SemiMajorAxis: 7000, Eccentricity: 0.01, Inclination: 0.9, Epoch: epoch}
+	ephemeris := &orbits.Ephemeris{Interpolation: orbits.InterpolationHermite}
+	for i := 0; i <= 60; i++ {
+		at := epoch.Add(time.Duration(i) * time.Minute)
+		state := orbit.StateAt(at)
+		ephemeris.Points = append(ephemeris.Points, orbits.EphemerisPoint{Time: at, Position: state.Position, Velocity: state.Velocity})
+	}
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "flight-dynamics", Ephemeris: ephemeris, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}},
+		},
+		Epoch: epoch,
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	if _, err := sim.Step(25*time.Minute + 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	want := orbit.StateAt(epoch.Add(25*time.Minute + 30*time.Second))
+	sat := sim.satellites["flight-dynamics"]
+	if d := visibility.SlantRange(sat.Position, want.Position); d > 1e-3 {
+		t.Fatalf("expected the ephemeris to track the orbit, off by %g km", d)
+	}
+	if _, err := sim.Step(time.Hour); !errors.Is(err, orbits.ErrOutsideEphemeris) {
+		t.Fatalf("expected stepping past the ephemeris to fail, got %v", err)
+	}
+	if _, err := sim.Step(0); !errors.Is(err, ErrInvalidStep) {
+		t.Fatalf("expected a zero step to be invalid, got %v", err)
+	}
+
+	cfg.Satellites[0].Orbit = &orbit
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a satellite with both an ephemeris and an orbit to be rejected")
+	}
+	cfg.Satellites[0].Orbit = nil
+	cfg.Epoch = epoch.Add(-time.Minute)
+	if _, err := NewSimulator(cfg); !errors.Is(err, orbits.ErrOutsideEphemeris) {
+		t.Fatalf("expected an epoch before the ephemeris to be rejected, got %v", err)
+	}
+}
+
+func TestAutoFootprintFollowsAltitudeAndMask(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 1, LonStep: 1},
+		Satellites: []Satellite{
+			{ID: "auto", Location: &visibility.Geodetic{AltKm: 550}, Footprint: coverage.Footprint{LinkStrength: 1, Auto: true}},
+		},
+		GroundStations: []GroundStation{{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}}},
+		ElevationMask:  25 * math.Pi / 180,
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+
+	radius := coverage.FootprintRadiusForAltitude(550, 25)
+	covered := 0
+	for _, cell := range sim.Snapshot().Heatmap {
+		lat, lon := cell.Lat*math.Pi/180, cell.Lon*math.Pi/180
+		distance := coverage.EarthRadiusKm * math.Acos(math.Cos(lat)*math.Cos(lon))
+		if (distance < radius-1 && cell.Count == 0) || (distance > radius+1 && cell.Count > 0) {
+			t.Fatalf("cell %v,%v at %.0f km disagrees with a %.0f km footprint", cell.Lat, cell.Lon, distance, radius)
+		}
+		covered += cell.Count
+	}
+	if covered == 0 {
+		t.Fatal("expected the auto footprint to cover some cells")
+	}
+}
+
+func TestConditionalMutationRejectsStaleVersion(t *testing.T) {
+	sim := NewDemoSimulator()
+	read := sim.Snapshot()
+
+	// Steps advance the snapshot but not the topology, so they do not invalidate the read.
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := sim.DisableSatelliteIfMatch("demo-02-01", read.TopologyVersion)
+	if err != nil {
+		t.Fatalf("expected matching version to apply: %v", err)
+	}
+	if updated.TopologyVersion <= read.TopologyVersion {
+		t.Fatalf("expected topology version to advance, got %d after %d", updated.TopologyVersion, read.TopologyVersion)
+	}
+
+	if _, err := sim.RemoveSatelliteIfMatch("demo-01-01", read.TopologyVersion); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected version conflict for stale read, got %v", err)
+	}
+	if got := sim.Snapshot().ActiveSatellites; !contains(got, "demo-01-01") {
+		t.Fatalf("stale mutation must not apply, active satellites: %v", got)
+	}
+}
+
+func TestSimulatorReportsTypedErrors(t *testing.T) {
+	sim := NewDemoSimulator()
+	if _, err := sim.DisableSatellite("sat-missing"); !errors.Is(err, ErrUnknownSatellite) {
+		t.Fatalf("expected ErrUnknownSatellite, got %v", err)
+	}
+	if _, err := sim.RemoveSatellite("sat-missing"); !errors.Is(err, ErrUnknownSatellite) {
+		t.Fatalf("expected ErrUnknownSatellite, got %v", err)
+	}
+
+	sat := Satellite{ID: "sat", Position: visibility.Vector3{X: visibility.EarthRadius + 550}}
+	for name, cfg := range map[string]Config{
+		"satellites": {
+			Satellites:     []Satellite{sat, sat},
+			GroundStations: []GroundStation{{ID: "gw"}},
+		},
+		"ground stations": {
+			Satellites:     []Satellite{sat},
+			GroundStations: []GroundStation{{ID: "gw"}, {ID: "gw"}},
+		},
+		"satellite and ground station": {
+			Satellites:     []Satellite{sat},
+			GroundStations: []GroundStation{{ID: "sat"}},
+		},
+	} {
+		cfg.GridConfig = coverage.GridConfig{LatStep: 90, LonStep: 90}
+		var dup ErrDuplicateID
+		if _, err := NewSimulator(cfg); !errors.As(err, &dup) {
+			t.Fatalf("%s: expected ErrDuplicateID, got %v", name, err)
+		}
+	}
+}
+
 func drainEvents(sim *Simulator) {
 	for {
 		select {
@@ -131,3 +389,363 @@ func contains(list []string, target string) bool {
 	}
 	return false
 }
+
+func TestVisibilityScheduleMatchesPerStepGeometry(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "east", Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: -800}, Velocity: visibility.Vector3{Y: 7.6}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+			{ID: "west", Position: visibility.Vector3{X: visibility.EarthRadius + 550, Y: 800}, Velocity: visibility.Vector3{Y: -7.6}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+			{ID: "parked", Position: visibility.Vector3{X: visibility.EarthRadius + 900, Y: 10}, Footprint: coverage.Footprint{RadiusKm: 1000, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "ground-a", Position: visibility.Vector3{X: visibility.EarthRadius}},
+			{ID: "ground-b", Position: visibility.Vector3{X: visibility.EarthRadius, Y: 20}},
+		},
+		Traffic: []TrafficDemand{{ID: "voip", FromID: "ground-a", ToID: "ground-b"}},
+		Epoch:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	baseline, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	cfg.VisibilityHorizonS = 60
+	scheduled, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+
+	for step := 0; step < 40; step++ {
+		if step == 20 {
+			// Topology changes invalidate the schedule.
+			if _, err := baseline.DisableSatellite("parked"); err != nil {
+				t.Fatalf("disable failed: %v", err)
+			}
+			if _, err := scheduled.DisableSatellite("parked"); err != nil {
+				t.Fatalf("disable failed: %v", err)
+			}
+		}
+		want, err := baseline.Step(5 * time.Second)
+		if err != nil {
+			t.Fatalf("step %d: %v", step, err)
+		}
+		got, err := scheduled.Step(5 * time.Second)
+		if err != nil {
+			t.Fatalf("step %d: %v", step, err)
+		}
+		w, g := want.Routes["voip"], got.Routes["voip"]
+		if !reflect.DeepEqual(w.Nodes, g.Nodes) || math.Abs(w.LatencyMS-g.LatencyMS) > 1e-9 {
+			t.Fatalf("step %d: scheduled route %+v, want %+v", step, g, w)
+		}
+	}
+}
+
+func TestShapedFootprintValidation(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 2, LonStep: 2},
+		Satellites: []Satellite{{ID: "geo", Position: visibility.Vector3{X: visibility.EarthRadius + 35786}, Footprint: coverage.Footprint{
+			Shape: coverage.ShapePolygon, LinkStrength: 1,
+			Vertices: []coverage.Vertex{{Lat: 35, Lon: -10}, {Lat: 35, Lon: 30}, {Lat: 60, Lon: 30}, {Lat: 60, Lon: -10}},
+		}}},
+		GroundStations: []GroundStation{{ID: "gateway", Position: visibility.Vector3{X: visibility.EarthRadius}}},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	for _, cell := range sim.Snapshot().Heatmap {
+		inside := cell.Lat > 36 && cell.Lat < 59 && cell.Lon > -9 && cell.Lon < 29
+		if inside && cell.Count == 0 {
+			t.Fatalf("cell %v,%v inside the beam is uncovered", cell.Lat, cell.Lon)
+		}
+		if cell.Count > 0 && (cell.Lat < 30 || cell.Lon < -15 || cell.Lon > 35) {
+			t.Fatalf("cell %v,%v outside the beam is covered", cell.Lat, cell.Lon)
+		}
+	}
+
+	cfg.Satellites[0].Footprint = coverage.Footprint{Shape: coverage.ShapeEllipse, SemiMajorKm: 100}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected an ellipse without a minor axis to be rejected")
+	}
+}
+
+func TestEarthRotationKeepsGeostationarySatellitesOverTheirStation(t *testing.T) {
+	geo := orbits.KeplerianElements{SemiMajorAxis: math.Cbrt(orbits.EarthMu / (orbits.EarthRotationRate * orbits.EarthRotationRate))}
+	cfg := Config{
+		GridConfig:     coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites:     []Satellite{{ID: "geo", Orbit: &geo, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}}},
+		GroundStations: []GroundStation{{ID: "gs", Location: &visibility.Geodetic{}}},
+		Epoch:          time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+	subLongitude := func(sim *Simulator) float64 {
+		return visibility.ToGeodetic(sim.satellites["geo"].Position).LonDeg
+	}
+	for _, rotate := range []bool{false, true} {
+		cfg.EarthRotation = rotate
+		sim, err := NewSimulator(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := subLongitude(sim)
+		if _, err := sim.Run(6, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		moved := math.Abs(subLongitude(sim) - start)
+		switch {
+		case rotate && moved > 1e-3:
+			t.Fatalf("expected the satellite to hover over %v° with Earth rotation, it moved %v°", start, moved)
+		case !rotate && math.Abs(moved-90) > 0.5:
+			t.Fatalf("expected a quarter turn over a non-rotating Earth in six hours, moved %v°", moved)
+		}
+	}
+}
+
+func TestWGS84LocationsAndSubSatellitePoints(t *testing.T) {
+	cfg := Config{
+		GridConfig:     coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites:     []Satellite{{ID: "sat", Location: &visibility.Geodetic{LatDeg: 45, LonDeg: 10, AltKm: 550}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}}},
+		GroundStations: []GroundStation{{ID: "gs", Location: &visibility.Geodetic{LatDeg: 45, LonDeg: 10}}},
+	}
+	for _, wgs84 := range []bool{false, true} {
+		cfg.WGS84 = wgs84
+		sim, err := NewSimulator(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gs := sim.ground["gs"].Position
+		footprint := sim.satellites["sat"].coverageFootprint(sim.elevationMask)
+		onEllipsoid := visibility.SlantRange(gs, visibility.FromWGS84(45, 10, 0)) < 1e-9
+		if onEllipsoid != wgs84 {
+			t.Fatalf("wgs84=%v: expected the station on the ellipsoid only with wgs84, got %+v", wgs84, gs)
+		}
+		if math.Abs(footprint.CenterLat-45) > 1e-9 || math.Abs(footprint.CenterLon-10) > 1e-9 {
+			t.Fatalf("wgs84=%v: expected the footprint centered below the satellite at 45°N 10°E, got %+v", wgs84, footprint)
+		}
+	}
+}
+
+// fixedPropagator stands in for an external propagator: it reports the satellite over a longitude
+// that advances one degree per minute from the scenario's epoch.
+type fixedPropagator struct{ epoch time.Time }
+
+func (p fixedPropagator) StateAt(t time.Time) (orbits.StateVector, error) {
+	lon := t.Sub(p.epoch).Minutes()
+	if lon > 2 {
+		return orbits.StateVector{}, errors.New("beyond the ephemeris")
+	}
+	return orbits.StateVector{Position: visibility.FromGeodetic(0, lon, 550)}, nil
+}
+
+func TestNamedPropagatorsMoveSatellites(t *testing.T) {
+	epoch := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var requested []Satellite
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites: []Satellite{{
+			ID:         "sat",
+			Orbit:      &orbits.KeplerianElements{SemiMajorAxis: 8000},
+			Propagator: "ephemeris",
+			Footprint:  coverage.Footprint{Auto: true, LinkStrength: 1},
+		}},
+		GroundStations: []GroundStation{{ID: "gs", Location: &visibility.Geodetic{}}},
+		Epoch:          epoch,
+		Propagators: map[string]PropagatorFactory{"ephemeris": func(sat Satellite) (orbits.Propagator, error) {
+			requested = append(requested, sat)
+			return fixedPropagator{epoch: epoch}, nil
+		}},
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 1 || requested[0].ID != "sat" || !requested[0].Orbit.Epoch.Equal(epoch) {
+		t.Fatalf("expected the factory to see the satellite with its epoch filled in, got %+v", requested)
+	}
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	sat := sim.satellites["sat"]
+	if math.Abs(sat.Footprint.CenterLon-1) > 1e-9 || math.Abs(visibility.ToGeodetic(sat.Position).AltKm-550) > 1e-9 {
+		t.Fatalf("expected the propagator, not the orbit, to place the satellite, got %+v", sat)
+	}
+	if _, err := sim.Step(2 * time.Minute); err == nil || !strings.Contains(err.Error(), "beyond the ephemeris") {
+		t.Fatalf("expected the propagator's error, got %v", err)
+	}
+
+	cfg.Satellites[0].Propagator = "missing"
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected an unknown propagator to be rejected")
+	}
+}
+
+func TestCoverageBoundsFollowFootprintUncertainty(t *testing.T) {
+	cfg := gridTestConfig()
+	cfg.GridConfig = coverage.GridConfig{LatStep: 5, LonStep: 5}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := sim.Snapshot().Coverage.Bounds; bounds != nil {
+		t.Fatalf("expected no bounds without uncertainty, got %+v", bounds)
+	}
+
+	cfg.Satellites[0].Footprint.Uncertainty = &coverage.FootprintUncertainty{RadiusPct: 20}
+	sim, err = NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := sim.Snapshot().Coverage
+	bounds := summary.Bounds
+	if bounds == nil {
+		t.Fatal("expected bounds for an uncertain footprint")
+	}
+	if bounds.CoveragePercent.Nominal != summary.CoveragePercent {
+		t.Fatalf("expected nominal coverage %v, got %+v", summary.CoveragePercent, bounds.CoveragePercent)
+	}
+	if !(bounds.CoveredCells.Min < bounds.CoveredCells.Nominal && bounds.CoveredCells.Nominal < bounds.CoveredCells.Max) {
+		t.Fatalf("expected the auto footprint's radius to widen coverage bounds, got %+v", bounds.CoveredCells)
+	}
+}
+
+func TestExpectedCoverageWeighsSatelliteFailures(t *testing.T) {
+	cfg := gridTestConfig()
+	cfg.GridConfig = coverage.GridConfig{LatStep: 5, LonStep: 5}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := sim.Snapshot().Coverage.Expected; expected != nil {
+		t.Fatalf("expected no expected coverage for reliable satellites, got %+v", expected)
+	}
+
+	cfg.Satellites[0].FailureProbability = 0.25
+	sim, err = NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := sim.Snapshot().Coverage
+	if summary.Expected == nil || math.Abs(summary.Expected.CoveragePercent-0.75*summary.CoveragePercent) > 1e-9 {
+		t.Fatalf("expected three quarters of %v%% coverage, got %+v", summary.CoveragePercent, summary.Expected)
+	}
+
+	cfg.Satellites[0].FailureProbability = 1.5
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected a failure probability above 1 to be rejected")
+	}
+}
+
+func TestStreamHeatmapReadsTheRetainedGrid(t *testing.T) {
+	cfg := gridTestConfig()
+	cfg.GridConfig = coverage.GridConfig{LatStep: 5, LonStep: 5}
+	copied, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.StreamHeatmap = true
+	streamed, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := streamed.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := copied.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	want := copied.Snapshot().Heatmap
+	snap, cells := streamed.StreamHeatmap()
+	if snap.Heatmap != nil {
+		t.Fatalf("expected a streamed snapshot to leave its heatmap empty, got %d cells", len(snap.Heatmap))
+	}
+	var got []coverage.HeatmapCell
+	cells(func(cell coverage.HeatmapCell) bool {
+		got = append(got, cell)
+		return true
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the streamed heatmap to match a copied one: %d vs %d cells", len(got), len(want))
+	}
+
+	gaps, err := streamed.CoverageGaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copiedGaps, _ := copied.CoverageGaps()
+	if gaps.TotalAreaKm2 != copiedGaps.TotalAreaKm2 {
+		t.Fatalf("expected gaps from the grid to match: %v vs %v", gaps.TotalAreaKm2, copiedGaps.TotalAreaKm2)
+	}
+}
+
+func TestDisablingTheLastSatelliteLeavesAGroundOnlyNetwork(t *testing.T) {
+	cfg := gridTestConfig()
+	cfg.GroundStations = append(cfg.GroundStations, GroundStation{ID: "gw-far", Location: &visibility.Geodetic{LatDeg: 45, LonDeg: 90}})
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := sim.DisableSatellite("sat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.ActiveSatellites) != 0 || snap.Coverage.CoveragePercent != 0 {
+		t.Fatalf("expected no active satellites or coverage, got %v and %v%%", snap.ActiveSatellites, snap.Coverage.CoveragePercent)
+	}
+}
+
+// coverFailingSharder computes recomputes in process but fails the extra coverage passes.
+type coverFailingSharder struct{}
+
+func (coverFailingSharder) Compute(_ context.Context, nodes []routing.Node, footprints map[string]coverage.Footprint, links routing.LinkSettings, config coverage.GridConfig) (*routing.Graph, *coverage.CoverageGrid, error) {
+	graph, err := routing.BuildGraph(nodes, links.Options()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	grid, err := coverage.AcquireGrid(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	grid.ApplyFootprints(sortedFootprints(footprints))
+	return graph, grid, nil
+}
+
+func (coverFailingSharder) Cover(context.Context, []coverage.Footprint, coverage.GridConfig) (*coverage.CoverageGrid, error) {
+	return nil, errors.New("shard unavailable")
+}
+
+func TestFailedRecomputesReturnTheirGrid(t *testing.T) {
+	released := 0
+	releaseGrid = func(g *coverage.CoverageGrid) {
+		released++
+		coverage.ReleaseGrid(g)
+	}
+	defer func() { releaseGrid = coverage.ReleaseGrid }()
+
+	for _, stream := range []bool{false, true} {
+		released = 0
+		cfg := gridTestConfig()
+		cfg.StreamHeatmap = stream
+		cfg.Sharder = coverFailingSharder{}
+		cfg.Satellites[0].Footprint.Uncertainty = &coverage.FootprintUncertainty{RadiusPct: 10}
+		if _, err := NewSimulator(cfg); err == nil || !strings.Contains(err.Error(), "shard unavailable") {
+			t.Fatalf("stream %v: expected the bounds to fail, got %v", stream, err)
+		}
+		if released != 1 {
+			t.Fatalf("stream %v: expected the failed recompute to return its grid once, got %d", stream, released)
+		}
+	}
+
+	released = 0
+	cfg := gridTestConfig()
+	cfg.StreamHeatmap = true
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Step(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if released != 0 {
+		t.Fatalf("expected published streamed grids to be kept, got %d returned", released)
+	}
+}
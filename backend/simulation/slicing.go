@@ -0,0 +1,115 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SharedSlice names the capacity left over after every slice's reservation. Demands that do not
+// name a slice are admitted against it.
+const SharedSlice = "shared"
+
+// Slice reserves Share of every link direction's capacity for the demands that name it, modeling a
+// wholesale capacity agreement. Slices are isolated: a slice's demands cannot use capacity reserved
+// for another slice or the shared remainder, even when it sits idle.
+type Slice struct {
+	Name  string  `json:"name"`
+	Share float64 `json:"share"`
+}
+
+// SliceMetrics reports how a slice used its reservation in a snapshot. Utilization is carried
+// bandwidth over reserved bandwidth, taken across the link directions the slice's routes use.
+// ReservedMbps is the slice's share of LinkCapacityMbps; on link directions a LinkProfile gives
+// their own capacity, the slice holds the same share of that.
+type SliceMetrics struct {
+	ReservedMbps    float64 `json:"reservedMbps"`
+	Demands         int     `json:"demands"`
+	Routed          int     `json:"routed"`
+	CarriedMbps     float64 `json:"carriedMbps"`
+	LinksUsed       int     `json:"linksUsed"`
+	MeanUtilization float64 `json:"meanUtilization"`
+	MaxUtilization  float64 `json:"maxUtilization"`
+}
+
+func validateSlices(slices []Slice, traffic []TrafficDemand, linkCapacity float64) error {
+	if len(slices) > 0 && linkCapacity <= 0 {
+		return errors.New("slices require linkCapacityMbps")
+	}
+	names := map[string]bool{SharedSlice: true}
+	total := 0.0
+	for _, slice := range slices {
+		if slice.Name == "" || slice.Name == SharedSlice {
+			return fmt.Errorf("slice name %q is reserved or empty", slice.Name)
+		}
+		if names[slice.Name] {
+			return fmt.Errorf("duplicate slice %q", slice.Name)
+		}
+		if slice.Share <= 0 || slice.Share > 1 {
+			return fmt.Errorf("slice %q share must be in (0, 1]", slice.Name)
+		}
+		names[slice.Name] = true
+		total += slice.Share
+	}
+	if total > 1+capacityEpsilon {
+		return fmt.Errorf("slice shares add up to %.3f, more than the whole link", total)
+	}
+	for _, demand := range traffic {
+		if !names[demandSlice(demand)] {
+			return fmt.Errorf("demand %q uses unknown slice %q", demand.ID, demand.Slice)
+		}
+	}
+	return nil
+}
+
+func demandSlice(demand TrafficDemand) string {
+	if demand.Slice == "" {
+		return SharedSlice
+	}
+	return demand.Slice
+}
+
+// newSliceStates partitions link capacity into one capacityState per slice plus the shared remainder.
+func (s *Simulator) newSliceStates() map[string]*capacityState {
+	states := make(map[string]*capacityState, len(s.slices)+1)
+	reserved := 0.0
+	for _, slice := range s.slices {
+		states[slice.Name] = newCapacityState(slice.Share, s.linkCapacity, s.directedCapacity)
+		reserved += slice.Share
+	}
+	states[SharedSlice] = newCapacityState(math.Max(0, 1-reserved), s.linkCapacity, s.directedCapacity)
+	return states
+}
+
+func (s *Simulator) sliceMetrics(states map[string]*capacityState, allocations map[string]Allocation) map[string]SliceMetrics {
+	metrics := make(map[string]SliceMetrics, len(states))
+	for name, state := range states {
+		m := SliceMetrics{ReservedMbps: state.capacity}
+		// Sum in link order: float addition in map order would vary between identical runs.
+		for _, link := range sortedLinks(state.used) {
+			used, limit := state.used[link], state.limit(link)
+			if used <= capacityEpsilon || limit <= 0 {
+				continue
+			}
+			utilization := used / limit
+			m.LinksUsed++
+			m.MeanUtilization += utilization
+			m.MaxUtilization = math.Max(m.MaxUtilization, utilization)
+		}
+		if m.LinksUsed > 0 {
+			m.MeanUtilization /= float64(m.LinksUsed)
+		}
+		metrics[name] = m
+	}
+	for _, demand := range s.traffic {
+		name := demandSlice(demand)
+		m := metrics[name]
+		m.Demands++
+		if alloc := allocations[demand.ID]; alloc.Status == StatusAdmitted || alloc.Status == StatusDegraded {
+			m.Routed++
+			m.CarriedMbps += alloc.AllocatedMbps
+		}
+		metrics[name] = m
+	}
+	return metrics
+}
@@ -0,0 +1,60 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func slicedConfig() Config {
+	cfg := bottleneckConfig(AdmitReject)
+	cfg.Slices = []Slice{{Name: "tenant-a", Share: 0.5}}
+	cfg.Traffic = []TrafficDemand{
+		{ID: "a1", FromID: "ground-a", ToID: "ground-b", BandwidthMbps: 40, Slice: "tenant-a"},
+		{ID: "a2", FromID: "ground-a", ToID: "ground-b", BandwidthMbps: 40, Slice: "tenant-a"},
+		{ID: "s1", FromID: "ground-a", ToID: "ground-b", BandwidthMbps: 45},
+	}
+	return cfg
+}
+
+func TestSlicesAreAdmittedAgainstTheirReservation(t *testing.T) {
+	sim, err := NewSimulator(slicedConfig())
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	snapshot := sim.Snapshot()
+	// The link has room for a2 overall, but not within tenant-a's 50 Mbps reservation.
+	for id, want := range map[string]AllocationStatus{"a1": StatusAdmitted, "a2": StatusBlocked, "s1": StatusAdmitted} {
+		if got := snapshot.Allocations[id].Status; got != want {
+			t.Fatalf("%s status = %s, want %s", id, got, want)
+		}
+	}
+
+	tenant := snapshot.Slices["tenant-a"]
+	if tenant.ReservedMbps != 50 || tenant.Demands != 2 || tenant.Routed != 1 || tenant.CarriedMbps != 40 {
+		t.Fatalf("unexpected tenant metrics %+v", tenant)
+	}
+	if tenant.LinksUsed != 2 || math.Abs(tenant.MaxUtilization-0.8) > 1e-9 || math.Abs(tenant.MeanUtilization-0.8) > 1e-9 {
+		t.Fatalf("expected 80%% utilization on both hops, got %+v", tenant)
+	}
+	shared := snapshot.Slices[SharedSlice]
+	if shared.ReservedMbps != 50 || shared.Routed != 1 || math.Abs(shared.MaxUtilization-0.9) > 1e-9 {
+		t.Fatalf("unexpected shared metrics %+v", shared)
+	}
+}
+
+func TestSliceValidation(t *testing.T) {
+	cases := map[string]func(*Config){
+		"oversubscribed": func(c *Config) { c.Slices = append(c.Slices, Slice{Name: "tenant-b", Share: 0.6}) },
+		"no capacity":    func(c *Config) { c.LinkCapacityMbps = 0 },
+		"unknown slice":  func(c *Config) { c.Traffic[0].Slice = "tenant-z" },
+		"reserved name":  func(c *Config) { c.Slices[0].Name = SharedSlice },
+		"zero share":     func(c *Config) { c.Slices[0].Share = 0 },
+	}
+	for name, mutate := range cases {
+		cfg := slicedConfig()
+		mutate(&cfg)
+		if _, err := NewSimulator(cfg); err == nil {
+			t.Fatalf("%s: expected the configuration to be rejected", name)
+		}
+	}
+}
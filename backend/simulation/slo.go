@@ -0,0 +1,149 @@
+package simulation
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// SLOStatus classifies a grid cell of a LatencySLOMap.
+type SLOStatus string
+
+const (
+	// SLOCompliant cells reach their gateway within the SLO.
+	SLOCompliant SLOStatus = "compliant"
+	// SLOViolated cells reach their gateway, but only slower than the SLO.
+	SLOViolated SLOStatus = "violated"
+	// SLONoRoute cells are covered, but no serving satellite has a route to their gateway.
+	SLONoRoute SLOStatus = "no-route"
+	// SLOUncovered cells are served by no satellite.
+	SLOUncovered SLOStatus = "uncovered"
+)
+
+// SLOCell reports whether a terminal at a grid cell's center meets the latency SLO to the nearest
+// gateway.
+type SLOCell struct {
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Gateway string  `json:"gateway"`
+	// LatencyMS is the best one-way latency: the uplink to a satellite whose footprint holds the
+	// cell plus that satellite's least-latency route to the gateway. It is zero unless routed.
+	LatencyMS float64 `json:"latencyMs,omitempty"`
+	// Satellite is the serving satellite of the best route.
+	Satellite string    `json:"satellite,omitempty"`
+	Status    SLOStatus `json:"status"`
+}
+
+// GatewayCompliance summarizes the cells nearest one gateway, its region.
+type GatewayCompliance struct {
+	Gateway           string  `json:"gateway"`
+	Cells             int     `json:"cells"`
+	Compliant         int     `json:"compliant"`
+	CompliancePercent float64 `json:"compliancePercent"`
+}
+
+// LatencySLOMap combines coverage and routing into a per-cell compliance map for a latency SLO.
+type LatencySLOMap struct {
+	Version           uint64              `json:"version"`
+	SLOMS             float64             `json:"sloMs"`
+	CompliancePercent float64             `json:"compliancePercent"`
+	Gateways          []GatewayCompliance `json:"gateways"`
+	Cells             []SLOCell           `json:"cells"`
+}
+
+// LatencySLO checks every grid cell of the latest recompute against a one-way latency SLO to the
+// gateway nearest the cell. Compliance percentages count cells equally.
+func (s *Simulator) LatencySLO(sloMS float64) (LatencySLOMap, error) {
+	if !(sloMS > 0) || math.IsInf(sloMS, 1) {
+		return LatencySLOMap{}, errors.New("latency SLO must be a positive number of milliseconds")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := LatencySLOMap{Version: s.snapshot.Version, SLOMS: sloMS, Gateways: []GatewayCompliance{}, Cells: []SLOCell{}}
+	if len(s.ground) == 0 {
+		return result, nil
+	}
+	gateways := make([]GroundStation, 0, len(s.ground))
+	for _, gs := range s.ground {
+		gateways = append(gateways, gs)
+	}
+	sort.Slice(gateways, func(i, j int) bool { return gateways[i].ID < gateways[j].ID })
+
+	type server struct {
+		id       string
+		position visibility.Vector3
+		contains func(lat, lon float64) bool
+	}
+	var servers []server
+	for _, id := range s.snapshot.ActiveSatellites {
+		sat := s.satellites[id]
+		footprint := sat.coverageFootprint(s.elevationMask)
+		servers = append(servers, server{id: id, position: sat.Position, contains: footprint.Contains})
+	}
+
+	costs := make(map[string]map[string]float64, len(gateways))
+	regions := make(map[string]*GatewayCompliance, len(gateways))
+	for _, gs := range gateways {
+		costs[gs.ID] = routing.CostsTo(s.graph, gs.ID, func(e routing.Edge) float64 { return e.LatencyMS })
+		regions[gs.ID] = &GatewayCompliance{Gateway: gs.ID}
+	}
+
+	compliant := 0
+	heatmap := s.heatmapLocked()
+	result.Cells = make([]SLOCell, len(heatmap))
+	for i, cell := range heatmap {
+		terminal := visibility.FromGeodetic(cell.Lat, cell.Lon, 0)
+		nearest := gateways[0]
+		for _, gs := range gateways[1:] {
+			if visibility.SlantRange(terminal, gs.Position) < visibility.SlantRange(terminal, nearest.Position) {
+				nearest = gs
+			}
+		}
+
+		out := SLOCell{Lat: cell.Lat, Lon: cell.Lon, Gateway: nearest.ID, Status: SLOUncovered}
+		if cell.Covered {
+			out.Status = SLONoRoute
+			toGateway := costs[nearest.ID]
+			for _, sat := range servers {
+				routeMS, ok := toGateway[sat.id]
+				if !ok || !sat.contains(cell.Lat, cell.Lon) {
+					continue
+				}
+				latency := visibility.SlantRange(terminal, sat.position)/routing.SpeedOfLightKMPerS*1000 + routeMS
+				if out.Satellite == "" || latency < out.LatencyMS {
+					out.LatencyMS, out.Satellite = latency, sat.id
+				}
+			}
+			if out.Satellite != "" {
+				out.Status = SLOViolated
+				if out.LatencyMS <= sloMS {
+					out.Status = SLOCompliant
+				}
+			}
+		}
+		result.Cells[i] = out
+
+		region := regions[nearest.ID]
+		region.Cells++
+		if out.Status == SLOCompliant {
+			region.Compliant++
+			compliant++
+		}
+	}
+
+	for _, gs := range gateways {
+		region := *regions[gs.ID]
+		if region.Cells > 0 {
+			region.CompliancePercent = float64(region.Compliant) / float64(region.Cells) * 100
+		}
+		result.Gateways = append(result.Gateways, region)
+	}
+	if len(result.Cells) > 0 {
+		result.CompliancePercent = float64(compliant) / float64(len(result.Cells)) * 100
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestLatencySLOClassifiesCells(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 10, LonStep: 10},
+		Satellites: []Satellite{
+			{ID: "sat", Location: &visibility.Geodetic{AltKm: 550}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "west", Location: &visibility.Geodetic{LonDeg: -5}},
+			{ID: "east", Location: &visibility.Geodetic{LonDeg: 5}},
+			// Inside the footprint, but its mask keeps it from linking to the satellite.
+			{ID: "blocked", Location: &visibility.Geodetic{LatDeg: 10}, ElevationMaskDeg: 89},
+		},
+		Epoch: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cellAt := func(slo LatencySLOMap, lat, lon float64) SLOCell {
+		t.Helper()
+		for _, cell := range slo.Cells {
+			if cell.Lat == lat && cell.Lon == lon {
+				return cell
+			}
+		}
+		t.Fatalf("no cell at %v, %v", lat, lon)
+		return SLOCell{}
+	}
+
+	slo, err := sim.LatencySLO(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := cellAt(slo, 5, 5)
+	if near.Status != SLOCompliant || near.Gateway != "east" || near.Satellite != "sat" {
+		t.Fatalf("expected the cell beside the east gateway to comply through sat, got %+v", near)
+	}
+	// Up to the satellite and back down: at least twice the altitude at the speed of light.
+	if floor := 2 * 550 / 299792.458 * 1000; near.LatencyMS < floor || near.LatencyMS > 20 {
+		t.Fatalf("latency %v outside [%v, 20]", near.LatencyMS, floor)
+	}
+	if cell := cellAt(slo, 15, 5); cell.Status != SLONoRoute || cell.Gateway != "blocked" {
+		t.Fatalf("expected a covered cell nearest the blocked gateway to have no route, got %+v", cell)
+	}
+	if cell := cellAt(slo, 5, 175); cell.Status != SLOUncovered {
+		t.Fatalf("expected the far side of the globe to be uncovered, got %+v", cell)
+	}
+
+	total := 0
+	for _, region := range slo.Gateways {
+		total += region.Cells
+		if region.Gateway == "blocked" && region.Compliant != 0 {
+			t.Fatalf("the blocked gateway's region cannot comply: %+v", region)
+		}
+	}
+	if total != len(slo.Cells) || len(slo.Gateways) != 3 || slo.CompliancePercent <= 0 {
+		t.Fatalf("regions should partition the grid: %+v", slo.Gateways)
+	}
+
+	strict, err := sim.LatencySLO(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cell := cellAt(strict, 5, 5); cell.Status != SLOViolated || cell.LatencyMS != near.LatencyMS {
+		t.Fatalf("expected a 1 ms SLO to be violated at the same latency, got %+v", cell)
+	}
+	if _, err := sim.LatencySLO(math.NaN()); err == nil {
+		t.Fatal("expected a NaN SLO to be rejected")
+	}
+}
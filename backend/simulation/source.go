@@ -0,0 +1,83 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// terminalPrefix starts the routing node ID of a demand sourced at a location.
+const terminalPrefix = "terminal:"
+
+// TerminalID returns the routing node that stands for the user terminal of a demand sourced at
+// FromLocation. Routes of such demands start there.
+func TerminalID(demandID string) string {
+	return terminalPrefix + demandID
+}
+
+func validateSource(demand TrafficDemand) error {
+	loc := demand.FromLocation
+	if loc == nil {
+		return nil
+	}
+	switch {
+	case demand.FromID != "":
+		return fmt.Errorf("demand %q sets both from and fromLocation", demand.ID)
+	case demand.ToAddress != "":
+		return fmt.Errorf("demand %q: address destinations need a source node advertising routes, not fromLocation", demand.ID)
+	case !(loc.LatDeg >= -90 && loc.LatDeg <= 90):
+		return fmt.Errorf("demand %q fromLocation latitude must be in [-90, 90]", demand.ID)
+	case !(loc.LonDeg >= -180 && loc.LonDeg <= 180):
+		return fmt.Errorf("demand %q fromLocation longitude must be in [-180, 180]", demand.ID)
+	case math.IsNaN(loc.AltKm) || math.IsInf(loc.AltKm, 0):
+		return fmt.Errorf("demand %q fromLocation altitude must be finite", demand.ID)
+	}
+	return nil
+}
+
+// terminalNode returns the routing node of a location-sourced demand's user terminal.
+func terminalNode(demand TrafficDemand, wgs84 bool) routing.Node {
+	return routing.Node{ID: TerminalID(demand.ID), Type: routing.Ground, Position: locate(*demand.FromLocation, wgs84), User: true}
+}
+
+// attachTerminalsLocked adds the user terminal of every location-sourced demand to graph, linked
+// only to its serving satellite: the active satellite highest in the terminal's sky among those
+// whose footprint holds the location and that clear the elevation mask. A terminal without one is
+// left unlinked, so its demand goes unrouted. It returns the serving satellite of each demand.
+func (s *Simulator) attachTerminalsLocked(graph *routing.Graph, activeIDs []string) map[string]string {
+	var serving map[string]string
+	for _, demand := range s.traffic {
+		if demand.FromLocation == nil {
+			continue
+		}
+		terminal := terminalNode(demand, s.scenario.WGS84)
+		graph.Nodes[terminal.ID] = terminal
+
+		var best routing.Node
+		bestElevation := math.Inf(-1)
+		for _, id := range activeIDs {
+			sat := s.satellites[id]
+			node := sat.routingNode()
+			if !sat.coverageFootprint(s.elevationMask).Contains(demand.FromLocation.LatDeg, demand.FromLocation.LonDeg) ||
+				!routing.LinkVisible(terminal, node, s.terminalMask) {
+				continue
+			}
+			if elevation := visibility.Elevation(terminal.Position, node.Position); elevation > bestElevation {
+				best, bestElevation = node, elevation
+			}
+		}
+		if best.ID == "" {
+			continue
+		}
+		for _, e := range routing.BuildEdges([]routing.Node{terminal, best}, nil, s.links.Options()...) {
+			graph.Adj[e.From] = append(graph.Adj[e.From], e)
+		}
+		if serving == nil {
+			serving = make(map[string]string)
+		}
+		serving[demand.ID] = best.ID
+	}
+	return serving
+}
@@ -0,0 +1,80 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func locationSourcedConfig() Config {
+	auto := coverage.Footprint{Auto: true, LinkStrength: 1}
+	return Config{
+		GridConfig: coverage.GridConfig{LatStep: 180, LonStep: 360},
+		Satellites: []Satellite{
+			{ID: "overhead", Location: &visibility.Geodetic{AltKm: 550}, Footprint: auto},
+			{ID: "east", Location: &visibility.Geodetic{LonDeg: 20, AltKm: 550}, Footprint: auto},
+		},
+		GroundStations: []GroundStation{
+			{ID: "gateway", Location: &visibility.Geodetic{LonDeg: 20}},
+		},
+		Traffic: []TrafficDemand{{ID: "user", FromLocation: &visibility.Geodetic{LonDeg: 1}, ToID: "gateway"}},
+	}
+}
+
+func TestLocationSourcedDemandsRouteThroughServingSatellite(t *testing.T) {
+	sim, err := NewSimulator(locationSourcedConfig())
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	snapshot := sim.Snapshot()
+	path, ok := snapshot.Routes["user"]
+	if !ok || path.Nodes[0] != TerminalID("user") || path.Nodes[1] != "overhead" || path.Nodes[len(path.Nodes)-1] != "gateway" {
+		t.Fatalf("expected a route from the terminal through the overhead satellite, got %+v", path)
+	}
+	if got := snapshot.ServingSatellites["user"]; got != "overhead" {
+		t.Fatalf("expected overhead to serve the terminal, got %q", got)
+	}
+	if from := sim.Scenario().Traffic[0]; from.FromID != "" || from.FromLocation == nil {
+		t.Fatalf("the scenario should keep the demand's location, got %+v", from)
+	}
+
+	// With the overhead satellite gone, the terminal is handed to the next one in its sky.
+	updated, err := sim.DisableSatellite("overhead")
+	if err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if got := updated.ServingSatellites["user"]; got != "east" {
+		t.Fatalf("expected east to take over the terminal, got %q", got)
+	}
+	if path, ok := updated.Routes["user"]; !ok || path.Nodes[1] != "east" || path.LatencyMS <= 0 {
+		t.Fatalf("expected a route through east, got %+v", path)
+	}
+	if report := sim.CheckInvariants(); !report.OK() {
+		t.Fatalf("terminal links broke an invariant: %+v", report.Violations)
+	}
+
+	if _, err := sim.DisableSatellite("east"); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if snapshot := sim.Snapshot(); len(snapshot.ServingSatellites) != 0 || snapshot.Routes["user"].Nodes != nil {
+		t.Fatalf("expected an unserved terminal to go unrouted, got %+v", snapshot.Routes["user"])
+	}
+}
+
+func TestLocationSourcedDemandValidation(t *testing.T) {
+	for name, mutate := range map[string]func(*Config){
+		"both sources": func(cfg *Config) { cfg.Traffic[0].FromID = "gateway" },
+		"bad latitude": func(cfg *Config) { cfg.Traffic[0].FromLocation.LatDeg = 91 },
+		"terminal clash": func(cfg *Config) {
+			cfg.GroundStations[0].ID = TerminalID("user")
+			cfg.Traffic[0].ToID = TerminalID("user")
+		},
+	} {
+		cfg := locationSourcedConfig()
+		mutate(&cfg)
+		if _, err := NewSimulator(cfg); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
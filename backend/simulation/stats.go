@@ -0,0 +1,119 @@
+package simulation
+
+import "time"
+
+// PhaseTimings breaks one recompute down by stage. With a Sharder configured, Sharded covers the
+// combined visibility and coverage work and Visibility and Coverage stay zero.
+type PhaseTimings struct {
+	Visibility time.Duration
+	Coverage   time.Duration
+	Sharded    time.Duration
+	Routing    time.Duration
+	// Snapshot covers summarizing the grid and exporting the heatmap.
+	Snapshot time.Duration
+	Total    time.Duration
+}
+
+// RecomputeStats aggregates PhaseTimings over every recompute since the simulator was built.
+// Max is taken per phase, so its fields may come from different recomputes.
+type RecomputeStats struct {
+	Count uint64
+	Last  PhaseTimings
+	Mean  PhaseTimings
+	Max   PhaseTimings
+}
+
+// StageSpan is one timed stage of a recompute, kept so callers can export it as a trace span.
+// Route spans, one per path search, name "routing" as their Parent; other stages are top-level.
+type StageSpan struct {
+	Name       string
+	Parent     string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]any
+}
+
+const (
+	// traceRing is how many recent recomputes keep their stage spans.
+	traceRing = 16
+	// maxRouteSpans bounds the route spans kept per recompute; the rest are only counted.
+	maxRouteSpans = 64
+)
+
+// recomputeTrace collects the stage spans of one recompute.
+type recomputeTrace struct {
+	version uint64
+	spans   []StageSpan
+	// routes counts the route spans kept; untraced counts path searches beyond maxRouteSpans.
+	routes, untraced int
+}
+
+// stage records a stage that started at start and ends now.
+func (t *recomputeTrace) stage(name string, start time.Time, attrs map[string]any) {
+	t.spans = append(t.spans, StageSpan{Name: name, Start: start, End: time.Now(), Attributes: attrs})
+}
+
+// route records one path search for demand that started at start and ends now.
+func (t *recomputeTrace) route(demand string, start time.Time) {
+	if t.routes >= maxRouteSpans {
+		t.untraced++
+		return
+	}
+	t.routes++
+	t.spans = append(t.spans, StageSpan{Name: "route", Parent: "routing", Start: start, End: time.Now(), Attributes: map[string]any{"demand": demand}})
+}
+
+// recomputeRecorder accumulates recompute timings and the stage spans of recent recomputes.
+type recomputeRecorder struct {
+	count uint64
+	last  PhaseTimings
+	sum   PhaseTimings
+	max   PhaseTimings
+
+	traces [traceRing]recomputeTrace
+}
+
+func (r *recomputeRecorder) record(t PhaseTimings) {
+	r.count++
+	r.last = t
+	r.sum = t.combine(r.sum, func(a, b time.Duration) time.Duration { return a + b })
+	r.max = t.combine(r.max, func(a, b time.Duration) time.Duration {
+		if a > b {
+			return a
+		}
+		return b
+	})
+}
+
+func (r *recomputeRecorder) keep(t recomputeTrace) {
+	r.traces[t.version%traceRing] = t
+}
+
+func (r *recomputeRecorder) trace(version uint64) ([]StageSpan, bool) {
+	t := r.traces[version%traceRing]
+	if t.version != version || t.spans == nil {
+		return nil, false
+	}
+	return t.spans, true
+}
+
+func (r *recomputeRecorder) stats() RecomputeStats {
+	stats := RecomputeStats{Count: r.count, Last: r.last, Max: r.max}
+	if r.count > 0 {
+		n := time.Duration(r.count)
+		stats.Mean = r.sum.combine(PhaseTimings{}, func(a, _ time.Duration) time.Duration { return a / n })
+	}
+	return stats
+}
+
+// combine applies fn phase by phase to t and other.
+func (t PhaseTimings) combine(other PhaseTimings, fn func(a, b time.Duration) time.Duration) PhaseTimings {
+	return PhaseTimings{
+		Visibility: fn(t.Visibility, other.Visibility),
+		Coverage:   fn(t.Coverage, other.Coverage),
+		Sharded:    fn(t.Sharded, other.Sharded),
+		Routing:    fn(t.Routing, other.Routing),
+		Snapshot:   fn(t.Snapshot, other.Snapshot),
+		Total:      fn(t.Total, other.Total),
+	}
+}
@@ -0,0 +1,76 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecomputeRecorderAggregatesPhases(t *testing.T) {
+	var r recomputeRecorder
+	r.record(PhaseTimings{Visibility: 2 * time.Millisecond, Routing: 6 * time.Millisecond, Total: 10 * time.Millisecond})
+	r.record(PhaseTimings{Visibility: 4 * time.Millisecond, Routing: 2 * time.Millisecond, Total: 8 * time.Millisecond})
+
+	stats := r.stats()
+	if stats.Count != 2 || stats.Last.Total != 8*time.Millisecond {
+		t.Fatalf("unexpected count or last sample: %+v", stats)
+	}
+	if stats.Mean.Visibility != 3*time.Millisecond || stats.Mean.Total != 9*time.Millisecond {
+		t.Fatalf("unexpected means: %+v", stats.Mean)
+	}
+	if stats.Max.Visibility != 4*time.Millisecond || stats.Max.Routing != 6*time.Millisecond {
+		t.Fatalf("max should be taken per phase: %+v", stats.Max)
+	}
+}
+
+func TestSimulatorRecordsRecomputeStats(t *testing.T) {
+	sim := NewDemoSimulator()
+	if _, err := sim.Run(3, time.Second); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	stats := sim.RecomputeStats()
+	if stats.Count != 4 {
+		t.Fatalf("expected construction plus three steps, got %d recomputes", stats.Count)
+	}
+	if stats.Last.Total <= 0 || stats.Last.Total < stats.Last.Visibility {
+		t.Fatalf("expected total to cover the visibility phase: %+v", stats.Last)
+	}
+}
+
+func TestSimulatorKeepsRecentRecomputeTraces(t *testing.T) {
+	sim := NewDemoSimulator()
+	snap, err := sim.Step(time.Second)
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	stages, ok := sim.RecomputeTrace(snap.Version)
+	if !ok {
+		t.Fatal("expected the latest recompute to be traced")
+	}
+	names := map[string]int{}
+	for _, stage := range stages {
+		names[stage.Name]++
+		if stage.End.Before(stage.Start) {
+			t.Fatalf("stage %s ends before it starts", stage.Name)
+		}
+		if stage.Name == "route" && stage.Parent != "routing" {
+			t.Fatalf("route spans should nest under routing: %+v", stage)
+		}
+	}
+	for _, name := range []string{"visibility", "coverage", "routing", "snapshot"} {
+		if names[name] != 1 {
+			t.Fatalf("expected one %s stage, got %v", name, names)
+		}
+	}
+	if names["route"] != len(sim.traffic) {
+		t.Fatalf("expected a route span per demand, got %v", names)
+	}
+
+	for i := 0; i < traceRing; i++ {
+		if _, err := sim.Step(time.Second); err != nil {
+			t.Fatalf("step failed: %v", err)
+		}
+	}
+	if _, ok := sim.RecomputeTrace(snap.Version); ok {
+		t.Fatal("expected an old recompute to fall out of the ring")
+	}
+}
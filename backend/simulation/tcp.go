@@ -0,0 +1,122 @@
+package simulation
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	defaultMSSBytes    = 1460
+	defaultWindowBytes = 4 << 20
+	// ackedPerACK is the number of segments acknowledged per ACK (b in the models), 2 with delayed ACKs.
+	ackedPerACK = 2
+	// minRTOSeconds is the retransmission timeout floor.
+	minRTOSeconds = 0.2
+)
+
+// TCPParams describe the TCP connection assumed by throughput estimates. Zero values select a
+// 1460-byte MSS and a 4 MiB window.
+type TCPParams struct {
+	MSSBytes    int `json:"mssBytes,omitempty"`
+	WindowBytes int `json:"windowBytes,omitempty"`
+}
+
+func (p TCPParams) validate() error {
+	if p.MSSBytes < 0 || p.WindowBytes < 0 {
+		return errors.New("TCP MSS and window cannot be negative")
+	}
+	return nil
+}
+
+func (p TCPParams) withDefaults() TCPParams {
+	if p.MSSBytes == 0 {
+		p.MSSBytes = defaultMSSBytes
+	}
+	if p.WindowBytes == 0 {
+		p.WindowBytes = defaultWindowBytes
+	}
+	return p
+}
+
+// TCPInput is what a throughput estimate needs to know about a route. A zero BottleneckMbps means
+// the route's capacity is not modeled.
+type TCPInput struct {
+	RTTMS          float64
+	LossRate       float64
+	BottleneckMbps float64
+	Params         TCPParams
+}
+
+// TCPEstimate is the expected steady-state goodput of a bulk TCP transfer over a route. The loss-bound
+// rates are omitted (zero) on lossless routes, and BottleneckMbps when capacity is not modeled.
+type TCPEstimate struct {
+	RTTMS          float64 `json:"rttMs"`
+	LossRate       float64 `json:"lossRate"`
+	BottleneckMbps float64 `json:"bottleneckMbps,omitempty"`
+	WindowMbps     float64 `json:"windowMbps"`
+	MathisMbps     float64 `json:"mathisMbps,omitempty"`
+	PFTKMbps       float64 `json:"pftkMbps,omitempty"`
+	GoodputMbps    float64 `json:"goodputMbps"`
+	// Limit names what bounds the goodput: "window", "loss", or "bottleneck".
+	Limit string `json:"limit"`
+}
+
+// EstimateTCP bounds goodput by the receive window (W/RTT), by loss using the PFTK model of Padhye
+// et al. including timeouts, and by the bottleneck capacity, and reports the smallest. The simpler
+// Mathis et al. square-root bound, MSS/RTT · sqrt(3/2b) / sqrt(p), is reported for comparison.
+func EstimateTCP(in TCPInput) TCPEstimate {
+	params := in.Params.withDefaults()
+	rtt := in.RTTMS / 1000
+	p := in.LossRate
+	mssMb := float64(params.MSSBytes) * 8 / 1e6
+
+	est := TCPEstimate{RTTMS: in.RTTMS, LossRate: p, BottleneckMbps: in.BottleneckMbps}
+	est.WindowMbps = float64(params.WindowBytes) * 8 / 1e6 / rtt
+	est.GoodputMbps, est.Limit = est.WindowMbps, "window"
+
+	if p > 0 {
+		b := float64(ackedPerACK)
+		est.MathisMbps = mssMb / rtt * math.Sqrt(3/(2*b)) / math.Sqrt(p)
+		rto := math.Max(minRTOSeconds, 2*rtt)
+		denominator := rtt*math.Sqrt(2*b*p/3) + rto*math.Min(1, 3*math.Sqrt(3*b*p/8))*p*(1+32*p*p)
+		est.PFTKMbps = mssMb / denominator
+		if est.PFTKMbps < est.GoodputMbps {
+			est.GoodputMbps, est.Limit = est.PFTKMbps, "loss"
+		}
+	}
+	if in.BottleneckMbps > 0 && in.BottleneckMbps < est.GoodputMbps {
+		est.GoodputMbps, est.Limit = in.BottleneckMbps, "bottleneck"
+	}
+	return est
+}
+
+// PathLossRate combines independent per-hop loss over a route with the given hop count.
+func PathLossRate(perHop float64, hops int) float64 {
+	return 1 - math.Pow(1-perHop, float64(hops))
+}
+
+// TCPEstimates estimates goodput for every routed demand of the latest snapshot. RTT is twice the
+// one-way route latency plus modeled queueing, assuming a symmetric return path. The bottleneck is
+// the demand's allocated bandwidth when admission control is active. Demands routed over zero hops
+// are omitted.
+func (s *Simulator) TCPEstimates() map[string]TCPEstimate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]TCPEstimate, len(s.snapshot.Routes))
+	for id, path := range s.snapshot.Routes {
+		hops := len(path.Nodes) - 1
+		if hops < 1 {
+			continue
+		}
+		in := TCPInput{RTTMS: 2 * path.LatencyMS, LossRate: PathLossRate(s.linkLoss, hops), Params: s.tcp}
+		if alloc, ok := s.snapshot.Allocations[id]; ok {
+			in.RTTMS += 2 * alloc.QueueingDelayMS
+			in.BottleneckMbps = alloc.AllocatedMbps
+		}
+		if in.RTTMS <= 0 {
+			continue
+		}
+		out[id] = EstimateTCP(in)
+	}
+	return out
+}
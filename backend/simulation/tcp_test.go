@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateTCPLossLimited(t *testing.T) {
+	est := EstimateTCP(TCPInput{RTTMS: 100, LossRate: 0.01})
+	// Mathis with delayed ACKs: 1460 B · 8 / 0.1 s · sqrt(3/4) / sqrt(0.01) ≈ 1.0115 Mbps.
+	if math.Abs(est.MathisMbps-1.0115) > 1e-3 {
+		t.Fatalf("Mathis estimate %.4f Mbps, want about 1.0115", est.MathisMbps)
+	}
+	if est.PFTKMbps <= 0 || est.PFTKMbps >= est.MathisMbps {
+		t.Fatalf("PFTK should be below Mathis once timeouts are counted: %+v", est)
+	}
+	if est.Limit != "loss" || est.GoodputMbps != est.PFTKMbps {
+		t.Fatalf("expected loss-limited goodput at PFTK rate: %+v", est)
+	}
+}
+
+func TestEstimateTCPWindowAndBottleneckLimits(t *testing.T) {
+	est := EstimateTCP(TCPInput{RTTMS: 100})
+	if est.Limit != "window" || math.Abs(est.GoodputMbps-4*1024*1024*8/1e6/0.1) > 1e-9 || est.MathisMbps != 0 {
+		t.Fatalf("expected lossless route limited by the 4 MiB window: %+v", est)
+	}
+
+	est = EstimateTCP(TCPInput{RTTMS: 100, LossRate: 1e-6, BottleneckMbps: 20})
+	if est.Limit != "bottleneck" || est.GoodputMbps != 20 {
+		t.Fatalf("expected a 20 Mbps bottleneck to bind: %+v", est)
+	}
+
+	small := EstimateTCP(TCPInput{RTTMS: 100, Params: TCPParams{WindowBytes: 64 << 10}})
+	if math.Abs(small.GoodputMbps-5.24288) > 1e-9 {
+		t.Fatalf("64 KiB window over 100 ms should give 5.24288 Mbps, got %v", small.GoodputMbps)
+	}
+}
+
+func TestPathLossRate(t *testing.T) {
+	if got := PathLossRate(0.1, 2); math.Abs(got-0.19) > 1e-12 {
+		t.Fatalf("PathLossRate(0.1, 2) = %v, want 0.19", got)
+	}
+	if got := PathLossRate(0, 5); got != 0 {
+		t.Fatalf("lossless hops should stay lossless, got %v", got)
+	}
+}
+
+func TestSimulatorTCPEstimatesUseRouteAndAllocation(t *testing.T) {
+	cfg := bottleneckConfig(AdmitDegrade)
+	cfg.LinkLossRate = 0.001
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatalf("failed to build simulator: %v", err)
+	}
+	snapshot := sim.Snapshot()
+	estimates := sim.TCPEstimates()
+
+	voice := estimates["voice"]
+	route := snapshot.Routes["voice"]
+	wantRTT := 2 * (route.LatencyMS + snapshot.Allocations["voice"].QueueingDelayMS)
+	if math.Abs(voice.RTTMS-wantRTT) > 1e-9 {
+		t.Fatalf("RTT %.4f ms, want %.4f", voice.RTTMS, wantRTT)
+	}
+	if math.Abs(voice.LossRate-PathLossRate(0.001, 2)) > 1e-12 {
+		t.Fatalf("unexpected path loss %v", voice.LossRate)
+	}
+	if voice.BottleneckMbps != 40 || voice.GoodputMbps > 40 {
+		t.Fatalf("degraded demand should be capped at its 40 Mbps allocation: %+v", voice)
+	}
+
+	cfg.LinkLossRate = 1
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatalf("expected a loss rate of 1 to be rejected")
+	}
+}
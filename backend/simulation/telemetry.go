@@ -0,0 +1,175 @@
+package simulation
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/routing"
+)
+
+// Terminal states reported in SatelliteTelemetry.
+const (
+	TerminalActive = "active" // the link carries routed traffic
+	TerminalIdle   = "idle"   // the link is up but carries nothing
+)
+
+// Synthetic power and thermal model of TelemetryEmulator. Rates are in percent of battery capacity
+// per hour of simulated time.
+const (
+	solarChargePctPerHour    = 40
+	busDrainPctPerHour       = 12
+	terminalDrainPctPerHour  = 2
+	trafficDrainPctPerGbitHr = 6
+	sunlitTemperatureC       = 24
+	eclipseTemperatureC      = -6
+	trafficHeatingCPerGbit   = 4
+	thermalTimeConstant      = 20 * time.Minute
+	temperatureNoiseC        = 0.4
+)
+
+// LinkTerminal is the state of one satellite terminal pointed at a peer. Kind is "isl" for a
+// terminal linked to another satellite and "ground" for one linked to a ground station.
+type LinkTerminal struct {
+	Peer     string  `json:"peer"`
+	Kind     string  `json:"kind"`
+	State    string  `json:"state"`
+	LoadMbps float64 `json:"loadMbps"`
+}
+
+// SatelliteTelemetry is one satellite's emulated housekeeping telemetry. The values come from a
+// simple synthetic model rather than a spacecraft design: they are meant to look and move like
+// real telemetry, not to predict it.
+type SatelliteTelemetry struct {
+	ID         string  `json:"id"`
+	BatteryPct float64 `json:"batteryPct"`
+	// TemperatureC is a bus temperature proxy that follows sunlight and traffic with a lag.
+	TemperatureC float64 `json:"temperatureC"`
+	// Shadow is where the satellite is in the Earth's shadow; Sunlit is true only outside it.
+	Shadow    orbits.ShadowState `json:"shadow"`
+	Sunlit    bool               `json:"sunlit"`
+	Terminals []LinkTerminal     `json:"terminals"`
+}
+
+// TelemetryFrame is the telemetry of every active satellite at one moment, ordered by ID.
+type TelemetryFrame struct {
+	Version    uint64               `json:"version"`
+	SimTime    time.Time            `json:"simTime"`
+	Satellites []SatelliteTelemetry `json:"satellites"`
+}
+
+// TelemetryEmulator turns a sequence of topologies into synthetic per-satellite telemetry.
+// Batteries charge with the share of the Sun's disk in view and drain with every linked terminal
+// and the traffic carried, and temperatures relax between an eclipse and a sunlit level, raised
+// by traffic. Eclipses use orbits.Illumination's conical umbra and penumbra, with the Sun over
+// the longitude where it is noon at the simulated UTC time, on the equator. Every satellite starts from its own charge and noise derived from its ID,
+// so runs are reproducible.
+//
+// The zero value emits a frame on every observation; it is not safe for concurrent use.
+type TelemetryEmulator struct {
+	// Cadence is the simulated time between emitted frames. Observations in between still advance
+	// the model, so a coarse cadence does not make it coarser.
+	Cadence time.Duration
+
+	last, emitted time.Time
+	satellites    map[string]*satelliteState
+}
+
+// satelliteState is the model state carried between observations.
+type satelliteState struct {
+	battery, temperature float64
+}
+
+// Observe advances the model to topo's simulated time and returns a frame when one is due: on the
+// first observation and once Cadence has passed since the last frame. A topology older than the
+// previous one restarts the model, as after a session reset.
+func (e *TelemetryEmulator) Observe(topo Topology) (TelemetryFrame, bool) {
+	now := topo.Snapshot.SimTime
+	if e.satellites == nil || now.Before(e.last) {
+		e.satellites = make(map[string]*satelliteState)
+		e.last, e.emitted = now, time.Time{}
+	}
+	hours := now.Sub(e.last).Hours()
+	e.last = now
+
+	frame := TelemetryFrame{Version: topo.Snapshot.Version, SimTime: now, Satellites: []SatelliteTelemetry{}}
+	if topo.Graph == nil {
+		return frame, e.due(now)
+	}
+	load := linkLoads(topo.Traffic, topo.Snapshot)
+	// Node positions are Earth-fixed, so the Sun is too.
+	sun := orbits.ECIToECEF(orbits.StateVector{Position: orbits.SunPosition(now)}, now).Position
+	seen := make(map[string]bool)
+	for id, node := range topo.Graph.Nodes {
+		if node.Type != routing.Satellite {
+			continue
+		}
+		seen[id] = true
+		light := orbits.Illumination(node.Position, sun)
+		telemetry := SatelliteTelemetry{ID: id, Shadow: orbits.Shadow(node.Position, sun), Terminals: []LinkTerminal{}}
+		telemetry.Sunlit = telemetry.Shadow == orbits.Sunlit
+		carried := 0.0
+		for _, edge := range topo.Graph.Adj[id] {
+			kind := "isl"
+			if topo.Graph.Nodes[edge.To].Type != routing.Satellite {
+				kind = "ground"
+			}
+			terminal := LinkTerminal{Peer: edge.To, Kind: kind, State: TerminalIdle}
+			// A terminal carries what it sends and what it receives.
+			terminal.LoadMbps = load[linkKey{id, edge.To}] + load[linkKey{edge.To, id}]
+			if terminal.LoadMbps > 0 {
+				terminal.State = TerminalActive
+			}
+			carried += terminal.LoadMbps
+			telemetry.Terminals = append(telemetry.Terminals, terminal)
+		}
+		sort.Slice(telemetry.Terminals, func(i, j int) bool { return telemetry.Terminals[i].Peer < telemetry.Terminals[j].Peer })
+
+		gbit := carried / 1000
+		target := eclipseTemperatureC + light*(sunlitTemperatureC-eclipseTemperatureC) + trafficHeatingCPerGbit*gbit
+		state, ok := e.satellites[id]
+		if !ok {
+			// New satellites start between 70 and 95% charged, already at their thermal balance.
+			state = &satelliteState{battery: 70 + 25*unitNoise(id, 0), temperature: target}
+			e.satellites[id] = state
+		} else {
+			rate := light*solarChargePctPerHour - busDrainPctPerHour - terminalDrainPctPerHour*float64(len(telemetry.Terminals)) - trafficDrainPctPerGbitHr*gbit
+			state.battery = math.Max(0, math.Min(100, state.battery+rate*hours))
+			state.temperature += (target - state.temperature) * (1 - math.Exp(-hours/thermalTimeConstant.Hours()))
+		}
+		noise := temperatureNoiseC * (2*unitNoise(id, now.Unix()) - 1)
+		telemetry.BatteryPct = math.Round(state.battery*10) / 10
+		telemetry.TemperatureC = math.Round((state.temperature+noise)*10) / 10
+		frame.Satellites = append(frame.Satellites, telemetry)
+	}
+	for id := range e.satellites {
+		if !seen[id] {
+			delete(e.satellites, id)
+		}
+	}
+	sort.Slice(frame.Satellites, func(i, j int) bool { return frame.Satellites[i].ID < frame.Satellites[j].ID })
+	return frame, e.due(now)
+}
+
+// due reports whether a frame is due at now and, if so, records it as emitted.
+func (e *TelemetryEmulator) due(now time.Time) bool {
+	if !e.emitted.IsZero() && now.Sub(e.emitted) < e.Cadence {
+		return false
+	}
+	e.emitted = now
+	return true
+}
+
+// unitNoise returns a value in [0, 1) fixed by id and salt.
+func unitNoise(id string, salt int64) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = byte(salt >> (8 * i))
+	}
+	h.Write(buf[:])
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
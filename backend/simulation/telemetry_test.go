@@ -0,0 +1,86 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/routing"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func telemetryTopology(t *testing.T, at time.Time) Topology {
+	t.Helper()
+	g, err := routing.BuildGraph([]routing.Node{
+		{ID: "gw", Type: routing.Ground, Position: visibility.FromGeodetic(0, 0, 0)},
+		{ID: "sat-1", Type: routing.Satellite, Position: visibility.FromGeodetic(0, 0, 550)},
+		{ID: "sat-2", Type: routing.Satellite, Position: visibility.FromGeodetic(1, 0, 550)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := g.PathAlong([]string{"gw", "sat-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Topology{
+		Graph:    g,
+		Snapshot: Snapshot{SimTime: at, Routes: map[string]routing.Path{"up": path}},
+		Traffic:  []TrafficDemand{{ID: "up", FromID: "gw", ToID: "sat-1", BandwidthMbps: 500}},
+	}
+}
+
+func TestTelemetryEmulatorReportsTerminalsAndSunlight(t *testing.T) {
+	noon := time.Date(2030, 3, 20, 12, 0, 0, 0, time.UTC)
+	var e TelemetryEmulator
+	frame, ok := e.Observe(telemetryTopology(t, noon))
+	if !ok || len(frame.Satellites) != 2 {
+		t.Fatalf("expected a first frame with both satellites, got %+v", frame)
+	}
+	sat := frame.Satellites[0]
+	if sat.ID != "sat-1" || !sat.Sunlit || sat.Shadow != orbits.Sunlit || sat.BatteryPct < 70 || sat.BatteryPct > 95 {
+		t.Fatalf("unexpected telemetry %+v", sat)
+	}
+	terminals := make(map[string]LinkTerminal, len(sat.Terminals))
+	for _, terminal := range sat.Terminals {
+		terminals[terminal.Peer] = terminal
+	}
+	if gw := terminals["gw"]; gw.Kind != "ground" || gw.State != TerminalActive || gw.LoadMbps != 500 {
+		t.Fatalf("expected the loaded ground terminal active, got %+v", gw)
+	}
+	if isl := terminals["sat-2"]; isl.Kind != "isl" || isl.State != TerminalIdle {
+		t.Fatalf("expected an idle inter-satellite terminal, got %+v", isl)
+	}
+
+	midnight, ok := e.Observe(telemetryTopology(t, noon.Add(12*time.Hour)))
+	if !ok || midnight.Satellites[0].Sunlit || midnight.Satellites[0].Shadow != orbits.Umbra {
+		t.Fatalf("expected the satellite in the Earth's shadow at midnight, got %+v", midnight)
+	}
+	if midnight.Satellites[0].BatteryPct >= sat.BatteryPct || midnight.Satellites[0].TemperatureC >= sat.TemperatureC {
+		t.Fatalf("expected the battery and temperature to fall, from %+v to %+v", sat, midnight.Satellites[0])
+	}
+}
+
+func TestTelemetryEmulatorHonorsCadence(t *testing.T) {
+	start := time.Date(2030, 3, 20, 0, 0, 0, 0, time.UTC)
+	e := TelemetryEmulator{Cadence: time.Minute}
+	var emitted []time.Time
+	for _, offset := range []time.Duration{0, 20 * time.Second, 40 * time.Second, 60 * time.Second, 90 * time.Second, 2 * time.Minute} {
+		if frame, ok := e.Observe(telemetryTopology(t, start.Add(offset))); ok {
+			emitted = append(emitted, frame.SimTime)
+		}
+	}
+	if len(emitted) != 3 || !emitted[1].Equal(start.Add(time.Minute)) || !emitted[2].Equal(start.Add(2*time.Minute)) {
+		t.Fatalf("expected frames every simulated minute, got %v", emitted)
+	}
+
+	// A reset rewinds simulated time and restarts the model with a fresh frame.
+	again, ok := e.Observe(telemetryTopology(t, start))
+	if !ok {
+		t.Fatal("expected a frame after the simulation was rewound")
+	}
+	first, _ := (&TelemetryEmulator{}).Observe(telemetryTopology(t, start))
+	if again.Satellites[0].BatteryPct != first.Satellites[0].BatteryPct {
+		t.Fatalf("expected the restarted model to match a fresh one, got %+v and %+v", again.Satellites[0], first.Satellites[0])
+	}
+}
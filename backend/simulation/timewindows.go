@@ -0,0 +1,164 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/visibility"
+)
+
+// LocalSolarTime is the TrafficDemand.TimeZone that reads active windows in mean solar time at the
+// demand's source, so "08:00" is 8 a.m. wherever the source is.
+const LocalSolarTime = "local"
+
+// DailyWindow is a time of day a demand is active, from Start up to End, both "HH:MM". A window
+// whose End is not after its Start runs past midnight.
+type DailyWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// demandWindows is a demand's active windows, parsed into minutes of the day in its time zone.
+type demandWindows struct {
+	windows [][2]int
+	// location is nil for LocalSolarTime, which instead shifts UTC by solarOffset.
+	location    *time.Location
+	solarOffset time.Duration
+}
+
+// active reports whether a demand with these windows is active at t.
+func (w demandWindows) active(t time.Time) bool {
+	if w.location != nil {
+		t = t.In(w.location)
+	} else {
+		t = t.UTC().Add(w.solarOffset)
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, window := range w.windows {
+		start, end := window[0], window[1]
+		if start < end && minute >= start && minute < end || start >= end && (minute >= start || minute < end) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClock(raw string) (int, error) {
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		return 0, fmt.Errorf("time of day %q must be HH:MM", raw)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseDemandWindows validates a demand's active windows. source is the demand's source position,
+// used for LocalSolarTime. A demand without windows is always active and returns nil.
+func parseDemandWindows(demand TrafficDemand, source visibility.Vector3) (*demandWindows, error) {
+	if len(demand.ActiveWindows) == 0 {
+		if demand.TimeZone != "" {
+			return nil, fmt.Errorf("demand %q sets timeZone without activeWindows", demand.ID)
+		}
+		return nil, nil
+	}
+	parsed := &demandWindows{}
+	switch demand.TimeZone {
+	case "":
+		parsed.location = time.UTC
+	case LocalSolarTime:
+		lon := visibility.ToGeodetic(source).LonDeg
+		parsed.solarOffset = time.Duration(math.Round(lon/15*3600)) * time.Second
+	default:
+		location, err := time.LoadLocation(demand.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("demand %q time zone: %w", demand.ID, err)
+		}
+		parsed.location = location
+	}
+	for _, window := range demand.ActiveWindows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return nil, fmt.Errorf("demand %q window: %w", demand.ID, err)
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return nil, fmt.Errorf("demand %q window: %w", demand.ID, err)
+		}
+		parsed.windows = append(parsed.windows, [2]int{start, end})
+	}
+	return parsed, nil
+}
+
+// activeLocked reports whether demand is active at the simulation clock: inside its windows and
+// not deferred.
+func (s *Simulator) activeLocked(demand TrafficDemand) bool {
+	if s.deferred[demand.ID] {
+		return false
+	}
+	windows, ok := s.demandWindows[demand.ID]
+	return !ok || windows.active(s.clock)
+}
+
+// inactiveLocked lists the demands outside their active windows at the simulation clock or
+// deferred, sorted.
+func (s *Simulator) inactiveLocked() []string {
+	var inactive []string
+	for _, demand := range s.traffic {
+		if !s.activeLocked(demand) {
+			inactive = append(inactive, demand.ID)
+		}
+	}
+	sort.Strings(inactive)
+	return inactive
+}
+
+// HourlyLoad aggregates the demand carried during one UTC hour of the day over the steps of
+// time-stepped runs, showing how scheduled traffic varies through the day.
+type HourlyLoad struct {
+	Hour  int `json:"hour"`
+	Steps int `json:"steps"`
+	// MeanActiveDemands and MeanRoutedDemands average the demands inside their windows, and those of
+	// them routed, per step.
+	MeanActiveDemands float64 `json:"meanActiveDemands"`
+	MeanRoutedDemands float64 `json:"meanRoutedDemands"`
+	// MeanCarriedMbps averages the bandwidth of routed demands per step, as allocated when link
+	// capacity is modeled.
+	MeanCarriedMbps float64 `json:"meanCarriedMbps"`
+}
+
+// loadRecorder accumulates per-hour load totals for time-stepped runs.
+type loadRecorder struct {
+	hours [24]struct {
+		steps          int
+		active, routed int
+		carriedMbps    float64
+	}
+}
+
+func (r *loadRecorder) record(at time.Time, active, routed int, carriedMbps float64) {
+	hour := &r.hours[at.UTC().Hour()]
+	hour.steps++
+	hour.active += active
+	hour.routed += routed
+	hour.carriedMbps += carriedMbps
+}
+
+// summary returns the hours with at least one step, in order.
+func (r *loadRecorder) summary() []HourlyLoad {
+	var out []HourlyLoad
+	for h, hour := range r.hours {
+		if hour.steps == 0 {
+			continue
+		}
+		n := float64(hour.steps)
+		out = append(out, HourlyLoad{
+			Hour:              h,
+			Steps:             hour.steps,
+			MeanActiveDemands: float64(hour.active) / n,
+			MeanRoutedDemands: float64(hour.routed) / n,
+			MeanCarriedMbps:   hour.carriedMbps / n,
+		})
+	}
+	return out
+}
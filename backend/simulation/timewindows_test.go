@@ -0,0 +1,104 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/visibility"
+)
+
+func TestDemandWindowsActive(t *testing.T) {
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		demand TrafficDemand
+		source visibility.Geodetic
+		active []int // UTC hours the demand is active
+	}{
+		{"daytime", TrafficDemand{ActiveWindows: []DailyWindow{{Start: "08:00", End: "20:00"}}}, visibility.Geodetic{},
+			[]int{8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}},
+		{"overnight", TrafficDemand{ActiveWindows: []DailyWindow{{Start: "22:00", End: "02:00"}}}, visibility.Geodetic{},
+			[]int{0, 1, 22, 23}},
+		// Local solar time at 90°E runs six hours ahead of UTC.
+		{"local", TrafficDemand{ActiveWindows: []DailyWindow{{Start: "08:00", End: "10:00"}}, TimeZone: LocalSolarTime},
+			visibility.Geodetic{LonDeg: 90}, []int{2, 3}},
+	}
+	for _, tc := range cases {
+		windows, err := parseDemandWindows(tc.demand, tc.source.Vector())
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var active []int
+		for hour := 0; hour < 24; hour++ {
+			if windows.active(day.Add(time.Duration(hour)*time.Hour + 30*time.Minute)) {
+				active = append(active, hour)
+			}
+		}
+		if len(active) != len(tc.active) {
+			t.Fatalf("%s: expected active hours %v, got %v", tc.name, tc.active, active)
+		}
+		for i := range active {
+			if active[i] != tc.active[i] {
+				t.Fatalf("%s: expected active hours %v, got %v", tc.name, tc.active, active)
+			}
+		}
+	}
+
+	for name, demand := range map[string]TrafficDemand{
+		"bad time":            {ActiveWindows: []DailyWindow{{Start: "8am", End: "20:00"}}},
+		"unknown zone":        {ActiveWindows: []DailyWindow{{Start: "08:00", End: "20:00"}}, TimeZone: "Mars/Olympus_Mons"},
+		"zone without window": {TimeZone: "UTC"},
+	} {
+		if _, err := parseDemandWindows(demand, visibility.Vector3{}); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestScheduledDemandsRouteOnlyWhenActive(t *testing.T) {
+	cfg := Config{
+		GridConfig: coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites: []Satellite{
+			{ID: "sat", Location: &visibility.Geodetic{AltKm: 1200}, Footprint: coverage.Footprint{Auto: true, LinkStrength: 1}},
+		},
+		GroundStations: []GroundStation{
+			{ID: "west", Location: &visibility.Geodetic{LonDeg: -3}},
+			{ID: "east", Location: &visibility.Geodetic{LonDeg: 3}},
+		},
+		Traffic: []TrafficDemand{
+			{ID: "always", FromID: "west", ToID: "east", BandwidthMbps: 1},
+			{ID: "backhaul", FromID: "east", ToID: "west", BandwidthMbps: 4, ActiveWindows: []DailyWindow{{Start: "08:00", End: "20:00"}}},
+		},
+		Epoch: time.Date(2024, time.January, 1, 7, 0, 0, 0, time.UTC),
+	}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := sim.Snapshot()
+	if _, routed := snap.Routes["backhaul"]; routed || len(snap.InactiveDemands) != 1 || snap.InactiveDemands[0] != "backhaul" {
+		t.Fatalf("expected backhaul to be inactive before 08:00, got routes %v, inactive %v", snap.Routes, snap.InactiveDemands)
+	}
+
+	summary, err := sim.Run(24, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Hourly) != 24 {
+		t.Fatalf("expected a load for every hour, got %+v", summary.Hourly)
+	}
+	for _, hour := range summary.Hourly {
+		want, carried := 1.0, 1.0
+		if hour.Hour >= 8 && hour.Hour < 20 {
+			want, carried = 2, 5
+		}
+		if hour.Steps != 1 || hour.MeanActiveDemands != want || hour.MeanRoutedDemands != want || hour.MeanCarriedMbps != carried {
+			t.Fatalf("unexpected load %+v", hour)
+		}
+	}
+	// Steps outside the window are not counted against the demand.
+	if stats := summary.Latency["backhaul"]; stats.Samples != 12 || stats.UnroutedSteps != 0 {
+		t.Fatalf("expected 12 routed samples for backhaul, got %+v", stats)
+	}
+}
@@ -0,0 +1,182 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// Traceroute lists the hops of the current route between two nodes with the latency accumulated
+// up to each, the way traceroute reports the routers a probe crosses.
+type Traceroute struct {
+	Version uint64 `json:"version"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	// DemandID names the routed demand whose path was traced; it is empty when no demand runs
+	// between the nodes and the scenario's default routing was used instead.
+	DemandID string `json:"demandId,omitempty"`
+	Reached  bool   `json:"reached"`
+	// RTTMS is the round trip to the destination, assuming replies retrace the route.
+	RTTMS float64         `json:"rttMs,omitempty"`
+	Hops  []TracerouteHop `json:"hops"`
+}
+
+// TracerouteHop is one node past the source. LinkType and LinkLatencyMS describe the link into it
+// and CumulativeMS is the one-way latency from the source.
+type TracerouteHop struct {
+	Hop           int              `json:"hop"`
+	Node          string           `json:"node"`
+	Type          routing.NodeType `json:"type"`
+	LinkType      routing.LinkType `json:"linkType"`
+	LinkLatencyMS float64          `json:"linkLatencyMs"`
+	CumulativeMS  float64          `json:"cumulativeMs"`
+	RTTMS         float64          `json:"rttMs"`
+}
+
+// PingSample is one probe of a ping series. Lost probes found no route and carry no RTT.
+type PingSample struct {
+	Seq     int       `json:"seq"`
+	SimTime time.Time `json:"simTime"`
+	Lost    bool      `json:"lost"`
+	RTTMS   float64   `json:"rttMs,omitempty"`
+	Hops    int       `json:"hops,omitempty"`
+}
+
+// PingSeries is the round-trip time between two nodes sampled as the constellation moves, with
+// the summary line ping prints when it stops.
+type PingSeries struct {
+	Version     uint64       `json:"version"`
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	IntervalS   float64      `json:"intervalS"`
+	Transmitted int          `json:"transmitted"`
+	Received    int          `json:"received"`
+	LossPct     float64      `json:"lossPct"`
+	MinRTTMS    float64      `json:"minRttMs"`
+	AvgRTTMS    float64      `json:"avgRttMs"`
+	MaxRTTMS    float64      `json:"maxRttMs"`
+	MdevRTTMS   float64      `json:"mdevRttMs"`
+	Samples     []PingSample `json:"samples"`
+}
+
+// Traceroute traces the current route from one node to another. A routed demand between the two
+// nodes is followed as routed; otherwise the path is the one the scenario's default routing would
+// take. An unreachable destination yields Reached false and no hops.
+func (s *Simulator) Traceroute(from, to string) (Traceroute, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trace := Traceroute{Version: s.version, From: from, To: to, Hops: []TracerouteHop{}}
+	path, demandID, err := s.probePathLocked(from, to)
+	if errors.Is(err, routing.ErrNoRoute) {
+		return trace, nil
+	}
+	if err != nil {
+		return Traceroute{}, err
+	}
+	trace.DemandID, trace.Reached, trace.RTTMS = demandID, true, 2*path.LatencyMS
+	cumulative := 0.0
+	for i := 1; i < len(path.Nodes); i++ {
+		hop, err := s.graph.PathAlong(path.Nodes[i-1 : i+1])
+		if err != nil {
+			return Traceroute{}, err
+		}
+		cumulative += hop.LatencyMS
+		prev, node := s.graph.Nodes[path.Nodes[i-1]], s.graph.Nodes[path.Nodes[i]]
+		trace.Hops = append(trace.Hops, TracerouteHop{
+			Hop: i, Node: node.ID, Type: node.Type, LinkType: routing.LinkTypeOf(prev, node),
+			LinkLatencyMS: hop.LatencyMS, CumulativeMS: cumulative, RTTMS: 2 * cumulative,
+		})
+	}
+	return trace, nil
+}
+
+// Ping sends count probes from one node to another, one every interval starting from the current
+// state, and reports the round trip of each over the route traced at that moment. It runs on a copy
+// rebuilt from the scenario and journal, so the simulator itself is not advanced.
+func (s *Simulator) Ping(from, to string, count int, interval time.Duration) (PingSeries, error) {
+	if count <= 0 || interval <= 0 {
+		return PingSeries{}, errors.New("count and interval must be positive")
+	}
+	s.mu.Lock()
+	cfg, ops, version := s.scenario, append([]Operation(nil), s.journal...), s.version
+	s.mu.Unlock()
+
+	copied, err := NewSimulator(cfg)
+	if err != nil {
+		return PingSeries{}, err
+	}
+	if err := copied.Replay(ops); err != nil {
+		return PingSeries{}, err
+	}
+
+	series := PingSeries{Version: version, From: from, To: to, IntervalS: interval.Seconds(), Samples: make([]PingSample, 0, count)}
+	var rtts []float64
+	for seq := 0; seq < count; seq++ {
+		if seq > 0 {
+			if _, err := copied.Step(interval); err != nil {
+				return PingSeries{}, err
+			}
+		}
+		copied.mu.Lock()
+		sample := PingSample{Seq: seq, SimTime: copied.clock}
+		path, _, err := copied.probePathLocked(from, to)
+		copied.mu.Unlock()
+		switch {
+		case errors.Is(err, routing.ErrNoRoute):
+			sample.Lost = true
+		case err != nil:
+			return PingSeries{}, err
+		default:
+			sample.RTTMS, sample.Hops = 2*path.LatencyMS, len(path.Nodes)-1
+			rtts = append(rtts, sample.RTTMS)
+		}
+		series.Samples = append(series.Samples, sample)
+	}
+
+	series.Transmitted, series.Received = count, len(rtts)
+	series.LossPct = 100 * float64(count-len(rtts)) / float64(count)
+	if len(rtts) == 0 {
+		return series, nil
+	}
+	series.MinRTTMS, series.MaxRTTMS = math.Inf(1), math.Inf(-1)
+	var sum, sumSquares float64
+	for _, rtt := range rtts {
+		series.MinRTTMS = math.Min(series.MinRTTMS, rtt)
+		series.MaxRTTMS = math.Max(series.MaxRTTMS, rtt)
+		sum += rtt
+		sumSquares += rtt * rtt
+	}
+	series.AvgRTTMS = sum / float64(len(rtts))
+	// Like ping, mdev is the population standard deviation of the round trips.
+	series.MdevRTTMS = math.Sqrt(math.Max(sumSquares/float64(len(rtts))-series.AvgRTTMS*series.AvgRTTMS, 0))
+	return series, nil
+}
+
+// probePathLocked returns the route a probe from one node to another takes in the latest
+// recompute, and the demand it follows if any. Unknown nodes are reported as routing.ErrUnknownNode;
+// a node whose satellite is disabled is unknown until it is enabled again.
+func (s *Simulator) probePathLocked(from, to string) (routing.Path, string, error) {
+	for _, id := range []string{from, to} {
+		if _, ok := s.graph.Nodes[id]; !ok {
+			return routing.Path{}, "", fmt.Errorf("%w %q", routing.ErrUnknownNode, id)
+		}
+	}
+	if from == to {
+		return routing.Path{}, "", errors.New("probe source and destination must differ")
+	}
+	for _, demand := range s.traffic {
+		path, ok := s.snapshot.Routes[demand.ID]
+		if ok && demand.FromID == from && path.Nodes[len(path.Nodes)-1] == to {
+			return path, demand.ID, nil
+		}
+	}
+	heuristic := func(id string) float64 {
+		return s.graph.Heuristic(id, to)
+	}
+	path, err := routing.StableShortestPath(s.graph, from, to, s.stabilityWeight, heuristic)
+	return path, "", err
+}
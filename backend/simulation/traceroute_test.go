@@ -0,0 +1,81 @@
+package simulation
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+func TestTracerouteAccumulatesHopLatency(t *testing.T) {
+	sim, err := NewSimulator(policyConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace, err := sim.Traceroute("west", "east")
+	if err != nil {
+		t.Fatal(err)
+	}
+	route := sim.Snapshot().Routes["default"]
+	if !trace.Reached || trace.DemandID != "default" || len(trace.Hops) != 2 {
+		t.Fatalf("expected the default demand's two hops, got %+v", trace)
+	}
+	first, last := trace.Hops[0], trace.Hops[1]
+	if first.Node != "low" || first.Type != routing.Satellite || last.Node != "east" || last.Type != routing.Ground {
+		t.Fatalf("unexpected hops %+v", trace.Hops)
+	}
+	if math.Abs(last.CumulativeMS-route.LatencyMS) > 1e-9 || last.CumulativeMS != first.LinkLatencyMS+last.LinkLatencyMS {
+		t.Fatalf("expected latency to accumulate to the route's %.3f ms, got %+v", route.LatencyMS, trace.Hops)
+	}
+	if trace.RTTMS != 2*route.LatencyMS || last.RTTMS != trace.RTTMS {
+		t.Fatalf("expected a round trip of twice the one-way latency, got %+v", trace)
+	}
+
+	// Between nodes no demand connects, the trace takes the default routing.
+	if trace, err = sim.Traceroute("high", "east"); err != nil || trace.DemandID != "" || len(trace.Hops) != 1 {
+		t.Fatalf("expected a direct hop from the high satellite, got %+v, %v", trace, err)
+	}
+	if _, err := sim.Traceroute("west", "missing"); !errors.Is(err, routing.ErrUnknownNode) {
+		t.Fatalf("expected an unknown node error, got %v", err)
+	}
+}
+
+func TestPingSamplesRoundTrips(t *testing.T) {
+	sim, err := NewSimulator(policyConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := sim.Ping("west", "east", 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.Transmitted != 3 || series.Received != 3 || series.LossPct != 0 || len(series.Samples) != 3 {
+		t.Fatalf("unexpected ping summary %+v", series)
+	}
+	rtt := 2 * sim.Snapshot().Routes["default"].LatencyMS
+	if series.MinRTTMS != rtt || series.MaxRTTMS != rtt || series.MdevRTTMS != 0 {
+		t.Fatalf("expected a steady %.3f ms round trip between fixed nodes, got %+v", rtt, series)
+	}
+	if got := series.Samples[2].SimTime.Sub(series.Samples[0].SimTime); got != 2*time.Minute {
+		t.Fatalf("expected probes a minute apart, got %v", got)
+	}
+	if !sim.Snapshot().SimTime.Equal(series.Samples[0].SimTime) {
+		t.Fatal("expected ping to leave the simulator's clock alone")
+	}
+
+	if _, err := sim.DisableSatellite("low"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.DisableSatellite("high"); err != nil {
+		t.Fatal(err)
+	}
+	if series, err = sim.Ping("west", "east", 2, time.Second); err != nil || series.Received != 0 || series.LossPct != 100 || !series.Samples[1].Lost {
+		t.Fatalf("expected every probe lost without satellites, got %+v, %v", series, err)
+	}
+	if !reflect.DeepEqual([]int{series.Samples[0].Seq, series.Samples[1].Seq}, []int{0, 1}) {
+		t.Fatalf("unexpected sequence numbers %+v", series.Samples)
+	}
+}
@@ -0,0 +1,193 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// TTCConfig models the telemetry, tracking and command path to the satellites. With it, commands
+// do not take effect when they are issued: each waits for its satellite to come into contact with
+// a command station, crosses the link, and then waits for the satellite to process it. Each
+// command station tracks at most one satellite per antenna; see Contacts.
+type TTCConfig struct {
+	// Stations lists the ground stations that can uplink commands; empty allows every station.
+	Stations []string `json:"stations,omitempty"`
+	// ProcessingS is the onboard delay between receiving a command and executing it.
+	ProcessingS float64 `json:"processingS,omitempty"`
+	// Antennas limits the antennas of command stations, by station ID. Stations left out have an
+	// antenna for every satellite in view.
+	Antennas map[string]int `json:"antennas,omitempty"`
+}
+
+func (c TTCConfig) validate(ground []GroundStation) error {
+	if c.ProcessingS < 0 || math.IsNaN(c.ProcessingS) {
+		return errors.New("ttc processing delay cannot be negative")
+	}
+	known := make(map[string]bool, len(ground))
+	for _, gs := range ground {
+		known[gs.ID] = true
+	}
+	commanding := make(map[string]bool, len(c.Stations))
+	for _, id := range c.Stations {
+		if !known[id] {
+			return fmt.Errorf("ttc station %q is not a ground station", id)
+		}
+		commanding[id] = true
+	}
+	for id, count := range c.Antennas {
+		if !known[id] || len(c.Stations) > 0 && !commanding[id] {
+			return fmt.Errorf("ttc antennas name %q, which is not a command station", id)
+		}
+		if count < 1 {
+			return fmt.Errorf("ttc station %q needs at least one antenna", id)
+		}
+	}
+	return nil
+}
+
+// ErrTTCNotModeled is returned when a command is issued to a simulator whose scenario has no ttc
+// section; such simulators apply changes immediately.
+var ErrTTCNotModeled = errors.New("scenario does not model TT&C")
+
+// CommandStatus is where a command is on its way to the satellite.
+type CommandStatus string
+
+const (
+	// CommandAwaitingContact commands wait for a command-station antenna to track the satellite.
+	CommandAwaitingContact CommandStatus = "awaiting-contact"
+	// CommandUplinked commands have been sent and execute at DueAt.
+	CommandUplinked CommandStatus = "uplinked"
+	CommandApplied  CommandStatus = "applied"
+	// CommandDropped commands were lost because their satellite was removed before executing them.
+	CommandDropped CommandStatus = "dropped"
+)
+
+// CommandDisable is the action of a command that takes its satellite out of service.
+const CommandDisable = "disable"
+
+// Command is one command issued to a satellite and its progress through the TT&C path. Times are
+// simulated; a command executes on the first step that reaches its DueAt, so the recorded
+// AppliedAt is as coarse as the steps.
+type Command struct {
+	ID        int           `json:"id"`
+	Action    string        `json:"action"`
+	Satellite string        `json:"satellite"`
+	Status    CommandStatus `json:"status"`
+	IssuedAt  time.Time     `json:"issuedAt"`
+	// Station is the command station that uplinked the command, and PropagationMS the one-way
+	// latency of its link to the satellite at the time.
+	Station       string     `json:"station,omitempty"`
+	UplinkedAt    *time.Time `json:"uplinkedAt,omitempty"`
+	PropagationMS float64    `json:"propagationMs,omitempty"`
+	DueAt         *time.Time `json:"dueAt,omitempty"`
+	AppliedAt     *time.Time `json:"appliedAt,omitempty"`
+	// LagS is the simulated time from issue to execution, once applied.
+	LagS float64 `json:"lagS,omitempty"`
+}
+
+// TTCModeled reports whether the scenario routes commands through the TT&C path.
+func (s *Simulator) TTCModeled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ttc != nil
+}
+
+// CommandDisableSatellite issues a command taking a satellite out of service. The satellite keeps
+// operating until the command has been uplinked at a contact and executed; steps advance it. The
+// command is uplinked at once when an antenna is tracking the satellite now.
+func (s *Simulator) CommandDisableSatellite(id string) (Command, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commandLocked(id, CommandDisable)
+}
+
+// Commands returns every command issued so far, oldest first.
+func (s *Simulator) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Command, len(s.commands))
+	copy(out, s.commands)
+	return out
+}
+
+func (s *Simulator) commandLocked(id, action string) (Command, error) {
+	if s.ttc == nil {
+		return Command{}, ErrTTCNotModeled
+	}
+	if _, ok := s.satellites[id]; !ok {
+		return Command{}, fmt.Errorf("%w %q", ErrUnknownSatellite, id)
+	}
+	s.commands = append(s.commands, Command{
+		ID:        len(s.commands) + 1,
+		Action:    action,
+		Satellite: id,
+		Status:    CommandAwaitingContact,
+		IssuedAt:  s.clock,
+	})
+	s.record(Operation{Op: OpCommandDisableSatellite, Target: id})
+	s.uplinkCommandsLocked()
+	return s.commands[len(s.commands)-1], nil
+}
+
+// uplinkCommandsLocked sends every command awaiting contact whose satellite an antenna is
+// tracking, over that antenna's link.
+func (s *Simulator) uplinkCommandsLocked() {
+	for i := range s.commands {
+		cmd := &s.commands[i]
+		if cmd.Status != CommandAwaitingContact {
+			continue
+		}
+		if _, ok := s.satellites[cmd.Satellite]; !ok {
+			cmd.Status = CommandDropped
+			continue
+		}
+		track, ok := s.contacts.tracks[cmd.Satellite]
+		if !ok {
+			continue
+		}
+		now := s.clock
+		due := now.Add(time.Duration((track.LatencyMS/1000 + s.ttc.ProcessingS) * float64(time.Second)))
+		cmd.Status, cmd.Station, cmd.UplinkedAt, cmd.PropagationMS, cmd.DueAt = CommandUplinked, track.Station, &now, track.LatencyMS, &due
+	}
+}
+
+// commandStationLocked reports whether a node can uplink commands.
+func (s *Simulator) commandStationLocked(id string) bool {
+	if _, ok := s.ground[id]; !ok {
+		return false
+	}
+	if len(s.ttc.Stations) == 0 {
+		return true
+	}
+	for _, station := range s.ttc.Stations {
+		if station == id {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDueCommandsLocked executes the uplinked commands due by the current clock. The caller
+// recomputes afterwards.
+func (s *Simulator) applyDueCommandsLocked() {
+	for i := range s.commands {
+		cmd := &s.commands[i]
+		if cmd.Status != CommandUplinked || cmd.DueAt.After(s.clock) {
+			continue
+		}
+		sat, ok := s.satellites[cmd.Satellite]
+		if !ok {
+			cmd.Status = CommandDropped
+			continue
+		}
+		switch cmd.Action {
+		case CommandDisable:
+			sat.Active = false
+		}
+		s.topologyVersion++
+		now := s.clock
+		cmd.Status, cmd.AppliedAt, cmd.LagS = CommandApplied, &now, now.Sub(cmd.IssuedAt).Seconds()
+	}
+}
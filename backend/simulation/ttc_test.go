@@ -0,0 +1,115 @@
+package simulation
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/example/satnet/backend/coverage"
+	"github.com/example/satnet/backend/orbits"
+	"github.com/example/satnet/backend/visibility"
+)
+
+// ttcConfig has one satellite starting on the far side of the Earth from the only command station,
+// above a gateway that cannot uplink, so commands wait about half an orbit for a contact.
+func ttcConfig() Config {
+	return Config{
+		GridConfig: coverage.GridConfig{LatStep: 90, LonStep: 90},
+		Satellites: []Satellite{{
+			ID:        "sat",
+			Orbit:     &orbits.KeplerianElements{SemiMajorAxis: visibility.EarthRadius + 550, MeanAnomaly: math.Pi},
+			Footprint: coverage.Footprint{Auto: true, LinkStrength: 1},
+		}},
+		GroundStations: []GroundStation{
+			{ID: "tt&c", Location: &visibility.Geodetic{}},
+			{ID: "gateway", Location: &visibility.Geodetic{LonDeg: 180}},
+		},
+		Epoch: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		TTC:   &TTCConfig{Stations: []string{"tt&c"}, ProcessingS: 30},
+	}
+}
+
+func TestCommandsWaitForContactAndPropagation(t *testing.T) {
+	sim, err := NewSimulator(ttcConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, err := sim.CommandDisableSatellite("sat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Status != CommandAwaitingContact {
+		t.Fatalf("expected the command to wait for the station to come into view, got %+v", cmd)
+	}
+
+	for steps := 0; ; steps++ {
+		if steps > 120 {
+			t.Fatalf("command never uplinked: %+v", sim.Commands())
+		}
+		if _, err := sim.Step(time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if cmd = sim.Commands()[0]; cmd.Status != CommandAwaitingContact {
+			break
+		}
+		if len(sim.Snapshot().ActiveSatellites) != 1 {
+			t.Fatal("expected the satellite to keep operating until the command executes")
+		}
+	}
+	if cmd.Status != CommandUplinked || cmd.Station != "tt&c" || cmd.PropagationMS <= 0 {
+		t.Fatalf("expected an uplink from the command station, got %+v", cmd)
+	}
+	if want := cmd.UplinkedAt.Add(30*time.Second + time.Duration(cmd.PropagationMS*float64(time.Millisecond))); math.Abs(float64(cmd.DueAt.Sub(want))) > float64(time.Microsecond) {
+		t.Fatalf("expected the command due after propagation and processing, got %v want %v", cmd.DueAt, want)
+	}
+	if cmd.UplinkedAt.Sub(cmd.IssuedAt) < 30*time.Minute {
+		t.Fatalf("expected the command to wait for a contact, uplinked after %v", cmd.UplinkedAt.Sub(cmd.IssuedAt))
+	}
+
+	snap, err := sim.Step(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd = sim.Commands()[0]
+	if cmd.Status != CommandApplied || len(snap.ActiveSatellites) != 0 || cmd.LagS != cmd.AppliedAt.Sub(cmd.IssuedAt).Seconds() {
+		t.Fatalf("expected the command applied on the next step, got %+v with active %v", cmd, snap.ActiveSatellites)
+	}
+
+	// The journal replays the command through the same contact.
+	replayed, err := NewSimulator(ttcConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayed.Replay(sim.Journal()); err != nil {
+		t.Fatal(err)
+	}
+	if got := replayed.Commands()[0]; got.Status != CommandApplied || !got.AppliedAt.Equal(*cmd.AppliedAt) {
+		t.Fatalf("expected the replayed command to apply identically, got %+v", got)
+	}
+}
+
+func TestCommandsRequireTTC(t *testing.T) {
+	cfg := ttcConfig()
+	cfg.TTC = nil
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.CommandDisableSatellite("sat"); !errors.Is(err, ErrTTCNotModeled) {
+		t.Fatalf("expected ErrTTCNotModeled, got %v", err)
+	}
+
+	cfg = ttcConfig()
+	cfg.TTC.Stations = []string{"nowhere"}
+	if _, err := NewSimulator(cfg); err == nil {
+		t.Fatal("expected an unknown command station to be rejected")
+	}
+	sim, err = NewSimulator(ttcConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.CommandDisableSatellite("missing"); !errors.Is(err, ErrUnknownSatellite) {
+		t.Fatalf("expected ErrUnknownSatellite, got %v", err)
+	}
+}
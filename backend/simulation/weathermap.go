@@ -0,0 +1,160 @@
+package simulation
+
+import (
+	"sort"
+	"time"
+
+	"github.com/example/satnet/backend/routing"
+)
+
+// UtilizationBands are the lower bounds, in percent, of the utilization bands links are colored by,
+// following the scale network weathermaps conventionally use.
+var UtilizationBands = []int{0, 1, 10, 25, 40, 55, 70, 85}
+
+// LinkUtilization is the load on one direction of an active link. CapacityMbps and UtilizationPct
+// are zero when the scenario does not model link capacity.
+type LinkUtilization struct {
+	From           string  `json:"from"`
+	To             string  `json:"to"`
+	LoadMbps       float64 `json:"loadMbps"`
+	CapacityMbps   float64 `json:"capacityMbps,omitempty"`
+	UtilizationPct float64 `json:"utilizationPct,omitempty"`
+	// Band is the lower bound of the entry of UtilizationBands the utilization falls in.
+	Band     int               `json:"band"`
+	Type     routing.LinkType  `json:"type"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Weathermap lists every active link direction with its load, ordered by endpoints.
+type Weathermap struct {
+	Version uint64            `json:"version"`
+	SimTime time.Time         `json:"simTime"`
+	Links   []LinkUtilization `json:"links"`
+}
+
+// LinkID names one direction of a link.
+type LinkID struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// WeathermapDelta is what changed between two weathermaps: links that appeared or moved to another
+// band, and links that went away.
+type WeathermapDelta struct {
+	Version uint64            `json:"version"`
+	SimTime time.Time         `json:"simTime"`
+	Changed []LinkUtilization `json:"changed"`
+	Removed []LinkID          `json:"removed"`
+}
+
+// Weathermap returns the utilization of every link in the latest recompute. Load counts the
+// bandwidth allocated to each routed demand, or the requested bandwidth when capacity is not
+// modeled.
+func (s *Simulator) Weathermap() Weathermap {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	load := linkLoads(s.traffic, s.snapshot)
+	result := Weathermap{Version: s.snapshot.Version, SimTime: s.snapshot.SimTime, Links: []LinkUtilization{}}
+	if s.graph == nil {
+		return result
+	}
+	for from := range s.graph.Adj {
+		s.graph.EdgesFrom(from, func(e routing.Edge) bool {
+			link := LinkUtilization{From: e.From, To: e.To, LoadMbps: load[linkKey{e.From, e.To}], Type: e.Type, Metadata: e.Metadata}
+			if s.linkCapacity > 0 {
+				link.CapacityMbps = s.capacityOfLocked(linkKey{e.From, e.To})
+				link.UtilizationPct = 100 * link.LoadMbps / link.CapacityMbps
+				link.Band = utilizationBand(link.UtilizationPct)
+			}
+			result.Links = append(result.Links, link)
+			return true
+		})
+	}
+	sort.Slice(result.Links, func(i, j int) bool {
+		if result.Links[i].From != result.Links[j].From {
+			return result.Links[i].From < result.Links[j].From
+		}
+		return result.Links[i].To < result.Links[j].To
+	})
+	return result
+}
+
+// OfTypes returns the weathermap with only the links of the given types.
+func (w Weathermap) OfTypes(types ...routing.LinkType) Weathermap {
+	links := make([]LinkUtilization, 0, len(w.Links))
+	for _, link := range w.Links {
+		for _, t := range types {
+			if link.Type == t {
+				links = append(links, link)
+				break
+			}
+		}
+	}
+	w.Links = links
+	return w
+}
+
+// linkLoads sums the bandwidth of the demands routed over each link direction in snapshot, counting
+// allocations where admission control ran.
+func linkLoads(traffic []TrafficDemand, snapshot Snapshot) map[linkKey]float64 {
+	load := make(map[linkKey]float64)
+	for _, demand := range traffic {
+		path, ok := snapshot.Routes[demand.ID]
+		if !ok {
+			continue
+		}
+		mbps := demand.BandwidthMbps
+		if alloc, ok := snapshot.Allocations[demand.ID]; ok {
+			mbps = alloc.AllocatedMbps
+		}
+		for _, key := range pathLinks(path) {
+			load[key] += mbps
+		}
+	}
+	return load
+}
+
+// utilizationBand returns the lower bound of the band pct falls in.
+func utilizationBand(pct float64) int {
+	band := UtilizationBands[0]
+	for _, lower := range UtilizationBands {
+		if pct >= float64(lower) {
+			band = lower
+		}
+	}
+	return band
+}
+
+// BandTracker turns a sequence of weathermaps into deltas, so frontends can repaint only the links
+// whose band changed. The zero value is ready to use; it is not safe for concurrent use.
+type BandTracker struct {
+	bands map[LinkID]int
+}
+
+// Observe compares w with the previously observed weathermap and reports whether any link
+// appeared, went away or changed band. The first observation reports every link.
+func (t *BandTracker) Observe(w Weathermap) (WeathermapDelta, bool) {
+	delta := WeathermapDelta{Version: w.Version, SimTime: w.SimTime, Changed: []LinkUtilization{}, Removed: []LinkID{}}
+	bands := make(map[LinkID]int, len(w.Links))
+	for _, link := range w.Links {
+		id := LinkID{link.From, link.To}
+		bands[id] = link.Band
+		if previous, ok := t.bands[id]; !ok || previous != link.Band {
+			delta.Changed = append(delta.Changed, link)
+		}
+	}
+	for id := range t.bands {
+		if _, ok := bands[id]; !ok {
+			delta.Removed = append(delta.Removed, id)
+		}
+	}
+	sort.Slice(delta.Removed, func(i, j int) bool {
+		if delta.Removed[i].From != delta.Removed[j].From {
+			return delta.Removed[i].From < delta.Removed[j].From
+		}
+		return delta.Removed[i].To < delta.Removed[j].To
+	})
+	t.bands = bands
+	return delta, len(delta.Changed) > 0 || len(delta.Removed) > 0
+}
@@ -0,0 +1,60 @@
+package simulation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWeathermapReportsLinkUtilization(t *testing.T) {
+	cfg := policyConfig()
+	cfg.LinkCapacityMbps = 100
+	cfg.Traffic = []TrafficDemand{{ID: "video", FromID: "west", ToID: "east", BandwidthMbps: 30}}
+	sim, err := NewSimulator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := sim.Weathermap()
+	byID := make(map[LinkID]LinkUtilization, len(w.Links))
+	for _, link := range w.Links {
+		byID[LinkID{link.From, link.To}] = link
+	}
+	if len(byID) != 10 {
+		t.Fatalf("expected both directions of the five links, got %+v", w.Links)
+	}
+	loaded := byID[LinkID{"west", "low"}]
+	if loaded.LoadMbps != 30 || loaded.CapacityMbps != 100 || loaded.UtilizationPct != 30 || loaded.Band != 25 {
+		t.Fatalf("unexpected loaded link %+v", loaded)
+	}
+	if idle := byID[LinkID{"low", "west"}]; idle.LoadMbps != 0 || idle.Band != 0 {
+		t.Fatalf("expected the return direction idle, got %+v", idle)
+	}
+
+	var tracker BandTracker
+	if delta, changed := tracker.Observe(w); !changed || len(delta.Changed) != 10 {
+		t.Fatalf("expected the first observation to report every link, got %+v", delta)
+	}
+	if _, changed := tracker.Observe(sim.Weathermap()); changed {
+		t.Fatal("expected no delta while utilization is unchanged")
+	}
+
+	updated, err := sim.DisableSatellite("high")
+	if err != nil {
+		t.Fatal(err)
+	}
+	delta, changed := tracker.Observe(sim.Weathermap())
+	if !changed || len(delta.Changed) != 0 || delta.Version != updated.Version {
+		t.Fatalf("expected only removals, got %+v", delta)
+	}
+	want := []LinkID{{"east", "high"}, {"high", "east"}, {"high", "low"}, {"high", "west"}, {"low", "high"}, {"west", "high"}}
+	if !reflect.DeepEqual(delta.Removed, want) {
+		t.Fatalf("expected the high satellite's links removed, got %+v", delta.Removed)
+	}
+}
+
+func TestUtilizationBand(t *testing.T) {
+	for pct, want := range map[float64]int{0: 0, 0.5: 0, 1: 1, 24.9: 10, 85: 85, 120: 85} {
+		if got := utilizationBand(pct); got != want {
+			t.Fatalf("band of %v%%: expected %d, got %d", pct, want, got)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package timescale converts between the UTC, TAI, and TT time scales used by orbit propagation
+// and sidereal time. Go's time.Time counts seconds as if every UTC day had 86400 of them, so an
+// instant in TAI or TT is represented here as a time.Time whose reading is the clock time in that
+// scale, and durations across leap seconds should be taken with Elapsed rather than Time.Sub.
+package timescale
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// TTMinusTAI is the fixed offset of Terrestrial Time from TAI.
+	TTMinusTAI = 32184 * time.Millisecond
+	// J2000 is the Julian date of the J2000.0 epoch, 2000-01-01 12:00 TT.
+	J2000 = 2451545.0
+	// unixEpochJD is the Julian date of 1970-01-01 00:00 in the scale of the time being converted.
+	unixEpochJD = 2440587.5
+)
+
+// leapSecond records TAI − UTC in whole seconds from the UTC instant it took effect.
+type leapSecond struct {
+	from   time.Time
+	offset int
+}
+
+func utcDate(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// leapSeconds is IERS Bulletin C's table since whole-second offsets began in 1972. Earlier instants
+// use the 1972 offset; the fractional "rubber second" era before it is not modeled.
+var leapSeconds = []leapSecond{
+	{utcDate(1972, time.January), 10},
+	{utcDate(1972, time.July), 11},
+	{utcDate(1973, time.January), 12},
+	{utcDate(1974, time.January), 13},
+	{utcDate(1975, time.January), 14},
+	{utcDate(1976, time.January), 15},
+	{utcDate(1977, time.January), 16},
+	{utcDate(1978, time.January), 17},
+	{utcDate(1979, time.January), 18},
+	{utcDate(1980, time.January), 19},
+	{utcDate(1981, time.July), 20},
+	{utcDate(1982, time.July), 21},
+	{utcDate(1983, time.July), 22},
+	{utcDate(1985, time.July), 23},
+	{utcDate(1988, time.January), 24},
+	{utcDate(1990, time.January), 25},
+	{utcDate(1991, time.January), 26},
+	{utcDate(1992, time.July), 27},
+	{utcDate(1993, time.July), 28},
+	{utcDate(1994, time.July), 29},
+	{utcDate(1996, time.January), 30},
+	{utcDate(1997, time.July), 31},
+	{utcDate(1999, time.January), 32},
+	{utcDate(2006, time.January), 33},
+	{utcDate(2009, time.January), 34},
+	{utcDate(2012, time.July), 35},
+	{utcDate(2015, time.July), 36},
+	{utcDate(2017, time.January), 37},
+}
+
+// TAIMinusUTC returns TAI − UTC at the UTC instant t.
+func TAIMinusUTC(utc time.Time) time.Duration {
+	i := sort.Search(len(leapSeconds), func(i int) bool { return leapSeconds[i].from.After(utc) })
+	if i == 0 {
+		i = 1
+	}
+	return time.Duration(leapSeconds[i-1].offset) * time.Second
+}
+
+// UTCToTAI returns the TAI reading at the UTC instant utc.
+func UTCToTAI(utc time.Time) time.Time {
+	utc = utc.UTC()
+	return utc.Add(TAIMinusUTC(utc))
+}
+
+// TAIToUTC returns the UTC reading at the TAI instant tai. time.Time cannot show 23:59:60, so
+// instants inside an inserted leap second map to the start of the following UTC day.
+func TAIToUTC(tai time.Time) time.Time {
+	tai = tai.UTC()
+	for i := len(leapSeconds) - 1; i >= 0; i-- {
+		offset := time.Duration(leapSeconds[i].offset) * time.Second
+		if i == 0 || !tai.Before(leapSeconds[i].from.Add(offset)) {
+			utc := tai.Add(-offset)
+			if i+1 < len(leapSeconds) && !utc.Before(leapSeconds[i+1].from) {
+				return leapSeconds[i+1].from
+			}
+			return utc
+		}
+	}
+	return tai
+}
+
+// TAIToTT returns the TT reading at the TAI instant tai.
+func TAIToTT(tai time.Time) time.Time {
+	return tai.Add(TTMinusTAI)
+}
+
+// TTToTAI returns the TAI reading at the TT instant tt.
+func TTToTAI(tt time.Time) time.Time {
+	return tt.Add(-TTMinusTAI)
+}
+
+// UTCToTT returns the TT reading at the UTC instant utc.
+func UTCToTT(utc time.Time) time.Time {
+	return TAIToTT(UTCToTAI(utc))
+}
+
+// TTToUTC returns the UTC reading at the TT instant tt.
+func TTToUTC(tt time.Time) time.Time {
+	return TAIToUTC(TTToTAI(tt))
+}
+
+// Elapsed returns the SI time between two UTC instants, counting any leap seconds between them.
+func Elapsed(from, to time.Time) time.Duration {
+	return UTCToTAI(to).Sub(UTCToTAI(from))
+}
+
+// JulianDate returns the Julian date of t's reading in whatever scale t represents. A float64 Julian
+// date resolves about 40 µs in the current era.
+func JulianDate(t time.Time) float64 {
+	t = t.UTC()
+	return unixEpochJD + (float64(t.Unix())+float64(t.Nanosecond())/1e9)/86400
+}
+
+// CenturiesSinceJ2000 returns Julian centuries of TT since J2000.0 at the UTC instant utc, the time
+// argument of precession, nutation, and sidereal time series.
+func CenturiesSinceJ2000(utc time.Time) float64 {
+	return (JulianDate(UTCToTT(utc)) - J2000) / 36525
+}
@@ -0,0 +1,82 @@
+package timescale
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTAIMinusUTC(t *testing.T) {
+	cases := []struct {
+		utc  time.Time
+		want time.Duration
+	}{
+		{time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), 10 * time.Second},
+		{time.Date(1972, 1, 1, 0, 0, 0, 0, time.UTC), 10 * time.Second},
+		{time.Date(1999, 6, 1, 0, 0, 0, 0, time.UTC), 32 * time.Second},
+		{time.Date(2016, 12, 31, 23, 59, 59, 999999999, time.UTC), 36 * time.Second},
+		{time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), 37 * time.Second},
+		{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 37 * time.Second},
+	}
+	for _, c := range cases {
+		if got := TAIMinusUTC(c.utc); got != c.want {
+			t.Fatalf("TAI-UTC at %v = %v, want %v", c.utc, got, c.want)
+		}
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	for _, utc := range []time.Time{
+		time.Date(1980, 3, 4, 5, 6, 7, 8, time.UTC),
+		time.Date(2016, 12, 31, 23, 59, 59, 500000000, time.UTC),
+		time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+	} {
+		if back := TAIToUTC(UTCToTAI(utc)); !back.Equal(utc) {
+			t.Fatalf("UTC %v round-tripped through TAI to %v", utc, back)
+		}
+		if back := TTToUTC(UTCToTT(utc)); !back.Equal(utc) {
+			t.Fatalf("UTC %v round-tripped through TT to %v", utc, back)
+		}
+	}
+
+	// J2000.0 is 2000-01-01 12:00 TT, which is 11:58:55.816 UTC.
+	j2000UTC := time.Date(2000, 1, 1, 11, 58, 55, 816000000, time.UTC)
+	if tt := UTCToTT(j2000UTC); !tt.Equal(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("J2000 in TT = %v", tt)
+	}
+}
+
+func TestLeapSecondInsertion(t *testing.T) {
+	before := time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC)
+	after := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := Elapsed(before, after); got != 2*time.Second {
+		t.Fatalf("elapsed across the 2016 leap second = %v, want 2s", got)
+	}
+	if got := after.Sub(before); got != time.Second {
+		t.Fatalf("Time.Sub is expected to ignore leap seconds, got %v", got)
+	}
+
+	// 23:59:60 UTC is TAI 00:00:36; it cannot be shown in time.Time and maps to the next day.
+	leap := time.Date(2017, 1, 1, 0, 0, 36, 500000000, time.UTC)
+	if got := TAIToUTC(leap); !got.Equal(after) {
+		t.Fatalf("TAI inside the leap second mapped to %v, want %v", got, after)
+	}
+	if got := TAIToUTC(leap.Add(-time.Second)); !got.Equal(before.Add(500 * time.Millisecond)) {
+		t.Fatalf("TAI before the leap second mapped to %v", got)
+	}
+}
+
+func TestJulianDates(t *testing.T) {
+	if got := JulianDate(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)); got != J2000 {
+		t.Fatalf("JD of 2000-01-01 12:00 = %v, want %v", got, J2000)
+	}
+	if got := JulianDate(time.Unix(0, 0)); got != unixEpochJD {
+		t.Fatalf("JD of the Unix epoch = %v", got)
+	}
+	// One Julian century of TT after J2000.0.
+	utc := TTToUTC(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC).Add(36525 * 24 * time.Hour))
+	if got := CenturiesSinceJ2000(utc); math.Abs(got-1) > 1e-12 {
+		t.Fatalf("centuries since J2000 = %v, want 1", got)
+	}
+}
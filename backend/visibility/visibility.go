@@ -4,14 +4,55 @@ import "math"
 
 // Vector3 represents a 3D position in an Earth-centered frame (kilometers).
 type Vector3 struct {
-	X float64
-	Y float64
-	Z float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
 }
 
 // EarthRadius is the mean Earth radius in kilometers.
 const EarthRadius = 6371.0
 
+// Geodetic describes a location by latitude and longitude (degrees) and altitude (km) above
+// the mean Earth radius.
+type Geodetic struct {
+	LatDeg float64 `json:"latDeg"`
+	LonDeg float64 `json:"lonDeg"`
+	AltKm  float64 `json:"altKm"`
+}
+
+// FromGeodetic converts latitude/longitude (degrees) and altitude (km) on a spherical Earth
+// into an Earth-centered position vector.
+func FromGeodetic(latDeg, lonDeg, altKm float64) Vector3 {
+	const degToRad = math.Pi / 180
+	lat, lon := latDeg*degToRad, lonDeg*degToRad
+	r := EarthRadius + altKm
+	return Vector3{
+		X: r * math.Cos(lat) * math.Cos(lon),
+		Y: r * math.Cos(lat) * math.Sin(lon),
+		Z: r * math.Sin(lat),
+	}
+}
+
+// Vector returns the Earth-centered position of the geodetic location.
+func (g Geodetic) Vector() Vector3 {
+	return FromGeodetic(g.LatDeg, g.LonDeg, g.AltKm)
+}
+
+// ToGeodetic converts an Earth-centered position into spherical latitude/longitude (degrees)
+// and altitude above the mean Earth radius (km).
+func ToGeodetic(v Vector3) Geodetic {
+	r := norm(v)
+	if r == 0 {
+		return Geodetic{AltKm: -EarthRadius}
+	}
+	const radToDeg = 180 / math.Pi
+	return Geodetic{
+		LatDeg: math.Asin(v.Z/r) * radToDeg,
+		LonDeg: math.Atan2(v.Y, v.X) * radToDeg,
+		AltKm:  r - EarthRadius,
+	}
+}
+
 // SlantRange returns the straight-line distance between two positions.
 func SlantRange(a, b Vector3) float64 {
 	return norm(sub(b, a))
@@ -46,6 +87,52 @@ func SatelliteToSatelliteVisible(a, b Vector3) bool {
 	return !segmentIntersectsEarth(a, b, EarthRadius)
 }
 
+// LimbClearance returns the lowest altitude (km) of the straight segment between a and b above the
+// Earth's surface; a negative value is how deep the segment cuts into the Earth.
+func LimbClearance(a, b Vector3) float64 {
+	direction := sub(b, a)
+	t := 0.0
+	if length := dot(direction, direction); length > 0 {
+		t = math.Max(0, math.Min(1, -dot(a, direction)/length))
+	}
+	return norm(add(a, scale(direction, t))) - EarthRadius
+}
+
+// OffNadirAngle returns the angle (radians) at a satellite between its nadir and a target.
+func OffNadirAngle(satellite, target Vector3) float64 {
+	toTarget := sub(target, satellite)
+	cos := -dot(satellite, toTarget) / (norm(satellite) * norm(toTarget))
+	return math.Acos(math.Max(-1, math.Min(1, cos)))
+}
+
+// PropagateCircular predicts where a body moving with the given velocity (km/s) will be after
+// the provided number of seconds, assuming a circular orbit about Earth's center. It returns the
+// new position and velocity; stationary bodies (zero velocity) are returned unchanged.
+func PropagateCircular(position, velocity Vector3, seconds float64) (Vector3, Vector3) {
+	r := norm(position)
+	speed := norm(velocity)
+	if r == 0 || speed == 0 {
+		return position, velocity
+	}
+
+	axis := cross(position, velocity)
+	axisNorm := norm(axis)
+	if axisNorm == 0 {
+		// Radial motion has no orbital plane; fall back to straight-line motion.
+		return add(position, scale(velocity, seconds)), velocity
+	}
+	k := scale(axis, 1.0/axisNorm)
+
+	theta := (speed / r) * seconds
+	return rotate(position, k, theta), rotate(velocity, k, theta)
+}
+
+// rotate applies Rodrigues' rotation of v about the unit axis k by theta radians.
+func rotate(v, k Vector3, theta float64) Vector3 {
+	cosT, sinT := math.Cos(theta), math.Sin(theta)
+	return add(add(scale(v, cosT), scale(cross(k, v), sinT)), scale(k, dot(k, v)*(1-cosT)))
+}
+
 func segmentIntersectsEarth(p0, p1 Vector3, radius float64) bool {
 	direction := sub(p1, p0)
 	a := dot(direction, direction)
@@ -74,6 +161,14 @@ func norm(v Vector3) float64 {
 	return math.Sqrt(dot(v, v))
 }
 
+func cross(a, b Vector3) Vector3 {
+	return Vector3{X: a.Y*b.Z - a.Z*b.Y, Y: a.Z*b.X - a.X*b.Z, Z: a.X*b.Y - a.Y*b.X}
+}
+
+func add(a, b Vector3) Vector3 {
+	return Vector3{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
 func sub(a, b Vector3) Vector3 {
 	return Vector3{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
 }
@@ -66,6 +66,26 @@ func TestSatelliteToSatelliteLineOfSight(t *testing.T) {
 	}
 }
 
+func TestLimbClearanceAndOffNadir(t *testing.T) {
+	highAltitude := EarthRadius + 3000
+	satA := Vector3{X: highAltitude, Y: 0, Z: 0}
+	satB := Vector3{X: 0, Y: highAltitude, Z: 0}
+	if got, want := LimbClearance(satA, satB), highAltitude/math.Sqrt2-EarthRadius; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("cross link clearance %f, want %f", got, want)
+	}
+	if got := LimbClearance(satA, Vector3{X: -highAltitude}); math.Abs(got+EarthRadius) > 1e-9 {
+		t.Fatalf("a link through the center should cut %f km deep, got %f", EarthRadius, got)
+	}
+
+	ground := Vector3{X: EarthRadius, Y: 0, Z: 0}
+	if got := OffNadirAngle(Vector3{X: EarthRadius + 500}, ground); math.Abs(got) > 1e-9 {
+		t.Fatalf("the sub-satellite point should be at nadir, got %f", got)
+	}
+	if got := OffNadirAngle(satA, satB); math.Abs(got-math.Pi/4) > 1e-9 {
+		t.Fatalf("expected 45° off nadir, got %f", got*180/math.Pi)
+	}
+}
+
 func TestPolarVisibility(t *testing.T) {
 	polarGround := Vector3{X: 0, Y: 0, Z: EarthRadius}
 	polarSat := Vector3{X: 0, Y: 0, Z: EarthRadius + 800}
@@ -87,3 +107,36 @@ func TestHorizonIsNotBlocked(t *testing.T) {
 		t.Fatalf("tangent path should not be considered intersecting Earth")
 	}
 }
+
+func TestPropagateCircularQuarterOrbit(t *testing.T) {
+	r := EarthRadius + 500
+	speed := 7.6
+	position := Vector3{X: r, Y: 0, Z: 0}
+	velocity := Vector3{X: 0, Y: speed, Z: 0}
+
+	quarter := (math.Pi / 2) * r / speed
+	got, gotVelocity := PropagateCircular(position, velocity, quarter)
+	if math.Abs(got.X) > 1e-6 || math.Abs(got.Y-r) > 1e-6 || math.Abs(got.Z) > 1e-6 {
+		t.Fatalf("expected quarter orbit to reach +Y axis, got %+v", got)
+	}
+	if math.Abs(gotVelocity.X+speed) > 1e-9 || math.Abs(gotVelocity.Y) > 1e-9 {
+		t.Fatalf("expected velocity to rotate with the orbit, got %+v", gotVelocity)
+	}
+
+	if stationary, _ := PropagateCircular(position, Vector3{}, quarter); stationary != position {
+		t.Fatalf("stationary body should not move, got %+v", stationary)
+	}
+}
+
+func TestGeodeticRoundTrip(t *testing.T) {
+	equator := FromGeodetic(0, 0, 0)
+	if math.Abs(equator.X-EarthRadius) > 1e-9 || math.Abs(equator.Y) > 1e-9 || math.Abs(equator.Z) > 1e-9 {
+		t.Fatalf("expected equator/prime meridian on +X axis, got %+v", equator)
+	}
+
+	loc := Geodetic{LatDeg: -33.9, LonDeg: 151.2, AltKm: 550}
+	back := ToGeodetic(loc.Vector())
+	if math.Abs(back.LatDeg-loc.LatDeg) > 1e-9 || math.Abs(back.LonDeg-loc.LonDeg) > 1e-9 || math.Abs(back.AltKm-loc.AltKm) > 1e-9 {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", back, loc)
+	}
+}
@@ -0,0 +1,61 @@
+package visibility
+
+import "math"
+
+// WGS84 ellipsoid defining constants.
+const (
+	// WGS84SemiMajorAxis is the equatorial radius in kilometers.
+	WGS84SemiMajorAxis = 6378.137
+	// WGS84Flattening is (a − b) / a.
+	WGS84Flattening = 1 / 298.257223563
+)
+
+// wgs84EccentricitySquared is the first eccentricity squared, f(2 − f).
+const wgs84EccentricitySquared = WGS84Flattening * (2 - WGS84Flattening)
+
+// FromWGS84 converts geodetic latitude/longitude (degrees) and height above the WGS84 ellipsoid
+// (km) into an Earth-centered, Earth-fixed position. Unlike FromGeodetic, latitude is measured
+// from the ellipsoid normal, as GPS receivers and maps report it.
+func FromWGS84(latDeg, lonDeg, altKm float64) Vector3 {
+	const degToRad = math.Pi / 180
+	sinLat, cosLat := math.Sincos(latDeg * degToRad)
+	sinLon, cosLon := math.Sincos(lonDeg * degToRad)
+	// n is the prime vertical radius of curvature.
+	n := WGS84SemiMajorAxis / math.Sqrt(1-wgs84EccentricitySquared*sinLat*sinLat)
+	return Vector3{
+		X: (n + altKm) * cosLat * cosLon,
+		Y: (n + altKm) * cosLat * sinLon,
+		Z: (n*(1-wgs84EccentricitySquared) + altKm) * sinLat,
+	}
+}
+
+// WGS84Vector returns the Earth-centered position of the location read as WGS84 coordinates.
+func (g Geodetic) WGS84Vector() Vector3 {
+	return FromWGS84(g.LatDeg, g.LonDeg, g.AltKm)
+}
+
+// ToWGS84 converts an Earth-centered, Earth-fixed position into geodetic latitude/longitude
+// (degrees) and height above the WGS84 ellipsoid (km). It is the inverse of FromWGS84 to well
+// under a millimeter from the Earth's center out past geostationary altitude.
+func ToWGS84(v Vector3) Geodetic {
+	const radToDeg = 180 / math.Pi
+	p := math.Hypot(v.X, v.Y)
+	// Fixed-point iteration on the latitude, starting from the value for zero height; each pass
+	// gains several digits for positions near the Earth.
+	lat := math.Atan2(v.Z, p*(1-wgs84EccentricitySquared))
+	var alt float64
+	for i := 0; i < 6; i++ {
+		sinLat, cosLat := math.Sincos(lat)
+		n := WGS84SemiMajorAxis / math.Sqrt(1-wgs84EccentricitySquared*sinLat*sinLat)
+		// This form of the height stays well conditioned at the poles, where p/cos(lat) does not.
+		alt = p*cosLat + v.Z*sinLat - WGS84SemiMajorAxis*math.Sqrt(1-wgs84EccentricitySquared*sinLat*sinLat)
+		lat = math.Atan2(v.Z, p*(1-wgs84EccentricitySquared*n/(n+alt)))
+	}
+	sinLat, cosLat := math.Sincos(lat)
+	alt = p*cosLat + v.Z*sinLat - WGS84SemiMajorAxis*math.Sqrt(1-wgs84EccentricitySquared*sinLat*sinLat)
+	return Geodetic{
+		LatDeg: lat * radToDeg,
+		LonDeg: math.Atan2(v.Y, v.X) * radToDeg,
+		AltKm:  alt,
+	}
+}
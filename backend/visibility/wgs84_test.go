@@ -0,0 +1,49 @@
+package visibility
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWGS84ReferencePoints(t *testing.T) {
+	const polarRadius = 6356.752314245 // km
+	for _, tc := range []struct {
+		lat, lon, alt float64
+		want          Vector3
+	}{
+		{0, 0, 0, Vector3{X: WGS84SemiMajorAxis}},
+		{0, 90, 1, Vector3{Y: WGS84SemiMajorAxis + 1}},
+		{90, 0, 0, Vector3{Z: polarRadius}},
+		{-90, 0, 10, Vector3{Z: -polarRadius - 10}},
+	} {
+		got := FromWGS84(tc.lat, tc.lon, tc.alt)
+		if SlantRange(got, tc.want) > 1e-6 {
+			t.Fatalf("FromWGS84(%v, %v, %v) = %+v, want %+v", tc.lat, tc.lon, tc.alt, got, tc.want)
+		}
+	}
+
+	// Geodetic latitude exceeds geocentric latitude away from the equator and poles.
+	mid := FromWGS84(45, 0, 0)
+	if geocentric := ToGeodetic(mid).LatDeg; math.Abs(45-geocentric-0.1924) > 1e-3 {
+		t.Fatalf("expected about 0.19° between geodetic and geocentric latitude at 45°, got %v", 45-geocentric)
+	}
+}
+
+func TestWGS84RoundTrip(t *testing.T) {
+	for _, lat := range []float64{-90, -60.5, -1e-9, 0, 33.3, 45, 89.999, 90} {
+		for _, lon := range []float64{-179, 0, 120} {
+			for _, alt := range []float64{-0.4, 0, 8.8, 550, 35786} {
+				got := ToWGS84(FromWGS84(lat, lon, alt))
+				if math.Abs(got.LatDeg-lat) > 1e-9 || math.Abs(got.AltKm-alt) > 1e-7 {
+					t.Fatalf("round trip of (%v, %v, %v) gave %+v", lat, lon, alt, got)
+				}
+				if math.Abs(lat) < 90 && math.Abs(got.LonDeg-lon) > 1e-9 {
+					t.Fatalf("round trip of (%v, %v, %v) gave %+v", lat, lon, alt, got)
+				}
+			}
+		}
+	}
+	if center := ToWGS84(Vector3{}); center.AltKm > -6356 {
+		t.Fatalf("expected the Earth's center far below the ellipsoid, got %+v", center)
+	}
+}